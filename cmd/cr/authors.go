@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/cli"
+	"github.com/icatw/ai-cr-tool/pkg/git"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// revisionRange 返回评审对应的提交范围，评审工作区或暂存区时返回空字符串
+func revisionRange(opts *cli.Options) string {
+	switch {
+	case opts.Files != "", opts.Staged:
+		return ""
+	case opts.CommitHash != "":
+		return opts.CommitHash + "^!"
+	case strings.Contains(opts.CommitRange, ".."):
+		return opts.CommitRange
+	default:
+		return ""
+	}
+}
+
+// attributeAuthors 将问题归属到提交范围内的作者
+// 有行号的问题按 git blame 归属到最后修改该行的作者；该行不是在范围内修改的，或问题没有行号时，归属到修改该文件次数最多的作者
+func attributeAuthors(gitClient *git.GitClient, opts *cli.Options, changes []types.FileChange, issues []types.Issue) []review.AuthorIssues {
+	revRange := revisionRange(opts)
+	if revRange == "" {
+		log.Printf("--by-author 只能用于评审提交或提交范围\n")
+		return nil
+	}
+	authorsByFile, err := gitClient.AuthorsByFile(revRange)
+	if err != nil {
+		log.Printf("%v\n", err)
+		return nil
+	}
+	commits, err := gitClient.CommitsInRange(revRange)
+	if err != nil {
+		log.Printf("%v\n", err)
+		return nil
+	}
+
+	filesOf := make(map[string][]string)
+	for _, change := range changes {
+		for _, author := range authorsByFile[change.FilePath] {
+			filesOf[author] = append(filesOf[author], change.FilePath)
+		}
+	}
+
+	rev := targetRevision(opts)
+	if rev == "" {
+		rev = "HEAD"
+	}
+	authorOf := func(issue types.Issue) string {
+		if issue.Line > 0 {
+			if commit, author, err := gitClient.BlameLine(rev, issue.FilePath, issue.Line); err == nil && commits[commit] {
+				return author
+			}
+		}
+		if authors := authorsByFile[issue.FilePath]; len(authors) > 0 {
+			return authors[0]
+		}
+		return ""
+	}
+	return review.GroupByAuthor(issues, filesOf, authorOf)
+}
+
+// authorReportPath 返回作者单独报告的路径，如 report.md 对应 report.alice.md
+func authorReportPath(path, author string) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s.%s%s", strings.TrimSuffix(path, ext), review.AuthorSlug(author), ext)
+}
+
+// writeAuthorReports 为每位作者单独生成只包含其问题的报告，返回生成的文件路径
+func writeAuthorReports(session *reviewSession, opts *cli.Options, format review.ReportFormat) ([]string, error) {
+	var paths []string
+	for _, group := range session.Authors {
+		reporter, err := newSessionReporter("ai-cr-tool", session, opts)
+		if err != nil {
+			return nil, err
+		}
+		reporter.ProjectName = fmt.Sprintf("ai-cr-tool - %s", reporter.AuthorName(group.Author))
+		reporter.Authors = nil
+		// 执行摘要和依赖变更针对全部改动，不放入单个作者的报告
+		reporter.Summary = nil
+		reporter.Dependencies = nil
+		path := authorReportPath(opts.OutputFile, group.Author)
+		if err := writeReport(reporter, group.Issues, format, path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
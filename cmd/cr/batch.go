@@ -0,0 +1,162 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/batch"
+	"github.com/icatw/ai-cr-tool/pkg/cli"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+)
+
+func init() {
+	registerCommand("batch", "cmd.summary.batch", runBatch)
+}
+
+// batchResult 单个批量任务的执行结果
+type batchResult struct {
+	job     batch.Job
+	files   int
+	issues  int
+	passed  bool
+	reasons []string
+	elapsed time.Duration
+	err     error
+}
+
+// runBatch 执行 batch 子命令
+func runBatch(args []string) error {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	manifestPath := fs.String("manifest", "", i18n.M("batch.flag.manifest"))
+	concurrency := fs.Int("concurrency", 0, i18n.M("batch.flag.concurrency"))
+	readOnly := fs.Bool("read-only", cli.ReadOnlyFromEnv(), i18n.M("batch.flag.read-only"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *readOnly {
+		cli.EnterReadOnly()
+	}
+	if *manifestPath == "" && fs.NArg() > 0 {
+		*manifestPath = fs.Arg(0)
+	}
+	if *manifestPath == "" {
+		return i18n.Errorf("batch.usage")
+	}
+
+	manifest, err := batch.Load(*manifestPath)
+	if err != nil {
+		return err
+	}
+	if *concurrency > 0 {
+		manifest.Concurrency = *concurrency
+	}
+	if *readOnly {
+		for _, job := range manifest.Jobs {
+			if len(job.Outputs) > 0 {
+				return i18n.Errorf("batch.err.read_only_outputs", job.Name)
+			}
+		}
+	}
+
+	results := make([]batchResult, len(manifest.Jobs))
+	sem := make(chan struct{}, manifest.Concurrency)
+	var wg sync.WaitGroup
+	var printMu sync.Mutex
+	for i, job := range manifest.Jobs {
+		wg.Add(1)
+		go func(i int, job batch.Job) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			fmt.Fprintln(os.Stderr, i18n.M("batch.started", job.Name))
+			results[i] = runBatchJob(job)
+
+			printMu.Lock()
+			printBatchResult(results[i])
+			printMu.Unlock()
+		}(i, job)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if result.err != nil || !result.passed {
+			failed++
+		}
+	}
+	fmt.Println("\n" + i18n.M("batch.done", len(results), len(results)-failed, failed))
+	if failed > 0 {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// runBatchJob 执行单个批量任务
+// 多个任务可能并发执行，因此以静默模式评审，避免进度输出互相干扰
+func runBatchJob(job batch.Job) batchResult {
+	start := time.Now()
+	result := batchResult{job: job}
+
+	opts, err := cli.ParseArgsIn(job.Repo, append(job.CLIArgs(), "--quiet"))
+	if err != nil {
+		result.err = i18n.Errorf("batch.err.job_args", err)
+		return result
+	}
+
+	session, err := runReview(opts, job.Repo)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	defer session.Span.End(nil)
+	result.files = len(session.Changes)
+	result.issues = len(session.Issues)
+
+	reporter, err := newSessionReporter(job.Name, session, opts)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	defaultTemplate := reporter.Template
+	for _, out := range job.Outputs {
+		format, _ := review.ParseReportFormat(out.Format)
+		reporter.Template = defaultTemplate
+		if out.Template != "" {
+			if reporter.Template, err = review.LoadTemplate(out.Template, session.Lang); err != nil {
+				result.err = err
+				return result
+			}
+		}
+		if err := writeReport(reporter, session.Issues, format, out.Path); err != nil {
+			result.err = err
+			return result
+		}
+	}
+
+	gate := session.Policy.Evaluate(session.Issues)
+	result.passed, result.reasons = gate.Passed, gate.Reasons
+	recordHistory(job.Repo, job.Name, "batch", reporter, session.Issues, opts, gate.Passed)
+	result.elapsed = time.Since(start)
+	return result
+}
+
+// printBatchResult 输出单个任务的结果
+func printBatchResult(result batchResult) {
+	name := result.job.Name
+	switch {
+	case result.err != nil:
+		fmt.Printf("✗ %s: %v\n", name, result.err)
+	case !result.passed:
+		fmt.Printf("✗ %s: %s\n", name, i18n.M("batch.job_gate_failed", result.files, result.issues, result.elapsed.Round(time.Second)))
+		for _, reason := range result.reasons {
+			fmt.Printf("    %s\n", reason)
+		}
+	default:
+		fmt.Printf("✓ %s: %s\n", name, i18n.M("batch.job_passed", result.files, result.issues, result.elapsed.Round(time.Second)))
+	}
+}
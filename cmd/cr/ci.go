@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/icatw/ai-cr-tool/pkg/cli"
+	"github.com/icatw/ai-cr-tool/pkg/github"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/policy"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// annotationLevels 问题严重程度对应的工作流命令
+var annotationLevels = map[types.SeverityLevel]string{
+	types.SeverityError:   "error",
+	types.SeverityWarning: "warning",
+	types.SeverityInfo:    "notice",
+}
+
+// reportGitHubActions 输出每个问题的工作流命令，使其在 PR 的文件改动中显示为行内注释，
+// 并把 markdown-github 报告写入作业摘要；报告以机器可读格式输出到标准输出时，工作流命令改为输出到标准错误
+func reportGitHubActions(reporter *review.DefaultReporter, issues []types.Issue, gate policy.Result, format review.ReportFormat, opts *cli.Options) {
+	var w io.Writer = os.Stdout
+	if opts.OutputFile == "" && format.IsMachineReadable() {
+		w = os.Stderr
+	}
+	for _, issue := range issues {
+		level, ok := annotationLevels[issue.Severity]
+		if !ok {
+			level = "notice"
+		}
+		message := issue.Description
+		if issue.Suggestion != "" {
+			message += "\n\n" + issue.Suggestion
+		}
+		fmt.Fprintln(w, github.Annotation(level, issue.FilePath, issue.Line, issue.Title, message))
+	}
+	for _, reason := range gate.Reasons {
+		fmt.Fprintln(w, github.Annotation("error", "", 0, i18n.M("cmd.gate_failed_title"), reason))
+	}
+
+	if err := cli.CheckWritable("readonly.job_summary"); err != nil {
+		return
+	}
+	summary, err := reporter.Generate(issues, review.GitHubMarkdownFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, i18n.M("cmd.step_summary_failed", err))
+		return
+	}
+	if _, err := github.AppendStepSummary(summary); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/tracing"
+)
+
+// command 定义一个子命令
+type command struct {
+	// 命令说明的消息键，输出帮助时按界面语言取文本
+	summary string
+	// 命令入口，args 不包含子命令名称本身
+	run func(args []string) error
+}
+
+// commands 已注册的子命令
+var commands = map[string]command{}
+
+// registerCommand 注册子命令，summary 为 i18n 中的消息键（init 时界面语言尚未确定）
+func registerCommand(name, summary string, run func(args []string) error) {
+	commands[name] = command{summary: summary, run: run}
+}
+
+// runCommand 执行子命令，返回 false 表示 name 不是已注册的子命令
+func runCommand(name string, args []string) bool {
+	cmd, ok := commands[name]
+	if !ok {
+		return false
+	}
+	if err := cmd.run(args); err != nil {
+		tracing.Shutdown()
+		log.Fatal(i18n.M("cmd.command_failed", name, err))
+	}
+	return true
+}
+
+// printCommands 输出子命令列表
+func printCommands() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "\n"+i18n.M("cmd.commands_heading"))
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", name, i18n.M(commands[name].summary))
+	}
+}
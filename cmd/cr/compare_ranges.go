@@ -0,0 +1,147 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/cli"
+	"github.com/icatw/ai-cr-tool/pkg/config"
+	"github.com/icatw/ai-cr-tool/pkg/git"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/model"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+)
+
+func init() {
+	registerCommand("compare-ranges", "cmd.summary.compare-ranges", runCompareRanges)
+}
+
+// runCompareRanges 执行 compare-ranges 子命令：对比同一功能在多个提交范围中的不同实现
+func runCompareRanges(args []string) error {
+	fs := flag.NewFlagSet("compare-ranges", flag.ExitOnError)
+	modelName := fs.String("model", "", i18n.M("compare-ranges.flag.model"))
+	lang := fs.String("lang", string(i18n.Default), i18n.M("compare-ranges.flag.lang"))
+	format := fs.String("format", string(review.MarkdownFormat), i18n.M("compare-ranges.flag.format"))
+	output := fs.String("output", "", i18n.M("compare-ranges.flag.output"))
+	concurrency := fs.Int("concurrency", review.DefaultConcurrency, i18n.M("compare-ranges.flag.concurrency"))
+	verbose := fs.Bool("verbose", false, i18n.M("compare-ranges.flag.verbose"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		return i18n.Errorf("compare-ranges.usage")
+	}
+	for _, r := range fs.Args() {
+		if !strings.Contains(r, "..") {
+			return i18n.Errorf("compare-ranges.err.range", r)
+		}
+	}
+	if *concurrency < 1 {
+		return i18n.Errorf("compare-ranges.err.concurrency", *concurrency)
+	}
+	reportLang, err := i18n.Parse(*lang)
+	if err != nil {
+		return err
+	}
+	reportFormat, err := review.ParseReportFormat(*format)
+	if err != nil {
+		return err
+	}
+	if reportFormat != review.MarkdownFormat && reportFormat != review.JSONFormat {
+		return i18n.Errorf("compare-ranges.err.format", *format)
+	}
+
+	// 使用仓库配置中的默认模型、模型池、排除规则和组织级策略
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	cfg, _, err := config.Load(config.Find(wd))
+	if err != nil {
+		return err
+	}
+	opts := &cli.Options{Config: cfg, ReadOnly: cli.ReadOnlyFromEnv()}
+	reviewPolicy, err := loadPolicy(opts)
+	if err != nil {
+		return i18n.Errorf("cmd.policy_failed", err)
+	}
+	if *modelName == "" {
+		*modelName = cfg.Model.Default
+	}
+
+	analyzer := review.NewAnalyzer(git.NewGitClient(wd))
+	ranges := make([]review.RangeChanges, 0, fs.NArg())
+	for _, r := range fs.Args() {
+		changes, err := analyzer.AnalyzeChanges(r, "")
+		if err != nil {
+			return i18n.Errorf("compare-ranges.err.changes", r, err)
+		}
+		changes, _ = reviewPolicy.FilterChanges(changes)
+		if len(changes) == 0 {
+			return i18n.Errorf("compare-ranges.err.no_changes", r)
+		}
+		ranges = append(ranges, review.RangeChanges{Range: r, Changes: changes})
+	}
+
+	modelClient, modelConfig, err := newModelClient(*modelName, cfg.Model.Pools)
+	if err != nil {
+		return err
+	}
+	providers := []string{modelConfig.Type}
+	if balancer, ok := modelClient.(*model.Balancer); ok {
+		providers = balancer.Types()
+	}
+	for _, provider := range providers {
+		if err := reviewPolicy.CheckProvider(provider); err != nil {
+			return err
+		}
+	}
+
+	prompt, err := loadReviewPrompt(wd)
+	if err != nil {
+		return err
+	}
+	if reportLang != i18n.Default {
+		prompt.Language = reportLang
+	}
+	engine := review.NewEngine(modelClient, review.EngineOptions{
+		ModelConfig: modelConfig,
+		Prompt:      prompt,
+		Concurrency: *concurrency,
+		Verbose:     *verbose,
+	})
+	fmt.Fprintln(os.Stderr, i18n.M("compare-ranges.comparing", len(ranges)))
+	comparison, err := engine.CompareRanges(ranges)
+	if err != nil {
+		// 汇总对比失败时仍输出各方案的概括
+		if comparison == nil || comparison.Approaches[0].Summary == "" {
+			return err
+		}
+		log.Printf("%v\n", err)
+	}
+
+	content, err := review.NewReporterWithLang("", "", reportLang).GenerateRangeComparison(comparison, reportFormat)
+	if err != nil {
+		return err
+	}
+	usage := engine.Usage()
+	fmt.Fprintln(os.Stderr, i18n.M("compare-ranges.tokens", usage.TotalTokens))
+	if *output == "" {
+		_, err = os.Stdout.Write(content)
+		return err
+	}
+	if dir := filepath.Dir(*output); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return i18n.Errorf("cmd.report_dir_failed", err)
+		}
+	}
+	if err := os.WriteFile(*output, content, 0644); err != nil {
+		return i18n.Errorf("cmd.save_report_failed", err)
+	}
+	fmt.Fprintln(os.Stderr, i18n.M("cmd.report_saved", *output))
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/icatw/ai-cr-tool/pkg/cli"
+	"github.com/icatw/ai-cr-tool/pkg/config"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+)
+
+func init() {
+	registerCommand("config", "cmd.summary.config", runConfig)
+}
+
+// runConfig 执行 config 子命令
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		return i18n.Errorf("config.usage")
+	}
+
+	fs := flag.NewFlagSet("config "+args[0], flag.ExitOnError)
+	path := fs.String("config", "", i18n.M("config.flag.config", config.FileName))
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if *path == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return i18n.Errorf("cmd.getwd_failed", err)
+		}
+		*path = config.Find(wd)
+		if *path == "" && args[0] == "init" {
+			*path = filepath.Join(wd, config.FileName)
+		}
+	}
+
+	switch args[0] {
+	case "init":
+		if err := cli.CheckWritable("readonly.create_config"); err != nil {
+			return err
+		}
+		if _, err := os.Stat(*path); err == nil {
+			return i18n.Errorf("config.err.exists", *path)
+		}
+		if err := config.Save(*path, config.Default()); err != nil {
+			return err
+		}
+		fmt.Println(i18n.M("config.created", *path))
+	case "show":
+		cfg, _, err := config.Load(*path)
+		if err != nil {
+			return err
+		}
+		data, err := config.Marshal(cfg)
+		if err != nil {
+			return err
+		}
+		if *path != "" {
+			fmt.Printf("# %s\n", *path)
+		}
+		fmt.Print(string(data))
+	case "migrate":
+		if err := cli.CheckWritable("readonly.migrate_config"); err != nil {
+			return err
+		}
+		if *path == "" {
+			return i18n.Errorf("config.err.not_found", config.FileName)
+		}
+		result, err := config.MigrateFile(*path)
+		if err != nil {
+			return err
+		}
+		if result.BackupPath == "" {
+			fmt.Println(i18n.M("config.up_to_date", result.ToVersion))
+			return nil
+		}
+		fmt.Println(i18n.M("config.migrated", result.FromVersion, result.ToVersion, result.BackupPath))
+	default:
+		return i18n.Errorf("config.err.unknown_action", args[0])
+	}
+	return nil
+}
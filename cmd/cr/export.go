@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/icatw/ai-cr-tool/pkg/cli"
+	"github.com/icatw/ai-cr-tool/pkg/codeowners"
+	"github.com/icatw/ai-cr-tool/pkg/git"
+	"github.com/icatw/ai-cr-tool/pkg/github"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/tasks"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+func init() {
+	registerCommand("export-tasks", "cmd.summary.export-tasks", runExportTasks)
+}
+
+// runExportTasks 执行 export-tasks 子命令
+func runExportTasks(args []string) error {
+	fs := flag.NewFlagSet("export-tasks", flag.ExitOnError)
+	input := fs.String("input", "-", i18n.M("export.flag.input"))
+	to := fs.String("to", "todo", i18n.M("export.flag.to"))
+	output := fs.String("output", "TODO.md", i18n.M("export.flag.output"))
+	minSeverity := fs.String("min-severity", string(types.SeverityWarning), i18n.M("export.flag.min-severity"))
+	repo := fs.String("repo", "", i18n.M("export.flag.repo"))
+	dryRun := fs.Bool("dry-run", false, i18n.M("export.flag.dry-run"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	severity := types.SeverityLevel(*minSeverity)
+	if severity.Rank() == 0 {
+		return i18n.Errorf("export.err.min_severity", *minSeverity)
+	}
+
+	report, err := review.LoadJSONReport(*input)
+	if err != nil {
+		return err
+	}
+
+	// CODEOWNERS 只用于填写负责人，读取失败不影响导出
+	wd, err := os.Getwd()
+	if err != nil {
+		return i18n.Errorf("cmd.getwd_failed", err)
+	}
+	var owners *codeowners.Owners
+	if root, err := git.NewGitClient(wd).RepoRoot(); err == nil {
+		if owners, err = codeowners.Load(root); err != nil {
+			fmt.Fprintln(os.Stderr, i18n.M("export.codeowners_ignored", err))
+		}
+	}
+
+	list := tasks.Collect(report.ToIssues(), severity, owners)
+	if len(list) == 0 {
+		fmt.Fprintln(os.Stderr, i18n.M("export.nothing", severity))
+		return nil
+	}
+
+	if *dryRun {
+		for _, task := range list {
+			fmt.Printf("[%s] %s — %s %v\n", task.Issue.Severity, task.Issue.Title, task.Location(), task.Owners)
+		}
+		return nil
+	}
+	if err := cli.CheckWritable("readonly.export_tasks"); err != nil {
+		return err
+	}
+
+	switch *to {
+	case "todo":
+		existing, err := os.ReadFile(*output)
+		if err != nil && !os.IsNotExist(err) {
+			return i18n.Errorf("export.err.read", *output, err)
+		}
+		content, added := tasks.MergeTODO(existing, list)
+		if err := os.WriteFile(*output, content, 0644); err != nil {
+			return i18n.Errorf("export.err.write", *output, err)
+		}
+		fmt.Println(i18n.M("export.todo_added", *output, added, len(list)-added))
+	case "github":
+		client, err := github.NewClientFromEnv(*repo)
+		if err != nil {
+			return err
+		}
+		created, skipped, err := tasks.ExportGitHub(client, list)
+		for _, url := range created {
+			fmt.Println(url)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(i18n.M("export.issues_created", client.Repo(), len(created), skipped))
+	default:
+		return i18n.Errorf("export.err.target", *to)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/fakeprovider"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+)
+
+func init() {
+	registerCommand("fake-provider", "cmd.summary.fake-provider", runFakeProvider)
+}
+
+// runFakeProvider 执行 fake-provider 子命令：运行兼容 OpenAI 接口、返回固定回复的模拟模型服务
+// 把模型池的地址指向该服务，即可在没有网络和密钥的环境中验证钩子、CI 配置和报告流程
+func runFakeProvider(args []string) error {
+	fs := flag.NewFlagSet("fake-provider", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8787", i18n.M("fake-provider.flag.addr"))
+	fixturesFile := fs.String("fixtures", "", i18n.M("fake-provider.flag.fixtures"))
+	delay := fs.Duration("delay", 0, i18n.M("fake-provider.flag.delay"))
+	modelName := fs.String("model", "", i18n.M("fake-provider.flag.model"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return i18n.Errorf("fake-provider.err.extra_args", fs.Args())
+	}
+	if *delay < 0 {
+		return i18n.Errorf("fake-provider.err.delay")
+	}
+
+	opts := fakeprovider.Options{Model: *modelName, Delay: *delay, Log: os.Stderr}
+	if *fixturesFile != "" {
+		fixtures, err := fakeprovider.LoadFixtures(*fixturesFile)
+		if err != nil {
+			return err
+		}
+		opts.Fixtures = fixtures
+	}
+
+	srv := fakeprovider.NewServer(opts)
+	httpServer := &http.Server{Addr: *addr, Handler: srv.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.ListenAndServe()
+	}()
+	fmt.Fprintln(os.Stderr, i18n.M("fake-provider.listening", *addr))
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	select {
+	case err := <-errs:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return i18n.Errorf("fake-provider.err.listen", *addr, err)
+		}
+	case <-interrupt:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	httpServer.Shutdown(ctx)
+	return nil
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/cli"
+	"github.com/icatw/ai-cr-tool/pkg/config"
+	"github.com/icatw/ai-cr-tool/pkg/git"
+	"github.com/icatw/ai-cr-tool/pkg/history"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+func init() {
+	registerCommand("history", "cmd.summary.history", runHistory)
+}
+
+// recordHistory 把本次评审记录到评审历史中，只读模式或配置为 off 时不记录，失败时只记录日志
+func recordHistory(dir, repo, trigger string, reporter review.Reporter, issues []types.Issue, opts *cli.Options, passed bool) {
+	if opts.ReadOnly || opts.Config == nil || opts.Config.History.Store == history.Disabled {
+		return
+	}
+	report, err := reporter.Generate(append([]types.Issue(nil), issues...), review.JSONFormat)
+	if err != nil {
+		log.Print(i18n.M("cmd.history_failed", err))
+		return
+	}
+	run, err := history.FromReport(report)
+	if err != nil {
+		log.Print(i18n.M("cmd.history_failed", err))
+		return
+	}
+	run.Repo, run.Trigger = repo, trigger
+	run.Ref = opts.CommitRange
+	if run.Ref == "" {
+		run.Ref, _ = git.NewGitClient(dir).CurrentBranch()
+	}
+	run.Gate = "passed"
+	if !passed {
+		run.Gate = "failed"
+	}
+
+	store, err := history.Open(opts.Config.History.Store)
+	if err != nil {
+		log.Print(i18n.M("cmd.history_failed", err))
+		return
+	}
+	defer store.Close()
+	if err := store.Record(run, report); err != nil {
+		log.Print(i18n.M("cmd.history_failed", err))
+	}
+}
+
+// runHistory 执行 history 子命令
+func runHistory(args []string) error {
+	if len(args) == 0 {
+		return i18n.Errorf("history.usage")
+	}
+	switch args[0] {
+	case "list":
+		return runHistoryList(args[1:])
+	case "show":
+		return runHistoryShow(args[1:])
+	default:
+		return i18n.Errorf("history.err.unknown_subcommand", args[0])
+	}
+}
+
+// openHistoryStore 打开 --store 指定的评审历史，未指定时使用当前仓库配置文件中的 history.store
+func openHistoryStore(dsn string) (history.Store, error) {
+	if dsn == "" {
+		if wd, err := os.Getwd(); err == nil {
+			cfg, _, err := config.Load(config.Find(wd))
+			if err != nil {
+				return nil, err
+			}
+			dsn = cfg.History.Store
+		}
+	}
+	return history.Open(dsn)
+}
+
+// runHistoryList 列出评审记录，最近的在前
+func runHistoryList(args []string) error {
+	fs := flag.NewFlagSet("history list", flag.ExitOnError)
+	store := fs.String("store", "", i18n.M("history.flag.store"))
+	repo := fs.String("repo", "", i18n.M("history.flag.repo"))
+	since := fs.String("since", "", i18n.M("history.flag.since"))
+	limit := fs.Int("limit", 20, i18n.M("history.flag.limit"))
+	format := fs.String("format", "text", i18n.M("history.flag.format"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	filter := history.Filter{Repo: *repo, Limit: *limit}
+	if *since != "" {
+		t, err := time.ParseInLocation("2006-01-02", *since, time.Local)
+		if err != nil {
+			return i18n.Errorf("history.err.since", *since)
+		}
+		filter.Since = t
+	}
+
+	s, err := openHistoryStore(*store)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	runs, err := s.Runs(filter)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "json":
+		if runs == nil {
+			runs = []history.Run{}
+		}
+		return printJSON(runs)
+	case "text":
+		if len(runs) == 0 {
+			fmt.Fprintln(os.Stderr, i18n.M("history.empty"))
+			return nil
+		}
+		fmt.Printf("%-24s %-16s %-24s %-20s %6s %5s %5s %5s %6s %9s %8s %s\n",
+			"ID", i18n.M("history.col.time"), i18n.M("history.col.repo"), i18n.M("history.col.ref"), i18n.M("history.col.issues"),
+			"error", "warn", "info", i18n.M("history.col.score"), "token", i18n.M("history.col.cost"), i18n.M("history.col.gate"))
+		for _, run := range runs {
+			cost := "-"
+			if run.Cost > 0 {
+				cost = fmt.Sprintf("%.4f", run.Cost)
+			}
+			gate := run.Gate
+			if gate == "" {
+				gate = "-"
+			}
+			fmt.Printf("%-24s %-16s %-24s %-20s %6d %5d %5d %5d %6s %9d %8s %s\n",
+				run.ID, run.ReviewedAt.Local().Format("2006-01-02 15:04"), truncate(run.Repo, 24), truncate(run.Ref, 20),
+				run.Issues, run.BySeverity["error"], run.BySeverity["warning"], run.BySeverity["info"],
+				fmt.Sprintf("%d %s", run.Score, run.Grade), run.PromptTokens+run.CompletionTokens, cost, gate)
+		}
+		return nil
+	default:
+		return i18n.Errorf("history.err.format", *format)
+	}
+}
+
+// runHistoryShow 输出一次评审的详细信息和问题列表
+func runHistoryShow(args []string) error {
+	// 评审 ID 写在选项之前，如 cr history show 20240101120000-1a2b3c4d --format json
+	id := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		id, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("history show", flag.ExitOnError)
+	store := fs.String("store", "", i18n.M("history.flag.store"))
+	format := fs.String("format", "text", i18n.M("history.flag.show-format"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if id == "" || fs.NArg() > 0 {
+		return i18n.Errorf("history.usage_show")
+	}
+
+	s, err := openHistoryStore(*store)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	if *format == "report" {
+		report, err := s.Report(id)
+		if errors.Is(err, history.ErrNotFound) {
+			return i18n.Errorf("history.err.not_found", id)
+		}
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(report)
+		return err
+	}
+	run, err := s.Get(id)
+	if errors.Is(err, history.ErrNotFound) {
+		return i18n.Errorf("history.err.not_found", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "json":
+		return printJSON(run)
+	case "text":
+		fmt.Println(i18n.M("history.show.title", run.ID))
+		fmt.Println(i18n.M("history.show.repo", run.Repo))
+		if run.Change != "" {
+			fmt.Println(i18n.M("history.show.change", run.Change, run.URL))
+		}
+		if run.Ref != "" {
+			fmt.Println(i18n.M("history.show.ref", run.Ref))
+		}
+		reviewedAt := run.ReviewedAt.Local().Format("2006-01-02 15:04:05")
+		if run.DurationMS > 0 {
+			fmt.Println(i18n.M("history.show.time_duration", reviewedAt, run.Trigger, (time.Duration(run.DurationMS) * time.Millisecond).Round(time.Second)))
+		} else {
+			fmt.Println(i18n.M("history.show.time", reviewedAt, run.Trigger))
+		}
+		if run.Model != "" {
+			fmt.Println(i18n.M("history.show.model", run.Model))
+		}
+		fmt.Println(i18n.M("history.show.result",
+			run.Files, run.Issues, run.BySeverity["error"], run.BySeverity["warning"], run.BySeverity["info"], run.Score, run.Grade))
+		if run.PromptTokens+run.CompletionTokens > 0 {
+			fmt.Println(i18n.M("history.show.tokens", run.PromptTokens, run.CachedTokens, run.CompletionTokens))
+		}
+		if run.Cost > 0 {
+			fmt.Println(i18n.M("history.show.cost", run.Cost, run.Currency))
+		}
+		if run.Gate != "" {
+			fmt.Println(i18n.M("history.show.gate", run.Gate))
+		}
+		if len(run.Findings) > 0 {
+			fmt.Println()
+		}
+		for _, issue := range run.Findings {
+			fmt.Printf("[%s] %s:%d %s\n", issue.Severity, issue.File, issue.Line, issue.Title)
+		}
+		return nil
+	default:
+		return i18n.Errorf("history.err.show_format", *format)
+	}
+}
+
+// printJSON 以缩进的 JSON 格式输出到标准输出
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// truncate 截断过长的字符串，用于对齐表格
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
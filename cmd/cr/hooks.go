@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/icatw/ai-cr-tool/pkg/cli"
+	"github.com/icatw/ai-cr-tool/pkg/git"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+)
+
+func init() {
+	registerCommand("hooks", "cmd.summary.hooks", runHooks)
+	registerCommand("install-hooks", "cmd.summary.install-hooks", func(args []string) error {
+		return runHooks(append([]string{"install"}, args...))
+	})
+}
+
+// runHooks 执行 hooks 子命令
+func runHooks(args []string) error {
+	if len(args) == 0 {
+		return i18n.Errorf("hooks.usage")
+	}
+
+	fs := flag.NewFlagSet("hooks "+args[0], flag.ExitOnError)
+	preCommit := fs.Bool("pre-commit", false, i18n.M("hooks.flag.pre-commit"))
+	prePush := fs.Bool("pre-push", false, i18n.M("hooks.flag.pre-push"))
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	// 未指定时处理全部钩子
+	hookTypes := []git.HookType{git.PreCommitHook, git.PrePushHook}
+	if *preCommit || *prePush {
+		hookTypes = nil
+		if *preCommit {
+			hookTypes = append(hookTypes, git.PreCommitHook)
+		}
+		if *prePush {
+			hookTypes = append(hookTypes, git.PrePushHook)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return i18n.Errorf("cmd.getwd_failed", err)
+	}
+	root, err := git.NewGitClient(wd).RepoRoot()
+	if err != nil {
+		return err
+	}
+	manager := git.NewHookManager(root)
+
+	for _, hookType := range hookTypes {
+		switch args[0] {
+		case "install":
+			if err := cli.CheckWritable("readonly.install_hooks"); err != nil {
+				return err
+			}
+			before, err := manager.HookStatus(hookType)
+			if err != nil {
+				return err
+			}
+			if err := manager.InstallHook(hookType); err != nil {
+				return err
+			}
+			switch {
+			case before.Managed:
+				fmt.Println(i18n.M("hooks.upgraded", hookType, before.Version))
+			case before.Installed:
+				fmt.Println(i18n.M("hooks.merged", hookType))
+			default:
+				fmt.Println(i18n.M("hooks.installed", hookType))
+			}
+		case "uninstall":
+			if err := cli.CheckWritable("readonly.uninstall_hooks"); err != nil {
+				return err
+			}
+			if err := manager.RemoveHook(hookType); err != nil {
+				return err
+			}
+			fmt.Println(i18n.M("hooks.removed", hookType))
+		case "status":
+			status, err := manager.HookStatus(hookType)
+			if err != nil {
+				return err
+			}
+			switch {
+			case status.Managed:
+				fmt.Println(i18n.M("hooks.status_managed", hookType, status.Version, status.GeneratedAt.Format("2006-01-02 15:04:05")))
+			case status.Installed:
+				fmt.Println(i18n.M("hooks.status_custom", hookType))
+			default:
+				fmt.Println(i18n.M("hooks.status_missing", hookType))
+			}
+		default:
+			return i18n.Errorf("hooks.err.unknown_action", args[0])
+		}
+	}
+	return nil
+}
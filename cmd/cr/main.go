@@ -1,171 +1,308 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"math"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/icatw/ai-cr-tool/pkg/cache"
 	"github.com/icatw/ai-cr-tool/pkg/cli"
-	"github.com/icatw/ai-cr-tool/pkg/git"
+	"github.com/icatw/ai-cr-tool/pkg/config"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
 	"github.com/icatw/ai-cr-tool/pkg/model"
+	"github.com/icatw/ai-cr-tool/pkg/policy"
 	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/tracing"
 	"github.com/icatw/ai-cr-tool/pkg/types"
+	"github.com/icatw/ai-cr-tool/pkg/version"
 )
 
 func main() {
+	// 先设置界面语言，子命令的帮助和错误信息也使用该语言
+	args, err := cli.ExtractLocale(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	// 设置了 OpenTelemetry 导出地址时记录评审链路，退出前导出
+	tracing.Setup(version.Version)
+	defer tracing.Shutdown()
+	if len(args) > 0 {
+		// 执行子命令
+		if runCommand(args[0], args[1:]) {
+			return
+		}
+		// diff 和 review 是默认评审命令的别名
+		if args[0] == "diff" || args[0] == "review" {
+			args = args[1:]
+		}
+	}
+
 	// 解析命令行参数
-	opts, err := cli.ParseFlags()
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, i18n.M("cmd.usage"))
+		flag.PrintDefaults()
+		printCommands()
+	}
+	opts, err := cli.ParseArgs(args)
 	if err != nil {
-		log.Fatalf("解析参数失败: %v\n", err)
+		log.Fatal(i18n.M("cmd.parse_args_failed", err))
 	}
 
-	// 初始化Git客户端
 	wd, err := os.Getwd()
 	if err != nil {
-		log.Fatalf("获取当前工作目录失败: %v\n", err)
-	}
-	gitClient := git.NewGitClient(wd)
-
-	// 初始化代码分析器
-	analyzer := review.NewAnalyzer(gitClient)
-
-	// 获取代码改动
-	var changes []types.FileChange
-	switch {
-	case opts.Files != "":
-		// 评审指定文件
-		files := strings.Split(opts.Files, ",")
-		changes, err = analyzer.AnalyzeFiles(files)
-	case opts.Staged:
-		// 评审已暂存的改动
-		changes, err = analyzer.AnalyzeStagedChanges()
-	case opts.CommitHash != "":
-		// 评审指定提交
-		changes, err = analyzer.AnalyzeCommit(opts.CommitHash)
-	case opts.CommitRange != "":
-		// 评审提交范围
-		changes, err = analyzer.AnalyzeChanges(opts.CommitRange, "")
-	default:
-		// 默认评审所有未提交的改动
-		changes, err = analyzer.AnalyzeWorkingDirChanges()
+		log.Fatal(i18n.M("cmd.getwd_failed", err))
 	}
 
+	session, err := runReview(opts, wd)
 	if err != nil {
-		log.Fatalf("分析代码改动失败: %v\n", err)
+		tracing.Shutdown()
+		log.Print(err)
+		printResultLine(0, nil, nil, "error")
+		os.Exit(1)
 	}
-
-	if len(changes) == 0 {
+	defer session.Span.End(nil)
+	if len(session.Changes) == 0 && session.Dependencies == nil && len(session.Issues) == 0 {
 		if !opts.Quiet {
-			fmt.Println("没有发现需要评审的代码改动")
+			fmt.Fprintln(os.Stderr, i18n.M("cmd.no_changes"))
 		}
+		printResultLine(0, nil, nil, "pass")
 		return
 	}
+	issues := session.Issues
+
+	// 生成评审报告
+	format, err := review.ParseReportFormat(opts.OutputFormat)
+	if err != nil {
+		log.Fatal(i18n.M("cli.err.unsupported_format", opts.OutputFormat))
+	}
+	// 未指定格式且直接输出到终端时使用终端格式
+	if !opts.FormatSet && opts.OutputFile == "" && isTerminal(os.Stdout) {
+		format = review.TerminalFormat
+	}
+	reporter, err := newSessionReporter("ai-cr-tool", session, opts)
+	if err != nil {
+		log.Fatalf("%v\n", err)
+	}
+	saveArtifact(wd, reporter, issues, opts)
+
+	// 保存报告
+	if opts.OutputFile != "" {
+		if err := writeReport(reporter, issues, format, opts.OutputFile); err != nil {
+			log.Fatalf("%v\n", err)
+		}
+		fmt.Println(i18n.M("cmd.report_saved", opts.OutputFile))
+		if len(session.Authors) > 0 {
+			paths, err := writeAuthorReports(session, opts, format)
+			if err != nil {
+				log.Fatalf("%v\n", err)
+			}
+			for _, path := range paths {
+				fmt.Println(i18n.M("cmd.author_report_saved", path))
+			}
+		}
+	} else {
+		reportContent, err := reporter.Generate(issues, format)
+		if err != nil {
+			log.Fatal(i18n.M("cmd.generate_report_failed", err))
+		}
+		// 机器可读的报告直接输出，便于通过管道交给其他工具处理
+		if !format.IsMachineReadable() && format != review.TerminalFormat {
+			fmt.Println("\n" + i18n.M("cmd.report_heading"))
+		}
+		os.Stdout.Write(reportContent)
+	}
+
+	// 生成徽章，供 CI 发布到 README 或 MR 页面
+	if opts.BadgePath != "" {
+		badgeFormat := review.BadgeFormat
+		if strings.EqualFold(filepath.Ext(opts.BadgePath), ".json") {
+			badgeFormat = review.BadgeJSONFormat
+		}
+		if err := writeReport(reporter, issues, badgeFormat, opts.BadgePath); err != nil {
+			log.Fatalf("%v\n", err)
+		}
+		if !opts.Quiet {
+			fmt.Fprintln(os.Stderr, i18n.M("cmd.badge_saved", opts.BadgePath))
+		}
+	}
+
+	// 质量门禁
+	result := session.Policy.Evaluate(issues)
+	recordHistory(wd, projectName(wd), "cli", reporter, issues, opts, result.Passed)
+	if opts.CI == "github" {
+		reportGitHubActions(reporter, issues, result, format, opts)
+	}
+	sendNotifications(projectName(wd), opts.CommitRange, ciChangeURL(), session, opts, result.Passed)
+	if !result.Passed {
+		for _, reason := range result.Reasons {
+			fmt.Fprintln(os.Stderr, i18n.M("cmd.gate_failed", reason))
+		}
+		printResultLine(len(session.Changes), issues, session.Stats, "fail")
+		// os.Exit 不执行 defer，先结束链路并导出
+		session.Span.End(nil)
+		tracing.Shutdown()
+		os.Exit(1)
+	}
+	printResultLine(len(session.Changes), issues, session.Stats, "pass")
+}
+
+// printResultLine 在标准错误输出最后打印一行固定格式的评审结果，不受报告格式和 --quiet 影响，
+// 便于包装脚本直接 grep，如 CR_RESULT files=12 issues=7 high=1 cost=0.42 verdict=fail；
+// high 为 error 级别的问题数，verdict 为 pass、fail 或 error（评审未能完成）
+func printResultLine(files int, issues []types.Issue, stats *review.ReviewStats, verdict string) {
+	high := 0
+	for _, issue := range issues {
+		if issue.Severity == types.SeverityError {
+			high++
+		}
+	}
+	cost := 0.0
+	if stats != nil {
+		cost = math.Round(stats.Cost*1e4) / 1e4
+	}
+	fmt.Fprintf(os.Stderr, "CR_RESULT files=%d issues=%d high=%d cost=%s verdict=%s\n",
+		files, len(issues), high, strconv.FormatFloat(cost, 'f', -1, 64), verdict)
+}
+
+// loadPolicy 根据配置构建评审策略，配置了 policy_url 时合并组织级策略，配置了 skip_list_url 时合并跳过清单
+func loadPolicy(opts *cli.Options) (*policy.Policy, error) {
+	p := policy.FromConfig(opts.Config)
+	if opts.FailOn != "" {
+		p.Gate.FailOn = types.SeverityLevel(opts.FailOn)
+	}
+	if opts.MinScore > 0 {
+		p.Gate.MinScore = opts.MinScore
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	// 只读模式下不使用本地缓存，每次都重新下载
+	cacheRoot := ""
+	if !opts.ReadOnly {
+		cacheRoot = filepath.Join(os.Getenv("HOME"), ".cr")
+	}
+	if opts.Config.PolicyURL != "" {
+		cacheDir := ""
+		if cacheRoot != "" {
+			cacheDir = filepath.Join(cacheRoot, "policy")
+		}
+		org, err := policy.LoadRemote(opts.Config.PolicyURL, opts.Config.PolicyPublicKey, cacheDir)
+		if err != nil {
+			return nil, err
+		}
+		p = p.Merge(org)
+	}
+	if opts.Config.SkipListURL != "" {
+		cacheDir := ""
+		if cacheRoot != "" {
+			cacheDir = filepath.Join(cacheRoot, "skiplist")
+		}
+		skipList, err := policy.LoadSkipList(opts.Config.SkipListURL, cacheDir)
+		if err != nil {
+			return nil, err
+		}
+		p = p.Merge(skipList.Policy())
+	}
+	return p, nil
+}
 
-	// 初始化缓存
+// newReviewCache 初始化评审缓存，失败时返回nil并记录日志
+// 只读模式下只读取已有的磁盘缓存；writeQueue 大于0时异步写入，使用完毕后需要调用 Close
+func newReviewCache(memoryMB, writeQueue int, readOnly bool) *cache.ReviewCache {
 	cacheDir := filepath.Join(os.Getenv("HOME"), ".cr", "cache")
-	reviewCache, err := cache.NewReviewCache(cacheDir)
+	if readOnly {
+		return cache.NewReadOnlyReviewCache(cacheDir, int64(memoryMB)<<20)
+	}
+	reviewCache, err := cache.NewAsyncReviewCache(cacheDir, int64(memoryMB)<<20, writeQueue)
 	if err != nil {
-		log.Printf("初始化缓存失败: %v\n", err)
+		log.Print(i18n.M("cmd.cache_init_failed", err))
+		return nil
 	}
+	return reviewCache
+}
 
-	// 初始化AI模型客户端
+// newModelClient 根据环境变量中的API密钥创建指定模型的客户端，name为空时使用默认模型
+// name 为配置中的模型池名称时，创建在池中各提供方之间负载均衡的客户端
+func newModelClient(name string, pools map[string]config.PoolConfig) (model.ModelClient, *model.Config, error) {
+	if pool, ok := pools[name]; ok {
+		return newPoolClient(name, pool)
+	}
 	deepseekKey := os.Getenv("DEEPSEEK_API_KEY")
 	qwenKey := os.Getenv("QWEN_API_KEY")
 	modelCfg := model.NewModelConfigWithKeys(deepseekKey, "", "", qwenKey)
 
 	modelManager, err := model.NewModelManager(modelCfg)
 	if err != nil {
-		log.Fatalf("初始化模型管理器失败: %v\n", err)
+		return nil, nil, i18n.Errorf("cmd.model_manager_failed", err)
 	}
 
-	modelClient, err := modelManager.GetClient(opts.Model)
+	modelClient, err := modelManager.GetClient(name)
 	if err != nil {
-		log.Fatalf("获取模型客户端失败: %v\n", err)
+		return nil, nil, i18n.Errorf("cmd.model_client_failed", err)
 	}
 
-	// 创建评审提示模板
-	prompt := model.DefaultReviewPrompt()
-
-	// 创建评审报告生成器
-	reporter := review.NewReporter("ai-cr-tool", "HEAD")
-	var issues []types.Issue
+	if name == "" {
+		name = modelCfg.DefaultModel
+	}
+	return modelClient, modelCfg.Models[name], nil
+}
 
-	// 处理每个改动文件
-	for _, change := range changes {
-		if !opts.Quiet {
-			fmt.Printf("正在评审文件: %s\n", change.FilePath)
-		}
-
-		// 检查缓存
-		if reviewCache != nil {
-			if cached, err := reviewCache.Get(change.DiffContent); err == nil && cached != nil {
-				issues = append(issues, types.Issue{
-					Title:       "缓存的评审结果",
-					FilePath:    change.FilePath,
-					Description: cached.ReviewResult,
-					Severity:    types.SeverityInfo,
-				})
-				continue
-			}
+// checkProviders 检查模型客户端的提供方是否符合策略，模型池中的每个提供方都要符合
+func checkProviders(client model.ModelClient, cfg *model.Config, check func(string) error) error {
+	providers := []string{cfg.Type}
+	if balancer, ok := client.(*model.Balancer); ok {
+		providers = balancer.Types()
+	}
+	for _, provider := range providers {
+		if err := check(provider); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		// 生成评审提示
-		messages := prompt.GeneratePrompt(change.FilePath, change.ChangeType, change.DiffContent)
-
-		// 调用AI进行评审
-		req := &model.ChatRequest{
-			Model:       modelCfg.Models[modelCfg.DefaultModel].Model,
-			Messages:    messages,
-			MaxTokens:   modelCfg.Models[modelCfg.DefaultModel].MaxTokens,
-			Temperature: modelCfg.Models[modelCfg.DefaultModel].Temperature,
+// newPoolClient 创建模型池的负载均衡客户端，返回的配置以池名称作为模型名称，
+// 实际请求使用各提供方自己的模型名称
+func newPoolClient(name string, pool config.PoolConfig) (model.ModelClient, *model.Config, error) {
+	backends := make([]model.Backend, 0, len(pool.Providers))
+	for i, provider := range pool.Providers {
+		defaults, ok := model.DefaultModelConfig.Models[provider.Type]
+		if !ok {
+			return nil, nil, i18n.Errorf("cmd.pool_provider_unsupported", name, i+1, provider.Type)
 		}
-
-		resp, err := modelClient.Chat(req)
+		keyEnv := provider.APIKeyEnv
+		if keyEnv == "" {
+			keyEnv = strings.ToUpper(provider.Type) + "_API_KEY"
+		}
+		cfg := *defaults
+		cfg.APIKey = os.Getenv(keyEnv)
+		cfg.BaseURL = provider.URL
+		if provider.Model != "" {
+			cfg.Model = provider.Model
+		}
+		client, err := model.NewModelClient(&cfg)
 		if err != nil {
-			log.Printf("评审失败 - %s: %v\n", change.FilePath, err)
-			continue
-		}
-
-		// 添加评审结果
-		issues = append(issues, types.Issue{
-			Title:       "AI代码评审结果",
-			FilePath:    change.FilePath,
-			Description: resp.Choices[0].Message.Content,
-			Severity:    types.SeverityInfo,
-		})
-
-		// 缓存评审结果
-		if reviewCache != nil {
-			expireAfter := 24 * time.Hour
-			if err := reviewCache.Set(change.DiffContent, resp.Choices[0].Message.Content, &expireAfter); err != nil {
-				log.Printf("缓存评审结果失败: %v\n", err)
-			}
+			return nil, nil, i18n.Errorf("cmd.pool_provider_failed", name, provider.Type, keyEnv, err)
 		}
-	}
 
-	// 生成评审报告
-	format, err := review.ParseReportFormat(opts.OutputFormat)
-	if err != nil {
-		log.Fatalf("不支持的输出格式: %v\n", err)
+		label := provider.Type
+		if u, err := url.Parse(provider.URL); err == nil && u.Host != "" {
+			label += "@" + u.Host
+		}
+		backends = append(backends, model.Backend{Name: label, Type: provider.Type, Model: cfg.Model, Weight: provider.Weight, Client: client})
 	}
 
-	reportContent, err := reporter.Generate(issues, format)
+	balancer, err := model.NewBalancer(model.BalanceStrategy(pool.Strategy), backends)
 	if err != nil {
-		log.Fatalf("生成评审报告失败: %v\n", err)
-	}
-
-	// 保存报告
-	if opts.OutputFile != "" {
-		if err := os.WriteFile(opts.OutputFile, []byte(reportContent), 0644); err != nil {
-			log.Fatalf("保存评审报告失败: %v\n", err)
-		}
-		fmt.Printf("评审报告已保存到: %s\n", opts.OutputFile)
-	} else {
-		fmt.Println("\n评审报告:")
-		fmt.Println(reportContent)
+		return nil, nil, i18n.Errorf("cmd.pool_failed", name, err)
 	}
+	fmt.Fprintln(os.Stderr, i18n.M("cmd.using_pool", name, len(backends)))
+	return balancer, &model.Config{Type: "pool", Model: name, MaxTokens: 2000, Temperature: 0.7}, nil
 }
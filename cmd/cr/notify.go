@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/cli"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/notify"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+)
+
+// sendNotifications 按配置把评审摘要发送到各通知渠道，发送失败只记录日志，不影响评审结果
+// changeURL 为评审的 pull request 的链接，为空时不附带；只读模式下不发送任何通知
+func sendNotifications(project, ref, changeURL string, session *reviewSession, opts *cli.Options, passed bool) {
+	if !opts.Notify || len(opts.Config.Notify) == 0 {
+		return
+	}
+	if opts.ReadOnly {
+		if !opts.Quiet {
+			fmt.Fprintln(os.Stderr, i18n.M("cmd.notify_read_only"))
+		}
+		return
+	}
+	score := review.QualityScore(session.Issues)
+	summary := &notify.Summary{
+		Project:    project,
+		Ref:        ref,
+		Files:      len(session.Changes),
+		Severities: review.CountBySeverity(session.Issues),
+		Score:      score,
+		Grade:      review.Grade(score),
+		Passed:     passed,
+		Top:        notify.TopIssues(session.Issues),
+		ReportURL:  opts.ReportURL,
+		ChangeURL:  changeURL,
+		Lang:       session.Lang,
+	}
+	// 只有邮件通知需要完整报告，发送时才生成
+	summary.HTMLReport = func() ([]byte, error) {
+		reporter, err := newSessionReporter(project, session, opts)
+		if err != nil {
+			return nil, err
+		}
+		return reporter.Generate(session.Issues, review.HTMLFormat)
+	}
+	if session.Stats != nil {
+		summary.Model = session.Stats.Model
+		summary.Duration = session.Stats.Duration
+	}
+
+	for _, cfg := range opts.Config.Notify {
+		channel, err := notify.New(cfg)
+		if errors.Is(err, notify.ErrNoEndpoint) {
+			// 本地运行时通常没有设置 Webhook 或 SMTP 地址
+			if opts.Verbose {
+				fmt.Fprintln(os.Stderr, i18n.M("cmd.notify_skipped", cfg.Type, err))
+			}
+			continue
+		}
+		if err != nil {
+			log.Print(i18n.M("cmd.notify_failed", err))
+			continue
+		}
+		if !channel.Should(summary) {
+			continue
+		}
+		if err := channel.Notify(summary); err != nil {
+			log.Print(i18n.M("cmd.notify_failed", err))
+			continue
+		}
+		if opts.Verbose {
+			fmt.Fprintln(os.Stderr, i18n.M("cmd.notify_sent", channel.Name()))
+		}
+	}
+}
+
+// ciChangeURL 返回在 CI 中评审的 pull request 或 merge request 的链接，不在其中评审时返回空字符串
+func ciChangeURL() string {
+	if iid := os.Getenv("CI_MERGE_REQUEST_IID"); iid != "" && os.Getenv("CI_PROJECT_URL") != "" {
+		return os.Getenv("CI_PROJECT_URL") + "/-/merge_requests/" + iid
+	}
+	// pull request 事件中 GITHUB_REF 为 refs/pull/<编号>/merge
+	if number, ok := strings.CutPrefix(os.Getenv("GITHUB_REF"), "refs/pull/"); ok && os.Getenv("GITHUB_REPOSITORY") != "" {
+		number, _, _ = strings.Cut(number, "/")
+		server := os.Getenv("GITHUB_SERVER_URL")
+		if server == "" {
+			server = "https://github.com"
+		}
+		return fmt.Sprintf("%s/%s/pull/%s", server, os.Getenv("GITHUB_REPOSITORY"), number)
+	}
+	return ""
+}
+
+// projectName 返回通知中显示的项目名称，在 CI 中使用平台提供的仓库名称，否则使用仓库目录名
+func projectName(dir string) string {
+	for _, env := range []string{"GITHUB_REPOSITORY", "CI_PROJECT_PATH", "BITBUCKET_REPO_FULL_NAME"} {
+		if name := os.Getenv(env); name != "" {
+			return name
+		}
+	}
+	return filepath.Base(dir)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+)
+
+// 进度条宽度
+const progressBarWidth = 30
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// isTerminal 判断输出是否为终端
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// startProgress 启动进度渲染，返回进度通道和结束函数
+// 结束函数会关闭通道并等待渲染协程退出
+func startProgress(w *os.File) (chan<- review.ProgressInfo, func()) {
+	progress := make(chan review.ProgressInfo)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		displayProgress(w, progress, isTerminal(w))
+	}()
+	return progress, func() {
+		close(progress)
+		<-done
+	}
+}
+
+// displayProgress 渲染评审进度
+// 终端中绘制带旋转指示的实时进度条，非终端环境只逐行输出每个文件的完成状态
+func displayProgress(w io.Writer, progress <-chan review.ProgressInfo, live bool) {
+	inFlight := make(map[string]bool)
+	var last review.ProgressInfo
+	frame := 0
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	redraw := func() {
+		if !live || last.Total == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\r\033[K%s", renderProgressBar(last.Completed, last.Total, spinnerFrames[frame%len(spinnerFrames)], inFlight))
+	}
+
+	for {
+		select {
+		case info, ok := <-progress:
+			if !ok {
+				if live && last.Total > 0 {
+					fmt.Fprint(w, "\r\033[K")
+				}
+				return
+			}
+			last = info
+			if !info.Status.IsFinished() {
+				inFlight[info.FilePath] = true
+				redraw()
+				continue
+			}
+
+			delete(inFlight, info.FilePath)
+			if live {
+				fmt.Fprint(w, "\r\033[K")
+			}
+			fmt.Fprintln(w, formatFileStatus(info))
+			redraw()
+		case <-ticker.C:
+			frame++
+			redraw()
+		}
+	}
+}
+
+// renderProgressBar 生成进度条文本
+func renderProgressBar(completed, total int, spinner string, inFlight map[string]bool) string {
+	filled := completed * progressBarWidth / total
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+
+	files := make([]string, 0, len(inFlight))
+	for file := range inFlight {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	current := ""
+	if len(files) > 0 {
+		current = " " + i18n.M("progress.reviewing", files[0])
+		if len(files) > 1 {
+			current = " " + i18n.M("progress.reviewing_many", files[0], len(files))
+		}
+	}
+	return fmt.Sprintf("%s [%s] %d/%d%s", spinner, bar, completed, total, current)
+}
+
+// formatFileStatus 生成单个文件的完成状态行
+func formatFileStatus(info review.ProgressInfo) string {
+	prefix := fmt.Sprintf("[%d/%d]", info.Completed, info.Total)
+	elapsed := info.Elapsed.Round(100 * time.Millisecond)
+	switch info.Status {
+	case review.StatusCached:
+		return fmt.Sprintf("%s ✓ %s (%s)", prefix, info.FilePath, i18n.M("progress.cached"))
+	case review.StatusResumed:
+		return fmt.Sprintf("%s ✓ %s (%s)", prefix, info.FilePath, i18n.M("progress.resumed"))
+	case review.StatusFailed:
+		return fmt.Sprintf("%s ✗ %s (%s): %v", prefix, info.FilePath, elapsed, info.Err)
+	case review.StatusSkipped:
+		return fmt.Sprintf("%s - %s (%s)", prefix, info.FilePath, i18n.M("progress.skipped"))
+	default:
+		return fmt.Sprintf("%s ✓ %s (%s)", prefix, info.FilePath, elapsed)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/icatw/ai-cr-tool/pkg/cli"
+	"github.com/icatw/ai-cr-tool/pkg/git"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/model"
+)
+
+func init() {
+	registerCommand("prompts", "cmd.summary.prompts", runPrompts)
+}
+
+// promptDirs 返回用户目录和仓库中的提示目录，不在仓库中时仓库目录为空
+func promptDirs(dir string) (userDir, repoDir string) {
+	userDir = filepath.Join(os.Getenv("HOME"), ".cr", "prompts")
+	if root, err := git.NewGitClient(dir).RepoRoot(); err == nil {
+		repoDir = filepath.Join(root, ".cr", "prompts")
+	}
+	return userDir, repoDir
+}
+
+// loadReviewPrompt 按 内置 < 用户目录 < 仓库目录 的顺序加载提示包，创建评审提示模板
+func loadReviewPrompt(dir string) (*model.ReviewPrompt, error) {
+	pack, err := model.LoadPromptPack(promptDirs(dir))
+	if err != nil {
+		return nil, err
+	}
+	return pack.ReviewPrompt()
+}
+
+// runPrompts 执行 prompts 子命令
+func runPrompts(args []string) error {
+	if len(args) == 0 {
+		return i18n.Errorf("prompts.usage")
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return i18n.Errorf("cmd.getwd_failed", err)
+	}
+	userDir, repoDir := promptDirs(wd)
+
+	switch args[0] {
+	case "list":
+		pack, err := model.LoadPromptPack(userDir, repoDir)
+		if err != nil {
+			return err
+		}
+		for _, f := range pack.Files() {
+			location := f.Path
+			if location == "" {
+				location = "-"
+			}
+			fmt.Printf("%-24s %-9s %s\n", f.Name, f.Source, location)
+		}
+		return nil
+	case "show":
+		if len(args) != 2 {
+			return i18n.Errorf("prompts.usage_show")
+		}
+		pack, err := model.LoadPromptPack(userDir, repoDir)
+		if err != nil {
+			return err
+		}
+		f, ok := pack.File(args[1])
+		if !ok {
+			return i18n.Errorf("prompts.err.not_found", args[1])
+		}
+		fmt.Print(f.Content)
+		return nil
+	case "export":
+		return runPromptsExport(args[1:], repoDir)
+	case "test":
+		return runPromptsTest(args[1:], userDir, repoDir)
+	default:
+		return i18n.Errorf("prompts.err.unknown_subcommand", args[0])
+	}
+}
+
+// runPromptsExport 将内置的提示文件导出到仓库的提示目录或指定目录，作为自定义的起点
+func runPromptsExport(args []string, repoDir string) error {
+	fs := flag.NewFlagSet("prompts export", flag.ExitOnError)
+	dir := fs.String("dir", repoDir, i18n.M("prompts.flag.dir"))
+	force := fs.Bool("force", false, i18n.M("prompts.flag.force"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dir == "" {
+		return i18n.Errorf("prompts.err.no_repo")
+	}
+	if err := cli.CheckWritable("readonly.export_prompts"); err != nil {
+		return err
+	}
+	written, err := model.ExportPrompts(*dir, fs.Args(), *force)
+	for _, path := range written {
+		fmt.Println(path)
+	}
+	return err
+}
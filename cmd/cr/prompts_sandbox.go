@@ -0,0 +1,257 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/cli"
+	"github.com/icatw/ai-cr-tool/pkg/git"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/model"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// promptTestResult cr prompts test --format json 的输出
+type promptTestResult struct {
+	File       string          `json:"file"`
+	ChangeType string          `json:"change_type"`
+	Messages   []model.Message `json:"messages"`
+	// 以下字段只在发送给模型时输出
+	Model      string            `json:"model,omitempty"`
+	Response   string            `json:"response,omitempty"`
+	Issues     []promptTestIssue `json:"issues,omitempty"`
+	Usage      *model.Usage      `json:"usage,omitempty"`
+	DurationMS int64             `json:"duration_ms,omitempty"`
+}
+
+// promptTestIssue 从模型响应中解析出的问题
+type promptTestIssue struct {
+	Title       string `json:"title"`
+	Line        int    `json:"line"`
+	Severity    string `json:"severity"`
+	Category    string `json:"category,omitempty"`
+	Description string `json:"description"`
+	Suggestion  string `json:"suggestion,omitempty"`
+}
+
+// runPromptsTest 用当前的提示包和 --template 指定的文件为一个文件的差异生成评审提示，
+// 加上 --send 时发送给模型并输出响应、解析出的问题和 token 用量，便于反复调整自定义提示
+func runPromptsTest(args []string, userDir, repoDir string) error {
+	fs := flag.NewFlagSet("prompts test", flag.ExitOnError)
+	file := fs.String("file", "", i18n.M("prompts.flag.file"))
+	diffPath := fs.String("diff", "", i18n.M("prompts.flag.diff"))
+	template := fs.String("template", "", i18n.M("prompts.flag.template"))
+	name := fs.String("name", "base", i18n.M("prompts.flag.name"))
+	send := fs.Bool("send", false, i18n.M("prompts.flag.send"))
+	modelName := fs.String("model", "", i18n.M("cli.flag.model"))
+	lang := fs.String("lang", "", i18n.M("cli.flag.lang"))
+	format := fs.String("format", "text", i18n.M("prompts.flag.format"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" && *diffPath == "" {
+		return i18n.Errorf("prompts.usage_test")
+	}
+	if *format != "text" && *format != "json" {
+		return i18n.Errorf("prompts.err.format", *format)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return i18n.Errorf("cmd.getwd_failed", err)
+	}
+	// 与评审使用相同的配置：术语表、评审语言、注入防护和模型
+	var reviewArgs []string
+	if *modelName != "" {
+		reviewArgs = append(reviewArgs, "--model", *modelName)
+	}
+	if *lang != "" {
+		reviewArgs = append(reviewArgs, "--lang", *lang)
+	}
+	opts, err := cli.ParseArgsIn(wd, reviewArgs)
+	if err != nil {
+		return err
+	}
+	reviewPolicy, err := loadPolicy(opts)
+	if err != nil {
+		return err
+	}
+
+	change, err := promptTestChange(wd, *file, *diffPath)
+	if err != nil {
+		return err
+	}
+
+	pack, err := model.LoadPromptPack(userDir, repoDir)
+	if err != nil {
+		return err
+	}
+	if *template != "" {
+		info, err := os.Stat(*template)
+		if err != nil {
+			return i18n.Errorf("prompts.err.read_template", err)
+		}
+		if info.IsDir() {
+			err = pack.Overlay(*template)
+		} else {
+			err = pack.Replace(*name, *template)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	prompt, err := pack.ReviewPrompt()
+	if err != nil {
+		return err
+	}
+	prompt.HardenInjection = opts.HardenPrompt
+	prompt.Glossary = reviewPolicy.Glossary
+	if reportLang, err := i18n.Parse(opts.Lang); err == nil && reportLang != i18n.Default {
+		prompt.Language = reportLang
+	}
+
+	result := promptTestResult{
+		File:       change.FilePath,
+		ChangeType: change.ChangeType,
+		Messages:   prompt.GeneratePrompt(change.FilePath, change.ChangeType, change.DiffContent),
+	}
+	if *format == "text" {
+		for _, msg := range result.Messages {
+			fmt.Printf("===== %s =====\n%s\n\n", i18n.M("prompts.message_header", msg.Role, len([]rune(msg.Content))), strings.TrimRight(msg.Content, "\n"))
+		}
+	}
+
+	if *send {
+		if err := sendTestPrompt(opts, reviewPolicy.CheckProvider, &result); err != nil {
+			return err
+		}
+		if *format == "text" {
+			printPromptTestResponse(&result)
+		}
+	}
+	if *format == "json" {
+		return printJSON(result)
+	}
+	return nil
+}
+
+// promptTestChange 返回用于生成提示的文件改动：指定 --diff 时从补丁文件中取出 file 的改动（未指定 file 时取第一个文件），
+// 否则取工作区中 file 相对 HEAD 的改动；文件没有改动时把全部内容作为新增文件
+func promptTestChange(dir, file, diffPath string) (*types.FileChange, error) {
+	if diffPath != "" {
+		var data []byte
+		var err error
+		if diffPath == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(diffPath)
+		}
+		if err != nil {
+			return nil, i18n.Errorf("prompts.err.read_patch", err)
+		}
+		changes := git.ParseDiff(string(data))
+		if len(changes) == 0 {
+			return nil, i18n.Errorf("prompts.err.empty_patch", diffPath)
+		}
+		if file == "" {
+			return &changes[0], nil
+		}
+		for i := range changes {
+			if changes[i].FilePath == filepath.ToSlash(file) {
+				return &changes[i], nil
+			}
+		}
+		return nil, i18n.Errorf("prompts.err.file_not_in_patch", diffPath, file)
+	}
+
+	diff, err := git.NewGitClient(dir).GetFileDiff(file)
+	if err == nil && strings.TrimSpace(diff) != "" {
+		if changes := git.ParseDiff(diff); len(changes) > 0 {
+			return &changes[0], nil
+		}
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, i18n.Errorf("prompts.err.read_file", err)
+	}
+	fmt.Fprintln(os.Stderr, i18n.M("prompts.unchanged_file", file))
+	path := filepath.ToSlash(file)
+	if root, err := git.NewGitClient(dir).RepoRoot(); err == nil {
+		if abs, err := filepath.Abs(file); err == nil {
+			if rel, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				path = filepath.ToSlash(rel)
+			}
+		}
+	}
+	return &types.FileChange{FilePath: path, ChangeType: "added", DiffContent: addedFileDiff(path, string(content))}, nil
+}
+
+// addedFileDiff 生成把 content 作为新增文件的统一差异
+func addedFileDiff(path, content string) string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\nnew file mode 100644\n--- /dev/null\n+++ b/%s\n@@ -0,0 +1,%d @@\n", path, path, path, len(lines))
+	for _, line := range lines {
+		b.WriteString("+" + line + "\n")
+	}
+	return b.String()
+}
+
+// sendTestPrompt 把生成的提示发送给模型，结果写入 result；模型提供方需要符合评审策略
+func sendTestPrompt(opts *cli.Options, checkProvider func(string) error, result *promptTestResult) error {
+	client, modelConfig, err := newModelClient(opts.Model, opts.Config.Model.Pools)
+	if err != nil {
+		return err
+	}
+	if err := checkProviders(client, modelConfig, checkProvider); err != nil {
+		return err
+	}
+
+	req := &model.ChatRequest{
+		Model:       modelConfig.Model,
+		Messages:    result.Messages,
+		MaxTokens:   modelConfig.MaxTokens,
+		Temperature: modelConfig.Temperature,
+	}
+	start := time.Now()
+	resp, err := client.Chat(req)
+	if err != nil {
+		return i18n.Errorf("prompts.err.chat", err)
+	}
+	if len(resp.Choices) == 0 {
+		return i18n.Errorf("prompts.err.empty_response")
+	}
+	result.Model = modelConfig.Model
+	result.DurationMS = time.Since(start).Milliseconds()
+	result.Response = resp.Choices[0].Message.Content
+	result.Usage = &resp.Usage
+	for _, issue := range review.ParseIssues(result.File, result.Response, result.File) {
+		result.Issues = append(result.Issues, promptTestIssue{
+			Title:       issue.Title,
+			Line:        issue.Line,
+			Severity:    string(issue.Severity),
+			Category:    string(issue.Category),
+			Description: issue.Description,
+			Suggestion:  issue.Suggestion,
+		})
+	}
+	return nil
+}
+
+// printPromptTestResponse 输出模型的响应、解析出的问题和 token 用量
+func printPromptTestResponse(result *promptTestResult) {
+	duration := (time.Duration(result.DurationMS) * time.Millisecond).Round(time.Millisecond)
+	fmt.Printf("===== %s =====\n%s\n\n", i18n.M("prompts.response_header", result.Model, duration), strings.TrimRight(result.Response, "\n"))
+	fmt.Printf("===== %s =====\n", i18n.M("prompts.issues_header", len(result.Issues)))
+	for _, issue := range result.Issues {
+		fmt.Printf("[%s] %s\n", issue.Severity, i18n.M("prompts.issue_line", issue.Line, issue.Title))
+	}
+	u := result.Usage
+	fmt.Printf("\n%s\n", i18n.M("prompts.usage_tokens", u.PromptTokens, u.CachedTokens(), u.CompletionTokens, u.TotalTokens))
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/bitbucket"
+	"github.com/icatw/ai-cr-tool/pkg/cli"
+	"github.com/icatw/ai-cr-tool/pkg/gerrit"
+	"github.com/icatw/ai-cr-tool/pkg/gitea"
+	"github.com/icatw/ai-cr-tool/pkg/github"
+	"github.com/icatw/ai-cr-tool/pkg/gitlab"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/publish"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+func init() {
+	registerCommand("publish", "cmd.summary.publish", runPublish)
+}
+
+// runPublish 执行 publish 子命令
+func runPublish(args []string) error {
+	fs := flag.NewFlagSet("publish", flag.ExitOnError)
+	input := fs.String("input", "-", i18n.M("publish.flag.input"))
+	to := fs.String("to", "github", i18n.M("publish.flag.to"))
+	repo := fs.String("repo", "", i18n.M("publish.flag.repo"))
+	baseURL := fs.String("url", "", i18n.M("publish.flag.url"))
+	pr := fs.Int("pr", 0, i18n.M("publish.flag.pr"))
+	lang := fs.String("lang", string(i18n.Default), i18n.M("publish.flag.lang"))
+	minSeverity := fs.String("min-severity", string(types.SeverityInfo), i18n.M("publish.flag.min-severity"))
+	reportURL := fs.String("report-url", "", i18n.M("publish.flag.report-url"))
+	commentStyle := fs.String("comment-style", string(review.DefaultStyle), i18n.M("publish.flag.comment-style"))
+	dryRun := fs.Bool("dry-run", false, i18n.M("publish.flag.dry-run"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	severity := types.SeverityLevel(*minSeverity)
+	if severity.Rank() == 0 {
+		return i18n.Errorf("publish.err.min_severity", *minSeverity)
+	}
+	commentLang, err := i18n.Parse(*lang)
+	if err != nil {
+		return err
+	}
+	style, err := review.ParseCommentStyle(*commentStyle)
+	if err != nil {
+		return err
+	}
+
+	report, err := review.LoadJSONReport(*input)
+	if err != nil {
+		return err
+	}
+	issues := report.ToIssues()
+	var inline []types.Issue
+	for _, issue := range issues {
+		if issue.Severity.Rank() >= severity.Rank() {
+			inline = append(inline, issue)
+		}
+	}
+
+	reporter := review.NewReporterWithLang(report.Project, report.Commit, commentLang)
+	reporter.ReportURL = *reportURL
+	reporter.Model = report.Summary.Model
+	reporter.CommentStyle = style
+	summary, err := reporter.Generate(issues, review.GitHubMarkdownFormat)
+	if err != nil {
+		return i18n.Errorf("publish.err.summary", err)
+	}
+
+	switch *to {
+	case "github":
+		client, err := github.NewClientFromEnv(*repo)
+		if err != nil {
+			return err
+		}
+		number := *pr
+		if number == 0 {
+			if number, err = github.PullNumberFromEnv(); err != nil {
+				return err
+			}
+		}
+
+		if *dryRun {
+			files, err := client.ListPullRequestFiles(number)
+			if err != nil {
+				return i18n.Errorf("publish.err.pr_files", number, err)
+			}
+			req, result := publish.GitHubReview(reporter, inline, files, nil, string(summary))
+			for _, c := range req.Comments {
+				fmt.Println(i18n.M("publish.dry_run_position", c.Path, c.Position))
+			}
+			fmt.Println(i18n.M("publish.dry_run_pr", client.Repo(), number, result.Inline, result.Outside))
+			return nil
+		}
+		if err := cli.CheckWritable("readonly.publish"); err != nil {
+			return err
+		}
+
+		result, err := publish.PublishGitHub(client, number, reporter, inline, string(summary))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, i18n.M("publish.published_pr",
+			client.Repo(), number, result.Inline, result.Duplicate, result.Outside))
+		if result.URL != "" {
+			fmt.Println(result.URL)
+		}
+	case "gitlab":
+		client, err := gitlab.NewClientFromEnv(*repo)
+		if err != nil {
+			return err
+		}
+		iid := *pr
+		if iid == 0 {
+			if iid, err = gitlab.MergeRequestIIDFromEnv(); err != nil {
+				return err
+			}
+		}
+
+		if *dryRun {
+			diffs, err := client.ListMergeRequestDiffs(iid)
+			if err != nil {
+				return i18n.Errorf("publish.err.mr_files", iid, err)
+			}
+			discussions, result := publish.GitLabDiscussions(reporter, inline, diffs, nil)
+			for _, d := range discussions {
+				fmt.Printf("%s:%d\n", d.Position.NewPath, d.Position.NewLine)
+			}
+			fmt.Println(i18n.M("publish.dry_run_gitlab", client.Project(), iid, result.Inline, result.Outside))
+			return nil
+		}
+		if err := cli.CheckWritable("readonly.publish"); err != nil {
+			return err
+		}
+
+		result, err := publish.PublishGitLab(client, iid, reporter, inline, string(summary))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, i18n.M("publish.published_gitlab",
+			client.Project(), iid, result.Inline, result.Duplicate, result.Outside))
+		if result.URL != "" {
+			fmt.Println(result.URL)
+		}
+	case "gitea":
+		client, err := gitea.NewClientFromEnv(*baseURL, *repo)
+		if err != nil {
+			return err
+		}
+		number := *pr
+		if number == 0 {
+			// Gitea Actions 提供与 GitHub Actions 相同的事件文件和环境变量
+			if number, err = github.PullNumberFromEnv(); err != nil {
+				return err
+			}
+		}
+
+		if *dryRun {
+			diff, err := client.GetPullRequestDiff(number)
+			if err != nil {
+				return i18n.Errorf("publish.err.pr_diff", number, err)
+			}
+			req, result := publish.GiteaReview(reporter, inline, diff, nil, string(summary))
+			for _, c := range req.Comments {
+				fmt.Printf("%s:%d\n", c.Path, c.NewPosition)
+			}
+			fmt.Println(i18n.M("publish.dry_run_pr", client.Repo(), number, result.Inline, result.Outside))
+			return nil
+		}
+		if err := cli.CheckWritable("readonly.publish"); err != nil {
+			return err
+		}
+
+		result, err := publish.PublishGitea(client, number, reporter, inline, string(summary))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, i18n.M("publish.published_pr",
+			client.Repo(), number, result.Inline, result.Duplicate, result.Outside))
+		if result.URL != "" {
+			fmt.Println(result.URL)
+		}
+	case "bitbucket":
+		client, err := bitbucket.NewClientFromEnv(*baseURL, *repo)
+		if err != nil {
+			return err
+		}
+		id := *pr
+		if id == 0 {
+			if id, err = bitbucket.PullRequestIDFromEnv(); err != nil {
+				return err
+			}
+		}
+
+		if *dryRun {
+			_, annotations, result := publish.BitbucketReport(reporter, inline)
+			for _, a := range annotations {
+				fmt.Printf("%s:%d\n", a.Path, a.Line)
+			}
+			fmt.Println(i18n.M("publish.dry_run_bitbucket", client.Repo(), id, result.Inline, result.Outside))
+			return nil
+		}
+		if err := cli.CheckWritable("readonly.publish"); err != nil {
+			return err
+		}
+
+		result, err := publish.PublishBitbucket(client, id, reporter, inline, publish.BitbucketSummary(reporter, issues))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, i18n.M("publish.published_bitbucket",
+			client.Repo(), id, result.Inline, result.Outside))
+		if result.URL != "" {
+			fmt.Println(result.URL)
+		}
+	case "gerrit":
+		client, err := gerrit.NewClientFromEnv(*baseURL)
+		if err != nil {
+			return err
+		}
+		number := *pr
+		if number == 0 {
+			if number, err = gerrit.ChangeNumberFromEnv(); err != nil {
+				return err
+			}
+		}
+		revision := gerrit.RevisionFromEnv()
+		runID := time.Now().UTC().Format("20060102T150405Z")
+
+		if *dryRun {
+			files, err := client.ListFiles(number, revision)
+			if err != nil {
+				return i18n.Errorf("publish.err.change_files", number, err)
+			}
+			input, result := publish.GerritReview(reporter, issues, inline, files, nil, runID)
+			for file, comments := range input.RobotComments {
+				for _, c := range comments {
+					fmt.Printf("%s:%d\n", file, c.Line)
+				}
+			}
+			fmt.Println(i18n.M("publish.dry_run_gerrit", number, revision, result.Inline, result.Outside))
+			return nil
+		}
+		if err := cli.CheckWritable("readonly.publish"); err != nil {
+			return err
+		}
+
+		result, err := publish.PublishGerrit(client, number, revision, reporter, issues, inline, runID)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, i18n.M("publish.published_gerrit",
+			number, result.Inline, result.Duplicate, result.Outside))
+		if result.URL != "" {
+			fmt.Println(result.URL)
+		}
+	default:
+		return i18n.Errorf("publish.err.target", *to)
+	}
+	return nil
+}
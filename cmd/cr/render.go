@@ -0,0 +1,212 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/cli"
+	"github.com/icatw/ai-cr-tool/pkg/git"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+func init() {
+	registerCommand("render", "cmd.summary.render", runRender)
+}
+
+// reportExtensions 同时生成多种格式时各格式的文件扩展名
+var reportExtensions = map[review.ReportFormat]string{
+	review.MarkdownFormat:       ".md",
+	review.GitHubMarkdownFormat: ".github.md",
+	review.HTMLFormat:           ".html",
+	review.PDFFormat:            ".pdf",
+	review.JSONFormat:           ".json",
+	review.TerminalFormat:       ".txt",
+	review.CodeQualityFormat:    ".codequality.json",
+	review.RDJSONFormat:         ".rdjson",
+	review.RDJSONLFormat:        ".rdjsonl",
+	review.TemplateFormat:       ".txt",
+	review.BadgeFormat:          ".svg",
+	review.BadgeJSONFormat:      ".badge.json",
+}
+
+// runRender 执行 render 子命令，从保存的评审结果重新生成报告，不调用模型
+func runRender(args []string) error {
+	// 评审编号写在选项之前，如 cr render run-20240101-120000 --format pdf
+	ref := review.LatestArtifact
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		ref, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	formats := fs.String("format", string(review.HTMLFormat), i18n.M("render.flag.format"))
+	output := fs.String("output", "", i18n.M("render.flag.output"))
+	lang := fs.String("lang", string(i18n.Default), i18n.M("report.flag.lang"))
+	templatePath := fs.String("report-template", "", i18n.M("cli.flag.report-template"))
+	htmlCDN := fs.Bool("html-cdn", false, i18n.M("cli.flag.html-cdn"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return i18n.Errorf("render.usage")
+	}
+
+	reportLang, err := i18n.Parse(*lang)
+	if err != nil {
+		return err
+	}
+	var list []review.ReportFormat
+	for _, name := range strings.Split(*formats, ",") {
+		format, err := review.ParseReportFormat(strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+		list = append(list, format)
+	}
+	if len(list) > 1 && *output == "" {
+		return i18n.Errorf("render.err.output_required")
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return i18n.Errorf("cmd.getwd_failed", err)
+	}
+	dir := ""
+	if gitDir, err := git.NewGitClient(wd).GitDir(); err == nil {
+		dir = review.ArtifactDir(gitDir)
+	}
+	path, err := review.ResolveArtifact(dir, ref)
+	if err != nil {
+		return err
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return i18n.Errorf("render.err.load", err)
+	}
+	report, err := review.LoadJSONReport(path)
+	if err != nil {
+		return err
+	}
+
+	reporter := report.NewReporter(reportLang)
+	reporter.CDNAssets = *htmlCDN
+	if *templatePath != "" {
+		if reporter.Template, err = review.LoadTemplate(*templatePath, reportLang); err != nil {
+			return err
+		}
+	}
+	issues := report.ToIssues()
+
+	if len(list) == 1 && *output == "" {
+		format, _, fallback := review.PDFFallback(list[0], "")
+		if fallback {
+			fmt.Fprintln(os.Stderr, i18n.M("cli.pdf_fallback"))
+		}
+		content, err := renderFormat(reporter, issues, format, raw)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(content)
+		return err
+	}
+	if err := cli.CheckWritable("readonly.report_file"); err != nil {
+		return err
+	}
+
+	// 各格式互不依赖，并行生成；PDF 需要调用外部程序转换，通常最慢
+	base := *output
+	if len(list) > 1 {
+		base = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, len(list))
+	written := make(map[string]bool)
+	for i, format := range list {
+		path := base
+		if len(list) > 1 {
+			path += reportExtensions[format]
+		}
+		format, path, fallback := review.PDFFallback(format, path)
+		if fallback {
+			fmt.Fprintln(os.Stderr, i18n.M("cli.pdf_fallback_path", path))
+		}
+		// PDF 降级为 HTML 时可能与同时生成的 HTML 报告重复
+		if written[path] {
+			continue
+		}
+		written[path] = true
+		wg.Add(1)
+		go func(i int, format review.ReportFormat, path string) {
+			defer wg.Done()
+			errs[i] = renderFile(reporter, issues, format, path, raw)
+		}(i, format, path)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderFile 生成一种格式的报告并写入 path
+func renderFile(reporter *review.DefaultReporter, issues []types.Issue, format review.ReportFormat, path string, raw []byte) error {
+	content, err := renderFormat(reporter, issues, format, raw)
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return i18n.Errorf("cmd.report_dir_failed", err)
+		}
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return i18n.Errorf("cmd.save_report_failed", err)
+	}
+	fmt.Fprintln(os.Stderr, i18n.M("cmd.report_saved", path))
+	return nil
+}
+
+// renderFormat 生成一种格式的报告，JSON 格式直接返回保存的评审结果
+// 生成报告时会对问题排序，每种格式使用单独的副本，以便并行生成
+func renderFormat(reporter *review.DefaultReporter, issues []types.Issue, format review.ReportFormat, raw []byte) ([]byte, error) {
+	if format == review.JSONFormat {
+		return raw, nil
+	}
+	content, err := reporter.Generate(append([]types.Issue(nil), issues...), format)
+	if err != nil {
+		return nil, i18n.Errorf("cmd.generate_report_failed", err)
+	}
+	return content, nil
+}
+
+// saveArtifact 保存本次评审的结果，之后可以用 cr render 重新生成其他格式的报告，失败时只记录日志
+func saveArtifact(dir string, reporter review.Reporter, issues []types.Issue, opts *cli.Options) {
+	if opts.ReadOnly {
+		return
+	}
+	gitDir, err := git.NewGitClient(dir).GitDir()
+	if err != nil {
+		return
+	}
+	content, err := reporter.Generate(append([]types.Issue(nil), issues...), review.JSONFormat)
+	if err != nil {
+		log.Print(i18n.M("cmd.artifact_failed", err))
+		return
+	}
+	id, err := review.SaveArtifact(review.ArtifactDir(gitDir), content, time.Now())
+	if err != nil {
+		log.Print(i18n.M("cmd.artifact_failed", err))
+		return
+	}
+	if !opts.Quiet {
+		fmt.Fprintln(os.Stderr, i18n.M("cmd.artifact_saved", id))
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+)
+
+func init() {
+	registerCommand("report", "cmd.summary.report", runReport)
+}
+
+// runReport 执行 report 子命令
+func runReport(args []string) error {
+	if len(args) == 0 {
+		return i18n.Errorf("report.usage")
+	}
+	switch args[0] {
+	case "compare":
+		return runReportCompare(args[1:])
+	default:
+		return i18n.Errorf("report.err.unknown_subcommand", args[0])
+	}
+}
+
+// runReportCompare 对比两份 JSON 报告，输出新增、已解决和仍存在的问题
+func runReportCompare(args []string) error {
+	fs := flag.NewFlagSet("report compare", flag.ExitOnError)
+	format := fs.String("format", "", i18n.M("report.flag.format"))
+	lang := fs.String("lang", string(i18n.Default), i18n.M("report.flag.lang"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return i18n.Errorf("report.usage_compare")
+	}
+
+	reportLang, err := i18n.Parse(*lang)
+	if err != nil {
+		return err
+	}
+	reportFormat := review.MarkdownFormat
+	if *format != "" {
+		if reportFormat, err = review.ParseReportFormat(*format); err != nil {
+			return err
+		}
+	} else if isTerminal(os.Stdout) {
+		reportFormat = review.TerminalFormat
+	}
+
+	previous, err := review.LoadJSONReport(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	current, err := review.LoadJSONReport(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	reporter := review.NewReporterWithLang(current.Project, current.Commit, reportLang)
+	reporter.Comparison = review.CompareReports(previous, current)
+	content, err := reporter.GenerateComparison(reportFormat)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(content)
+	return err
+}
@@ -0,0 +1,1088 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/apispec"
+	"github.com/icatw/ai-cr-tool/pkg/calibration"
+	"github.com/icatw/ai-cr-tool/pkg/cli"
+	"github.com/icatw/ai-cr-tool/pkg/coverage"
+	"github.com/icatw/ai-cr-tool/pkg/deps"
+	"github.com/icatw/ai-cr-tool/pkg/git"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/impact"
+	"github.com/icatw/ai-cr-tool/pkg/model"
+	"github.com/icatw/ai-cr-tool/pkg/policy"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/testrun"
+	"github.com/icatw/ai-cr-tool/pkg/tracing"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// maxTestSummaryBytes 提供给模型的测试结果摘要的最大长度
+const maxTestSummaryBytes = 8 << 10
+
+// reviewSession 一次评审的执行结果
+type reviewSession struct {
+	// 实际评审的文件改动，为空表示没有需要评审的改动
+	Changes []types.FileChange
+	// 评审发现的问题
+	Issues []types.Issue
+	// 本次评审使用的策略，用于质量门禁
+	Policy *policy.Policy
+	// 报告语言
+	Lang i18n.Lang
+	// 与当前分支上次评审的对比，没有上次的结果时为 nil
+	Comparison *review.Comparison
+	// Go 仓库中被修改的包的影响范围
+	Impact []impact.PackageImpact
+	// 评审前执行的测试结果，未执行时为 nil
+	Tests *testrun.Result
+	// 变更行的测试覆盖率，未统计时为 nil
+	Coverage *coverage.Report
+	// 按作者分组的问题，未启用 --by-author 时为空
+	Authors []review.AuthorIssues
+	// 达到评审时限时未评审的文件，评审完整时为 nil
+	TimeBox *review.TimeBox
+	// 重试之后仍评审失败的文件
+	Failures []review.FailedFile
+	// 跳过的二进制文件
+	BinaryFiles []string
+	// 评审过程的统计信息
+	Stats *review.ReviewStats
+	// 执行摘要，未启用 --summary 或生成失败时为 nil
+	Summary *review.ExecutiveSummary
+	// 归纳问题得到的主题，未启用 --themes 或归纳失败时为空
+	Themes []review.Theme
+	// 评审的文件中各改动类型的文件数
+	ChangeKinds []review.KindCount
+	// 依赖清单文件中的依赖变更，没有依赖变更或未启用 --deps 时为 nil
+	Dependencies *review.DependencyReview
+	// 各文件的评审缓存命中情况
+	Cache []review.CacheStatus
+	// 本次评审在链路中的根 span，调用方生成报告后结束；未启用链路记录时为 nil
+	Span *tracing.Span
+}
+
+// runReview 按选项评审 dir 所在仓库的改动
+// 进度和提示信息输出到标准错误，opts.Quiet 时只输出错误
+func runReview(opts *cli.Options, dir string) (*reviewSession, error) {
+	span := tracing.Start("review")
+	span.SetAttr("cr.repo", dir)
+	session, err := reviewRepo(opts, dir, span)
+	if err != nil {
+		span.End(err)
+		return nil, err
+	}
+	span.SetAttr("cr.files", len(session.Changes))
+	span.SetAttr("cr.issues", len(session.Issues))
+	session.Span = span
+	return session, nil
+}
+
+// reviewRepo 执行 runReview 的评审过程，git 命令和模型调用记录为 span 的子 span
+func reviewRepo(opts *cli.Options, dir string, span *tracing.Span) (*reviewSession, error) {
+	// 时限从评审开始计算，包含执行测试等准备工作的时间
+	var deadline time.Time
+	if opts.MaxDuration > 0 {
+		deadline = time.Now().Add(opts.MaxDuration)
+	}
+
+	lang, err := i18n.Parse(opts.Lang)
+	if err != nil {
+		return nil, err
+	}
+
+	// 在调用模型之前读取基线报告
+	var baseline *review.JSONReport
+	if opts.Baseline != "" {
+		if baseline, err = review.LoadJSONReport(opts.Baseline); err != nil {
+			return nil, err
+		}
+	}
+
+	// 加载评审策略（仓库配置与组织级策略）
+	reviewPolicy, err := loadPolicy(opts)
+	if err != nil {
+		return nil, i18n.Errorf("cmd.policy_failed", err)
+	}
+	session := &reviewSession{Policy: reviewPolicy, Lang: lang}
+
+	// 初始化Git客户端和代码分析器
+	gitClient := git.NewGitClient(dir)
+	gitClient.SetSpan(span)
+	analyzer := review.NewAnalyzer(gitClient)
+
+	// 获取代码改动
+	var changes []types.FileChange
+	switch {
+	case opts.DiffFile != "":
+		// 评审补丁文件中的改动
+		changes, err = readDiffFile(opts.DiffFile)
+	case opts.Files != "":
+		// 评审指定文件
+		files := strings.Split(opts.Files, ",")
+		changes, err = analyzer.AnalyzeFiles(files)
+	case opts.Staged:
+		// 评审已暂存的改动
+		changes, err = analyzer.AnalyzeStagedChanges()
+	case opts.CommitHash != "":
+		// 评审指定提交
+		changes, err = analyzer.AnalyzeCommit(opts.CommitHash)
+	case opts.CommitRange != "":
+		// 评审提交范围
+		changes, err = analyzer.AnalyzeChanges(opts.CommitRange, "")
+	default:
+		// 默认评审所有未提交的改动
+		changes, err = analyzer.AnalyzeWorkingDirChanges()
+	}
+	if err != nil {
+		return nil, i18n.Errorf("cmd.analyze_failed", err)
+	}
+	// 未跟踪的文件作为新增文件一起评审，指定了文件列表时只保留其中的文件
+	if opts.Untracked {
+		untracked, err := analyzer.AnalyzeUntrackedFiles()
+		if err != nil {
+			return nil, i18n.Errorf("cmd.analyze_failed", err)
+		}
+		if opts.Files != "" {
+			untracked = onlyFiles(untracked, strings.Split(opts.Files, ","))
+		}
+		if len(untracked) > 0 && !opts.Quiet {
+			fmt.Fprintln(os.Stderr, i18n.M("cmd.untracked_included", len(untracked)))
+		}
+		changes = append(changes, untracked...)
+	}
+	if opts.OnlyFiles != nil {
+		changes = onlyFiles(changes, opts.OnlyFiles)
+	}
+
+	// 检查新增的大型二进制文件和资源文件，排除规则不影响该检查
+	rev := targetRevision(opts)
+	assetIssues := reviewPolicy.CheckAssets(changes, lang, func(filePath string) (int64, error) {
+		return gitClient.FileSize(rev, filePath)
+	})
+
+	// 排除策略中指定的路径
+	changes, excluded := reviewPolicy.FilterChanges(changes)
+	if len(excluded) > 0 && !opts.Quiet {
+		fmt.Fprintln(os.Stderr, i18n.M("cmd.excluded", len(excluded)))
+	}
+	// 跳过生成的代码和第三方代码，.gitattributes 中的 linguist 属性与 GitHub 的处理一致
+	if !opts.IncludeGenerated {
+		var generated []types.FileChange
+		changes, generated = filterGenerated(gitClient, opts, changes)
+		if len(generated) > 0 && !opts.Quiet {
+			fmt.Fprintln(os.Stderr, i18n.M("cmd.generated_skipped", len(generated)))
+		}
+	}
+	// 二进制文件的差异对模型没有意义，跳过并在报告中注明
+	changes, binary := skipBinary(gitClient, changes, opts.Verbose)
+	if len(binary) > 0 {
+		if !opts.Quiet {
+			fmt.Fprintln(os.Stderr, i18n.M("cmd.binary_skipped", len(binary)))
+		}
+		for _, change := range binary {
+			session.BinaryFiles = append(session.BinaryFiles, change.FilePath)
+		}
+	}
+	// 内容没有变化的重命名和复制没有需要评审的代码
+	changes, moved := skipPureMoves(changes)
+	if len(moved) > 0 && !opts.Quiet {
+		fmt.Fprintln(os.Stderr, i18n.M("cmd.moves_skipped", len(moved)))
+	}
+	if opts.DeletedFiles == review.DeletedFilesSkip {
+		var deleted []types.FileChange
+		changes, deleted = skipDeleted(changes)
+		if len(deleted) > 0 && !opts.Quiet {
+			fmt.Fprintln(os.Stderr, i18n.M("cmd.deleted_skipped", len(deleted)))
+		}
+	}
+
+	// 依赖变更单独评估，不受按改动类型跳过的影响
+	var dependencyChanges []deps.Change
+	if opts.Dependencies {
+		dependencyChanges = manifestChanges(gitClient, opts, changes)
+	}
+
+	// 接口定义文件的不兼容变更由工具确定性检测，同样不受按改动类型跳过的影响
+	var specChanges map[string][]apispec.Change
+	if opts.APISpec {
+		specChanges = apiSpecChanges(gitClient, opts, changes)
+	}
+
+	// 推断改动类型，策略指定的类型（如只升级依赖）不做AI评审
+	review.ClassifyChanges(changes)
+	changes, skippedKinds := reviewPolicy.FilterKinds(changes)
+	if len(skippedKinds) > 0 && !opts.Quiet {
+		fmt.Fprintln(os.Stderr, i18n.M("cmd.skipped_kinds", len(skippedKinds), kindList(skippedKinds)))
+	}
+
+	// 交互选择需要评审的改动块
+	if opts.Select && len(changes) > 0 {
+		if !isTerminal(os.Stdin) {
+			return nil, i18n.Errorf("cmd.select_tty")
+		}
+		changes, err = selectHunks(os.Stdin, os.Stderr, changes)
+		if err != nil {
+			return nil, i18n.Errorf("cmd.select_failed", err)
+		}
+	}
+
+	// 没有需要模型评审的改动时仍然保留大文件问题，交给报告和门禁处理
+	if len(changes) == 0 && len(dependencyChanges) == 0 {
+		session.Issues = assetIssues
+		return session, nil
+	}
+
+	// 应用安全上限，避免意外评审超大改动
+	changes, skipped := review.ApplyLimits(changes, opts.Limits())
+	if len(skipped) > 0 {
+		fmt.Fprint(os.Stderr, review.SkippedSummary(skipped))
+	}
+	if len(changes) == 0 && len(skipped) > 0 {
+		return nil, i18n.Errorf("cmd.all_over_limits")
+	}
+
+	// 同一仓库中已有评审在运行时等待其结束，之后相同的改动可以直接命中它写入的缓存
+	var lock *review.RunLock
+	if opts.LockTimeout > 0 {
+		lock = lockRepo(gitClient, opts)
+	}
+	// 评审结果写入缓存后立即释放，这里只处理提前返回的情况
+	defer lock.Release()
+
+	// 初始化缓存
+	reviewCache := newReviewCache(opts.CacheMemoryMB, opts.CacheWriteQueue, opts.ReadOnly)
+	if reviewCache != nil {
+		// 提前返回时停止写入线程，正常情况下在评审完成后关闭
+		defer reviewCache.Close()
+	}
+
+	// 初始化AI模型客户端
+	modelClient, modelConfig, err := newModelClient(opts.Model, opts.Config.Model.Pools)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkProviders(modelClient, modelConfig, reviewPolicy.CheckProvider); err != nil {
+		return nil, err
+	}
+	// 归纳主题可以使用单独的模型，同样需要符合策略
+	var themeClient model.ModelClient
+	var themeConfig *model.Config
+	if opts.Themes && opts.ThemesModel != "" && opts.ThemesModel != opts.Model {
+		themeClient, themeConfig, err = newModelClient(opts.ThemesModel, opts.Config.Model.Pools)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkProviders(themeClient, themeConfig, reviewPolicy.CheckProvider); err != nil {
+			return nil, err
+		}
+	}
+
+	// 创建评审提示模板
+	prompt, err := loadReviewPrompt(dir)
+	if err != nil {
+		return nil, err
+	}
+	prompt.HardenInjection = opts.HardenPrompt
+	prompt.Glossary = reviewPolicy.Glossary
+	if opts.HistoryCommits > 0 {
+		prompt.History = recentHistory(gitClient, changes, historyRevision(opts), opts.HistoryCommits)
+	}
+	if len(specChanges) > 0 {
+		prompt.APISpecs = make(map[string][]string, len(specChanges))
+		for file, found := range specChanges {
+			prompt.APISpecs[file] = apispec.Summaries(found)
+		}
+	}
+	if opts.DeletedFiles == review.DeletedFilesReferences {
+		prompt.Deletions = deletedReferences(gitClient, opts, changes)
+	}
+	if lang != i18n.Default {
+		prompt.Language = lang
+	}
+
+	// 需要统计覆盖率但未提供覆盖率文件时，执行测试生成
+	coverProfile := opts.CoverageProfile
+	if opts.Coverage && coverProfile == "" && opts.TestCommand == "" {
+		tmp, err := os.CreateTemp("", "cr-cover-*.out")
+		if err == nil {
+			tmp.Close()
+			coverProfile = tmp.Name()
+			defer os.Remove(coverProfile)
+		}
+	}
+
+	// 执行测试，结果作为评审的参考信息
+	if opts.RunTests || (coverProfile != "" && coverProfile != opts.CoverageProfile) {
+		session.Tests = runTests(gitClient, changes, opts, coverProfile)
+		if session.Tests != nil {
+			prompt.TestResults = session.Tests.Summary(maxTestSummaryBytes)
+		}
+	}
+
+	// 创建评审引擎
+	engineOpts := review.EngineOptions{
+		ModelConfig: modelConfig,
+		Prompt:      prompt,
+		Cache:       reviewCache,
+		Concurrency: opts.Concurrency,
+		Deadline:    deadline,
+		Limiter:     opts.Limiter,
+		Verbose:     opts.Verbose,
+		Span:        span,
+		ThemeClient: themeClient,
+		ThemeModel:  themeConfig,
+	}
+	// 评审结果中提到的仓库文件不会被当作臆造的路径替换
+	if files, err := gitClient.ListFiles(); err == nil {
+		engineOpts.RepoFiles = files
+	}
+
+	// 每完成一个文件记录一次断点，进程中断后可用 --resume 继续；只读模式下不记录
+	var checkpoint *review.Checkpoint
+	if gitDir, err := gitClient.GitDir(); err == nil && !opts.ReadOnly {
+		checkpointPath := filepath.Join(gitDir, "ai-cr-tool", "checkpoint.json")
+		checkpoint, err = review.OpenCheckpoint(checkpointPath, review.RunKey(modelConfig.Model, prompt, changes), opts.Resume)
+		if err != nil {
+			log.Print(i18n.M("cmd.checkpoint_restart", err))
+			checkpoint, _ = review.OpenCheckpoint(checkpointPath, review.RunKey(modelConfig.Model, prompt, changes), false)
+		}
+		if opts.Resume && !opts.Quiet {
+			fmt.Fprintln(os.Stderr, i18n.M("cmd.resumed", checkpoint.Len()))
+		}
+		engineOpts.Checkpoint = checkpoint
+	}
+
+	// 非静默模式下在标准错误输出渲染评审进度
+	stopProgress := func() {}
+	if !opts.Quiet {
+		engineOpts.Progress, stopProgress = startProgress(os.Stderr)
+	}
+	engine := review.NewEngine(modelClient, engineOpts)
+	if opts.Overview {
+		prompt.Overview = repoOverview(gitClient, engine, !opts.ReadOnly)
+	}
+
+	// 并发评审所有改动文件
+	reviewStart := time.Now()
+	modelIssues := engine.Review(changes)
+	// 等待评审结果全部写入磁盘缓存
+	if reviewCache != nil {
+		if err := reviewCache.Close(); err != nil {
+			log.Print(i18n.M("cmd.cache_write_failed", err))
+		}
+	}
+	lock.Release()
+	if opts.Calibrate {
+		modelIssues = calibrateIssues(gitClient, modelConfig.Model, modelIssues)
+	}
+	// 标题模板只用于模型发现的问题，工具检测的问题（如大文件）保持固定标题
+	if opts.TitleTemplate != "" {
+		if tmpl, err := review.ParseTitleTemplate(opts.TitleTemplate); err == nil {
+			tmpl.Apply(modelIssues)
+		}
+	}
+	session.Issues = append(modelIssues, assetIssues...)
+	session.Changes = changes
+	session.Failures = engine.Failed()
+	session.Cache = engine.CacheStatuses()
+	stopProgress()
+	reviewElapsed := time.Since(reviewStart)
+	// 达到时限时保留断点，之后可以用 --resume 评审剩余的文件
+	if skipped := engine.Skipped(); len(skipped) > 0 {
+		session.TimeBox = &review.TimeBox{Limit: opts.MaxDuration, Skipped: skipped}
+		fmt.Fprint(os.Stderr, i18n.M("cmd.time_limit", opts.MaxDuration, len(skipped)))
+		if checkpoint != nil {
+			fmt.Fprint(os.Stderr, i18n.M("cmd.time_limit_resume"))
+		}
+		fmt.Fprintln(os.Stderr)
+	} else if checkpoint != nil {
+		if err := checkpoint.Remove(); err != nil {
+			log.Print(i18n.M("cmd.checkpoint_remove_failed", err))
+		}
+	}
+
+	// 统计变更行的测试覆盖率，未覆盖的新增代码作为警告加入问题列表
+	if coverProfile != "" {
+		session.Coverage = analyzeCoverage(gitClient, changes, coverProfile)
+		if session.Coverage != nil {
+			session.Issues = append(session.Issues, coverage.UncoveredIssues(session.Coverage)...)
+		}
+	} else if opts.Coverage {
+		log.Print(i18n.M("cmd.coverage_profile_required"))
+	}
+
+	// 分析 Go 包的影响范围，高影响改动作为警告加入问题列表
+	if opts.Impact {
+		session.Impact = analyzeImpact(gitClient, changes)
+		session.Issues = append(session.Issues, impact.HighImpactIssues(session.Impact, opts.ImpactThreshold)...)
+	}
+
+	// 接口定义的不兼容变更与代码问题一起报告
+	specFiles := make([]string, 0, len(specChanges))
+	for file := range specChanges {
+		specFiles = append(specFiles, file)
+	}
+	sort.Strings(specFiles)
+	for _, file := range specFiles {
+		session.Issues = append(session.Issues, apispec.BreakingIssues(specChanges[file])...)
+	}
+
+	// 评估依赖变更的不兼容变更和供应链风险，失败时仍在报告中列出依赖变更
+	if len(dependencyChanges) > 0 {
+		dependencyStart := time.Now()
+		session.Dependencies, err = engine.ReviewDependencies(dependencyChanges)
+		if err != nil {
+			log.Print(i18n.M("cmd.skip_dependency_review", err))
+		}
+		reviewElapsed += time.Since(dependencyStart)
+	}
+
+	// 为所有问题计算稳定的内容指纹，供各输出格式跨次评审去重和跟踪
+	review.AssignFingerprints(session.Issues, changes)
+
+	// 按作者分组问题
+	if opts.ByAuthor {
+		session.Authors = attributeAuthors(gitClient, opts, changes, session.Issues)
+	}
+
+	// 与当前分支上次的评审结果对比，并保存本次结果；指定了基线报告时与基线对比
+	if opts.Compare {
+		session.Comparison = compareWithLastRun(gitClient, changes, session.Issues, !opts.ReadOnly)
+	}
+	if baseline != nil {
+		session.Comparison = review.CompareBaseline(baseline, changedFiles(changes), session.Issues)
+	}
+
+	// 汇总所有问题生成执行摘要，失败时只记录日志，不影响评审结果
+	if opts.Summary {
+		summaryStart := time.Now()
+		summary, err := engine.Summarize(session.Issues)
+		if err != nil {
+			log.Print(i18n.M("cmd.skip_summary", err))
+		}
+		// 译文失败时只保留原文
+		if summary != nil && opts.SummaryLang != "" {
+			if err := engine.TranslateSummary(summary, i18n.Lang(opts.SummaryLang)); err != nil {
+				log.Print(i18n.M("cmd.skip_summary_translation", err))
+			}
+		}
+		session.Summary = summary
+		reviewElapsed += time.Since(summaryStart)
+	}
+	// 把问题归纳为主题，失败时只记录日志
+	if opts.Themes {
+		themesStart := time.Now()
+		themes, err := engine.GroupThemes(session.Issues)
+		if err != nil {
+			log.Print(i18n.M("cmd.skip_themes", err))
+		}
+		session.Themes = themes
+		reviewElapsed += time.Since(themesStart)
+	}
+	session.Stats = reviewStats(opts, changes, engine, reviewElapsed)
+	session.ChangeKinds = review.CountKinds(changes)
+
+	return session, nil
+}
+
+// filterGenerated 过滤掉生成的代码和第三方代码，返回保留和跳过的文件改动
+// 识别规则见 review.DetectGenerated；读取 .gitattributes 失败时（如评审的补丁不在仓库中）只按文件头和目录识别
+func filterGenerated(gitClient *git.GitClient, opts *cli.Options, changes []types.FileChange) ([]types.FileChange, []types.FileChange) {
+	paths := make([]string, len(changes))
+	for i, change := range changes {
+		paths[i] = change.FilePath
+	}
+	attrs, err := gitClient.LinguistAttributes(paths)
+	if err != nil && opts.Verbose {
+		log.Print(i18n.M("cmd.linguist_failed", err))
+	}
+	rev := targetRevision(opts)
+	read := func(filePath string) (string, error) {
+		return readRevision(gitClient, rev, filePath)
+	}
+	kept := make([]types.FileChange, 0, len(changes))
+	var skipped []types.FileChange
+	for _, change := range changes {
+		if reason := review.DetectGenerated(change, attrs[change.FilePath], read); reason != "" {
+			if opts.Verbose {
+				log.Print(i18n.M("cmd.generated_file", change.FilePath, reason))
+			}
+			skipped = append(skipped, change)
+			continue
+		}
+		kept = append(kept, change)
+	}
+	return kept, skipped
+}
+
+// skipBinary 去掉二进制文件，返回其余的改动和去掉的改动；读取 .gitattributes 失败时只按差异内容判断
+func skipBinary(gitClient *git.GitClient, changes []types.FileChange, verbose bool) ([]types.FileChange, []types.FileChange) {
+	paths := make([]string, len(changes))
+	for i, change := range changes {
+		paths[i] = change.FilePath
+	}
+	marked, err := gitClient.BinaryAttributes(paths)
+	if err != nil && verbose {
+		log.Print(i18n.M("cmd.binary_attributes_failed", err))
+	}
+	return review.SkipBinary(changes, marked)
+}
+
+// skipPureMoves 去掉内容没有变化的重命名和复制，返回其余的改动和去掉的改动
+func skipPureMoves(changes []types.FileChange) ([]types.FileChange, []types.FileChange) {
+	var kept, moved []types.FileChange
+	for _, change := range changes {
+		if change.PureMove() {
+			moved = append(moved, change)
+		} else {
+			kept = append(kept, change)
+		}
+	}
+	return kept, moved
+}
+
+// skipDeleted 分出被删除的文件
+func skipDeleted(changes []types.FileChange) ([]types.FileChange, []types.FileChange) {
+	var kept, deleted []types.FileChange
+	for _, change := range changes {
+		if change.ChangeType == "deleted" {
+			deleted = append(deleted, change)
+		} else {
+			kept = append(kept, change)
+		}
+	}
+	return kept, deleted
+}
+
+// deletedReferences 在评审的目标版本中搜索被删除文件的残留引用，返回评审提示中的说明，键为文件路径
+// 搜索失败时只记录日志，被删除的文件按普通差异评审
+func deletedReferences(gitClient *git.GitClient, opts *cli.Options, changes []types.FileChange) map[string][]string {
+	found, err := review.FindDeletedReferences(gitClient, targetRevision(opts), changes)
+	if err != nil {
+		log.Print(i18n.M("cmd.deleted_references_failed", err))
+		return nil
+	}
+	result := make(map[string][]string, len(found))
+	for file, refs := range found {
+		result[file] = review.DeletedReferenceSummaries(refs)
+	}
+	return result
+}
+
+// reviewStats 汇总评审的统计信息，配置了模型单价时估算费用
+func reviewStats(opts *cli.Options, changes []types.FileChange, engine *review.Engine, elapsed time.Duration) *review.ReviewStats {
+	usage := engine.Usage()
+	stats := &review.ReviewStats{
+		Model:            engine.ModelName(),
+		ChangedLines:     review.ChangedLines(changes),
+		Duration:         elapsed,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		CachedTokens:     usage.CachedTokens(),
+	}
+	if pricing := opts.Config.Model.Pricing; pricing != nil {
+		stats.Cost = pricing.Cost(usage.PromptTokens, stats.CachedTokens, usage.CompletionTokens)
+		stats.CostSaved = pricing.Saved(stats.CachedTokens)
+		stats.Currency = pricing.Currency
+		if stats.Currency == "" {
+			stats.Currency = "USD"
+		}
+	}
+	// 详细模式下输出提示缓存的命中情况，便于确认缓存优化是否生效
+	if opts.Verbose && usage.PromptTokens > 0 {
+		log.Print(i18n.M("cmd.prompt_cache_hit", stats.CachedTokens, usage.PromptTokens,
+			float64(stats.CachedTokens)*100/float64(usage.PromptTokens)))
+		if stats.CostSaved > 0 {
+			log.Print(i18n.M("cmd.prompt_cache_saved", stats.CostSaved, stats.Currency))
+		}
+	}
+	return stats
+}
+
+// lockRepo 获取仓库的评审锁，其他评审持有锁时等待其结束；只读模式下只等待不加锁
+// 获取失败或等待超时时不加锁继续评审，返回 nil
+func lockRepo(gitClient *git.GitClient, opts *cli.Options) *review.RunLock {
+	gitDir, err := gitClient.GitDir()
+	if err != nil {
+		return nil
+	}
+	path := filepath.Join(gitDir, "ai-cr-tool", "review.lock")
+	onWait := func(holder review.LockHolder) {
+		if !opts.Quiet {
+			fmt.Fprintln(os.Stderr, i18n.M("cmd.lock_waiting", holder.PID, holder.StartedAt.Format("15:04:05")))
+		}
+	}
+	if opts.ReadOnly {
+		err = review.WaitRunLock(path, opts.LockTimeout, onWait)
+	} else {
+		var lock *review.RunLock
+		if lock, err = review.AcquireRunLock(path, opts.LockTimeout, onWait); err == nil {
+			return lock
+		}
+	}
+	if err == review.ErrLockTimeout {
+		log.Print(i18n.M("cmd.lock_timeout"))
+	} else if err != nil {
+		log.Print(i18n.M("cmd.lock_failed", err))
+	}
+	return nil
+}
+
+// calibrateIssues 按模型的反馈记录过滤模型发现的问题，精确率低的模型只保留较高级别的问题
+// 读取反馈记录失败时只记录日志，不影响评审
+func calibrateIssues(gitClient *git.GitClient, modelName string, issues []types.Issue) []types.Issue {
+	gitDir, err := gitClient.GitDir()
+	if err != nil {
+		return issues
+	}
+	store, err := calibration.Open(calibration.Path(gitDir))
+	if err != nil {
+		log.Print(i18n.M("cmd.skip_calibration", err))
+		return issues
+	}
+	stats := store.For(modelName)
+	threshold := stats.Threshold()
+	kept, dropped := calibration.Filter(issues, threshold)
+	if threshold != "" {
+		fmt.Fprintln(os.Stderr, i18n.M("cmd.calibrated", modelName, stats.Precision()*100, stats.Published, threshold, dropped))
+	}
+	return kept
+}
+
+// repoOverview 返回仓库概览：目录结构没有明显变化时使用缓存，否则调用模型重新生成，save 为 true 时写回缓存
+// 模型调用失败时使用目录结构提纲，获取文件列表失败时只记录日志，不附带概览
+func repoOverview(gitClient *git.GitClient, engine *review.Engine, save bool) string {
+	gitDir, err := gitClient.GitDir()
+	if err != nil {
+		log.Print(i18n.M("cmd.skip_overview", err))
+		return ""
+	}
+	files, err := gitClient.ListFiles()
+	if err != nil {
+		log.Print(i18n.M("cmd.skip_overview", err))
+		return ""
+	}
+	dirs := review.DirCounts(files)
+	path := review.OverviewPath(gitDir)
+	cached, err := review.LoadOverview(path)
+	if err != nil {
+		log.Printf("%v\n", err)
+	}
+	if cached != nil && !cached.Stale(dirs) {
+		return cached.Content
+	}
+
+	// 关键文件从 HEAD 读取，概览描述的是已提交的代码
+	tracked := make(map[string]bool, len(files))
+	for _, file := range files {
+		tracked[file] = true
+	}
+	excerpts := make(map[string]string)
+	for _, file := range review.OverviewFiles {
+		if !tracked[file] {
+			continue
+		}
+		if content, err := gitClient.GetFileContent(file, "HEAD"); err == nil {
+			excerpts[file] = content
+		}
+	}
+	outline := review.RepoOutline(files)
+	content, err := engine.GenerateOverview(outline, excerpts)
+	if err != nil {
+		log.Print(i18n.M("cmd.overview_fallback", err))
+		return outline
+	}
+	if save {
+		overview := &review.RepoOverview{Content: content, Model: engine.ModelName(), Dirs: dirs, GeneratedAt: time.Now()}
+		if err := overview.Save(path); err != nil {
+			log.Print(i18n.M("cmd.overview_save_failed", err))
+		}
+	}
+	return content
+}
+
+// changedFiles 返回文件改动的路径列表
+func changedFiles(changes []types.FileChange) []string {
+	files := make([]string, 0, len(changes))
+	for _, change := range changes {
+		files = append(files, change.FilePath)
+	}
+	return files
+}
+
+// kindList 返回文件改动的类型列表，如 "dependency 2, docs 1"
+func kindList(changes []types.FileChange) string {
+	var parts []string
+	for _, kc := range review.CountKinds(changes) {
+		parts = append(parts, fmt.Sprintf("%s %d", kc.Kind, kc.Files))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// maxHistorySubject 提交说明保留的最大字符数
+const maxHistorySubject = 120
+
+// recentHistory 获取每个文件在 rev 及之前最近的 n 个提交说明，新增的文件没有历史，获取失败时只记录日志
+func recentHistory(gitClient *git.GitClient, changes []types.FileChange, rev string, n int) map[string][]string {
+	history := make(map[string][]string)
+	for _, change := range changes {
+		if change.ChangeType == "added" {
+			continue
+		}
+		// 重命名或复制的文件在 rev 中还是原来的路径
+		commits, err := gitClient.RecentCommits(rev, change.SourcePath(), n)
+		if err != nil {
+			log.Printf("%v\n", err)
+			continue
+		}
+		for _, commit := range commits {
+			subject := []rune(commit.Subject)
+			if len(subject) > maxHistorySubject {
+				subject = append(subject[:maxHistorySubject], '…')
+			}
+			history[change.FilePath] = append(history[change.FilePath],
+				fmt.Sprintf("%s %s %s: %s", commit.Date, commit.Hash, commit.Author, string(subject)))
+		}
+	}
+	return history
+}
+
+// historyRevision 返回读取提交历史的版本：评审提交或提交范围时为改动之前的版本，其余情况为 HEAD
+func historyRevision(opts *cli.Options) string {
+	switch {
+	case opts.Files != "", opts.Staged:
+		return "HEAD"
+	case opts.CommitHash != "":
+		return opts.CommitHash + "^"
+	case opts.CommitRange != "":
+		from, _, ok := strings.Cut(strings.Replace(opts.CommitRange, "...", "..", 1), "..")
+		if !ok || from == "" {
+			return "HEAD"
+		}
+		return from
+	default:
+		return "HEAD"
+	}
+}
+
+// onlyFiles 保留指定文件中的改动
+func onlyFiles(changes []types.FileChange, files []string) []types.FileChange {
+	keep := make(map[string]bool, len(files))
+	for _, file := range files {
+		keep[file] = true
+	}
+	var kept []types.FileChange
+	for _, change := range changes {
+		if keep[change.FilePath] {
+			kept = append(kept, change)
+		}
+	}
+	return kept
+}
+
+// readDiffFile 读取并解析补丁文件，path 为 - 时从标准输入读取
+func readDiffFile(path string) ([]types.FileChange, error) {
+	var content []byte
+	var err error
+	if path == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取补丁文件失败: %v", err)
+	}
+	return git.ParseDiff(string(content)), nil
+}
+
+// targetRevision 返回评审目标版本，用于读取改动后的文件
+// 工作区和指定文件模式返回空字符串，暂存区模式返回 ":"
+func targetRevision(opts *cli.Options) string {
+	switch {
+	case opts.Files != "":
+		return ""
+	case opts.Staged:
+		return ":"
+	case opts.CommitHash != "":
+		return opts.CommitHash
+	case opts.CommitRange != "":
+		_, to, ok := strings.Cut(strings.Replace(opts.CommitRange, "...", "..", 1), "..")
+		if !ok {
+			// 单个版本时与工作区比较
+			return ""
+		}
+		if to == "" {
+			return "HEAD"
+		}
+		return to
+	default:
+		return ""
+	}
+}
+
+// newSessionReporter 创建包含本次评审附加信息的报告生成器
+func newSessionReporter(project string, session *reviewSession, opts *cli.Options) (*review.DefaultReporter, error) {
+	reporter := review.NewReporterWithLang(project, "HEAD", session.Lang)
+	reporter.Comparison = session.Comparison
+	reporter.Impact = session.Impact
+	reporter.Tests = session.Tests
+	reporter.Coverage = session.Coverage
+	reporter.Authors = session.Authors
+	reporter.CDNAssets = opts.HTMLCDN
+	reporter.Stats = session.Stats
+	reporter.TimeBox = session.TimeBox
+	reporter.Failures = session.Failures
+	reporter.BinaryFiles = session.BinaryFiles
+	reporter.Cache = session.Cache
+	reporter.Summary = session.Summary
+	reporter.Themes = session.Themes
+	reporter.ChangeKinds = session.ChangeKinds
+	reporter.Dependencies = session.Dependencies
+	reporter.ReportURL = opts.ReportURL
+	reporter.SnippetWidth = opts.SnippetWidth
+	reporter.CommentStyle, _ = review.ParseCommentStyle(opts.CommentStyle)
+	reporter.Changes = session.Changes
+	reporter.Span = session.Span
+	if opts.ReportTemplate != "" {
+		tmpl, err := review.LoadTemplate(opts.ReportTemplate, session.Lang)
+		if err != nil {
+			return nil, err
+		}
+		reporter.Template = tmpl
+	}
+	return reporter, nil
+}
+
+// writeReport 生成指定格式的报告并写入文件
+func writeReport(reporter review.Reporter, issues []types.Issue, format review.ReportFormat, path string) error {
+	content, err := reporter.Generate(issues, format)
+	if err != nil {
+		return i18n.Errorf("cmd.generate_report_failed", err)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return i18n.Errorf("cmd.report_dir_failed", err)
+		}
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return i18n.Errorf("cmd.save_report_failed", err)
+	}
+	return nil
+}
+
+// compareWithLastRun 与当前分支上次的评审结果对比，save 为 true 时保存本次结果，失败时只记录日志
+func compareWithLastRun(gitClient *git.GitClient, changes []types.FileChange, issues []types.Issue, save bool) *review.Comparison {
+	gitDir, err := gitClient.GitDir()
+	if err != nil {
+		return nil
+	}
+	branch, err := gitClient.CurrentBranch()
+	if err != nil {
+		branch = "HEAD"
+	}
+	path := review.RunRecordPath(gitDir, branch)
+
+	files := make([]string, 0, len(changes))
+	for _, change := range changes {
+		files = append(files, change.FilePath)
+	}
+
+	previous, err := review.LoadRunRecord(path)
+	if err != nil {
+		log.Printf("%v\n", err)
+	}
+	var comparison *review.Comparison
+	if previous != nil {
+		comparison = review.Compare(previous, files, issues)
+	}
+
+	if !save {
+		return comparison
+	}
+	if err := review.SaveRunRecord(path, previous.Merge(time.Now(), files, issues)); err != nil {
+		log.Printf("%v\n", err)
+	}
+	return comparison
+}
+
+// analyzeImpact 分析 Go 仓库中被修改的包的影响范围，非 Go 仓库或分析失败时返回 nil
+func analyzeImpact(gitClient *git.GitClient, changes []types.FileChange) []impact.PackageImpact {
+	root, err := gitClient.RepoRoot()
+	if err != nil || !impact.IsGoModule(root) {
+		return nil
+	}
+
+	files := make([]string, 0, len(changes))
+	for _, change := range changes {
+		files = append(files, change.FilePath)
+	}
+	impacts, err := impact.Analyze(root, files)
+	if err != nil {
+		log.Print(i18n.M("cmd.impact_failed", err))
+		return nil
+	}
+	return impacts
+}
+
+// manifestChanges 比较依赖清单文件改动前后的内容，返回其中的依赖变更，读取或解析失败的文件只记录日志
+func manifestChanges(gitClient *git.GitClient, opts *cli.Options, changes []types.FileChange) []deps.Change {
+	base, target := historyRevision(opts), targetRevision(opts)
+	var result []deps.Change
+	for _, change := range changes {
+		if deps.Ecosystem(change.FilePath) == "" {
+			continue
+		}
+		var oldContent, newContent string
+		var err error
+		// 复制的文件按新增处理，重命名的文件与原文件比较
+		if change.ChangeType != "added" && change.ChangeType != "copied" {
+			if oldContent, err = gitClient.GetFileContent(change.SourcePath(), base); err != nil {
+				log.Print(i18n.M("cmd.read_old_failed", change.FilePath, err))
+				continue
+			}
+		}
+		if change.ChangeType != "deleted" {
+			if newContent, err = readRevision(gitClient, target, change.FilePath); err != nil {
+				log.Print(i18n.M("cmd.read_new_failed", change.FilePath, err))
+				continue
+			}
+		}
+		found, err := deps.Diff(change.FilePath, oldContent, newContent)
+		if err != nil {
+			log.Printf("%v\n", err)
+			continue
+		}
+		result = append(result, found...)
+	}
+	return result
+}
+
+// apiSpecChanges 比较接口定义文件改动前后的内容，返回各文件中的不兼容变更，键为文件路径
+// 没有不兼容变更的接口定义文件对应空列表；读取或解析失败的文件只记录日志
+func apiSpecChanges(gitClient *git.GitClient, opts *cli.Options, changes []types.FileChange) map[string][]apispec.Change {
+	base, target := historyRevision(opts), targetRevision(opts)
+	result := make(map[string][]apispec.Change)
+	for _, change := range changes {
+		if !apispec.Candidate(change.FilePath) {
+			continue
+		}
+		var oldContent, newContent string
+		var err error
+		// 复制的文件按新增处理，重命名的文件与原文件比较
+		if change.ChangeType != "added" && change.ChangeType != "copied" {
+			if oldContent, err = gitClient.GetFileContent(change.SourcePath(), base); err != nil {
+				log.Print(i18n.M("cmd.read_old_failed", change.FilePath, err))
+				continue
+			}
+		}
+		if change.ChangeType != "deleted" {
+			if newContent, err = readRevision(gitClient, target, change.FilePath); err != nil {
+				log.Print(i18n.M("cmd.read_new_failed", change.FilePath, err))
+				continue
+			}
+		}
+		if apispec.Kind(change.FilePath, newContent) == "" && apispec.Kind(change.FilePath, oldContent) == "" {
+			continue
+		}
+		found, err := apispec.Diff(change.FilePath, oldContent, newContent)
+		if err != nil {
+			log.Printf("%v\n", err)
+			continue
+		}
+		result[change.FilePath] = found
+	}
+	return result
+}
+
+// readRevision 读取文件在 targetRevision 返回的版本中的内容，rev 为空时读取工作区中的文件
+func readRevision(gitClient *git.GitClient, rev, filePath string) (string, error) {
+	if rev != "" {
+		// 暂存区的对象名为 ":路径"
+		return gitClient.GetFileContent(filePath, strings.TrimSuffix(rev, ":"))
+	}
+	root, err := gitClient.RepoRoot()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(filepath.Join(root, filePath))
+	if err != nil {
+		return "", i18n.Errorf("cmd.read_file_failed", err)
+	}
+	return string(data), nil
+}
+
+// runTests 执行测试命令，未配置命令时在 Go 仓库中对改动的包执行 go test，无法执行时返回 nil
+// coverProfile 不为空时，go test 会将覆盖率写入该文件
+func runTests(gitClient *git.GitClient, changes []types.FileChange, opts *cli.Options, coverProfile string) *testrun.Result {
+	root, err := gitClient.RepoRoot()
+	if err != nil {
+		log.Printf("%v\n", err)
+		return nil
+	}
+
+	var result *testrun.Result
+	switch {
+	case opts.TestCommand != "":
+		if !opts.Quiet {
+			fmt.Fprintln(os.Stderr, i18n.M("cmd.running_test_command", opts.TestCommand))
+		}
+		result, err = testrun.RunCommand(root, opts.TestCommand, opts.TestTimeout)
+	case impact.IsGoModule(root):
+		files := make([]string, 0, len(changes))
+		for _, change := range changes {
+			files = append(files, change.FilePath)
+		}
+		packages := testrun.GoPackages(root, files)
+		if len(packages) == 0 {
+			return nil
+		}
+		if !opts.Quiet {
+			fmt.Fprintln(os.Stderr, i18n.M("cmd.running_go_tests", len(packages)))
+		}
+		var extraArgs []string
+		if coverProfile != "" {
+			extraArgs = append(extraArgs, "-coverprofile="+coverProfile)
+		}
+		result, err = testrun.RunGo(root, packages, opts.TestTimeout, extraArgs...)
+	default:
+		log.Print(i18n.M("cmd.no_test_command"))
+		return nil
+	}
+	if err != nil {
+		log.Printf("%v\n", err)
+		return nil
+	}
+	return result
+}
+
+// analyzeCoverage 根据覆盖率文件统计变更行的覆盖率，失败时返回 nil
+func analyzeCoverage(gitClient *git.GitClient, changes []types.FileChange, profilePath string) *coverage.Report {
+	root, err := gitClient.RepoRoot()
+	if err != nil {
+		log.Printf("%v\n", err)
+		return nil
+	}
+	profile, err := coverage.LoadProfile(profilePath, root)
+	if err != nil {
+		log.Printf("%v\n", err)
+		return nil
+	}
+
+	changed := make(map[string][]int, len(changes))
+	for _, change := range changes {
+		if lines := coverage.AddedLines(change.DiffContent); len(lines) > 0 {
+			changed[change.FilePath] = lines
+		}
+	}
+	return coverage.Analyze(profile, changed)
+}
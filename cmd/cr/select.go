@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// selectHunks 逐个展示改动块，由用户选择需要评审的部分
+func selectHunks(in io.Reader, out io.Writer, changes []types.FileChange) ([]types.FileChange, error) {
+	reader := bufio.NewReader(in)
+	var selected []types.FileChange
+
+	for _, change := range changes {
+		// 没有改动块的文件（如二进制文件、纯重命名）不会出现在选择列表中
+		header, hunks := review.SplitHunks(change)
+
+		var chosen []review.Hunk
+		fileDone := false
+		for i, hunk := range hunks {
+			if fileDone {
+				break
+			}
+			fmt.Fprintf(out, "\n%s (%d/%d)\n%s\n", change.FilePath, i+1, len(hunks), hunk.String())
+
+			for {
+				fmt.Fprint(out, i18n.M("cmd.select_prompt"))
+				answer, err := reader.ReadString('\n')
+				if err != nil && answer == "" {
+					if err == io.EOF {
+						return append(selected, keepSelected(change, header, chosen)...), nil
+					}
+					return nil, i18n.Errorf("cmd.read_input_failed", err)
+				}
+
+				switch strings.TrimSpace(strings.ToLower(answer)) {
+				case "y":
+					chosen = append(chosen, hunk)
+				case "n":
+				case "a":
+					chosen = append(chosen, hunks[i:]...)
+					fileDone = true
+				case "d":
+					fileDone = true
+				case "q":
+					return append(selected, keepSelected(change, header, chosen)...), nil
+				default:
+					fmt.Fprintln(out, i18n.M("cmd.select_help"))
+					continue
+				}
+				break
+			}
+		}
+
+		selected = append(selected, keepSelected(change, header, chosen)...)
+	}
+
+	return selected, nil
+}
+
+// keepSelected 返回只包含选中改动块的文件改动，没有选中时返回空
+func keepSelected(change types.FileChange, header string, chosen []review.Hunk) []types.FileChange {
+	if kept, ok := review.JoinHunks(change, header, chosen); ok {
+		return []types.FileChange{kept}
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/cli"
+	"github.com/icatw/ai-cr-tool/pkg/github"
+	"github.com/icatw/ai-cr-tool/pkg/gitlab"
+	"github.com/icatw/ai-cr-tool/pkg/history"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/publish"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/server"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+func init() {
+	registerCommand("serve", "cmd.summary.serve", runServe)
+}
+
+// runServe 执行 serve 子命令：接收 GitHub/GitLab 的 webhook，在后台评审 pull request 并发布评审评论
+// -- 之后的参数作为每次评审的命令行参数，如 cr serve -- --model gpt-4o --lang en
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", i18n.M("serve.flag.addr"))
+	workDir := fs.String("workdir", "", i18n.M("serve.flag.workdir"))
+	workers := fs.Int("workers", 2, i18n.M("serve.flag.workers"))
+	retries := fs.Int("retries", 2, i18n.M("serve.flag.retries"))
+	redisURL := fs.String("redis", "", i18n.M("serve.flag.redis"))
+	maxCalls := fs.Int("max-model-calls", 8, i18n.M("serve.flag.max-model-calls"))
+	maxRepoCalls := fs.Int("max-repo-model-calls", review.DefaultConcurrency, i18n.M("serve.flag.max-repo-model-calls"))
+	minSeverity := fs.String("min-severity", string(types.SeverityInfo), i18n.M("serve.flag.min-severity"))
+	historyStore := fs.String("history", "", i18n.M("serve.flag.history"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	reviewArgs := fs.Args()
+
+	severity := types.SeverityLevel(*minSeverity)
+	if severity.Rank() == 0 {
+		return i18n.Errorf("serve.err.min_severity", *minSeverity)
+	}
+	if err := cli.CheckWritable("readonly.serve"); err != nil {
+		return err
+	}
+	// 提前检查评审参数，避免每个事件都因参数错误失败
+	if _, err := cli.ParseArgsIn("", append([]string{"--commit-range", "HEAD~1..HEAD"}, reviewArgs...)); err != nil {
+		return i18n.Errorf("serve.err.review_args", err)
+	}
+
+	opts := server.Options{
+		GitHubSecret: os.Getenv("GITHUB_WEBHOOK_SECRET"),
+		GitLabToken:  os.Getenv("GITLAB_WEBHOOK_TOKEN"),
+		APIToken:     os.Getenv("CR_API_TOKEN"),
+		Workers:      *workers,
+		Retries:      *retries,
+	}
+	if opts.GitHubSecret == "" && opts.GitLabToken == "" && opts.APIToken == "" {
+		return i18n.Errorf("serve.err.no_secret")
+	}
+	if *workDir == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return i18n.Errorf("serve.err.workdir", err)
+		}
+		*workDir = filepath.Join(cacheDir, "ai-cr-tool", "serve")
+	}
+	opts.Workspace = server.NewWorkspace(*workDir, []server.Credential{
+		{Host: serverHost(os.Getenv("GITHUB_SERVER_URL"), "github.com"), User: "x-access-token", Token: os.Getenv("GITHUB_TOKEN")},
+		{Host: serverHost(os.Getenv("CI_SERVER_URL"), "gitlab.com"), User: "oauth2", Token: os.Getenv("GITLAB_TOKEN")},
+	})
+	if *redisURL != "" {
+		queue, err := server.NewRedisQueue(*redisURL, server.DefaultQueueSize)
+		if err != nil {
+			return err
+		}
+		opts.Queue = queue
+	}
+	scheduler := server.NewScheduler(*maxCalls, *maxRepoCalls)
+	opts.Scheduler = scheduler
+	if *historyStore == "" {
+		*historyStore = filepath.Join(*workDir, "history.db")
+	}
+	if *historyStore != history.Disabled {
+		store, err := history.Open(*historyStore)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		opts.History = store
+	}
+	opts.Review = func(e *server.Event, c *server.Checkout) ([]byte, error) {
+		return serveReview(e, c, reviewArgs, scheduler.ForRepo(e.Platform+":"+e.Repo), severity)
+	}
+	opts.RunJob = func(req *server.ReviewRequest, dir string, args []string) ([]byte, error) {
+		// 补丁任务没有仓库，共用同一个并发额度
+		repo := "api"
+		if req.CloneURL != "" {
+			repo = req.CloneURL
+		}
+		return serveJob(req, dir, args, reviewArgs, scheduler.ForRepo(repo))
+	}
+
+	srv := server.NewServer(opts)
+	srv.Start()
+	httpServer := &http.Server{Addr: *addr, Handler: srv.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.ListenAndServe()
+	}()
+	if opts.GitHubSecret != "" {
+		fmt.Fprintln(os.Stderr, i18n.M("serve.github_endpoint", *addr))
+	}
+	if opts.GitLabToken != "" {
+		fmt.Fprintln(os.Stderr, i18n.M("serve.gitlab_endpoint", *addr))
+	}
+	if opts.APIToken != "" {
+		fmt.Fprintln(os.Stderr, i18n.M("serve.api_endpoint", *addr))
+		if opts.History != nil {
+			fmt.Fprintln(os.Stderr, i18n.M("serve.dashboard_endpoint", *addr))
+		}
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	select {
+	case err := <-errs:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return i18n.Errorf("serve.err.listen", *addr, err)
+		}
+	case <-interrupt:
+	}
+
+	fmt.Fprintln(os.Stderr, i18n.M("serve.stopping"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	httpServer.Shutdown(ctx)
+	srv.Stop()
+	return nil
+}
+
+// serveReview 评审服务模式下检出的 pull request，并把结果发布为评审评论
+// 多个 pull request 可能并发评审，因此以静默模式评审
+// 强制推送后只评审有变化的提交修改的文件，已发布过的行内评论不会重复发布
+// 返回 JSON 格式的评审报告，记录在评审历史中；没有需要评审的改动时返回 nil
+func serveReview(e *server.Event, c *server.Checkout, reviewArgs []string, limiter review.Limiter, severity types.SeverityLevel) ([]byte, error) {
+	args := append([]string{"--commit-range", c.Range}, reviewArgs...)
+	opts, err := cli.ParseArgsIn(c.Dir, append(args, "--quiet"))
+	if err != nil {
+		return nil, i18n.Errorf("cmd.parse_args_failed", err)
+	}
+	opts.Limiter = limiter
+	opts.OnlyFiles = c.Files
+
+	session, err := runReview(opts, c.Dir)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Span.End(nil)
+	if len(session.Changes) == 0 && len(session.Issues) == 0 {
+		log.Println(i18n.M("serve.no_changes", e))
+		return nil, nil
+	}
+
+	reporter, err := newSessionReporter(e.Repo, session, opts)
+	if err != nil {
+		return nil, err
+	}
+	reporter.CommitID = e.HeadSHA
+	summary, err := reporter.Generate(session.Issues, review.GitHubMarkdownFormat)
+	if err != nil {
+		return nil, i18n.Errorf("serve.err.summary", err)
+	}
+	var inline []types.Issue
+	for _, issue := range session.Issues {
+		if issue.Severity.Rank() >= severity.Rank() {
+			inline = append(inline, issue)
+		}
+	}
+
+	var result publish.Result
+	switch e.Platform {
+	case "github":
+		client, err := github.NewClientFromEnv(e.Repo)
+		if err != nil {
+			return nil, err
+		}
+		result, err = publish.PublishGitHub(client, e.Number, reporter, inline, string(summary))
+		if err != nil {
+			return nil, err
+		}
+	case "gitlab":
+		client, err := gitlab.NewClientFromEnv(e.Repo)
+		if err != nil {
+			return nil, err
+		}
+		result, err = publish.PublishGitLab(client, e.Number, reporter, inline, string(summary))
+		if err != nil {
+			return nil, err
+		}
+	}
+	sendNotifications(e.Repo, fmt.Sprintf("#%d", e.Number), result.URL, session, opts, session.Policy.Evaluate(session.Issues).Passed)
+	log.Println(i18n.M("serve.published", e, len(session.Issues), result.Inline, result.Duplicate, result.Outside))
+	report, err := reporter.Generate(session.Issues, review.JSONFormat)
+	if err != nil {
+		return nil, i18n.Errorf("cmd.generate_report_failed", err)
+	}
+	return report, nil
+}
+
+// serveJob 评审 REST API 提交的任务，返回 JSON 格式的评审报告，没有需要评审的改动时报告中没有问题
+func serveJob(req *server.ReviewRequest, dir string, args, reviewArgs []string, limiter review.Limiter) ([]byte, error) {
+	args = append(args, reviewArgs...)
+	if req.Model != "" {
+		args = append(args, "--model", req.Model)
+	}
+	if req.Lang != "" {
+		args = append(args, "--lang", req.Lang)
+	}
+	opts, err := cli.ParseArgsIn(dir, append(args, "--quiet"))
+	if err != nil {
+		return nil, i18n.Errorf("cmd.parse_args_failed", err)
+	}
+	opts.Limiter = limiter
+
+	session, err := runReview(opts, dir)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Span.End(nil)
+	project := req.CloneURL
+	if project == "" {
+		project = "patch"
+	}
+	reporter, err := newSessionReporter(project, session, opts)
+	if err != nil {
+		return nil, err
+	}
+	report, err := reporter.Generate(session.Issues, review.JSONFormat)
+	if err != nil {
+		return nil, i18n.Errorf("cmd.generate_report_failed", err)
+	}
+	return report, nil
+}
+
+// serverHost 返回托管平台地址中的主机名，地址为空或无效时返回默认主机
+func serverHost(serverURL, fallback string) string {
+	if u, err := url.Parse(serverURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return fallback
+}
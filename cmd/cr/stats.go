@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/calibration"
+	"github.com/icatw/ai-cr-tool/pkg/cli"
+	"github.com/icatw/ai-cr-tool/pkg/git"
+	"github.com/icatw/ai-cr-tool/pkg/github"
+	"github.com/icatw/ai-cr-tool/pkg/gitlab"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/publish"
+)
+
+func init() {
+	registerCommand("stats", "cmd.summary.stats", runStats)
+}
+
+// runStats 执行 stats 子命令：同步 PR 评论上的反馈，输出各模型的校准情况
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	from := fs.String("from", "", i18n.M("stats.flag.from"))
+	repo := fs.String("repo", "", i18n.M("stats.flag.repo"))
+	pr := fs.Int("pr", 0, i18n.M("stats.flag.pr"))
+	dismiss := fs.String("dismiss", "", i18n.M("stats.flag.dismiss"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	gitDir, err := git.NewGitClient(wd).GitDir()
+	if err != nil {
+		return err
+	}
+	store, err := calibration.Open(calibration.Path(gitDir))
+	if err != nil {
+		return err
+	}
+
+	var feedback []publish.Feedback
+	switch *from {
+	case "":
+	case "github":
+		client, err := github.NewClientFromEnv(*repo)
+		if err != nil {
+			return err
+		}
+		number := *pr
+		if number == 0 {
+			if number, err = github.PullNumberFromEnv(); err != nil {
+				return err
+			}
+		}
+		if feedback, err = publish.GitHubFeedback(client, number); err != nil {
+			return err
+		}
+	case "gitlab":
+		client, err := gitlab.NewClientFromEnv(*repo)
+		if err != nil {
+			return err
+		}
+		iid := *pr
+		if iid == 0 {
+			if iid, err = gitlab.MergeRequestIIDFromEnv(); err != nil {
+				return err
+			}
+		}
+		if feedback, err = publish.GitLabFeedback(client, iid); err != nil {
+			return err
+		}
+	default:
+		return i18n.Errorf("stats.err.source", *from)
+	}
+
+	for _, f := range feedback {
+		store.Record(f.Fingerprint, f.Model, f.Dismissed)
+	}
+	changed := len(feedback) > 0
+	if *from != "" {
+		fmt.Fprintln(os.Stderr, i18n.M("stats.synced", len(feedback)))
+	}
+	for _, fingerprint := range strings.Split(*dismiss, ",") {
+		if fingerprint = strings.TrimSpace(fingerprint); fingerprint == "" {
+			continue
+		}
+		if !store.Dismiss(fingerprint) {
+			return i18n.Errorf("stats.err.not_published", fingerprint)
+		}
+		changed = true
+	}
+	if changed {
+		if err := cli.CheckWritable("readonly.calibration"); err != nil {
+			return err
+		}
+		if err := store.Save(); err != nil {
+			return err
+		}
+	}
+
+	printCalibration(store.Stats())
+	return nil
+}
+
+// printCalibration 以 Markdown 表格输出各模型的校准情况
+func printCalibration(stats []calibration.ModelStats) {
+	fmt.Println("## " + i18n.M("stats.title"))
+	fmt.Println()
+	if len(stats) == 0 {
+		fmt.Println(i18n.M("stats.empty"))
+		return
+	}
+	fmt.Println(i18n.M("stats.header"))
+	fmt.Println("| --- | --- | --- | --- | --- |")
+	for _, m := range stats {
+		threshold := "-"
+		if level := m.Threshold(); level != "" {
+			threshold = string(level)
+		} else if m.Published < calibration.MinSamples {
+			threshold = i18n.M("stats.too_few", calibration.MinSamples)
+		}
+		fmt.Printf("| %s | %d | %d | %.1f%% | %s |\n", m.Model, m.Published, m.Dismissed, m.Precision()*100, threshold)
+	}
+	fmt.Println()
+	fmt.Println(i18n.M("stats.note"))
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/cli"
+	"github.com/icatw/ai-cr-tool/pkg/git"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+func init() {
+	registerCommand("watch", "cmd.summary.watch", runWatch)
+}
+
+// watchRetryDelay 评审失败的文件在没有新的改动时，等待多久后重新评审
+const watchRetryDelay = 30 * time.Second
+
+// watchState 记录每个文件最近一次看到和评审过的差异指纹
+type watchState struct {
+	// 最近一次轮询看到的差异指纹
+	seen map[string][32]byte
+	// 最近一次评审成功时的差异指纹
+	reviewed map[string][32]byte
+	// 文件最近一次发生变化的时间
+	changedAt map[string]time.Time
+	// 评审失败或因时限跳过的文件下次重试的时间，文件再次变化后清除
+	retryAt map[string]time.Time
+}
+
+// runWatch 执行 watch 子命令
+func runWatch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	interval := fs.Duration("interval", 2*time.Second, i18n.M("watch.flag.interval"))
+	debounce := fs.Duration("debounce", 3*time.Second, i18n.M("watch.flag.debounce"))
+	modelName := fs.String("model", "", i18n.M("watch.flag.model"))
+	concurrency := fs.Int("concurrency", review.DefaultConcurrency, i18n.M("watch.flag.concurrency"))
+	cacheMemoryMB := fs.Int("cache-memory", 32, i18n.M("watch.flag.cache-memory"))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *interval <= 0 || *debounce < 0 {
+		return i18n.Errorf("watch.err.interval")
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return i18n.Errorf("cmd.getwd_failed", err)
+	}
+	gitClient := git.NewGitClient(wd)
+
+	// 与评审使用相同的配置：排除规则、模型池和提供方限制
+	var reviewArgs []string
+	if *modelName != "" {
+		reviewArgs = append(reviewArgs, "--model", *modelName)
+	}
+	opts, err := cli.ParseArgsIn(wd, reviewArgs)
+	if err != nil {
+		return err
+	}
+	reviewPolicy, err := loadPolicy(opts)
+	if err != nil {
+		return i18n.Errorf("cmd.policy_failed", err)
+	}
+
+	modelClient, modelConfig, err := newModelClient(opts.Model, opts.Config.Model.Pools)
+	if err != nil {
+		return err
+	}
+	if err := checkProviders(modelClient, modelConfig, reviewPolicy.CheckProvider); err != nil {
+		return err
+	}
+	prompt, err := loadReviewPrompt(wd)
+	if err != nil {
+		return err
+	}
+	prompt.HardenInjection = opts.HardenPrompt
+	prompt.Glossary = reviewPolicy.Glossary
+	engine := review.NewEngine(modelClient, review.EngineOptions{
+		ModelConfig: modelConfig,
+		Prompt:      prompt,
+		// 监控模式一直运行到被中断，同步写入缓存，避免中断时丢失等待写入的结果
+		Cache:       newReviewCache(*cacheMemoryMB, 0, opts.ReadOnly),
+		Concurrency: *concurrency,
+	})
+
+	state := &watchState{
+		seen:      make(map[string][32]byte),
+		reviewed:  make(map[string][32]byte),
+		changedAt: make(map[string]time.Time),
+		retryAt:   make(map[string]time.Time),
+	}
+
+	// 启动时已有的改动视为已评审，只评审之后发生的变化
+	if changes, err := gitClient.GetWorkingDirChanges(); err == nil {
+		for _, change := range changes {
+			sum := sha256.Sum256([]byte(change.DiffContent))
+			state.seen[change.FilePath] = sum
+			state.reviewed[change.FilePath] = sum
+		}
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	fmt.Println(i18n.M("watch.started", wd, *interval, *debounce))
+	for {
+		select {
+		case <-interrupt:
+			fmt.Println("\n" + i18n.M("watch.stopped"))
+			return nil
+		case <-ticker.C:
+			changes, err := gitClient.GetWorkingDirChanges()
+			if err != nil {
+				log.Println(i18n.M("watch.err.changes", err))
+				continue
+			}
+
+			// 策略排除的文件和二进制文件不评审，记为已评审，直到再次变化
+			pending, excluded := reviewPolicy.FilterChanges(state.update(changes, time.Now(), *debounce))
+			pending, binary := skipBinary(gitClient, pending, false)
+			for _, change := range append(excluded, binary...) {
+				state.reviewed[change.FilePath] = sha256.Sum256([]byte(change.DiffContent))
+			}
+			if len(pending) == 0 {
+				continue
+			}
+
+			issues := engine.Review(pending)
+			printWatchFindings(pending, issues)
+			// 评审失败或被跳过的文件不记为已评审，稍后重试
+			unreviewed := make(map[string]bool)
+			for _, failed := range engine.Failed() {
+				unreviewed[failed.FilePath] = true
+				fmt.Println(i18n.M("watch.review_failed", failed.FilePath, failed.Message, watchRetryDelay))
+			}
+			for _, file := range engine.Skipped() {
+				unreviewed[file] = true
+			}
+			now := time.Now()
+			for _, change := range pending {
+				if unreviewed[change.FilePath] {
+					state.retryAt[change.FilePath] = now.Add(watchRetryDelay)
+					continue
+				}
+				state.reviewed[change.FilePath] = sha256.Sum256([]byte(change.DiffContent))
+				delete(state.retryAt, change.FilePath)
+			}
+		}
+	}
+}
+
+// update 根据最新的工作区改动更新状态，返回已经稳定且尚未评审的文件改动，评审失败的文件到了重试时间才返回
+func (s *watchState) update(changes []types.FileChange, now time.Time, debounce time.Duration) []types.FileChange {
+	current := make(map[string]bool, len(changes))
+	var pending []types.FileChange
+
+	for _, change := range changes {
+		current[change.FilePath] = true
+		sum := sha256.Sum256([]byte(change.DiffContent))
+
+		if prev, ok := s.seen[change.FilePath]; !ok || prev != sum {
+			s.seen[change.FilePath] = sum
+			s.changedAt[change.FilePath] = now
+			delete(s.retryAt, change.FilePath)
+			continue
+		}
+		if reviewed, ok := s.reviewed[change.FilePath]; ok && reviewed == sum {
+			continue
+		}
+		if retry, ok := s.retryAt[change.FilePath]; ok && now.Before(retry) {
+			continue
+		}
+		if now.Sub(s.changedAt[change.FilePath]) >= debounce {
+			pending = append(pending, change)
+		}
+	}
+
+	// 已还原的文件不再跟踪
+	for file := range s.seen {
+		if !current[file] {
+			delete(s.seen, file)
+			delete(s.reviewed, file)
+			delete(s.changedAt, file)
+			delete(s.retryAt, file)
+		}
+	}
+	return pending
+}
+
+// printWatchFindings 在终端输出本轮的评审结果
+func printWatchFindings(changes []types.FileChange, issues []types.Issue) {
+	files := make([]string, 0, len(changes))
+	for _, change := range changes {
+		files = append(files, change.FilePath)
+	}
+
+	fmt.Printf("\n[%s] %s\n", time.Now().Format("15:04:05"), i18n.M("watch.reviewed", len(files), strings.Join(files, ", ")))
+	for _, issue := range issues {
+		fmt.Printf("\n── %s ──\n%s\n", issue.FilePath, strings.TrimSpace(issue.Description))
+	}
+}
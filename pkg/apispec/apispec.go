@@ -0,0 +1,141 @@
+package apispec
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// 支持的接口定义类型
+const (
+	Proto   = "proto"
+	OpenAPI = "openapi"
+)
+
+// Change 接口定义中一处不兼容的变更
+type Change struct {
+	// 接口定义文件路径
+	File string
+	// 接口定义类型：proto 或 openapi
+	Kind string
+	// 变更的元素，如 message User、GET /users/{id}
+	Element     string
+	Description string
+	Severity    types.SeverityLevel
+	// 变更后文件中的行号，元素已删除时为其所在位置的行号，无法确定时为 0
+	Line int
+}
+
+// String 返回变更的简短说明，用于评审提示
+func (c Change) String() string {
+	return c.Element + "：" + c.Description
+}
+
+// Candidate 判断文件是否可能是接口定义，OpenAPI 文件还需要根据内容确认
+func Candidate(filePath string) bool {
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".proto", ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// Kind 根据文件名和内容判断接口定义类型，不是接口定义时返回空字符串
+// OpenAPI 和 Swagger 文件以顶层的 openapi 或 swagger 字段识别
+func Kind(filePath, content string) string {
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".proto":
+		return Proto
+	case ".yaml", ".yml", ".json":
+		if isOpenAPI(content) {
+			return OpenAPI
+		}
+	}
+	return ""
+}
+
+// Diff 比较接口定义变更前后的内容，返回按行号排序的不兼容变更
+// 新增的文件 oldContent 为空，删除的文件 newContent 为空
+func Diff(file, oldContent, newContent string) ([]Change, error) {
+	kind := Kind(file, newContent)
+	if kind == "" {
+		kind = Kind(file, oldContent)
+	}
+
+	// 整个文件被删除时只报告一次，不逐个列出其中的定义
+	if strings.TrimSpace(newContent) == "" && strings.TrimSpace(oldContent) != "" {
+		return []Change{{
+			File:        file,
+			Kind:        kind,
+			Element:     file,
+			Description: "删除了接口定义文件，其中的接口和数据模型都不再可用",
+			Severity:    types.SeverityError,
+		}}, nil
+	}
+
+	var changes []Change
+	switch kind {
+	case Proto:
+		before, err := parseProto(oldContent)
+		if err != nil {
+			return nil, fmt.Errorf("解析变更前的 %s 失败: %v", file, err)
+		}
+		after, err := parseProto(newContent)
+		if err != nil {
+			return nil, fmt.Errorf("解析变更后的 %s 失败: %v", file, err)
+		}
+		changes = diffProto(before, after)
+	case OpenAPI:
+		before, err := parseOpenAPI(oldContent)
+		if err != nil {
+			return nil, fmt.Errorf("解析变更前的 %s 失败: %v", file, err)
+		}
+		after, err := parseOpenAPI(newContent)
+		if err != nil {
+			return nil, fmt.Errorf("解析变更后的 %s 失败: %v", file, err)
+		}
+		changes = diffOpenAPI(before, after)
+	default:
+		return nil, fmt.Errorf("不支持的接口定义文件: %s", file)
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Line < changes[j].Line })
+	for i := range changes {
+		changes[i].File = file
+		changes[i].Kind = kind
+	}
+	return changes, nil
+}
+
+// BreakingIssues 把不兼容变更转换为评审问题，与代码问题一起出现在报告中
+func BreakingIssues(changes []Change) []types.Issue {
+	issues := make([]types.Issue, 0, len(changes))
+	for _, c := range changes {
+		suggestion := "保持旧的接口和字段可用并标记为 deprecated，或提升 API 的主版本号并通知调用方迁移。"
+		if c.Kind == Proto {
+			suggestion = "删除字段时用 reserved 保留其编号和名称，不要修改已有字段的编号和类型；必须做不兼容的改动时，在新的包版本（如 v2）中引入。"
+		}
+		issues = append(issues, types.Issue{
+			Title:       "API 不兼容变更：" + c.Element,
+			FilePath:    c.File,
+			Line:        c.Line,
+			Severity:    c.Severity,
+			Category:    types.CategoryBug,
+			Description: c.Description,
+			Suggestion:  suggestion,
+		})
+	}
+	return issues
+}
+
+// Summaries 返回各变更的简短说明
+func Summaries(changes []Change) []string {
+	result := make([]string, 0, len(changes))
+	for _, c := range changes {
+		result = append(result, c.String())
+	}
+	return result
+}
@@ -0,0 +1,306 @@
+package apispec
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// httpMethods OpenAPI 中可以定义操作的 HTTP 方法
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPIDoc 解析后的 OpenAPI 或 Swagger 文档，nil 表示文件为空
+type openAPIDoc struct {
+	root *yaml.Node
+}
+
+// isOpenAPI 判断 YAML 或 JSON 内容的顶层是否包含 openapi 或 swagger 字段
+func isOpenAPI(content string) bool {
+	if strings.TrimSpace(content) == "" {
+		return false
+	}
+	doc, err := parseOpenAPI(content)
+	if err != nil || doc.root == nil {
+		return false
+	}
+	return lookup(doc.root, "openapi") != nil || lookup(doc.root, "swagger") != nil
+}
+
+// parseOpenAPI 解析 OpenAPI 文档，JSON 是 YAML 的子集，同样可以解析
+func parseOpenAPI(content string) (*openAPIDoc, error) {
+	if strings.TrimSpace(content) == "" {
+		return &openAPIDoc{}, nil
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &node); err != nil {
+		return nil, err
+	}
+	if node.Kind != yaml.DocumentNode || len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("顶层不是对象")
+	}
+	return &openAPIDoc{root: node.Content[0]}, nil
+}
+
+// lookup 依次按键查找映射节点中的值，任一层不存在时返回 nil
+func lookup(node *yaml.Node, keys ...string) *yaml.Node {
+	for _, key := range keys {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var found *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				found = node.Content[i+1]
+				break
+			}
+		}
+		node = found
+	}
+	return node
+}
+
+// keyLine 返回映射中键所在的行号，键不存在时返回映射本身的行号
+func keyLine(node *yaml.Node, key string) int {
+	if node == nil {
+		return 0
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i].Line
+		}
+	}
+	return node.Line
+}
+
+// mappingKeys 返回映射节点的键，保持文档中的顺序
+func mappingKeys(node *yaml.Node) []string {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	keys := make([]string, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys = append(keys, node.Content[i].Value)
+	}
+	return keys
+}
+
+// scalar 返回标量节点的值，不是标量时返回空字符串
+func scalar(node *yaml.Node) string {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
+
+// schemas 返回文档中可复用的数据模型，OpenAPI 3 位于 components.schemas，Swagger 2 位于 definitions
+func (d *openAPIDoc) schemas() *yaml.Node {
+	if s := lookup(d.root, "components", "schemas"); s != nil {
+		return s
+	}
+	return lookup(d.root, "definitions")
+}
+
+// parameters 返回操作的参数，包括路径级别的公共参数，键为 位置:名称
+func parameters(pathItem, operation *yaml.Node) map[string]*yaml.Node {
+	result := make(map[string]*yaml.Node)
+	for _, list := range []*yaml.Node{lookup(pathItem, "parameters"), lookup(operation, "parameters")} {
+		if list == nil || list.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, param := range list.Content {
+			// 引用的公共参数以引用路径作为标识
+			if ref := scalar(lookup(param, "$ref")); ref != "" {
+				result["$ref:"+ref] = param
+				continue
+			}
+			result[scalar(lookup(param, "in"))+":"+scalar(lookup(param, "name"))] = param
+		}
+	}
+	return result
+}
+
+// schemaType 返回数据模型的类型描述，如 string(date-time)、array<User>
+func schemaType(schema *yaml.Node) string {
+	if schema == nil {
+		return ""
+	}
+	if ref := scalar(lookup(schema, "$ref")); ref != "" {
+		return ref[strings.LastIndex(ref, "/")+1:]
+	}
+	typ := scalar(lookup(schema, "type"))
+	if typ == "array" {
+		return "array<" + schemaType(lookup(schema, "items")) + ">"
+	}
+	if format := scalar(lookup(schema, "format")); format != "" {
+		typ += "(" + format + ")"
+	}
+	return typ
+}
+
+// paramType 返回参数的类型，OpenAPI 3 的类型位于 schema 中，Swagger 2 直接位于参数上
+func paramType(param *yaml.Node) string {
+	if schema := lookup(param, "schema"); schema != nil {
+		return schemaType(schema)
+	}
+	return schemaType(param)
+}
+
+// required 返回数据模型中必填属性的集合
+func required(schema *yaml.Node) map[string]bool {
+	result := make(map[string]bool)
+	if list := lookup(schema, "required"); list != nil && list.Kind == yaml.SequenceNode {
+		for _, item := range list.Content {
+			result[item.Value] = true
+		}
+	}
+	return result
+}
+
+// enumValues 返回枚举的取值集合
+func enumValues(schema *yaml.Node) map[string]bool {
+	list := lookup(schema, "enum")
+	if list == nil || list.Kind != yaml.SequenceNode {
+		return nil
+	}
+	result := make(map[string]bool)
+	for _, item := range list.Content {
+		result[item.Value] = true
+	}
+	return result
+}
+
+// diffOpenAPI 比较 OpenAPI 文档前后的定义，找出会破坏已有调用方的变更
+func diffOpenAPI(before, after *openAPIDoc) []Change {
+	if before.root == nil {
+		return nil
+	}
+	var changes []Change
+	add := func(severity types.SeverityLevel, line int, element, format string, args ...interface{}) {
+		changes = append(changes, Change{Element: element, Description: fmt.Sprintf(format, args...), Severity: severity, Line: line})
+	}
+
+	oldPaths, newPaths := lookup(before.root, "paths"), lookup(after.root, "paths")
+	for _, p := range mappingKeys(oldPaths) {
+		oldItem, newItem := lookup(oldPaths, p), lookup(newPaths, p)
+		for _, method := range httpMethods {
+			oldOp := lookup(oldItem, method)
+			if oldOp == nil {
+				continue
+			}
+			element := strings.ToUpper(method) + " " + p
+			newOp := lookup(newItem, method)
+			if newOp == nil {
+				line := 0
+				if newItem != nil {
+					line = newItem.Line
+				}
+				add(types.SeverityError, line, element, "删除了接口 %s", element)
+				continue
+			}
+
+			oldParams, newParams := parameters(oldItem, oldOp), parameters(newItem, newOp)
+			for _, key := range sortedKeys(oldParams) {
+				oldParam := oldParams[key]
+				name := strings.TrimPrefix(key[strings.Index(key, ":")+1:], "#/")
+				newParam, ok := newParams[key]
+				switch {
+				case !ok:
+					add(types.SeverityError, newOp.Line, element, "删除了参数 %s，仍在传递该参数的调用方可能出错或行为改变", name)
+				case paramType(newParam) != paramType(oldParam):
+					add(types.SeverityError, newParam.Line, element, "参数 %s 的类型从 %s 改为 %s", name, paramType(oldParam), paramType(newParam))
+				case scalar(lookup(newParam, "required")) == "true" && scalar(lookup(oldParam, "required")) != "true":
+					add(types.SeverityError, newParam.Line, element, "参数 %s 改为必填，未传递该参数的调用方将失败", name)
+				}
+			}
+			for _, key := range sortedKeys(newParams) {
+				newParam := newParams[key]
+				if _, existed := oldParams[key]; !existed && scalar(lookup(newParam, "required")) == "true" {
+					add(types.SeverityError, newParam.Line, element, "新增了必填参数 %s，已有的调用方没有传递该参数", scalar(lookup(newParam, "name")))
+				}
+			}
+
+			if scalar(lookup(newOp, "requestBody", "required")) == "true" && scalar(lookup(oldOp, "requestBody", "required")) != "true" {
+				add(types.SeverityError, keyLine(newOp, "requestBody"), element, "请求体改为必填，未提供请求体的调用方将失败")
+			}
+
+			oldResponses, newResponses := lookup(oldOp, "responses"), lookup(newOp, "responses")
+			for _, code := range mappingKeys(oldResponses) {
+				if strings.HasPrefix(code, "2") && lookup(newResponses, code) == nil {
+					add(types.SeverityError, keyLine(newOp, "responses"), element, "删除了成功响应 %s，依赖该响应的调用方无法处理新的返回", code)
+				}
+			}
+		}
+	}
+
+	oldSchemas, newSchemas := before.schemas(), after.schemas()
+	for _, name := range mappingKeys(oldSchemas) {
+		oldSchema, newSchema := lookup(oldSchemas, name), lookup(newSchemas, name)
+		element := "schema " + name
+		if newSchema == nil {
+			add(types.SeverityError, 0, element, "删除了数据模型 %s", name)
+			continue
+		}
+		if oldType, newType := schemaType(oldSchema), schemaType(newSchema); oldType != newType {
+			add(types.SeverityError, newSchema.Line, element, "类型从 %s 改为 %s", oldType, newType)
+		}
+		changes = append(changes, diffEnum(element, oldSchema, newSchema)...)
+
+		oldProps, newProps := lookup(oldSchema, "properties"), lookup(newSchema, "properties")
+		oldRequired, newRequired := required(oldSchema), required(newSchema)
+		for _, prop := range mappingKeys(oldProps) {
+			oldProp, newProp := lookup(oldProps, prop), lookup(newProps, prop)
+			switch {
+			case newProp == nil:
+				add(types.SeverityError, keyLine(newSchema, "properties"), element, "删除了属性 %s", prop)
+			case schemaType(newProp) != schemaType(oldProp):
+				add(types.SeverityError, keyLine(newProps, prop), element, "属性 %s 的类型从 %s 改为 %s", prop, schemaType(oldProp), schemaType(newProp))
+			default:
+				changes = append(changes, diffEnum(element+"."+prop, oldProp, newProp)...)
+			}
+		}
+		for _, prop := range mappingKeys(newProps) {
+			if newRequired[prop] && !oldRequired[prop] {
+				add(types.SeverityWarning, keyLine(newProps, prop), element,
+					"属性 %s 改为必填，作为请求体使用时未提供该属性的调用方将失败", prop)
+			}
+		}
+	}
+
+	// 有不兼容变更时，提醒同步提升文档的主版本号
+	if len(changes) > 0 {
+		oldVersion, newVersion := scalar(lookup(before.root, "info", "version")), scalar(lookup(after.root, "info", "version"))
+		if oldVersion != "" && majorVersion(oldVersion) == majorVersion(newVersion) {
+			add(types.SeverityWarning, keyLine(lookup(after.root, "info"), "version"), "info.version",
+				"存在不兼容变更，但 info.version 的主版本号没有变化（%s → %s）", oldVersion, newVersion)
+		}
+	}
+	return changes
+}
+
+// diffEnum 找出数据模型中被删除的枚举值
+func diffEnum(element string, oldSchema, newSchema *yaml.Node) []Change {
+	oldValues, newValues := enumValues(oldSchema), enumValues(newSchema)
+	if oldValues == nil || newValues == nil {
+		return nil
+	}
+	var changes []Change
+	for _, item := range lookup(oldSchema, "enum").Content {
+		if !newValues[item.Value] {
+			changes = append(changes, Change{
+				Element:     element,
+				Description: fmt.Sprintf("删除了枚举值 %s", item.Value),
+				Severity:    types.SeverityError,
+				Line:        lookup(newSchema, "enum").Line,
+			})
+		}
+	}
+	return changes
+}
+
+// majorVersion 返回版本号的主版本部分，如 v2.1.0 返回 2
+func majorVersion(version string) string {
+	major, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(version), "v"), ".")
+	return major
+}
@@ -0,0 +1,554 @@
+package apispec
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// protoToken proto 文件中的一个词法单元
+type protoToken struct {
+	text string
+	line int
+}
+
+// protoField 消息中的字段
+type protoField struct {
+	Name   string
+	Type   string
+	Label  string
+	Number int
+	Line   int
+}
+
+// protoMessage 消息定义，嵌套消息以 Outer.Inner 的形式单独记录
+type protoMessage struct {
+	Line           int
+	Fields         map[int]protoField
+	ReservedNumber func(int) bool
+	ReservedNames  map[string]bool
+}
+
+// protoEnum 枚举定义
+type protoEnum struct {
+	Line           int
+	Values         map[int]protoField
+	ReservedNumber func(int) bool
+	ReservedNames  map[string]bool
+}
+
+// protoRPC 服务中的方法，请求和响应类型包含 stream 前缀
+type protoRPC struct {
+	Request  string
+	Response string
+	Line     int
+}
+
+// protoFile 解析后的 proto 文件
+type protoFile struct {
+	Package     string
+	PackageLine int
+	Messages    map[string]*protoMessage
+	Enums       map[string]*protoEnum
+	// 键为服务名
+	Services map[string]int
+	// 键为 服务名.方法名
+	RPCs map[string]protoRPC
+}
+
+// protoParser 基于词法单元的简单递归下降解析器，只提取比较所需的结构
+type protoParser struct {
+	tokens []protoToken
+	pos    int
+	file   *protoFile
+}
+
+// parseProto 解析 proto 文件，内容为空时返回空定义
+func parseProto(content string) (*protoFile, error) {
+	p := &protoParser{
+		tokens: tokenizeProto(content),
+		file: &protoFile{
+			Messages: make(map[string]*protoMessage),
+			Enums:    make(map[string]*protoEnum),
+			Services: make(map[string]int),
+			RPCs:     make(map[string]protoRPC),
+		},
+	}
+	for !p.done() {
+		tok := p.next()
+		switch tok.text {
+		case "package":
+			p.file.Package, p.file.PackageLine = p.next().text, tok.line
+			p.skipStatement()
+		case "message":
+			if err := p.parseMessage(""); err != nil {
+				return nil, err
+			}
+		case "enum":
+			if err := p.parseEnum(""); err != nil {
+				return nil, err
+			}
+		case "service":
+			if err := p.parseService(); err != nil {
+				return nil, err
+			}
+		case "extend":
+			p.skipStatement()
+		case ";":
+		default:
+			p.skipStatement()
+		}
+	}
+	return p.file, nil
+}
+
+// tokenizeProto 把 proto 文件拆分为词法单元，去掉注释
+func tokenizeProto(content string) []protoToken {
+	var tokens []protoToken
+	line := 1
+	runes := []rune(content)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case unicode.IsSpace(c):
+			i++
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				if runes[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			i += 2
+		case c == '"' || c == '\'':
+			start := i
+			for i++; i < len(runes) && runes[i] != c; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+			i++
+			if i > len(runes) {
+				i = len(runes)
+			}
+			tokens = append(tokens, protoToken{text: string(runes[start:i]), line: line})
+		case isProtoIdent(c) || c == '-':
+			start := i
+			for i++; i < len(runes) && (isProtoIdent(runes[i]) || runes[i] == '.'); i++ {
+			}
+			tokens = append(tokens, protoToken{text: string(runes[start:i]), line: line})
+		default:
+			tokens = append(tokens, protoToken{text: string(c), line: line})
+			i++
+		}
+	}
+	return tokens
+}
+
+// isProtoIdent 判断字符能否出现在标识符、数字或限定名中
+func isProtoIdent(c rune) bool {
+	return c == '_' || c == '.' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+func (p *protoParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *protoParser) next() protoToken {
+	if p.done() {
+		return protoToken{}
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	return tok
+}
+
+func (p *protoParser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.tokens[p.pos].text
+}
+
+// expect 读取下一个词法单元，与期望的不一致时返回错误
+func (p *protoParser) expect(text string) error {
+	tok := p.next()
+	if tok.text != text {
+		return fmt.Errorf("第 %d 行: 期望 %q，实际为 %q", tok.line, text, tok.text)
+	}
+	return nil
+}
+
+// skipStatement 跳过到语句结束的分号，或跳过整个块
+func (p *protoParser) skipStatement() {
+	depth := 0
+	for !p.done() {
+		switch p.next().text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth <= 0 {
+				return
+			}
+		case ";":
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// parseMessage 解析消息定义，当前位置为消息名称
+func (p *protoParser) parseMessage(prefix string) error {
+	nameTok := p.next()
+	name := prefix + nameTok.text
+	msg := &protoMessage{Line: nameTok.line, Fields: make(map[int]protoField), ReservedNames: make(map[string]bool)}
+	p.file.Messages[name] = msg
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	var reserved []numberRange
+	for !p.done() {
+		tok := p.next()
+		switch tok.text {
+		case "}":
+			msg.ReservedNumber = inRanges(reserved)
+			return nil
+		case ";":
+		case "message":
+			if err := p.parseMessage(name + "."); err != nil {
+				return err
+			}
+		case "enum":
+			if err := p.parseEnum(name + "."); err != nil {
+				return err
+			}
+		case "oneof":
+			// oneof 中的字段与普通字段一样按编号比较
+			p.next()
+			if err := p.expect("{"); err != nil {
+				return err
+			}
+			for !p.done() && p.peek() != "}" {
+				if p.peek() == "option" || p.peek() == ";" {
+					p.skipStatement()
+					continue
+				}
+				if err := p.parseField(msg, p.next()); err != nil {
+					return err
+				}
+			}
+			p.next()
+		case "reserved":
+			reserved = append(reserved, p.parseReserved(msg.ReservedNames)...)
+		case "option", "extensions", "extend":
+			p.skipStatement()
+		default:
+			if err := p.parseField(msg, tok); err != nil {
+				return err
+			}
+		}
+	}
+	return fmt.Errorf("消息 %s 缺少结束的 }", name)
+}
+
+// parseField 解析字段定义，first 为字段的第一个词法单元（标签、类型或 map）
+func (p *protoParser) parseField(msg *protoMessage, first protoToken) error {
+	field := protoField{Line: first.line}
+	typ := first.text
+	if typ == "optional" || typ == "required" || typ == "repeated" {
+		field.Label = typ
+		typ = p.next().text
+	}
+	if typ == "map" {
+		var b strings.Builder
+		b.WriteString("map")
+		for !p.done() {
+			tok := p.next().text
+			b.WriteString(tok)
+			if tok == ">" {
+				break
+			}
+		}
+		typ = b.String()
+	}
+	if typ == "group" {
+		p.skipStatement()
+		return nil
+	}
+	field.Type = typ
+	field.Name = p.next().text
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	numTok := p.next()
+	number, err := strconv.Atoi(numTok.text)
+	if err != nil {
+		return fmt.Errorf("第 %d 行: 无效的字段编号 %q", numTok.line, numTok.text)
+	}
+	field.Number = number
+	msg.Fields[number] = field
+	p.skipStatement()
+	return nil
+}
+
+// parseEnum 解析枚举定义，当前位置为枚举名称
+func (p *protoParser) parseEnum(prefix string) error {
+	nameTok := p.next()
+	name := prefix + nameTok.text
+	enum := &protoEnum{Line: nameTok.line, Values: make(map[int]protoField), ReservedNames: make(map[string]bool)}
+	p.file.Enums[name] = enum
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	var reserved []numberRange
+	for !p.done() {
+		tok := p.next()
+		switch tok.text {
+		case "}":
+			enum.ReservedNumber = inRanges(reserved)
+			return nil
+		case ";":
+		case "reserved":
+			reserved = append(reserved, p.parseReserved(enum.ReservedNames)...)
+		case "option":
+			p.skipStatement()
+		default:
+			if err := p.expect("="); err != nil {
+				return err
+			}
+			numTok := p.next()
+			number, err := strconv.Atoi(numTok.text)
+			if err != nil {
+				return fmt.Errorf("第 %d 行: 无效的枚举值 %q", numTok.line, numTok.text)
+			}
+			// 允许别名时多个名称对应同一个值，只记录第一个
+			if _, ok := enum.Values[number]; !ok {
+				enum.Values[number] = protoField{Name: tok.text, Number: number, Line: tok.line}
+			}
+			p.skipStatement()
+		}
+	}
+	return fmt.Errorf("枚举 %s 缺少结束的 }", name)
+}
+
+// parseService 解析服务定义，当前位置为服务名称
+func (p *protoParser) parseService() error {
+	nameTok := p.next()
+	name := nameTok.text
+	p.file.Services[name] = nameTok.line
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for !p.done() {
+		tok := p.next()
+		switch tok.text {
+		case "}":
+			return nil
+		case ";":
+		case "rpc":
+			rpcTok := p.next()
+			request, err := p.parseRPCType()
+			if err != nil {
+				return err
+			}
+			if err := p.expect("returns"); err != nil {
+				return err
+			}
+			response, err := p.parseRPCType()
+			if err != nil {
+				return err
+			}
+			p.file.RPCs[name+"."+rpcTok.text] = protoRPC{Request: request, Response: response, Line: rpcTok.line}
+			if p.peek() == "{" || p.peek() == ";" {
+				p.skipStatement()
+			}
+		default:
+			p.skipStatement()
+		}
+	}
+	return fmt.Errorf("服务 %s 缺少结束的 }", name)
+}
+
+// parseRPCType 解析 rpc 的请求或响应类型，如 (stream Foo)
+func (p *protoParser) parseRPCType() (string, error) {
+	if err := p.expect("("); err != nil {
+		return "", err
+	}
+	typ := p.next().text
+	if typ == "stream" {
+		typ = "stream " + p.next().text
+	}
+	return typ, p.expect(")")
+}
+
+// numberRange 保留的编号区间
+type numberRange struct{ from, to int }
+
+// parseReserved 解析 reserved 语句，名称记入 names，返回保留的编号区间
+func (p *protoParser) parseReserved(names map[string]bool) []numberRange {
+	var ranges []numberRange
+	for !p.done() {
+		tok := p.next().text
+		switch {
+		case tok == ";":
+			return ranges
+		case tok == ",":
+		case strings.HasPrefix(tok, `"`) || strings.HasPrefix(tok, "'"):
+			names[strings.Trim(tok, `"'`)] = true
+		default:
+			from, err := strconv.Atoi(tok)
+			if err != nil {
+				// 新版本语法中保留的名称不带引号
+				names[tok] = true
+				continue
+			}
+			r := numberRange{from, from}
+			if p.peek() == "to" {
+				p.next()
+				if end := p.next().text; end == "max" {
+					r.to = 1<<29 - 1
+				} else if to, err := strconv.Atoi(end); err == nil {
+					r.to = to
+				}
+			}
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+// inRanges 返回判断编号是否在保留区间内的函数
+func inRanges(ranges []numberRange) func(int) bool {
+	return func(n int) bool {
+		for _, r := range ranges {
+			if n >= r.from && n <= r.to {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// diffProto 比较 proto 文件前后的定义，找出破坏线上兼容性的变更
+func diffProto(before, after *protoFile) []Change {
+	var changes []Change
+	add := func(severity types.SeverityLevel, line int, element, format string, args ...interface{}) {
+		changes = append(changes, Change{Element: element, Description: fmt.Sprintf(format, args...), Severity: severity, Line: line})
+	}
+
+	if before.Package != "" && before.Package != after.Package {
+		add(types.SeverityError, after.PackageLine, "package "+after.Package,
+			"包名从 %s 改为 %s，消息和服务的全限定名随之变化，已有的客户端和 gRPC 路由将无法匹配", before.Package, after.Package)
+	}
+
+	for _, name := range sortedKeys(before.Messages) {
+		old := before.Messages[name]
+		msg, ok := after.Messages[name]
+		if !ok {
+			add(types.SeverityError, 0, "message "+name, "删除了消息 %s", name)
+			continue
+		}
+		for _, number := range sortedKeys(old.Fields) {
+			oldField := old.Fields[number]
+			element := fmt.Sprintf("message %s.%s", name, oldField.Name)
+			field, ok := msg.Fields[number]
+			switch {
+			case !ok && msg.ReservedNumber(number):
+			case !ok:
+				add(types.SeverityError, msg.Line, element,
+					"删除了字段 %s（编号 %d），但没有用 reserved 保留编号，之后复用该编号会导致新旧数据按不同含义解析", oldField.Name, number)
+			case field.Type != oldField.Type:
+				add(types.SeverityError, field.Line, element,
+					"字段 %s（编号 %d）的类型从 %s 改为 %s，已有数据和客户端无法正确解析", oldField.Name, number, oldField.Type, field.Type)
+			case (field.Label == "repeated") != (oldField.Label == "repeated"):
+				add(types.SeverityError, field.Line, element,
+					"字段 %s（编号 %d）从%s改为%s，单值字段与 repeated 字段不兼容", oldField.Name, number, cardinality(oldField), cardinality(field))
+			case field.Name != oldField.Name:
+				add(types.SeverityWarning, field.Line, element,
+					"字段编号 %d 从 %s 改名为 %s，二进制编码兼容，但 JSON 编码和生成的代码不兼容", number, oldField.Name, field.Name)
+			}
+		}
+		for _, number := range sortedKeys(msg.Fields) {
+			field := msg.Fields[number]
+			if _, existed := old.Fields[number]; !existed && old.ReservedNumber != nil && old.ReservedNumber(number) {
+				add(types.SeverityError, field.Line, fmt.Sprintf("message %s.%s", name, field.Name),
+					"字段 %s 使用了此前已保留的编号 %d，旧数据中该编号的内容会被错误解析", field.Name, number)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(before.Enums) {
+		old := before.Enums[name]
+		enum, ok := after.Enums[name]
+		if !ok {
+			add(types.SeverityError, 0, "enum "+name, "删除了枚举 %s", name)
+			continue
+		}
+		for _, number := range sortedKeys(old.Values) {
+			oldValue := old.Values[number]
+			element := fmt.Sprintf("enum %s.%s", name, oldValue.Name)
+			value, ok := enum.Values[number]
+			switch {
+			case !ok && enum.ReservedNumber(number):
+			case !ok:
+				add(types.SeverityError, enum.Line, element, "删除了枚举值 %s（%d），但没有用 reserved 保留", oldValue.Name, number)
+			case value.Name != oldValue.Name:
+				add(types.SeverityWarning, value.Line, element,
+					"枚举值 %d 从 %s 改名为 %s，JSON 编码和生成的代码不兼容", number, oldValue.Name, value.Name)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(before.Services) {
+		if _, ok := after.Services[name]; !ok {
+			add(types.SeverityError, 0, "service "+name, "删除了服务 %s", name)
+		}
+	}
+	for _, name := range sortedKeys(before.RPCs) {
+		old := before.RPCs[name]
+		rpc, ok := after.RPCs[name]
+		service, _, _ := strings.Cut(name, ".")
+		switch {
+		case !ok:
+			if _, exists := after.Services[service]; exists {
+				add(types.SeverityError, after.Services[service], "rpc "+name, "删除了方法 %s", name)
+			}
+		case rpc.Request != old.Request || rpc.Response != old.Response:
+			add(types.SeverityError, rpc.Line, "rpc "+name,
+				"方法 %s 的签名从 (%s) returns (%s) 改为 (%s) returns (%s)", name, old.Request, old.Response, rpc.Request, rpc.Response)
+		}
+	}
+	return changes
+}
+
+// cardinality 返回字段是单值还是 repeated 的说明
+func cardinality(field protoField) string {
+	if field.Label == "repeated" {
+		return " repeated "
+	}
+	return "单值"
+}
+
+// sortedKeys 返回映射按顺序排列的键
+func sortedKeys[K int | string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,188 @@
+package batch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/icatw/ai-cr-tool/pkg/review"
+)
+
+// Manifest 批量评审清单，YAML 和 JSON 格式均可
+//
+//	concurrency: 2
+//	jobs:
+//	  - name: api
+//	    repo: ../services/api
+//	    range: origin/main~20..origin/main
+//	    profile: profiles/strict.yaml
+//	    outputs:
+//	      - {format: markdown, path: reports/api.md}
+//	      - {format: json, path: reports/api.json}
+type Manifest struct {
+	// 同时执行的任务数，默认逐个执行
+	Concurrency int `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+	// 评审任务列表
+	Jobs []Job `yaml:"jobs" json:"jobs"`
+}
+
+// Job 单个评审任务
+type Job struct {
+	// 任务名称，默认为仓库目录名
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// 仓库路径，相对路径以清单文件所在目录为准
+	Repo string `yaml:"repo" json:"repo"`
+	// 评审范围，最多指定一项；都不指定时与 cr 默认行为一致
+	Range  string   `yaml:"range,omitempty" json:"range,omitempty"`
+	Commit string   `yaml:"commit,omitempty" json:"commit,omitempty"`
+	Staged bool     `yaml:"staged,omitempty" json:"staged,omitempty"`
+	Files  []string `yaml:"files,omitempty" json:"files,omitempty"`
+	// 评审配置文件（.cr.yaml 格式），默认使用仓库中的配置
+	Profile string `yaml:"profile,omitempty" json:"profile,omitempty"`
+	// 覆盖配置中的模型、门禁级别和报告语言
+	Model  string `yaml:"model,omitempty" json:"model,omitempty"`
+	FailOn string `yaml:"fail_on,omitempty" json:"fail_on,omitempty"`
+	Lang   string `yaml:"lang,omitempty" json:"lang,omitempty"`
+	// 追加的命令行参数，如 ["--max-files", "50"]
+	Args []string `yaml:"args,omitempty" json:"args,omitempty"`
+	// 报告输出
+	Outputs []Output `yaml:"outputs" json:"outputs"`
+}
+
+// Output 报告输出位置
+type Output struct {
+	Format string `yaml:"format" json:"format"`
+	// 报告路径，相对路径以清单文件所在目录为准
+	Path string `yaml:"path" json:"path"`
+	// template 格式使用的模板文件，相对路径以清单文件所在目录为准
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
+}
+
+// Load 读取并校验批量评审清单，清单中的相对路径会转换为绝对路径
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取批量评审清单失败: %v", err)
+	}
+
+	// JSON 是 YAML 的子集，统一按 YAML 解析
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("解析批量评审清单失败: %v", err)
+	}
+
+	base, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("获取清单所在目录失败: %v", err)
+	}
+	if err := m.normalize(base); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// normalize 填充默认值、解析相对路径并校验任务
+func (m *Manifest) normalize(base string) error {
+	if len(m.Jobs) == 0 {
+		return fmt.Errorf("批量评审清单中没有任务")
+	}
+	if m.Concurrency < 0 {
+		return fmt.Errorf("并发数不能为负数：%d", m.Concurrency)
+	}
+	if m.Concurrency == 0 {
+		m.Concurrency = 1
+	}
+
+	names := make(map[string]bool)
+	for i := range m.Jobs {
+		job := &m.Jobs[i]
+		if job.Repo == "" {
+			return fmt.Errorf("第%d个任务未指定 repo", i+1)
+		}
+		job.Repo = resolve(base, job.Repo)
+		if job.Name == "" {
+			job.Name = filepath.Base(job.Repo)
+		}
+		if names[job.Name] {
+			return fmt.Errorf("任务名称重复：%s", job.Name)
+		}
+		names[job.Name] = true
+
+		scopes := 0
+		for _, set := range []bool{job.Range != "", job.Commit != "", job.Staged, len(job.Files) > 0} {
+			if set {
+				scopes++
+			}
+		}
+		if scopes > 1 {
+			return fmt.Errorf("任务 %s 只能指定 range、commit、staged、files 中的一项", job.Name)
+		}
+
+		if job.Profile != "" {
+			job.Profile = resolve(base, job.Profile)
+		}
+		if len(job.Outputs) == 0 {
+			return fmt.Errorf("任务 %s 未指定 outputs", job.Name)
+		}
+		for j := range job.Outputs {
+			out := &job.Outputs[j]
+			format, err := review.ParseReportFormat(out.Format)
+			if err != nil {
+				return fmt.Errorf("任务 %s: %v", job.Name, err)
+			}
+			if out.Path == "" {
+				return fmt.Errorf("任务 %s 的第%d个输出未指定 path", job.Name, j+1)
+			}
+			out.Path = resolve(base, out.Path)
+			if fallback, path, ok := review.PDFFallback(format, out.Path); ok {
+				fmt.Fprintf(os.Stderr, "未找到 wkhtmltopdf，任务 %s 的PDF报告改为输出HTML：%s\n", job.Name, path)
+				out.Format, out.Path = string(fallback), path
+			}
+			if out.Template != "" {
+				out.Template = resolve(base, out.Template)
+			} else if format == review.TemplateFormat {
+				return fmt.Errorf("任务 %s 的第%d个输出使用 template 格式但未指定 template", job.Name, j+1)
+			}
+		}
+	}
+	return nil
+}
+
+// CLIArgs 将任务转换为等价的 cr 命令行参数
+func (j Job) CLIArgs() []string {
+	var args []string
+	switch {
+	case j.Range != "":
+		args = append(args, "--commit-range", j.Range)
+	case j.Commit != "":
+		args = append(args, "--commit", j.Commit)
+	case j.Staged:
+		args = append(args, "--staged")
+	case len(j.Files) > 0:
+		args = append(args, "--files", strings.Join(j.Files, ","))
+	}
+	if j.Profile != "" {
+		args = append(args, "--config", j.Profile)
+	}
+	if j.Model != "" {
+		args = append(args, "--model", j.Model)
+	}
+	if j.FailOn != "" {
+		args = append(args, "--fail-on", j.FailOn)
+	}
+	if j.Lang != "" {
+		args = append(args, "--lang", j.Lang)
+	}
+	return append(args, j.Args...)
+}
+
+// resolve 将相对路径转换为相对 base 的绝对路径
+func resolve(base, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(base, path)
+}
@@ -0,0 +1,145 @@
+package bitbucket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// CloudAPIURL Bitbucket Cloud REST API 地址
+const CloudAPIURL = "https://api.bitbucket.org/2.0"
+
+// Client Bitbucket REST API 客户端，同时支持 Bitbucket Cloud 和自建的 Bitbucket Server/Data Center
+type Client struct {
+	// Cloud 为 API 地址，Server 为实例地址
+	baseURL string
+	// Server 为 true 时使用 Bitbucket Server 的接口
+	server bool
+	token  string
+	// 使用用户名和应用密码认证时设置，仅 Bitbucket Cloud
+	username string
+	password string
+	// Cloud 为 workspace，Server 为项目 key
+	owner  string
+	repo   string
+	client *http.Client
+}
+
+// NewClient 创建访问指定仓库的客户端，repo 形如 workspace/slug（Cloud）或 PROJECT/slug（Server）
+// baseURL 为空时访问 Bitbucket Cloud，否则视为 Bitbucket Server 的实例地址
+func NewClient(baseURL, token, repo string) (*Client, error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || owner == "" || name == "" {
+		return nil, fmt.Errorf("仓库名称格式应为 workspace/slug 或 PROJECT/slug：%s", repo)
+	}
+	c := &Client{
+		baseURL: CloudAPIURL,
+		token:   token,
+		owner:   owner,
+		repo:    name,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+	if baseURL != "" {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+		c.server = true
+	}
+	return c, nil
+}
+
+// NewClientFromEnv 使用环境变量创建客户端
+// 访问令牌读取 BITBUCKET_TOKEN，Bitbucket Cloud 也可以使用 BITBUCKET_USERNAME 和 BITBUCKET_APP_PASSWORD；
+// baseURL 为空时读取 BITBUCKET_URL，仍为空时访问 Bitbucket Cloud；repo 为空时使用 Bitbucket Pipelines 提供的 BITBUCKET_REPO_FULL_NAME
+func NewClientFromEnv(baseURL, repo string) (*Client, error) {
+	if baseURL == "" {
+		baseURL = os.Getenv("BITBUCKET_URL")
+	}
+	if repo == "" {
+		repo = os.Getenv("BITBUCKET_REPO_FULL_NAME")
+	}
+	token := os.Getenv("BITBUCKET_TOKEN")
+	username, password := os.Getenv("BITBUCKET_USERNAME"), os.Getenv("BITBUCKET_APP_PASSWORD")
+	if token == "" && (baseURL != "" || username == "" || password == "") {
+		return nil, fmt.Errorf("未设置 BITBUCKET_TOKEN 环境变量（Bitbucket Cloud 也可以设置 BITBUCKET_USERNAME 和 BITBUCKET_APP_PASSWORD）")
+	}
+	c, err := NewClient(baseURL, token, repo)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		c.username, c.password = username, password
+	}
+	return c, nil
+}
+
+// Repo 返回 workspace/slug 或 PROJECT/slug 形式的仓库名称
+func (c *Client) Repo() string {
+	return c.owner + "/" + c.repo
+}
+
+// Server 返回是否访问 Bitbucket Server/Data Center
+func (c *Client) Server() bool {
+	return c.server
+}
+
+// APIError 接口返回的错误状态
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("Bitbucket API %s %s 返回 %d: %s", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+// do 发送API请求，body 和 out 为 nil 时分别表示不发送请求体、不解析响应
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求 Bitbucket 失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取 Bitbucket 响应失败: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &APIError{Method: method, Path: path, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("解析 Bitbucket 响应失败: %v", err)
+		}
+	}
+	return nil
+}
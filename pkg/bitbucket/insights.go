@@ -0,0 +1,153 @@
+package bitbucket
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const (
+	// MaxAnnotations 一份报告最多包含的注解数
+	MaxAnnotations = 1000
+	// annotationBatch 每次请求最多提交的注解数
+	annotationBatch = 100
+)
+
+// 注解的严重程度
+const (
+	SeverityLow    = "LOW"
+	SeverityMedium = "MEDIUM"
+	SeverityHigh   = "HIGH"
+)
+
+// 注解的类型
+const (
+	TypeBug           = "BUG"
+	TypeCodeSmell     = "CODE_SMELL"
+	TypeVulnerability = "VULNERABILITY"
+)
+
+// Report Code Insights 报告，显示在 pull request 的 Reports 标签页
+type Report struct {
+	Title    string
+	Details  string
+	Reporter string
+	// 完整报告的链接，可以为空
+	Link   string
+	Passed bool
+	Data   []ReportData
+}
+
+// ReportData 报告中展示的一项数据，Type 为 NUMBER、TEXT、PERCENTAGE 或 BOOLEAN
+type ReportData struct {
+	Title string      `json:"title"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// Annotation 报告中的一条注解，显示在 pull request 差异的对应行上
+type Annotation struct {
+	ExternalID string
+	Path       string
+	Line       int
+	Message    string
+	Details    string
+	Severity   string
+	Type       string
+}
+
+// PutReport 创建或替换提交上的报告；已有同名报告时先删除，使旧的注解一并清除
+func (c *Client) PutReport(commit, key string, report Report) error {
+	path := c.reportPath(commit, key)
+	var apiErr *APIError
+	if err := c.do("DELETE", path, nil, nil); err != nil && !(errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound) {
+		return err
+	}
+
+	if c.server {
+		result := "FAIL"
+		if report.Passed {
+			result = "PASS"
+		}
+		body := map[string]interface{}{
+			"title":    report.Title,
+			"details":  report.Details,
+			"reporter": report.Reporter,
+			"result":   result,
+			"data":     report.Data,
+		}
+		if report.Link != "" {
+			body["link"] = report.Link
+		}
+		return c.do("PUT", path, body, nil)
+	}
+
+	result := "FAILED"
+	if report.Passed {
+		result = "PASSED"
+	}
+	body := map[string]interface{}{
+		"title":       report.Title,
+		"details":     report.Details,
+		"reporter":    report.Reporter,
+		"report_type": "BUG",
+		"result":      result,
+		"data":        report.Data,
+	}
+	if report.Link != "" {
+		body["link"] = report.Link
+	}
+	return c.do("PUT", path, body, nil)
+}
+
+// AddAnnotations 为报告添加注解，超过接口单次上限时分批提交
+func (c *Client) AddAnnotations(commit, key string, annotations []Annotation) error {
+	path := c.reportPath(commit, key) + "/annotations"
+	for start := 0; start < len(annotations); start += annotationBatch {
+		batch := annotations[start:min(start+annotationBatch, len(annotations))]
+		if c.server {
+			items := make([]map[string]interface{}, 0, len(batch))
+			for _, a := range batch {
+				items = append(items, map[string]interface{}{
+					"externalId": a.ExternalID,
+					"path":       a.Path,
+					"line":       a.Line,
+					"message":    a.Message,
+					"severity":   a.Severity,
+					"type":       a.Type,
+				})
+			}
+			if err := c.do("POST", path, map[string]interface{}{"annotations": items}, nil); err != nil {
+				return err
+			}
+			continue
+		}
+
+		items := make([]map[string]interface{}, 0, len(batch))
+		for _, a := range batch {
+			items = append(items, map[string]interface{}{
+				"external_id":     a.ExternalID,
+				"path":            a.Path,
+				"line":            a.Line,
+				"summary":         a.Message,
+				"details":         a.Details,
+				"severity":        a.Severity,
+				"annotation_type": a.Type,
+			})
+		}
+		if err := c.do("POST", path, items, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportPath 返回提交上指定报告的接口路径
+func (c *Client) reportPath(commit, key string) string {
+	if c.server {
+		return fmt.Sprintf("/rest/insights/1.0/projects/%s/repos/%s/commits/%s/reports/%s",
+			url.PathEscape(c.owner), url.PathEscape(c.repo), commit, url.PathEscape(key))
+	}
+	return c.cloudPath(fmt.Sprintf("/commit/%s/reports/%s", commit, url.PathEscape(key)))
+}
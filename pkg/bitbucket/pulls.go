@@ -0,0 +1,106 @@
+package bitbucket
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// PullRequest Bitbucket pull request
+type PullRequest struct {
+	ID int
+	// pull request 页面的链接
+	URL string
+	// 源分支最新提交的 SHA
+	Commit string
+}
+
+// GetPullRequest 获取 pull request
+func (c *Client) GetPullRequest(id int) (*PullRequest, error) {
+	if c.server {
+		var pr struct {
+			ID      int `json:"id"`
+			FromRef struct {
+				LatestCommit string `json:"latestCommit"`
+			} `json:"fromRef"`
+			Links struct {
+				Self []struct {
+					Href string `json:"href"`
+				} `json:"self"`
+			} `json:"links"`
+		}
+		if err := c.do("GET", c.serverPath(fmt.Sprintf("/pull-requests/%d", id)), nil, &pr); err != nil {
+			return nil, err
+		}
+		result := &PullRequest{ID: pr.ID, Commit: pr.FromRef.LatestCommit}
+		if len(pr.Links.Self) > 0 {
+			result.URL = pr.Links.Self[0].Href
+		}
+		return result, nil
+	}
+
+	var pr struct {
+		ID     int `json:"id"`
+		Source struct {
+			Commit struct {
+				Hash string `json:"hash"`
+			} `json:"commit"`
+		} `json:"source"`
+		Links struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	}
+	if err := c.do("GET", c.cloudPath(fmt.Sprintf("/pullrequests/%d", id)), nil, &pr); err != nil {
+		return nil, err
+	}
+	return &PullRequest{ID: pr.ID, URL: pr.Links.HTML.Href, Commit: pr.Source.Commit.Hash}, nil
+}
+
+// CreateComment 在 pull request 上发表一条评论，返回评论的链接
+func (c *Client) CreateComment(id int, text string) (string, error) {
+	if c.server {
+		var created struct {
+			ID int64 `json:"id"`
+		}
+		path := c.serverPath(fmt.Sprintf("/pull-requests/%d/comments", id))
+		if err := c.do("POST", path, map[string]string{"text": text}, &created); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s/projects/%s/repos/%s/pull-requests/%d/overview?commentId=%d",
+			c.baseURL, url.PathEscape(c.owner), url.PathEscape(c.repo), id, created.ID), nil
+	}
+
+	var created struct {
+		Links struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	}
+	body := map[string]interface{}{"content": map[string]string{"raw": text}}
+	if err := c.do("POST", c.cloudPath(fmt.Sprintf("/pullrequests/%d/comments", id)), body, &created); err != nil {
+		return "", err
+	}
+	return created.Links.HTML.Href, nil
+}
+
+// cloudPath 返回 Bitbucket Cloud 仓库相关接口的路径
+func (c *Client) cloudPath(path string) string {
+	return fmt.Sprintf("/repositories/%s/%s%s", url.PathEscape(c.owner), url.PathEscape(c.repo), path)
+}
+
+// serverPath 返回 Bitbucket Server 仓库相关接口的路径
+func (c *Client) serverPath(path string) string {
+	return fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s%s", url.PathEscape(c.owner), url.PathEscape(c.repo), path)
+}
+
+// PullRequestIDFromEnv 在 Bitbucket Pipelines 的 pull request 流水线中获取当前 pull request 的编号
+func PullRequestIDFromEnv() (int, error) {
+	if id, err := strconv.Atoi(os.Getenv("BITBUCKET_PR_ID")); err == nil && id > 0 {
+		return id, nil
+	}
+	return 0, fmt.Errorf("无法从 Bitbucket Pipelines 环境中获取 pull request 编号（BITBUCKET_PR_ID），请通过 --pr 指定")
+}
@@ -6,13 +6,21 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+	"unicode"
 )
 
 // ReviewCache 代码评审缓存
 type ReviewCache struct {
 	// 缓存目录路径
 	cacheDir string
+	// 内存LRU缓存，为nil时表示禁用
+	memory *shardedLRU
+	// 只读模式下只读取磁盘缓存，新的评审结果只保存在内存中
+	readOnly bool
+	// 异步写入磁盘的写入线程，为nil时同步写入
+	writer *asyncWriter
 }
 
 // CacheItem 缓存项
@@ -25,28 +33,103 @@ type CacheItem struct {
 	CachedAt time.Time `json:"cached_at"`
 	// 过期时间（可选）
 	ExpireAt *time.Time `json:"expire_at,omitempty"`
+	// 生成该结果时评审提示模板的指纹，为空表示未记录
+	PromptFingerprint string `json:"prompt_fingerprint,omitempty"`
+	// 生成该结果的模型，为空表示未记录
+	Model string `json:"model,omitempty"`
 }
 
-// NewReviewCache 创建新的评审缓存管理器
+// NewReviewCache 创建新的评审缓存管理器，内存缓存使用默认字节预算
 func NewReviewCache(cacheDir string) (*ReviewCache, error) {
+	return NewReviewCacheWithLimit(cacheDir, DefaultMemoryLimit)
+}
+
+// NewReviewCacheWithLimit 创建指定内存字节预算的评审缓存管理器
+// maxMemoryBytes<=0 时只使用磁盘缓存
+func NewReviewCacheWithLimit(cacheDir string, maxMemoryBytes int64) (*ReviewCache, error) {
 	// 确保缓存目录存在
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
 		return nil, fmt.Errorf("创建缓存目录失败: %v", err)
 	}
 
-	return &ReviewCache{cacheDir: cacheDir}, nil
+	return &ReviewCache{
+		cacheDir: cacheDir,
+		memory:   newShardedLRU(maxMemoryBytes),
+	}, nil
+}
+
+// NewAsyncReviewCache 创建异步写入磁盘的评审缓存管理器
+// 新的评审结果由单独的写入线程批量写入，最多 writeQueue 个等待写入，队列满时 Set 阻塞；
+// writeQueue<=0 时同步写入。使用完毕后需要调用 Close 等待写入完成
+func NewAsyncReviewCache(cacheDir string, maxMemoryBytes int64, writeQueue int) (*ReviewCache, error) {
+	c, err := NewReviewCacheWithLimit(cacheDir, maxMemoryBytes)
+	if err != nil {
+		return nil, err
+	}
+	if writeQueue > 0 {
+		c.writer = newAsyncWriter(cacheDir, writeQueue)
+	}
+	return c, nil
+}
+
+// Close 等待异步写入全部完成，返回写入失败的汇总；同步写入时直接返回
+// 关闭后新的评审结果改为同步写入
+func (c *ReviewCache) Close() error {
+	if c.writer == nil {
+		return nil
+	}
+	return c.writer.close()
+}
+
+// NewReadOnlyReviewCache 创建只读的评审缓存管理器
+// 已有的磁盘缓存仍会被命中，但不会创建缓存目录、写入新结果或删除过期文件
+func NewReadOnlyReviewCache(cacheDir string, maxMemoryBytes int64) *ReviewCache {
+	return &ReviewCache{
+		cacheDir: cacheDir,
+		memory:   newShardedLRU(maxMemoryBytes),
+		readOnly: true,
+	}
 }
 
-// Get 获取缓存的评审结果
+// MemoryUsage 返回内存缓存当前的条目数和占用字节数
+func (c *ReviewCache) MemoryUsage() (int, int64) {
+	if c.memory == nil {
+		return 0, 0
+	}
+	return c.memory.usage()
+}
+
+// Get 获取缓存的评审结果，不检查提示模板指纹
 func (c *ReviewCache) Get(content string) (*CacheItem, error) {
+	return c.GetWithPrompt(content, "")
+}
+
+// GetWithPrompt 获取缓存的评审结果，promptFingerprint 不为空且与缓存项记录的指纹不同时，
+// 视为旧提示模板下生成的过期结果，删除后按未命中处理
+func (c *ReviewCache) GetWithPrompt(content, promptFingerprint string) (*CacheItem, error) {
 	// 计算内容哈希
 	contentHash := c.hashContent(content)
-
-	// 构建缓存文件路径
 	cacheFile := filepath.Join(c.cacheDir, contentHash+".json")
 
-	// 读取缓存文件
-	data, err := os.ReadFile(cacheFile)
+	// 优先查询内存缓存
+	if c.memory != nil {
+		if item, ok := c.memory.get(contentHash); ok {
+			if stale(item, promptFingerprint) {
+				return nil, c.invalidate(contentHash, cacheFile)
+			}
+			return item, nil
+		}
+	}
+
+	// 读取缓存文件，尚未写入磁盘的结果从写入队列中读取
+	var data []byte
+	var err error
+	if c.writer != nil {
+		data, _ = c.writer.lookup(contentHash)
+	}
+	if data == nil {
+		data, err = os.ReadFile(cacheFile)
+	}
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
@@ -62,23 +145,62 @@ func (c *ReviewCache) Get(content string) (*CacheItem, error) {
 
 	// 检查是否过期
 	if item.ExpireAt != nil && time.Now().After(*item.ExpireAt) {
-		// 删除过期缓存
-		if err := os.Remove(cacheFile); err != nil {
-			return nil, fmt.Errorf("删除过期缓存文件失败: %v", err)
-		}
-		return nil, nil
+		// 删除过期缓存，其他goroutine可能已经删除了该文件
+		return nil, c.invalidate(contentHash, cacheFile)
+	}
+	if stale(&item, promptFingerprint) {
+		return nil, c.invalidate(contentHash, cacheFile)
+	}
+
+	// 回填内存缓存
+	if c.memory != nil {
+		c.memory.set(contentHash, &item)
 	}
 
 	return &item, nil
 }
 
-// Set 设置评审结果缓存
+// stale 判断缓存项是否在不同的提示模板下生成
+func stale(item *CacheItem, promptFingerprint string) bool {
+	return promptFingerprint != "" && item.PromptFingerprint != promptFingerprint
+}
+
+// invalidate 删除内存和磁盘中的缓存项，只读模式下保留磁盘文件
+func (c *ReviewCache) invalidate(contentHash, cacheFile string) error {
+	if c.memory != nil {
+		c.memory.remove(contentHash)
+	}
+	if c.readOnly {
+		return nil
+	}
+	if c.writer != nil {
+		c.writer.cancel(contentHash)
+	}
+	if err := os.Remove(cacheFile); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除过期缓存文件失败: %v", err)
+	}
+	return nil
+}
+
+// Set 设置评审结果缓存，不记录提示模板指纹
 func (c *ReviewCache) Set(content string, result string, expireAfter *time.Duration) error {
+	return c.SetWithPrompt(content, result, expireAfter, "")
+}
+
+// SetWithPrompt 设置评审结果缓存，并记录生成结果时提示模板的指纹
+func (c *ReviewCache) SetWithPrompt(content, result string, expireAfter *time.Duration, promptFingerprint string) error {
+	return c.SetWithModel(content, result, expireAfter, promptFingerprint, "")
+}
+
+// SetWithModel 设置评审结果缓存，并记录生成结果时提示模板的指纹和使用的模型
+func (c *ReviewCache) SetWithModel(content, result string, expireAfter *time.Duration, promptFingerprint, model string) error {
 	// 创建缓存项
 	item := CacheItem{
-		ContentHash:  c.hashContent(content),
-		ReviewResult: result,
-		CachedAt:     time.Now(),
+		ContentHash:       c.hashContent(content),
+		ReviewResult:      result,
+		CachedAt:          time.Now(),
+		PromptFingerprint: promptFingerprint,
+		Model:             model,
 	}
 
 	// 设置过期时间（如果指定）
@@ -93,19 +215,76 @@ func (c *ReviewCache) Set(content string, result string, expireAfter *time.Durat
 		return err
 	}
 
-	// 写入缓存文件
-	cacheFile := filepath.Join(c.cacheDir, item.ContentHash+".json")
-	return os.WriteFile(cacheFile, data, 0644)
+	if c.readOnly {
+		if c.memory != nil {
+			c.memory.set(item.ContentHash, &item)
+		}
+		return nil
+	}
+
+	// 写入缓存文件，异步写入时只提交到写入队列
+	if c.writer == nil || !c.writer.enqueue(item.ContentHash, data) {
+		cacheFile := filepath.Join(c.cacheDir, item.ContentHash+".json")
+		if err := writeFileAtomic(cacheFile, data); err != nil {
+			return err
+		}
+	}
+
+	if c.memory != nil {
+		c.memory.set(item.ContentHash, &item)
+	}
+	return nil
 }
 
-// hashContent 计算内容的哈希值
+// writeFileAtomic 先写入临时文件再重命名，避免并发读取到写了一半的缓存文件
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// hashContent 计算内容的哈希值，先统一换行符并去掉行尾空白，
+// 同一改动在 Windows（CRLF）和 Linux（LF）上生成的差异共用缓存项
 func (c *ReviewCache) hashContent(content string) string {
-	hash := sha256.Sum256([]byte(content))
+	hash := sha256.Sum256([]byte(normalizeContent(content)))
 	return fmt.Sprintf("%x", hash)
 }
 
+// normalizeContent 将 CRLF 换行符统一为 LF，并去掉每行末尾的空白；不需要归一化时原样返回
+func normalizeContent(content string) string {
+	lines := strings.Split(content, "\n")
+	changed := false
+	for i, line := range lines {
+		if trimmed := strings.TrimRightFunc(line, unicode.IsSpace); len(trimmed) != len(line) {
+			lines[i], changed = trimmed, true
+		}
+	}
+	if !changed {
+		return content
+	}
+	return strings.Join(lines, "\n")
+}
+
 // Clear 清理过期的缓存文件
 func (c *ReviewCache) Clear() error {
+	if c.readOnly {
+		return nil
+	}
+
 	// 遍历缓存目录
 	files, err := os.ReadDir(c.cacheDir)
 	if err != nil {
@@ -132,7 +311,10 @@ func (c *ReviewCache) Clear() error {
 
 		// 删除过期的缓存文件
 		if item.ExpireAt != nil && time.Now().After(*item.ExpireAt) {
-			if err := os.Remove(filePath); err != nil {
+			if c.memory != nil {
+				c.memory.remove(item.ContentHash)
+			}
+			if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
 				// 记录错误但继续处理其他文件
 				fmt.Printf("删除过期缓存文件失败 %s: %v\n", filePath, err)
 			}
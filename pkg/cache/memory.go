@@ -0,0 +1,273 @@
+package cache
+
+import (
+	"container/list"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultMemoryLimit 内存缓存默认字节预算（32MB）
+	DefaultMemoryLimit int64 = 32 << 20
+
+	// entryOverhead 每个缓存项的固定开销估算（哈希、时间戳、链表节点等）
+	entryOverhead = 256
+)
+
+// memoryEntry 内存缓存中的单个条目
+type memoryEntry struct {
+	key          string
+	item         *CacheItem
+	size         int64
+	LastAccessed time.Time
+	// 访问序号，越大越近，分片缓存据此在各分片之间比较最久未使用的条目
+	seq uint64
+}
+
+// accessSeq 全局递增的访问序号，时间戳在连续操作中可能相同，不能用于精确排序
+var accessSeq atomic.Uint64
+
+// memoryLRU 按字节预算淘汰的内存LRU缓存
+// 评审结果从1KB到100KB不等，按条目数限制容易浪费内存或频繁抖动，因此按字节计算容量
+type memoryLRU struct {
+	mu       sync.Mutex
+	maxBytes int64
+	curBytes int64
+	ll       *list.List
+	entries  map[string]*list.Element
+	// 分片缓存中所有分片共享的占用字节数，单独使用时为 nil
+	shared *atomic.Int64
+}
+
+// newMemoryLRU 创建指定字节预算的内存缓存，maxBytes<=0 表示禁用
+func newMemoryLRU(maxBytes int64) *memoryLRU {
+	if maxBytes <= 0 {
+		return nil
+	}
+	return &memoryLRU{
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// itemSize 估算缓存项占用的字节数
+func itemSize(item *CacheItem) int64 {
+	return int64(len(item.ContentHash)+len(item.ReviewResult)) + entryOverhead
+}
+
+// get 获取缓存项并将其标记为最近使用
+func (m *memoryLRU) get(key string) (*CacheItem, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*memoryEntry)
+	if entry.item.ExpireAt != nil && time.Now().After(*entry.item.ExpireAt) {
+		m.removeElement(elem)
+		return nil, false
+	}
+
+	entry.LastAccessed = time.Now()
+	entry.seq = accessSeq.Add(1)
+	m.ll.MoveToFront(elem)
+	return entry.item, true
+}
+
+// set 写入缓存项，超出字节预算时从最久未使用的条目开始淘汰
+func (m *memoryLRU) set(key string, item *CacheItem) {
+	size := itemSize(item)
+	// 单个条目超过总预算时不放入内存，避免清空整个缓存
+	if size > m.maxBytes {
+		m.remove(key)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.put(key, item, size)
+	for m.curBytes > m.maxBytes {
+		oldest := m.ll.Back()
+		if oldest == nil {
+			break
+		}
+		m.removeElement(oldest)
+	}
+}
+
+// put 写入缓存项并标记为最近使用，不做淘汰，调用方需持有锁
+func (m *memoryLRU) put(key string, item *CacheItem, size int64) {
+	if elem, ok := m.entries[key]; ok {
+		entry := elem.Value.(*memoryEntry)
+		m.addBytes(size - entry.size)
+		entry.item = item
+		entry.size = size
+		entry.LastAccessed = time.Now()
+		entry.seq = accessSeq.Add(1)
+		m.ll.MoveToFront(elem)
+		return
+	}
+	entry := &memoryEntry{key: key, item: item, size: size, LastAccessed: time.Now(), seq: accessSeq.Add(1)}
+	m.entries[key] = m.ll.PushFront(entry)
+	m.addBytes(size)
+}
+
+// oldest 返回除 keep 以外最久未使用的条目的访问序号，没有可淘汰的条目时返回 false
+func (m *memoryLRU) oldest(keep string) (uint64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if elem := m.oldestElement(keep); elem != nil {
+		return elem.Value.(*memoryEntry).seq, true
+	}
+	return 0, false
+}
+
+// evictOldest 淘汰除 keep 以外最久未使用的一个条目，返回是否淘汰了条目
+func (m *memoryLRU) evictOldest(keep string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem := m.oldestElement(keep)
+	if elem == nil {
+		return false
+	}
+	m.removeElement(elem)
+	return true
+}
+
+// oldestElement 返回除 keep 以外最久未使用的链表节点，调用方需持有锁
+func (m *memoryLRU) oldestElement(keep string) *list.Element {
+	for elem := m.ll.Back(); elem != nil; elem = elem.Prev() {
+		if elem.Value.(*memoryEntry).key != keep {
+			return elem
+		}
+	}
+	return nil
+}
+
+// addBytes 更新占用字节数，分片缓存中同时更新共享的总数，调用方需持有锁
+func (m *memoryLRU) addBytes(delta int64) {
+	m.curBytes += delta
+	if m.shared != nil {
+		m.shared.Add(delta)
+	}
+}
+
+// remove 删除指定缓存项
+func (m *memoryLRU) remove(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.entries[key]; ok {
+		m.removeElement(elem)
+	}
+}
+
+// removeElement 删除链表节点并更新占用字节数，调用方需持有锁
+func (m *memoryLRU) removeElement(elem *list.Element) {
+	entry := m.ll.Remove(elem).(*memoryEntry)
+	delete(m.entries, entry.key)
+	m.addBytes(-entry.size)
+}
+
+// usage 返回当前条目数和占用字节数
+func (m *memoryLRU) usage() (int, int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ll.Len(), m.curBytes
+}
+
+// shardCount 内存缓存分片数
+const shardCount = 16
+
+// shardedLRU 分片的内存LRU缓存
+// 每个分片持有独立的互斥锁，读取时也会调整LRU顺序，因此不能使用读锁；
+// 分片可以降低并发评审时多个goroutine争用同一把锁的开销。
+// 字节预算由所有分片共享，单个条目最多可以使用整个预算；超出预算时淘汰各分片中最久未使用的条目
+type shardedLRU struct {
+	shards   [shardCount]*memoryLRU
+	maxBytes int64
+	curBytes atomic.Int64
+	// 淘汰时只允许一个goroutine扫描各分片，避免多个写入者同时淘汰过多条目
+	evictMu sync.Mutex
+}
+
+// newShardedLRU 创建分片内存缓存，maxBytes<=0 表示禁用
+func newShardedLRU(maxBytes int64) *shardedLRU {
+	if maxBytes <= 0 {
+		return nil
+	}
+	s := &shardedLRU{maxBytes: maxBytes}
+	for i := range s.shards {
+		// 分片自身不淘汰，由共享的字节预算统一控制
+		s.shards[i] = newMemoryLRU(maxBytes)
+		s.shards[i].shared = &s.curBytes
+	}
+	return s
+}
+
+// shard 根据键选择分片
+func (s *shardedLRU) shard(key string) *memoryLRU {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return s.shards[h.Sum32()%shardCount]
+}
+
+func (s *shardedLRU) get(key string) (*CacheItem, bool) {
+	return s.shard(key).get(key)
+}
+
+// set 写入缓存项，超出共享的字节预算时淘汰其他条目，刚写入的条目保留
+func (s *shardedLRU) set(key string, item *CacheItem) {
+	size := itemSize(item)
+	shard := s.shard(key)
+	if size > s.maxBytes {
+		shard.remove(key)
+		return
+	}
+	shard.mu.Lock()
+	shard.put(key, item, size)
+	shard.mu.Unlock()
+	s.evict(key)
+}
+
+// evict 淘汰条目直到占用字节数不超过预算，每次淘汰所有分片中最久未使用的条目
+// 每次只持有一个分片的锁，不会与读写分片的goroutine死锁
+func (s *shardedLRU) evict(keep string) {
+	s.evictMu.Lock()
+	defer s.evictMu.Unlock()
+	for s.curBytes.Load() > s.maxBytes {
+		var victim *memoryLRU
+		var victimSeq uint64
+		for _, shard := range s.shards {
+			if seq, ok := shard.oldest(keep); ok && (victim == nil || seq < victimSeq) {
+				victim, victimSeq = shard, seq
+			}
+		}
+		// 分片在扫描后被其他goroutine清空时重新扫描，没有可淘汰的条目时结束
+		if victim == nil {
+			return
+		}
+		victim.evictOldest(keep)
+	}
+}
+
+func (s *shardedLRU) remove(key string) {
+	s.shard(key).remove(key)
+}
+
+// usage 返回所有分片的条目数和占用字节数之和
+func (s *shardedLRU) usage() (int, int64) {
+	var items int
+	for _, shard := range s.shards {
+		n, _ := shard.usage()
+		items += n
+	}
+	return items, s.curBytes.Load()
+}
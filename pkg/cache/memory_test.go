@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newTestItem 创建评审结果为 size 字节的缓存项
+func newTestItem(key string, size int) *CacheItem {
+	return &CacheItem{ContentHash: key, ReviewResult: strings.Repeat("x", size)}
+}
+
+func TestShardedLRUStaysWithinBudget(t *testing.T) {
+	const budget = 64 << 10
+	s := newShardedLRU(budget)
+	for i := 0; i < 1000; i++ {
+		s.set(fmt.Sprintf("key-%d", i), newTestItem("", 1000))
+	}
+	items, bytes := s.usage()
+	if bytes > budget {
+		t.Fatalf("占用 %d 字节，超过预算 %d", bytes, budget)
+	}
+	if items == 0 {
+		t.Fatal("缓存为空")
+	}
+	// 最近写入的条目应当保留
+	if _, ok := s.get("key-999"); !ok {
+		t.Fatal("最近写入的条目被淘汰")
+	}
+}
+
+func TestShardedLRULargeEntry(t *testing.T) {
+	tests := []struct {
+		name   string
+		budget int64
+		size   int
+		cached bool
+	}{
+		// 超过预算的 1/16，分片平分预算时无法缓存
+		{name: "大于单个分片的平均预算", budget: 320 << 10, size: 100 << 10, cached: true},
+		{name: "接近整个预算", budget: 128 << 10, size: 128<<10 - entryOverhead - 16, cached: true},
+		{name: "超过整个预算", budget: 64 << 10, size: 100 << 10, cached: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newShardedLRU(tt.budget)
+			// 先填入小条目，写入大条目时需要从其他分片淘汰
+			for i := 0; i < 100; i++ {
+				s.set(fmt.Sprintf("small-%d", i), newTestItem("", 500))
+			}
+			s.set("large", newTestItem("large", tt.size))
+			if _, ok := s.get("large"); ok != tt.cached {
+				t.Fatalf("大条目是否缓存: 得到 %v，期望 %v", ok, tt.cached)
+			}
+			if _, bytes := s.usage(); bytes > tt.budget {
+				t.Fatalf("占用 %d 字节，超过预算 %d", bytes, tt.budget)
+			}
+		})
+	}
+}
+
+func TestShardedLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	item := newTestItem("", 1000)
+	size := itemSize(item)
+	s := newShardedLRU(size * 3)
+	s.set("a", item)
+	s.set("b", item)
+	s.set("c", item)
+	// 访问 a 后，最久未使用的是 b
+	s.get("a")
+	s.set("d", item)
+	if _, ok := s.get("b"); ok {
+		t.Fatal("最久未使用的条目没有被淘汰")
+	}
+	for _, key := range []string{"a", "c", "d"} {
+		if _, ok := s.get(key); !ok {
+			t.Fatalf("条目 %s 被错误淘汰", key)
+		}
+	}
+}
+
+// 使用 go test -race 运行，检查并发读写、删除和淘汰时的数据竞争
+func TestShardedLRUConcurrent(t *testing.T) {
+	const budget = 256 << 10
+	s := newShardedLRU(budget)
+	var wg sync.WaitGroup
+	for w := 0; w < 16; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := fmt.Sprintf("key-%d", (w*31+i)%200)
+				switch i % 4 {
+				case 0, 1:
+					// 1KB 到 100KB 的评审结果
+					s.set(key, newTestItem(key, 1<<10+(i*997)%(99<<10)))
+				case 2:
+					if item, ok := s.get(key); ok && item.ContentHash != key {
+						t.Errorf("键 %s 读到了 %s 的条目", key, item.ContentHash)
+					}
+				case 3:
+					s.remove(key)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	items, bytes := s.usage()
+	if bytes > budget {
+		t.Fatalf("占用 %d 字节，超过预算 %d", bytes, budget)
+	}
+	// 共享的字节数应当与各分片之和一致
+	var sum int64
+	var count int
+	for _, shard := range s.shards {
+		n, b := shard.usage()
+		count += n
+		sum += b
+	}
+	if sum != bytes || count != items {
+		t.Fatalf("共享计数 %d 字节 %d 条，各分片之和 %d 字节 %d 条", bytes, items, sum, count)
+	}
+}
+
+func TestReviewCacheConcurrent(t *testing.T) {
+	c, err := NewReviewCacheWithLimit(t.TempDir(), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				content := fmt.Sprintf("diff-%d", (w+i)%20)
+				if err := c.Set(content, "result of "+content, nil); err != nil {
+					t.Error(err)
+					return
+				}
+				item, err := c.Get(content)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if item != nil && item.ReviewResult != "result of "+content {
+					t.Errorf("读到错误的评审结果: %s", item.ReviewResult)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+)
+
+// DefaultWriteQueue 异步写入队列的默认长度
+const DefaultWriteQueue = 64
+
+// pendingWrite 等待写入磁盘的缓存项
+type pendingWrite struct {
+	data []byte
+	// 写入线程已取出、正在写入
+	writing bool
+}
+
+// asyncWriter 在单独的 goroutine 中批量写入缓存文件
+// 同一缓存项在写入前多次更新时只写入最后一次；队列满时 enqueue 阻塞，
+// 评审速度超过磁盘写入速度时由调用方等待，而不是无限占用内存
+type asyncWriter struct {
+	dir   string
+	queue chan string
+	// 保护 closed，入队期间持有读锁
+	closeMu sync.RWMutex
+	closed  bool
+
+	mu      sync.Mutex
+	pending map[string]*pendingWrite
+	// 写入失败的文件数和第一个错误
+	failed   int
+	firstErr error
+
+	done chan struct{}
+}
+
+// newAsyncWriter 创建并启动写入线程，queueSize 为队列长度
+func newAsyncWriter(dir string, queueSize int) *asyncWriter {
+	w := &asyncWriter{
+		dir:     dir,
+		queue:   make(chan string, queueSize),
+		pending: make(map[string]*pendingWrite),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// enqueue 提交缓存项的写入，已关闭时返回 false，由调用方同步写入
+func (w *asyncWriter) enqueue(key string, data []byte) bool {
+	// 持有读锁直到入队完成，close 在所有入队结束后才关闭队列
+	w.closeMu.RLock()
+	defer w.closeMu.RUnlock()
+	if w.closed {
+		return false
+	}
+	w.mu.Lock()
+	// 已在队列中且尚未写入时，写入线程取出时会使用最新的内容
+	p, ok := w.pending[key]
+	queued := ok && !p.writing
+	w.pending[key] = &pendingWrite{data: data}
+	w.mu.Unlock()
+	if queued {
+		return true
+	}
+	w.queue <- key
+	return true
+}
+
+// lookup 返回尚未写入磁盘的缓存项内容
+func (w *asyncWriter) lookup(key string) ([]byte, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if p, ok := w.pending[key]; ok {
+		return p.data, true
+	}
+	return nil, false
+}
+
+// cancel 取消尚未开始的写入，用于删除过期的缓存项
+func (w *asyncWriter) cancel(key string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if p, ok := w.pending[key]; ok && !p.writing {
+		delete(w.pending, key)
+	}
+}
+
+// run 从队列中取出缓存项，每次尽量取完队列中已有的项后依次写入
+func (w *asyncWriter) run() {
+	defer close(w.done)
+	batch := make([]string, 0, cap(w.queue))
+	for key := range w.queue {
+		batch = append(batch[:0], key)
+	drain:
+		for len(batch) < cap(w.queue) {
+			select {
+			case key, ok := <-w.queue:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, key)
+			default:
+				break drain
+			}
+		}
+		for _, key := range batch {
+			w.write(key)
+		}
+	}
+}
+
+// write 写入一个缓存项，写入期间有新的内容时保留待写入状态，由再次入队的项写入
+func (w *asyncWriter) write(key string) {
+	w.mu.Lock()
+	p, ok := w.pending[key]
+	if !ok || p.writing {
+		// 已被取消，或同一项重复入队
+		w.mu.Unlock()
+		return
+	}
+	p.writing = true
+	w.mu.Unlock()
+
+	err := writeFileAtomic(filepath.Join(w.dir, key+".json"), p.data)
+
+	w.mu.Lock()
+	if w.pending[key] == p {
+		delete(w.pending, key)
+	}
+	if err != nil {
+		w.failed++
+		if w.firstErr == nil {
+			w.firstErr = err
+		}
+	}
+	w.mu.Unlock()
+}
+
+// close 等待队列中的缓存项全部写入后停止写入线程，返回写入失败的汇总
+func (w *asyncWriter) close() error {
+	w.closeMu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.closeMu.Unlock()
+	<-w.done
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.failed > 0 {
+		err := fmt.Errorf("%d 个缓存文件写入失败: %v", w.failed, w.firstErr)
+		w.failed, w.firstErr = 0, nil
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package calibration
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+const (
+	// MinSamples 模型至少有这么多条反馈时才根据精确率调整阈值，避免少量反馈造成误判
+	MinSamples = 10
+	// warningPrecision 精确率低于该值时只报告 warning 及以上的问题
+	warningPrecision = 0.5
+	// errorPrecision 精确率低于该值时只报告 error 级别的问题
+	errorPrecision = 0.25
+)
+
+// Finding 一个已发布到代码托管平台的问题及其反馈
+type Finding struct {
+	// 发现该问题的模型
+	Model string `json:"model"`
+	// 用户是否驳回了该问题（PR 评论上的 👎 或 cr stats --dismiss）
+	Dismissed bool `json:"dismissed,omitempty"`
+	// 最近一次同步反馈的时间
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Store 各模型问题的反馈记录，按问题指纹保存
+type Store struct {
+	path string
+
+	Findings map[string]*Finding `json:"findings"`
+}
+
+// Path 返回反馈记录的默认路径
+func Path(gitDir string) string {
+	return filepath.Join(gitDir, "ai-cr-tool", "calibration.json")
+}
+
+// Open 读取反馈记录，文件不存在时返回空记录
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, Findings: make(map[string]*Finding)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("读取模型反馈记录失败: %v", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("解析模型反馈记录失败: %v", err)
+	}
+	if s.Findings == nil {
+		s.Findings = make(map[string]*Finding)
+	}
+	return s, nil
+}
+
+// Record 记录一个已发布问题的反馈；已手动驳回的问题不会因为平台上没有 👎 而恢复
+func (s *Store) Record(fingerprint, model string, dismissed bool) {
+	f, ok := s.Findings[fingerprint]
+	if !ok {
+		f = &Finding{}
+		s.Findings[fingerprint] = f
+	}
+	f.Model = model
+	f.Dismissed = f.Dismissed || dismissed
+	f.UpdatedAt = time.Now()
+}
+
+// Dismiss 将已记录的问题标记为被驳回，问题不在记录中时返回 false
+func (s *Store) Dismiss(fingerprint string) bool {
+	f, ok := s.Findings[fingerprint]
+	if !ok {
+		return false
+	}
+	f.Dismissed = true
+	f.UpdatedAt = time.Now()
+	return true
+}
+
+// Save 保存反馈记录
+func (s *Store) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("保存模型反馈记录失败: %v", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// ModelStats 单个模型的反馈统计
+type ModelStats struct {
+	Model string
+	// 已发布的问题数
+	Published int
+	// 被驳回的问题数
+	Dismissed int
+}
+
+// Precision 返回未被驳回的问题占比，没有反馈时返回 1
+func (m ModelStats) Precision() float64 {
+	if m.Published == 0 {
+		return 1
+	}
+	return float64(m.Published-m.Dismissed) / float64(m.Published)
+}
+
+// Threshold 根据精确率返回该模型问题的最低报告级别，反馈不足或精确率正常时返回空
+func (m ModelStats) Threshold() types.SeverityLevel {
+	if m.Published < MinSamples {
+		return ""
+	}
+	switch p := m.Precision(); {
+	case p < errorPrecision:
+		return types.SeverityError
+	case p < warningPrecision:
+		return types.SeverityWarning
+	default:
+		return ""
+	}
+}
+
+// Stats 按模型名称汇总反馈
+func (s *Store) Stats() []ModelStats {
+	byModel := make(map[string]*ModelStats)
+	for _, f := range s.Findings {
+		m, ok := byModel[f.Model]
+		if !ok {
+			m = &ModelStats{Model: f.Model}
+			byModel[f.Model] = m
+		}
+		m.Published++
+		if f.Dismissed {
+			m.Dismissed++
+		}
+	}
+	stats := make([]ModelStats, 0, len(byModel))
+	for _, m := range byModel {
+		stats = append(stats, *m)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Model < stats[j].Model })
+	return stats
+}
+
+// For 返回指定模型的反馈统计
+func (s *Store) For(model string) ModelStats {
+	for _, m := range s.Stats() {
+		if m.Model == model {
+			return m
+		}
+	}
+	return ModelStats{Model: model}
+}
+
+// Filter 去掉低于最低级别的问题，返回保留的问题和去掉的数量；min 为空时不过滤
+func Filter(issues []types.Issue, min types.SeverityLevel) ([]types.Issue, int) {
+	if min == "" {
+		return issues, 0
+	}
+	kept := make([]types.Issue, 0, len(issues))
+	for _, issue := range issues {
+		if issue.Severity.Rank() >= min.Rank() {
+			kept = append(kept, issue)
+		}
+	}
+	return kept, len(issues) - len(kept)
+}
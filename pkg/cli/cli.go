@@ -3,6 +3,17 @@ package cli
 import (
 	"flag"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/config"
+	"github.com/icatw/ai-cr-tool/pkg/github"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/impact"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/testrun"
 )
 
 // Options 定义命令行参数选项
@@ -12,14 +23,138 @@ type Options struct {
 	Staged      bool
 	CommitHash  string
 	CommitRange string
+	// 统一差异格式的补丁文件，- 表示标准输入
+	DiffFile string
 
 	// 输出相关选项
 	OutputFormat string
-	OutputFile   string
-	Quiet        bool
+	// 输出格式是否由命令行或配置文件显式指定
+	FormatSet  bool
+	OutputFile string
+	Quiet      bool
+	// 报告与评审意见使用的语言
+	Lang string
+	// 命令行帮助、错误和进度信息使用的语言
+	Locale string
+	// 自定义报告模板路径
+	ReportTemplate string
+	// HTML 报告从 CDN 加载资源
+	HTMLCDN bool
+	// 额外生成的徽章文件路径，.json 为 shields.io endpoint 格式，其余为 SVG
+	BadgePath string
+	// 完整报告的链接，markdown-github 格式附在评论末尾
+	ReportURL string
+	// 报告中代码片段每行的最大显示宽度，0 表示不限制
+	SnippetWidth int
+	// Markdown 报告和 PR 评论中问题标题的样式：default, conventional
+	CommentStyle string
+	// 问题标题模板，为空时使用模型给出的标题
+	TitleTemplate string
 
 	// AI模型选项
 	Model string
+	// 是否启用提示词注入防护
+	HardenPrompt bool
+
+	// 缓存选项
+	CacheMemoryMB int
+	// 等待写入磁盘的缓存项上限，0表示同步写入
+	CacheWriteQueue int
+
+	// 并发选项
+	Concurrency int
+
+	// 安全上限选项
+	MaxFiles    int
+	MaxDiffSize string
+	// 单次评审的时间上限，0表示不限制
+	MaxDuration time.Duration
+	// 等待同一仓库中其他评审结束的时间上限，0表示不等待
+	LockTimeout time.Duration
+
+	// 质量门禁选项
+	FailOn string
+	// 质量分下限，低于该分数时以非零状态退出，0表示使用配置中的下限
+	MinScore int
+
+	// 配置文件选项
+	ConfigPath string
+	// 加载后的配置，未显式指定的命令行参数从这里取默认值
+	Config *config.Config
+
+	// 断点续评选项
+	Resume bool
+
+	// 交互选择需要评审的改动块
+	Select bool
+
+	// 与当前分支上次的评审结果对比
+	Compare bool
+	// 作为对比基线的 JSON 报告，指定后代替上次的评审结果
+	Baseline string
+
+	// Go 仓库的包影响范围分析
+	Impact          bool
+	ImpactThreshold int
+
+	// 评审前执行测试
+	RunTests    bool
+	TestCommand string
+	TestTimeout time.Duration
+
+	// 变更行覆盖率
+	Coverage        bool
+	CoverageProfile string
+
+	// 按作者分组评审结果
+	ByAuthor bool
+
+	// 评审完成后汇总所有问题生成执行摘要
+	Summary bool
+	// 执行摘要额外翻译为的第二种语言，为空或与报告语言相同时不翻译
+	SummaryLang string
+
+	// 评审完成后把问题归纳为若干主题，及归纳使用的模型，为空时使用评审模型
+	Themes      bool
+	ThemesModel string
+
+	// 根据 PR 评论上的反馈，提高精确率低的模型的最低报告级别
+	Calibrate bool
+
+	// 每个文件附带的最近提交数，0 表示不附带
+	HistoryCommits int
+
+	// 在评审提示开头附带缓存的仓库概览
+	Overview bool
+
+	// 依赖清单文件变更时评估依赖变更的风险
+	Dependencies bool
+
+	// 接口定义文件变更时检测不兼容变更，并使用接口兼容性评审提示
+	APISpec bool
+
+	// 被删除文件的评审方式：diff、references、skip
+	DeletedFiles string
+
+	// 评审生成的代码和第三方代码，默认跳过
+	IncludeGenerated bool
+
+	// 同时评审工作区中未跟踪的文件（遵循 .gitignore），只能在评审工作区时使用
+	Untracked bool
+
+	// 只读模式，不写入缓存、断点、评审记录和报告文件，结果只输出到标准输出
+	ReadOnly bool
+
+	// 模型调用的外部调度器，不对应命令行参数，由 cr serve 为每个仓库设置
+	Limiter review.Limiter
+	// 只评审这些文件中的改动，不对应命令行参数，由 cr serve 在 pull request 被强制推送后设置
+	OnlyFiles []string
+
+	// 评审完成后按配置发送通知
+	Notify bool
+
+	// 持续集成平台，github 时从 GitHub Actions 事件确定评审范围，输出行内注释和作业摘要
+	CI string
 
 	// 其他选项
 	Verbose bool
@@ -27,27 +162,186 @@ type Options struct {
 
 // ParseFlags 解析命令行参数
 func ParseFlags() (*Options, error) {
+	return ParseArgs(os.Args[1:])
+}
+
+// ParseArgs 解析指定的命令行参数
+func ParseArgs(args []string) (*Options, error) {
+	return parse(flag.CommandLine, args, "")
+}
+
+// ExtractLocale 从参数中取出 --locale 选项并设置界面语言，返回其余参数
+// 需要在解析子命令和其他选项之前调用，使帮助信息和参数错误也使用指定的语言
+func ExtractLocale(args []string) ([]string, error) {
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "locale" {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, i18n.Errorf("cli.err.locale_missing")
+			}
+			i++
+			value = args[i]
+		}
+		locale, err := i18n.Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		i18n.SetLocale(locale)
+	}
+	return rest, nil
+}
+
+// ParseArgsIn 使用独立的参数集解析参数，配置文件从 dir 向上查找
+// 用于批量评审等需要在一个进程中多次解析参数的场景
+func ParseArgsIn(dir string, args []string) (*Options, error) {
+	fs := flag.NewFlagSet("cr", flag.ContinueOnError)
+	return parse(fs, args, dir)
+}
+
+// parse 在指定参数集上注册并解析参数，dir 为空时从当前工作目录查找配置文件
+func parse(fs *flag.FlagSet, args []string, dir string) (*Options, error) {
 	opts := &Options{}
 
 	// 评审范围选项
-	flag.StringVar(&opts.Files, "files", "", "指定要评审的文件列表，多个文件用逗号分隔")
-	flag.BoolVar(&opts.Staged, "staged", false, "只评审已暂存(git add)的改动")
-	flag.StringVar(&opts.CommitHash, "commit", "", "评审指定的提交")
-	flag.StringVar(&opts.CommitRange, "commit-range", "", "指定要评审的提交范围，例如：HEAD~1..HEAD")
+	fs.StringVar(&opts.Files, "files", "", i18n.M("cli.flag.files"))
+	fs.BoolVar(&opts.Staged, "staged", false, i18n.M("cli.flag.staged"))
+	fs.StringVar(&opts.CommitHash, "commit", "", i18n.M("cli.flag.commit"))
+	fs.StringVar(&opts.CommitRange, "commit-range", "", i18n.M("cli.flag.commit-range"))
+	fs.StringVar(&opts.DiffFile, "diff-file", "", i18n.M("cli.flag.diff-file"))
+	fs.BoolVar(&opts.Untracked, "untracked", false, i18n.M("cli.flag.untracked"))
 
 	// 输出选项
-	flag.StringVar(&opts.OutputFormat, "format", "markdown", "输出格式：markdown, html, pdf")
-	flag.StringVar(&opts.OutputFile, "output", "", "输出文件路径，默认输出到标准输出")
-	flag.BoolVar(&opts.Quiet, "quiet", false, "静默模式，只输出错误信息")
+	fs.StringVar(&opts.OutputFormat, "format", "markdown", i18n.M("cli.flag.format"))
+	fs.StringVar(&opts.OutputFormat, "output-format", "markdown", i18n.M("cli.flag.output-format"))
+	fs.StringVar(&opts.OutputFile, "output", "", i18n.M("cli.flag.output"))
+	fs.StringVar(&opts.ReportTemplate, "report-template", "", i18n.M("cli.flag.report-template"))
+	fs.StringVar(&opts.BadgePath, "badge", "", i18n.M("cli.flag.badge"))
+	fs.StringVar(&opts.ReportURL, "report-url", "", i18n.M("cli.flag.report-url"))
+	fs.IntVar(&opts.SnippetWidth, "snippet-width", review.DefaultSnippetWidth, i18n.M("cli.flag.snippet-width"))
+	fs.StringVar(&opts.CommentStyle, "comment-style", string(review.DefaultStyle), i18n.M("cli.flag.comment-style"))
+	fs.StringVar(&opts.TitleTemplate, "title-template", "", i18n.M("cli.flag.title-template"))
+	fs.BoolVar(&opts.HTMLCDN, "html-cdn", false, i18n.M("cli.flag.html-cdn"))
+	fs.StringVar(&opts.Lang, "lang", string(i18n.Default), i18n.M("cli.flag.lang"))
+	fs.StringVar(&opts.Locale, "locale", string(i18n.Locale()), i18n.M("cli.flag.locale"))
+	fs.BoolVar(&opts.Quiet, "quiet", false, i18n.M("cli.flag.quiet"))
 
 	// AI模型选项
-	flag.StringVar(&opts.Model, "model", "", "指定使用的AI模型，可选值：qwen, deepseek, openai, chatglm")
+	fs.StringVar(&opts.Model, "model", "", i18n.M("cli.flag.model"))
+	fs.BoolVar(&opts.HardenPrompt, "harden", true, i18n.M("cli.flag.harden"))
+
+	// 缓存选项
+	fs.IntVar(&opts.CacheMemoryMB, "cache-memory", 32, i18n.M("cli.flag.cache-memory"))
+	fs.IntVar(&opts.CacheWriteQueue, "cache-write-queue", 64, i18n.M("cli.flag.cache-write-queue"))
+
+	// 并发选项
+	fs.IntVar(&opts.Concurrency, "concurrency", 4, i18n.M("cli.flag.concurrency"))
+
+	// 安全上限选项
+	fs.IntVar(&opts.MaxFiles, "max-files", 100, i18n.M("cli.flag.max-files"))
+	fs.StringVar(&opts.MaxDiffSize, "max-diff-size", "2MB", i18n.M("cli.flag.max-diff-size"))
+	fs.DurationVar(&opts.MaxDuration, "max-duration", 0, i18n.M("cli.flag.max-duration"))
+	fs.DurationVar(&opts.LockTimeout, "lock-timeout", 10*time.Minute, i18n.M("cli.flag.lock-timeout"))
+
+	// 质量门禁选项
+	fs.StringVar(&opts.FailOn, "fail-on", "", i18n.M("cli.flag.fail-on"))
+	fs.IntVar(&opts.MinScore, "min-score", 0, i18n.M("cli.flag.min-score"))
+
+	// 配置文件选项
+	fs.StringVar(&opts.ConfigPath, "config", "", i18n.M("cli.flag.config", config.FileName))
+
+	// 断点续评选项
+	fs.BoolVar(&opts.Resume, "resume", false, i18n.M("cli.flag.resume"))
+
+	// 交互选择选项
+	fs.BoolVar(&opts.Select, "select", false, i18n.M("cli.flag.select"))
+
+	// 对比选项
+	fs.BoolVar(&opts.Compare, "compare", true, i18n.M("cli.flag.compare"))
+	fs.StringVar(&opts.Baseline, "baseline", "", i18n.M("cli.flag.baseline"))
+
+	// 影响范围选项
+	fs.BoolVar(&opts.Impact, "impact", true, i18n.M("cli.flag.impact"))
+	fs.IntVar(&opts.ImpactThreshold, "impact-threshold", impact.DefaultThreshold, i18n.M("cli.flag.impact-threshold"))
+
+	// 测试选项
+	fs.BoolVar(&opts.RunTests, "run-tests", false, i18n.M("cli.flag.run-tests"))
+	fs.StringVar(&opts.TestCommand, "test-command", "", i18n.M("cli.flag.test-command"))
+	fs.DurationVar(&opts.TestTimeout, "test-timeout", testrun.DefaultTimeout, i18n.M("cli.flag.test-timeout"))
+
+	// 覆盖率选项
+	fs.BoolVar(&opts.Coverage, "coverage", false, i18n.M("cli.flag.coverage"))
+	fs.StringVar(&opts.CoverageProfile, "coverage-profile", "", i18n.M("cli.flag.coverage-profile"))
+
+	// 按作者分组选项
+	fs.BoolVar(&opts.ByAuthor, "by-author", false, i18n.M("cli.flag.by-author"))
+
+	// 执行摘要选项
+	fs.BoolVar(&opts.Summary, "summary", false, i18n.M("cli.flag.summary"))
+	fs.StringVar(&opts.SummaryLang, "summary-lang", "", i18n.M("cli.flag.summary-lang"))
+	fs.BoolVar(&opts.Themes, "themes", false, i18n.M("cli.flag.themes"))
+	fs.StringVar(&opts.ThemesModel, "themes-model", "", i18n.M("cli.flag.themes-model"))
+	fs.BoolVar(&opts.Calibrate, "calibrate", false, i18n.M("cli.flag.calibrate"))
+
+	// 依赖变更选项
+	fs.BoolVar(&opts.Dependencies, "deps", true, i18n.M("cli.flag.deps"))
+
+	// 接口定义选项
+	fs.BoolVar(&opts.APISpec, "api-spec", true, i18n.M("cli.flag.api-spec"))
+
+	// 被删除文件选项
+	fs.StringVar(&opts.DeletedFiles, "deleted-files", review.DeletedFilesDiff, i18n.M("cli.flag.deleted-files"))
+
+	// 生成代码选项
+	fs.BoolVar(&opts.IncludeGenerated, "include-generated", false, i18n.M("cli.flag.include-generated"))
+
+	// 提交历史选项
+	fs.IntVar(&opts.HistoryCommits, "history", 0, i18n.M("cli.flag.history"))
+
+	// 仓库概览选项
+	fs.BoolVar(&opts.Overview, "overview", false, i18n.M("cli.flag.overview"))
+
+	// 只读选项
+	fs.BoolVar(&opts.ReadOnly, "read-only", ReadOnlyFromEnv(), i18n.M("cli.flag.read-only", ReadOnlyEnv))
+
+	// 通知选项
+	fs.BoolVar(&opts.Notify, "notify", true, i18n.M("cli.flag.notify"))
+
+	// 持续集成选项
+	fs.StringVar(&opts.CI, "ci", "", i18n.M("cli.flag.ci"))
 
 	// 其他选项
-	flag.BoolVar(&opts.Verbose, "verbose", false, "显示详细日志信息")
+	fs.BoolVar(&opts.Verbose, "verbose", false, i18n.M("cli.flag.verbose"))
 
 	// 解析参数
-	flag.Parse()
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// 加载配置文件
+	if err := applyConfig(opts, fs, dir); err != nil {
+		return nil, err
+	}
+
+	if opts.CoverageProfile != "" {
+		opts.Coverage = true
+	}
+	if opts.ReadOnly {
+		EnterReadOnly()
+	}
+	if opts.ReportTemplate != "" && !opts.FormatSet {
+		opts.OutputFormat = string(review.TemplateFormat)
+		opts.FormatSet = true
+	}
 
 	// 验证参数
 	if err := validateOptions(opts); err != nil {
@@ -57,29 +351,333 @@ func ParseFlags() (*Options, error) {
 	return opts, nil
 }
 
+// applyConfig 加载配置文件，并用配置中的值填充未在命令行显式指定的参数
+func applyConfig(opts *Options, fs *flag.FlagSet, dir string) error {
+	path := opts.ConfigPath
+	if path == "" {
+		if dir == "" {
+			dir, _ = os.Getwd()
+		}
+		if dir != "" {
+			path = config.Find(dir)
+		}
+	} else if _, err := os.Stat(path); err != nil {
+		return i18n.Errorf("cli.err.config_not_found", path)
+	}
+
+	cfg, migrated, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	if migrated {
+		fmt.Fprintln(os.Stderr, i18n.M("cli.config_migrated", path))
+	}
+	opts.ConfigPath = path
+	opts.Config = cfg
+
+	// 记录命令行显式指定的参数
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	if !explicit["model"] && cfg.Model.Default != "" {
+		opts.Model = cfg.Model.Default
+	}
+	if !explicit["concurrency"] && cfg.Review.Concurrency != 0 {
+		opts.Concurrency = cfg.Review.Concurrency
+	}
+	if !explicit["cache-memory"] && cfg.Review.CacheMemoryMB != 0 {
+		opts.CacheMemoryMB = cfg.Review.CacheMemoryMB
+	}
+	if !explicit["cache-write-queue"] && cfg.Review.CacheWriteQueue != nil {
+		opts.CacheWriteQueue = *cfg.Review.CacheWriteQueue
+	}
+	if !explicit["max-files"] && cfg.Review.MaxFiles != 0 {
+		opts.MaxFiles = cfg.Review.MaxFiles
+	}
+	if !explicit["max-diff-size"] && cfg.Review.MaxDiffSize != "" {
+		opts.MaxDiffSize = cfg.Review.MaxDiffSize
+	}
+	if !explicit["max-duration"] && cfg.Review.MaxDuration != "" {
+		d, err := time.ParseDuration(cfg.Review.MaxDuration)
+		if err != nil {
+			return i18n.Errorf("cli.err.config_max_duration", err)
+		}
+		opts.MaxDuration = d
+	}
+	if !explicit["lock-timeout"] && cfg.Review.LockTimeout != "" {
+		d, err := time.ParseDuration(cfg.Review.LockTimeout)
+		if err != nil {
+			return i18n.Errorf("cli.err.config_lock_timeout", err)
+		}
+		opts.LockTimeout = d
+	}
+	opts.FormatSet = explicit["format"] || explicit["output-format"]
+	if !opts.FormatSet && cfg.Output.Format != "" {
+		opts.OutputFormat = cfg.Output.Format
+		opts.FormatSet = true
+	}
+	if !explicit["impact-threshold"] && cfg.Review.ImpactThreshold != 0 {
+		opts.ImpactThreshold = cfg.Review.ImpactThreshold
+	}
+	if !explicit["test-command"] && cfg.Review.TestCommand != "" {
+		opts.TestCommand = cfg.Review.TestCommand
+	}
+	if !explicit["history"] && cfg.Review.HistoryCommits != 0 {
+		opts.HistoryCommits = cfg.Review.HistoryCommits
+	}
+	if !explicit["overview"] && cfg.Review.RepoOverview {
+		opts.Overview = true
+	}
+	if !explicit["deps"] && cfg.Review.DependencyReview != nil {
+		opts.Dependencies = *cfg.Review.DependencyReview
+	}
+	if !explicit["api-spec"] && cfg.Review.APISpecReview != nil {
+		opts.APISpec = *cfg.Review.APISpecReview
+	}
+	if !explicit["deleted-files"] && cfg.Review.DeletedFiles != "" {
+		opts.DeletedFiles = cfg.Review.DeletedFiles
+	}
+	if !explicit["include-generated"] && cfg.Review.IncludeGenerated {
+		opts.IncludeGenerated = true
+	}
+	if !explicit["summary"] && cfg.Review.Summary {
+		opts.Summary = true
+	}
+	if !explicit["summary-lang"] && cfg.Review.SummaryLang != "" {
+		opts.SummaryLang = cfg.Review.SummaryLang
+	}
+	// 显式指定译文语言时同时生成执行摘要
+	if explicit["summary-lang"] && opts.SummaryLang != "" {
+		opts.Summary = true
+	}
+	if !explicit["themes"] && cfg.Review.Themes {
+		opts.Themes = true
+	}
+	if !explicit["themes-model"] && cfg.Review.ThemesModel != "" {
+		opts.ThemesModel = cfg.Review.ThemesModel
+	}
+	if !explicit["calibrate"] && cfg.Review.Calibrate {
+		opts.Calibrate = true
+	}
+	if !explicit["lang"] && cfg.Output.Lang != "" {
+		opts.Lang = cfg.Output.Lang
+	}
+	if !explicit["report-url"] && cfg.Output.ReportURL != "" {
+		opts.ReportURL = cfg.Output.ReportURL
+	}
+	if !explicit["snippet-width"] && cfg.Output.SnippetWidth != nil {
+		opts.SnippetWidth = *cfg.Output.SnippetWidth
+	}
+	if !explicit["comment-style"] && cfg.Output.CommentStyle != "" {
+		opts.CommentStyle = cfg.Output.CommentStyle
+	}
+	if !explicit["title-template"] && cfg.Output.TitleTemplate != "" {
+		opts.TitleTemplate = cfg.Output.TitleTemplate
+	}
+	if !explicit["report-template"] && cfg.Output.Template != "" {
+		// 配置文件中的相对路径以配置文件所在目录为准
+		opts.ReportTemplate = cfg.Output.Template
+		if !filepath.IsAbs(opts.ReportTemplate) && path != "" {
+			opts.ReportTemplate = filepath.Join(filepath.Dir(path), opts.ReportTemplate)
+		}
+	}
+	return nil
+}
+
+// Limits 返回本次评审的安全上限
+func (o *Options) Limits() review.Limits {
+	maxDiffSize, _ := review.ParseSize(o.MaxDiffSize)
+	return review.Limits{MaxFiles: o.MaxFiles, MaxDiffSize: maxDiffSize}
+}
+
 // validateOptions 验证命令行参数
 func validateOptions(opts *Options) error {
+	// 在 GitHub Actions 中未指定评审范围时，从触发工作流的事件中确定
+	switch opts.CI {
+	case "":
+	case "github":
+		if opts.Files == "" && !opts.Staged && opts.CommitHash == "" && opts.CommitRange == "" && opts.DiffFile == "" {
+			commitRange, err := github.ActionsRange()
+			if err != nil {
+				return err
+			}
+			opts.CommitRange = commitRange
+		}
+	default:
+		return i18n.Errorf("cli.err.unsupported_ci", opts.CI)
+	}
+
+	// 未跟踪的文件只存在于工作区，评审范围必须以工作区为目标；未指定范围时评审相对 HEAD 的全部未提交改动
+	if opts.Untracked {
+		if opts.Staged || opts.CommitHash != "" || opts.DiffFile != "" || strings.Contains(opts.CommitRange, "..") {
+			return i18n.Errorf("cli.err.untracked_scope")
+		}
+		if opts.Files == "" && opts.CommitRange == "" {
+			opts.CommitRange = "HEAD"
+		}
+	}
+
 	// 检查评审范围参数
-	if opts.Files == "" && opts.CommitRange == "" {
+	if opts.Files == "" && opts.CommitRange == "" && opts.DiffFile == "" {
 		// 如果未指定任何参数，默认使用HEAD~1..HEAD
 		opts.CommitRange = "HEAD~1..HEAD"
 	}
 
 	// 检查输出格式
-	switch opts.OutputFormat {
-	case "markdown", "html", "pdf":
-		// 支持的格式
+	format, err := review.ParseReportFormat(opts.OutputFormat)
+	if err != nil {
+		return i18n.Errorf("cli.err.unsupported_format", opts.OutputFormat)
+	}
+	if format == review.TemplateFormat && opts.ReportTemplate == "" {
+		return i18n.Errorf("cli.err.template_required")
+	}
+
+	// 只读模式下拒绝需要写入文件的选项
+	if opts.ReadOnly {
+		switch {
+		case opts.OutputFile != "":
+			return i18n.Errorf("cli.err.read_only_output")
+		case opts.BadgePath != "":
+			return i18n.Errorf("cli.err.read_only_badge")
+		case format == review.PDFFormat:
+			return i18n.Errorf("cli.err.read_only_pdf")
+		case opts.Resume:
+			return i18n.Errorf("cli.err.read_only_resume")
+		case opts.RunTests:
+			return i18n.Errorf("cli.err.read_only_tests")
+		case opts.Coverage && opts.CoverageProfile == "":
+			return i18n.Errorf("cli.err.read_only_coverage")
+		}
+	}
+
+	// 在调用模型之前检查PDF转换程序，不可用时改为输出HTML
+	if fallback, path, ok := review.PDFFallback(format, opts.OutputFile); ok {
+		if path != opts.OutputFile {
+			fmt.Fprintln(os.Stderr, i18n.M("cli.pdf_fallback_path", path))
+		} else {
+			fmt.Fprintln(os.Stderr, i18n.M("cli.pdf_fallback"))
+		}
+		opts.OutputFormat, opts.OutputFile = string(fallback), path
+	}
+
+	// 检查界面语言和报告语言
+	locale, err := i18n.Parse(opts.Locale)
+	if err != nil {
+		return err
+	}
+	i18n.SetLocale(locale)
+	lang, err := i18n.Parse(opts.Lang)
+	if err != nil {
+		return err
+	}
+	// 执行摘要的译文语言与报告语言相同时不需要翻译
+	if opts.SummaryLang != "" {
+		summaryLang, err := i18n.Parse(opts.SummaryLang)
+		if err != nil {
+			return err
+		}
+		opts.SummaryLang = string(summaryLang)
+		if summaryLang == lang {
+			opts.SummaryLang = ""
+		}
+	}
+
+	// 提前检查报告模板，避免评审完成后才发现模板错误
+	if opts.ReportTemplate != "" {
+		if _, err := review.LoadTemplate(opts.ReportTemplate, lang); err != nil {
+			return err
+		}
+	}
+
+	// 提前检查基线报告，避免评审完成后才发现文件不存在
+	if opts.Baseline != "" {
+		if _, err := os.Stat(opts.Baseline); err != nil {
+			return i18n.Errorf("cli.err.baseline_not_found", opts.Baseline)
+		}
+	}
+
+	// 按作者分组需要提交历史
+	if opts.ByAuthor && (opts.Files != "" || opts.Staged) {
+		return i18n.Errorf("cli.err.by_author_scope")
+	}
+
+	// 检查缓存容量
+	if opts.CacheMemoryMB < 0 {
+		return i18n.Errorf("cli.err.negative_cache", opts.CacheMemoryMB)
+	}
+	if opts.CacheWriteQueue < 0 {
+		return i18n.Errorf("cli.err.negative_write_queue", opts.CacheWriteQueue)
+	}
+
+	// 检查并发数
+	if opts.Concurrency < 1 {
+		return i18n.Errorf("cli.err.concurrency", opts.Concurrency)
+	}
+
+	// 检查安全上限
+	if opts.MaxFiles < 0 {
+		return i18n.Errorf("cli.err.negative_max_files", opts.MaxFiles)
+	}
+	if _, err := review.ParseSize(opts.MaxDiffSize); err != nil {
+		return i18n.Errorf("cli.err.max_diff_size", err)
+	}
+	if opts.ReportURL != "" && !strings.HasPrefix(opts.ReportURL, "https://") && !strings.HasPrefix(opts.ReportURL, "http://") {
+		return i18n.Errorf("cli.err.report_url", opts.ReportURL)
+	}
+	if opts.SnippetWidth < 0 {
+		return i18n.Errorf("cli.err.negative_snippet_width", opts.SnippetWidth)
+	}
+	if _, err := review.ParseCommentStyle(opts.CommentStyle); err != nil {
+		return i18n.Errorf("cli.err.comment_style", opts.CommentStyle)
+	}
+	if opts.TitleTemplate != "" {
+		if _, err := review.ParseTitleTemplate(opts.TitleTemplate); err != nil {
+			return i18n.Errorf("cli.err.title_template", err)
+		}
+	}
+	if opts.HistoryCommits < 0 {
+		return i18n.Errorf("cli.err.negative_history", opts.HistoryCommits)
+	}
+	if opts.MaxDuration < 0 {
+		return i18n.Errorf("cli.err.negative_max_duration", opts.MaxDuration)
+	}
+	if opts.LockTimeout < 0 {
+		return i18n.Errorf("cli.err.negative_lock_timeout", opts.LockTimeout)
+	}
+	switch opts.DeletedFiles {
+	case review.DeletedFilesDiff, review.DeletedFilesReferences, review.DeletedFilesSkip:
 	default:
-		return fmt.Errorf("不支持的输出格式：%s", opts.OutputFormat)
+		return i18n.Errorf("cli.err.deleted_files", opts.DeletedFiles)
 	}
 
-	// 检查AI模型
-	if opts.Model != "" {
-		switch opts.Model {
+	// 检查门禁级别
+	switch opts.FailOn {
+	case "", "error", "warning", "info":
+	default:
+		return i18n.Errorf("cli.err.unsupported_fail_on", opts.FailOn)
+	}
+	if opts.MinScore < 0 || opts.MinScore > 100 {
+		return i18n.Errorf("cli.err.min_score", opts.MinScore)
+	}
+
+	// 检查AI模型，配置中的模型池名称同样可用
+	for _, name := range []string{opts.Model, opts.ThemesModel} {
+		if name == "" {
+			continue
+		}
+		switch name {
 		case "qwen", "deepseek", "openai", "chatglm":
 			// 支持的模型
 		default:
-			return fmt.Errorf("不支持的AI模型：%s", opts.Model)
+			var pools map[string]config.PoolConfig
+			if opts.Config != nil {
+				pools = opts.Config.Model.Pools
+			}
+			if _, ok := pools[name]; !ok {
+				return i18n.Errorf("cli.err.unsupported_model", name)
+			}
 		}
 	}
 
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+)
+
+// ReadOnlyEnv 环境变量，设置为 1 或 true 时以只读模式运行，对子命令和 Git 钩子中的评审同样生效
+const ReadOnlyEnv = "CR_READ_ONLY"
+
+// ReadOnlyFromEnv 返回环境变量是否要求以只读模式运行
+func ReadOnlyFromEnv() bool {
+	readOnly, _ := strconv.ParseBool(os.Getenv(ReadOnlyEnv))
+	return readOnly
+}
+
+// EnterReadOnly 进入只读模式
+// 设置环境变量使本进程启动的子进程也以只读模式运行，并禁止 git 在读取状态时刷新索引文件
+func EnterReadOnly() {
+	os.Setenv(ReadOnlyEnv, "1")
+	os.Setenv("GIT_OPTIONAL_LOCKS", "0")
+}
+
+// CheckWritable 只读模式下返回错误，actionKey 为描述被拒绝的写操作的消息键，如 "readonly.publish"
+func CheckWritable(actionKey string) error {
+	if ReadOnlyFromEnv() {
+		return i18n.Errorf("cli.err.read_only_action", i18n.M(actionKey), ReadOnlyEnv)
+	}
+	return nil
+}
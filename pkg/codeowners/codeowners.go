@@ -0,0 +1,137 @@
+package codeowners
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// 按 GitHub 的查找顺序排列的 CODEOWNERS 文件位置
+var locations = []string{
+	".github/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+// rule 一条 CODEOWNERS 规则
+type rule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// Owners 解析后的 CODEOWNERS 规则集
+type Owners struct {
+	rules []rule
+}
+
+// Load 从仓库根目录查找并解析 CODEOWNERS，文件不存在时返回空规则集
+func Load(repoRoot string) (*Owners, error) {
+	for _, loc := range locations {
+		f, err := os.Open(filepath.Join(repoRoot, loc))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %v", loc, err)
+		}
+		defer f.Close()
+		return Parse(f)
+	}
+	return &Owners{}, nil
+}
+
+// Parse 解析 CODEOWNERS 内容
+func Parse(r io.Reader) (*Owners, error) {
+	owners := &Owners{}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		pattern, err := compilePattern(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("CODEOWNERS 第%d行规则无效: %v", lineNo, err)
+		}
+		owners.rules = append(owners.rules, rule{pattern: pattern, owners: fields[1:]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取 CODEOWNERS 失败: %v", err)
+	}
+	return owners, nil
+}
+
+// Of 返回文件的负责人，后出现的规则优先，与 GitHub 行为一致
+func (o *Owners) Of(path string) []string {
+	path = strings.TrimPrefix(filepath.ToSlash(path), "./")
+	for i := len(o.rules) - 1; i >= 0; i-- {
+		if o.rules[i].pattern.MatchString(path) {
+			return o.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// Users 从负责人列表中取出个人账号（去掉 @ 前缀），团队和邮箱无法直接指派，予以忽略
+func Users(owners []string) []string {
+	var users []string
+	for _, owner := range owners {
+		if !strings.HasPrefix(owner, "@") || strings.Contains(owner, "/") {
+			continue
+		}
+		users = append(users, strings.TrimPrefix(owner, "@"))
+	}
+	return users
+}
+
+// compilePattern 将 gitignore 风格的规则转换为正则表达式
+// 以 / 开头或中间含 / 的规则相对仓库根目录匹配，否则可匹配任意层级；以 / 结尾的规则匹配目录下的所有文件
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	dir := strings.HasSuffix(pattern, "/")
+	pattern = strings.Trim(pattern, "/")
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '*' && i+1 < len(pattern) && pattern[i+1] == '*':
+			i++
+			if i+1 < len(pattern) && pattern[i+1] == '/' {
+				i++
+				sb.WriteString("(?:.*/)?")
+			} else {
+				sb.WriteString(".*")
+			}
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if dir {
+		sb.WriteString("/.*")
+	} else {
+		// 规则也可能是目录名，匹配其下的所有文件
+		sb.WriteString("(?:/.*)?")
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
@@ -0,0 +1,322 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName 仓库级配置文件名
+const FileName = ".cr.yaml"
+
+// CurrentVersion 当前配置文件结构版本
+const CurrentVersion = 1
+
+// Config 定义配置文件结构
+type Config struct {
+	// 配置文件结构版本
+	Version int `yaml:"version"`
+	// 模型配置
+	Model ModelConfig `yaml:"model"`
+	// 评审配置
+	Review ReviewConfig `yaml:"review"`
+	// 输出配置
+	Output OutputConfig `yaml:"output"`
+	// 质量门禁
+	Gate GateConfig `yaml:"gate"`
+	// 新增二进制文件和资源文件的检查
+	Assets AssetsConfig `yaml:"assets,omitempty"`
+	// 禁止使用的模型提供方
+	ForbiddenProviders []string `yaml:"forbidden_providers,omitempty"`
+	// 领域术语表，键为术语或缩写，值为解释；差异中出现的术语会提供给模型
+	Glossary map[string]string `yaml:"glossary,omitempty"`
+	// 组织级策略地址，策略会合并到仓库配置之下，且其中的强制项不能被仓库配置放宽
+	PolicyURL string `yaml:"policy_url,omitempty"`
+	// 校验组织级策略签名的 Ed25519 公钥（base64）
+	PolicyPublicKey string `yaml:"policy_public_key,omitempty"`
+	// 跳过清单地址，返回 JSON 格式的排除路径和跳过的改动类型，与仓库配置合并
+	SkipListURL string `yaml:"skip_list_url,omitempty"`
+	// 评审完成后发送摘要的通知渠道
+	Notify []NotifyConfig `yaml:"notify,omitempty"`
+	// 评审历史
+	History HistoryConfig `yaml:"history,omitempty"`
+}
+
+// HistoryConfig 评审历史配置
+type HistoryConfig struct {
+	// 存储位置，默认为 SQLite 数据库 ~/.cr/history.db；"off" 表示不记录
+	Store string `yaml:"store,omitempty"`
+}
+
+// NotifyConfig 通知渠道配置
+type NotifyConfig struct {
+	// 渠道类型：slack, dingtalk, wecom, feishu（lark）, email
+	Type string `yaml:"type"`
+	// 保存 Webhook 地址的环境变量，默认为 SLACK_WEBHOOK_URL 等该渠道的默认变量
+	WebhookURLEnv string `yaml:"webhook_url_env,omitempty"`
+	// 保存签名密钥的环境变量，机器人启用了签名校验时需要，用于钉钉和飞书
+	SecretEnv string `yaml:"secret_env,omitempty"`
+	// 发送到的频道，为空时发送到 Webhook 绑定的频道
+	Channel string `yaml:"channel,omitempty"`
+	// 保存 SMTP 服务地址的环境变量，默认为 SMTP_URL，地址形如 smtps://用户名:密码@smtp.example.com:465
+	SMTPURLEnv string `yaml:"smtp_url_env,omitempty"`
+	// 邮件的发件人和收件人
+	From string   `yaml:"from,omitempty"`
+	To   []string `yaml:"to,omitempty"`
+	// 发送时机：always（默认）、issues（发现问题时）、failed（未通过质量门禁时）
+	When string `yaml:"when,omitempty"`
+}
+
+// GateConfig 质量门禁配置
+type GateConfig struct {
+	// 出现该严重程度及以上的问题时评审不通过：error、warning、info
+	FailOn string `yaml:"fail_on,omitempty"`
+	// 各严重程度及以上的问题数上限，超过时评审不通过，例如 warning: 10
+	MaxIssues map[string]int `yaml:"max_issues,omitempty"`
+	// 质量分下限，低于该分数时评审不通过，0 表示不检查
+	MinScore int `yaml:"min_score,omitempty"`
+}
+
+// AssetsConfig 新增二进制文件和资源文件的检查配置
+type AssetsConfig struct {
+	// 文件大小上限，如 "1MB"，"0" 表示不检查
+	MaxSize string `yaml:"max_size,omitempty"`
+	// 发现超限文件时是否使评审不通过
+	Block bool `yaml:"block,omitempty"`
+}
+
+// ModelConfig 模型相关配置
+type ModelConfig struct {
+	// 默认使用的模型
+	Default string `yaml:"default,omitempty"`
+	// 模型单价，用于在报告中估算费用
+	Pricing *PricingConfig `yaml:"pricing,omitempty"`
+	// 模型池，键为池名称，可以像模型名称一样通过 --model 或 default 选用
+	Pools map[string]PoolConfig `yaml:"pools,omitempty"`
+}
+
+// PoolConfig 模型池配置，池中的各提供方服务同一个模型，请求按策略、权重和健康状况分配
+type PoolConfig struct {
+	// 负载均衡策略：weighted（默认）, round_robin
+	Strategy  string           `yaml:"strategy,omitempty"`
+	Providers []ProviderConfig `yaml:"providers"`
+}
+
+// ProviderConfig 模型池中的一个提供方
+type ProviderConfig struct {
+	// 接口类型：qwen, deepseek, openai, chatglm
+	Type string `yaml:"type"`
+	// 接口地址，为空时使用该类型的默认地址
+	URL string `yaml:"url,omitempty"`
+	// 该提供方使用的模型名称，为空时使用该类型的默认模型
+	Model string `yaml:"model,omitempty"`
+	// 保存 API 密钥的环境变量，默认为 <TYPE>_API_KEY，如 DEEPSEEK_API_KEY
+	APIKeyEnv string `yaml:"api_key_env,omitempty"`
+	// 权重，默认为 1
+	Weight int `yaml:"weight,omitempty"`
+}
+
+// PricingConfig 模型调用单价
+type PricingConfig struct {
+	// 每百万输入 token 的价格
+	InputPerMillion float64 `yaml:"input_per_million"`
+	// 每百万输出 token 的价格
+	OutputPerMillion float64 `yaml:"output_per_million"`
+	// 命中提示缓存的每百万输入 token 的价格，为0时按普通输入计价且不估算节省的费用
+	CachedInputPerMillion float64 `yaml:"cached_input_per_million,omitempty"`
+	// 货币代码，如 USD、CNY，默认 USD
+	Currency string `yaml:"currency,omitempty"`
+}
+
+// Cost 按单价计算 token 用量的费用，cachedTokens 为输入中命中提示缓存的部分
+func (p *PricingConfig) Cost(promptTokens, cachedTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.InputPerMillion+float64(completionTokens)*p.OutputPerMillion)/1e6 - p.Saved(cachedTokens)
+}
+
+// Saved 估算提示缓存节省的费用，未配置缓存单价时返回0
+func (p *PricingConfig) Saved(cachedTokens int) float64 {
+	if p.CachedInputPerMillion <= 0 {
+		return 0
+	}
+	return float64(cachedTokens) * (p.InputPerMillion - p.CachedInputPerMillion) / 1e6
+}
+
+// ReviewConfig 评审相关配置
+type ReviewConfig struct {
+	// 同时评审的文件数
+	Concurrency int `yaml:"concurrency,omitempty"`
+	// 内存缓存容量上限(MB)
+	CacheMemoryMB int `yaml:"cache_memory_mb,omitempty"`
+	// 等待写入磁盘的缓存项上限，队列满时评审等待写入，0 表示同步写入，同 --cache-write-queue
+	CacheWriteQueue *int `yaml:"cache_write_queue,omitempty"`
+	// 单次最多评审的文件数
+	MaxFiles int `yaml:"max_files,omitempty"`
+	// 单次评审的差异总大小上限，如 "2MB"
+	MaxDiffSize string `yaml:"max_diff_size,omitempty"`
+	// 单次评审的时间上限，如 "5m"，达到时输出部分结果
+	MaxDuration string `yaml:"max_duration,omitempty"`
+	// 等待同一仓库中其他评审结束的时间上限，如 "10m"，"0" 表示不等待，同 --lock-timeout
+	LockTimeout string `yaml:"lock_timeout,omitempty"`
+	// Go 包被依赖数达到该值时提示高影响改动，0 表示使用默认值
+	ImpactThreshold int `yaml:"impact_threshold,omitempty"`
+	// 依赖清单文件变更时是否评估依赖变更的风险，默认评估，同 --deps
+	DependencyReview *bool `yaml:"dependency_review,omitempty"`
+	// proto、OpenAPI 文件变更时是否检测不兼容的接口变更，默认检测，同 --api-spec
+	APISpecReview *bool `yaml:"api_spec_review,omitempty"`
+	// 被删除文件的评审方式：diff（默认，只评审差异）、references（搜索残留引用并检查有风险的删除）、skip（不评审），同 --deleted-files
+	DeletedFiles string `yaml:"deleted_files,omitempty"`
+	// 评审生成的代码和第三方代码（linguist 属性、生成代码标记、vendor 等目录），默认跳过，同 --include-generated
+	IncludeGenerated bool `yaml:"include_generated,omitempty"`
+	// 每个文件附带的最近提交数，0 表示不附带，同 --history
+	HistoryCommits int `yaml:"history_commits,omitempty"`
+	// 在评审提示开头附带仓库概览，首次使用时生成并缓存，同 --overview
+	RepoOverview bool `yaml:"repo_overview,omitempty"`
+	// 评审完成后汇总所有问题生成执行摘要，同 --summary
+	Summary bool `yaml:"summary,omitempty"`
+	// 执行摘要额外翻译为的第二种语言：zh、en，问题详情仍使用报告语言，同 --summary-lang
+	SummaryLang string `yaml:"summary_lang,omitempty"`
+	// 评审完成后把问题归纳为若干主题，同 --themes
+	Themes bool `yaml:"themes,omitempty"`
+	// 归纳主题使用的模型或模型池，通常配置更便宜的模型，为空时使用评审模型，同 --themes-model
+	ThemesModel string `yaml:"themes_model,omitempty"`
+	// 根据 cr stats 同步的反馈提高精确率低的模型的最低报告级别，同 --calibrate
+	Calibrate bool `yaml:"calibrate,omitempty"`
+	// 启用 --run-tests 时执行的测试命令，为空时在 Go 仓库中对改动的包执行 go test
+	TestCommand string `yaml:"test_command,omitempty"`
+	// 不参与评审的路径，支持 * 和 ** 通配符
+	Exclude []string `yaml:"exclude,omitempty"`
+	// 不调用模型评审的改动类型：feature, bugfix, refactor, test, docs, config, dependency
+	SkipKinds []string `yaml:"skip_kinds,omitempty"`
+}
+
+// OutputConfig 输出相关配置
+type OutputConfig struct {
+	// 报告格式
+	Format string `yaml:"format,omitempty"`
+	// 报告语言：zh, en
+	Lang string `yaml:"lang,omitempty"`
+	// 自定义报告模板路径，相对路径以配置文件所在目录为准
+	Template string `yaml:"template,omitempty"`
+	// 完整报告的链接，markdown-github 格式附在评论末尾
+	ReportURL string `yaml:"report_url,omitempty"`
+	// 代码片段每行的最大显示宽度，0 表示不限制，同 --snippet-width
+	SnippetWidth *int `yaml:"snippet_width,omitempty"`
+	// Markdown 报告和 PR 评论中问题标题的样式：default, conventional，同 --comment-style
+	CommentStyle string `yaml:"comment_style,omitempty"`
+	// 问题标题模板，使用 text/template 语法，如 "[{{.Category}}] {{.Summary}}"，同 --title-template
+	TitleTemplate string `yaml:"title_template,omitempty"`
+}
+
+// Default 返回默认配置
+func Default() *Config {
+	return &Config{Version: CurrentVersion}
+}
+
+// Find 从dir开始向上查找配置文件，到达仓库根目录(包含.git)或文件系统根目录时停止
+// 未找到时返回空字符串
+func Find(dir string) string {
+	for {
+		path := filepath.Join(dir, FileName)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// Load 加载配置文件，文件不存在时返回默认配置
+// 旧版本的配置会在内存中自动迁移，第二个返回值表示是否发生了迁移
+func Load(path string) (*Config, bool, error) {
+	if path == "" {
+		return Default(), false, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Default(), false, nil
+		}
+		return nil, false, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	raw, err := decodeRaw(data)
+	if err != nil {
+		return nil, false, err
+	}
+	from := rawVersion(raw)
+	if from > CurrentVersion {
+		return nil, false, fmt.Errorf("配置文件版本 %d 高于当前工具支持的版本 %d，请升级工具", from, CurrentVersion)
+	}
+
+	if err := migrateRaw(raw, from); err != nil {
+		return nil, false, err
+	}
+
+	cfg, err := fromRaw(raw)
+	if err != nil {
+		return nil, false, err
+	}
+	return cfg, from < CurrentVersion, nil
+}
+
+// Marshal 将配置序列化为YAML
+func Marshal(cfg *Config) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return nil, fmt.Errorf("序列化配置失败: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("序列化配置失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Save 将配置写入文件
+func Save(path string, cfg *Config) error {
+	data, err := Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入配置文件失败: %v", err)
+	}
+	return nil
+}
+
+// decodeRaw 将配置文件解析为通用的键值结构，供迁移使用
+func decodeRaw(data []byte) (map[string]interface{}, error) {
+	raw := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+	if raw == nil {
+		raw = make(map[string]interface{})
+	}
+	return raw, nil
+}
+
+// fromRaw 将迁移后的键值结构转换为Config
+func fromRaw(raw map[string]interface{}) (*Config, error) {
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("序列化配置失败: %v", err)
+	}
+
+	cfg := Default()
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %v", err)
+	}
+	return cfg, nil
+}
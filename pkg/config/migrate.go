@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+// migration 将配置从 version 升级到 version+1
+type migration func(raw map[string]interface{}) error
+
+// migrations 按版本排列的迁移步骤，migrations[i] 负责从版本 i 升级到版本 i+1
+var migrations = []migration{
+	migrateV0ToV1,
+}
+
+// MigrateResult 迁移结果
+type MigrateResult struct {
+	FromVersion int    // 迁移前的版本
+	ToVersion   int    // 迁移后的版本
+	BackupPath  string // 备份文件路径，未发生迁移时为空
+}
+
+// MigrateFile 将配置文件原地升级到当前版本，升级前会备份原文件
+func MigrateFile(path string) (*MigrateResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	raw, err := decodeRaw(data)
+	if err != nil {
+		return nil, err
+	}
+
+	from := rawVersion(raw)
+	result := &MigrateResult{FromVersion: from, ToVersion: from}
+	if from > CurrentVersion {
+		return nil, fmt.Errorf("配置文件版本 %d 高于当前工具支持的版本 %d，请升级工具", from, CurrentVersion)
+	}
+	if from == CurrentVersion {
+		return result, nil
+	}
+
+	if err := migrateRaw(raw, from); err != nil {
+		return nil, err
+	}
+	cfg, err := fromRaw(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	// 备份原文件
+	backupPath := fmt.Sprintf("%s.v%d.bak", path, from)
+	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+		return nil, fmt.Errorf("备份配置文件失败: %v", err)
+	}
+
+	if err := Save(path, cfg); err != nil {
+		return nil, err
+	}
+
+	result.ToVersion = CurrentVersion
+	result.BackupPath = backupPath
+	return result, nil
+}
+
+// rawVersion 读取配置的版本号，未声明版本的配置视为版本0
+func rawVersion(raw map[string]interface{}) int {
+	if v, ok := raw["version"].(int); ok {
+		return v
+	}
+	return 0
+}
+
+// migrateRaw 依次执行迁移步骤，直到当前版本
+func migrateRaw(raw map[string]interface{}, from int) error {
+	for v := from; v < CurrentVersion; v++ {
+		if err := migrations[v](raw); err != nil {
+			return fmt.Errorf("配置从版本 %d 迁移到 %d 失败: %v", v, v+1, err)
+		}
+		raw["version"] = v + 1
+	}
+	return nil
+}
+
+// migrateV0ToV1 版本0是未声明版本、键名与命令行参数一致的扁平结构，
+// 版本1按 model/review/output 分组
+func migrateV0ToV1(raw map[string]interface{}) error {
+	moves := []struct {
+		oldKey  string
+		section string
+		newKey  string
+	}{
+		{"model", "model", "default"},
+		{"concurrency", "review", "concurrency"},
+		{"cache-memory", "review", "cache_memory_mb"},
+		{"format", "output", "format"},
+	}
+
+	for _, m := range moves {
+		value, ok := raw[m.oldKey]
+		if !ok {
+			continue
+		}
+		// 已经是分组结构的键保持不变
+		if _, isMap := value.(map[string]interface{}); isMap {
+			continue
+		}
+		delete(raw, m.oldKey)
+
+		section, ok := raw[m.section].(map[string]interface{})
+		if !ok {
+			section = make(map[string]interface{})
+			raw[m.section] = section
+		}
+		section[m.newKey] = value
+	}
+	return nil
+}
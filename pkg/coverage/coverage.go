@@ -0,0 +1,209 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// block 覆盖率文件中的一个代码块
+type block struct {
+	startLine, endLine int
+	count              int
+}
+
+// Profile 解析后的覆盖率数据，键为相对仓库根目录的文件路径
+type Profile map[string][]block
+
+// FileCoverage 单个文件中变更行的覆盖情况
+type FileCoverage struct {
+	File string
+	// 可执行的变更行数（不含注释、空行等未被插桩的行）
+	Total int
+	// 被测试覆盖的变更行数
+	Covered int
+	// 未被覆盖的变更行号
+	Uncovered []int
+}
+
+// Percent 返回覆盖率百分比，没有可执行的变更行时返回100
+func (f FileCoverage) Percent() float64 {
+	if f.Total == 0 {
+		return 100
+	}
+	return float64(f.Covered) * 100 / float64(f.Total)
+}
+
+// Report 变更行覆盖率报告
+type Report struct {
+	Files   []FileCoverage
+	Total   int
+	Covered int
+}
+
+// Percent 返回整体覆盖率百分比
+func (r *Report) Percent() float64 {
+	if r.Total == 0 {
+		return 100
+	}
+	return float64(r.Covered) * 100 / float64(r.Total)
+}
+
+// LoadProfile 读取 go test -coverprofile 生成的覆盖率文件
+// 文件中的路径以模块路径开头，root 为模块根目录，用于将其转换为相对路径
+func LoadProfile(path, root string) (Profile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取覆盖率文件失败: %v", err)
+	}
+	defer f.Close()
+	return ParseProfile(f, modulePath(root))
+}
+
+// ParseProfile 解析覆盖率数据，module 为模块路径
+func ParseProfile(r io.Reader, module string) (Profile, error) {
+	profile := make(Profile)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+
+		// 格式：file.go:startLine.startCol,endLine.endCol numStmt count
+		colon := strings.LastIndex(line, ":")
+		if colon < 0 {
+			return nil, fmt.Errorf("覆盖率文件第%d行格式错误: %s", lineNo, line)
+		}
+		fields := strings.Fields(line[colon+1:])
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("覆盖率文件第%d行格式错误: %s", lineNo, line)
+		}
+		start, end, ok := strings.Cut(fields[0], ",")
+		if !ok {
+			return nil, fmt.Errorf("覆盖率文件第%d行格式错误: %s", lineNo, line)
+		}
+		startLine, err1 := strconv.Atoi(strings.Split(start, ".")[0])
+		endLine, err2 := strconv.Atoi(strings.Split(end, ".")[0])
+		count, err3 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil || err3 != nil {
+			return nil, fmt.Errorf("覆盖率文件第%d行格式错误: %s", lineNo, line)
+		}
+
+		file := line[:colon]
+		if module != "" {
+			file = strings.TrimPrefix(strings.TrimPrefix(file, module), "/")
+		}
+		profile[file] = append(profile[file], block{startLine: startLine, endLine: endLine, count: count})
+	}
+	return profile, scanner.Err()
+}
+
+// Analyze 计算变更行的覆盖率，changed 为文件到新增行号的映射
+// 覆盖率文件中没有的文件（如非 Go 文件、未被测试编译的包）不参与统计
+func Analyze(profile Profile, changed map[string][]int) *Report {
+	report := &Report{}
+	files := make([]string, 0, len(changed))
+	for file := range changed {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		blocks, ok := profile[file]
+		if !ok {
+			continue
+		}
+		fc := FileCoverage{File: file}
+		for _, line := range changed[file] {
+			instrumented, covered := false, false
+			for _, b := range blocks {
+				if line >= b.startLine && line <= b.endLine {
+					instrumented = true
+					if b.count > 0 {
+						covered = true
+						break
+					}
+				}
+			}
+			if !instrumented {
+				continue
+			}
+			fc.Total++
+			if covered {
+				fc.Covered++
+			} else {
+				fc.Uncovered = append(fc.Uncovered, line)
+			}
+		}
+		if fc.Total == 0 {
+			continue
+		}
+		report.Files = append(report.Files, fc)
+		report.Total += fc.Total
+		report.Covered += fc.Covered
+	}
+	return report
+}
+
+// UncoveredIssues 为存在未覆盖新增代码的文件生成警告
+func UncoveredIssues(report *Report) []types.Issue {
+	var issues []types.Issue
+	for _, fc := range report.Files {
+		if len(fc.Uncovered) == 0 {
+			continue
+		}
+		issues = append(issues, types.Issue{
+			Title:       "新增代码缺少测试覆盖",
+			FilePath:    fc.File,
+			Line:        fc.Uncovered[0],
+			Severity:    types.SeverityWarning,
+			Category:    types.CategoryTest,
+			Description: fmt.Sprintf("%d 行新增代码中有 %d 行未被测试覆盖（覆盖率 %.1f%%），未覆盖的行：%s。", fc.Total, len(fc.Uncovered), fc.Percent(), FormatRanges(fc.Uncovered)),
+			Suggestion:  "为未覆盖的分支和错误处理路径补充测试。",
+		})
+	}
+	return issues
+}
+
+// FormatRanges 将有序行号列表格式化为区间，如 3-5, 9, 12-14
+func FormatRanges(lines []int) string {
+	var parts []string
+	for i := 0; i < len(lines); {
+		j := i
+		for j+1 < len(lines) && lines[j+1] == lines[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(lines[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", lines[i], lines[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
+
+// modulePath 读取 go.mod 中的模块路径，读取失败时返回空字符串
+func modulePath(root string) string {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module")), `"`)
+		}
+	}
+	return ""
+}
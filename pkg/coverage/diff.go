@@ -0,0 +1,52 @@
+package coverage
+
+import (
+	"strconv"
+	"strings"
+)
+
+// AddedLines 从统一差异格式中解析新文件中新增的行号
+func AddedLines(diff string) []int {
+	var lines []int
+	newLine := 0
+	inHunk := false
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "@@") {
+			newLine = hunkNewStart(line)
+			inHunk = newLine > 0
+			continue
+		}
+		if !inHunk {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+"):
+			lines = append(lines, newLine)
+			newLine++
+		case strings.HasPrefix(line, "-"):
+		case strings.HasPrefix(line, `\`):
+			// \ No newline at end of file
+		default:
+			newLine++
+		}
+	}
+	return lines
+}
+
+// hunkNewStart 解析 @@ -a,b +c,d @@ 中新文件的起始行号 c
+func hunkNewStart(header string) int {
+	i := strings.Index(header, " +")
+	if i < 0 {
+		return 0
+	}
+	rest := header[i+2:]
+	end := strings.IndexAny(rest, ", ")
+	if end < 0 {
+		return 0
+	}
+	n, err := strconv.Atoi(rest[:end])
+	if err != nil {
+		return 0
+	}
+	return n
+}
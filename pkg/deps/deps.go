@@ -0,0 +1,283 @@
+package deps
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeType 依赖的变更类型
+type ChangeType string
+
+const (
+	Added   ChangeType = "added"
+	Updated ChangeType = "updated"
+	Removed ChangeType = "removed"
+)
+
+// Jump 版本跨度
+type Jump string
+
+const (
+	JumpMajor     Jump = "major"
+	JumpMinor     Jump = "minor"
+	JumpPatch     Jump = "patch"
+	JumpDowngrade Jump = "downgrade"
+	// 版本号无法比较，如分支名、范围表达式
+	JumpUnknown Jump = "unknown"
+)
+
+// Change 清单文件中一个依赖的变更
+type Change struct {
+	// 清单文件路径
+	Manifest string
+	// 生态，如 go、npm、pypi、maven
+	Ecosystem string
+	Name      string
+	// 变更前后的版本，新增时 From 为空，删除时 To 为空
+	From string
+	To   string
+	Type ChangeType
+	// 版本跨度，只对更新的依赖计算
+	Jump Jump
+}
+
+// Ecosystem 返回清单文件所属的生态，不是支持的清单文件时返回空字符串
+func Ecosystem(filePath string) string {
+	base := strings.ToLower(path.Base(filePath))
+	switch {
+	case base == "go.mod":
+		return "go"
+	case base == "package.json":
+		return "npm"
+	case strings.HasPrefix(base, "requirements") && path.Ext(base) == ".txt":
+		return "pypi"
+	case base == "pom.xml":
+		return "maven"
+	default:
+		return ""
+	}
+}
+
+// Diff 比较清单文件变更前后的内容，返回按名称排序的依赖变更
+// 新增的清单文件 oldContent 为空，删除的清单文件 newContent 为空
+func Diff(manifest, oldContent, newContent string) ([]Change, error) {
+	ecosystem := Ecosystem(manifest)
+	if ecosystem == "" {
+		return nil, fmt.Errorf("不支持的依赖清单文件: %s", manifest)
+	}
+	before, err := parse(ecosystem, oldContent)
+	if err != nil {
+		return nil, fmt.Errorf("解析变更前的 %s 失败: %v", manifest, err)
+	}
+	after, err := parse(ecosystem, newContent)
+	if err != nil {
+		return nil, fmt.Errorf("解析变更后的 %s 失败: %v", manifest, err)
+	}
+
+	var changes []Change
+	for name, to := range after {
+		from, ok := before[name]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Name: name, To: to, Type: Added})
+		case from != to:
+			changes = append(changes, Change{Name: name, From: from, To: to, Type: Updated, Jump: VersionJump(from, to)})
+		}
+	}
+	for name, from := range before {
+		if _, ok := after[name]; !ok {
+			changes = append(changes, Change{Name: name, From: from, Type: Removed})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	for i := range changes {
+		changes[i].Manifest = manifest
+		changes[i].Ecosystem = ecosystem
+	}
+	return changes, nil
+}
+
+// parse 解析清单文件，返回依赖名称到版本的映射
+func parse(ecosystem, content string) (map[string]string, error) {
+	if strings.TrimSpace(content) == "" {
+		return map[string]string{}, nil
+	}
+	switch ecosystem {
+	case "go":
+		return parseGoMod(content), nil
+	case "npm":
+		return parsePackageJSON(content)
+	case "pypi":
+		return parseRequirements(content), nil
+	case "maven":
+		return parsePOM(content)
+	default:
+		return nil, fmt.Errorf("不支持的生态: %s", ecosystem)
+	}
+}
+
+// parseGoMod 解析 go.mod 中的 require 指令，包括 require 块
+func parseGoMod(content string) map[string]string {
+	result := make(map[string]string)
+	inBlock := false
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case inBlock && fields[0] == ")":
+			inBlock = false
+			continue
+		case fields[0] == "require" && len(fields) >= 2 && fields[1] == "(":
+			inBlock = true
+			continue
+		case fields[0] == "require":
+			fields = fields[1:]
+		case !inBlock:
+			continue
+		}
+		if len(fields) >= 2 {
+			result[fields[0]] = fields[1]
+		}
+	}
+	return result
+}
+
+// parsePackageJSON 解析 package.json 中的各类依赖，同名依赖以 dependencies 为准
+func parsePackageJSON(content string) (map[string]string, error) {
+	var manifest struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		PeerDependencies     map[string]string `json:"peerDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err := json.Unmarshal([]byte(content), &manifest); err != nil {
+		return nil, err
+	}
+	result := make(map[string]string)
+	for _, group := range []map[string]string{manifest.PeerDependencies, manifest.OptionalDependencies, manifest.DevDependencies, manifest.Dependencies} {
+		for name, version := range group {
+			result[name] = version
+		}
+	}
+	return result, nil
+}
+
+// parseRequirements 解析 requirements.txt，名称按 PEP 503 规范化，未指定版本时版本为空
+func parseRequirements(content string) map[string]string {
+	result := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if i := strings.Index(line, ";"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "-") || strings.Contains(line, "://") {
+			continue
+		}
+		end := strings.IndexAny(line, "=<>!~[ ")
+		if end < 0 {
+			end = len(line)
+		}
+		name := strings.NewReplacer("_", "-", ".", "-").Replace(strings.ToLower(line[:end]))
+		spec := line[end:]
+		if i := strings.Index(spec, "]"); i >= 0 {
+			spec = spec[i+1:]
+		}
+		result[name] = strings.ReplaceAll(strings.TrimSpace(spec), " ", "")
+	}
+	return result
+}
+
+// parsePOM 解析 pom.xml 中的依赖和 dependencyManagement，版本中的 ${属性} 使用 properties 中的值
+func parsePOM(content string) (map[string]string, error) {
+	type dependency struct {
+		GroupID    string `xml:"groupId"`
+		ArtifactID string `xml:"artifactId"`
+		Version    string `xml:"version"`
+	}
+	var pom struct {
+		Properties struct {
+			Entries []struct {
+				XMLName xml.Name
+				Value   string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:"properties"`
+		Dependencies []dependency `xml:"dependencies>dependency"`
+		Managed      []dependency `xml:"dependencyManagement>dependencies>dependency"`
+	}
+	if err := xml.Unmarshal([]byte(content), &pom); err != nil {
+		return nil, err
+	}
+	properties := make(map[string]string)
+	for _, entry := range pom.Properties.Entries {
+		properties[entry.XMLName.Local] = strings.TrimSpace(entry.Value)
+	}
+	result := make(map[string]string)
+	for _, dep := range append(pom.Managed, pom.Dependencies...) {
+		version := strings.TrimSpace(dep.Version)
+		if strings.HasPrefix(version, "${") && strings.HasSuffix(version, "}") {
+			if value, ok := properties[version[2:len(version)-1]]; ok {
+				version = value
+			}
+		}
+		result[strings.TrimSpace(dep.GroupID)+":"+strings.TrimSpace(dep.ArtifactID)] = version
+	}
+	return result, nil
+}
+
+// VersionJump 比较两个版本号的跨度，忽略 v、^、~、== 等前缀和预发布后缀
+func VersionJump(from, to string) Jump {
+	a, okA := versionNumbers(from)
+	b, okB := versionNumbers(to)
+	if !okA || !okB {
+		return JumpUnknown
+	}
+	for i := 0; i < 3; i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		if b[i] < a[i] {
+			return JumpDowngrade
+		}
+		return []Jump{JumpMajor, JumpMinor, JumpPatch}[i]
+	}
+	// 数字部分相同，只有预发布等后缀不同
+	return JumpUnknown
+}
+
+// versionNumbers 提取版本号的主、次、修订号，缺少的部分视为 0
+func versionNumbers(version string) ([3]int, bool) {
+	var numbers [3]int
+	version = strings.TrimLeft(strings.TrimSpace(version), "vV^~=<>! ")
+	if i := strings.IndexAny(version, "-+,"); i >= 0 {
+		version = version[:i]
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) == 0 || len(parts) > 4 {
+		return numbers, false
+	}
+	for i := 0; i < len(parts) && i < 3; i++ {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return numbers, false
+		}
+		numbers[i] = n
+	}
+	return numbers, true
+}
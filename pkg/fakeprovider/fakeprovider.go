@@ -0,0 +1,398 @@
+// Package fakeprovider 提供兼容 OpenAI 接口、返回固定回复的模拟模型服务
+// 不调用真实的模型，用于在本地和隔离网络中对钩子、CI 配置和报告流程做端到端的冒烟测试
+package fakeprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/model"
+)
+
+// DefaultModel 未指定时回复中的模型名称
+const DefaultModel = "fake"
+
+// Options 模拟模型服务的配置
+type Options struct {
+	// 回复中的模型名称，为空时使用请求中的模型名称
+	Model string
+	// 固定回复规则，为 nil 时全部使用默认回复
+	Fixtures *Fixtures
+	// 每次回复前的等待时间，规则中设置了 delay 时以规则为准
+	Delay time.Duration
+	// 每个请求记录一行日志，为 nil 时不记录
+	Log io.Writer
+}
+
+// Server 模拟模型服务
+type Server struct {
+	opts Options
+
+	mu sync.Mutex
+	// 请求序号和各规则已生效的次数
+	seq  int
+	used []int
+}
+
+// NewServer 创建模拟模型服务
+func NewServer(opts Options) *Server {
+	s := &Server{opts: opts}
+	if opts.Fixtures != nil {
+		s.used = make([]int, len(opts.Fixtures.Responses))
+	}
+	return s
+}
+
+// request 解析后的聊天请求
+type request struct {
+	model  string
+	system string
+	user   string
+	// 逐个文件评审的请求中的文件路径和改动类型，其他请求为空
+	file, changeType string
+}
+
+// reply 对一个请求的回复
+type reply struct {
+	status  int
+	content string
+	delay   time.Duration
+	// 生效的规则序号（从1开始），默认回复为0
+	rule int
+}
+
+// Handler 返回模拟服务的 HTTP 处理器：
+// POST 任意以 /chat/completions 结尾的路径（如 /v1/chat/completions）返回聊天回复，GET /v1/models 返回模型列表
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/chat/completions"):
+			s.handleChat(w, r)
+		case r.URL.Path == "/v1/models" || r.URL.Path == "/models":
+			s.handleModels(w, r)
+		default:
+			writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("未知的接口: %s", r.URL.Path))
+		}
+	})
+}
+
+// handleChat 处理聊天请求
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "只支持 POST 请求")
+		return
+	}
+	// 与真实的服务一样要求认证头，便于发现未设置密钥的配置，密钥的值不做校验
+	if r.Header.Get("Authorization") == "" {
+		writeError(w, http.StatusUnauthorized, "invalid_api_key", "缺少 Authorization 请求头")
+		return
+	}
+	var body model.ChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("解析请求失败: %v", err))
+		return
+	}
+	if len(body.Messages) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "messages 不能为空")
+		return
+	}
+	req := parseRequest(&body)
+
+	s.mu.Lock()
+	s.seq++
+	seq := s.seq
+	rep := s.match(req)
+	s.mu.Unlock()
+
+	kind := kindOf(req)
+	if req.file != "" {
+		kind += " " + req.file
+	}
+	s.logf("#%d %s -> %d%s", seq, kind, rep.status, ruleSuffix(rep.rule))
+	if rep.delay > 0 {
+		select {
+		case <-time.After(rep.delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if rep.status != http.StatusOK {
+		if rep.status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "1")
+		}
+		writeError(w, rep.status, "fake_error", rep.content)
+		return
+	}
+
+	modelName := s.opts.Model
+	if modelName == "" {
+		modelName = req.model
+	}
+	prompt := estimateTokens(req.system) + estimateTokens(req.user)
+	completion := estimateTokens(rep.content)
+	writeJSON(w, http.StatusOK, model.ChatResponse{
+		ID:      fmt.Sprintf("fake-%d", seq),
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   modelName,
+		Choices: []model.Choice{{
+			Message:      model.Message{Role: "assistant", Content: rep.content},
+			FinishReason: "stop",
+		}},
+		Usage: model.Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion},
+	})
+}
+
+// handleModels 返回只包含一个模型的模型列表
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	name := s.opts.Model
+	if name == "" {
+		name = DefaultModel
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   []map[string]interface{}{{"id": name, "object": "model", "owned_by": "ai-cr-tool"}},
+	})
+}
+
+// match 按固定回复规则生成回复，没有规则匹配时使用默认回复；调用方持有锁
+func (s *Server) match(req *request) reply {
+	rep := reply{status: http.StatusOK, delay: s.opts.Delay}
+	if s.opts.Fixtures != nil {
+		for i := range s.opts.Fixtures.Responses {
+			rule := &s.opts.Fixtures.Responses[i]
+			if (rule.Times > 0 && s.used[i] >= rule.Times) || !rule.matches(req) {
+				continue
+			}
+			s.used[i]++
+			rep.rule = i + 1
+			if rule.Delay != "" {
+				rep.delay = rule.delay
+			}
+			if rule.Status != 0 {
+				rep.status = rule.Status
+			}
+			switch {
+			case rule.Content != "":
+				rep.content = rule.Content
+			case rep.status != http.StatusOK:
+				rep.content = fmt.Sprintf("fake-provider 按第 %d 条规则返回 %d", i+1, rep.status)
+			case rule.hasIssues:
+				rep.content = reviewContent(req, rule.Issues, rule.ChangeKind)
+			default:
+				rep.content = defaultContent(req)
+			}
+			return rep
+		}
+	}
+	rep.content = defaultContent(req)
+	return rep
+}
+
+// logf 记录一行日志
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.opts.Log != nil {
+		fmt.Fprintf(s.opts.Log, time.Now().Format("15:04:05 ")+format+"\n", args...)
+	}
+}
+
+// parseRequest 从聊天请求中取出系统提示、用户消息，以及逐个文件评审的文件路径和改动类型
+func parseRequest(body *model.ChatRequest) *request {
+	req := &request{model: body.Model}
+	var system, user []string
+	for _, m := range body.Messages {
+		if m.Role == "system" {
+			system = append(system, m.Content)
+		} else {
+			user = append(user, m.Content)
+		}
+	}
+	req.system = strings.Join(system, "\n")
+	req.user = strings.Join(user, "\n")
+
+	// 评审提示的用户消息以 "文件: 路径\n改动类型: 类型" 开头
+	if rest, ok := strings.CutPrefix(req.user, "文件: "); ok {
+		file, rest, _ := strings.Cut(rest, "\n")
+		req.file = file
+		if changeType, ok := strings.CutPrefix(rest, "改动类型: "); ok {
+			req.changeType, _, _ = strings.Cut(changeType, "\n")
+		}
+	}
+	return req
+}
+
+// kindOf 按提示内容判断请求的类型，用于选择默认回复和记录日志
+func kindOf(req *request) string {
+	switch {
+	case req.file != "":
+		return "review"
+	case strings.Contains(req.system, `"themes"`):
+		return "themes"
+	case strings.Contains(req.system, `"decision"`):
+		return "summary"
+	case strings.Contains(req.system, `"dependencies"`):
+		return "dependencies"
+	case strings.Contains(req.system, `"overview"`) && strings.Contains(req.system, `"risks"`):
+		return "translate"
+	case strings.Contains(req.system, `"strengths"`):
+		return "approach"
+	case strings.Contains(req.system, `"recommendation"`):
+		return "contrast"
+	default:
+		return "chat"
+	}
+}
+
+// defaultContent 返回内置的默认回复，格式与各类提示要求的输出一致
+func defaultContent(req *request) string {
+	switch kindOf(req) {
+	case "review":
+		var issues []Issue
+		if line := firstAddedLine(req.user); line > 0 {
+			issues = append(issues, Issue{
+				Title:       "fake-provider 示例问题",
+				Line:        line,
+				Severity:    "warning",
+				Category:    "maintainability",
+				Description: "这是 cr fake-provider 返回的固定评审意见，用于验证钩子、CI 和报告流程，不代表真实的评审结果。",
+				Suggestion:  "无需处理；正式评审请使用真实的模型。",
+			})
+		}
+		return reviewContent(req, issues, "")
+	case "themes":
+		var numbers []int
+		for _, line := range strings.Split(req.user, "\n") {
+			if n, _, ok := strings.Cut(line, ". "); ok {
+				if i, err := strconv.Atoi(n); err == nil {
+					numbers = append(numbers, i)
+				}
+			}
+		}
+		themes := []interface{}{}
+		if len(numbers) > 1 {
+			themes = append(themes, map[string]interface{}{
+				"title": "fake-provider 示例主题", "summary": "fake-provider 把全部问题归为一个主题。", "issues": numbers,
+			})
+		}
+		return mustJSON(map[string]interface{}{"themes": themes})
+	case "summary":
+		return mustJSON(map[string]interface{}{
+			"overview": "这是 cr fake-provider 返回的固定执行摘要，不代表真实的评审结论。",
+			"risks":    []string{},
+			"decision": "approve_with_suggestions",
+		})
+	case "translate":
+		// 原样返回待翻译的摘要，格式与译文相同
+		return req.user
+	case "dependencies":
+		return mustJSON(map[string]interface{}{
+			"overview":     "这是 cr fake-provider 返回的固定依赖评审结果。",
+			"dependencies": []interface{}{},
+		})
+	case "approach":
+		return mustJSON(map[string]interface{}{
+			"summary":    "这是 cr fake-provider 返回的固定方案概括。",
+			"strengths":  []string{},
+			"weaknesses": []string{},
+		})
+	case "contrast":
+		return mustJSON(map[string]interface{}{
+			"differences":    []string{},
+			"recommendation": "这是 cr fake-provider 返回的固定对比结论。",
+		})
+	default:
+		return "这是 cr fake-provider 返回的固定回复。"
+	}
+}
+
+// reviewContent 生成逐个文件评审的回复；系统提示未要求 JSON 格式时返回 Markdown 文本
+func reviewContent(req *request, issues []Issue, changeKind string) string {
+	if changeKind == "" {
+		changeKind = "refactor"
+		if req.changeType == "added" {
+			changeKind = "feature"
+		}
+	}
+	if !strings.Contains(req.system, `"change_kind"`) {
+		if len(issues) == 0 {
+			return "未发现问题。"
+		}
+		var b strings.Builder
+		for _, issue := range issues {
+			fmt.Fprintf(&b, "- **%s**（第 %d 行，%s）：%s\n", issue.Title, issue.Line, issue.Severity, issue.Description)
+		}
+		return b.String()
+	}
+	if issues == nil {
+		issues = []Issue{}
+	}
+	return mustJSON(map[string]interface{}{"change_kind": changeKind, "issues": issues})
+}
+
+// hunkHeader 匹配改动块头部行中新文件的起始行号
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// firstAddedLine 返回差异中第一个新增行在新文件中的行号，没有新增行时返回0
+func firstAddedLine(diff string) int {
+	line := 0
+	for _, text := range strings.Split(diff, "\n") {
+		if m := hunkHeader.FindStringSubmatch(text); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			if line == 0 {
+				line = 1
+			}
+			continue
+		}
+		if line == 0 {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+		case strings.HasPrefix(text, "+"):
+			return line
+		case strings.HasPrefix(text, " ") || text == "":
+			line++
+		}
+	}
+	return 0
+}
+
+// estimateTokens 粗略估算文本的 token 数，用于回复中的用量统计
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// ruleSuffix 日志中标明生效的规则
+func ruleSuffix(rule int) string {
+	if rule == 0 {
+		return ""
+	}
+	return fmt.Sprintf("（规则 %d）", rule)
+}
+
+// mustJSON 序列化回复内容，内容均为内置的简单结构，不会失败
+func mustJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// writeJSON 写入 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError 写入 OpenAI 格式的错误响应
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{"message": message, "type": code, "code": status},
+	})
+}
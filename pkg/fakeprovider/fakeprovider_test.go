@@ -0,0 +1,284 @@
+package fakeprovider
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/icatw/ai-cr-tool/pkg/model"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+const testDiff = "@@ -1,2 +1,3 @@\n package main\n+// TODO\n func main() {}\n"
+
+// newTestClient 启动模拟服务，返回指向它的 OpenAI 兼容客户端
+func newTestClient(t *testing.T, opts Options) (*httptest.Server, model.ModelClient) {
+	t.Helper()
+	srv := httptest.NewServer(NewServer(opts).Handler())
+	t.Cleanup(srv.Close)
+	client := model.NewOpenAIClient(&model.Config{
+		Type:    "openai",
+		APIKey:  "test",
+		Model:   "fake-test",
+		BaseURL: srv.URL + "/v1/chat/completions",
+	})
+	return srv, client
+}
+
+// loadTestFixtures 把固定回复写入临时文件后读取
+func loadTestFixtures(t *testing.T, content string) *Fixtures {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "fixtures.yaml")
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fixtures, err := LoadFixtures(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fixtures
+}
+
+// reviewRequest 用内置的评审提示生成逐个文件评审的请求
+func reviewRequest(file string) *model.ChatRequest {
+	return &model.ChatRequest{Messages: model.DefaultReviewPrompt().GeneratePrompt(file, "modified", testDiff)}
+}
+
+func TestCannedResponses(t *testing.T) {
+	fixtures := loadTestFixtures(t, `
+responses:
+  - file: "*_test.go"
+    issues: []
+  - file: "internal/*"
+    change_kind: bugfix
+    issues:
+      - title: 固定问题
+        line: 2
+        severity: error
+        category: bug
+        description: 规则返回的问题
+        suggestion: 修复它
+  - match: 固定文本
+    content: 原样返回的回复
+`)
+	_, client := newTestClient(t, Options{Fixtures: fixtures})
+
+	tests := []struct {
+		name     string
+		req      *model.ChatRequest
+		issues   []string
+		severity types.SeverityLevel
+		kind     types.ChangeKind
+		content  string
+	}{
+		{
+			// 没有规则匹配时在第一个新增行报告一个示例问题
+			name:     "默认回复",
+			req:      reviewRequest("main.go"),
+			issues:   []string{"fake-provider 示例问题"},
+			severity: types.SeverityLevel("warning"),
+			kind:     types.ChangeKind("refactor"),
+		},
+		{
+			name: "显式的空问题列表",
+			req:  reviewRequest("main_test.go"),
+			kind: types.ChangeKind("refactor"),
+		},
+		{
+			name:     "规则指定的问题和改动类型",
+			req:      reviewRequest("internal/a.go"),
+			issues:   []string{"固定问题"},
+			severity: types.SeverityLevel("error"),
+			kind:     types.ChangeKind("bugfix"),
+		},
+		{
+			name:    "按文本匹配原样返回",
+			req:     &model.ChatRequest{Messages: []model.Message{{Role: "user", Content: "包含固定文本的请求"}}},
+			content: "原样返回的回复",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Chat(tt.req)
+			if err != nil {
+				t.Fatalf("请求失败: %v", err)
+			}
+			content := resp.Choices[0].Message.Content
+			if tt.content != "" {
+				if content != tt.content {
+					t.Fatalf("回复: 得到 %q，期望 %q", content, tt.content)
+				}
+				return
+			}
+
+			// 回复按评审引擎的方式解析
+			issues := review.ParseIssues("main.go", content, "")
+			if len(issues) != len(tt.issues) {
+				t.Fatalf("问题数: 得到 %d，期望 %d（回复: %s）", len(issues), len(tt.issues), content)
+			}
+			for i, issue := range issues {
+				if issue.Title != tt.issues[i] || issue.Severity != tt.severity || issue.Line != 2 {
+					t.Fatalf("问题不符: %+v", issue)
+				}
+			}
+			if kind := review.ParseChangeKind(content); kind != tt.kind {
+				t.Fatalf("改动类型: 得到 %q，期望 %q", kind, tt.kind)
+			}
+		})
+	}
+}
+
+func TestErrorInjection(t *testing.T) {
+	fixtures := loadTestFixtures(t, `
+responses:
+  - file: flaky.go
+    status: 429
+    times: 1
+  - file: broken.go
+    status: 503
+    content: 服务暂不可用
+`)
+	_, client := newTestClient(t, Options{Fixtures: fixtures})
+
+	tests := []struct {
+		name   string
+		file   string
+		status int
+		body   string
+	}{
+		{name: "限流", file: "flaky.go", status: http.StatusTooManyRequests, body: "fake-provider 按第 1 条规则返回 429"},
+		// 规则只生效一次，重试时使用默认回复
+		{name: "重试后成功", file: "flaky.go"},
+		{name: "服务故障", file: "broken.go", status: http.StatusServiceUnavailable, body: "服务暂不可用"},
+		{name: "每次都失败", file: "broken.go", status: http.StatusServiceUnavailable, body: "服务暂不可用"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Chat(reviewRequest(tt.file))
+			if tt.status == 0 {
+				if err != nil {
+					t.Fatalf("请求失败: %v", err)
+				}
+				if len(resp.Choices) != 1 {
+					t.Fatalf("回复中有 %d 个选项", len(resp.Choices))
+				}
+				return
+			}
+			var apiErr *model.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("期望 APIError，得到 %v", err)
+			}
+			if apiErr.StatusCode != tt.status || !strings.Contains(apiErr.Body, tt.body) {
+				t.Fatalf("错误响应不符: %d %s", apiErr.StatusCode, apiErr.Body)
+			}
+		})
+	}
+}
+
+func TestResponseShape(t *testing.T) {
+	srv, _ := newTestClient(t, Options{})
+
+	post := func(t *testing.T, auth bool) *http.Response {
+		t.Helper()
+		body := `{"model":"gpt-test","messages":[{"role":"user","content":"hello"}]}`
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/v1/chat/completions", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if auth {
+			req.Header.Set("Authorization", "Bearer test")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("聊天回复", func(t *testing.T) {
+		resp := post(t, true)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("状态码: %d", resp.StatusCode)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+			t.Fatalf("Content-Type: %s", ct)
+		}
+		var body struct {
+			ID      string `json:"id"`
+			Object  string `json:"object"`
+			Created int64  `json:"created"`
+			Model   string `json:"model"`
+			Choices []struct {
+				Message      model.Message `json:"message"`
+				FinishReason string        `json:"finish_reason"`
+			} `json:"choices"`
+			Usage struct {
+				PromptTokens     int `json:"prompt_tokens"`
+				CompletionTokens int `json:"completion_tokens"`
+				TotalTokens      int `json:"total_tokens"`
+			} `json:"usage"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body.ID == "" || body.Object != "chat.completion" || body.Created == 0 {
+			t.Fatalf("响应字段不符: %+v", body)
+		}
+		// 未指定模型名称时使用请求中的模型
+		if body.Model != "gpt-test" {
+			t.Fatalf("模型: 得到 %q，期望 gpt-test", body.Model)
+		}
+		if len(body.Choices) != 1 || body.Choices[0].Message.Role != "assistant" || body.Choices[0].Message.Content == "" || body.Choices[0].FinishReason != "stop" {
+			t.Fatalf("选项不符: %+v", body.Choices)
+		}
+		if body.Usage.PromptTokens == 0 || body.Usage.TotalTokens != body.Usage.PromptTokens+body.Usage.CompletionTokens {
+			t.Fatalf("用量不符: %+v", body.Usage)
+		}
+	})
+
+	t.Run("缺少认证头", func(t *testing.T) {
+		resp := post(t, false)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("状态码: 得到 %d，期望 401", resp.StatusCode)
+		}
+		var body struct {
+			Error struct {
+				Message string `json:"message"`
+				Type    string `json:"type"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Error.Type != "invalid_api_key" || body.Error.Message == "" {
+			t.Fatalf("错误响应不符: %+v", body.Error)
+		}
+	})
+
+	t.Run("模型列表", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/v1/models")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var body struct {
+			Object string `json:"object"`
+			Data   []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Object != "list" || len(body.Data) != 1 || body.Data[0].ID != DefaultModel {
+			t.Fatalf("模型列表不符: %+v", body)
+		}
+	})
+}
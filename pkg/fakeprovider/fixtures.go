@@ -0,0 +1,117 @@
+package fakeprovider
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixtures 固定回复文件，按顺序匹配规则，第一条匹配的规则决定回复；没有规则匹配时使用内置的默认回复
+type Fixtures struct {
+	Responses []Rule `yaml:"responses"`
+}
+
+// Rule 一条固定回复规则
+type Rule struct {
+	// 请求中（系统提示或用户消息）需要包含的文本，为空时不限制
+	Match string `yaml:"match,omitempty"`
+	// 评审的文件路径需要匹配的 glob 模式（如 "*.go"、"internal/*"），为空时不限制；设置后只匹配逐个文件的评审请求
+	File string `yaml:"file,omitempty"`
+	// 原样返回的回复内容
+	Content string `yaml:"content,omitempty"`
+	// 评审请求返回的问题列表，设置 content 时忽略；显式写成空列表表示没有问题
+	Issues []Issue `yaml:"issues,omitempty"`
+	// 评审请求返回的改动类型，为空时按文件的改动类型推断
+	ChangeKind string `yaml:"change_kind,omitempty"`
+	// 返回的 HTTP 状态码，非 200 时返回错误响应，用于模拟限流（429）和服务故障（500、503）
+	Status int `yaml:"status,omitempty"`
+	// 回复前的等待时间，如 "2s"，用于模拟慢速模型和超时
+	Delay string `yaml:"delay,omitempty"`
+	// 规则最多生效的次数，为0时不限制；与 status 一起使用可以模拟先失败、重试后成功
+	Times int `yaml:"times,omitempty"`
+
+	// 是否设置了 issues（包括空列表）
+	hasIssues bool
+	delay     time.Duration
+}
+
+// Issue 评审回复中的一个问题，字段与评审提示要求的输出格式相同
+type Issue struct {
+	Title       string `yaml:"title" json:"title"`
+	Line        int    `yaml:"line" json:"line"`
+	Severity    string `yaml:"severity" json:"severity"`
+	Category    string `yaml:"category" json:"category"`
+	Description string `yaml:"description" json:"description"`
+	Suggestion  string `yaml:"suggestion" json:"suggestion"`
+}
+
+// UnmarshalYAML 记录规则是否设置了 issues，区分未设置和空列表
+func (r *Rule) UnmarshalYAML(node *yaml.Node) error {
+	type plain Rule
+	if err := node.Decode((*plain)(r)); err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "issues" {
+			r.hasIssues = true
+		}
+	}
+	return nil
+}
+
+// LoadFixtures 读取并校验固定回复文件
+func LoadFixtures(file string) (*Fixtures, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("读取固定回复文件失败: %v", err)
+	}
+	var f Fixtures
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("解析固定回复文件失败: %v", err)
+	}
+	for i := range f.Responses {
+		r := &f.Responses[i]
+		if r.File != "" {
+			if _, err := path.Match(r.File, ""); err != nil {
+				return nil, fmt.Errorf("第 %d 条规则的 file 无效: %v", i+1, err)
+			}
+		}
+		if r.Status != 0 && (r.Status < 100 || r.Status > 599) {
+			return nil, fmt.Errorf("第 %d 条规则的 status 无效: %d", i+1, r.Status)
+		}
+		if r.Delay != "" {
+			if r.delay, err = time.ParseDuration(r.Delay); err != nil || r.delay < 0 {
+				return nil, fmt.Errorf("第 %d 条规则的 delay 无效: %s", i+1, r.Delay)
+			}
+		}
+		if r.Times < 0 {
+			return nil, fmt.Errorf("第 %d 条规则的 times 不能为负数", i+1)
+		}
+	}
+	return &f, nil
+}
+
+// matches 判断规则是否适用于请求
+func (r *Rule) matches(req *request) bool {
+	if r.Match != "" && !strings.Contains(req.system, r.Match) && !strings.Contains(req.user, r.Match) {
+		return false
+	}
+	if r.File != "" {
+		if req.file == "" {
+			return false
+		}
+		// 不含 / 的模式同时匹配文件名，与 .gitignore 的习惯相同
+		matched, _ := path.Match(r.File, req.file)
+		if !matched && !strings.Contains(r.File, "/") {
+			matched, _ = path.Match(r.File, path.Base(req.file))
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
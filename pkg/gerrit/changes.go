@@ -0,0 +1,117 @@
+package gerrit
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// CommitMessagePath 文件列表中代表提交说明的特殊路径
+const CommitMessagePath = "/COMMIT_MSG"
+
+// Change Gerrit 中的变更
+type Change struct {
+	Project         string `json:"project"`
+	Number          int    `json:"_number"`
+	CurrentRevision string `json:"current_revision"`
+}
+
+// FileInfo 补丁集中改动的文件，Status 为空表示修改，A 为新增，D 为删除，R 为重命名
+type FileInfo struct {
+	Status string `json:"status"`
+}
+
+// CommentRange 评论或替换覆盖的范围，字符位置从 0 开始，结束位置不包含在内
+type CommentRange struct {
+	StartLine      int `json:"start_line"`
+	StartCharacter int `json:"start_character"`
+	EndLine        int `json:"end_line"`
+	EndCharacter   int `json:"end_character"`
+}
+
+// FixReplacement 修复建议中的一处替换
+type FixReplacement struct {
+	Path        string       `json:"path"`
+	Range       CommentRange `json:"range"`
+	Replacement string       `json:"replacement"`
+}
+
+// FixSuggestion 机器人评论附带的修复建议，用户可以在界面上预览并一键应用
+type FixSuggestion struct {
+	Description  string           `json:"description"`
+	Replacements []FixReplacement `json:"replacements"`
+}
+
+// RobotComment 机器人评论，Line 为 0 时评论整个文件
+type RobotComment struct {
+	RobotID        string            `json:"robot_id"`
+	RobotRunID     string            `json:"robot_run_id"`
+	URL            string            `json:"url,omitempty"`
+	Line           int               `json:"line,omitempty"`
+	Message        string            `json:"message"`
+	Properties     map[string]string `json:"properties,omitempty"`
+	FixSuggestions []FixSuggestion   `json:"fix_suggestions,omitempty"`
+}
+
+// ReviewInput 设置评审的请求参数，RobotComments 的键为文件路径
+type ReviewInput struct {
+	Message       string                    `json:"message,omitempty"`
+	Tag           string                    `json:"tag,omitempty"`
+	RobotComments map[string][]RobotComment `json:"robot_comments,omitempty"`
+}
+
+// GetChange 获取变更及其当前补丁集
+func (c *Client) GetChange(number int) (*Change, error) {
+	var change Change
+	if err := c.do("GET", fmt.Sprintf("/changes/%d?o=CURRENT_REVISION", number), nil, &change); err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// ListFiles 列出补丁集中改动的文件，键为文件路径，包含提交说明 /COMMIT_MSG
+func (c *Client) ListFiles(number int, revision string) (map[string]FileInfo, error) {
+	var files map[string]FileInfo
+	path := fmt.Sprintf("/changes/%d/revisions/%s/files", number, url.PathEscape(revision))
+	if err := c.do("GET", path, nil, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// ListRobotComments 列出变更所有补丁集上的机器人评论，键为文件路径
+func (c *Client) ListRobotComments(number int) (map[string][]RobotComment, error) {
+	var comments map[string][]RobotComment
+	if err := c.do("GET", fmt.Sprintf("/changes/%d/robotcomments", number), nil, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// SetReview 在补丁集上发表评审消息和机器人评论
+func (c *Client) SetReview(number int, revision string, input ReviewInput) error {
+	path := fmt.Sprintf("/changes/%d/revisions/%s/review", number, url.PathEscape(revision))
+	return c.do("POST", path, input, nil)
+}
+
+// ChangeURL 返回变更页面的链接
+func (c *Client) ChangeURL(project string, number int) string {
+	return fmt.Sprintf("%s/c/%s/+/%d", c.baseURL, project, number)
+}
+
+// ChangeNumberFromEnv 在 Gerrit 触发的流水线（如 Jenkins Gerrit Trigger）中获取变更编号
+func ChangeNumberFromEnv() (int, error) {
+	if number, err := strconv.Atoi(os.Getenv("GERRIT_CHANGE_NUMBER")); err == nil && number > 0 {
+		return number, nil
+	}
+	return 0, fmt.Errorf("无法从环境中获取 Gerrit 变更编号（GERRIT_CHANGE_NUMBER），请通过 --pr 指定")
+}
+
+// RevisionFromEnv 返回流水线评审的补丁集提交（GERRIT_PATCHSET_REVISION），未设置时返回 current 表示当前补丁集
+func RevisionFromEnv() string {
+	if revision := os.Getenv("GERRIT_PATCHSET_REVISION"); revision != "" {
+		return revision
+	}
+	return "current"
+}
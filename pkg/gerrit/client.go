@@ -0,0 +1,100 @@
+package gerrit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// xssiPrefix Gerrit 在 JSON 响应前添加的防 XSSI 前缀，解析前需要去掉
+const xssiPrefix = ")]}'"
+
+// Client Gerrit REST API 客户端
+type Client struct {
+	baseURL  string
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewClient 创建客户端，baseURL 为实例地址（如 https://gerrit.example.com），password 为用户设置页面生成的 HTTP 密码
+func NewClient(baseURL, username, password string) (*Client, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("未指定 Gerrit 实例地址")
+	}
+	return &Client{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// NewClientFromEnv 使用 GERRIT_USERNAME 和 GERRIT_PASSWORD 环境变量创建客户端，baseURL 为空时读取 GERRIT_URL
+func NewClientFromEnv(baseURL string) (*Client, error) {
+	username, password := os.Getenv("GERRIT_USERNAME"), os.Getenv("GERRIT_PASSWORD")
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("未设置 GERRIT_USERNAME 和 GERRIT_PASSWORD 环境变量（需要机器人账号的 HTTP 密码）")
+	}
+	if baseURL == "" {
+		baseURL = os.Getenv("GERRIT_URL")
+	}
+	if baseURL == "" {
+		return nil, fmt.Errorf("未指定 Gerrit 实例地址，请通过 --url 或 GERRIT_URL 环境变量指定")
+	}
+	return NewClient(baseURL, username, password)
+}
+
+// BaseURL 返回实例地址
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// do 发送API请求，body 和 out 为 nil 时分别表示不发送请求体、不解析响应
+// 需要认证的接口位于 /a/ 之下，path 不包含该前缀
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+"/a"+path, reader)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(c.username, c.password)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求 Gerrit 失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取 Gerrit 响应失败: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Gerrit API %s %s 返回 %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	data = bytes.TrimPrefix(data, []byte(xssiPrefix))
+	if out != nil && len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("解析 Gerrit 响应失败: %v", err)
+		}
+	}
+	return nil
+}
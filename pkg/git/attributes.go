@@ -0,0 +1,85 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Linguist 属性，GitHub 据此把文件视为生成的代码或第三方代码，在差异中默认折叠
+const (
+	LinguistGenerated = "linguist-generated"
+	LinguistVendored  = "linguist-vendored"
+)
+
+// LinguistAttributes 读取 .gitattributes 中为文件指定的 linguist-generated 和 linguist-vendored 属性，路径相对于仓库根目录
+// 返回值为 文件 -> 属性名 -> 是否设置，只包含显式指定的属性：linguist-generated 或 =true 为 true，-linguist-generated 或 =false 为 false
+func (c *GitClient) LinguistAttributes(paths []string) (map[string]map[string]bool, error) {
+	values, err := c.checkAttributes(paths, LinguistGenerated, LinguistVendored)
+	if err != nil {
+		return nil, err
+	}
+	attrs := make(map[string]map[string]bool)
+	for _, v := range values {
+		var set bool
+		switch v.value {
+		case "set", "true":
+			set = true
+		case "unset", "false":
+		default:
+			continue
+		}
+		if attrs[v.path] == nil {
+			attrs[v.path] = make(map[string]bool)
+		}
+		attrs[v.path][v.attr] = set
+	}
+	return attrs, nil
+}
+
+// BinaryAttributes 返回 .gitattributes 中标记为二进制的文件，路径相对于仓库根目录
+// 设置了 binary 属性或取消了 diff 属性（-diff）的文件，git diff 只输出二进制标记；go-git 后端不读取这些属性，需要单独检查
+func (c *GitClient) BinaryAttributes(paths []string) (map[string]bool, error) {
+	values, err := c.checkAttributes(paths, "binary", "diff")
+	if err != nil {
+		return nil, err
+	}
+	binary := make(map[string]bool)
+	for _, v := range values {
+		if (v.attr == "binary" && v.value == "set") || (v.attr == "diff" && v.value == "unset") {
+			binary[v.path] = true
+		}
+	}
+	return binary, nil
+}
+
+// attrValue git check-attr 输出的一项属性
+type attrValue struct {
+	path, attr, value string
+}
+
+// checkAttributes 读取文件的 .gitattributes 属性，值为 set、unset、unspecified 或属性的取值
+func (c *GitClient) checkAttributes(paths []string, attrs ...string) ([]attrValue, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	root, err := c.RepoRoot()
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command("git", append([]string{"check-attr", "-z", "--stdin"}, attrs...)...)
+	cmd.Dir = root
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\x00") + "\x00")
+	output, err := c.output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("读取 .gitattributes 失败: %v", err)
+	}
+
+	// 输出为 "路径 NUL 属性 NUL 值 NUL" 的序列
+	var values []attrValue
+	fields := strings.Split(string(output), "\x00")
+	for i := 0; i+2 < len(fields); i += 3 {
+		values = append(values, attrValue{path: fields[i], attr: fields[i+1], value: fields[i+2]})
+	}
+	return values, nil
+}
@@ -0,0 +1,393 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/tracing"
+)
+
+// BackendEnv 选择 Git 后端的环境变量：auto（默认）、go-git 或 exec
+const BackendEnv = "CR_GIT_BACKEND"
+
+// Git 后端名称
+const (
+	// BackendAuto 优先使用 go-git，失败或遇到不支持的情况时改用 git 命令（git 在 PATH 中时）
+	BackendAuto = "auto"
+	// BackendGoGit 只使用 go-git，不依赖 git 命令
+	BackendGoGit = "go-git"
+	// BackendExec 只使用 git 命令
+	BackendExec = "exec"
+)
+
+// errUnsupported go-git 后端不支持的情况，如版本表达式 a...b、存在冲突的索引、配置了换行转换的工作区
+var errUnsupported = errors.New("go-git 后端不支持")
+
+// GitBackend 读取仓库信息的后端，路径都相对于仓库根目录，差异为 git diff 格式的统一差异
+//
+// 评审用到的读操作都通过后端完成；blame、range-diff、check-attr 和钩子安装等操作只通过 git 命令执行
+type GitBackend interface {
+	// Diff 对应 git diff：from、to 都指定时比较两个版本，只指定 from 时比较该版本和工作区，都为空时比较暂存区和工作区
+	Diff(from, to string) (string, error)
+	// StagedDiff 对应 git diff --cached
+	StagedDiff() (string, error)
+	// FileDiff 对应 git diff HEAD -- path
+	FileDiff(path string) (string, error)
+	// FileContent 读取文件在指定版本中的内容
+	FileContent(rev, path string) (string, error)
+	// FileSize 读取文件在指定版本中的字节数，rev 为 ":" 时读取暂存区中的版本
+	FileSize(rev, path string) (int64, error)
+	RepoRoot() (string, error)
+	// GitDir 返回 .git 目录的绝对路径，worktree 中为对应的 .git/worktrees/<名称> 目录
+	GitDir() (string, error)
+	// CurrentBranch 返回当前分支名，处于分离头指针状态时返回 HEAD
+	CurrentBranch() (string, error)
+	// CommitsInRange 列出 a..b 或单个版本可达的提交哈希
+	CommitsInRange(revRange string) ([]string, error)
+	// RecentCommits 列出 rev 及之前最近修改过文件的 n 个提交，不包含合并提交
+	RecentCommits(rev, path string, n int) ([]CommitInfo, error)
+	// ListFiles 列出索引中跟踪的文件
+	ListFiles() ([]string, error)
+	// UntrackedFiles 列出工作区中未跟踪且未被 .gitignore 忽略的文件
+	UntrackedFiles() ([]string, error)
+	MergeBase(a, b string) (string, error)
+	IsAncestor(ancestor, rev string) (bool, error)
+	// CommitFiles 列出提交相对第一个父提交修改的文件
+	CommitFiles(commit string) ([]string, error)
+}
+
+// tracer 记录 git 操作的 span，由客户端和它的后端共享
+type tracer struct {
+	span *tracing.Span
+}
+
+// start 开始记录一次 git 操作，span 名称为 git 子命令，如 "git diff"；未设置上级 span 时返回 nil
+func (t *tracer) start(backend, op string, args ...string) *tracing.Span {
+	if t == nil || t.span == nil {
+		return nil
+	}
+	span := t.span.Start("git " + op)
+	span.SetAttr("cr.git.backend", backend)
+	span.SetAttr("process.command_args", strings.Join(append([]string{"git", op}, args...), " "))
+	return span
+}
+
+// newBackend 按 BackendEnv 创建仓库的后端，取值无效时按 auto 处理
+func newBackend(repoPath string, trace *tracer) GitBackend {
+	execBackend := &execBackend{dir: repoPath, trace: trace}
+	switch os.Getenv(BackendEnv) {
+	case BackendExec:
+		return execBackend
+	case BackendGoGit:
+		return &goGitBackend{dir: repoPath, trace: trace}
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return &goGitBackend{dir: repoPath, trace: trace}
+	}
+	return &fallbackBackend{primary: &goGitBackend{dir: repoPath, trace: trace}, fallback: execBackend}
+}
+
+// fallbackBackend 优先使用 primary，出错时改用 fallback 重试；两者都失败时返回 fallback 的错误
+type fallbackBackend struct {
+	primary  GitBackend
+	fallback GitBackend
+}
+
+func (b *fallbackBackend) Diff(from, to string) (string, error) {
+	if out, err := b.primary.Diff(from, to); err == nil {
+		return out, nil
+	}
+	return b.fallback.Diff(from, to)
+}
+
+func (b *fallbackBackend) StagedDiff() (string, error) {
+	if out, err := b.primary.StagedDiff(); err == nil {
+		return out, nil
+	}
+	return b.fallback.StagedDiff()
+}
+
+func (b *fallbackBackend) FileDiff(path string) (string, error) {
+	if out, err := b.primary.FileDiff(path); err == nil {
+		return out, nil
+	}
+	return b.fallback.FileDiff(path)
+}
+
+func (b *fallbackBackend) FileContent(rev, path string) (string, error) {
+	if out, err := b.primary.FileContent(rev, path); err == nil {
+		return out, nil
+	}
+	return b.fallback.FileContent(rev, path)
+}
+
+func (b *fallbackBackend) FileSize(rev, path string) (int64, error) {
+	if size, err := b.primary.FileSize(rev, path); err == nil {
+		return size, nil
+	}
+	return b.fallback.FileSize(rev, path)
+}
+
+func (b *fallbackBackend) RepoRoot() (string, error) {
+	if root, err := b.primary.RepoRoot(); err == nil {
+		return root, nil
+	}
+	return b.fallback.RepoRoot()
+}
+
+func (b *fallbackBackend) GitDir() (string, error) {
+	if dir, err := b.primary.GitDir(); err == nil {
+		return dir, nil
+	}
+	return b.fallback.GitDir()
+}
+
+func (b *fallbackBackend) CurrentBranch() (string, error) {
+	if branch, err := b.primary.CurrentBranch(); err == nil {
+		return branch, nil
+	}
+	return b.fallback.CurrentBranch()
+}
+
+func (b *fallbackBackend) CommitsInRange(revRange string) ([]string, error) {
+	if commits, err := b.primary.CommitsInRange(revRange); err == nil {
+		return commits, nil
+	}
+	return b.fallback.CommitsInRange(revRange)
+}
+
+func (b *fallbackBackend) RecentCommits(rev, path string, n int) ([]CommitInfo, error) {
+	if commits, err := b.primary.RecentCommits(rev, path, n); err == nil {
+		return commits, nil
+	}
+	return b.fallback.RecentCommits(rev, path, n)
+}
+
+func (b *fallbackBackend) ListFiles() ([]string, error) {
+	if files, err := b.primary.ListFiles(); err == nil {
+		return files, nil
+	}
+	return b.fallback.ListFiles()
+}
+
+func (b *fallbackBackend) UntrackedFiles() ([]string, error) {
+	if files, err := b.primary.UntrackedFiles(); err == nil {
+		return files, nil
+	}
+	return b.fallback.UntrackedFiles()
+}
+
+func (b *fallbackBackend) MergeBase(a, c string) (string, error) {
+	if base, err := b.primary.MergeBase(a, c); err == nil {
+		return base, nil
+	}
+	return b.fallback.MergeBase(a, c)
+}
+
+func (b *fallbackBackend) IsAncestor(ancestor, rev string) (bool, error) {
+	if ok, err := b.primary.IsAncestor(ancestor, rev); err == nil {
+		return ok, nil
+	}
+	return b.fallback.IsAncestor(ancestor, rev)
+}
+
+func (b *fallbackBackend) CommitFiles(commit string) ([]string, error) {
+	if files, err := b.primary.CommitFiles(commit); err == nil {
+		return files, nil
+	}
+	return b.fallback.CommitFiles(commit)
+}
+
+// execBackend 执行 git 命令的后端
+type execBackend struct {
+	dir   string
+	trace *tracer
+}
+
+// git 在仓库目录中执行 git 命令并返回标准输出，失败时错误中包含标准错误输出
+func (b *execBackend) git(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = b.dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	span := b.trace.start(BackendExec, args[0], args[1:]...)
+	output, err := cmd.Output()
+	span.End(err)
+	if err != nil && stderr.Len() > 0 {
+		return nil, fmt.Errorf("%v\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+	return output, err
+}
+
+func (b *execBackend) Diff(from, to string) (string, error) {
+	args := []string{"diff", "--unified=3", "-M", "-C"}
+	if from != "" && to != "" {
+		args = append(args, fmt.Sprintf("%s..%s", from, to))
+	} else if from != "" {
+		args = append(args, from)
+	}
+	output, err := b.git(args...)
+	if err != nil {
+		return "", fmt.Errorf("git diff failed: %v", err)
+	}
+	return string(output), nil
+}
+
+func (b *execBackend) StagedDiff() (string, error) {
+	output, err := b.git("diff", "--cached", "-M", "-C")
+	return string(output), err
+}
+
+func (b *execBackend) FileDiff(path string) (string, error) {
+	output, err := b.git("diff", "HEAD", "--", path)
+	return string(output), err
+}
+
+func (b *execBackend) FileContent(rev, path string) (string, error) {
+	output, err := b.git("show", fmt.Sprintf("%s:%s", rev, path))
+	if err != nil {
+		return "", fmt.Errorf("获取文件内容失败: %v", err)
+	}
+	return string(output), nil
+}
+
+func (b *execBackend) FileSize(rev, path string) (int64, error) {
+	if rev == "" {
+		root, err := b.RepoRoot()
+		if err != nil {
+			return 0, err
+		}
+		info, err := os.Stat(filepath.Join(root, path))
+		if err != nil {
+			return 0, fmt.Errorf("获取文件大小失败: %v", err)
+		}
+		return info.Size(), nil
+	}
+	object := rev + ":" + path
+	if rev == ":" {
+		object = ":" + path
+	}
+	output, err := b.git("cat-file", "-s", object)
+	if err != nil {
+		return 0, fmt.Errorf("获取文件大小失败: %v", err)
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+}
+
+func (b *execBackend) RepoRoot() (string, error) {
+	output, err := b.git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("获取仓库根目录失败: %v", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (b *execBackend) GitDir() (string, error) {
+	output, err := b.git("rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", fmt.Errorf("获取 .git 目录失败: %v", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (b *execBackend) CurrentBranch() (string, error) {
+	output, err := b.git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("获取当前分支失败: %v", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (b *execBackend) CommitsInRange(revRange string) ([]string, error) {
+	output, err := b.git("log", "--format=%H", revRange)
+	if err != nil {
+		return nil, fmt.Errorf("获取提交列表失败: %v", err)
+	}
+	return strings.Fields(string(output)), nil
+}
+
+func (b *execBackend) RecentCommits(rev, path string, n int) ([]CommitInfo, error) {
+	output, err := b.git("log", "-n", strconv.Itoa(n), "--no-merges", "--date=short",
+		"--format=%h%x1f%ad%x1f%an%x1f%s", rev, "--", path)
+	if err != nil {
+		return nil, fmt.Errorf("获取文件的提交历史失败: %v", err)
+	}
+	var commits []CommitInfo
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.SplitN(line, "\x1f", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		commits = append(commits, CommitInfo{Hash: fields[0], Date: fields[1], Author: fields[2], Subject: fields[3]})
+	}
+	return commits, nil
+}
+
+func (b *execBackend) ListFiles() ([]string, error) {
+	output, err := b.git("ls-files", "-z", "--full-name")
+	if err != nil {
+		return nil, fmt.Errorf("获取仓库文件列表失败: %v", err)
+	}
+	return splitNUL(output), nil
+}
+
+func (b *execBackend) UntrackedFiles() ([]string, error) {
+	// :/ 表示从仓库根目录列出，不受当前目录影响
+	output, err := b.git("ls-files", "-z", "--others", "--exclude-standard", "--full-name", "--", ":/")
+	if err != nil {
+		return nil, fmt.Errorf("获取未跟踪的文件失败: %v", err)
+	}
+	var files []string
+	for _, file := range splitNUL(output) {
+		// 未跟踪的嵌套仓库以 / 结尾
+		if !strings.HasSuffix(file, "/") {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+func (b *execBackend) MergeBase(a, c string) (string, error) {
+	output, err := b.git("merge-base", a, c)
+	if err != nil {
+		return "", fmt.Errorf("git merge-base 失败: %v", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (b *execBackend) IsAncestor(ancestor, rev string) (bool, error) {
+	_, err := b.git("merge-base", "--is-ancestor", ancestor, rev)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("git merge-base 失败: %v", err)
+	}
+	return true, nil
+}
+
+func (b *execBackend) CommitFiles(commit string) ([]string, error) {
+	output, err := b.git("diff-tree", "--no-commit-id", "--name-only", "-r", "--root", "-z", commit)
+	if err != nil {
+		return nil, fmt.Errorf("获取提交 %s 修改的文件失败: %v", commit, err)
+	}
+	return splitNUL(output), nil
+}
+
+// splitNUL 拆分以 NUL 分隔的输出，忽略空项
+func splitNUL(output []byte) []string {
+	var items []string
+	for _, item := range strings.Split(string(output), "\x00") {
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
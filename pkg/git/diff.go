@@ -0,0 +1,294 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// devNull 新增或删除的文件在 ---、+++ 行中的路径
+const devNull = "/dev/null"
+
+// fileDiff 解析中的一个文件的差异
+type fileDiff struct {
+	change types.FileChange
+	// 差异在输入中的起止行，DiffContent 为这些行的原文
+	start, end int
+	// 是否已读到 --- 和 +++ 行
+	sawPaths bool
+	// 扩展头部中的路径和改动类型
+	oldPath, newPath string
+	added, deleted   bool
+	renamed, copied  bool
+	similarity       int
+}
+
+// ParseDiff 将统一差异按文件拆分为文件改动，可用于评审不在本地仓库中的补丁
+// 解析每个文件的路径、改动类型和带行号的改动块，支持 git diff 的扩展头部（新增、删除、重命名、复制及其相似度、二进制文件）
+// 和不带 diff --git 行的普通统一差异（如 diff -u 的输出）；补丁开头的邮件头等内容会被忽略
+func ParseDiff(diffOutput string) []types.FileChange {
+	if diffOutput == "" {
+		return []types.FileChange{}
+	}
+
+	lines := strings.SplitAfter(diffOutput, "\n")
+	var files []*fileDiff
+	var current *fileDiff
+	var hunk *types.DiffHunk
+	// 当前改动块中尚未读到的旧文件和新文件行数
+	oldLeft, newLeft := 0, 0
+	oldLine, newLine := 0, 0
+
+	startFile := func(i int) {
+		if current != nil {
+			current.end = i
+		}
+		current = &fileDiff{start: i}
+		files = append(files, current)
+		hunk = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+
+		// 改动块内按头部行中的行数读取，避免把内容中的 "diff --git"、"--- " 当作新文件
+		if oldLeft > 0 || newLeft > 0 {
+			kind := byte(' ')
+			if line != "" {
+				kind = line[0]
+			}
+			content := ""
+			if len(line) > 1 {
+				content = line[1:]
+			}
+			switch {
+			case kind == ' ' && oldLeft > 0 && newLeft > 0:
+				hunk.Lines = append(hunk.Lines, types.DiffLine{Kind: ' ', Content: content, OldLine: oldLine, NewLine: newLine})
+				oldLine, newLine = oldLine+1, newLine+1
+				oldLeft, newLeft = oldLeft-1, newLeft-1
+				continue
+			case kind == '-' && oldLeft > 0:
+				hunk.Lines = append(hunk.Lines, types.DiffLine{Kind: '-', Content: content, OldLine: oldLine})
+				oldLine, oldLeft = oldLine+1, oldLeft-1
+				continue
+			case kind == '+' && newLeft > 0:
+				hunk.Lines = append(hunk.Lines, types.DiffLine{Kind: '+', Content: content, NewLine: newLine})
+				newLine, newLeft = newLine+1, newLeft-1
+				continue
+			case kind == '\\':
+				markNoNewline(hunk)
+				continue
+			}
+			// 行数与头部行不符，按头部行继续解析
+			oldLeft, newLeft = 0, 0
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			startFile(i)
+			current.oldPath, current.newPath = parseGitHeader(line[len("diff --git "):])
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			if current == nil || current.sawPaths {
+				startFile(i)
+			}
+			current.sawPaths = true
+			current.oldPath = parsePathLine(line[len("--- "):], "a/")
+			current.newPath = parsePathLine(strings.TrimRight(lines[i+1], "\r\n")[len("+++ "):], "b/")
+			i++
+		case current == nil:
+			// 第一个文件之前的内容，如邮件头和提交说明
+		case strings.HasPrefix(line, "@@ "):
+			h, ok := parseHunkHeader(line)
+			if !ok {
+				continue
+			}
+			current.change.Hunks = append(current.change.Hunks, h)
+			hunk = &current.change.Hunks[len(current.change.Hunks)-1]
+			oldLeft, newLeft = h.OldLines, h.NewLines
+			oldLine, newLine = h.OldStart, h.NewStart
+			// 新增文件的头部行为 -0,0，删除文件为 +0,0，行号从 1 开始
+			if oldLine == 0 {
+				oldLine = 1
+			}
+			if newLine == 0 {
+				newLine = 1
+			}
+		case strings.HasPrefix(line, `\`):
+			markNoNewline(hunk)
+		case strings.HasPrefix(line, "new file mode "):
+			current.added = true
+		case strings.HasPrefix(line, "deleted file mode "):
+			current.deleted = true
+		case strings.HasPrefix(line, "similarity index "):
+			current.similarity, _ = strconv.Atoi(strings.TrimSuffix(line[len("similarity index "):], "%"))
+		case strings.HasPrefix(line, "rename from "):
+			current.renamed = true
+			current.oldPath = unquotePath(line[len("rename from "):])
+		case strings.HasPrefix(line, "rename to "):
+			current.newPath = unquotePath(line[len("rename to "):])
+		case strings.HasPrefix(line, "copy from "):
+			current.copied = true
+			current.oldPath = unquotePath(line[len("copy from "):])
+		case strings.HasPrefix(line, "copy to "):
+			current.newPath = unquotePath(line[len("copy to "):])
+		case strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch":
+			current.change.Binary = true
+		}
+	}
+	if current != nil {
+		current.end = len(lines)
+	}
+
+	changes := make([]types.FileChange, 0, len(files))
+	for _, f := range files {
+		change := f.change
+		change.DiffContent = strings.Join(lines[f.start:f.end], "")
+		switch {
+		case f.added || f.oldPath == devNull:
+			change.ChangeType = "added"
+			change.FilePath = f.newPath
+		case f.deleted || f.newPath == devNull:
+			change.ChangeType = "deleted"
+			change.FilePath = f.oldPath
+		case f.copied:
+			change.ChangeType = "copied"
+			change.FilePath, change.OldPath = f.newPath, f.oldPath
+			change.Similarity = f.similarity
+		case f.renamed:
+			change.ChangeType = "renamed"
+			change.FilePath, change.OldPath = f.newPath, f.oldPath
+			change.Similarity = f.similarity
+		default:
+			// 普通统一差异的新旧路径常常不同（如 x.orig 和 x），不视为重命名
+			change.ChangeType = "modified"
+			change.FilePath = f.newPath
+		}
+		if change.FilePath == "" || change.FilePath == devNull {
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// markNoNewline 标记改动块的最后一行之后没有换行符
+func markNoNewline(hunk *types.DiffHunk) {
+	if hunk != nil && len(hunk.Lines) > 0 {
+		hunk.Lines[len(hunk.Lines)-1].NoNewline = true
+	}
+}
+
+// parseHunkHeader 解析 @@ -a,b +c,d @@ section 形式的头部行，省略行数时为 1
+func parseHunkHeader(line string) (types.DiffHunk, bool) {
+	var h types.DiffHunk
+	rest, ok := strings.CutPrefix(line, "@@ -")
+	if !ok {
+		return h, false
+	}
+	ranges, section, ok := strings.Cut(rest, " @@")
+	if !ok {
+		return h, false
+	}
+	oldRange, newRange, ok := strings.Cut(ranges, " +")
+	if !ok {
+		return h, false
+	}
+	if h.OldStart, h.OldLines, ok = parseRange(oldRange); !ok {
+		return h, false
+	}
+	if h.NewStart, h.NewLines, ok = parseRange(newRange); !ok {
+		return h, false
+	}
+	h.Section = strings.TrimSpace(section)
+	return h, true
+}
+
+// parseRange 解析 start,count 形式的行范围
+func parseRange(spec string) (int, int, bool) {
+	startText, countText, hasCount := strings.Cut(spec, ",")
+	start, err := strconv.Atoi(startText)
+	if err != nil || start < 0 {
+		return 0, 0, false
+	}
+	if !hasCount {
+		return start, 1, true
+	}
+	count, err := strconv.Atoi(countText)
+	if err != nil || count < 0 {
+		return 0, 0, false
+	}
+	return start, count, true
+}
+
+// parsePathLine 解析 ---、+++ 行中的路径，去掉 prefix（a/ 或 b/）和普通统一差异在路径后附带的时间戳
+func parsePathLine(text, prefix string) string {
+	if strings.HasPrefix(text, `"`) {
+		if path, _, ok := cutQuoted(text); ok {
+			return strings.TrimPrefix(path, prefix)
+		}
+	}
+	// 包含空格的路径后 git 会加一个制表符，diff -u 则在制表符后附带时间戳
+	if i := strings.IndexByte(text, '\t'); i >= 0 {
+		text = text[:i]
+	}
+	text = strings.TrimRight(text, " ")
+	if text == devNull {
+		return devNull
+	}
+	return strings.TrimPrefix(text, prefix)
+}
+
+// parseGitHeader 解析 diff --git 行中的新旧路径，没有 ---、+++ 行的差异（如二进制文件、只修改权限）只能从这里得到路径
+func parseGitHeader(text string) (string, string) {
+	if strings.HasPrefix(text, `"`) {
+		oldPath, rest, ok := cutQuoted(text)
+		if ok {
+			return strings.TrimPrefix(oldPath, "a/"), parsePathLine(strings.TrimPrefix(rest, " "), "b/")
+		}
+	}
+	if strings.HasSuffix(text, `"`) {
+		if i := strings.LastIndex(text, ` "`); i >= 0 {
+			return strings.TrimPrefix(text[:i], "a/"), parsePathLine(text[i+1:], "b/")
+		}
+	}
+	// 路径中可能有空格：新旧路径相同时两半长度相等，否则按最后一个 " b/" 拆分
+	if len(text)%2 == 1 {
+		half := len(text) / 2
+		oldPath, newPath := strings.TrimPrefix(text[:half], "a/"), strings.TrimPrefix(text[half+1:], "b/")
+		if text[half] == ' ' && oldPath == newPath {
+			return oldPath, newPath
+		}
+	}
+	if i := strings.LastIndex(text, " b/"); i >= 0 {
+		return strings.TrimPrefix(text[:i], "a/"), text[i+3:]
+	}
+	return "", ""
+}
+
+// cutQuoted 解析开头的 C 风格引号路径（git 对包含特殊字符的路径加引号并转义），返回路径和剩余部分
+func cutQuoted(text string) (string, string, bool) {
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '"':
+			path, err := strconv.Unquote(text[:i+1])
+			if err != nil {
+				return "", "", false
+			}
+			return path, text[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// unquotePath 解析扩展头部中可能带引号的路径
+func unquotePath(text string) string {
+	if strings.HasPrefix(text, `"`) {
+		if path, _, ok := cutQuoted(text); ok {
+			return path
+		}
+	}
+	return text
+}
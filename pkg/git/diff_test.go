@@ -0,0 +1,127 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// parsedChange 测试中比较的文件改动字段，DiffContent 单独检查
+type parsedChange struct {
+	FilePath   string
+	ChangeType string
+	OldPath    string
+	Similarity int
+	Binary     bool
+	Hunks      []types.DiffHunk
+}
+
+func TestParseDiffFixtures(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []parsedChange
+	}{
+		{
+			fixture: "rename.diff",
+			want: []parsedChange{{
+				FilePath: "new.go", ChangeType: "renamed", OldPath: "old.go", Similarity: 80,
+				Hunks: []types.DiffHunk{{
+					OldStart: 1, OldLines: 7, NewStart: 1, NewLines: 7,
+					Lines: []types.DiffLine{
+						{Kind: ' ', Content: "package a", OldLine: 1, NewLine: 1},
+						{Kind: ' ', Content: "", OldLine: 2, NewLine: 2},
+						{Kind: ' ', Content: "func A() int {", OldLine: 3, NewLine: 3},
+						{Kind: '-', Content: "\treturn 1", OldLine: 4},
+						{Kind: '+', Content: "\treturn 2", NewLine: 4},
+						{Kind: ' ', Content: "}", OldLine: 5, NewLine: 5},
+						{Kind: ' ', Content: "", OldLine: 6, NewLine: 6},
+						{Kind: ' ', Content: "func B() {}", OldLine: 7, NewLine: 7},
+					},
+				}},
+			}},
+		},
+		{
+			// 内容没有变化的重命名没有改动块
+			fixture: "rename_pure.diff",
+			want:    []parsedChange{{FilePath: "tools.sh", ChangeType: "renamed", OldPath: "run.sh", Similarity: 100}},
+		},
+		{
+			// 只修改文件权限时没有改动块，按修改处理
+			fixture: "mode_change.diff",
+			want:    []parsedChange{{FilePath: "run.sh", ChangeType: "modified"}},
+		},
+		{
+			fixture: "binary.diff",
+			want:    []parsedChange{{FilePath: "logo.png", ChangeType: "modified", Binary: true}},
+		},
+		{
+			// git diff --binary 输出的二进制补丁
+			fixture: "binary_patch.diff",
+			want:    []parsedChange{{FilePath: "data.bin", ChangeType: "added", Binary: true}},
+		},
+		{
+			fixture: "no_newline.diff",
+			want: []parsedChange{
+				{
+					// 旧文件和新文件末尾都没有换行符
+					FilePath: "eof.txt", ChangeType: "modified",
+					Hunks: []types.DiffHunk{{
+						OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 3,
+						Lines: []types.DiffLine{
+							{Kind: ' ', Content: "first", OldLine: 1, NewLine: 1},
+							{Kind: '-', Content: "second", OldLine: 2, NoNewline: true},
+							{Kind: '+', Content: "second", NewLine: 2},
+							{Kind: '+', Content: "third", NewLine: 3, NoNewline: true},
+						},
+					}},
+				},
+				{
+					// 补上了文件末尾的换行符
+					FilePath: "fixed.txt", ChangeType: "modified",
+					Hunks: []types.DiffHunk{{
+						OldStart: 1, OldLines: 2, NewStart: 1, NewLines: 2,
+						Lines: []types.DiffLine{
+							{Kind: ' ', Content: "a", OldLine: 1, NewLine: 1},
+							{Kind: '-', Content: "b", OldLine: 2, NoNewline: true},
+							{Kind: '+', Content: "b", NewLine: 2},
+						},
+					}},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			changes := ParseDiff(string(data))
+
+			got := make([]parsedChange, 0, len(changes))
+			var contents []string
+			for _, c := range changes {
+				got = append(got, parsedChange{
+					FilePath:   c.FilePath,
+					ChangeType: c.ChangeType,
+					OldPath:    c.OldPath,
+					Similarity: c.Similarity,
+					Binary:     c.Binary,
+					Hunks:      c.Hunks,
+				})
+				contents = append(contents, c.DiffContent)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("解析结果不符\n得到: %+v\n期望: %+v", got, tt.want)
+			}
+			// 各文件的差异原文拼接后应与输入一致
+			if joined := strings.Join(contents, ""); joined != string(data) {
+				t.Fatalf("差异原文不符\n得到: %q\n期望: %q", joined, data)
+			}
+		})
+	}
+}
@@ -1,182 +1,241 @@
 package git
 
 import (
-	"bytes"
 	"fmt"
 	"os/exec"
+	"sort"
 	"strings"
 
+	"github.com/icatw/ai-cr-tool/pkg/tracing"
 	"github.com/icatw/ai-cr-tool/pkg/types"
 )
 
-// GitClient 提供Git操作的封装
+// GitClient 提供Git操作的封装，读操作通过 GitBackend 完成，后端由环境变量 CR_GIT_BACKEND 选择
 type GitClient struct {
 	repoPath string
+	backend  GitBackend
+	// 记录 git 操作的耗时，与后端共享
+	trace *tracer
 }
 
 // NewGitClient 创建新的Git客户端
 func NewGitClient(repoPath string) *GitClient {
-	return &GitClient{repoPath: repoPath}
+	trace := &tracer{}
+	return &GitClient{repoPath: repoPath, backend: newBackend(repoPath, trace), trace: trace}
 }
 
-// GetDiff 获取指定范围的代码差异
-func (g *GitClient) GetDiff(from, to string) (string, error) {
-	args := []string{"diff", "--unified=3"}
-
-	// 如果提供了范围，则使用范围比较
-	if from != "" && to != "" {
-		args = append(args, fmt.Sprintf("%s..%s", from, to))
-	} else if from != "" {
-		// 与指定提交比较
-		args = append(args, from)
-	}
+// NewGitClientWithBackend 创建使用指定后端的Git客户端
+func NewGitClientWithBackend(repoPath string, backend GitBackend) *GitClient {
+	return &GitClient{repoPath: repoPath, backend: backend, trace: &tracer{}}
+}
 
-	cmd := exec.Command("git", args...)
-	cmd.Dir = g.repoPath
+// SetSpan 设置链路中的上级 span，之后的每次 git 操作记录为其子 span
+func (c *GitClient) SetSpan(span *tracing.Span) {
+	c.trace.span = span
+}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+// output 执行只能通过 git 命令完成的操作并返回标准输出，记录命令的耗时
+func (c *GitClient) output(cmd *exec.Cmd) ([]byte, error) {
+	span := c.startCommand(cmd)
+	output, err := cmd.Output()
+	span.End(err)
+	return output, err
+}
+
+// run 执行只能通过 git 命令完成的操作，记录命令的耗时
+func (c *GitClient) run(cmd *exec.Cmd) error {
+	span := c.startCommand(cmd)
+	err := cmd.Run()
+	span.End(err)
+	return err
+}
 
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("git diff failed: %v\n%s", err, stderr.String())
+// startCommand 开始记录一条 git 命令，span 名称为 git 子命令，如 "git diff"
+func (c *GitClient) startCommand(cmd *exec.Cmd) *tracing.Span {
+	if len(cmd.Args) < 2 {
+		return nil
 	}
+	return c.trace.start(BackendExec, cmd.Args[1], cmd.Args[2:]...)
+}
 
-	return stdout.String(), nil
+// GetDiff 获取指定范围的代码差异
+func (g *GitClient) GetDiff(from, to string) (string, error) {
+	return g.backend.Diff(from, to)
 }
 
 // GetChangedFiles 获取改动的文件列表
 func (g *GitClient) GetChangedFiles(from, to string) ([]string, error) {
-	args := []string{"diff", "--name-only"}
-
-	if from != "" && to != "" {
-		args = append(args, fmt.Sprintf("%s..%s", from, to))
-	} else if from != "" {
-		args = append(args, from)
-	}
-
-	cmd := exec.Command("git", args...)
-	cmd.Dir = g.repoPath
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("git diff --name-only failed: %v\n%s", err, stderr.String())
+	diff, err := g.backend.Diff(from, to)
+	if err != nil {
+		return nil, err
 	}
-
-	files := strings.Split(strings.TrimSpace(stdout.String()), "\n")
-	if len(files) == 1 && files[0] == "" {
-		return []string{}, nil
+	files := []string{}
+	for _, change := range ParseDiff(diff) {
+		files = append(files, change.FilePath)
 	}
-
 	return files, nil
 }
 
 // GetFileContent 获取指定提交中的文件内容
 func (g *GitClient) GetFileContent(filePath string, commitHash string) (string, error) {
-	args := []string{"show", fmt.Sprintf("%s:%s", commitHash, filePath)}
-
-	cmd := exec.Command("git", args...)
-	cmd.Dir = g.repoPath
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("获取文件内容失败: %v\n%s", err, stderr.String())
-	}
-
-	return stdout.String(), nil
+	return g.backend.FileContent(commitHash, filePath)
 }
 
 // GetFileDiff 获取指定文件的改动内容
 func (c *GitClient) GetFileDiff(file string) (string, error) {
-	cmd := exec.Command("git", "diff", "HEAD", "--", file)
-	cmd.Dir = c.repoPath
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-	return string(output), nil
+	return c.backend.FileDiff(file)
 }
 
 // GetStagedChanges 获取已暂存的改动
 func (c *GitClient) GetStagedChanges() ([]types.FileChange, error) {
-	cmd := exec.Command("git", "diff", "--cached")
-	cmd.Dir = c.repoPath
-	output, err := cmd.Output()
+	diff, err := c.backend.StagedDiff()
 	if err != nil {
 		return nil, err
 	}
-	return c.parseDiff(string(output))
+	return c.parseDiff(diff)
 }
 
 // GetCommitChanges 获取指定提交的改动
 func (c *GitClient) GetCommitChanges(commitHash string) ([]types.FileChange, error) {
-	cmd := exec.Command("git", "diff", commitHash+"^", commitHash)
-	cmd.Dir = c.repoPath
-	output, err := cmd.Output()
+	diff, err := c.backend.Diff(commitHash+"^", commitHash)
 	if err != nil {
 		return nil, err
 	}
-	return c.parseDiff(string(output))
+	return c.parseDiff(diff)
 }
 
 // GetWorkingDirChanges 获取工作区的改动
 func (c *GitClient) GetWorkingDirChanges() ([]types.FileChange, error) {
-	cmd := exec.Command("git", "diff")
-	cmd.Dir = c.repoPath
-	output, err := cmd.Output()
+	diff, err := c.backend.Diff("", "")
 	if err != nil {
 		return nil, err
 	}
-	return c.parseDiff(string(output))
+	return c.parseDiff(diff)
 }
 
 // parseDiff 解析git diff输出
 func (c *GitClient) parseDiff(diffOutput string) ([]types.FileChange, error) {
-	if diffOutput == "" {
-		return []types.FileChange{}, nil
-	}
+	return ParseDiff(diffOutput), nil
+}
+
+// RepoRoot 获取仓库根目录
+func (c *GitClient) RepoRoot() (string, error) {
+	return c.backend.RepoRoot()
+}
+
+// GitDir 获取仓库的 .git 目录，兼容 worktree
+func (c *GitClient) GitDir() (string, error) {
+	return c.backend.GitDir()
+}
+
+// CurrentBranch 获取当前分支名，处于分离头指针状态时返回 HEAD
+func (c *GitClient) CurrentBranch() (string, error) {
+	return c.backend.CurrentBranch()
+}
+
+// FileSize 获取文件在指定版本中的字节数
+// rev 为空时读取工作区中的文件，为 ":" 时读取暂存区中的版本
+func (c *GitClient) FileSize(rev, filePath string) (int64, error) {
+	return c.backend.FileSize(rev, filePath)
+}
 
-	var changes []types.FileChange
-	// 按文件分割diff输出
-	diffFiles := strings.Split(diffOutput, "diff --git")
+// AuthorsByFile 统计提交范围内修改过每个文件的作者，格式为 "姓名 <邮箱>"
+// 每个文件的作者按提交次数降序排列，次数相同时按最近提交在前
+func (c *GitClient) AuthorsByFile(revRange string) (map[string][]string, error) {
+	cmd := exec.Command("git", "log", "--no-merges", "--format=%x00%an <%ae>", "--name-only", revRange)
+	cmd.Dir = c.repoPath
+	output, err := c.output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("获取提交作者失败: %v", err)
+	}
 
-	for _, diffFile := range diffFiles[1:] { // 跳过第一个空字符串
-		lines := strings.Split(diffFile, "\n")
-		if len(lines) < 2 {
+	counts := make(map[string]map[string]int)
+	order := make(map[string][]string)
+	var author string
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "\x00") {
+			author = strings.TrimPrefix(line, "\x00")
 			continue
 		}
-
-		// 解析文件路径
-		// diff --git a/file.go b/file.go
-		pathLine := lines[0]
-		parts := strings.Fields(pathLine)
-		if len(parts) < 4 {
+		file := strings.TrimSpace(line)
+		if file == "" || author == "" {
 			continue
 		}
-		filePath := strings.TrimPrefix(parts[3], "b/")
-
-		// 确定改动类型
-		changeType := "modified"
-		if strings.Contains(diffFile, "new file mode") {
-			changeType = "added"
-		} else if strings.Contains(diffFile, "deleted file mode") {
-			changeType = "deleted"
+		if counts[file] == nil {
+			counts[file] = make(map[string]int)
 		}
+		if counts[file][author] == 0 {
+			order[file] = append(order[file], author)
+		}
+		counts[file][author]++
+	}
 
-		change := types.FileChange{
-			FilePath:    filePath,
-			ChangeType:  changeType,
-			DiffContent: "diff --git" + diffFile,
+	result := make(map[string][]string, len(order))
+	for file, authors := range order {
+		sort.SliceStable(authors, func(i, j int) bool {
+			return counts[file][authors[i]] > counts[file][authors[j]]
+		})
+		result[file] = authors
+	}
+	return result, nil
+}
+
+// CommitsInRange 获取提交范围内的提交哈希
+func (c *GitClient) CommitsInRange(revRange string) (map[string]bool, error) {
+	hashes, err := c.backend.CommitsInRange(revRange)
+	if err != nil {
+		return nil, err
+	}
+	commits := make(map[string]bool, len(hashes))
+	for _, hash := range hashes {
+		commits[hash] = true
+	}
+	return commits, nil
+}
+
+// BlameLine 获取文件在指定版本中某一行的最后修改提交和作者
+func (c *GitClient) BlameLine(rev, filePath string, line int) (string, string, error) {
+	cmd := exec.Command("git", "blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", line, line), rev, "--", filePath)
+	cmd.Dir = c.repoPath
+	output, err := c.output(cmd)
+	if err != nil {
+		return "", "", fmt.Errorf("获取行作者失败: %v", err)
+	}
+
+	var commit, name, mail string
+	for i, l := range strings.Split(string(output), "\n") {
+		switch {
+		case i == 0:
+			commit = strings.Fields(l + " ")[0]
+		case strings.HasPrefix(l, "author "):
+			name = strings.TrimPrefix(l, "author ")
+		case strings.HasPrefix(l, "author-mail "):
+			mail = strings.TrimPrefix(l, "author-mail ")
 		}
+	}
+	if commit == "" || name == "" {
+		return "", "", fmt.Errorf("获取行作者失败: 无法解析 git blame 输出")
+	}
+	return commit, name + " " + mail, nil
+}
+
+// CommitInfo 提交的简要信息
+type CommitInfo struct {
+	Hash    string
+	Date    string
+	Author  string
+	Subject string
+}
 
-		changes = append(changes, change)
+// RecentCommits 获取 rev 及之前最近修改过文件的 n 个提交，不包含合并提交，rev 为空时使用 HEAD
+func (c *GitClient) RecentCommits(rev, filePath string, n int) ([]CommitInfo, error) {
+	if rev == "" {
+		rev = "HEAD"
 	}
+	return c.backend.RecentCommits(rev, filePath, n)
+}
 
-	return changes, nil
+// ListFiles 列出索引中跟踪的所有文件，路径相对于仓库根目录
+func (c *GitClient) ListFiles() ([]string, error) {
+	return c.backend.ListFiles()
 }
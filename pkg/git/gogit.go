@@ -0,0 +1,957 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/binary"
+	udiff "github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// goGitBackend 使用 go-git 直接读取仓库的后端，不依赖 git 命令；首次使用时打开仓库
+type goGitBackend struct {
+	dir   string
+	trace *tracer
+
+	once    sync.Once
+	repo    *gogit.Repository
+	root    string
+	openErr error
+}
+
+// open 打开 dir 所在的仓库，向上查找 .git，支持 worktree
+func (b *goGitBackend) open() (*gogit.Repository, error) {
+	b.once.Do(func() {
+		b.repo, b.openErr = gogit.PlainOpenWithOptions(b.dir, &gogit.PlainOpenOptions{DetectDotGit: true, EnableDotGitCommonDir: true})
+		if b.openErr != nil {
+			b.openErr = fmt.Errorf("打开仓库失败: %v", b.openErr)
+			return
+		}
+		wt, err := b.repo.Worktree()
+		if err != nil {
+			// 裸仓库没有工作区
+			b.openErr = fmt.Errorf("打开仓库失败: %v", err)
+			return
+		}
+		b.root = wt.Filesystem.Root()
+	})
+	return b.repo, b.openErr
+}
+
+// traced 记录一次 go-git 操作的 span
+func (b *goGitBackend) traced(op string, args []string, fn func() error) error {
+	span := b.trace.start(BackendGoGit, op, args...)
+	err := fn()
+	span.End(err)
+	return err
+}
+
+// commit 解析版本表达式对应的提交，go-git 无法解析的表达式（如 @{u}、:/消息）返回 errUnsupported
+func (b *goGitBackend) commit(rev string) (*object.Commit, error) {
+	repo, err := b.open()
+	if err != nil {
+		return nil, err
+	}
+	if rev == "" || strings.Contains(rev, "..") || strings.Contains(rev, ":") {
+		return nil, errUnsupported
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("%w: 无法解析版本 %s: %v", errUnsupported, rev, err)
+	}
+	return repo.CommitObject(*hash)
+}
+
+// resolveRange 解析 a..b 和 a...b 形式的版本范围，省略的一端为 HEAD，返回差异的起止版本
+// 与 git diff 相同，a...b 从两者的共同祖先开始；rev 不是范围时原样返回
+func (b *goGitBackend) resolveRange(rev string) (from, to string, err error) {
+	if a, c, ok := strings.Cut(rev, "..."); ok {
+		a, c = orHead(a), orHead(c)
+		base, err := b.mergeBase(a, c)
+		if err != nil {
+			return "", "", err
+		}
+		return base.Hash.String(), c, nil
+	}
+	if a, c, ok := strings.Cut(rev, ".."); ok {
+		return orHead(a), orHead(c), nil
+	}
+	return rev, "", nil
+}
+
+// orHead 返回版本，为空时返回 HEAD
+func orHead(rev string) string {
+	if rev == "" {
+		return "HEAD"
+	}
+	return rev
+}
+
+func (b *goGitBackend) Diff(from, to string) (string, error) {
+	var out string
+	err := b.traced("diff", nonEmpty(from, to), func() error {
+		// 只指定 from 时可以是 a..b 或 a...b 形式的范围，与 git diff a..b 相同比较两个版本
+		if to == "" && strings.Contains(from, "..") {
+			var err error
+			if from, to, err = b.resolveRange(from); err != nil {
+				return err
+			}
+		}
+		var src, dst snapshot
+		var err error
+		switch {
+		case from != "" && to != "":
+			if src, err = b.treeSnapshot(from); err == nil {
+				dst, err = b.treeSnapshot(to)
+			}
+		case from != "":
+			if src, err = b.treeSnapshot(from); err == nil {
+				dst, err = b.worktreeSnapshot()
+			}
+		default:
+			if src, err = b.indexSnapshot(); err == nil {
+				dst, err = b.worktreeSnapshot()
+			}
+		}
+		if err != nil {
+			return err
+		}
+		out, err = encodeDiff(src, dst, "")
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("git diff failed: %v", err)
+	}
+	return out, nil
+}
+
+func (b *goGitBackend) StagedDiff() (string, error) {
+	var out string
+	err := b.traced("diff", []string{"--cached"}, func() error {
+		src, err := b.treeSnapshot("HEAD")
+		if err != nil {
+			return err
+		}
+		dst, err := b.indexSnapshot()
+		if err != nil {
+			return err
+		}
+		out, err = encodeDiff(src, dst, "")
+		return err
+	})
+	return out, err
+}
+
+func (b *goGitBackend) FileDiff(path string) (string, error) {
+	var out string
+	err := b.traced("diff", []string{"HEAD", "--", path}, func() error {
+		rel, err := b.relPath(path)
+		if err != nil {
+			return err
+		}
+		src, err := b.treeSnapshot("HEAD")
+		if err != nil {
+			return err
+		}
+		dst, err := b.worktreeSnapshot()
+		if err != nil {
+			return err
+		}
+		out, err = encodeDiff(src, dst, rel)
+		return err
+	})
+	return out, err
+}
+
+func (b *goGitBackend) FileContent(rev, path string) (string, error) {
+	var content string
+	err := b.traced("show", []string{rev + ":" + path}, func() error {
+		commit, err := b.commit(rev)
+		if err != nil {
+			return err
+		}
+		file, err := commit.File(path)
+		if err != nil {
+			return err
+		}
+		content, err = file.Contents()
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("获取文件内容失败: %v", err)
+	}
+	return content, nil
+}
+
+func (b *goGitBackend) FileSize(rev, path string) (int64, error) {
+	var size int64
+	err := b.traced("cat-file", []string{"-s", rev + path}, func() error {
+		repo, err := b.open()
+		if err != nil {
+			return err
+		}
+		switch rev {
+		case "":
+			info, err := os.Stat(filepath.Join(b.root, path))
+			if err != nil {
+				return err
+			}
+			size = info.Size()
+			return nil
+		case ":":
+			idx, err := repo.Storer.Index()
+			if err != nil {
+				return err
+			}
+			entry, err := idx.Entry(path)
+			if err != nil {
+				return err
+			}
+			blob, err := repo.BlobObject(entry.Hash)
+			if err != nil {
+				return err
+			}
+			size = blob.Size
+			return nil
+		}
+		commit, err := b.commit(rev)
+		if err != nil {
+			return err
+		}
+		file, err := commit.File(path)
+		if err != nil {
+			return err
+		}
+		size = file.Size
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("获取文件大小失败: %v", err)
+	}
+	return size, nil
+}
+
+func (b *goGitBackend) RepoRoot() (string, error) {
+	if _, err := b.open(); err != nil {
+		return "", fmt.Errorf("获取仓库根目录失败: %v", err)
+	}
+	return b.root, nil
+}
+
+// GitDir 仓库根目录下的 .git 为目录时直接返回，为文件时（worktree、子模块）读取其中的 gitdir
+func (b *goGitBackend) GitDir() (string, error) {
+	if _, err := b.open(); err != nil {
+		return "", fmt.Errorf("获取 .git 目录失败: %v", err)
+	}
+	dotGit := filepath.Join(b.root, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", fmt.Errorf("获取 .git 目录失败: %v", err)
+	}
+	if info.IsDir() {
+		return dotGit, nil
+	}
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", fmt.Errorf("获取 .git 目录失败: %v", err)
+	}
+	dir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("获取 .git 目录失败: 无法解析 %s", dotGit)
+	}
+	dir = strings.TrimSpace(dir)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(b.root, dir)
+	}
+	return filepath.Clean(dir), nil
+}
+
+func (b *goGitBackend) CurrentBranch() (string, error) {
+	var branch string
+	err := b.traced("rev-parse", []string{"--abbrev-ref", "HEAD"}, func() error {
+		repo, err := b.open()
+		if err != nil {
+			return err
+		}
+		head, err := repo.Head()
+		if err != nil {
+			return err
+		}
+		branch = "HEAD"
+		if head.Name().IsBranch() {
+			branch = head.Name().Short()
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("获取当前分支失败: %v", err)
+	}
+	return branch, nil
+}
+
+// CommitsInRange 支持单个版本、a..b 和 a...b；a...b 与 git log 相同，列出只能从其中一端到达的提交
+func (b *goGitBackend) CommitsInRange(revRange string) ([]string, error) {
+	var commits []string
+	err := b.traced("log", []string{"--format=%H", revRange}, func() error {
+		var heads []string
+		excluded := make(map[plumbing.Hash]bool)
+		exclude := func(rev string) error {
+			c, err := b.commit(rev)
+			if err != nil {
+				return err
+			}
+			return walkCommits(c, excluded, func(*object.Commit) error { return nil })
+		}
+		if a, c, ok := strings.Cut(revRange, "..."); ok {
+			a, c = orHead(a), orHead(c)
+			base, err := b.mergeBase(a, c)
+			if err != nil {
+				return err
+			}
+			if err := walkCommits(base, excluded, func(*object.Commit) error { return nil }); err != nil {
+				return err
+			}
+			heads = []string{c, a}
+		} else if a, c, ok := strings.Cut(revRange, ".."); ok {
+			if err := exclude(orHead(a)); err != nil {
+				return err
+			}
+			heads = []string{orHead(c)}
+		} else {
+			heads = []string{orHead(revRange)}
+		}
+		for _, rev := range heads {
+			head, err := b.commit(rev)
+			if err != nil {
+				return err
+			}
+			if err := walkCommits(head, excluded, func(c *object.Commit) error {
+				commits = append(commits, c.Hash.String())
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取提交列表失败: %v", err)
+	}
+	return commits, nil
+}
+
+// walkCommits 从 start 开始遍历可达的提交，跳过并记录已访问的提交
+func walkCommits(start *object.Commit, seen map[plumbing.Hash]bool, fn func(*object.Commit) error) error {
+	iter := object.NewCommitPreorderIter(start, seen, nil)
+	defer iter.Close()
+	return iter.ForEach(func(c *object.Commit) error {
+		seen[c.Hash] = true
+		return fn(c)
+	})
+}
+
+// RecentCommits 按提交时间从新到旧遍历，保留相对第一个父提交修改过文件的非合并提交
+func (b *goGitBackend) RecentCommits(rev, path string, n int) ([]CommitInfo, error) {
+	var commits []CommitInfo
+	err := b.traced("log", []string{"-n", fmt.Sprint(n), rev, "--", path}, func() error {
+		start, err := b.commit(rev)
+		if err != nil {
+			return err
+		}
+		iter := object.NewCommitIterCTime(start, nil, nil)
+		defer iter.Close()
+		err = iter.ForEach(func(c *object.Commit) error {
+			if c.NumParents() > 1 {
+				return nil
+			}
+			changed, err := fileChanged(c, path)
+			if err != nil || !changed {
+				return err
+			}
+			commits = append(commits, CommitInfo{
+				Hash:    c.Hash.String()[:7],
+				Date:    c.Author.When.Format("2006-01-02"),
+				Author:  c.Author.Name,
+				Subject: commitSubject(c.Message),
+			})
+			if len(commits) == n {
+				return errStopIter
+			}
+			return nil
+		})
+		if err == errStopIter {
+			err = nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取文件的提交历史失败: %v", err)
+	}
+	return commits, nil
+}
+
+// errStopIter 提前结束提交遍历
+var errStopIter = fmt.Errorf("停止遍历")
+
+// fileChanged 判断提交是否修改了文件：文件在提交和父提交中的内容或模式不同
+func fileChanged(c *object.Commit, path string) (bool, error) {
+	hash, mode := fileEntry(c, path)
+	if c.NumParents() == 0 {
+		return !hash.IsZero(), nil
+	}
+	parent, err := c.Parent(0)
+	if err != nil {
+		return false, err
+	}
+	parentHash, parentMode := fileEntry(parent, path)
+	return hash != parentHash || mode != parentMode, nil
+}
+
+// fileEntry 返回文件在提交中的对象哈希和模式，文件不存在时返回零值
+func fileEntry(c *object.Commit, path string) (plumbing.Hash, filemode.FileMode) {
+	tree, err := c.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, 0
+	}
+	entry, err := tree.FindEntry(path)
+	if err != nil {
+		return plumbing.ZeroHash, 0
+	}
+	return entry.Hash, entry.Mode
+}
+
+func (b *goGitBackend) ListFiles() ([]string, error) {
+	var files []string
+	err := b.traced("ls-files", nil, func() error {
+		repo, err := b.open()
+		if err != nil {
+			return err
+		}
+		idx, err := repo.Storer.Index()
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(idx.Entries))
+		for _, entry := range idx.Entries {
+			if !seen[entry.Name] {
+				seen[entry.Name] = true
+				files = append(files, entry.Name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取仓库文件列表失败: %v", err)
+	}
+	return files, nil
+}
+
+func (b *goGitBackend) UntrackedFiles() ([]string, error) {
+	var files []string
+	err := b.traced("ls-files", []string{"--others", "--exclude-standard"}, func() error {
+		repo, err := b.open()
+		if err != nil {
+			return err
+		}
+		wt, err := repo.Worktree()
+		if err != nil {
+			return err
+		}
+		// Status 会按各级目录的 .gitignore 排除被忽略的文件
+		status, err := wt.Status()
+		if err != nil {
+			return err
+		}
+		for path, s := range status {
+			if s.Worktree == gogit.Untracked && s.Staging == gogit.Untracked {
+				files = append(files, path)
+			}
+		}
+		sort.Strings(files)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取未跟踪的文件失败: %v", err)
+	}
+	return files, nil
+}
+
+// mergeBase 返回两个版本的共同祖先
+func (b *goGitBackend) mergeBase(a, c string) (*object.Commit, error) {
+	ca, err := b.commit(a)
+	if err != nil {
+		return nil, err
+	}
+	cc, err := b.commit(c)
+	if err != nil {
+		return nil, err
+	}
+	bases, err := ca.MergeBase(cc)
+	if err != nil {
+		return nil, err
+	}
+	if len(bases) == 0 {
+		return nil, fmt.Errorf("%s 和 %s 没有共同的祖先", a, c)
+	}
+	return bases[0], nil
+}
+
+func (b *goGitBackend) MergeBase(a, c string) (string, error) {
+	var base string
+	err := b.traced("merge-base", []string{a, c}, func() error {
+		commit, err := b.mergeBase(a, c)
+		if err != nil {
+			return err
+		}
+		base = commit.Hash.String()
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("git merge-base 失败: %v", err)
+	}
+	return base, nil
+}
+
+func (b *goGitBackend) IsAncestor(ancestor, rev string) (bool, error) {
+	var ok bool
+	err := b.traced("merge-base", []string{"--is-ancestor", ancestor, rev}, func() error {
+		ca, err := b.commit(ancestor)
+		if err != nil {
+			return err
+		}
+		cr, err := b.commit(rev)
+		if err != nil {
+			return err
+		}
+		ok, err = ca.IsAncestor(cr)
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("git merge-base 失败: %v", err)
+	}
+	return ok, nil
+}
+
+// CommitFiles 合并提交与 git diff-tree 的默认行为不同，返回 errUnsupported
+func (b *goGitBackend) CommitFiles(commit string) ([]string, error) {
+	var files []string
+	err := b.traced("diff-tree", []string{"--name-only", "-r", "--root", commit}, func() error {
+		c, err := b.commit(commit)
+		if err != nil {
+			return err
+		}
+		if c.NumParents() > 1 {
+			return errUnsupported
+		}
+		dst, err := commitSnapshot(b.repo, c)
+		if err != nil {
+			return err
+		}
+		src := snapshot{}
+		if c.NumParents() == 1 {
+			parent, err := c.Parent(0)
+			if err != nil {
+				return err
+			}
+			if src, err = commitSnapshot(b.repo, parent); err != nil {
+				return err
+			}
+		}
+		files = changedPaths(src, dst)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取提交 %s 修改的文件失败: %v", commit, err)
+	}
+	return files, nil
+}
+
+// commitSubject 返回提交说明的标题，与 git log 的 %s 相同：第一段的各行以空格连接
+func commitSubject(message string) string {
+	paragraph := strings.SplitN(strings.TrimSpace(message), "\n\n", 2)[0]
+	lines := strings.Split(paragraph, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	return strings.Join(lines, " ")
+}
+
+// relPath 把相对 dir 的路径转换为相对仓库根目录的路径
+func (b *goGitBackend) relPath(path string) (string, error) {
+	if _, err := b.open(); err != nil {
+		return "", err
+	}
+	abs := path
+	if !filepath.IsAbs(abs) {
+		abs = filepath.Join(b.dir, path)
+	}
+	// 仓库根目录可能经过符号链接解析，统一解析后再计算相对路径
+	if resolved, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		abs = filepath.Join(resolved, filepath.Base(abs))
+	}
+	root := b.root
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s 不在仓库中", path)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// nonEmpty 返回非空的参数，用于记录 span
+func nonEmpty(args ...string) []string {
+	var out []string
+	for _, arg := range args {
+		if arg != "" {
+			out = append(out, arg)
+		}
+	}
+	return out
+}
+
+// snapshotEntry 快照中的一个文件
+type snapshotEntry struct {
+	hash plumbing.Hash
+	mode filemode.FileMode
+	// read 读取文件内容
+	read func() ([]byte, error)
+}
+
+// snapshot 某一时刻仓库中的文件，键为相对仓库根目录的路径
+type snapshot map[string]snapshotEntry
+
+// treeSnapshot 返回版本中的文件
+func (b *goGitBackend) treeSnapshot(rev string) (snapshot, error) {
+	commit, err := b.commit(rev)
+	if err != nil {
+		return nil, err
+	}
+	return commitSnapshot(b.repo, commit)
+}
+
+// commitSnapshot 返回提交中的文件，子模块不在其中
+func commitSnapshot(repo *gogit.Repository, commit *object.Commit) (snapshot, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	snap := snapshot{}
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if err != nil {
+			break
+		}
+		if !entry.Mode.IsFile() {
+			continue
+		}
+		blobHash := entry.Hash
+		snap[name] = snapshotEntry{hash: blobHash, mode: entry.Mode, read: func() ([]byte, error) {
+			return readBlob(repo, blobHash)
+		}}
+	}
+	return snap, nil
+}
+
+// readBlob 读取对象的内容
+func readBlob(repo *gogit.Repository, hash plumbing.Hash) ([]byte, error) {
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(r)
+	return buf.Bytes(), err
+}
+
+// indexSnapshot 返回暂存区中的文件；存在未解决的冲突时返回 errUnsupported
+func (b *goGitBackend) indexSnapshot() (snapshot, error) {
+	repo, err := b.open()
+	if err != nil {
+		return nil, err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+	snap := snapshot{}
+	for _, entry := range idx.Entries {
+		// 未冲突的条目阶段为 0，存在冲突时有 1～3 阶段的条目
+		if entry.Stage != 0 {
+			return nil, errUnsupported
+		}
+		if !entry.Mode.IsFile() || entry.IntentToAdd {
+			continue
+		}
+		blobHash := entry.Hash
+		snap[entry.Name] = snapshotEntry{hash: blobHash, mode: entry.Mode, read: func() ([]byte, error) {
+			return readBlob(repo, blobHash)
+		}}
+	}
+	return snap, nil
+}
+
+// worktreeSnapshot 返回工作区中被跟踪的文件，与 git diff 一样不包含未跟踪的文件；
+// 大小和修改时间与索引一致的文件视为未修改，不读取内容。
+// 配置了换行转换或过滤器时工作区内容与对象不能直接比较，返回 errUnsupported
+func (b *goGitBackend) worktreeSnapshot() (snapshot, error) {
+	repo, err := b.open()
+	if err != nil {
+		return nil, err
+	}
+	if b.hasFilters() {
+		return nil, errUnsupported
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, err
+	}
+	snap := snapshot{}
+	for _, entry := range idx.Entries {
+		// 未冲突的条目阶段为 0，存在冲突时有 1～3 阶段的条目
+		if entry.Stage != 0 {
+			return nil, errUnsupported
+		}
+		if !entry.Mode.IsFile() || entry.SkipWorktree {
+			continue
+		}
+		path := filepath.Join(b.root, filepath.FromSlash(entry.Name))
+		info, err := os.Lstat(path)
+		if err != nil {
+			// 已删除的文件
+			continue
+		}
+		mode := entry.Mode
+		if info.Mode()&os.ModeSymlink != 0 {
+			mode = filemode.Symlink
+		} else if entry.Mode != filemode.Symlink {
+			mode = filemode.Regular
+			if info.Mode()&0o111 != 0 {
+				mode = filemode.Executable
+			}
+		}
+		if !entry.IntentToAdd && mode == entry.Mode && info.Size() == int64(entry.Size) && info.ModTime().Equal(entry.ModifiedAt) {
+			entryHash := entry.Hash
+			snap[entry.Name] = snapshotEntry{hash: entryHash, mode: mode, read: func() ([]byte, error) {
+				return readBlob(repo, entryHash)
+			}}
+			continue
+		}
+		var content []byte
+		if mode == filemode.Symlink {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return nil, err
+			}
+			content = []byte(target)
+		} else if content, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+		data := content
+		snap[entry.Name] = snapshotEntry{
+			hash: plumbing.ComputeHash(plumbing.BlobObject, data),
+			mode: mode,
+			read: func() ([]byte, error) { return data, nil },
+		}
+	}
+	return snap, nil
+}
+
+// hasFilters 判断工作区是否配置了换行转换或过滤器：core.autocrlf 不为 false，
+// 或仓库根目录的 .gitattributes 中设置了 text、eol、filter 等属性
+func (b *goGitBackend) hasFilters() bool {
+	if cfg, err := b.repo.Config(); err == nil {
+		if autocrlf := strings.ToLower(cfg.Raw.Section("core").Option("autocrlf")); autocrlf != "" && autocrlf != "false" {
+			return true
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(b.root, ".gitattributes"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		for _, attr := range fields[1:] {
+			name := strings.TrimLeft(strings.SplitN(attr, "=", 2)[0], "-!")
+			switch name {
+			case "text", "eol", "crlf", "filter", "ident", "working-tree-encoding":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// changedPaths 返回两个快照中内容或模式不同的路径，按路径排序
+func changedPaths(src, dst snapshot) []string {
+	var paths []string
+	for path, s := range src {
+		if d, ok := dst[path]; !ok || d.hash != s.hash || d.mode != s.mode {
+			paths = append(paths, path)
+		}
+	}
+	for path := range dst {
+		if _, ok := src[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// encodeDiff 生成两个快照之间 git diff -M -C 格式的统一差异，only 不为空时只包含该文件且不识别重命名
+func encodeDiff(src, dst snapshot, only string) (string, error) {
+	var moves map[string]movedFile
+	if only == "" {
+		var err error
+		if moves, err = detectMoves(src, dst); err != nil {
+			return "", err
+		}
+	}
+	renamedFrom := make(map[string]bool)
+	for _, move := range moves {
+		if !move.copied {
+			renamedFrom[move.from] = true
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := fdiff.NewUnifiedEncoder(&buf, fdiff.DefaultContextLines)
+	for _, path := range changedPaths(src, dst) {
+		if (only != "" && path != only) || renamedFrom[path] {
+			continue
+		}
+		move, moved := moves[path]
+		from := path
+		if moved {
+			from = move.from
+		}
+		patch, err := newFilePatch(from, path, src, dst)
+		if err != nil {
+			return "", err
+		}
+		if !moved {
+			if err := encoder.Encode(&treePatch{files: []fdiff.FilePatch{patch}}); err != nil {
+				return "", err
+			}
+			continue
+		}
+		// go-git 的编码器对路径不同的文件只输出 rename from/to，补上相似度并区分复制
+		var moveBuf bytes.Buffer
+		if err := fdiff.NewUnifiedEncoder(&moveBuf, fdiff.DefaultContextLines).Encode(&treePatch{files: []fdiff.FilePatch{patch}}); err != nil {
+			return "", err
+		}
+		header := fmt.Sprintf("\nsimilarity index %d%%\nrename from ", move.similarity)
+		text := strings.Replace(moveBuf.String(), "\nrename from ", header, 1)
+		if move.copied {
+			text = strings.Replace(text, "\nrename from ", "\ncopy from ", 1)
+			text = strings.Replace(text, "\nrename to ", "\ncopy to ", 1)
+		}
+		buf.WriteString(text)
+	}
+	return buf.String(), nil
+}
+
+// treePatch 实现 go-git 统一差异编码器需要的 Patch 接口
+type treePatch struct {
+	files []fdiff.FilePatch
+}
+
+func (p *treePatch) FilePatches() []fdiff.FilePatch { return p.files }
+func (p *treePatch) Message() string                { return "" }
+
+// filePatch 单个文件的改动
+type filePatch struct {
+	from, to fdiff.File
+	binary   bool
+	chunks   []fdiff.Chunk
+}
+
+func (p *filePatch) IsBinary() bool                  { return p.binary }
+func (p *filePatch) Files() (fdiff.File, fdiff.File) { return p.from, p.to }
+func (p *filePatch) Chunks() []fdiff.Chunk           { return p.chunks }
+
+// patchFile 改动前后的文件
+type patchFile struct {
+	path string
+	hash plumbing.Hash
+	mode filemode.FileMode
+}
+
+func (f *patchFile) Hash() plumbing.Hash     { return f.hash }
+func (f *patchFile) Mode() filemode.FileMode { return f.mode }
+func (f *patchFile) Path() string            { return f.path }
+
+// patchChunk 差异中的一段连续内容
+type patchChunk struct {
+	content string
+	op      fdiff.Operation
+}
+
+func (c *patchChunk) Content() string       { return c.content }
+func (c *patchChunk) Type() fdiff.Operation { return c.op }
+
+// newFilePatch 比较文件 from 在 src 中和 to 在 dst 中的内容，生成文件改动；重命名或复制的文件 from 为原文件
+func newFilePatch(from, path string, src, dst snapshot) (*filePatch, error) {
+	patch := &filePatch{}
+	var before, after []byte
+	if s, ok := src[from]; ok {
+		patch.from = &patchFile{path: from, hash: s.hash, mode: s.mode}
+		content, err := s.read()
+		if err != nil {
+			return nil, err
+		}
+		before = content
+	}
+	if d, ok := dst[path]; ok {
+		patch.to = &patchFile{path: path, hash: d.hash, mode: d.mode}
+		content, err := d.read()
+		if err != nil {
+			return nil, err
+		}
+		after = content
+	}
+	for _, content := range [][]byte{before, after} {
+		if isBinary, _ := binary.IsBinary(bytes.NewReader(content)); isBinary {
+			patch.binary = true
+			return patch, nil
+		}
+	}
+	if bytes.Equal(before, after) {
+		// 只有文件模式变化
+		return patch, nil
+	}
+	for _, d := range udiff.Do(string(before), string(after)) {
+		op := fdiff.Equal
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			op = fdiff.Add
+		case diffmatchpatch.DiffDelete:
+			op = fdiff.Delete
+		}
+		patch.chunks = append(patch.chunks, &patchChunk{content: d.Text, op: op})
+	}
+	return patch, nil
+}
@@ -0,0 +1,127 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// rangeTestRepo 用 go-git 创建测试仓库，不依赖 git 命令：
+// main 上依次提交 base、main，feature 分支从 base 分出并提交 feature，检出 main
+func rangeTestRepo(t *testing.T) (dir string, hashes map[string]string) {
+	t.Helper()
+	dir = t.TempDir()
+	repo, err := gogit.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes = make(map[string]string)
+	when := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	commit := func(name, file, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.Add(file); err != nil {
+			t.Fatal(err)
+		}
+		when = when.Add(time.Minute)
+		sig := &object.Signature{Name: "A", Email: "a@b", When: when}
+		hash, err := wt.Commit(name, &gogit.CommitOptions{Author: sig, Committer: sig})
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes[name] = hash.String()
+	}
+
+	commit("base", "main.go", "package main\n")
+	commit("main", "main.go", "package main\n\nfunc main() {}\n")
+	feature := plumbing.NewBranchReferenceName("feature")
+	if err := wt.Checkout(&gogit.CheckoutOptions{Hash: plumbing.NewHash(hashes["base"]), Branch: feature, Create: true}); err != nil {
+		t.Fatal(err)
+	}
+	commit("feature", "feature.go", "package main\n\nfunc feature() {}\n")
+	if err := wt.Checkout(&gogit.CheckoutOptions{Branch: plumbing.Master}); err != nil {
+		t.Fatal(err)
+	}
+	return dir, hashes
+}
+
+func TestGoGitBackendRanges(t *testing.T) {
+	dir, hashes := rangeTestRepo(t)
+	client := NewGitClientWithBackend(dir, &goGitBackend{dir: dir, trace: &tracer{}})
+
+	diffTests := []struct {
+		name  string
+		from  string
+		files []string
+	}{
+		// 评审命令默认的 --commit-range
+		{name: "默认范围", from: "HEAD~1..HEAD", files: []string{"main.go"}},
+		{name: "省略终点", from: "HEAD~1..", files: []string{"main.go"}},
+		{name: "两个分支", from: "master..feature", files: []string{"feature.go", "main.go"}},
+		// 从共同祖先开始，只包含 feature 分支的改动
+		{name: "三点范围", from: "master...feature", files: []string{"feature.go"}},
+	}
+	for _, tt := range diffTests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := client.GetChangedFiles(tt.from, "")
+			if err != nil {
+				t.Fatalf("获取 %s 的改动失败: %v", tt.from, err)
+			}
+			sort.Strings(files)
+			if !equalStrings(files, tt.files) {
+				t.Fatalf("%s 的改动文件: 得到 %v，期望 %v", tt.from, files, tt.files)
+			}
+		})
+	}
+
+	commitTests := []struct {
+		revRange string
+		commits  []string
+	}{
+		{revRange: "HEAD~1..HEAD", commits: []string{"main"}},
+		{revRange: "master..feature", commits: []string{"feature"}},
+		{revRange: "master...feature", commits: []string{"feature", "main"}},
+		{revRange: "feature", commits: []string{"base", "feature"}},
+	}
+	for _, tt := range commitTests {
+		t.Run(tt.revRange, func(t *testing.T) {
+			got, err := client.CommitsInRange(tt.revRange)
+			if err != nil {
+				t.Fatalf("获取 %s 的提交失败: %v", tt.revRange, err)
+			}
+			if len(got) != len(tt.commits) {
+				t.Fatalf("%s 的提交数: 得到 %d，期望 %v", tt.revRange, len(got), tt.commits)
+			}
+			for _, name := range tt.commits {
+				if !got[hashes[name]] {
+					t.Fatalf("%s 中缺少提交 %s", tt.revRange, name)
+				}
+			}
+		})
+	}
+}
+
+// equalStrings 判断两个字符串切片是否相同
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
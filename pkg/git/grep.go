@@ -0,0 +1,74 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// GrepMatch git grep 找到的一行
+type GrepMatch struct {
+	// 相对于仓库根目录的路径
+	Path string
+	Line int
+	Text string
+}
+
+// GrepWords 在整个仓库中按完整单词搜索 words 中的任意一个，跳过二进制文件；每个文件最多返回 perFile 行，为0时不限制
+// rev 为空时搜索工作区，为 ":" 时搜索暂存区，否则搜索该版本；没有匹配时返回空列表
+// 只能通过 git 命令完成，go-git 后端下同样需要安装 git
+func (c *GitClient) GrepWords(rev string, words []string, perFile int) ([]GrepMatch, error) {
+	if len(words) == 0 {
+		return nil, nil
+	}
+	args := []string{"grep", "-z", "-n", "-I", "-w", "-F", "--full-name", "--no-color"}
+	if perFile > 0 {
+		args = append(args, "--max-count", strconv.Itoa(perFile))
+	}
+	for _, word := range words {
+		args = append(args, "-e", word)
+	}
+	switch rev {
+	case "":
+	case ":":
+		args = append(args, "--cached")
+	default:
+		args = append(args, rev)
+	}
+	// :/ 表示从仓库根目录搜索，不受当前目录影响
+	args = append(args, "--", ":/")
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = c.repoPath
+	output, err := c.output(cmd)
+	if err != nil {
+		// 退出码 1 表示没有匹配
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("搜索引用失败: %v", err)
+	}
+
+	// -z 时每行为 路径\0行号\0内容，搜索版本时路径前带有 "版本:"
+	var matches []GrepMatch
+	for _, line := range bytes.Split(output, []byte("\n")) {
+		fields := bytes.SplitN(line, []byte{0}, 3)
+		if len(fields) != 3 {
+			continue
+		}
+		n, err := strconv.Atoi(string(fields[1]))
+		if err != nil {
+			continue
+		}
+		path := string(fields[0])
+		if rev != "" && rev != ":" {
+			path = strings.TrimPrefix(path, rev+":")
+		}
+		matches = append(matches, GrepMatch{Path: path, Line: n, Text: string(fields[2])})
+	}
+	return matches, nil
+}
@@ -5,6 +5,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/version"
 )
 
 // HookType 定义Git钩子类型
@@ -15,12 +18,26 @@ const (
 	PrePushHook   HookType = "pre-push"
 )
 
+// 受管区块标记，安装和升级时只替换标记之间的内容
+const (
+	ManagedBlockBegin = "# >>> ai-cr-tool managed block >>>"
+	ManagedBlockEnd   = "# <<< ai-cr-tool managed block <<<"
+)
+
 // HookConfig 钩子配置
 type HookConfig struct {
 	Enabled bool
 	Options map[string]string
 }
 
+// HookStatus 钩子安装状态
+type HookStatus struct {
+	Installed   bool      // 钩子文件是否存在
+	Managed     bool      // 是否包含受管区块
+	Version     string    // 生成受管区块的工具版本
+	GeneratedAt time.Time // 受管区块的生成时间
+}
+
 // HookManager Git钩子管理器
 type HookManager struct {
 	repoPath string
@@ -40,9 +57,16 @@ func (m *HookManager) ConfigureHook(hookType HookType, config HookConfig) {
 	m.config[hookType] = config
 }
 
-// InstallHook 安装Git钩子
+// hookPath 返回钩子文件路径
+func (m *HookManager) hookPath(hookType HookType) string {
+	return filepath.Join(m.repoPath, ".git", "hooks", string(hookType))
+}
+
+// InstallHook 安装或升级Git钩子
+// 已有受管区块时只替换区块内容；已有用户自定义钩子时先备份，再把受管区块插入到 shebang 之后，
+// 区块之外的用户内容保持不变
 func (m *HookManager) InstallHook(hookType HookType) error {
-	hookPath := filepath.Join(m.repoPath, ".git", "hooks", string(hookType))
+	hookPath := m.hookPath(hookType)
 
 	// 检查钩子配置
 	config, ok := m.config[hookType]
@@ -55,19 +79,32 @@ func (m *HookManager) InstallHook(hookType HookType) error {
 		return nil
 	}
 
-	// 检查钩子文件是否已存在
-	if _, err := os.Stat(hookPath); err == nil {
-		// 备份已存在的钩子
+	block := m.generateManagedBlock(hookType)
+
+	existing, err := os.ReadFile(hookPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read existing hook: %v", err)
+	}
+
+	var content string
+	switch {
+	case os.IsNotExist(err):
+		content = "#!/bin/sh\n\n" + block
+	case hasManagedBlock(string(existing)):
+		content = replaceManagedBlock(string(existing), block)
+	default:
+		// 备份已存在的用户钩子
 		backupPath := hookPath + ".backup"
-		if err := os.Rename(hookPath, backupPath); err != nil {
+		if err := os.WriteFile(backupPath, existing, 0755); err != nil {
 			return fmt.Errorf("failed to backup existing hook: %v", err)
 		}
+		content = insertManagedBlock(string(existing), block)
 	}
 
-	// 创建钩子脚本内容
-	content := m.generateHookScript(hookType)
-
 	// 写入钩子文件
+	if err := os.MkdirAll(filepath.Dir(hookPath), 0755); err != nil {
+		return fmt.Errorf("failed to create hooks directory: %v", err)
+	}
 	if err := os.WriteFile(hookPath, []byte(content), 0755); err != nil {
 		return fmt.Errorf("failed to write hook file: %v", err)
 	}
@@ -75,26 +112,74 @@ func (m *HookManager) InstallHook(hookType HookType) error {
 	return nil
 }
 
-// RemoveHook 移除Git钩子
+// RemoveHook 移除Git钩子中的受管区块
+// 如果移除后只剩下 shebang 和空行则删除整个钩子文件
 func (m *HookManager) RemoveHook(hookType HookType) error {
-	hookPath := filepath.Join(m.repoPath, ".git", "hooks", string(hookType))
+	hookPath := m.hookPath(hookType)
 
-	if err := os.Remove(hookPath); err != nil {
-		return fmt.Errorf("failed to remove hook: %v", err)
+	existing, err := os.ReadFile(hookPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read hook: %v", err)
+	}
+	if !hasManagedBlock(string(existing)) {
+		return nil
 	}
 
+	rest := replaceManagedBlock(string(existing), "")
+	if isEmptyScript(rest) {
+		if err := os.Remove(hookPath); err != nil {
+			return fmt.Errorf("failed to remove hook: %v", err)
+		}
+		return nil
+	}
+
+	if err := os.WriteFile(hookPath, []byte(rest), 0755); err != nil {
+		return fmt.Errorf("failed to write hook file: %v", err)
+	}
 	return nil
 }
 
-// generateHookScript 生成钩子脚本内容
-func (m *HookManager) generateHookScript(hookType HookType) string {
-	var script strings.Builder
+// HookStatus 查询钩子的安装状态
+func (m *HookManager) HookStatus(hookType HookType) (HookStatus, error) {
+	var status HookStatus
 
-	// 添加脚本头部
-	script.WriteString("#!/bin/sh\n\n")
+	data, err := os.ReadFile(m.hookPath(hookType))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return status, nil
+		}
+		return status, fmt.Errorf("failed to read hook: %v", err)
+	}
+	status.Installed = true
 
-	// 添加错误处理
-	script.WriteString("set -e\n\n")
+	content := string(data)
+	if !hasManagedBlock(content) {
+		return status, nil
+	}
+	status.Managed = true
+
+	for _, line := range strings.Split(content, "\n") {
+		if v, ok := strings.CutPrefix(line, "# version: "); ok {
+			status.Version = strings.TrimSpace(v)
+		} else if v, ok := strings.CutPrefix(line, "# generated: "); ok {
+			status.GeneratedAt, _ = time.Parse(time.RFC3339, strings.TrimSpace(v))
+		}
+	}
+	return status, nil
+}
+
+// generateManagedBlock 生成钩子的受管区块内容
+func (m *HookManager) generateManagedBlock(hookType HookType) string {
+	var script strings.Builder
+
+	// 添加区块头部和元信息
+	script.WriteString(ManagedBlockBegin + "\n")
+	script.WriteString(fmt.Sprintf("# version: %s\n", version.Version))
+	script.WriteString(fmt.Sprintf("# generated: %s\n", time.Now().Format(time.RFC3339)))
+	script.WriteString("# 该区块由 ai-cr-tool 自动生成并在升级时整体替换，自定义内容请写在区块之外\n")
 
 	// 添加日志函数
 	script.WriteString("log() {\n")
@@ -105,11 +190,76 @@ func (m *HookManager) generateHookScript(hookType HookType) string {
 	switch hookType {
 	case PreCommitHook:
 		script.WriteString("# 运行代码评审工具\n")
-		script.WriteString("ai-cr-tool review pre-commit || exit 1\n")
+		script.WriteString("cr --staged --quiet || { log \"代码评审未通过，请修复问题后再提交\"; exit 1; }\n")
 	case PrePushHook:
+		// git 通过标准输入传入引用列表，评审后恢复，区块之后的用户脚本仍然可以读取
 		script.WriteString("# 运行代码评审工具\n")
-		script.WriteString("ai-cr-tool review pre-push || exit 1\n")
+		script.WriteString("refs=$(cat)\n")
+		script.WriteString("z40=0000000000000000000000000000000000000000\n")
+		script.WriteString("while read local_ref local_sha remote_ref remote_sha; do\n")
+		script.WriteString("    [ -z \"$local_sha\" ] || [ \"$local_sha\" = \"$z40\" ] && continue\n")
+		script.WriteString("    if [ \"$remote_sha\" = \"$z40\" ]; then\n")
+		// 新分支评审还不在任何远程分支上的提交，从其中最早的提交的父提交开始；最早的提交是根提交时从空树开始
+		script.WriteString("        base=$(git rev-list \"$local_sha\" --not --remotes | tail -n 1)\n")
+		script.WriteString("        [ -z \"$base\" ] && continue\n")
+		script.WriteString("        if git rev-parse -q --verify \"$base^\" >/dev/null; then\n")
+		script.WriteString("            range=\"$base^..$local_sha\"\n")
+		script.WriteString("        else\n")
+		script.WriteString("            range=\"$(git hash-object -t tree /dev/null)..$local_sha\"\n")
+		script.WriteString("        fi\n")
+		script.WriteString("    else\n")
+		script.WriteString("        range=\"$remote_sha..$local_sha\"\n")
+		script.WriteString("    fi\n")
+		script.WriteString("    cr --commit-range \"$range\" --quiet || { log \"代码评审未通过，请修复问题后再推送\"; exit 1; }\n")
+		script.WriteString("done <<CR_REFS\n")
+		script.WriteString("$refs\n")
+		script.WriteString("CR_REFS\n")
+		script.WriteString("if [ -n \"$refs\" ]; then\n")
+		script.WriteString("    exec <<CR_REFS\n")
+		script.WriteString("$refs\n")
+		script.WriteString("CR_REFS\n")
+		script.WriteString("else\n")
+		script.WriteString("    exec </dev/null\n")
+		script.WriteString("fi\n")
 	}
 
+	script.WriteString(ManagedBlockEnd + "\n")
 	return script.String()
 }
+
+// hasManagedBlock 判断脚本是否包含完整的受管区块
+func hasManagedBlock(content string) bool {
+	begin := strings.Index(content, ManagedBlockBegin)
+	return begin >= 0 && strings.Index(content[begin:], ManagedBlockEnd) >= 0
+}
+
+// replaceManagedBlock 用新内容替换受管区块，区块外的内容保持不变
+func replaceManagedBlock(content, block string) string {
+	begin := strings.Index(content, ManagedBlockBegin)
+	end := begin + strings.Index(content[begin:], ManagedBlockEnd) + len(ManagedBlockEnd)
+	// 连同结束标记后的换行一起替换
+	if end < len(content) && content[end] == '\n' {
+		end++
+	}
+	return content[:begin] + block + content[end:]
+}
+
+// insertManagedBlock 把受管区块插入到用户脚本的 shebang 之后
+func insertManagedBlock(content, block string) string {
+	if !strings.HasPrefix(content, "#!") {
+		return "#!/bin/sh\n\n" + block + "\n" + content
+	}
+	shebang, rest, _ := strings.Cut(content, "\n")
+	return shebang + "\n\n" + block + "\n" + rest
+}
+
+// isEmptyScript 判断脚本是否只包含 shebang 和空行
+func isEmptyScript(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#!") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// RangeDiffStatus git range-diff 中一对提交的比较结果
+type RangeDiffStatus byte
+
+const (
+	// RangeDiffSame 两个版本中的提交内容相同
+	RangeDiffSame RangeDiffStatus = '='
+	// RangeDiffChanged 提交在新版本中被修改
+	RangeDiffChanged RangeDiffStatus = '!'
+	// RangeDiffRemoved 提交只在旧版本中
+	RangeDiffRemoved RangeDiffStatus = '<'
+	// RangeDiffAdded 提交只在新版本中
+	RangeDiffAdded RangeDiffStatus = '>'
+)
+
+// RangeDiffEntry git range-diff 输出中的一对提交
+type RangeDiffEntry struct {
+	Status RangeDiffStatus
+	// 旧版本和新版本中的提交，提交只在一个版本中时另一个为空
+	Old     string
+	New     string
+	Subject string
+}
+
+// rangeDiffLine 匹配 range-diff 中每对提交的标题行，如 "2:  ed466da ! 2:  792f08c 标题"
+var rangeDiffLine = regexp.MustCompile(`^(?:-|\d+):\s+(-+|[0-9a-f]+) ([=!<>]) (?:-|\d+):\s+(-+|[0-9a-f]+) (.*)$`)
+
+// RangeDiff 比较同一分支的两个版本，oldRange 和 newRange 为 base..head 形式的提交范围
+// 用于判断强制推送后哪些提交真正发生了变化
+func (c *GitClient) RangeDiff(oldRange, newRange string) ([]RangeDiffEntry, error) {
+	cmd := exec.Command("git", "range-diff", "--no-color", oldRange, newRange)
+	cmd.Dir = c.repoPath
+	output, err := c.output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("git range-diff 失败: %v", err)
+	}
+
+	var entries []RangeDiffEntry
+	for _, line := range strings.Split(string(output), "\n") {
+		m := rangeDiffLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		entry := RangeDiffEntry{Status: RangeDiffStatus(m[2][0]), Subject: m[4]}
+		if strings.Trim(m[1], "-") != "" {
+			entry.Old = m[1]
+		}
+		if strings.Trim(m[3], "-") != "" {
+			entry.New = m[3]
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// IsAncestor 判断 ancestor 是否为 rev 的祖先提交，用于区分普通推送和强制推送
+func (c *GitClient) IsAncestor(ancestor, rev string) (bool, error) {
+	return c.backend.IsAncestor(ancestor, rev)
+}
+
+// MergeBase 返回两个版本的合并基础
+func (c *GitClient) MergeBase(a, b string) (string, error) {
+	return c.backend.MergeBase(a, b)
+}
+
+// CommitFiles 返回提交修改的文件，路径为修改后的路径
+func (c *GitClient) CommitFiles(commit string) ([]string, error) {
+	return c.backend.CommitFiles(commit)
+}
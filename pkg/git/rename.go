@@ -0,0 +1,157 @@
+package git
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// emptyBlob 空文件的对象哈希，空文件不参与重命名识别
+var emptyBlob = plumbing.NewHash("e69de29bb2d1d6434b8b29ae775ad8c2e48c5391")
+
+const (
+	// renameScore 视为重命名或复制的最低相似度，与 git diff -M -C 的默认值相同
+	renameScore = 50
+	// renameLimit 新增文件数与候选来源数的乘积超过其平方时只识别内容完全相同的文件，与 git 的 diff.renameLimit 默认值相同
+	renameLimit = 1000
+	// similarityChunk 计算相似度时切块的最大字节数
+	similarityChunk = 64
+)
+
+// movedFile 内容来自另一个文件的新增文件
+type movedFile struct {
+	from       string
+	similarity int
+	copied     bool
+}
+
+// detectMoves 在 src 到 dst 新增的文件中找出重命名和复制的文件，与 git diff -M -C 相同：
+// 重命名的来源为被删除的文件，复制的来源为同一改动中被修改的文件；一个被删除的文件被多个新文件使用时，只有一个为重命名，其余为复制
+func detectMoves(src, dst snapshot) (map[string]movedFile, error) {
+	var added, sources []string
+	deleted := make(map[string]bool)
+	for path, d := range dst {
+		if s, ok := src[path]; !ok {
+			added = append(added, path)
+		} else if s.hash != d.hash {
+			sources = append(sources, path)
+		}
+	}
+	for path := range src {
+		if _, ok := dst[path]; !ok {
+			sources = append(sources, path)
+			deleted[path] = true
+		}
+	}
+	if len(added) == 0 || len(sources) == 0 {
+		return nil, nil
+	}
+	sort.Strings(added)
+	sort.Strings(sources)
+
+	// 先匹配内容完全相同的文件，空文件不参与
+	moves := make(map[string]movedFile)
+	for _, path := range added {
+		for _, from := range sources {
+			if s := src[from]; s.hash == dst[path].hash && s.hash != emptyBlob && s.mode.IsFile() == dst[path].mode.IsFile() {
+				moves[path] = movedFile{from: from, similarity: 100}
+				break
+			}
+		}
+	}
+
+	// 其余文件按估算的相似度从高到低匹配
+	var pending []string
+	for _, path := range added {
+		if _, ok := moves[path]; !ok && dst[path].hash != emptyBlob {
+			pending = append(pending, path)
+		}
+	}
+	if len(pending) > 0 && len(pending)*len(sources) <= renameLimit*renameLimit {
+		type candidate struct {
+			to, from string
+			score    int
+		}
+		var candidates []candidate
+		sourceContent := make(map[string][]byte)
+		for _, path := range pending {
+			after, err := dst[path].read()
+			if err != nil {
+				return nil, err
+			}
+			for _, from := range sources {
+				if src[from].mode.IsFile() != dst[path].mode.IsFile() {
+					continue
+				}
+				before, ok := sourceContent[from]
+				if !ok {
+					if before, err = src[from].read(); err != nil {
+						return nil, err
+					}
+					sourceContent[from] = before
+				}
+				if score := similarity(before, after); score >= renameScore {
+					candidates = append(candidates, candidate{to: path, from: from, score: score})
+				}
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+		for _, c := range candidates {
+			if _, ok := moves[c.to]; !ok {
+				moves[c.to] = movedFile{from: c.from, similarity: c.score}
+			}
+		}
+	}
+
+	// 来源仍然存在的是复制；与 git 相同，被删除的来源按新路径排序后最后一个为重命名
+	renamed := make(map[string]bool)
+	for i := len(added) - 1; i >= 0; i-- {
+		path := added[i]
+		move, ok := moves[path]
+		if !ok {
+			continue
+		}
+		if deleted[move.from] && !renamed[move.from] {
+			renamed[move.from] = true
+		} else {
+			move.copied = true
+			moves[path] = move
+		}
+	}
+	return moves, nil
+}
+
+// similarity 估算两个文件内容的相似度（0-100），与 git 的方法相同：
+// 把内容按行（超过 64 字节的行按 64 字节）切块，共有的字节数占较大文件的比例即为相似度；大小相差过多时直接返回 0
+func similarity(src, dst []byte) int {
+	maxSize, minSize := len(src), len(dst)
+	if maxSize < minSize {
+		maxSize, minSize = minSize, maxSize
+	}
+	if maxSize == 0 || (maxSize-minSize)*100 > maxSize*(100-renameScore) {
+		return 0
+	}
+	counts := chunkCounts(src)
+	common := 0
+	for chunk, n := range chunkCounts(dst) {
+		common += min(n, counts[chunk])
+	}
+	return common * 100 / maxSize
+}
+
+// chunkCounts 返回内容中各块的字节数，文本中行尾的 \r 不计入
+func chunkCounts(content []byte) map[string]int {
+	counts := make(map[string]int)
+	for len(content) > 0 {
+		n := bytes.IndexByte(content, '\n') + 1
+		if n == 0 || n > similarityChunk {
+			n = min(len(content), similarityChunk)
+		}
+		chunk := content[:n]
+		key := bytes.TrimSuffix(bytes.TrimSuffix(chunk, []byte("\n")), []byte("\r"))
+		counts[string(key)] += len(chunk)
+		content = content[n:]
+	}
+	return counts
+}
@@ -0,0 +1,105 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/utils/binary"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// GetUntrackedChanges 获取工作区中未跟踪且未被忽略的文件，每个文件按新增文件处理，差异为文件的完整内容
+func (c *GitClient) GetUntrackedChanges() ([]types.FileChange, error) {
+	files, err := c.backend.UntrackedFiles()
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	root, err := c.RepoRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []types.FileChange
+	for _, file := range files {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		info, err := os.Lstat(path)
+		if err != nil {
+			// 列出之后被删除的文件
+			continue
+		}
+		var content []byte
+		mode := "100644"
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return nil, fmt.Errorf("读取未跟踪的文件 %s 失败: %v", file, err)
+			}
+			content, mode = []byte(target), "120000"
+		case info.Mode().IsRegular():
+			if content, err = os.ReadFile(path); err != nil {
+				return nil, fmt.Errorf("读取未跟踪的文件 %s 失败: %v", file, err)
+			}
+			if info.Mode()&0o111 != 0 {
+				mode = "100755"
+			}
+		default:
+			continue
+		}
+		changes = append(changes, addedFileChange(file, mode, content))
+	}
+	return changes, nil
+}
+
+// addedFileChange 按 git diff 显示新增文件的格式生成文件改动：整个文件为一个改动块，每行都是新增的行；
+// 与 git 相同，开头含有 NUL 字节的内容视为二进制文件，没有改动块
+func addedFileChange(filePath, mode string, content []byte) types.FileChange {
+	change := types.FileChange{FilePath: filePath, ChangeType: "added"}
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "diff --git a/%s b/%s\nnew file mode %s\n", filePath, filePath, mode)
+	if isBinary, _ := binary.IsBinary(bytes.NewReader(content)); isBinary {
+		change.Binary = true
+		fmt.Fprintf(&diff, "Binary files %s and b/%s differ\n", devNull, filePath)
+		change.DiffContent = diff.String()
+		return change
+	}
+
+	text := string(content)
+	change.NewContent = text
+	change.Lines = strings.Split(text, "\n")
+	if text == "" {
+		change.DiffContent = diff.String()
+		return change
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	hunk := types.DiffHunk{NewStart: 1, NewLines: len(lines)}
+	for i, line := range lines {
+		hunk.Lines = append(hunk.Lines, types.DiffLine{
+			Kind:      '+',
+			Content:   strings.TrimRight(line, "\r\n"),
+			NewLine:   i + 1,
+			NoNewline: !strings.HasSuffix(line, "\n"),
+		})
+	}
+	change.Hunks = []types.DiffHunk{hunk}
+
+	fmt.Fprintf(&diff, "--- %s\n+++ b/%s\n%s\n", devNull, filePath, hunk.Header())
+	for _, line := range lines {
+		diff.WriteString("+" + line)
+	}
+	if !strings.HasSuffix(text, "\n") {
+		diff.WriteString("\n\\ No newline at end of file\n")
+	}
+	change.DiffContent = diff.String()
+	return change
+}
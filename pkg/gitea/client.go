@@ -0,0 +1,117 @@
+package gitea
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Client Gitea/Forgejo REST API 客户端，两者的接口兼容
+type Client struct {
+	baseURL string
+	token   string
+	owner   string
+	repo    string
+	client  *http.Client
+}
+
+// NewClient 创建访问指定仓库的客户端，baseURL 为实例地址（如 https://gitea.example.com），repo 形如 owner/name
+func NewClient(baseURL, token, repo string) (*Client, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("未指定 Gitea 实例地址")
+	}
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || owner == "" || name == "" {
+		return nil, fmt.Errorf("仓库名称格式应为 owner/name：%s", repo)
+	}
+	baseURL = strings.TrimRight(baseURL, "/")
+	if !strings.HasSuffix(baseURL, "/api/v1") {
+		baseURL += "/api/v1"
+	}
+	return &Client{
+		baseURL: baseURL,
+		token:   token,
+		owner:   owner,
+		repo:    name,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// NewClientFromEnv 使用 GITEA_TOKEN 环境变量创建客户端
+// baseURL 为空时读取 GITEA_URL，在 Gitea Actions 中使用 GITHUB_SERVER_URL；repo 为空时使用 GITHUB_REPOSITORY
+func NewClientFromEnv(baseURL, repo string) (*Client, error) {
+	token := os.Getenv("GITEA_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("未设置 GITEA_TOKEN 环境变量（需要具有仓库写权限的访问令牌）")
+	}
+	if baseURL == "" {
+		baseURL = os.Getenv("GITEA_URL")
+	}
+	if baseURL == "" && os.Getenv("GITEA_ACTIONS") == "true" {
+		baseURL = os.Getenv("GITHUB_SERVER_URL")
+	}
+	if baseURL == "" {
+		return nil, fmt.Errorf("未指定 Gitea 实例地址，请通过 --url 或 GITEA_URL 环境变量指定")
+	}
+	if repo == "" {
+		repo = os.Getenv("GITHUB_REPOSITORY")
+	}
+	return NewClient(baseURL, token, repo)
+}
+
+// Repo 返回 owner/name 形式的仓库名称
+func (c *Client) Repo() string {
+	return c.owner + "/" + c.repo
+}
+
+// do 发送API请求，body 和 out 为 nil 时分别表示不发送请求体、不解析响应
+// out 为 *string 时保存原始响应内容
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "token "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求 Gitea 失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取 Gitea 响应失败: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Gitea API %s %s 返回 %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if raw, ok := out.(*string); ok {
+		*raw = string(data)
+		return nil
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("解析 Gitea 响应失败: %v", err)
+		}
+	}
+	return nil
+}
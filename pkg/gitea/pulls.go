@@ -0,0 +1,105 @@
+package gitea
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// pageSize 分页接口每页的条数，Gitea 默认的上限为 50
+const pageSize = 50
+
+// PullRequest Gitea pull request
+type PullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Head    struct {
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		SHA string `json:"sha"`
+	} `json:"base"`
+}
+
+// ReviewComment 评审中的行内评论，NewPosition 为新文件的行号，OldPosition 为旧文件的行号（评论删除的行时使用）
+type ReviewComment struct {
+	Path        string `json:"path"`
+	Body        string `json:"body"`
+	NewPosition int    `json:"new_position,omitempty"`
+	OldPosition int    `json:"old_position,omitempty"`
+}
+
+// NewReview 创建评审的请求参数，Event 为 COMMENT、APPROVED 或 REQUEST_CHANGES
+type NewReview struct {
+	CommitID string          `json:"commit_id,omitempty"`
+	Body     string          `json:"body,omitempty"`
+	Event    string          `json:"event"`
+	Comments []ReviewComment `json:"comments,omitempty"`
+}
+
+// Review pull request 上的评审
+type Review struct {
+	ID      int64  `json:"id"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+// GetPullRequest 获取 pull request
+func (c *Client) GetPullRequest(number int) (*PullRequest, error) {
+	var pr PullRequest
+	path := fmt.Sprintf("/repos/%s/%s/pulls/%d", c.owner, c.repo, number)
+	if err := c.do("GET", path, nil, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// GetPullRequestDiff 获取 pull request 的完整统一差异（包含各文件的 diff --git 文件头）
+// Gitea 列出改动文件的接口不返回差异内容，行内评论的位置需要从完整差异中计算
+func (c *Client) GetPullRequestDiff(number int) (string, error) {
+	var diff string
+	path := fmt.Sprintf("/repos/%s/%s/pulls/%d.diff", c.owner, c.repo, number)
+	if err := c.do("GET", path, nil, &diff); err != nil {
+		return "", err
+	}
+	return diff, nil
+}
+
+// ListReviews 列出 pull request 上已有的评审
+func (c *Client) ListReviews(number int) ([]Review, error) {
+	var all []Review
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("limit", fmt.Sprint(pageSize))
+		query.Set("page", fmt.Sprint(page))
+
+		var reviews []Review
+		path := fmt.Sprintf("/repos/%s/%s/pulls/%d/reviews?%s", c.owner, c.repo, number, query.Encode())
+		if err := c.do("GET", path, nil, &reviews); err != nil {
+			return nil, err
+		}
+		all = append(all, reviews...)
+		if len(reviews) < pageSize {
+			return all, nil
+		}
+	}
+}
+
+// ListReviewComments 列出一次评审中的行内评论
+func (c *Client) ListReviewComments(number int, reviewID int64) ([]ReviewComment, error) {
+	var comments []ReviewComment
+	path := fmt.Sprintf("/repos/%s/%s/pulls/%d/reviews/%d/comments", c.owner, c.repo, number, reviewID)
+	if err := c.do("GET", path, nil, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// CreateReview 在 pull request 上创建评审，可以同时包含多条行内评论
+func (c *Client) CreateReview(number int, review NewReview) (*Review, error) {
+	var created Review
+	path := fmt.Sprintf("/repos/%s/%s/pulls/%d/reviews", c.owner, c.repo, number)
+	if err := c.do("POST", path, review, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
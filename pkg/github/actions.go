@@ -0,0 +1,100 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// zeroSHA push 事件中新建分支时 before 的取值
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+// ActionsRange 根据 GitHub Actions 的事件确定需要评审的提交范围
+// pull_request 事件返回 base...head（与合并基础比较），push 事件返回 before..after；
+// 事件中没有提交信息但能确定 PR 编号时（如 issue_comment），使用 GITHUB_TOKEN 查询 PR
+func ActionsRange() (string, error) {
+	eventPath := os.Getenv("GITHUB_EVENT_PATH")
+	if eventPath == "" {
+		return "", fmt.Errorf("未设置 GITHUB_EVENT_PATH，--ci github 只能在 GitHub Actions 中使用")
+	}
+	data, err := os.ReadFile(eventPath)
+	if err != nil {
+		return "", fmt.Errorf("读取 GitHub Actions 事件失败: %v", err)
+	}
+	var event struct {
+		PullRequest *PullRequest `json:"pull_request"`
+		Before      string       `json:"before"`
+		After       string       `json:"after"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return "", fmt.Errorf("解析 GitHub Actions 事件失败: %v", err)
+	}
+
+	switch {
+	case event.PullRequest != nil && event.PullRequest.Base.SHA != "" && event.PullRequest.Head.SHA != "":
+		return event.PullRequest.Base.SHA + "..." + event.PullRequest.Head.SHA, nil
+	case event.Before != "" && event.Before != zeroSHA && event.After != "":
+		return event.Before + ".." + event.After, nil
+	}
+
+	number, err := PullNumberFromEnv()
+	if err != nil {
+		return "", fmt.Errorf("无法从 GitHub Actions 事件中确定评审范围，请通过 --commit-range 指定")
+	}
+	client, err := NewClientFromEnv("")
+	if err != nil {
+		return "", err
+	}
+	pr, err := client.GetPullRequest(number)
+	if err != nil {
+		return "", fmt.Errorf("获取 pull request #%d 失败: %v", number, err)
+	}
+	return pr.Base.SHA + "..." + pr.Head.SHA, nil
+}
+
+// Annotation 生成 GitHub Actions 的工作流命令，如 ::error file=a.go,line=3,title=...::message，
+// level 为 error、warning 或 notice；line 小于 1 时只标注文件，file 为空时不关联文件
+func Annotation(level, file string, line int, title, message string) string {
+	var props []string
+	if file != "" {
+		props = append(props, "file="+escapeProperty(file))
+		if line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", line))
+		}
+	}
+	if title != "" {
+		props = append(props, "title="+escapeProperty(title))
+	}
+	if len(props) == 0 {
+		return fmt.Sprintf("::%s::%s", level, escapeData(message))
+	}
+	return fmt.Sprintf("::%s %s::%s", level, strings.Join(props, ","), escapeData(message))
+}
+
+// escapeData 转义工作流命令消息中的特殊字符
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty 转义工作流命令属性值中的特殊字符
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// AppendStepSummary 把 Markdown 内容追加到 GITHUB_STEP_SUMMARY 指向的作业摘要，未设置该变量时返回 false
+func AppendStepSummary(content []byte) (bool, error) {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return false, nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return false, fmt.Errorf("打开作业摘要文件失败: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(content); err != nil {
+		return false, fmt.Errorf("写入作业摘要失败: %v", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,103 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL GitHub REST API 地址，GitHub Enterprise 可通过 GITHUB_API_URL 覆盖
+const DefaultAPIURL = "https://api.github.com"
+
+// Client GitHub REST API 客户端
+type Client struct {
+	baseURL string
+	token   string
+	owner   string
+	repo    string
+	client  *http.Client
+}
+
+// NewClient 创建访问指定仓库的客户端，repo 形如 owner/name
+func NewClient(baseURL, token, repo string) (*Client, error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || owner == "" || name == "" {
+		return nil, fmt.Errorf("仓库名称格式应为 owner/name：%s", repo)
+	}
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		owner:   owner,
+		repo:    name,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// NewClientFromEnv 使用 GITHUB_TOKEN、GITHUB_API_URL 环境变量创建客户端
+// repo 为空时使用 GitHub Actions 提供的 GITHUB_REPOSITORY
+func NewClientFromEnv(repo string) (*Client, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("未设置 GITHUB_TOKEN 环境变量")
+	}
+	if repo == "" {
+		repo = os.Getenv("GITHUB_REPOSITORY")
+	}
+	return NewClient(os.Getenv("GITHUB_API_URL"), token, repo)
+}
+
+// Repo 返回 owner/name 形式的仓库名称
+func (c *Client) Repo() string {
+	return c.owner + "/" + c.repo
+}
+
+// do 发送API请求，body 和 out 为 nil 时分别表示不发送请求体、不解析响应
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求 GitHub 失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取 GitHub 响应失败: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("GitHub API %s %s 返回 %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("解析 GitHub 响应失败: %v", err)
+		}
+	}
+	return nil
+}
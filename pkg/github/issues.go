@@ -0,0 +1,58 @@
+package github
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Issue GitHub issue
+type Issue struct {
+	Number  int    `json:"number,omitempty"`
+	Title   string `json:"title"`
+	Body    string `json:"body,omitempty"`
+	HTMLURL string `json:"html_url,omitempty"`
+	State   string `json:"state,omitempty"`
+}
+
+// NewIssue 创建 issue 的请求参数
+type NewIssue struct {
+	Title     string   `json:"title"`
+	Body      string   `json:"body,omitempty"`
+	Labels    []string `json:"labels,omitempty"`
+	Assignees []string `json:"assignees,omitempty"`
+}
+
+// CreateIssue 创建 issue
+func (c *Client) CreateIssue(issue NewIssue) (*Issue, error) {
+	var created Issue
+	path := fmt.Sprintf("/repos/%s/%s/issues", c.owner, c.repo)
+	if err := c.do("POST", path, issue, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// ListOpenIssues 列出带有全部指定标签的未关闭 issue
+func (c *Client) ListOpenIssues(labels []string) ([]Issue, error) {
+	var all []Issue
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("state", "open")
+		query.Set("per_page", "100")
+		query.Set("page", fmt.Sprint(page))
+		if len(labels) > 0 {
+			query.Set("labels", strings.Join(labels, ","))
+		}
+
+		var issues []Issue
+		path := fmt.Sprintf("/repos/%s/%s/issues?%s", c.owner, c.repo, query.Encode())
+		if err := c.do("GET", path, nil, &issues); err != nil {
+			return nil, err
+		}
+		all = append(all, issues...)
+		if len(issues) < 100 {
+			return all, nil
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PullRequest GitHub pull request
+type PullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	Head    struct {
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		SHA string `json:"sha"`
+	} `json:"base"`
+}
+
+// PullRequestFile pull request 中改动的文件，Patch 为该文件的统一差异（不含文件头），二进制或过大的文件为空
+type PullRequestFile struct {
+	Filename string `json:"filename"`
+	Status   string `json:"status"`
+	Patch    string `json:"patch,omitempty"`
+}
+
+// ReviewComment 评审中的行内评论，Position 为评论行在该文件差异中的位置
+type ReviewComment struct {
+	Path     string `json:"path"`
+	Position int    `json:"position"`
+	Body     string `json:"body"`
+	// 评论收到的表情回应，只在列出评论时返回
+	Reactions *Reactions `json:"reactions,omitempty"`
+}
+
+// Reactions 评论收到的表情回应数
+type Reactions struct {
+	PlusOne  int `json:"+1"`
+	MinusOne int `json:"-1"`
+}
+
+// NewReview 创建评审的请求参数，Event 为 COMMENT、APPROVE 或 REQUEST_CHANGES
+type NewReview struct {
+	CommitID string          `json:"commit_id,omitempty"`
+	Body     string          `json:"body,omitempty"`
+	Event    string          `json:"event"`
+	Comments []ReviewComment `json:"comments,omitempty"`
+}
+
+// Review 已创建的评审
+type Review struct {
+	ID      int64  `json:"id"`
+	HTMLURL string `json:"html_url"`
+}
+
+// GetPullRequest 获取 pull request
+func (c *Client) GetPullRequest(number int) (*PullRequest, error) {
+	var pr PullRequest
+	path := fmt.Sprintf("/repos/%s/%s/pulls/%d", c.owner, c.repo, number)
+	if err := c.do("GET", path, nil, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// ListPullRequestFiles 列出 pull request 中改动的文件
+func (c *Client) ListPullRequestFiles(number int) ([]PullRequestFile, error) {
+	var all []PullRequestFile
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("per_page", "100")
+		query.Set("page", fmt.Sprint(page))
+
+		var files []PullRequestFile
+		path := fmt.Sprintf("/repos/%s/%s/pulls/%d/files?%s", c.owner, c.repo, number, query.Encode())
+		if err := c.do("GET", path, nil, &files); err != nil {
+			return nil, err
+		}
+		all = append(all, files...)
+		if len(files) < 100 {
+			return all, nil
+		}
+	}
+}
+
+// ListReviewComments 列出 pull request 中已有的行内评论
+func (c *Client) ListReviewComments(number int) ([]ReviewComment, error) {
+	var all []ReviewComment
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("per_page", "100")
+		query.Set("page", fmt.Sprint(page))
+
+		var comments []ReviewComment
+		path := fmt.Sprintf("/repos/%s/%s/pulls/%d/comments?%s", c.owner, c.repo, number, query.Encode())
+		if err := c.do("GET", path, nil, &comments); err != nil {
+			return nil, err
+		}
+		all = append(all, comments...)
+		if len(comments) < 100 {
+			return all, nil
+		}
+	}
+}
+
+// CreateReview 在 pull request 上创建评审，行内评论随评审一起提交
+func (c *Client) CreateReview(number int, review NewReview) (*Review, error) {
+	var created Review
+	path := fmt.Sprintf("/repos/%s/%s/pulls/%d/reviews", c.owner, c.repo, number)
+	if err := c.do("POST", path, review, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// PullNumberFromEnv 在 GitHub Actions 中获取当前 pull request 的编号
+// 优先读取 GITHUB_EVENT_PATH 指向的事件内容，其次解析形如 refs/pull/123/merge 的 GITHUB_REF
+func PullNumberFromEnv() (int, error) {
+	if eventPath := os.Getenv("GITHUB_EVENT_PATH"); eventPath != "" {
+		if data, err := os.ReadFile(eventPath); err == nil {
+			var event struct {
+				Number      int `json:"number"`
+				PullRequest struct {
+					Number int `json:"number"`
+				} `json:"pull_request"`
+			}
+			if json.Unmarshal(data, &event) == nil {
+				if event.PullRequest.Number > 0 {
+					return event.PullRequest.Number, nil
+				}
+				if event.Number > 0 {
+					return event.Number, nil
+				}
+			}
+		}
+	}
+	if ref := os.Getenv("GITHUB_REF"); strings.HasPrefix(ref, "refs/pull/") {
+		number, _, _ := strings.Cut(strings.TrimPrefix(ref, "refs/pull/"), "/")
+		if n, err := strconv.Atoi(number); err == nil && n > 0 {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("无法从 GitHub Actions 环境中获取 pull request 编号，请通过 --pr 指定")
+}
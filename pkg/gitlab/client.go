@@ -0,0 +1,104 @@
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL GitLab REST API 地址，自建实例可通过 CI_API_V4_URL 覆盖
+const DefaultAPIURL = "https://gitlab.com/api/v4"
+
+// Client GitLab REST API 客户端
+type Client struct {
+	baseURL string
+	token   string
+	project string
+	client  *http.Client
+}
+
+// NewClient 创建访问指定项目的客户端，project 为项目 ID 或 group/name 形式的路径
+func NewClient(baseURL, token, project string) (*Client, error) {
+	if project == "" {
+		return nil, fmt.Errorf("未指定 GitLab 项目")
+	}
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		project: project,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// NewClientFromEnv 使用 GITLAB_TOKEN、CI_API_V4_URL 环境变量创建客户端
+// project 为空时使用 GitLab CI 提供的 CI_PROJECT_ID
+func NewClientFromEnv(project string) (*Client, error) {
+	token := os.Getenv("GITLAB_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("未设置 GITLAB_TOKEN 环境变量（需要具有 api 权限的访问令牌）")
+	}
+	if project == "" {
+		project = os.Getenv("CI_PROJECT_ID")
+	}
+	return NewClient(os.Getenv("CI_API_V4_URL"), token, project)
+}
+
+// Project 返回项目 ID 或路径
+func (c *Client) Project() string {
+	return c.project
+}
+
+// projectPath 返回项目相关接口的路径前缀，项目路径中的 / 需要转义
+func (c *Client) projectPath() string {
+	return "/projects/" + url.PathEscape(c.project)
+}
+
+// do 发送API请求，body 和 out 为 nil 时分别表示不发送请求体、不解析响应
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("序列化请求失败: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求 GitLab 失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取 GitLab 响应失败: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("GitLab API %s %s 返回 %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("解析 GitLab 响应失败: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// MergeRequest GitLab merge request
+type MergeRequest struct {
+	IID      int    `json:"iid"`
+	WebURL   string `json:"web_url"`
+	DiffRefs struct {
+		BaseSHA  string `json:"base_sha"`
+		HeadSHA  string `json:"head_sha"`
+		StartSHA string `json:"start_sha"`
+	} `json:"diff_refs"`
+}
+
+// MergeRequestDiff merge request 中一个文件的差异，Diff 为统一差异（不含文件头），二进制或过大的文件为空
+type MergeRequestDiff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	Diff        string `json:"diff"`
+	NewFile     bool   `json:"new_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+// Position 行内讨论在差异中的位置；新增的行只有 NewLine，未改动的上下文行需要同时指定 OldLine 和 NewLine
+type Position struct {
+	PositionType string `json:"position_type"`
+	BaseSHA      string `json:"base_sha"`
+	StartSHA     string `json:"start_sha"`
+	HeadSHA      string `json:"head_sha"`
+	OldPath      string `json:"old_path"`
+	NewPath      string `json:"new_path"`
+	OldLine      int    `json:"old_line,omitempty"`
+	NewLine      int    `json:"new_line,omitempty"`
+}
+
+// NewDiscussion 创建讨论的请求参数，Position 为空时是普通讨论
+type NewDiscussion struct {
+	Body     string    `json:"body"`
+	Position *Position `json:"position,omitempty"`
+}
+
+// Note 讨论中的一条评论
+type Note struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// Discussion merge request 上的讨论
+type Discussion struct {
+	ID    string `json:"id"`
+	Notes []Note `json:"notes"`
+}
+
+// AwardEmoji 评论收到的表情回应，Name 如 thumbsup、thumbsdown
+type AwardEmoji struct {
+	Name string `json:"name"`
+}
+
+// GetMergeRequest 获取 merge request
+func (c *Client) GetMergeRequest(iid int) (*MergeRequest, error) {
+	var mr MergeRequest
+	path := fmt.Sprintf("%s/merge_requests/%d", c.projectPath(), iid)
+	if err := c.do("GET", path, nil, &mr); err != nil {
+		return nil, err
+	}
+	return &mr, nil
+}
+
+// ListMergeRequestDiffs 列出 merge request 中改动的文件
+func (c *Client) ListMergeRequestDiffs(iid int) ([]MergeRequestDiff, error) {
+	var all []MergeRequestDiff
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("per_page", "100")
+		query.Set("page", fmt.Sprint(page))
+
+		var diffs []MergeRequestDiff
+		path := fmt.Sprintf("%s/merge_requests/%d/diffs?%s", c.projectPath(), iid, query.Encode())
+		if err := c.do("GET", path, nil, &diffs); err != nil {
+			return nil, err
+		}
+		all = append(all, diffs...)
+		if len(diffs) < 100 {
+			return all, nil
+		}
+	}
+}
+
+// ListDiscussions 列出 merge request 上已有的讨论
+func (c *Client) ListDiscussions(iid int) ([]Discussion, error) {
+	var all []Discussion
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("per_page", "100")
+		query.Set("page", fmt.Sprint(page))
+
+		var discussions []Discussion
+		path := fmt.Sprintf("%s/merge_requests/%d/discussions?%s", c.projectPath(), iid, query.Encode())
+		if err := c.do("GET", path, nil, &discussions); err != nil {
+			return nil, err
+		}
+		all = append(all, discussions...)
+		if len(discussions) < 100 {
+			return all, nil
+		}
+	}
+}
+
+// CreateDiscussion 在 merge request 上创建讨论，指定位置时为差异中的行内讨论
+func (c *Client) CreateDiscussion(iid int, discussion NewDiscussion) (*Discussion, error) {
+	var created Discussion
+	path := fmt.Sprintf("%s/merge_requests/%d/discussions", c.projectPath(), iid)
+	if err := c.do("POST", path, discussion, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// CreateNote 在 merge request 上发表一条评论
+func (c *Client) CreateNote(iid int, body string) (*Note, error) {
+	var created Note
+	path := fmt.Sprintf("%s/merge_requests/%d/notes", c.projectPath(), iid)
+	if err := c.do("POST", path, map[string]string{"body": body}, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// ListNoteAwardEmoji 列出 merge request 上一条评论收到的表情回应
+func (c *Client) ListNoteAwardEmoji(iid int, noteID int64) ([]AwardEmoji, error) {
+	var emoji []AwardEmoji
+	path := fmt.Sprintf("%s/merge_requests/%d/notes/%d/award_emoji?per_page=100", c.projectPath(), iid, noteID)
+	if err := c.do("GET", path, nil, &emoji); err != nil {
+		return nil, err
+	}
+	return emoji, nil
+}
+
+// MergeRequestIIDFromEnv 在 GitLab CI 的 merge request 流水线中获取当前 merge request 的 IID
+func MergeRequestIIDFromEnv() (int, error) {
+	if iid, err := strconv.Atoi(os.Getenv("CI_MERGE_REQUEST_IID")); err == nil && iid > 0 {
+		return iid, nil
+	}
+	return 0, fmt.Errorf("无法从 GitLab CI 环境中获取 merge request 编号（CI_MERGE_REQUEST_IID），请通过 --pr 指定")
+}
@@ -0,0 +1,200 @@
+// Package history 记录每次评审的元数据、问题、token 用量和费用，供 cr history 和服务模式的评审历史页面查询
+//
+// 默认保存在 SQLite 数据库中；其他存储实现 Store 接口后通过 Register 注册，以 "<名称>:<位置>" 的形式选用
+package history
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/review"
+)
+
+// Disabled 表示不记录评审历史的存储位置
+const Disabled = "off"
+
+// ErrNotFound 评审记录不存在
+var ErrNotFound = errors.New("评审记录不存在")
+
+// Run 一次评审的记录
+type Run struct {
+	ID string `json:"id"`
+	// 仓库：命令行评审时为项目名称，服务模式下形如 github:owner/name
+	Repo string `json:"repo"`
+	// 评审的 pull request（如 owner/name#12）及其页面地址，本地评审时为空
+	Change string `json:"change,omitempty"`
+	URL    string `json:"url,omitempty"`
+	// 评审的提交、提交范围或分支
+	Ref string `json:"ref,omitempty"`
+	// 发起评审的方式：cli、batch、webhook 或 api
+	Trigger    string         `json:"trigger"`
+	Model      string         `json:"model,omitempty"`
+	ReviewedAt time.Time      `json:"reviewed_at"`
+	DurationMS int64          `json:"duration_ms,omitempty"`
+	Files      int            `json:"files"`
+	Issues     int            `json:"issues"`
+	BySeverity map[string]int `json:"by_severity,omitempty"`
+	Score      int            `json:"score"`
+	Grade      string         `json:"grade"`
+	// token 用量和估算的费用，未配置模型单价时费用为 0
+	PromptTokens     int     `json:"prompt_tokens,omitempty"`
+	CompletionTokens int     `json:"completion_tokens,omitempty"`
+	CachedTokens     int     `json:"cached_tokens,omitempty"`
+	Cost             float64 `json:"cost,omitempty"`
+	Currency         string  `json:"currency,omitempty"`
+	// 质量门禁结果：passed、failed，未检查门禁时为空
+	Gate string `json:"gate,omitempty"`
+	// 评审发现的问题，列出评审记录时不读取
+	Findings []Issue `json:"findings,omitempty"`
+}
+
+// Issue 评审记录中的一个问题
+type Issue struct {
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Severity    string `json:"severity"`
+	Category    string `json:"category,omitempty"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Suggestion  string `json:"suggestion,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// RepoSummary 仓库的评审概况
+type RepoSummary struct {
+	Repo    string `json:"repo"`
+	Reviews int    `json:"reviews"`
+	// 最近一次评审
+	Latest Run `json:"latest"`
+}
+
+// Filter 查询评审记录的条件，零值表示不限制
+type Filter struct {
+	Repo  string
+	Since time.Time
+	Limit int
+}
+
+// Store 评审历史的存储，实现需要支持多个 goroutine 并发调用
+type Store interface {
+	// Record 保存一次评审及其 JSON 报告，run.ID 为空时自动生成
+	Record(run *Run, report []byte) error
+	// Runs 按条件列出评审记录，最近的在前，不包含问题列表
+	Runs(filter Filter) ([]Run, error)
+	// Get 读取评审记录及其问题列表，不存在时返回 ErrNotFound
+	Get(id string) (*Run, error)
+	// Report 读取评审的 JSON 报告，不存在时返回 ErrNotFound
+	Report(id string) ([]byte, error)
+	// Repos 列出有评审记录的仓库，最近评审过的在前
+	Repos() ([]RepoSummary, error)
+	// Prune 只保留仓库最近的 keep 次评审
+	Prune(repo string, keep int) error
+	Close() error
+}
+
+// Opener 根据存储位置打开评审历史
+type Opener func(location string) (Store, error)
+
+var (
+	openersMu sync.Mutex
+	openers   = map[string]Opener{"sqlite": openSQLite}
+)
+
+// Register 注册一种存储，之后可以用 "<name>:<位置>" 选用
+func Register(name string, open Opener) {
+	openersMu.Lock()
+	defer openersMu.Unlock()
+	openers[name] = open
+}
+
+// DefaultPath 返回默认的 SQLite 数据库路径 ~/.cr/history.db
+func DefaultPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".cr", "history.db")
+}
+
+// Open 打开评审历史：dsn 为空时使用默认路径的 SQLite 数据库，"<名称>:<位置>" 使用注册的存储，
+// 其余的值作为 SQLite 数据库的路径
+func Open(dsn string) (Store, error) {
+	if dsn == Disabled {
+		return nil, fmt.Errorf("评审历史已关闭")
+	}
+	if dsn == "" {
+		return openSQLite(DefaultPath())
+	}
+	// 单个字母的前缀是 Windows 的盘符
+	if name, location, ok := strings.Cut(dsn, ":"); ok && len(name) > 1 {
+		openersMu.Lock()
+		open, found := openers[name]
+		openersMu.Unlock()
+		if !found {
+			return nil, fmt.Errorf("不支持的评审历史存储: %s，可选值：%s", name, strings.Join(storeNames(), ", "))
+		}
+		return open(location)
+	}
+	return openSQLite(dsn)
+}
+
+// storeNames 返回已注册的存储名称
+func storeNames() []string {
+	openersMu.Lock()
+	defer openersMu.Unlock()
+	names := make([]string, 0, len(openers))
+	for name := range openers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FromReport 根据 JSON 报告生成评审记录，仓库、触发方式等信息由调用方填写
+func FromReport(report []byte) (*Run, error) {
+	parsed, err := review.ParseJSONReport(report)
+	if err != nil {
+		return nil, err
+	}
+	s := parsed.Summary
+	run := &Run{
+		Ref:              parsed.Commit,
+		Model:            s.Model,
+		ReviewedAt:       parsed.GeneratedAt,
+		DurationMS:       s.DurationMS,
+		Files:            s.Files,
+		Issues:           s.Issues,
+		BySeverity:       s.BySeverity,
+		Score:            s.Score,
+		Grade:            s.Grade,
+		PromptTokens:     s.PromptTokens,
+		CompletionTokens: s.CompletionTokens,
+		CachedTokens:     s.CachedTokens,
+		Cost:             s.Cost,
+		Currency:         s.Currency,
+	}
+	for _, issue := range parsed.Issues {
+		run.Findings = append(run.Findings, Issue{
+			File:        issue.File,
+			Line:        issue.Line,
+			Severity:    issue.Severity,
+			Category:    issue.Category,
+			Title:       issue.Title,
+			Description: issue.Description,
+			Suggestion:  issue.Suggestion,
+			Fingerprint: issue.Fingerprint,
+		})
+	}
+	return run, nil
+}
+
+// newID 生成评审记录的 ID，按时间排序
+func newID(at time.Time) string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return at.UTC().Format("20060102150405") + "-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,328 @@
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	// 纯 Go 实现的 SQLite 驱动，不需要 cgo
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchemaVersion 数据库结构版本，记录在 PRAGMA user_version 中
+const sqliteSchemaVersion = 1
+
+// sqliteSchema 数据库结构，时间以 Unix 毫秒保存
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id                TEXT PRIMARY KEY,
+	repo              TEXT NOT NULL,
+	change            TEXT NOT NULL DEFAULT '',
+	url               TEXT NOT NULL DEFAULT '',
+	ref               TEXT NOT NULL DEFAULT '',
+	trigger           TEXT NOT NULL DEFAULT '',
+	model             TEXT NOT NULL DEFAULT '',
+	reviewed_at       INTEGER NOT NULL,
+	duration_ms       INTEGER NOT NULL DEFAULT 0,
+	files             INTEGER NOT NULL DEFAULT 0,
+	issues            INTEGER NOT NULL DEFAULT 0,
+	by_severity       TEXT NOT NULL DEFAULT '{}',
+	score             INTEGER NOT NULL DEFAULT 0,
+	grade             TEXT NOT NULL DEFAULT '',
+	prompt_tokens     INTEGER NOT NULL DEFAULT 0,
+	completion_tokens INTEGER NOT NULL DEFAULT 0,
+	cached_tokens     INTEGER NOT NULL DEFAULT 0,
+	cost              REAL NOT NULL DEFAULT 0,
+	currency          TEXT NOT NULL DEFAULT '',
+	gate              TEXT NOT NULL DEFAULT '',
+	report            BLOB
+);
+CREATE INDEX IF NOT EXISTS runs_repo ON runs (repo, reviewed_at);
+CREATE INDEX IF NOT EXISTS runs_reviewed_at ON runs (reviewed_at);
+CREATE TABLE IF NOT EXISTS issues (
+	run_id      TEXT NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+	seq         INTEGER NOT NULL,
+	file        TEXT NOT NULL,
+	line        INTEGER NOT NULL DEFAULT 0,
+	severity    TEXT NOT NULL,
+	category    TEXT NOT NULL DEFAULT '',
+	title       TEXT NOT NULL DEFAULT '',
+	description TEXT NOT NULL DEFAULT '',
+	suggestion  TEXT NOT NULL DEFAULT '',
+	fingerprint TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY (run_id, seq)
+);
+CREATE INDEX IF NOT EXISTS issues_fingerprint ON issues (fingerprint);
+`
+
+// runColumns 列出评审记录时读取的列，顺序与 scanRun 一致
+const runColumns = `id, repo, change, url, ref, trigger, model, reviewed_at, duration_ms, files, issues, by_severity,
+	score, grade, prompt_tokens, completion_tokens, cached_tokens, cost, currency, gate`
+
+// sqliteStore 保存在 SQLite 数据库中的评审历史
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// openSQLite 打开或创建 SQLite 数据库
+func openSQLite(path string) (Store, error) {
+	if path == "" {
+		return nil, fmt.Errorf("未指定评审历史数据库的路径")
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("创建评审历史目录失败: %v", err)
+		}
+	}
+	// 命令行评审和服务可能同时写入，WAL 模式下读写互不阻塞，写入冲突时等待
+	dsn := (&url.URL{Scheme: "file", Opaque: filepath.ToSlash(path)}).String() +
+		"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("打开评审历史数据库失败: %v", err)
+	}
+	// 同一进程内的写入排队执行，避免 SQLite 返回 database is locked
+	db.SetMaxOpenConns(1)
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqliteStore{db: db}, nil
+}
+
+// migrateSQLite 创建或升级数据库结构
+func migrateSQLite(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("打开评审历史数据库失败: %v", err)
+	}
+	if version > sqliteSchemaVersion {
+		return fmt.Errorf("评审历史数据库由更新版本的工具创建（结构版本 %d），请升级工具", version)
+	}
+	if version == sqliteSchemaVersion {
+		return nil
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("初始化评审历史数据库失败: %v", err)
+	}
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", sqliteSchemaVersion)); err != nil {
+		return fmt.Errorf("初始化评审历史数据库失败: %v", err)
+	}
+	return nil
+}
+
+// Record 保存一次评审及其问题和 JSON 报告
+func (s *sqliteStore) Record(run *Run, report []byte) error {
+	if run.ReviewedAt.IsZero() {
+		run.ReviewedAt = time.Now()
+	}
+	if run.ID == "" {
+		run.ID = newID(run.ReviewedAt)
+	}
+	bySeverity, err := json.Marshal(run.BySeverity)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("保存评审历史失败: %v", err)
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec(`INSERT INTO runs (`+runColumns+`, report) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.ID, run.Repo, run.Change, run.URL, run.Ref, run.Trigger, run.Model, run.ReviewedAt.UnixMilli(), run.DurationMS,
+		run.Files, run.Issues, string(bySeverity), run.Score, run.Grade, run.PromptTokens, run.CompletionTokens,
+		run.CachedTokens, run.Cost, run.Currency, run.Gate, report)
+	if err != nil {
+		return fmt.Errorf("保存评审历史失败: %v", err)
+	}
+	stmt, err := tx.Prepare(`INSERT INTO issues (run_id, seq, file, line, severity, category, title, description, suggestion, fingerprint)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("保存评审历史失败: %v", err)
+	}
+	defer stmt.Close()
+	for i, issue := range run.Findings {
+		if _, err := stmt.Exec(run.ID, i, issue.File, issue.Line, issue.Severity, issue.Category, issue.Title,
+			issue.Description, issue.Suggestion, issue.Fingerprint); err != nil {
+			return fmt.Errorf("保存评审历史失败: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("保存评审历史失败: %v", err)
+	}
+	return nil
+}
+
+// Runs 按条件列出评审记录，最近的在前
+func (s *sqliteStore) Runs(filter Filter) ([]Run, error) {
+	var where []string
+	var args []interface{}
+	if filter.Repo != "" {
+		where = append(where, "repo = ?")
+		args = append(args, filter.Repo)
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, "reviewed_at >= ?")
+		args = append(args, filter.Since.UnixMilli())
+	}
+	query := "SELECT " + runColumns + " FROM runs"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY reviewed_at DESC, id DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询评审历史失败: %v", err)
+	}
+	defer rows.Close()
+	var runs []Run
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询评审历史失败: %v", err)
+	}
+	return runs, nil
+}
+
+// Get 读取评审记录及其问题列表
+func (s *sqliteStore) Get(id string) (*Run, error) {
+	run, err := scanRun(s.db.QueryRow("SELECT "+runColumns+" FROM runs WHERE id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(`SELECT file, line, severity, category, title, description, suggestion, fingerprint
+		FROM issues WHERE run_id = ? ORDER BY seq`, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询评审历史失败: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var issue Issue
+		if err := rows.Scan(&issue.File, &issue.Line, &issue.Severity, &issue.Category, &issue.Title,
+			&issue.Description, &issue.Suggestion, &issue.Fingerprint); err != nil {
+			return nil, fmt.Errorf("查询评审历史失败: %v", err)
+		}
+		run.Findings = append(run.Findings, issue)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询评审历史失败: %v", err)
+	}
+	return run, nil
+}
+
+// Report 读取评审的 JSON 报告
+func (s *sqliteStore) Report(id string) ([]byte, error) {
+	var report []byte
+	err := s.db.QueryRow("SELECT report FROM runs WHERE id = ?", id).Scan(&report)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && len(report) == 0) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询评审历史失败: %v", err)
+	}
+	return report, nil
+}
+
+// Repos 列出有评审记录的仓库及其最近一次评审
+func (s *sqliteStore) Repos() ([]RepoSummary, error) {
+	rows, err := s.db.Query(`SELECT c.reviews, ` + prefixColumns("r.") + `
+		FROM runs r JOIN (SELECT repo, COUNT(*) AS reviews, MAX(reviewed_at) AS latest FROM runs GROUP BY repo) c
+		ON r.repo = c.repo AND r.reviewed_at = c.latest
+		ORDER BY r.reviewed_at DESC, r.id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("查询评审历史失败: %v", err)
+	}
+	defer rows.Close()
+	var repos []RepoSummary
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var reviews int
+		run, err := scanRun(extraColumns{rows, []interface{}{&reviews}})
+		if err != nil {
+			return nil, err
+		}
+		// 同一时刻的多次评审只取一次
+		if seen[run.Repo] {
+			continue
+		}
+		seen[run.Repo] = true
+		repos = append(repos, RepoSummary{Repo: run.Repo, Reviews: reviews, Latest: *run})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询评审历史失败: %v", err)
+	}
+	return repos, nil
+}
+
+// Prune 删除仓库中超出保留数量的最早记录
+func (s *sqliteStore) Prune(repo string, keep int) error {
+	_, err := s.db.Exec(`DELETE FROM runs WHERE repo = ? AND id NOT IN
+		(SELECT id FROM runs WHERE repo = ? ORDER BY reviewed_at DESC, id DESC LIMIT ?)`, repo, repo, keep)
+	if err != nil {
+		return fmt.Errorf("整理评审历史失败: %v", err)
+	}
+	return nil
+}
+
+// Close 关闭数据库
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
+
+// rowScanner sql.Row 和 sql.Rows 共有的方法
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// extraColumns 读取 runColumns 之前的其他列
+type extraColumns struct {
+	row  rowScanner
+	dest []interface{}
+}
+
+func (e extraColumns) Scan(dest ...interface{}) error {
+	return e.row.Scan(append(e.dest, dest...)...)
+}
+
+// scanRun 读取一条评审记录，列的顺序与 runColumns 一致
+func scanRun(row rowScanner) (*Run, error) {
+	var run Run
+	var reviewedAt int64
+	var bySeverity string
+	err := row.Scan(&run.ID, &run.Repo, &run.Change, &run.URL, &run.Ref, &run.Trigger, &run.Model, &reviewedAt,
+		&run.DurationMS, &run.Files, &run.Issues, &bySeverity, &run.Score, &run.Grade, &run.PromptTokens,
+		&run.CompletionTokens, &run.CachedTokens, &run.Cost, &run.Currency, &run.Gate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询评审历史失败: %v", err)
+	}
+	run.ReviewedAt = time.UnixMilli(reviewedAt)
+	json.Unmarshal([]byte(bySeverity), &run.BySeverity)
+	return &run, nil
+}
+
+// prefixColumns 为 runColumns 中的每一列加上表别名
+func prefixColumns(prefix string) string {
+	columns := strings.Split(runColumns, ",")
+	for i, column := range columns {
+		columns[i] = prefix + strings.TrimSpace(column)
+	}
+	return strings.Join(columns, ", ")
+}
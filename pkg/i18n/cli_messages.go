@@ -0,0 +1,444 @@
+package i18n
+
+// cliMessages 命令行帮助、错误和进度信息的文本，按界面语言（--locale）显示
+var cliMessages = map[string]map[Lang]string{
+	// 评审命令的选项
+	"cli.flag.files":             {Chinese: "指定要评审的文件列表，多个文件用逗号分隔", English: "Comma-separated list of files to review"},
+	"cli.flag.untracked":         {Chinese: "同时评审工作区中未跟踪的新文件（遵循 .gitignore），未指定评审范围时评审相对 HEAD 的全部未提交改动", English: "Also review untracked files in the working tree (respecting .gitignore); without a scope, reviews all uncommitted changes against HEAD"},
+	"cli.flag.staged":            {Chinese: "只评审已暂存(git add)的改动", English: "Review only staged changes (git add)"},
+	"cli.flag.commit":            {Chinese: "评审指定的提交", English: "Review the given commit"},
+	"cli.flag.commit-range":      {Chinese: "指定要评审的提交范围，例如：HEAD~1..HEAD", English: "Commit range to review, e.g. HEAD~1..HEAD"},
+	"cli.flag.diff-file":         {Chinese: "评审统一差异格式（git diff 输出）的补丁文件，- 表示标准输入", English: "Review a patch file in unified diff format (git diff output), - for standard input"},
+	"cli.flag.format":            {Chinese: "输出格式：markdown, html, pdf, json, terminal, codequality, rdjson, rdjsonl, template, badge, badge-json, markdown-github（输出到终端时默认为 terminal）", English: "Output format: markdown, html, pdf, json, terminal, codequality, rdjson, rdjsonl, template, badge, badge-json, markdown-github (defaults to terminal when writing to a terminal)"},
+	"cli.flag.output-format":     {Chinese: "同 --format", English: "Same as --format"},
+	"cli.flag.output":            {Chinese: "输出文件路径，默认输出到标准输出", English: "Output file path, defaults to standard output"},
+	"cli.flag.report-template":   {Chinese: "使用 Go text/template 模板生成报告，指定后默认输出格式为 template", English: "Render the report with a Go text/template file; implies --format template"},
+	"cli.flag.badge":             {Chinese: "额外生成显示质量分和问题数的徽章文件，.json 结尾时为 shields.io endpoint 格式，否则为 SVG", English: "Also write a badge with the quality score and issue count; shields.io endpoint JSON when the path ends in .json, SVG otherwise"},
+	"cli.flag.report-url":        {Chinese: "完整报告的链接，markdown-github 格式附在评论末尾，内容被截断时可查看全部问题", English: "Link to the full report, appended to markdown-github comments so truncated content stays reachable"},
+	"cli.flag.snippet-width":     {Chinese: "报告中代码片段每行的最大显示宽度（中日韩字符按两列计算），超出部分折行，过长时截断，0 表示不限制", English: "Maximum display width of each code snippet line (CJK characters count as two columns); longer lines wrap and very long ones are truncated, 0 means unlimited"},
+	"cli.flag.comment-style":     {Chinese: "Markdown 报告和 PR 评论中问题的样式：default，或 conventional（按 Conventional Comments 约定以 issue (blocking):、suggestion:、nitpick: 等标签开头）", English: "Style of issues in Markdown reports and PR comments: default, or conventional (prefix each finding with a Conventional Comments label such as issue (blocking):, suggestion: or nitpick:)"},
+	"cli.flag.title-template":    {Chinese: "问题标题模板（Go text/template），可用字段：.Summary（简短标题）、.Severity、.Category、.File、.Base（文件名）、.Line，如 \"[{{.Category}}] {{.Summary}}\"", English: "Issue title template (Go text/template) with fields .Summary (short title), .Severity, .Category, .File, .Base (file name) and .Line, e.g. \"[{{.Category}}] {{.Summary}}\""},
+	"cli.flag.html-cdn":          {Chinese: "HTML 报告从 CDN 加载 highlight.js，默认内联内置资源以便离线查看", English: "Load highlight.js from a CDN in HTML reports instead of inlining the bundled assets for offline viewing"},
+	"cli.flag.lang":              {Chinese: "报告语言：zh, en，同时决定模型撰写评审意见使用的语言", English: "Report language: zh, en; also the language the model writes review comments in"},
+	"cli.flag.locale":            {Chinese: "命令行帮助、错误和进度信息的语言：zh, en，默认根据 LC_ALL、LC_MESSAGES、LANG 环境变量推断", English: "Language of CLI help, errors and progress messages: zh, en; detected from LC_ALL, LC_MESSAGES or LANG by default"},
+	"cli.flag.notify":            {Chinese: "评审完成后向配置文件中的通知渠道发送摘要，--notify=false 时不发送", English: "Send a summary to the notification channels in the config file after the review; --notify=false disables it"},
+	"cli.flag.quiet":             {Chinese: "静默模式，只输出错误信息", English: "Quiet mode, only print errors"},
+	"cli.flag.model":             {Chinese: "指定使用的AI模型，可选值：qwen, deepseek, openai, chatglm", English: "AI model to use: qwen, deepseek, openai, chatglm"},
+	"cli.flag.harden":            {Chinese: "启用提示词注入防护，将差异内容视为不可信输入", English: "Enable prompt injection hardening and treat diff content as untrusted input"},
+	"cli.flag.cache-write-queue": {Chinese: "评审结果由单独的线程批量写入磁盘缓存，等待写入的结果达到该数量时评审暂停等待写入，0表示同步写入", English: "Review results are written to the disk cache in batches by a background writer; reviews wait when this many results are pending, 0 writes synchronously"},
+	"cli.flag.cache-memory":      {Chinese: "内存缓存容量上限(MB)，0表示只使用磁盘缓存", English: "In-memory cache limit (MB), 0 uses the disk cache only"},
+	"cli.flag.concurrency":       {Chinese: "同时评审的文件数", English: "Number of files reviewed concurrently"},
+	"cli.flag.max-files":         {Chinese: "单次最多评审的文件数，0表示不限制", English: "Maximum number of files per review, 0 means unlimited"},
+	"cli.flag.max-diff-size":     {Chinese: "单次评审的差异总大小上限，如 512KB、2MB，0表示不限制", English: "Maximum total diff size per review, e.g. 512KB, 2MB, 0 means unlimited"},
+	"cli.flag.lock-timeout":      {Chinese: "同一仓库中已有评审在运行时等待其结束的时间上限，结束后复用其缓存的结果；超时后不再等待，0表示不等待", English: "How long to wait for another review running in the same repository and then reuse its cached results; the review proceeds after the timeout, 0 disables waiting"},
+	"cli.flag.max-duration":      {Chinese: "单次评审的时间上限，如 5m；临近时不再发起新的模型调用，输出标记为部分结果的报告，0表示不限制", English: "Time limit per review, e.g. 5m; no new model calls are started near the limit and the report is marked as partial, 0 means unlimited"},
+	"cli.flag.min-score":         {Chinese: "质量分低于该分数时以非零状态退出（0-100）", English: "Exit with a non-zero status when the quality score is below this value (0-100)"},
+	"cli.flag.fail-on":           {Chinese: "出现该级别及以上的问题时以非零状态退出：error, warning, info", English: "Exit with a non-zero status when issues at or above this severity are found: error, warning, info"},
+	"cli.flag.config":            {Chinese: "配置文件路径，默认从当前目录向上查找 %s", English: "Config file path, searched upwards from the current directory for %s by default"},
+	"cli.flag.resume":            {Chinese: "从上次中断的评审断点继续，跳过已完成的文件", English: "Resume an interrupted review from its checkpoint, skipping finished files"},
+	"cli.flag.select":            {Chinese: "逐个列出改动块，交互选择需要评审的部分（类似 git add -p）", English: "List hunks one by one and choose interactively which to review (like git add -p)"},
+	"cli.flag.compare":           {Chinese: "与当前分支上次的评审结果对比，显示问题数、质量分的变化和已解决的问题", English: "Compare with the previous review of the current branch, showing changes in issue counts, quality score and resolved issues"},
+	"cli.flag.baseline":          {Chinese: "以之前生成的 JSON 报告为基线对比，代替当前分支上次的评审结果", English: "Compare with a previously generated JSON report instead of the previous review of the current branch"},
+	"cli.flag.impact":            {Chinese: "Go 仓库中分析被修改的包被哪些包依赖，并在报告中列出影响范围", English: "In Go repositories, find the packages depending on the modified packages and list the impact in the report"},
+	"cli.flag.impact-threshold":  {Chinese: "包被依赖数达到该值时生成高影响改动警告，0表示不生成", English: "Warn about high-impact changes when a package has at least this many dependents, 0 disables the warning"},
+	"cli.flag.run-tests":         {Chinese: "评审前执行测试，并将结果写入报告和评审提示", English: "Run tests before reviewing and include the results in the report and the review prompt"},
+	"cli.flag.test-command":      {Chinese: "自定义测试命令，默认在 Go 仓库中对改动的包执行 go test", English: "Custom test command, defaults to go test on the changed packages in Go repositories"},
+	"cli.flag.test-timeout":      {Chinese: "测试命令的超时时间", English: "Timeout of the test command"},
+	"cli.flag.coverage":          {Chinese: "统计变更行的测试覆盖率，未提供覆盖率文件时对改动的包执行 go test -coverprofile", English: "Measure test coverage of changed lines; runs go test -coverprofile on the changed packages when no profile is given"},
+	"cli.flag.coverage-profile":  {Chinese: "go test -coverprofile 生成的覆盖率文件，指定后自动启用 --coverage", English: "Coverage profile produced by go test -coverprofile; implies --coverage"},
+	"cli.flag.by-author":         {Chinese: "按提交作者分组评审结果；指定 --output 时还会为每位作者单独生成报告", English: "Group review results by commit author; with --output also writes a report per author"},
+	"cli.flag.calibrate":         {Chinese: "根据 cr stats 同步的 PR 评论反馈校准模型：被驳回较多的模型只报告 warning 或 error 级别的问题", English: "Calibrate models with PR comment feedback synced by cr stats: models whose findings are often dismissed only report warning or error issues"},
+	"cli.flag.summary-lang":      {Chinese: "执行摘要同时输出的第二种语言：zh, en，通过模型翻译，问题详情仍使用 --lang 指定的语言", English: "Also render the executive summary in this second language (zh, en) via a model translation; issue details stay in the --lang language"},
+	"cli.flag.summary":           {Chinese: "评审完成后再调用一次模型汇总所有问题，在报告开头给出整体评价、主要风险和合并建议", English: "After the review, ask the model once more to summarize all issues into an overall assessment, key risks and a merge recommendation at the top of the report"},
+	"cli.flag.themes":            {Chinese: "评审完成后把问题按共性归纳为若干主题（如错误处理缺失、缺少输入校验），在报告开头列出", English: "After the review, group issues into themes such as missing error handling or input validation and list them at the top of the report"},
+	"cli.flag.themes-model":      {Chinese: "归纳主题使用的模型或模型池，通常选择更便宜的模型，默认使用评审模型", English: "Model or pool used to group themes, usually a cheaper one; defaults to the review model"},
+	"cli.flag.deps":              {Chinese: "go.mod、package.json、requirements.txt、pom.xml 变更时列出依赖的新增、升级和删除，并由模型评估不兼容变更和供应链风险", English: "When go.mod, package.json, requirements.txt or pom.xml change, list added, upgraded and removed dependencies and let the model assess breaking changes and supply-chain risks"},
+	"cli.flag.api-spec":          {Chinese: ".proto 和 OpenAPI/Swagger 文件变更时检测字段删除、类型变化等不兼容变更，并按接口兼容性和版本管理评审", English: "When .proto or OpenAPI/Swagger files change, detect breaking changes such as removed fields and type changes, and review them for API compatibility and versioning"},
+	"cli.flag.include-generated": {Chinese: "评审生成的代码和第三方代码：.gitattributes 中标记为 linguist-generated/linguist-vendored、开头有 \"Code generated ... DO NOT EDIT\" 等标记或位于 vendor/、node_modules/ 等目录的文件默认跳过", English: "Review generated and vendored code: files marked linguist-generated/linguist-vendored in .gitattributes, files with a \"Code generated ... DO NOT EDIT\" style header and files under vendor/, node_modules/ and similar directories are skipped by default"},
+	"cli.flag.deleted-files":     {Chinese: "被删除文件的评审方式：diff（只评审差异）, references（在仓库中搜索残留的引用，检查悬空引用和有风险的删除，需要安装 git）, skip（不评审）", English: "How to review deleted files: diff (review the diff only), references (search the repository for leftover references and check for dangling references and risky removals; requires git), skip (do not review)"},
+	"cli.flag.overview":          {Chinese: "在评审提示开头附带仓库概览（目录结构、主要模块和编码约定），首次使用时由模型生成并缓存，目录结构明显变化后重新生成", English: "Prepend a repository overview (structure, main packages and conventions) to review prompts; it is generated by the model on first use, cached, and refreshed when the tree changes significantly"},
+	"cli.flag.history":           {Chinese: "在评审提示中附带每个文件最近 N 个提交的说明，帮助模型了解进行中的工作，0 表示不附带", English: "Include the messages of the last N commits of each file in the review prompt to give the model context on ongoing work, 0 disables it"},
+	"cli.flag.read-only":         {Chinese: "只读模式，不写入任何文件（缓存、断点、评审记录、报告），结果只输出到标准输出；也可设置环境变量 %s=1", English: "Read-only mode: write no files (cache, checkpoints, review history, reports) and print results to standard output only; can also be enabled with %s=1"},
+	"cli.flag.ci":                {Chinese: "在持续集成中运行：github，从 GITHUB_EVENT_PATH 确定 PR 的评审范围，输出 ::error 等工作流命令并写入作业摘要", English: "Run in CI: github determines the PR range from GITHUB_EVENT_PATH, prints ::error workflow commands and writes a job summary"},
+	"cli.flag.verbose":           {Chinese: "显示详细日志信息", English: "Show verbose logs"},
+
+	// 选项解析与配置校验
+	"cli.err.locale_missing":         {Chinese: "--locale 需要指定语言：zh, en", English: "--locale requires a language: zh, en"},
+	"cli.err.config_not_found":       {Chinese: "配置文件不存在：%s", English: "config file not found: %s"},
+	"cli.config_migrated":            {Chinese: "配置文件 %s 使用旧版本格式，已在内存中自动升级，请运行 cr config migrate 更新文件", English: "config file %s uses an old format and was upgraded in memory; run cr config migrate to update the file"},
+	"cli.err.config_lock_timeout":    {Chinese: "配置项 review.lock_timeout 格式错误：%v", English: "invalid review.lock_timeout in config: %v"},
+	"cli.err.config_max_duration":    {Chinese: "配置项 review.max_duration 格式错误：%v", English: "invalid review.max_duration in config: %v"},
+	"cli.err.unsupported_ci":         {Chinese: "不支持的持续集成平台：%s，可选值：github", English: "unsupported CI platform: %s, valid values: github"},
+	"cli.err.unsupported_format":     {Chinese: "不支持的输出格式：%s", English: "unsupported output format: %s"},
+	"cli.err.template_required":      {Chinese: "template 格式需要通过 --report-template 指定模板文件", English: "the template format requires a template file via --report-template"},
+	"cli.err.read_only_output":       {Chinese: "只读模式下不能使用 --output 写入报告文件，请重定向标准输出", English: "--output cannot write a report file in read-only mode; redirect standard output instead"},
+	"cli.err.read_only_badge":        {Chinese: "只读模式下不能使用 --badge 写入徽章文件，请使用 --format badge 输出到标准输出", English: "--badge cannot write a badge file in read-only mode; use --format badge to print it to standard output"},
+	"cli.err.read_only_pdf":          {Chinese: "只读模式下不能生成PDF报告，PDF转换需要写入临时文件", English: "PDF reports cannot be generated in read-only mode because the conversion writes temporary files"},
+	"cli.err.read_only_resume":       {Chinese: "只读模式下不记录评审断点，不能使用 --resume", English: "--resume is unavailable in read-only mode because no checkpoints are recorded"},
+	"cli.err.read_only_tests":        {Chinese: "只读模式下不能使用 --run-tests，执行测试会写入构建缓存等文件", English: "--run-tests is unavailable in read-only mode because running tests writes build caches and other files"},
+	"cli.err.read_only_coverage":     {Chinese: "只读模式下统计覆盖率需要通过 --coverage-profile 指定已有的覆盖率文件", English: "coverage in read-only mode requires an existing profile via --coverage-profile"},
+	"cli.pdf_fallback":               {Chinese: "未找到 wkhtmltopdf，无法生成PDF报告，将改为输出HTML报告（安装 wkhtmltopdf 后可生成PDF）", English: "wkhtmltopdf not found, writing an HTML report instead of PDF (install wkhtmltopdf to generate PDF)"},
+	"cli.pdf_fallback_path":          {Chinese: "未找到 wkhtmltopdf，无法生成PDF报告，将改为输出HTML报告：%s（安装 wkhtmltopdf 后可生成PDF）", English: "wkhtmltopdf not found, writing an HTML report instead of PDF: %s (install wkhtmltopdf to generate PDF)"},
+	"cli.err.baseline_not_found":     {Chinese: "基线报告不存在：%s", English: "baseline report not found: %s"},
+	"cli.err.by_author_scope":        {Chinese: "--by-author 只能用于评审提交或提交范围", English: "--by-author can only be used when reviewing a commit or commit range"},
+	"cli.err.negative_write_queue":   {Chinese: "缓存写入队列长度不能为负数：%d", English: "cache write queue length cannot be negative: %d"},
+	"cli.err.negative_cache":         {Chinese: "内存缓存容量不能为负数：%d", English: "in-memory cache size cannot be negative: %d"},
+	"cli.err.concurrency":            {Chinese: "并发数必须大于0：%d", English: "concurrency must be greater than 0: %d"},
+	"cli.err.negative_max_files":     {Chinese: "文件数上限不能为负数：%d", English: "file limit cannot be negative: %d"},
+	"cli.err.max_diff_size":          {Chinese: "差异大小上限格式错误：%v", English: "invalid diff size limit: %v"},
+	"cli.err.report_url":             {Chinese: "完整报告的链接必须是 http 或 https 地址：%s", English: "the full report link must be an http or https URL: %s"},
+	"cli.err.comment_style":          {Chinese: "不支持的评论样式：%s，可选值：default, conventional", English: "unsupported comment style: %s, expected default or conventional"},
+	"cli.err.deleted_files":          {Chinese: "不支持的被删除文件评审方式：%s，可选值：diff, references, skip", English: "unsupported deleted file review mode: %s, expected diff, references or skip"},
+	"cli.err.title_template":         {Chinese: "问题标题模板无效：%v", English: "invalid issue title template: %v"},
+	"cli.err.negative_snippet_width": {Chinese: "代码片段宽度不能为负数：%d", English: "snippet width cannot be negative: %d"},
+	"cli.err.negative_history":       {Chinese: "提交历史数不能为负数：%d", English: "commit history count cannot be negative: %d"},
+	"cli.err.negative_lock_timeout":  {Chinese: "等待时间上限不能为负数：%s", English: "lock timeout cannot be negative: %s"},
+	"cli.err.negative_max_duration":  {Chinese: "评审时间上限不能为负数：%s", English: "review time limit cannot be negative: %s"},
+	"cli.err.unsupported_fail_on":    {Chinese: "不支持的门禁级别：%s", English: "unsupported gate severity: %s"},
+	"cli.err.untracked_scope":        {Chinese: "--untracked 只能在评审工作区时使用，不能与 --staged、--commit、--diff-file 或两个版本之间的 --commit-range 同时使用", English: "--untracked only applies when reviewing the working tree and cannot be combined with --staged, --commit, --diff-file or a two-revision --commit-range"},
+	"cli.err.read_only_action":       {Chinese: "只读模式下不能%[1]s（%[2]s=1）", English: "not allowed in read-only mode (%[2]s=1): %[1]s"},
+	"cli.err.min_score":              {Chinese: "质量分下限必须在 0 到 100 之间：%d", English: "minimum quality score must be between 0 and 100: %d"},
+	"cli.err.unsupported_model":      {Chinese: "不支持的AI模型：%s", English: "unsupported AI model: %s"},
+
+	// 评审流程
+	"cmd.usage":                     {Chinese: "用法: cr [diff|review] [选项]\n\n选项:\n", English: "Usage: cr [diff|review] [options]\n\nOptions:\n"},
+	"cmd.parse_args_failed":         {Chinese: "解析参数失败: %v", English: "failed to parse arguments: %v"},
+	"cmd.getwd_failed":              {Chinese: "获取当前工作目录失败: %v", English: "failed to get the working directory: %v"},
+	"cmd.no_changes":                {Chinese: "没有发现需要评审的代码改动", English: "no code changes to review"},
+	"cmd.report_saved":              {Chinese: "评审报告已保存到: %s", English: "review report saved to: %s"},
+	"cmd.artifact_saved":            {Chinese: "评审结果已保存为 %[1]s，可以用 cr render %[1]s --format pdf 重新生成其他格式的报告", English: "review results saved as %[1]s; run cr render %[1]s --format pdf to render other formats"},
+	"cmd.cache_write_failed":        {Chinese: "写入评审缓存失败: %v", English: "failed to write the review cache: %v"},
+	"cmd.history_failed":            {Chinese: "记录评审历史失败: %v", English: "failed to record the review history: %v"},
+	"cmd.artifact_failed":           {Chinese: "保存评审结果失败: %v", English: "failed to save the review results: %v"},
+	"cmd.author_report_saved":       {Chinese: "作者报告已保存到: %s", English: "author report saved to: %s"},
+	"cmd.generate_report_failed":    {Chinese: "生成评审报告失败: %v", English: "failed to generate the review report: %v"},
+	"cmd.report_heading":            {Chinese: "评审报告:", English: "Review report:"},
+	"cmd.notify_sent":               {Chinese: "已发送 %s 通知", English: "sent %s notification"},
+	"cmd.notify_read_only":          {Chinese: "只读模式下不发送通知", English: "notifications are not sent in read-only mode"},
+	"cmd.notify_skipped":            {Chinese: "跳过 %s 通知: %v", English: "skipped %s notification: %v"},
+	"cmd.notify_failed":             {Chinese: "发送通知失败: %v", English: "failed to send notification: %v"},
+	"cmd.badge_saved":               {Chinese: "徽章已保存到: %s", English: "badge saved to: %s"},
+	"cmd.gate_failed":               {Chinese: "评审未通过: %s", English: "review failed: %s"},
+	"cmd.cache_init_failed":         {Chinese: "初始化缓存失败: %v", English: "failed to initialize the cache: %v"},
+	"cmd.model_manager_failed":      {Chinese: "初始化模型管理器失败: %v", English: "failed to initialize the model manager: %v"},
+	"cmd.model_client_failed":       {Chinese: "获取模型客户端失败: %v", English: "failed to create the model client: %v"},
+	"cmd.pool_provider_unsupported": {Chinese: "模型池 %s 的第 %d 个提供方类型不支持：%s", English: "provider #%[2]d of model pool %[1]s has an unsupported type: %[3]s"},
+	"cmd.pool_provider_failed":      {Chinese: "创建模型池 %s 的提供方 %s 失败（API 密钥读取自 %s）: %v", English: "failed to create provider %[2]s of model pool %[1]s (API key read from %[3]s): %[4]v"},
+	"cmd.pool_failed":               {Chinese: "创建模型池 %s 失败: %v", English: "failed to create model pool %s: %v"},
+	"cmd.using_pool":                {Chinese: "使用模型池: %s（%d 个提供方）", English: "using model pool: %s (%d providers)"},
+	"cmd.policy_failed":             {Chinese: "加载评审策略失败: %v", English: "failed to load the review policy: %v"},
+	"cmd.analyze_failed":            {Chinese: "分析代码改动失败: %v", English: "failed to analyze code changes: %v"},
+	"cmd.excluded":                  {Chinese: "已按排除规则跳过 %d 个文件", English: "skipped %d files matching exclude rules"},
+	"cmd.binary_skipped":            {Chinese: "已跳过 %d 个二进制文件", English: "skipped %d binary files"},
+	"cmd.binary_attributes_failed":  {Chinese: "读取 .gitattributes 中的二进制属性失败，只按差异内容识别二进制文件: %v", English: "failed to read binary attributes from .gitattributes, detecting binary files from the diff only: %v"},
+	"cmd.moves_skipped":             {Chinese: "已跳过内容没有变化的 %d 个重命名或复制的文件", English: "skipped %d renamed or copied files without content changes"},
+	"cmd.deleted_skipped":           {Chinese: "已跳过 %d 个被删除的文件", English: "skipped %d deleted files"},
+	"cmd.deleted_references_failed": {Chinese: "搜索被删除文件的引用失败，按普通差异评审: %v", English: "failed to search for references to deleted files, reviewing them as plain diffs: %v"},
+	"cmd.untracked_included":        {Chinese: "包含 %d 个未跟踪的文件", English: "including %d untracked files"},
+	"cmd.generated_skipped":         {Chinese: "已跳过 %d 个生成的代码或第三方代码文件，可用 --include-generated 评审这些文件", English: "skipped %d generated or vendored files, use --include-generated to review them"},
+	"cmd.generated_file":            {Chinese: "跳过 %s（%s）", English: "skipping %s (%s)"},
+	"cmd.linguist_failed":           {Chinese: "无法读取 .gitattributes，不按 linguist 属性跳过文件: %v", English: "cannot read .gitattributes, not skipping files by linguist attributes: %v"},
+	"cmd.skipped_kinds":             {Chinese: "已按改动类型跳过 %d 个文件（%s）", English: "skipped %d files by change kind (%s)"},
+	"cmd.select_tty":                {Chinese: "--select 需要在交互式终端中使用", English: "--select requires an interactive terminal"},
+	"cmd.select_failed":             {Chinese: "选择改动块失败: %v", English: "failed to select hunks: %v"},
+	"cmd.all_over_limits":           {Chinese: "所有改动均超出评审上限，未执行评审", English: "all changes exceed the review limits, nothing was reviewed"},
+	"cmd.checkpoint_restart":        {Chinese: "%v，将从头开始评审", English: "%v, starting the review from scratch"},
+	"cmd.lock_waiting":              {Chinese: "仓库中已有评审在运行（进程 %d，开始于 %s），等待其结束后复用结果…", English: "another review is running in this repository (pid %d, started %s), waiting to reuse its results…"},
+	"cmd.lock_timeout":              {Chinese: "等待其他评审结束超时，不再等待", English: "timed out waiting for the other review, continuing without waiting"},
+	"cmd.lock_failed":               {Chinese: "获取评审锁失败，不等待其他评审：%v", English: "failed to acquire the review lock, continuing without it: %v"},
+	"cmd.resumed":                   {Chinese: "从断点恢复了 %d 个已完成的文件", English: "restored %d finished files from the checkpoint"},
+	"cmd.time_limit":                {Chinese: "已达到评审时间上限 %s，%d 个文件未评审，报告只包含部分结果", English: "review time limit %s reached, %d files were not reviewed and the report is partial"},
+	"cmd.time_limit_resume":         {Chinese: "；使用 --resume 可继续评审剩余文件", English: "; use --resume to review the remaining files"},
+	"cmd.checkpoint_remove_failed":  {Chinese: "删除评审断点失败: %v", English: "failed to remove the review checkpoint: %v"},
+	"cmd.coverage_profile_required": {Chinese: "使用自定义测试命令时需要通过 --coverage-profile 指定覆盖率文件，跳过覆盖率统计", English: "a custom test command requires a coverage profile via --coverage-profile, skipping coverage"},
+	"cmd.skip_dependency_review":    {Chinese: "跳过依赖风险评估: %v", English: "skipping dependency risk assessment: %v"},
+	"cmd.overview_fallback":         {Chinese: "生成仓库概览失败，改用目录结构提纲: %v", English: "failed to generate the repository overview, using the directory outline instead: %v"},
+	"cmd.overview_save_failed":      {Chinese: "保存仓库概览失败: %v", English: "failed to save the repository overview: %v"},
+	"cmd.skip_overview":             {Chinese: "跳过仓库概览: %v", English: "skipping repository overview: %v"},
+	"cmd.skip_calibration":          {Chinese: "跳过模型校准: %v", English: "skipping model calibration: %v"},
+	"cmd.calibrated":                {Chinese: "模型 %s 的精确率为 %.0f%%（%d 条反馈），只报告 %s 及以上的问题，跳过了 %d 个问题", English: "model %s has %.0f%% precision (%d feedback), reporting only %s and above, skipped %d issues"},
+	"cmd.skip_themes":               {Chinese: "跳过问题主题: %v", English: "skipping issue themes: %v"},
+	"cmd.skip_summary_translation":  {Chinese: "跳过执行摘要的翻译: %v", English: "skipping executive summary translation: %v"},
+	"cmd.skip_summary":              {Chinese: "跳过执行摘要: %v", English: "skipping the executive summary: %v"},
+	"cmd.report_dir_failed":         {Chinese: "创建报告目录失败: %v", English: "failed to create the report directory: %v"},
+	"cmd.save_report_failed":        {Chinese: "保存评审报告失败: %v", English: "failed to save the review report: %v"},
+	"cmd.impact_failed":             {Chinese: "分析包影响范围失败: %v", English: "failed to analyze package impact: %v"},
+	"cmd.read_old_failed":           {Chinese: "读取 %s 改动前的内容失败: %v", English: "failed to read %s before the change: %v"},
+	"cmd.read_new_failed":           {Chinese: "读取 %s 改动后的内容失败: %v", English: "failed to read %s after the change: %v"},
+	"cmd.read_file_failed":          {Chinese: "读取文件失败: %v", English: "failed to read file: %v"},
+	"cmd.running_test_command":      {Chinese: "正在执行测试: %s", English: "running tests: %s"},
+	"cmd.running_go_tests":          {Chinese: "正在执行 %d 个包的测试", English: "running tests for %d packages"},
+	"cmd.no_test_command":           {Chinese: "未配置测试命令，且当前仓库不是 Go 模块，跳过测试", English: "no test command configured and the repository is not a Go module, skipping tests"},
+	"cmd.gate_failed_title":         {Chinese: "评审未通过", English: "Review failed"},
+	"cmd.step_summary_failed":       {Chinese: "生成作业摘要失败: %v", English: "failed to generate the job summary: %v"},
+	"cmd.select_prompt":             {Chinese: "评审这个改动块 [y,n,a,d,q,?]? ", English: "Review this hunk [y,n,a,d,q,?]? "},
+	"cmd.read_input_failed":         {Chinese: "读取输入失败: %v", English: "failed to read input: %v"},
+	"cmd.select_help":               {Chinese: "y - 评审这个改动块\nn - 跳过这个改动块\na - 评审这个文件剩余的全部改动块\nd - 跳过这个文件剩余的全部改动块\nq - 跳过剩余的全部改动块并开始评审\n? - 显示帮助", English: "y - review this hunk\nn - skip this hunk\na - review this and all remaining hunks in the file\nd - skip this and all remaining hunks in the file\nq - skip all remaining hunks and start the review\n? - print help"},
+	"cmd.command_failed":            {Chinese: "%s 执行失败: %v", English: "%s failed: %v"},
+	"cmd.commands_heading":          {Chinese: "子命令:", English: "Commands:"},
+	"cmd.prompt_cache_hit":          {Chinese: "提示缓存命中 %d/%d 个输入 tokens（%.1f%%）", English: "prompt cache hit %d/%d input tokens (%.1f%%)"},
+	"cmd.prompt_cache_saved":        {Chinese: "提示缓存节省约 %.4f %s", English: "prompt cache saved about %.4f %s"},
+
+	// 只读模式下被拒绝的写操作
+	"readonly.calibration":     {Chinese: "更新模型反馈记录", English: "updating the model feedback records"},
+	"readonly.report_file":     {Chinese: "写入报告文件，请去掉 --output 输出到标准输出", English: "writing the report file; drop --output to print to standard output"},
+	"readonly.publish":         {Chinese: "发布评审评论，请使用 --dry-run", English: "publishing review comments; use --dry-run"},
+	"readonly.export_prompts":  {Chinese: "导出提示文件", English: "exporting prompt files"},
+	"readonly.job_summary":     {Chinese: "写入作业摘要", English: "writing the job summary"},
+	"readonly.install_hooks":   {Chinese: "安装Git钩子", English: "installing Git hooks"},
+	"readonly.uninstall_hooks": {Chinese: "移除Git钩子", English: "removing Git hooks"},
+	"readonly.create_config":   {Chinese: "创建配置文件", English: "creating the config file"},
+	"readonly.migrate_config":  {Chinese: "升级配置文件", English: "migrating the config file"},
+	"readonly.serve":           {Chinese: "以服务模式运行", English: "running in server mode"},
+	"readonly.export_tasks":    {Chinese: "导出待办或创建 issue，请使用 --dry-run", English: "exporting tasks or creating issues; use --dry-run"},
+
+	// 进度显示
+	"progress.reviewing":      {Chinese: "评审中: %s", English: "reviewing: %s"},
+	"progress.reviewing_many": {Chinese: "评审中: %s 等%d个文件", English: "reviewing: %s and %d files in total"},
+	"progress.cached":         {Chinese: "缓存", English: "cached"},
+	"progress.resumed":        {Chinese: "断点恢复", English: "resumed"},
+	"progress.skipped":        {Chinese: "已达到评审时限，跳过", English: "time limit reached, skipped"},
+
+	// 子命令说明
+	"cmd.summary.batch":          {Chinese: "按清单文件批量评审多个仓库或范围", English: "Review multiple repositories or ranges from a manifest"},
+	"cmd.summary.compare-ranges": {Chinese: "对比同一功能在多个提交范围中的不同实现，辅助设计决策评审", English: "Compare competing implementations of a feature across commit ranges for design reviews"},
+	"cmd.summary.config":         {Chinese: "管理配置文件：init、show、migrate", English: "Manage the config file: init, show, migrate"},
+	"cmd.summary.export-tasks":   {Chinese: "将评审问题导出为 TODO.md 待办或 GitHub issue", English: "Export review issues as TODO.md tasks or GitHub issues"},
+	"cmd.summary.fake-provider":  {Chinese: "运行返回固定回复的模拟模型服务（兼容 OpenAI 接口），用于端到端冒烟测试", English: "Run a fake OpenAI-compatible model service with canned responses for end-to-end smoke tests"},
+	"cmd.summary.history":        {Chinese: "查询评审历史：list、show", English: "Query the review history: list, show"},
+	"cmd.summary.hooks":          {Chinese: "管理Git钩子：install、uninstall、status", English: "Manage Git hooks: install, uninstall, status"},
+	"cmd.summary.install-hooks":  {Chinese: "安装Git钩子，等同于 hooks install", English: "Install Git hooks, same as hooks install"},
+	"cmd.summary.prompts":        {Chinese: "查看、导出和试用评审提示：list、show、export、test", English: "Inspect, export and try out the review prompts: list, show, export, test"},
+	"cmd.summary.publish":        {Chinese: "将评审结果发布为代码托管平台上的评审评论", English: "Publish review results as comments on a code hosting platform"},
+	"cmd.summary.render":         {Chinese: "从保存的评审结果重新生成报告，不再调用模型", English: "Render reports from saved review results without calling the model again"},
+	"cmd.summary.report":         {Chinese: "处理已生成的 JSON 报告：compare", English: "Work with generated JSON reports: compare"},
+	"cmd.summary.serve":          {Chinese: "以服务模式运行，接收 GitHub/GitLab 的 webhook 自动评审 pull request", English: "Run as a review bot that reviews pull requests from GitHub/GitLab webhooks"},
+	"cmd.summary.stats":          {Chinese: "同步 PR 评论上的反馈，输出各模型的校准情况", English: "Sync feedback from PR comments and show per-model calibration"},
+	"cmd.summary.watch":          {Chinese: "持续监控工作区，增量评审有改动的文件", English: "Watch the working tree and review changed files incrementally"},
+
+	// 子命令的选项
+	"batch.flag.manifest":             {Chinese: "批量评审清单文件（YAML 或 JSON）", English: "Batch review manifest file (YAML or JSON)"},
+	"batch.flag.concurrency":          {Chinese: "同时执行的任务数，默认使用清单中的设置", English: "Number of jobs to run at once, defaults to the manifest setting"},
+	"batch.flag.read-only":            {Chinese: "只读模式，所有任务都不写入任何文件，清单中不能配置报告输出", English: "Read-only mode: no job writes any file and the manifest cannot configure report output"},
+	"compare-ranges.flag.model":       {Chinese: "使用的AI模型，默认使用配置文件中的 model.default", English: "AI model to use, defaults to model.default in the config file"},
+	"compare-ranges.flag.lang":        {Chinese: "报告语言：zh, en", English: "Report language: zh, en"},
+	"compare-ranges.flag.format":      {Chinese: "输出格式：markdown, json", English: "Output format: markdown, json"},
+	"compare-ranges.flag.output":      {Chinese: "报告输出文件，为空时输出到标准输出", English: "Report output file, standard output when empty"},
+	"compare-ranges.flag.concurrency": {Chinese: "同时概括的提交范围数", English: "Number of ranges summarized concurrently"},
+	"compare-ranges.flag.verbose":     {Chinese: "输出每次模型调用的 token 用量", English: "Log token usage of every model call"},
+	"config.flag.config":              {Chinese: "配置文件路径，默认从当前目录向上查找 %s", English: "Config file path, searched upward from the current directory for %s by default"},
+	"export.flag.input":               {Chinese: "JSON格式的评审报告（cr --format json 的输出），- 表示标准输入", English: "Review report in JSON (output of cr --format json), - for standard input"},
+	"export.flag.to":                  {Chinese: "导出目标：todo, github", English: "Export target: todo, github"},
+	"export.flag.output":              {Chinese: "导出为 todo 时写入的文件", English: "File written when exporting to todo"},
+	"export.flag.min-severity":        {Chinese: "只导出该级别及以上的问题：error, warning, info", English: "Only export issues at or above this severity: error, warning, info"},
+	"export.flag.repo":                {Chinese: "GitHub 仓库 owner/name，默认读取 GITHUB_REPOSITORY", English: "GitHub repository owner/name, defaults to GITHUB_REPOSITORY"},
+	"export.flag.dry-run":             {Chinese: "只输出将要导出的待办，不写文件也不创建 issue", English: "Only print the tasks to export without writing files or creating issues"},
+	"fake-provider.flag.addr":         {Chinese: "监听地址", English: "Listen address"},
+	"fake-provider.flag.fixtures":     {Chinese: "固定回复规则文件（YAML），为空时全部使用内置的默认回复", English: "Canned response rules file (YAML); built-in default responses are used when empty"},
+	"fake-provider.flag.delay":        {Chinese: "每次回复前的等待时间，如 500ms，用于模拟慢速模型", English: "Delay before each response, e.g. 500ms, to simulate a slow model"},
+	"fake-provider.flag.model":        {Chinese: "回复中的模型名称，为空时使用请求中的模型名称", English: "Model name in responses; the requested model name is used when empty"},
+	"history.flag.store":              {Chinese: "评审历史的存储位置，默认使用配置文件中的 history.store，未配置时为 ~/.cr/history.db", English: "Review history store, defaults to history.store in the config file or ~/.cr/history.db"},
+	"history.flag.repo":               {Chinese: "只列出该仓库的评审", English: "Only list reviews of this repository"},
+	"history.flag.since":              {Chinese: "只列出该日期（2006-01-02）之后的评审", English: "Only list reviews on or after this date (2006-01-02)"},
+	"history.flag.limit":              {Chinese: "最多列出的评审数，为0时不限制", English: "Maximum number of reviews to list, 0 for no limit"},
+	"history.flag.format":             {Chinese: "输出格式：text, json", English: "Output format: text, json"},
+	"history.flag.show-format":        {Chinese: "输出格式：text, json，report 输出保存的 JSON 报告", English: "Output format: text, json; report prints the saved JSON report"},
+	"prompts.flag.dir":                {Chinese: "导出目录，默认为仓库中的 .cr/prompts", English: "Export directory, defaults to .cr/prompts in the repository"},
+	"prompts.flag.file":               {Chinese: "生成提示的文件，使用其相对 HEAD 的改动，没有改动时把全部内容作为新增文件", English: "File to build the prompt for, using its changes against HEAD or its whole content as a new file when unchanged"},
+	"prompts.flag.diff":               {Chinese: "使用补丁文件中的改动代替工作区的改动，- 表示标准输入；补丁包含多个文件时用 --file 选择", English: "Use the changes in a patch file instead of the working tree, - for standard input; pick a file with --file when it has several"},
+	"prompts.flag.template":           {Chinese: "试用的提示文件，替换 --name 指定的文件；为目录时按 .cr/prompts 的结构覆盖同名文件", English: "Prompt file to try, replacing the file named by --name; a directory overrides files laid out like .cr/prompts"},
+	"prompts.flag.name":               {Chinese: "--template 替换的提示文件：base、focus 或 languages/<语言>", English: "Prompt file replaced by --template: base, focus or languages/<language>"},
+	"prompts.flag.send":               {Chinese: "把提示发送给模型，输出响应、解析出的问题和 token 用量", English: "Send the prompt to the model and show the response, parsed issues and token usage"},
+	"prompts.flag.format":             {Chinese: "输出格式：text, json", English: "Output format: text, json"},
+	"prompts.flag.force":              {Chinese: "覆盖已存在的文件", English: "Overwrite existing files"},
+	"render.flag.format":              {Chinese: "报告格式，多个格式用逗号分隔并行生成，如 html,pdf", English: "Report format; separate several formats with commas to render them in parallel, e.g. html,pdf"},
+	"render.flag.output":              {Chinese: "报告的保存路径，默认输出到标准输出；生成多种格式时按格式替换扩展名", English: "Path of the report, defaults to standard output; with several formats the extension is replaced per format"},
+	"hooks.flag.pre-commit":           {Chinese: "只处理 pre-commit 钩子", English: "Only handle the pre-commit hook"},
+	"hooks.flag.pre-push":             {Chinese: "只处理 pre-push 钩子", English: "Only handle the pre-push hook"},
+	"publish.flag.input":              {Chinese: "JSON格式的评审报告（cr --format json 的输出），- 表示标准输入", English: "Review report in JSON (output of cr --format json), - for standard input"},
+	"publish.flag.to":                 {Chinese: "发布目标：github, gitlab, gitea（Gitea 与 Forgejo 通用）, bitbucket（Cloud 与 Server 通用）, gerrit", English: "Publish target: github, gitlab, gitea (works for Gitea and Forgejo), bitbucket (works for Cloud and Server), gerrit"},
+	"publish.flag.repo":               {Chinese: "仓库：GitHub 和 Gitea 为 owner/name，默认读取 GITHUB_REPOSITORY；GitLab 为项目 ID 或路径，默认读取 CI_PROJECT_ID；Bitbucket 为 workspace/slug 或 PROJECT/slug，默认读取 BITBUCKET_REPO_FULL_NAME", English: "Repository: owner/name for GitHub and Gitea, defaults to GITHUB_REPOSITORY; project ID or path for GitLab, defaults to CI_PROJECT_ID; workspace/slug or PROJECT/slug for Bitbucket, defaults to BITBUCKET_REPO_FULL_NAME"},
+	"publish.flag.url":                {Chinese: "自建实例地址：Gitea/Forgejo 默认读取 GITEA_URL，在 Gitea Actions 中使用 GITHUB_SERVER_URL；Bitbucket Server 默认读取 BITBUCKET_URL，为空时访问 Bitbucket Cloud；Gerrit 默认读取 GERRIT_URL", English: "Self-hosted instance URL: Gitea/Forgejo defaults to GITEA_URL or GITHUB_SERVER_URL in Gitea Actions; Bitbucket Server defaults to BITBUCKET_URL, Bitbucket Cloud is used when empty; Gerrit defaults to GERRIT_URL"},
+	"publish.flag.pr":                 {Chinese: "pull request、merge request 或 Gerrit 变更的编号，为 0 时从 GitHub Actions、Gitea Actions、GitLab CI、Bitbucket Pipelines 或 Gerrit 触发的流水线环境中获取", English: "Pull request, merge request or Gerrit change number; 0 reads it from the GitHub Actions, Gitea Actions, GitLab CI, Bitbucket Pipelines or Gerrit-triggered pipeline environment"},
+	"publish.flag.lang":               {Chinese: "评论语言：zh, en", English: "Comment language: zh, en"},
+	"publish.flag.min-severity":       {Chinese: "只为该级别及以上的问题发布行内评论：error, warning, info", English: "Only post inline comments for issues at or above this severity: error, warning, info"},
+	"publish.flag.report-url":         {Chinese: "完整报告的链接，附在总结评论末尾", English: "Link to the full report, appended to the summary comment"},
+	"publish.flag.comment-style":      {Chinese: "评论中问题的样式：default, conventional（Conventional Comments）", English: "Style of issues in comments: default, conventional (Conventional Comments)"},
+	"publish.flag.dry-run":            {Chinese: "只输出将要发布的评论，不调用平台接口", English: "Only print the comments to publish without calling the platform API"},
+	"report.flag.format":              {Chinese: "输出格式：markdown, json, terminal（输出到终端时默认为 terminal，否则为 markdown）", English: "Output format: markdown, json, terminal (terminal when writing to a terminal, markdown otherwise)"},
+	"report.flag.lang":                {Chinese: "报告语言：zh, en", English: "Report language: zh, en"},
+	"serve.flag.addr":                 {Chinese: "监听地址", English: "Listen address"},
+	"serve.flag.workdir":              {Chinese: "仓库克隆的工作目录，默认为用户缓存目录下的 ai-cr-tool/serve", English: "Working directory for repository clones, defaults to ai-cr-tool/serve under the user cache directory"},
+	"serve.flag.workers":              {Chinese: "同时评审的任务数", English: "Number of reviews run at once"},
+	"serve.flag.retries":              {Chinese: "评审失败后的重试次数，重试间隔从30秒开始每次加倍", English: "Number of retries for failed reviews, with the delay doubling from 30 seconds"},
+	"serve.flag.redis":                {Chinese: "保存评审队列的 Redis 地址，如 redis://:密码@localhost:6379/0，为空时使用内存队列", English: "Redis URL for a persistent review queue, e.g. redis://:password@localhost:6379/0; in-memory queue when empty"},
+	"serve.flag.max-model-calls":      {Chinese: "所有仓库同时进行的模型调用数上限，为0时不限制", English: "Maximum concurrent model calls across all repositories, 0 for no limit"},
+	"serve.flag.max-repo-model-calls": {Chinese: "单个仓库同时进行的模型调用数上限，为0时不限制", English: "Maximum concurrent model calls per repository, 0 for no limit"},
+	"serve.flag.history":              {Chinese: "评审历史的存储位置，默认为工作目录下的 SQLite 数据库 history.db，off 表示不记录", English: "Review history store, defaults to the SQLite database history.db in the working directory; off disables it"},
+	"serve.flag.min-severity":         {Chinese: "只为该级别及以上的问题发布行内评论：error, warning, info", English: "Only post inline comments for issues at or above this severity: error, warning, info"},
+	"stats.flag.from":                 {Chinese: "从代码托管平台同步行内评论收到的 👍/👎：github, gitlab", English: "Sync 👍/👎 reactions on inline comments from a code hosting platform: github, gitlab"},
+	"stats.flag.repo":                 {Chinese: "仓库：GitHub 为 owner/name，默认读取 GITHUB_REPOSITORY；GitLab 为项目 ID 或路径，默认读取 CI_PROJECT_ID", English: "Repository: owner/name for GitHub, defaults to GITHUB_REPOSITORY; project ID or path for GitLab, defaults to CI_PROJECT_ID"},
+	"stats.flag.pr":                   {Chinese: "pull request 或 merge request 编号，为 0 时从 GitHub Actions 或 GitLab CI 环境中获取", English: "Pull request or merge request number; 0 reads it from the GitHub Actions or GitLab CI environment"},
+	"stats.flag.dismiss":              {Chinese: "手动将问题标记为被驳回，多个指纹用逗号分隔", English: "Mark issues as dismissed by hand, comma-separated fingerprints"},
+	"watch.flag.interval":             {Chinese: "轮询工作区改动的间隔", English: "Interval for polling the working tree"},
+	"watch.flag.debounce":             {Chinese: "文件停止变化多久后才开始评审", English: "How long files must stay unchanged before a review starts"},
+	"watch.flag.model":                {Chinese: "指定使用的AI模型，可选值：qwen, deepseek, openai, chatglm", English: "AI model to use: qwen, deepseek, openai, chatglm"},
+	"watch.flag.concurrency":          {Chinese: "同时评审的文件数", English: "Number of files reviewed at once"},
+	"watch.flag.cache-memory":         {Chinese: "内存缓存容量上限(MB)", English: "In-memory cache size limit (MB)"},
+
+	// 子命令的运行信息
+	"watch.review_failed":            {Chinese: "%s 评审失败: %s，%s 后重试", English: "review of %s failed: %s, retrying in %s"},
+	"serve.err.min_severity":         {Chinese: "无效的严重程度: %s", English: "invalid severity: %s"},
+	"serve.err.review_args":          {Chinese: "评审参数无效: %v", English: "invalid review arguments: %v"},
+	"serve.err.no_secret":            {Chinese: "未设置 GITHUB_WEBHOOK_SECRET、GITLAB_WEBHOOK_TOKEN 或 CR_API_TOKEN 环境变量，服务模式只接收经过校验的请求", English: "none of GITHUB_WEBHOOK_SECRET, GITLAB_WEBHOOK_TOKEN or CR_API_TOKEN is set; server mode only accepts verified requests"},
+	"serve.err.workdir":              {Chinese: "无法确定工作目录，请使用 --workdir 指定: %v", English: "cannot determine the working directory, set it with --workdir: %v"},
+	"serve.err.listen":               {Chinese: "监听 %s 失败: %v", English: "failed to listen on %s: %v"},
+	"serve.err.summary":              {Chinese: "生成总结评论失败: %v", English: "failed to generate the summary comment: %v"},
+	"serve.github_endpoint":          {Chinese: "接收 GitHub webhook: http://%s/webhook/github", English: "GitHub webhook: http://%s/webhook/github"},
+	"serve.gitlab_endpoint":          {Chinese: "接收 GitLab webhook: http://%s/webhook/gitlab", English: "GitLab webhook: http://%s/webhook/gitlab"},
+	"serve.api_endpoint":             {Chinese: "评审 API: http://%[1]s/reviews，服务状态: http://%[1]s/status", English: "review API: http://%[1]s/reviews, status: http://%[1]s/status"},
+	"serve.dashboard_endpoint":       {Chinese: "评审历史: http://%s/dashboard（用户名任意，密码为 CR_API_TOKEN）", English: "review history: http://%s/dashboard (any user name, CR_API_TOKEN as the password)"},
+	"serve.stopping":                 {Chinese: "正在停止服务，等待进行中的评审完成……", English: "stopping the server, waiting for running reviews to finish..."},
+	"serve.no_changes":               {Chinese: "%s 没有需要评审的改动", English: "%s has no changes to review"},
+	"serve.published":                {Chinese: "已在 %s 发布评审：%d 个问题，%d 条行内评论（%d 个问题已评论过，%d 个问题不在差异中）", English: "published the review on %s: %d issues, %d inline comments (%d already commented, %d outside the diff)"},
+	"render.usage":                   {Chinese: "用法: cr render [评审编号|latest|report.json] [--format html,pdf] [--output 路径]", English: "usage: cr render [review ID|latest|report.json] [--format html,pdf] [--output path]"},
+	"render.err.output_required":     {Chinese: "同时生成多种格式时需要用 --output 指定报告路径，各格式的扩展名会自动添加", English: "rendering several formats requires a report path via --output; each format's extension is added automatically"},
+	"render.err.no_artifacts":        {Chinese: "还没有保存的评审结果", English: "no saved review results yet"},
+	"render.err.artifact_not_found":  {Chinese: "找不到评审结果 %s", English: "review results not found: %s"},
+	"render.err.load":                {Chinese: "读取评审结果失败: %v", English: "failed to read the review results: %v"},
+	"prompts.usage":                  {Chinese: "用法: cr prompts list|show <名称>|export [--dir 目录] [--force] [名称...]|test --file x.go [--template my.md] [--send]", English: "usage: cr prompts list|show <name>|export [--dir directory] [--force] [name...]|test --file x.go [--template my.md] [--send]"},
+	"prompts.usage_show":             {Chinese: "用法: cr prompts show <名称>，名称见 cr prompts list", English: "usage: cr prompts show <name>, see cr prompts list for the names"},
+	"prompts.err.not_found":          {Chinese: "没有提示文件 %s，可用的名称见 cr prompts list", English: "no prompt file %s, see cr prompts list for the available names"},
+	"prompts.err.unknown_subcommand": {Chinese: "未知的 prompts 子命令: %s", English: "unknown prompts subcommand: %s"},
+	"prompts.err.no_repo":            {Chinese: "当前目录不在 Git 仓库中，请用 --dir 指定导出目录", English: "the current directory is not in a Git repository; set the export directory with --dir"},
+	"prompts.usage_test":             {Chinese: "用法: cr prompts test --file x.go [--diff fixture.patch] [--template my.md] [--send]", English: "usage: cr prompts test --file x.go [--diff fixture.patch] [--template my.md] [--send]"},
+	"prompts.err.format":             {Chinese: "不支持的输出格式: %s，可选值：text, json", English: "unsupported output format: %s, expected text or json"},
+	"prompts.err.read_template":      {Chinese: "读取提示文件失败: %v", English: "failed to read the prompt file: %v"},
+	"prompts.err.read_patch":         {Chinese: "读取补丁文件失败: %v", English: "failed to read the patch file: %v"},
+	"prompts.err.empty_patch":        {Chinese: "补丁文件 %s 中没有文件改动", English: "patch file %s contains no file changes"},
+	"prompts.err.file_not_in_patch":  {Chinese: "补丁文件 %s 中没有 %s 的改动", English: "patch file %s has no changes to %s"},
+	"prompts.err.read_file":          {Chinese: "读取文件失败: %v", English: "failed to read the file: %v"},
+	"prompts.err.chat":               {Chinese: "调用模型失败: %v", English: "model call failed: %v"},
+	"prompts.err.empty_response":     {Chinese: "模型没有返回内容", English: "the model returned no content"},
+	"prompts.unchanged_file":         {Chinese: "%s 没有未提交的改动，按新增文件生成提示", English: "%s has no uncommitted changes, building the prompt as for a new file"},
+	"prompts.message_header":         {Chinese: "%s（%d 字符）", English: "%s (%d characters)"},
+	"prompts.response_header":        {Chinese: "模型响应（%s，%s）", English: "model response (%s, %s)"},
+	"prompts.issues_header":          {Chinese: "解析出 %d 个问题", English: "%d issues parsed"},
+	"prompts.issue_line":             {Chinese: "第 %d 行 %s", English: "line %d %s"},
+	"prompts.usage_tokens":           {Chinese: "token: 输入 %d（缓存命中 %d），输出 %d，共 %d", English: "tokens: %d input (%d cached), %d output, %d total"},
+	"hooks.usage":                    {Chinese: "用法: cr hooks install|uninstall|status [--pre-commit] [--pre-push]", English: "usage: cr hooks install|uninstall|status [--pre-commit] [--pre-push]"},
+	"hooks.err.unknown_action":       {Chinese: "未知的 hooks 操作: %s", English: "unknown hooks action: %s"},
+	"hooks.upgraded":                 {Chinese: "已升级 %s 钩子 (%s)", English: "upgraded the %s hook (%s)"},
+	"hooks.merged":                   {Chinese: "已在现有 %[1]s 钩子中插入受管区块，原文件已备份为 %[1]s.backup", English: "inserted the managed block into the existing %[1]s hook, the original was backed up as %[1]s.backup"},
+	"hooks.installed":                {Chinese: "已安装 %s 钩子", English: "installed the %s hook"},
+	"hooks.removed":                  {Chinese: "已移除 %s 钩子", English: "removed the %s hook"},
+	"hooks.status_managed":           {Chinese: "%s: 已安装 (版本 %s, 生成于 %s)", English: "%s: installed (version %s, generated at %s)"},
+	"hooks.status_custom":            {Chinese: "%s: 存在用户自定义钩子，未包含 ai-cr-tool 区块", English: "%s: custom hook without an ai-cr-tool block"},
+	"hooks.status_missing":           {Chinese: "%s: 未安装", English: "%s: not installed"},
+	"config.usage":                   {Chinese: "用法: cr config init|show|migrate [--config path]", English: "usage: cr config init|show|migrate [--config path]"},
+	"config.err.unknown_action":      {Chinese: "未知的 config 操作: %s", English: "unknown config action: %s"},
+	"config.err.exists":              {Chinese: "配置文件已存在: %s", English: "config file already exists: %s"},
+	"config.err.not_found":           {Chinese: "未找到配置文件 %s", English: "config file %s not found"},
+	"config.created":                 {Chinese: "已创建配置文件: %s", English: "created config file: %s"},
+	"config.up_to_date":              {Chinese: "配置文件已是最新版本 (版本 %d)", English: "the config file is up to date (version %d)"},
+	"config.migrated":                {Chinese: "配置文件已从版本 %d 升级到版本 %d，原文件已备份为 %s", English: "migrated the config file from version %d to version %d, the original was backed up as %s"},
+	"watch.err.interval":             {Chinese: "轮询间隔必须大于0，防抖时间不能为负数", English: "the polling interval must be positive and the debounce must not be negative"},
+	"watch.err.changes":              {Chinese: "获取工作区改动失败: %v", English: "failed to read working tree changes: %v"},
+	"watch.started":                  {Chinese: "正在监控 %s 的改动 (间隔 %s, 防抖 %s)，按 Ctrl-C 退出", English: "watching %s for changes (interval %s, debounce %s), press Ctrl-C to exit"},
+	"watch.stopped":                  {Chinese: "已停止监控", English: "stopped watching"},
+	"watch.reviewed":                 {Chinese: "评审了 %d 个文件: %s", English: "reviewed %d files: %s"},
+	"export.err.min_severity":        {Chinese: "无效的严重程度: %s", English: "invalid severity: %s"},
+	"export.err.read":                {Chinese: "读取 %s 失败: %v", English: "failed to read %s: %v"},
+	"export.err.write":               {Chinese: "写入 %s 失败: %v", English: "failed to write %s: %v"},
+	"export.err.target":              {Chinese: "不支持的导出目标: %s，可选值：todo, github", English: "unsupported export target: %s, expected todo or github"},
+	"export.codeowners_ignored":      {Chinese: "忽略 CODEOWNERS: %v", English: "ignoring CODEOWNERS: %v"},
+	"export.nothing":                 {Chinese: "没有 %s 及以上级别的问题需要导出", English: "no issues at or above %s to export"},
+	"export.todo_added":              {Chinese: "已向 %s 添加 %d 个待办（%d 个已存在）", English: "added %[2]d tasks to %[1]s (%[3]d already present)"},
+	"export.issues_created":          {Chinese: "已在 %s 创建 %d 个 issue（%d 个已存在）", English: "created %[2]d issues in %[1]s (%[3]d already present)"},
+	"batch.usage":                    {Chinese: "用法: cr batch --manifest jobs.yaml [--concurrency N]", English: "usage: cr batch --manifest jobs.yaml [--concurrency N]"},
+	"batch.err.read_only_outputs":    {Chinese: "只读模式下不能写入报告文件，请移除任务 %s 的 outputs 配置", English: "report files cannot be written in read-only mode, remove the outputs of job %s"},
+	"batch.err.job_args":             {Chinese: "解析任务参数失败: %v", English: "failed to parse the job arguments: %v"},
+	"batch.started":                  {Chinese: "开始评审 %s", English: "reviewing %s"},
+	"batch.done":                     {Chinese: "批量评审完成：%d 个任务，%d 个通过，%d 个失败或未通过门禁", English: "batch review finished: %d jobs, %d passed, %d failed or did not pass the gate"},
+	"batch.job_passed":               {Chinese: "%d 个文件，%d 个问题 (%s)", English: "%d files, %d issues (%s)"},
+	"batch.job_gate_failed":          {Chinese: "%d 个文件，%d 个问题，未通过门禁 (%s)", English: "%d files, %d issues, did not pass the gate (%s)"},
+	"report.usage":                   {Chinese: "用法: cr report compare old.json new.json [--format markdown|json|terminal]", English: "usage: cr report compare old.json new.json [--format markdown|json|terminal]"},
+	"report.usage_compare":           {Chinese: "用法: cr report compare old.json new.json", English: "usage: cr report compare old.json new.json"},
+	"report.err.unknown_subcommand":  {Chinese: "未知的 report 子命令: %s", English: "unknown report subcommand: %s"},
+	"history.usage":                  {Chinese: "用法: cr history list [--repo 仓库] [--since 2024-01-01] [--limit 20] [--format text|json] | show <ID> [--format text|json|report]", English: "usage: cr history list [--repo repository] [--since 2024-01-01] [--limit 20] [--format text|json] | show <ID> [--format text|json|report]"},
+	"history.usage_show":             {Chinese: "用法: cr history show <ID> [--format text|json|report]，ID 见 cr history list", English: "usage: cr history show <ID> [--format text|json|report], see cr history list for the IDs"},
+	"history.err.unknown_subcommand": {Chinese: "未知的 history 子命令: %s", English: "unknown history subcommand: %s"},
+	"history.err.since":              {Chinese: "无效的日期 %s，格式为 2006-01-02", English: "invalid date %s, expected 2006-01-02"},
+	"history.err.format":             {Chinese: "不支持的输出格式: %s，可选值：text, json", English: "unsupported output format: %s, expected text or json"},
+	"history.err.show_format":        {Chinese: "不支持的输出格式: %s，可选值：text, json, report", English: "unsupported output format: %s, expected text, json or report"},
+	"history.err.not_found":          {Chinese: "没有评审记录 %s，ID 见 cr history list", English: "no review record %s, see cr history list for the IDs"},
+	"history.empty":                  {Chinese: "没有评审记录", English: "no review records"},
+	"history.col.time":               {Chinese: "时间", English: "Time"},
+	"history.col.repo":               {Chinese: "仓库", English: "Repository"},
+	"history.col.ref":                {Chinese: "版本", English: "Ref"},
+	"history.col.issues":             {Chinese: "问题", English: "Issues"},
+	"history.col.score":              {Chinese: "质量分", English: "Score"},
+	"history.col.cost":               {Chinese: "费用", English: "Cost"},
+	"history.col.gate":               {Chinese: "门禁", English: "Gate"},
+	"history.show.title":             {Chinese: "评审 %s", English: "Review %s"},
+	"history.show.repo":              {Chinese: "  仓库:   %s", English: "  Repo:   %s"},
+	"history.show.change":            {Chinese: "  变更:   %s %s", English: "  Change: %s %s"},
+	"history.show.ref":               {Chinese: "  版本:   %s", English: "  Ref:    %s"},
+	"history.show.time":              {Chinese: "  时间:   %s（%s 发起）", English: "  Time:   %s (triggered by %s)"},
+	"history.show.time_duration":     {Chinese: "  时间:   %s（%s 发起，耗时 %s）", English: "  Time:   %s (triggered by %s, took %s)"},
+	"history.show.model":             {Chinese: "  模型:   %s", English: "  Model:  %s"},
+	"history.show.result":            {Chinese: "  结果:   %d 个文件，%d 个问题（error %d，warning %d，info %d），质量分 %d（%s）", English: "  Result: %d files, %d issues (error %d, warning %d, info %d), score %d (%s)"},
+	"history.show.tokens":            {Chinese: "  token:  输入 %d（缓存命中 %d），输出 %d", English: "  Tokens: %d input (%d cached), %d output"},
+	"history.show.cost":              {Chinese: "  费用:   %.4f %s", English: "  Cost:   %.4f %s"},
+	"history.show.gate":              {Chinese: "  门禁:   %s", English: "  Gate:   %s"},
+	"stats.err.source":               {Chinese: "不支持的反馈来源: %s，可选值：github, gitlab", English: "unsupported feedback source: %s, expected github or gitlab"},
+	"stats.err.not_published":        {Chinese: "没有问题 %s 的发布记录，请先用 --from 同步该问题所在 PR 的反馈", English: "no published record for issue %s, sync the feedback of its PR with --from first"},
+	"stats.synced":                   {Chinese: "已同步 %d 条行内评论的反馈", English: "synced feedback for %d inline comments"},
+	"stats.title":                    {Chinese: "模型校准", English: "Model calibration"},
+	"stats.empty":                    {Chinese: "还没有反馈记录，可以用 cr stats --from github --pr <编号> 从已发布的行内评论同步", English: "no feedback yet, sync it from published inline comments with cr stats --from github --pr <number>"},
+	"stats.header":                   {Chinese: "| 模型 | 已发布 | 已驳回 | 精确率 | 最低报告级别 |", English: "| Model | Published | Dismissed | Precision | Minimum severity |"},
+	"stats.too_few":                  {Chinese: "-（反馈不足 %d 条）", English: "- (fewer than %d feedback entries)"},
+	"stats.note":                     {Chinese: "👎 多于 👍 的行内评论视为被驳回；启用 --calibrate（或配置项 review.calibrate）后，精确率低的模型只报告较高级别的问题", English: "Inline comments with more 👎 than 👍 count as dismissed; with --calibrate (or the review.calibrate setting), models with low precision only report higher severities"},
+	"publish.err.min_severity":       {Chinese: "无效的严重程度: %s", English: "invalid severity: %s"},
+	"publish.err.summary":            {Chinese: "生成总结评论失败: %v", English: "failed to generate the summary comment: %v"},
+	"publish.err.target":             {Chinese: "不支持的发布目标: %s，可选值：github, gitlab, gitea, bitbucket, gerrit", English: "unsupported publish target: %s, expected github, gitlab, gitea, bitbucket or gerrit"},
+	"publish.err.pr_files":           {Chinese: "获取 pull request #%d 的改动文件失败: %v", English: "failed to list the files of pull request #%d: %v"},
+	"publish.err.pr_diff":            {Chinese: "获取 pull request #%d 的差异失败: %v", English: "failed to get the diff of pull request #%d: %v"},
+	"publish.err.mr_files":           {Chinese: "获取 merge request !%d 的改动文件失败: %v", English: "failed to list the files of merge request !%d: %v"},
+	"publish.err.change_files":       {Chinese: "获取变更 %d 的改动文件失败: %v", English: "failed to list the files of change %d: %v"},
+	"publish.dry_run_position":       {Chinese: "%s（差异位置 %d）", English: "%s (diff position %d)"},
+	"publish.dry_run_pr":             {Chinese: "将在 %s#%d 发布 %d 条行内评论，%d 个问题不在差异中，只出现在总结评论里", English: "would post %[3]d inline comments on %[1]s#%[2]d; %[4]d issues are outside the diff and only appear in the summary comment"},
+	"publish.dry_run_gitlab":         {Chinese: "将在 %s!%d 创建 %d 条行内讨论，%d 个问题不在差异中，只出现在总结评论里", English: "would open %[3]d inline discussions on %[1]s!%[2]d; %[4]d issues are outside the diff and only appear in the summary comment"},
+	"publish.dry_run_bitbucket":      {Chinese: "将在 %s#%d 的 Code Insights 报告中添加 %d 条注解，%d 个问题超出报告上限", English: "would add %[3]d annotations to the Code Insights report of %[1]s#%[2]d; %[4]d issues exceed the report limit"},
+	"publish.dry_run_gerrit":         {Chinese: "将在变更 %d 的补丁集 %s 上发布 %d 条机器人评论，%d 个问题不在改动的文件中，只出现在评审消息里", English: "would post %[3]d robot comments on patch set %[2]s of change %[1]d; %[4]d issues are outside the changed files and only appear in the review message"},
+	"publish.published_pr":           {Chinese: "已在 %s#%d 发布评审：%d 条行内评论（%d 个问题已评论过，%d 个问题不在差异中）", English: "published the review on %s#%d: %d inline comments (%d already commented, %d outside the diff)"},
+	"publish.published_gitlab":       {Chinese: "已在 %s!%d 发布评审：%d 条行内讨论（%d 个问题已评论过，%d 个问题不在差异中）", English: "published the review on %s!%d: %d inline discussions (%d already commented, %d outside the diff)"},
+	"publish.published_bitbucket":    {Chinese: "已在 %s#%d 发布评审：Code Insights 报告包含 %d 条注解（%d 个问题超出报告上限）", English: "published the review on %s#%d: the Code Insights report has %d annotations (%d issues over the report limit)"},
+	"publish.published_gerrit":       {Chinese: "已在变更 %d 上发布评审：%d 条机器人评论（%d 个问题已评论过，%d 个问题不在改动的文件中）", English: "published the review on change %d: %d robot comments (%d already commented, %d outside the changed files)"},
+	"fake-provider.err.extra_args":   {Chinese: "多余的参数: %v", English: "unexpected arguments: %v"},
+	"fake-provider.err.delay":        {Chinese: "--delay 不能为负数", English: "--delay must not be negative"},
+	"fake-provider.err.listen":       {Chinese: "监听 %s 失败: %v", English: "failed to listen on %s: %v"},
+	"fake-provider.listening":        {Chinese: "模拟模型服务: http://%s/v1/chat/completions", English: "fake model service: http://%s/v1/chat/completions"},
+	"compare-ranges.usage":           {Chinese: "用法: cr compare-ranges [--format markdown|json] A..B C..D [...]", English: "usage: cr compare-ranges [--format markdown|json] A..B C..D [...]"},
+	"compare-ranges.err.range":       {Chinese: "提交范围格式应为 A..B 或 A...B：%s", English: "commit ranges must look like A..B or A...B: %s"},
+	"compare-ranges.err.concurrency": {Chinese: "并发数必须大于0: %d", English: "concurrency must be greater than 0: %d"},
+	"compare-ranges.err.format":      {Chinese: "方案对比报告只支持 markdown 和 json 格式: %s", English: "the comparison report only supports markdown and json: %s"},
+	"compare-ranges.err.changes":     {Chinese: "获取 %s 的改动失败: %v", English: "failed to get the changes of %s: %v"},
+	"compare-ranges.err.no_changes":  {Chinese: "%s 中没有需要对比的改动", English: "%s has no changes to compare"},
+	"compare-ranges.comparing":       {Chinese: "正在对比 %d 个提交范围……", English: "comparing %d commit ranges..."},
+	"compare-ranges.tokens":          {Chinese: "模型调用共使用 %d tokens", English: "model calls used %d tokens in total"},
+
+	// 评审引擎的日志
+	"engine.checkpoint_failed":  {Chinese: "保存评审断点失败: %v", English: "failed to save the review checkpoint: %v"},
+	"engine.review_failed":      {Chinese: "评审失败 - %s: %v", English: "review failed - %s: %v"},
+	"engine.cache_hit":          {Chinese: "%s: 命中缓存，评审结果生成于 %s（%s前），模型 %s", English: "%s: cache hit, reviewed at %s (%s ago) by %s"},
+	"engine.cache_miss":         {Chinese: "%s: 未命中缓存", English: "%s: cache miss"},
+	"engine.usage":              {Chinese: "%s: 输入 %d tokens（提示缓存命中 %d），输出 %d tokens，耗时 %s", English: "%s: %d input tokens (%d cached), %d output tokens, took %s"},
+	"engine.cache_write_failed": {Chinese: "缓存评审结果失败: %v", English: "failed to cache the review result: %v"},
+}
+
+func init() {
+	for key, text := range cliMessages {
+		messages[key] = text
+	}
+}
@@ -0,0 +1,85 @@
+package i18n
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Number 格式化整数，使用千位分隔符
+func (l Lang) Number(n int) string {
+	s := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, s = "-", s[1:]
+	}
+	var buf strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteRune(c)
+	}
+	return sign + buf.String()
+}
+
+// Decimal 格式化小数，整数部分使用千位分隔符
+func (l Lang) Decimal(f float64, precision int) string {
+	s := strconv.FormatFloat(math.Abs(f), 'f', precision, 64)
+	intPart, frac, _ := strings.Cut(s, ".")
+	n, _ := strconv.Atoi(intPart)
+	result := l.Number(n)
+	if frac != "" {
+		result += "." + frac
+	}
+	if f < 0 && strings.Trim(s, "0.") != "" {
+		result = "-" + result
+	}
+	return result
+}
+
+// Percent 格式化百分比，value 为 0-100 之间的数值
+func (l Lang) Percent(value float64) string {
+	return l.Decimal(value, 1) + "%"
+}
+
+// Duration 格式化耗时，精确到秒，一秒以内精确到毫秒
+func (l Lang) Duration(d time.Duration) string {
+	if d < time.Second {
+		return l.T("format.milliseconds", d.Milliseconds())
+	}
+	d = d.Round(time.Second)
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	switch {
+	case h > 0:
+		return l.T("format.hours_minutes", h, m)
+	case m > 0:
+		return l.T("format.minutes_seconds", m, s)
+	default:
+		return l.T("format.seconds", s)
+	}
+}
+
+// currencySymbols 常见货币的符号
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"CNY": "¥",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+}
+
+// Money 格式化金额，小于 1 的金额保留四位小数以免显示为零
+func (l Lang) Money(amount float64, currency string) string {
+	precision := 2
+	if math.Abs(amount) < 1 {
+		precision = 4
+	}
+	currency = strings.ToUpper(currency)
+	if symbol, ok := currencySymbols[currency]; ok {
+		return symbol + l.Decimal(amount, precision)
+	}
+	return fmt.Sprintf("%s %s", l.Decimal(amount, precision), currency)
+}
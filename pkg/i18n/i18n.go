@@ -0,0 +1,51 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lang 报告与提示使用的语言
+type Lang string
+
+const (
+	Chinese Lang = "zh"
+	English Lang = "en"
+)
+
+// Default 默认语言
+const Default = Chinese
+
+// Parse 解析语言代码，兼容 zh-CN、en_US 等带地区的写法，空字符串返回默认语言
+func Parse(s string) (Lang, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return Default, nil
+	}
+	if i := strings.IndexAny(s, "-_"); i > 0 {
+		s = s[:i]
+	}
+	switch Lang(s) {
+	case Chinese, English:
+		return Lang(s), nil
+	default:
+		return "", fmt.Errorf("不支持的语言: %s，可选值：zh, en", s)
+	}
+}
+
+// T 返回指定键在该语言下的文本，带参数时按 fmt.Sprintf 格式化
+// 缺少该语言的翻译时回退到默认语言，键不存在时原样返回键名
+func (l Lang) T(key string, args ...interface{}) string {
+	entry, ok := messages[key]
+	if !ok {
+		return key
+	}
+	text, ok := entry[l]
+	if !ok {
+		text = entry[Default]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
@@ -0,0 +1,50 @@
+package i18n
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// locale 命令行帮助、错误和进度信息使用的语言，与报告语言（--lang）相互独立
+var locale = DetectLocale()
+
+// DetectLocale 依次从 LC_ALL、LC_MESSAGES、LANG 环境变量推断界面语言
+// 中文环境使用中文，其他明确指定的语言环境使用英文；未设置或为 C、POSIX 时使用默认语言
+func DetectLocale() Lang {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := strings.ToLower(os.Getenv(name))
+		if value == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(value, "zh"):
+			return Chinese
+		case value == "c" || value == "posix" || strings.HasPrefix(value, "c."):
+			return Default
+		default:
+			return English
+		}
+	}
+	return Default
+}
+
+// SetLocale 设置界面语言
+func SetLocale(l Lang) {
+	locale = l
+}
+
+// Locale 返回当前的界面语言
+func Locale() Lang {
+	return locale
+}
+
+// M 返回命令行消息在当前界面语言下的文本，带参数时按 fmt.Sprintf 格式化
+func M(key string, args ...interface{}) string {
+	return locale.T(key, args...)
+}
+
+// Errorf 返回使用当前界面语言的错误
+func Errorf(key string, args ...interface{}) error {
+	return errors.New(M(key, args...))
+}
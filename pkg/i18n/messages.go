@@ -0,0 +1,292 @@
+package i18n
+
+// messages 文本目录，键按使用位置分组
+var messages = map[string]map[Lang]string{
+	// 数值格式
+	"format.milliseconds":    {Chinese: "%d毫秒", English: "%dms"},
+	"format.seconds":         {Chinese: "%d秒", English: "%ds"},
+	"format.minutes_seconds": {Chinese: "%d分%d秒", English: "%dm %ds"},
+	"format.hours_minutes":   {Chinese: "%d小时%d分", English: "%dh %dm"},
+
+	// 报告标题与项目信息
+	"report.title":        {Chinese: "代码评审报告", English: "Code Review Report"},
+	"report.project_info": {Chinese: "项目信息", English: "Project"},
+	"report.project_name": {Chinese: "项目名称：", English: "Project: "},
+	"report.commit_id":    {Chinese: "提交ID：", English: "Commit: "},
+	"report.review_time":  {Chinese: "评审时间：", English: "Reviewed at: "},
+
+	// 统计信息
+	"report.stats":                 {Chinese: "评审结果统计", English: "Summary"},
+	"report.change_stats":          {Chinese: "代码变更统计", English: "Change Statistics"},
+	"report.metric":                {Chinese: "指标", English: "Metric"},
+	"report.value":                 {Chinese: "数值", English: "Value"},
+	"report.files_reviewed":        {Chinese: "评审文件数", English: "Files reviewed"},
+	"report.total_issues":          {Chinese: "问题总数", English: "Total issues"},
+	"report.severity_distribution": {Chinese: "问题严重程度分布", English: "Issues by Severity"},
+	"report.severity_column":       {Chinese: "严重程度", English: "Severity"},
+	"report.count":                 {Chinese: "数量", English: "Count"},
+	"report.files_short":           {Chinese: "文件 %d", English: "%d files"},
+	"report.issues_short":          {Chinese: "问题 %d", English: "%d issues"},
+	"report.no_issues":             {Chinese: "没有发现问题", English: "No issues found"},
+
+	// 与上次评审对比
+	"report.comparison":                 {Chinese: "与上次评审对比", English: "Compared with Previous Review"},
+	"report.previous_at":                {Chinese: "上次评审时间：", English: "Previous review: "},
+	"report.previous":                   {Chinese: "上次", English: "Previous"},
+	"report.current":                    {Chinese: "本次", English: "Current"},
+	"report.change":                     {Chinese: "变化", English: "Change"},
+	"report.quality_score":              {Chinese: "质量分", English: "Quality score"},
+	"report.grade":                      {Chinese: "评级", English: "Grade"},
+	"report.change_kinds":               {Chinese: "改动类型", English: "Change kinds"},
+	"report.kind_column":                {Chinese: "类型", English: "Kind"},
+	"report.files_column":               {Chinese: "文件数", English: "Files"},
+	"report.kinds_short":                {Chinese: "改动类型：%s", English: "Change kinds: %s"},
+	"kind.feature":                      {Chinese: "新功能", English: "feature"},
+	"kind.bugfix":                       {Chinese: "缺陷修复", English: "bugfix"},
+	"kind.refactor":                     {Chinese: "重构", English: "refactor"},
+	"kind.test":                         {Chinese: "测试", English: "test"},
+	"kind.docs":                         {Chinese: "文档", English: "docs"},
+	"kind.config":                       {Chinese: "配置", English: "config"},
+	"kind.dependency":                   {Chinese: "依赖", English: "dependency"},
+	"badge.label":                       {Chinese: "代码评审", English: "code review"},
+	"badge.message":                     {Chinese: "%d %s · %d 个问题", English: "%d %s · %d issues"},
+	"badge.no_issues":                   {Chinese: "%d %s · 无问题", English: "%d %s · no issues"},
+	"report.executive_summary":          {Chinese: "执行摘要", English: "Executive summary"},
+	"report.top_risks":                  {Chinese: "主要风险", English: "Top risks"},
+	"report.themes":                     {Chinese: "问题主题", English: "Themes"},
+	"report.theme_count":                {Chinese: "%d 个问题，最高 %s", English: "%d issues, highest %s"},
+	"report.theme_more":                 {Chinese: "%s，另有 %d 处", English: "%s and %d more"},
+	"report.decision_label":             {Chinese: "合并建议：%s", English: "Recommendation: %s"},
+	"decision.approve":                  {Chinese: "可以合并", English: "approve"},
+	"decision.approve_with_suggestions": {Chinese: "可以合并，建议后续改进", English: "approve with suggestions"},
+	"decision.request_changes":          {Chinese: "修改后再合并", English: "request changes"},
+	"report.score_grade":                {Chinese: "%d（%s）", English: "%d (%s)"},
+	"report.score_short":                {Chinese: "质量分 %d（%s）", English: "Score %d (%s)"},
+	"report.resolved":                   {Chinese: "已解决的问题", English: "Resolved Issues"},
+	"report.resolved_short":             {Chinese: "已解决 %d", English: "%d resolved"},
+	"report.new_issues":                 {Chinese: "新增的问题", English: "New Issues"},
+	"report.persisting":                 {Chinese: "仍存在的问题", English: "Persisting Issues"},
+	"report.persisting_short":           {Chinese: "仍存在 %d", English: "%d persisting"},
+	"report.new_short":                  {Chinese: "新增 %d", English: "%d new"},
+	"report.full_report":                {Chinese: "查看完整报告", English: "View the full report"},
+	"report.github_truncated":           {Chinese: "评论长度超出 GitHub 上限，另有 %d 个文件的 %d 个问题未显示", English: "Truncated to fit GitHub's comment limit: %d more files with %d issues are not shown"},
+	"report.bitbucket_details":          {Chinese: "发现 %d 个问题，已标注在差异的对应行上", English: "Found %d issues, annotated on the changed lines"},
+	"report.bitbucket_reports_tab":      {Chinese: "各问题的详情见 pull request 的 Reports 标签页", English: "See the Reports tab of the pull request for details on each issue"},
+	"report.gerrit_robot_comments":      {Chinese: "各问题以机器人评论的形式标注在对应的行上，多行的改进建议可以作为修复直接应用", English: "Each issue is posted as a robot comment on its line; multi-line suggestions can be applied as fixes"},
+	"report.gerrit_outside":             {Chinese: "以下问题不在本次改动的文件中：", English: "The following issues are outside the files changed in this patch set:"},
+
+	// 实现方案对比
+	"report.range_comparison": {Chinese: "实现方案对比", English: "Implementation Comparison"},
+	"report.range_column":     {Chinese: "提交范围", English: "Range"},
+	"report.added_lines":      {Chinese: "新增行", English: "Added"},
+	"report.removed_lines":    {Chinese: "删除行", English: "Removed"},
+	"report.strengths":        {Chinese: "优点", English: "Strengths"},
+	"report.weaknesses":       {Chinese: "缺点", English: "Weaknesses"},
+	"report.differences":      {Chinese: "主要差异", English: "Key Differences"},
+	"report.recommendation":   {Chinese: "建议", English: "Recommendation"},
+
+	// Go 包影响范围
+	"report.impact":                {Chinese: "影响范围", English: "Impact Radius"},
+	"report.package":               {Chinese: "包", English: "Package"},
+	"report.direct_dependents":     {Chinese: "直接依赖方", English: "Direct dependents"},
+	"report.transitive_dependents": {Chinese: "全部依赖方", English: "All dependents"},
+	"report.impact_short":          {Chinese: "%s 影响 %d 个包", English: "%s affects %d packages"},
+
+	// 依赖变更
+	"report.dependencies":            {Chinese: "依赖变更", English: "Dependency Changes"},
+	"report.dependency":              {Chinese: "依赖", English: "Dependency"},
+	"report.dependency_change":       {Chinese: "变更", English: "Change"},
+	"report.dependency_version":      {Chinese: "版本", English: "Version"},
+	"report.dependency_risk":         {Chinese: "风险", English: "Risk"},
+	"report.dependency_notes":        {Chinese: "说明", English: "Notes"},
+	"report.dependency_jump":         {Chinese: "（%s）", English: " (%s)"},
+	"report.dependency_breaking":     {Chinese: "不兼容变更：", English: "Breaking: "},
+	"report.dependency_supply_chain": {Chinese: "供应链：", English: "Supply chain: "},
+	"report.dependencies_short":      {Chinese: "依赖变更 %d 项，高风险 %d 项", English: "%d dependency changes, %d high risk"},
+	"dependency.added":               {Chinese: "新增", English: "added"},
+	"dependency.updated":             {Chinese: "更新", English: "updated"},
+	"dependency.removed":             {Chinese: "删除", English: "removed"},
+	"jump.major":                     {Chinese: "主版本", English: "major"},
+	"jump.minor":                     {Chinese: "次版本", English: "minor"},
+	"jump.patch":                     {Chinese: "修订版本", English: "patch"},
+	"jump.downgrade":                 {Chinese: "降级", English: "downgrade"},
+	"jump.unknown":                   {Chinese: "无法比较", English: "not comparable"},
+	"risk.high":                      {Chinese: "高", English: "high"},
+	"risk.medium":                    {Chinese: "中", English: "medium"},
+	"risk.low":                       {Chinese: "低", English: "low"},
+
+	// 测试结果
+	"report.tests":         {Chinese: "测试结果", English: "Test Results"},
+	"report.test_command":  {Chinese: "命令：", English: "Command: "},
+	"report.test_duration": {Chinese: "耗时：", English: "Duration: "},
+	"report.test_passed":   {Chinese: "测试全部通过", English: "All tests passed"},
+	"report.test_failed":   {Chinese: "测试失败", English: "Tests failed"},
+	"report.test_failed_n": {Chinese: "测试失败（%d 个用例）", English: "Tests failed (%d)"},
+
+	// 变更行覆盖率
+	"report.coverage":         {Chinese: "变更行覆盖率", English: "Changed-Line Coverage"},
+	"report.coverage_total":   {Chinese: "已覆盖的变更行：", English: "Covered changed lines: "},
+	"report.coverage_short":   {Chinese: "变更行覆盖率 %.1f%%（%d/%d）", English: "Changed-line coverage %.1f%% (%d/%d)"},
+	"report.file_column":      {Chinese: "文件", English: "File"},
+	"report.covered_lines":    {Chinese: "已覆盖/可执行", English: "Covered/Executable"},
+	"report.coverage_percent": {Chinese: "覆盖率", English: "Coverage"},
+	"report.uncovered_lines":  {Chinese: "未覆盖的行", English: "Uncovered lines"},
+
+	// 按作者分组
+	"report.by_author":      {Chinese: "按作者分组", English: "By Author"},
+	"report.author":         {Chinese: "作者", English: "Author"},
+	"report.files_changed":  {Chinese: "修改的文件", English: "Files changed"},
+	"report.issues_column":  {Chinese: "问题", English: "Issues"},
+	"report.unknown_author": {Chinese: "未知作者", English: "Unknown author"},
+	"report.author_short":   {Chinese: "%s：%s", English: "%s: %s"},
+
+	// HTML 报告交互
+	"report.search_placeholder": {Chinese: "搜索标题、描述、文件…", English: "Search title, description, file…"},
+	"report.all_severities":     {Chinese: "全部级别", English: "All severities"},
+	"report.all_files":          {Chinese: "全部文件", English: "All files"},
+	"report.collapse_all":       {Chinese: "全部折叠", English: "Collapse all"},
+	"report.expand_all":         {Chinese: "全部展开", English: "Expand all"},
+	"report.issue_count_format": {Chinese: "显示 {shown}/{total} 个问题", English: "Showing {shown} of {total} issues"},
+
+	// 评审统计
+	"report.changed_lines":        {Chinese: "变更行数", English: "Changed lines"},
+	"report.issues_per_100":       {Chinese: "每百行问题数", English: "Issues per 100 changed lines"},
+	"report.duration":             {Chinese: "评审耗时", English: "Review duration"},
+	"report.tokens":               {Chinese: "Token 用量", English: "Tokens"},
+	"report.tokens_detail":        {Chinese: "%s（输入 %s / 输出 %s）", English: "%s (input %s / output %s)"},
+	"report.cost":                 {Chinese: "预估费用", English: "Estimated cost"},
+	"report.prompt_cache":         {Chinese: "提示缓存命中", English: "Prompt cache hits"},
+	"report.prompt_cache_detail":  {Chinese: "%s tokens（占输入 %s）", English: "%s tokens (%s of input)"},
+	"report.prompt_cache_short":   {Chinese: "缓存命中 %s tokens", English: "%s cached tokens"},
+	"report.cost_saved":           {Chinese: "节省 %s", English: "saved %s"},
+	"report.percent_column":       {Chinese: "占比", English: "Share"},
+	"report.changed_lines_short":  {Chinese: "变更 %s 行", English: "%s changed lines"},
+	"report.issues_per_100_short": {Chinese: "每百行 %s 个问题", English: "%s issues/100 lines"},
+
+	// 问题列表
+	"report.overall_suggestions": {Chinese: "整体优化建议", English: "Overall Suggestions"},
+	"report.issue_list":          {Chinese: "详细问题列表", English: "Issues"},
+	"report.file":                {Chinese: "文件：", English: "File: "},
+	"report.location":            {Chinese: "位置：", English: "Location: "},
+	"report.line":                {Chinese: "第%d行", English: "line %d"},
+	"report.severity":            {Chinese: "严重程度：", English: "Severity: "},
+	"report.description":         {Chinese: "描述：", English: "Description: "},
+	"report.suggestion":          {Chinese: "建议：", English: "Suggestion: "},
+	"report.references":          {Chinese: "参考：", English: "References: "},
+	"report.fingerprint":         {Chinese: "指纹：", English: "Fingerprint: "},
+	"report.hunk_omitted":        {Chinese: "… 省略 %d 行", English: "… %d lines omitted"},
+	"report.list_separator":      {Chinese: "，", English: ", "},
+
+	// 评审时限
+	"report.time_boxed": {
+		Chinese: "评审达到时间上限（%s），%d 个文件未评审，本报告只包含部分结果",
+		English: "Review hit the %s time limit; %d files were not reviewed and this report is partial",
+	},
+
+	// 评审失败
+	"report.failed_files": {
+		Chinese: "%d 个文件评审失败，本报告不包含这些文件的问题",
+		English: "%d files failed to review; issues in them are not included in this report",
+	},
+	"report.retries": {Chinese: "重试 %d 次", English: "%d retries"},
+
+	// 跳过的二进制文件
+	"report.binary_skipped": {
+		Chinese: "跳过了 %d 个二进制文件，未进行评审",
+		English: "%d binary files were skipped and not reviewed",
+	},
+
+	// 新增的大型二进制文件和资源文件
+	"asset.title":  {Chinese: "新增大型二进制文件或资源文件", English: "Large binary or asset file added"},
+	"asset.binary": {Chinese: "二进制文件", English: "binary file"},
+	"asset.asset":  {Chinese: "资源文件", English: "asset file"},
+	"asset.description": {
+		Chinese: "新增的%s大小为 %s，超过上限 %s。大文件直接提交会永久增大仓库体积，拖慢克隆和拉取。",
+		English: "The added %s is %s, exceeding the limit of %s. Committing large files permanently grows the repository and slows down clones and fetches.",
+	},
+	"asset.suggestion": {
+		Chinese: "使用 Git LFS 管理该文件（git lfs track \"%s\"），或改为从制品库、对象存储下载。",
+		English: "Track the file with Git LFS (git lfs track \"%s\"), or download it from an artifact repository or object storage instead.",
+	},
+
+	// 评审缓存附录
+	"report.cache_appendix": {Chinese: "附录：评审缓存", English: "Appendix: Review Cache"},
+	"report.cache_notice": {
+		Chinese: "%d/%d 个文件的评审结果来自缓存。缓存结果可能由其他模型或较早的提示生成，如需重新评审请清除缓存（~/.cr/cache）",
+		English: "Results for %d of %d files came from the cache. Cached results may have been produced by another model or an earlier prompt; clear the cache (~/.cr/cache) to review them again",
+	},
+	"report.cache_column":     {Chinese: "缓存", English: "Cache"},
+	"report.cached_at_column": {Chinese: "生成时间", English: "Generated"},
+	"report.model_column":     {Chinese: "模型", English: "Model"},
+	"report.cache_hit":        {Chinese: "命中", English: "hit"},
+	"report.cache_miss":       {Chinese: "未命中", English: "miss"},
+	"report.cache_age":        {Chinese: "%s（%s前）", English: "%s (%s ago)"},
+
+	// 评审通知
+	"notify.title":       {Chinese: "代码评审完成：%s", English: "Code review finished: %s"},
+	"notify.gate_passed": {Chinese: "通过质量门禁", English: "Quality gate passed"},
+	"notify.gate_failed": {Chinese: "未通过质量门禁", English: "Quality gate failed"},
+	"notify.model":       {Chinese: "模型", English: "Model"},
+	"notify.top_issues":  {Chinese: "主要问题", English: "Top issues"},
+	"notify.field":       {Chinese: "%s：%s", English: "%s: %s"},
+	"notify.issue":       {Chinese: "%s（%s）", English: "%s (%s)"},
+	"notify.view_change": {Chinese: "查看合并请求", English: "View pull request"},
+
+	// 目录与分组
+	"report.toc":               {Chinese: "目录", English: "Contents"},
+	"report.categories_column": {Chinese: "类别", English: "Categories"},
+	"report.file_summary":      {Chinese: "%d 个问题", English: "%d issues"},
+	"report.all_categories":    {Chinese: "全部类别", English: "All categories"},
+
+	// 问题类别
+	"category.security":        {Chinese: "安全", English: "Security"},
+	"category.bug":             {Chinese: "缺陷", English: "Bugs"},
+	"category.performance":     {Chinese: "性能", English: "Performance"},
+	"category.maintainability": {Chinese: "可维护性", English: "Maintainability"},
+	"category.best_practice":   {Chinese: "最佳实践", English: "Best practices"},
+	"category.test":            {Chinese: "测试", English: "Tests"},
+	"category.other":           {Chinese: "其他", English: "Other"},
+
+	// 提示词
+	"prompt.summary_respond_in": {
+		Chinese: "\n请使用中文撰写 overview 和 risks。\n",
+		English: "\nWrite the overview and risks in English.\n",
+	},
+	"prompt.themes_respond_in": {
+		Chinese: "\n请使用中文撰写 title 和 summary。\n",
+		English: "\nWrite every title and summary in English.\n",
+	},
+	"prompt.compare_respond_in": {
+		Chinese: "\n请使用中文撰写各字段的内容。\n",
+		English: "\nWrite every field in English.\n",
+	},
+	"prompt.respond_in": {
+		Chinese: "\n请使用中文撰写问题的标题、描述和建议。\n",
+		English: "\nWrite every issue title, description and suggestion in English.\n",
+	},
+
+	// 评审结果的安全过滤
+	"safety.unknown_file":  {Chinese: "[未知文件]", English: "[unknown file]"},
+	"safety.omitted_lines": {Chinese: "省略 %d 行", English: "%d lines omitted"},
+
+	// 评审历史页面
+	"dashboard.html_lang":        {Chinese: "zh-CN", English: "en"},
+	"dashboard.title":            {Chinese: "AI 代码评审历史", English: "AI Code Review History"},
+	"dashboard.repositories":     {Chinese: "仓库", English: "Repositories"},
+	"dashboard.repository":       {Chinese: "仓库", English: "Repository"},
+	"dashboard.reviews":          {Chinese: "评审次数", English: "Reviews"},
+	"dashboard.latest":           {Chinese: "最近评审", English: "Latest review"},
+	"dashboard.issues":           {Chinese: "问题数", English: "Issues"},
+	"dashboard.score":            {Chinese: "质量分", English: "Quality score"},
+	"dashboard.empty":            {Chinese: "还没有评审记录。", English: "No reviews yet."},
+	"dashboard.all_repositories": {Chinese: "所有仓库", English: "All repositories"},
+	"dashboard.trend":            {Chinese: "问题数趋势", English: "Issue trend"},
+	"dashboard.chart_note":       {Chinese: "最近 %d 次评审（%s 至 %s），纵轴上限 %d 个问题；质量分以 0–100 绘制。", English: "Last %d reviews (%s to %s), vertical axis up to %d issues; quality score plotted on 0–100."},
+	"dashboard.bar_title":        {Chinese: "%s  %d 个问题，质量分 %d", English: "%s  %d issues, quality score %d"},
+	"dashboard.history":          {Chinese: "评审记录", English: "Reviews"},
+	"dashboard.time":             {Chinese: "时间", English: "Time"},
+	"dashboard.ref":              {Chinese: "版本", English: "Revision"},
+	"dashboard.files":            {Chinese: "文件", English: "Files"},
+	"dashboard.report":           {Chinese: "报告", English: "Report"},
+	"dashboard.render_failed":    {Chinese: "生成评审报告失败: %v", English: "failed to generate the review report: %v"},
+}
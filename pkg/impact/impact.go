@@ -0,0 +1,177 @@
+package impact
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// DefaultThreshold 被依赖的包数达到该值时视为高影响改动
+const DefaultThreshold = 10
+
+// listTimeout 执行 go list 的超时时间
+const listTimeout = 2 * time.Minute
+
+// PackageImpact 一个被修改的包在模块内的影响范围
+type PackageImpact struct {
+	// 被修改的包
+	Package string
+	// 该包中被修改的文件
+	Files []string
+	// 直接导入该包的模块内的包
+	Direct []string
+	// 直接或间接依赖该包的模块内的包（包含 Direct）
+	Transitive []string
+}
+
+// goPackage go list 输出的包信息
+type goPackage struct {
+	importPath string
+	dir        string
+	imports    map[string]bool
+	deps       map[string]bool
+}
+
+// IsGoModule 判断目录是否为 Go 模块根目录
+func IsGoModule(root string) bool {
+	_, err := os.Stat(filepath.Join(root, "go.mod"))
+	return err == nil
+}
+
+// Analyze 计算改动文件所在包在模块内的反向依赖
+// files 为相对 root 的路径，非 Go 文件和不属于任何包的文件会被忽略；结果按影响范围从大到小排序
+func Analyze(root string, files []string) ([]PackageImpact, error) {
+	packages, err := listPackages(root)
+	if err != nil {
+		return nil, err
+	}
+
+	byDir := make(map[string]*goPackage, len(packages))
+	for _, pkg := range packages {
+		byDir[pkg.dir] = pkg
+	}
+
+	// 按包归集改动文件
+	changed := make(map[string]*PackageImpact)
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".go") {
+			continue
+		}
+		pkg, ok := byDir[filepath.Dir(filepath.Join(root, file))]
+		if !ok {
+			continue
+		}
+		if changed[pkg.importPath] == nil {
+			changed[pkg.importPath] = &PackageImpact{Package: pkg.importPath}
+		}
+		changed[pkg.importPath].Files = append(changed[pkg.importPath].Files, file)
+	}
+
+	var result []PackageImpact
+	for path, impact := range changed {
+		for _, pkg := range packages {
+			if pkg.importPath == path {
+				continue
+			}
+			if pkg.imports[path] {
+				impact.Direct = append(impact.Direct, pkg.importPath)
+			}
+			if pkg.deps[path] {
+				impact.Transitive = append(impact.Transitive, pkg.importPath)
+			}
+		}
+		sort.Strings(impact.Direct)
+		sort.Strings(impact.Transitive)
+		result = append(result, *impact)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if len(result[i].Transitive) != len(result[j].Transitive) {
+			return len(result[i].Transitive) > len(result[j].Transitive)
+		}
+		return result[i].Package < result[j].Package
+	})
+	return result, nil
+}
+
+// listPackages 列出模块内的所有包及其依赖
+func listPackages(root string) ([]*goPackage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), listTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "go", "list", "-e",
+		"-f", "{{.ImportPath}}\t{{.Dir}}\t{{join .Imports \" \"}}\t{{join .Deps \" \"}}", "./...")
+	cmd.Dir = root
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go list 执行失败: %v\n%s", err, stderr.String())
+	}
+
+	var packages []*goPackage
+	scanner := bufio.NewScanner(&stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 4 {
+			continue
+		}
+		packages = append(packages, &goPackage{
+			importPath: fields[0],
+			dir:        fields[1],
+			imports:    toSet(fields[2]),
+			deps:       toSet(fields[3]),
+		})
+	}
+	return packages, scanner.Err()
+}
+
+// toSet 将空格分隔的列表转换为集合
+func toSet(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, item := range strings.Fields(list) {
+		set[item] = true
+	}
+	return set
+}
+
+// HighImpactIssues 为被依赖的包数达到阈值的改动生成警告，提示评审者关注兼容性和回归测试
+func HighImpactIssues(impacts []PackageImpact, threshold int) []types.Issue {
+	if threshold <= 0 {
+		return nil
+	}
+	var issues []types.Issue
+	for _, impact := range impacts {
+		if len(impact.Transitive) < threshold || len(impact.Files) == 0 {
+			continue
+		}
+		issues = append(issues, types.Issue{
+			Title:    fmt.Sprintf("改动影响 %d 个包", len(impact.Transitive)),
+			FilePath: impact.Files[0],
+			Severity: types.SeverityWarning,
+			Category: types.CategoryMaintainability,
+			Description: fmt.Sprintf("包 %s 被模块内 %d 个包直接或间接依赖（直接导入：%d 个），改动可能影响较大范围的功能。",
+				impact.Package, len(impact.Transitive), len(impact.Direct)),
+			Suggestion: "确认导出的函数、类型和行为保持兼容，并运行依赖方的测试：go test " + strings.Join(limit(impact.Direct, 5), " "),
+		})
+	}
+	return issues
+}
+
+// limit 返回列表的前 n 项
+func limit(items []string, n int) []string {
+	if len(items) > n {
+		return items[:n]
+	}
+	return items
+}
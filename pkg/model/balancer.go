@@ -0,0 +1,187 @@
+package model
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// BalanceStrategy 模型池的负载均衡策略
+type BalanceStrategy string
+
+const (
+	// 平滑加权轮询，实际权重为配置权重乘以健康度
+	Weighted BalanceStrategy = "weighted"
+	// 依次轮流使用各提供方，跳过暂停中的提供方
+	RoundRobin BalanceStrategy = "round_robin"
+)
+
+const (
+	// 健康度下限，失败再多的提供方也保留少量流量以便恢复
+	minHealth = 0.05
+	// 每次调用成功后恢复的健康度
+	healthRecovery = 0.2
+	// 连续失败达到该次数时暂停使用该提供方
+	maxConsecutiveFailures = 3
+	// 暂停使用的时长
+	backendCooldown = 30 * time.Second
+)
+
+// Backend 模型池中的一个提供方
+type Backend struct {
+	// 用于日志的名称，如 deepseek@api.deepseek.com
+	Name string
+	// 接口类型，如 deepseek、qwen
+	Type string
+	// 该提供方使用的模型名称，为空时使用请求中的模型
+	Model string
+	// 权重，小于等于 0 时视为 1
+	Weight int
+	Client ModelClient
+}
+
+// backendState 提供方的健康状况
+type backendState struct {
+	Backend
+	// 健康度，0~1，失败时减半，成功时逐步恢复
+	health float64
+	// 平滑加权轮询的当前权重
+	current float64
+	// 连续失败次数
+	failures int
+	// 暂停使用的截止时间
+	downUntil time.Time
+}
+
+// Balancer 在服务同一模型的多个提供方之间分配请求的客户端
+// 调用失败的提供方健康度下降、分到的请求减少，连续失败时暂停使用一段时间；
+// 单次请求失败时依次换用其他提供方，全部失败才返回错误
+type Balancer struct {
+	strategy BalanceStrategy
+	mu       sync.Mutex
+	backends []*backendState
+	next     int
+}
+
+// NewBalancer 创建负载均衡客户端，strategy 为空时使用加权策略
+func NewBalancer(strategy BalanceStrategy, backends []Backend) (*Balancer, error) {
+	if strategy == "" {
+		strategy = Weighted
+	}
+	if strategy != Weighted && strategy != RoundRobin {
+		return nil, fmt.Errorf("不支持的负载均衡策略: %s，可选值：weighted, round_robin", strategy)
+	}
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("模型池中至少需要一个提供方")
+	}
+	b := &Balancer{strategy: strategy}
+	for _, backend := range backends {
+		if backend.Weight <= 0 {
+			backend.Weight = 1
+		}
+		b.backends = append(b.backends, &backendState{Backend: backend, health: 1})
+	}
+	return b, nil
+}
+
+// Types 返回池中各提供方的接口类型，去除重复
+func (b *Balancer) Types() []string {
+	var types []string
+	seen := make(map[string]bool)
+	for _, backend := range b.backends {
+		if !seen[backend.Type] {
+			seen[backend.Type] = true
+			types = append(types, backend.Type)
+		}
+	}
+	return types
+}
+
+// Chat 选择一个提供方发送请求，失败时依次换用其他提供方，全部失败时返回最后一个错误
+func (b *Balancer) Chat(req *ChatRequest) (*ChatResponse, error) {
+	tried := make(map[*backendState]bool, len(b.backends))
+	var lastErr error
+	for len(tried) < len(b.backends) {
+		backend := b.pick(tried)
+		attempt := *req
+		if backend.Model != "" {
+			attempt.Model = backend.Model
+		}
+		resp, err := backend.Client.Chat(&attempt)
+		b.record(backend, err)
+		if err == nil {
+			return resp, nil
+		}
+		tried[backend] = true
+		lastErr = &ProviderError{Provider: backend.Name, Err: err}
+		if len(tried) < len(b.backends) {
+			log.Printf("模型服务 %s 调用失败，改用其他提供方: %v\n", backend.Name, err)
+		}
+	}
+	return nil, lastErr
+}
+
+// pick 在未尝试过的提供方中选择一个，优先选择未暂停的；全部暂停时仍从中选择，避免请求直接失败
+func (b *Balancer) pick(tried map[*backendState]bool) *backendState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	var candidates []*backendState
+	for _, backend := range b.backends {
+		if !tried[backend] && now.After(backend.downUntil) {
+			candidates = append(candidates, backend)
+		}
+	}
+	if len(candidates) == 0 {
+		for _, backend := range b.backends {
+			if !tried[backend] {
+				candidates = append(candidates, backend)
+			}
+		}
+	}
+
+	if b.strategy == RoundRobin {
+		for i := 0; i < len(b.backends); i++ {
+			backend := b.backends[(b.next+i)%len(b.backends)]
+			for _, c := range candidates {
+				if c == backend {
+					b.next = (b.next + i + 1) % len(b.backends)
+					return backend
+				}
+			}
+		}
+	}
+
+	// 平滑加权轮询：各候选累加实际权重，选出当前权重最大的，再减去总权重
+	var best *backendState
+	total := 0.0
+	for _, c := range candidates {
+		weight := float64(c.Weight) * c.health
+		c.current += weight
+		total += weight
+		if best == nil || c.current > best.current {
+			best = c
+		}
+	}
+	best.current -= total
+	return best
+}
+
+// record 根据调用结果更新提供方的健康状况
+func (b *Balancer) record(backend *backendState, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		backend.failures = 0
+		backend.health = min(1, backend.health+healthRecovery)
+		return
+	}
+	backend.failures++
+	backend.health = max(minHealth, backend.health/2)
+	if backend.failures >= maxConsecutiveFailures {
+		backend.downUntil = time.Now().Add(backendCooldown)
+		log.Printf("模型服务 %s 连续失败 %d 次，暂停使用 %s\n", backend.Name, backend.failures, backendCooldown)
+	}
+}
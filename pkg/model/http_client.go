@@ -49,11 +49,14 @@ func (c *HTTPClient) SendRequest(url string, req interface{}, resp interface{})
 			break
 		}
 		lastErr = err
+		if chat, ok := req.(*ChatRequest); ok && chat.OnRetry != nil && retries < 2 {
+			chat.OnRetry(retries+1, err)
+		}
 		time.Sleep(time.Duration(retries+1) * time.Second)
 	}
 
 	if httpResp == nil {
-		return fmt.Errorf("all retries failed: %v", lastErr)
+		return fmt.Errorf("all retries failed: %w", lastErr)
 	}
 	defer httpResp.Body.Close()
 
@@ -63,11 +66,11 @@ func (c *HTTPClient) SendRequest(url string, req interface{}, resp interface{})
 	}
 
 	if httpResp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API request failed with status %d: %s", httpResp.StatusCode, string(body))
+		return &APIError{StatusCode: httpResp.StatusCode, Body: string(body)}
 	}
 
 	if err := json.Unmarshal(body, resp); err != nil {
-		return fmt.Errorf("unmarshal response failed: %v", err)
+		return fmt.Errorf("unmarshal response failed: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,224 @@
+package model
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// extLanguages 文件扩展名到语言标识的映射
+var extLanguages = map[string]string{
+	".go":         "go",
+	".py":         "python",
+	".pyw":        "python",
+	".js":         "javascript",
+	".mjs":        "javascript",
+	".cjs":        "javascript",
+	".jsx":        "javascript",
+	".ts":         "typescript",
+	".tsx":        "typescript",
+	".java":       "java",
+	".kt":         "kotlin",
+	".rb":         "ruby",
+	".rs":         "rust",
+	".c":          "c",
+	".h":          "c",
+	".cc":         "cpp",
+	".cpp":        "cpp",
+	".hpp":        "cpp",
+	".php":        "php",
+	".sh":         "shell",
+	".bash":       "shell",
+	".zsh":        "shell",
+	".sql":        "sql",
+	".yaml":       "yaml",
+	".yml":        "yaml",
+	".cmake":      "cmake",
+	".mk":         "makefile",
+	".dockerfile": "dockerfile",
+}
+
+// fileNameLanguages 特殊文件名到语言标识的映射
+var fileNameLanguages = map[string]string{
+	"dockerfile":     "dockerfile",
+	"containerfile":  "dockerfile",
+	"makefile":       "makefile",
+	"gnumakefile":    "makefile",
+	"cmakelists.txt": "cmake",
+	"rakefile":       "ruby",
+	"gemfile":        "ruby",
+	"jenkinsfile":    "groovy",
+}
+
+// interpreterLanguages shebang 解释器到语言标识的映射
+var interpreterLanguages = map[string]string{
+	"sh":      "shell",
+	"bash":    "shell",
+	"zsh":     "shell",
+	"dash":    "shell",
+	"ksh":     "shell",
+	"python":  "python",
+	"python2": "python",
+	"python3": "python",
+	"node":    "javascript",
+	"deno":    "typescript",
+	"ruby":    "ruby",
+	"perl":    "perl",
+	"php":     "php",
+	"make":    "makefile",
+}
+
+var (
+	// vim: set ft=python: / vim: filetype=sh / vi: syntax=make
+	vimModeline = regexp.MustCompile(`(?:vi|vim|ex):.*?\b(?:ft|filetype|syntax)=([A-Za-z0-9_+-]+)`)
+	// -*- mode: python -*- / -*- Python -*-
+	emacsModeline = regexp.MustCompile(`-\*-(?:.*?\bmode:\s*([\w+-]+).*?|\s*([\w+-]+)\s*)-\*-`)
+)
+
+// modelineAliases 模式行中常见的语言别名
+var modelineAliases = map[string]string{
+	"sh":           "shell",
+	"bash":         "shell",
+	"zsh":          "shell",
+	"py":           "python",
+	"python3":      "python",
+	"js":           "javascript",
+	"ts":           "typescript",
+	"make":         "makefile",
+	"docker":       "dockerfile",
+	"c++":          "cpp",
+	"golang":       "go",
+	"shell-script": "shell",
+}
+
+// DetectLanguage 根据文件名和内容推断代码语言
+// 优先使用文件名和扩展名，无法识别时再依次尝试 shebang 和编辑器模式行
+func DetectLanguage(filePath, content string) string {
+	base := strings.ToLower(filepath.Base(filePath))
+	if lang, ok := fileNameLanguages[base]; ok {
+		return lang
+	}
+	// Dockerfile.dev、Makefile.linux 等带后缀的变体
+	if prefix, _, found := strings.Cut(base, "."); found {
+		if lang, ok := fileNameLanguages[prefix]; ok && (lang == "dockerfile" || lang == "makefile") {
+			return lang
+		}
+	}
+	if lang, ok := extLanguages[strings.ToLower(filepath.Ext(base))]; ok {
+		return lang
+	}
+
+	lines := sourceLines(content)
+	if len(lines) > 0 {
+		if lang := languageFromShebang(lines[0]); lang != "" {
+			return lang
+		}
+	}
+	if lang := languageFromModeline(lines); lang != "" {
+		return lang
+	}
+	return languageFromContent(lines)
+}
+
+// sourceLines 从内容中提取源码行，如果内容是 diff 则还原新版本的行
+func sourceLines(content string) []string {
+	raw := strings.Split(content, "\n")
+	if !strings.HasPrefix(content, "diff --git") && !strings.HasPrefix(content, "--- ") {
+		return raw
+	}
+
+	var lines []string
+	inHunk := false
+	for _, line := range raw {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case !inHunk:
+			continue
+		case strings.HasPrefix(line, "+"), strings.HasPrefix(line, " "):
+			lines = append(lines, line[1:])
+		}
+	}
+	return lines
+}
+
+// languageFromShebang 解析 #! 行中的解释器
+func languageFromShebang(line string) string {
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := filepath.Base(fields[0])
+	// #!/usr/bin/env python3 或 #!/usr/bin/env -S deno run
+	if interpreter == "env" {
+		interpreter = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") {
+				interpreter = filepath.Base(f)
+				break
+			}
+		}
+	}
+	if lang, ok := interpreterLanguages[interpreter]; ok {
+		return lang
+	}
+	// python3.11、ruby2.7 等带版本号的解释器
+	if lang, ok := interpreterLanguages[strings.TrimRight(interpreter, "0123456789.")]; ok {
+		return lang
+	}
+	return ""
+}
+
+// languageFromModeline 在文件首尾几行中查找 vim/emacs 模式行
+func languageFromModeline(lines []string) string {
+	const scanLines = 5
+	candidates := lines
+	if len(lines) > scanLines*2 {
+		candidates = append(append([]string{}, lines[:scanLines]...), lines[len(lines)-scanLines:]...)
+	}
+
+	for _, line := range candidates {
+		var name string
+		if m := vimModeline.FindStringSubmatch(line); m != nil {
+			name = m[1]
+		} else if m := emacsModeline.FindStringSubmatch(line); m != nil {
+			name = m[1] + m[2]
+		} else {
+			continue
+		}
+		name = strings.ToLower(name)
+		if alias, ok := modelineAliases[name]; ok {
+			return alias
+		}
+		return name
+	}
+	return ""
+}
+
+// languageFromContent 根据内容特征做最后的猜测
+func languageFromContent(lines []string) string {
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		upper := strings.ToUpper(trimmed)
+		switch {
+		case strings.HasPrefix(trimmed, "package ") && !strings.Contains(trimmed, ";"):
+			return "go"
+		case strings.HasPrefix(upper, "FROM ") && len(strings.Fields(trimmed)) >= 2:
+			return "dockerfile"
+		case strings.HasPrefix(upper, "CMAKE_MINIMUM_REQUIRED("), strings.HasPrefix(upper, "PROJECT("):
+			return "cmake"
+		case strings.HasPrefix(trimmed, ".PHONY:"):
+			return "makefile"
+		}
+		// 只检查第一条有效语句
+		return ""
+	}
+	return ""
+}
@@ -1,6 +1,9 @@
 package model
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+)
 
 // ModelClient 定义通用的AI模型客户端接口
 type ModelClient interface {
@@ -24,6 +27,8 @@ type Config struct {
 	APIKey string `json:"api_key"`
 	// 模型名称
 	Model string `json:"model"`
+	// 接口地址，为空时使用该类型的默认地址；同一模型由多个服务商提供时用于指定服务商
+	BaseURL string `json:"base_url,omitempty"`
 	// 其他通用配置参数
 	MaxTokens   int     `json:"max_tokens"`
 	Temperature float64 `json:"temperature"`
@@ -44,6 +49,8 @@ type ChatRequest struct {
 	N                int               `json:"n"`
 	ResponseFormat   map[string]string `json:"response_format"`
 	Tools            []Tool            `json:"tools,omitempty"`
+	// 请求失败、即将重试时的回调，attempt 从 1 开始；不会发送给模型服务
+	OnRetry func(attempt int, err error) `json:"-"`
 }
 
 // Message 定义聊天消息的结构
@@ -88,6 +95,34 @@ type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+	// Number of prompt tokens served from the provider's prompt cache (DeepSeek)
+	PromptCacheHitTokens int `json:"prompt_cache_hit_tokens,omitempty"`
+	// Prompt token breakdown returned by OpenAI-compatible APIs (OpenAI, Qwen)
+	PromptTokensDetails *PromptTokensDetails `json:"prompt_tokens_details,omitempty"`
+}
+
+// PromptTokensDetails describes how prompt tokens were billed
+type PromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+// CachedTokens returns the number of prompt tokens that hit the provider's prompt cache
+func (u Usage) CachedTokens() int {
+	cached := u.PromptCacheHitTokens
+	if d := u.PromptTokensDetails; d != nil && d.CachedTokens > cached {
+		cached = d.CachedTokens
+	}
+	return cached
+}
+
+// Add accumulates another usage record; cache hits from either field are summed into PromptCacheHitTokens
+func (u *Usage) Add(other Usage) {
+	cached := u.CachedTokens() + other.CachedTokens()
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+	u.PromptCacheHitTokens = cached
+	u.PromptTokensDetails = nil
 }
 
 // ToolCall 定义工具调用的结构
@@ -117,6 +152,14 @@ func NewBaseModelClient(cfg *Config) *BaseModelClient {
 	}
 }
 
+// endpoint 返回请求的接口地址，配置了 BaseURL 时使用配置的地址
+func (c *BaseModelClient) endpoint(defaultURL string) string {
+	if c.config.BaseURL != "" {
+		return c.config.BaseURL
+	}
+	return defaultURL
+}
+
 // ApplyConfig 应用配置到请求
 func (c *BaseModelClient) ApplyConfig(req *ChatRequest) {
 	if req.Model == "" {
@@ -186,7 +229,7 @@ func (m *ModelManager) GetClient(modelType string) (ModelClient, error) {
 
 	// 检查客户端是否已经创建
 	if client, exists := m.clients[modelType]; exists {
-		fmt.Printf("使用已创建的模型客户端: %s (模型: %s)\n", modelType, m.config.Models[modelType].Model)
+		fmt.Fprintf(os.Stderr, "使用已创建的模型客户端: %s (模型: %s)\n", modelType, m.config.Models[modelType].Model)
 		return client, nil
 	}
 
@@ -204,6 +247,6 @@ func (m *ModelManager) GetClient(modelType string) (ModelClient, error) {
 
 	// 缓存客户端
 	m.clients[modelType] = client
-	fmt.Printf("创建新的模型客户端: %s (模型: %s)\n", modelType, config.Model)
+	fmt.Fprintf(os.Stderr, "创建新的模型客户端: %s (模型: %s)\n", modelType, config.Model)
 	return client, nil
 }
@@ -23,7 +23,7 @@ func (c *OpenAIClient) Chat(req *ChatRequest) (*ChatResponse, error) {
 
 	// 发送请求并获取响应
 	var resp ChatResponse
-	err := c.httpClient.SendRequest(OpenAIAPIURL, req, &resp)
+	err := c.httpClient.SendRequest(c.endpoint(OpenAIAPIURL), req, &resp)
 	if err != nil {
 		return nil, err
 	}
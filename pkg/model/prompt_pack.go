@@ -0,0 +1,215 @@
+package model
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// embeddedPrompts 内置的提示包，随二进制一起发布
+//
+//go:embed prompts
+var embeddedPrompts embed.FS
+
+// 提示包中的文件，名称为相对提示目录的路径去掉 .md 扩展名
+const (
+	// 基础提示，全文作为系统提示的开头
+	basePromptName = "base"
+	// 评审重点，每行以 "- " 开头的内容为一条
+	focusPromptName = "focus"
+	// languages/<语言> 为该语言的最佳实践，格式同评审重点
+	languagePromptDir = "languages"
+)
+
+// PromptSource 提示文件的来源，后者覆盖前者
+type PromptSource string
+
+const (
+	PromptEmbedded PromptSource = "embedded"
+	PromptUser     PromptSource = "user"
+	PromptRepo     PromptSource = "repo"
+	// 命令行临时指定的文件，如 cr prompts test --template
+	PromptOverride PromptSource = "override"
+)
+
+// PromptFile 提示包中的一个文件
+type PromptFile struct {
+	// 文件名称，如 base、focus、languages/go
+	Name   string
+	Source PromptSource
+	// 用户目录或仓库目录中的文件路径，内置文件为空
+	Path    string
+	Content string
+}
+
+// PromptPack 按 内置 < 用户目录 < 仓库目录 的顺序合并后的提示包，同名文件以后者为准
+type PromptPack struct {
+	files map[string]PromptFile
+}
+
+// LoadPromptPack 加载提示包，userDir、repoDir 为空或不存在时跳过
+func LoadPromptPack(userDir, repoDir string) (*PromptPack, error) {
+	pack := &PromptPack{files: make(map[string]PromptFile)}
+	sub, _ := fs.Sub(embeddedPrompts, "prompts")
+	if err := pack.load(sub, PromptEmbedded, ""); err != nil {
+		return nil, err
+	}
+	for _, layer := range []struct {
+		dir    string
+		source PromptSource
+	}{{userDir, PromptUser}, {repoDir, PromptRepo}} {
+		if layer.dir == "" {
+			continue
+		}
+		if info, err := os.Stat(layer.dir); err != nil || !info.IsDir() {
+			continue
+		}
+		if err := pack.load(os.DirFS(layer.dir), layer.source, layer.dir); err != nil {
+			return nil, err
+		}
+	}
+	return pack, nil
+}
+
+// EmbeddedPromptPack 返回只包含内置文件的提示包
+func EmbeddedPromptPack() *PromptPack {
+	pack, err := LoadPromptPack("", "")
+	if err != nil {
+		// 内置文件随二进制发布，读取不会失败
+		panic(err)
+	}
+	return pack
+}
+
+// load 读取目录中的所有 .md 文件，覆盖已有的同名文件
+func (p *PromptPack) load(fsys fs.FS, source PromptSource, dir string) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("读取提示目录失败: %v", err)
+		}
+		if d.IsDir() || path.Ext(name) != ".md" {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("读取提示文件失败: %v", err)
+		}
+		file := PromptFile{Name: strings.TrimSuffix(name, ".md"), Source: source, Content: string(data)}
+		if dir != "" {
+			file.Path = filepath.Join(dir, filepath.FromSlash(name))
+		}
+		p.files[file.Name] = file
+		return nil
+	})
+}
+
+// Overlay 用目录中的文件覆盖提示包中的同名文件，目录结构与 .cr/prompts 相同
+func (p *PromptPack) Overlay(dir string) error {
+	return p.load(os.DirFS(dir), PromptOverride, dir)
+}
+
+// Replace 用 path 的内容替换提示包中名为 name 的文件，name 为 base、focus 或 languages/<语言>
+func (p *PromptPack) Replace(name, path string) error {
+	name = strings.TrimSuffix(filepath.ToSlash(name), ".md")
+	if name != basePromptName && name != focusPromptName && !strings.HasPrefix(name, languagePromptDir+"/") {
+		return fmt.Errorf("无效的提示文件名称 %s，可选值：%s、%s 或 %s/<语言>", name, basePromptName, focusPromptName, languagePromptDir)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取提示文件失败: %v", err)
+	}
+	p.files[name] = PromptFile{Name: name, Source: PromptOverride, Path: path, Content: string(data)}
+	return nil
+}
+
+// Files 返回提示包中的所有文件，按名称排列
+func (p *PromptPack) Files() []PromptFile {
+	files := make([]PromptFile, 0, len(p.files))
+	for _, f := range p.files {
+		files = append(files, f)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files
+}
+
+// File 返回指定名称的文件，名称可以带 .md 扩展名
+func (p *PromptPack) File(name string) (PromptFile, bool) {
+	f, ok := p.files[strings.TrimSuffix(filepath.ToSlash(name), ".md")]
+	return f, ok
+}
+
+// ReviewPrompt 根据提示包创建评审提示模板，其余设置与 DefaultReviewPrompt 相同
+func (p *PromptPack) ReviewPrompt() (*ReviewPrompt, error) {
+	base, ok := p.files[basePromptName]
+	if !ok || strings.TrimSpace(base.Content) == "" {
+		return nil, fmt.Errorf("提示包缺少基础提示 %s.md", basePromptName)
+	}
+	prompt := &ReviewPrompt{
+		BasePrompt:            strings.TrimSpace(base.Content),
+		FocusAreas:            promptItems(p.files[focusPromptName].Content),
+		OutputFormat:          "json",
+		HardenInjection:       true,
+		LanguageBestPractices: make(map[string][]string),
+	}
+	for name, f := range p.files {
+		lang, ok := strings.CutPrefix(name, languagePromptDir+"/")
+		if !ok {
+			continue
+		}
+		if items := promptItems(f.Content); len(items) > 0 {
+			prompt.LanguageBestPractices[lang] = items
+		}
+	}
+	return prompt, nil
+}
+
+// promptItems 返回列表文件中以 "- " 开头的各行
+func promptItems(content string) []string {
+	var items []string
+	for _, line := range strings.Split(content, "\n") {
+		if item, ok := strings.CutPrefix(strings.TrimSpace(line), "- "); ok {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// ExportPrompts 将内置的提示文件复制到 dir，作为自定义的起点；names 为空时导出全部文件
+// 已存在的文件只在 force 为 true 时覆盖，返回写入的文件路径
+func ExportPrompts(dir string, names []string, force bool) ([]string, error) {
+	embedded := EmbeddedPromptPack()
+	var files []PromptFile
+	if len(names) == 0 {
+		files = embedded.Files()
+	}
+	for _, name := range names {
+		f, ok := embedded.File(name)
+		if !ok {
+			return nil, fmt.Errorf("没有内置的提示文件 %s", name)
+		}
+		files = append(files, f)
+	}
+
+	var written []string
+	for _, f := range files {
+		target := filepath.Join(dir, filepath.FromSlash(f.Name)+".md")
+		if _, err := os.Stat(target); err == nil && !force {
+			return written, fmt.Errorf("%s 已存在，使用 --force 覆盖", target)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return written, fmt.Errorf("创建提示目录失败: %v", err)
+		}
+		if err := os.WriteFile(target, []byte(f.Content), 0644); err != nil {
+			return written, fmt.Errorf("写入提示文件失败: %v", err)
+		}
+		written = append(written, target)
+	}
+	return written, nil
+}
@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DingTalk 通过自定义机器人发送到钉钉群
+type DingTalk struct {
+	webhook string
+	// 机器人的加签密钥，为空时不签名
+	secret string
+}
+
+// NewDingTalk 创建钉钉通知，secret 为机器人安全设置中的加签密钥，未启用加签时为空
+func NewDingTalk(webhook, secret string) *DingTalk {
+	return &DingTalk{webhook: webhook, secret: secret}
+}
+
+func (n *DingTalk) Name() string {
+	return "dingtalk"
+}
+
+// Notify 以 Markdown 消息发送评审摘要
+func (n *DingTalk) Notify(s *Summary) error {
+	webhook, err := n.signedURL(time.Now())
+	if err != nil {
+		return err
+	}
+	msg := map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": s.Lang.T("notify.title", s.Project),
+			"text":  markdownSummary(s),
+		},
+	}
+	data, err := postJSON(webhook, msg)
+	if err != nil {
+		return err
+	}
+	return checkErrcode(data)
+}
+
+// signedURL 在 Webhook 地址后附加时间戳和签名
+// 签名为以密钥对 "时间戳\n密钥" 做 HmacSHA256 后的 base64 编码，时间戳为毫秒
+func (n *DingTalk) signedURL(now time.Time) (string, error) {
+	if n.secret == "" {
+		return n.webhook, nil
+	}
+	u, err := url.Parse(n.webhook)
+	if err != nil {
+		return "", fmt.Errorf("无效的钉钉 Webhook 地址")
+	}
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(n.secret))
+	mac.Write([]byte(timestamp + "\n" + n.secret))
+	query := u.Query()
+	query.Set("timestamp", timestamp)
+	query.Set("sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// checkErrcode 检查钉钉、企业微信接口在 HTTP 200 的响应中返回的错误码
+func checkErrcode(data []byte) error {
+	var resp struct {
+		Errcode int    `json:"errcode"`
+		Errmsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("解析通知响应失败: %v", err)
+	}
+	if resp.Errcode != 0 {
+		return fmt.Errorf("发送通知失败: %d %s", resp.Errcode, resp.Errmsg)
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// smtpTimeout 发送一封邮件的时间上限
+const smtpTimeout = time.Minute
+
+// Email 通过 SMTP 发送评审摘要邮件，并附带 HTML 格式的完整报告，适合定时评审默认分支后通知相关人员
+type Email struct {
+	// host:port 形式的服务地址
+	addr string
+	host string
+	// smtps 使用 TLS 连接，smtp 在服务端支持时通过 STARTTLS 加密
+	implicitTLS bool
+	user        string
+	password    string
+	from        string
+	to          []string
+}
+
+// NewEmail 创建邮件通知，smtpURL 形如 smtps://用户名:密码@smtp.example.com:465 或 smtp://smtp.example.com:587，
+// from 为空且用户名是邮件地址时以用户名为发件人
+func NewEmail(smtpURL, from string, to []string) (*Email, error) {
+	u, err := url.Parse(smtpURL)
+	if err != nil || (u.Scheme != "smtp" && u.Scheme != "smtps") || u.Hostname() == "" {
+		// 地址中可能包含密码，不写入错误信息
+		return nil, fmt.Errorf("无效的 SMTP 地址，格式应为 smtp://或 smtps://[用户名:密码@]主机[:端口]")
+	}
+	n := &Email{host: u.Hostname(), implicitTLS: u.Scheme == "smtps", to: to}
+	port := u.Port()
+	if port == "" {
+		port = "587"
+		if n.implicitTLS {
+			port = "465"
+		}
+	}
+	n.addr = net.JoinHostPort(n.host, port)
+	if u.User != nil {
+		n.user = u.User.Username()
+		n.password, _ = u.User.Password()
+	}
+
+	if from == "" && strings.Contains(n.user, "@") {
+		from = n.user
+	}
+	if from == "" {
+		return nil, fmt.Errorf("未配置邮件的发件人（from）")
+	}
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("无效的发件人地址 %s: %v", from, err)
+	}
+	n.from = from
+	if len(to) == 0 {
+		return nil, fmt.Errorf("未配置邮件的收件人（to）")
+	}
+	for _, addr := range to {
+		if _, err := mail.ParseAddress(addr); err != nil {
+			return nil, fmt.Errorf("无效的收件人地址 %s: %v", addr, err)
+		}
+	}
+	return n, nil
+}
+
+func (n *Email) Name() string {
+	return "email"
+}
+
+// Notify 发送评审摘要邮件，正文为摘要文本，配置了 HTMLReport 时附带完整报告
+func (n *Email) Notify(s *Summary) error {
+	var report []byte
+	if s.HTMLReport != nil {
+		var err error
+		if report, err = s.HTMLReport(); err != nil {
+			return fmt.Errorf("生成邮件附带的报告失败: %v", err)
+		}
+	}
+	msg, err := n.message(s, report, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := n.send(msg); err != nil {
+		return fmt.Errorf("发送邮件失败: %v", err)
+	}
+	return nil
+}
+
+// message 生成 MIME 格式的邮件，report 为空时不带附件
+func (n *Email) message(s *Summary, report []byte, now time.Time) ([]byte, error) {
+	t := s.Lang.T
+	subject := t("notify.title", s.Project)
+	if s.Ref != "" {
+		subject += " (" + s.Ref + ")"
+	}
+	subject += " - " + t("report.issues_short", s.Issues())
+
+	var buf bytes.Buffer
+	body := multipart.NewWriter(&buf)
+	header := []string{
+		"From: " + headerAddress(n.from),
+		"To: " + headerAddresses(n.to),
+		"Subject: " + mime.BEncoding.Encode("utf-8", subject),
+		"Date: " + now.Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary=" + body.Boundary(),
+	}
+	var msg bytes.Buffer
+	msg.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+
+	text, err := body.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeBase64(text, []byte(markdownSummary(s)))
+
+	if len(report) > 0 {
+		attachment, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {"text/html; charset=utf-8"},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {`attachment; filename="cr-report.html"`},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(attachment, report)
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	msg.Write(buf.Bytes())
+	return msg.Bytes(), nil
+}
+
+// writeBase64 以每行76个字符的 base64 编码写入内容
+func writeBase64(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
+
+// send 连接 SMTP 服务发送邮件，服务端支持 STARTTLS 时先加密连接，配置了用户名时进行认证
+func (n *Email) send(msg []byte) error {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	tlsConfig := &tls.Config{ServerName: n.host}
+	var conn net.Conn
+	var err error
+	if n.implicitTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", n.addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", n.addr)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(smtpTimeout))
+	c, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if !n.implicitTLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+	if n.user != "" {
+		if err := c.Auth(smtp.PlainAuth("", n.user, n.password, n.host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(address(n.from)); err != nil {
+		return err
+	}
+	for _, to := range n.to {
+		if err := c.Rcpt(address(to)); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// headerAddress 返回邮件头中的地址，名称中的非 ASCII 字符按 RFC 2047 编码
+func headerAddress(s string) string {
+	if addr, err := mail.ParseAddress(s); err == nil {
+		return addr.String()
+	}
+	return s
+}
+
+// headerAddresses 返回邮件头中逗号分隔的多个地址
+func headerAddresses(list []string) string {
+	addrs := make([]string, len(list))
+	for i, s := range list {
+		addrs[i] = headerAddress(s)
+	}
+	return strings.Join(addrs, ", ")
+}
+
+// address 返回 "名称 <地址>" 形式中的地址部分
+func address(s string) string {
+	if addr, err := mail.ParseAddress(s); err == nil {
+		return addr.Address
+	}
+	return s
+}
@@ -0,0 +1,172 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// Feishu 通过自定义机器人发送到飞书（Lark）群，消息为交互式卡片
+type Feishu struct {
+	webhook string
+	// 机器人的签名密钥，为空时不签名
+	secret string
+}
+
+// NewFeishu 创建飞书通知，secret 为机器人安全设置中的签名校验密钥，未启用时为空
+func NewFeishu(webhook, secret string) *Feishu {
+	return &Feishu{webhook: webhook, secret: secret}
+}
+
+func (n *Feishu) Name() string {
+	return "feishu"
+}
+
+type feishuText struct {
+	Tag     string `json:"tag"`
+	Content string `json:"content"`
+}
+
+type feishuField struct {
+	IsShort bool       `json:"is_short"`
+	Text    feishuText `json:"text"`
+}
+
+type feishuButton struct {
+	Tag  string     `json:"tag"`
+	Text feishuText `json:"text"`
+	Type string     `json:"type"`
+	URL  string     `json:"url"`
+}
+
+type feishuElement struct {
+	Tag     string         `json:"tag"`
+	Text    *feishuText    `json:"text,omitempty"`
+	Fields  []feishuField  `json:"fields,omitempty"`
+	Actions []feishuButton `json:"actions,omitempty"`
+}
+
+type feishuCard struct {
+	Config struct {
+		WideScreenMode bool `json:"wide_screen_mode"`
+	} `json:"config"`
+	Header struct {
+		Template string     `json:"template"`
+		Title    feishuText `json:"title"`
+	} `json:"header"`
+	Elements []feishuElement `json:"elements"`
+}
+
+// feishuMessage 机器人消息，启用签名校验时附带时间戳和签名
+type feishuMessage struct {
+	Timestamp string     `json:"timestamp,omitempty"`
+	Sign      string     `json:"sign,omitempty"`
+	MsgType   string     `json:"msg_type"`
+	Card      feishuCard `json:"card"`
+}
+
+// Notify 发送评审摘要卡片：标题颜色表示结果，正文为统计和最严重的几个问题，
+// 底部按钮链接到完整报告和 pull request
+func (n *Feishu) Notify(s *Summary) error {
+	msg := feishuMessage{MsgType: "interactive", Card: n.card(s)}
+	if n.secret != "" {
+		msg.Timestamp, msg.Sign = feishuSign(n.secret, time.Now())
+	}
+	data, err := postJSON(n.webhook, msg)
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("解析通知响应失败: %v", err)
+	}
+	if resp.Code != 0 {
+		return fmt.Errorf("发送通知失败: %d %s", resp.Code, resp.Msg)
+	}
+	return nil
+}
+
+// card 生成评审摘要卡片
+func (n *Feishu) card(s *Summary) feishuCard {
+	t := s.Lang.T
+	var card feishuCard
+	card.Config.WideScreenMode = true
+	title := t("notify.title", s.Project)
+	if s.Ref != "" {
+		title += " (" + s.Ref + ")"
+	}
+	card.Header.Title = feishuText{Tag: "plain_text", Content: title}
+	// 未通过门禁为红色，有 error 或 warning 为橙色，否则为绿色
+	switch {
+	case !s.Passed:
+		card.Header.Template = "red"
+	case s.Severities[types.SeverityError]+s.Severities[types.SeverityWarning] > 0:
+		card.Header.Template = "orange"
+	default:
+		card.Header.Template = "green"
+	}
+
+	gate := "✅ " + t("notify.gate_passed")
+	if !s.Passed {
+		gate = "❌ " + t("notify.gate_failed")
+	}
+	field := func(label, value string) feishuField {
+		return feishuField{IsShort: true, Text: feishuText{Tag: "lark_md", Content: fmt.Sprintf("**%s**\n%s", label, value)}}
+	}
+	fields := []feishuField{
+		field(t("report.files_reviewed"), s.Lang.Number(s.Files)),
+		field(t("report.total_issues"), s.Lang.Number(s.Issues())),
+		field(t("report.quality_score"), t("report.score_grade", s.Score, s.Grade)),
+	}
+	for _, severity := range severityOrder {
+		fields = append(fields, field(fmt.Sprintf("%s %s", severityEmojis[severity], severity), s.Lang.Number(s.Severities[severity])))
+	}
+	if s.Model != "" {
+		fields = append(fields, field(t("notify.model"), s.Model))
+	}
+	if s.Duration > 0 {
+		fields = append(fields, field(t("report.duration"), s.Lang.Duration(s.Duration)))
+	}
+	card.Elements = []feishuElement{
+		{Tag: "div", Text: &feishuText{Tag: "lark_md", Content: gate}},
+		{Tag: "div", Fields: fields},
+	}
+
+	if len(s.Top) > 0 {
+		lines := []string{fmt.Sprintf("**%s**", t("notify.top_issues"))}
+		for _, issue := range s.Top {
+			lines = append(lines, fmt.Sprintf("%s %s", severityEmojis[issue.Severity], t("notify.issue", issue.Title, issueLocation(issue))))
+		}
+		card.Elements = append(card.Elements, feishuElement{Tag: "hr"},
+			feishuElement{Tag: "div", Text: &feishuText{Tag: "lark_md", Content: strings.Join(lines, "\n")}})
+	}
+
+	var buttons []feishuButton
+	if s.ReportURL != "" {
+		buttons = append(buttons, feishuButton{Tag: "button", Text: feishuText{Tag: "plain_text", Content: t("report.full_report")}, Type: "primary", URL: s.ReportURL})
+	}
+	if s.ChangeURL != "" {
+		buttons = append(buttons, feishuButton{Tag: "button", Text: feishuText{Tag: "plain_text", Content: t("notify.view_change")}, Type: "default", URL: s.ChangeURL})
+	}
+	if len(buttons) > 0 {
+		card.Elements = append(card.Elements, feishuElement{Tag: "action", Actions: buttons})
+	}
+	return card
+}
+
+// feishuSign 计算飞书机器人的签名：以 "时间戳\n密钥" 为密钥对空字符串做 HmacSHA256 后 base64 编码，时间戳为秒
+func feishuSign(secret string, now time.Time) (timestamp, sign string) {
+	timestamp = strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return timestamp, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,279 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/config"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// 发送通知的时机
+const (
+	// WhenAlways 每次评审后都发送
+	WhenAlways = "always"
+	// WhenIssues 发现问题时发送
+	WhenIssues = "issues"
+	// WhenFailed 质量门禁未通过时发送
+	WhenFailed = "failed"
+)
+
+// ErrNoEndpoint 未设置保存 Webhook 或 SMTP 地址的环境变量，本地运行时通常没有设置，此时跳过该渠道
+var ErrNoEndpoint = errors.New("未设置通知的发送地址")
+
+// Summary 一次评审的结果摘要
+type Summary struct {
+	// 项目或仓库名称
+	Project string
+	// 评审的分支或提交范围，可以为空
+	Ref string
+	// 评审的文件数
+	Files int
+	// 各严重程度的问题数
+	Severities map[types.SeverityLevel]int
+	// 质量分和评级
+	Score int
+	Grade string
+	// 是否通过质量门禁
+	Passed bool
+	// 完整报告和 pull request / merge request 的链接，可以为空
+	ReportURL string
+	ChangeURL string
+	// 最严重的几个问题，按严重程度从高到低排列
+	Top []types.Issue
+	// 评审使用的模型和耗时，可以为空
+	Model    string
+	Duration time.Duration
+	// 通知使用的语言
+	Lang i18n.Lang
+	// 生成 HTML 格式的完整报告，为 nil 时邮件不附带报告
+	HTMLReport func() ([]byte, error)
+}
+
+// Issues 返回问题总数
+func (s *Summary) Issues() int {
+	total := 0
+	for _, n := range s.Severities {
+		total += n
+	}
+	return total
+}
+
+// maxTopIssues 通知中列出的问题数上限
+const maxTopIssues = 5
+
+// TopIssues 按严重程度从高到低选出最多 maxTopIssues 个问题，同一级别保持原有顺序
+func TopIssues(issues []types.Issue) []types.Issue {
+	sorted := append([]types.Issue(nil), issues...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Severity.Rank() > sorted[j].Severity.Rank()
+	})
+	if len(sorted) > maxTopIssues {
+		sorted = sorted[:maxTopIssues]
+	}
+	return sorted
+}
+
+// Notifier 把评审摘要发送到聊天工具等渠道
+type Notifier interface {
+	// Name 返回渠道名称，用于日志
+	Name() string
+	Notify(s *Summary) error
+}
+
+// severityOrder 通知中严重程度的展示顺序
+var severityOrder = []types.SeverityLevel{types.SeverityError, types.SeverityWarning, types.SeverityInfo}
+
+// Channel 按配置发送通知的渠道
+type Channel struct {
+	Notifier
+	when string
+}
+
+// Should 判断评审结果是否满足渠道配置的发送时机
+func (c *Channel) Should(s *Summary) bool {
+	switch c.when {
+	case WhenIssues:
+		return s.Issues() > 0
+	case WhenFailed:
+		return !s.Passed
+	default:
+		return true
+	}
+}
+
+// New 根据配置创建通知渠道，Webhook 和 SMTP 地址从配置指定的环境变量中读取
+// 环境变量未设置时返回包装了 ErrNoEndpoint 的错误
+func New(cfg config.NotifyConfig) (*Channel, error) {
+	when := cfg.When
+	switch when {
+	case "":
+		when = WhenAlways
+	case WhenAlways, WhenIssues, WhenFailed:
+	default:
+		return nil, fmt.Errorf("不支持的通知时机: %s，可选值：always, issues, failed", cfg.When)
+	}
+
+	var notifier Notifier
+	switch cfg.Type {
+	case "slack":
+		webhook, err := endpointURL(cfg.WebhookURLEnv, "SLACK_WEBHOOK_URL")
+		if err != nil {
+			return nil, err
+		}
+		notifier = NewSlack(webhook, cfg.Channel)
+	case "dingtalk":
+		webhook, err := endpointURL(cfg.WebhookURLEnv, "DINGTALK_WEBHOOK_URL")
+		if err != nil {
+			return nil, err
+		}
+		notifier = NewDingTalk(webhook, secret(cfg.SecretEnv))
+	case "wecom":
+		webhook, err := endpointURL(cfg.WebhookURLEnv, "WECOM_WEBHOOK_URL")
+		if err != nil {
+			return nil, err
+		}
+		notifier = NewWeCom(webhook)
+	case "feishu", "lark":
+		webhook, err := endpointURL(cfg.WebhookURLEnv, "FEISHU_WEBHOOK_URL")
+		if err != nil {
+			return nil, err
+		}
+		notifier = NewFeishu(webhook, secret(cfg.SecretEnv))
+	case "email":
+		smtpURL, err := endpointURL(cfg.SMTPURLEnv, "SMTP_URL")
+		if err != nil {
+			return nil, err
+		}
+		notifier, err = NewEmail(smtpURL, cfg.From, cfg.To)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("不支持的通知类型: %s，可选值：slack, dingtalk, wecom, feishu, email", cfg.Type)
+	}
+	return &Channel{Notifier: notifier, when: when}, nil
+}
+
+// endpointURL 从环境变量读取 Webhook 或 SMTP 地址，env 为空时使用默认的环境变量
+func endpointURL(env, fallback string) (string, error) {
+	if env == "" {
+		env = fallback
+	}
+	webhook := os.Getenv(env)
+	if webhook == "" {
+		return "", fmt.Errorf("%w: %s 环境变量为空", ErrNoEndpoint, env)
+	}
+	return webhook, nil
+}
+
+// secret 读取保存签名密钥的环境变量，未配置时返回空字符串
+func secret(env string) string {
+	if env == "" {
+		return ""
+	}
+	return os.Getenv(env)
+}
+
+// markdownSummary 生成钉钉、企业微信等支持 Markdown 的渠道使用的摘要正文
+// 包含门禁结果、文件数、各严重程度的问题数、最严重的几个问题、报告和 pull request 的链接
+func markdownSummary(s *Summary) string {
+	t := s.Lang.T
+	var b strings.Builder
+	title := t("notify.title", s.Project)
+	if s.Ref != "" {
+		title += " (" + s.Ref + ")"
+	}
+	fmt.Fprintf(&b, "### %s\n\n", title)
+	if s.Passed {
+		fmt.Fprintf(&b, "✅ %s\n\n", t("notify.gate_passed"))
+	} else {
+		fmt.Fprintf(&b, "❌ %s\n\n", t("notify.gate_failed"))
+	}
+	fmt.Fprintf(&b, "- %s\n", t("notify.field", t("report.files_reviewed"), s.Lang.Number(s.Files)))
+	fmt.Fprintf(&b, "- %s\n", t("notify.field", t("report.total_issues"), s.Lang.Number(s.Issues())))
+	fmt.Fprintf(&b, "- %s\n", t("notify.field", t("report.quality_score"), t("report.score_grade", s.Score, s.Grade)))
+	var severities []string
+	for _, severity := range severityOrder {
+		if count := s.Severities[severity]; count > 0 {
+			severities = append(severities, fmt.Sprintf("%s %s %s", severityEmojis[severity], severity, s.Lang.Number(count)))
+		}
+	}
+	if len(severities) > 0 {
+		fmt.Fprintf(&b, "- %s\n", t("notify.field", t("report.severity_distribution"), strings.Join(severities, t("report.list_separator"))))
+	}
+	if s.Model != "" {
+		fmt.Fprintf(&b, "- %s\n", t("notify.field", t("notify.model"), s.Model))
+	}
+	if s.Duration > 0 {
+		fmt.Fprintf(&b, "- %s\n", t("notify.field", t("report.duration"), s.Lang.Duration(s.Duration)))
+	}
+
+	if len(s.Top) > 0 {
+		fmt.Fprintf(&b, "\n**%s**\n\n", t("notify.top_issues"))
+		for _, issue := range s.Top {
+			fmt.Fprintf(&b, "- %s %s\n", severityEmojis[issue.Severity], t("notify.issue", issue.Title, issueLocation(issue)))
+		}
+	}
+	var links []string
+	if s.ReportURL != "" {
+		links = append(links, fmt.Sprintf("[%s](%s)", t("report.full_report"), s.ReportURL))
+	}
+	if s.ChangeURL != "" {
+		links = append(links, fmt.Sprintf("[%s](%s)", t("notify.view_change"), s.ChangeURL))
+	}
+	if len(links) > 0 {
+		fmt.Fprintf(&b, "\n%s\n", strings.Join(links, " | "))
+	}
+	return b.String()
+}
+
+// severityEmojis 各严重程度在 Markdown 消息中使用的标记
+var severityEmojis = map[types.SeverityLevel]string{
+	types.SeverityError:   "🔴",
+	types.SeverityWarning: "🟡",
+	types.SeverityInfo:    "🔵",
+}
+
+// issueLocation 返回问题所在的文件和行号
+func issueLocation(issue types.Issue) string {
+	if issue.Line > 0 {
+		return fmt.Sprintf("%s:%d", issue.FilePath, issue.Line)
+	}
+	return issue.FilePath
+}
+
+// httpClient 发送通知使用的客户端，通知失败不应长时间阻塞评审
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// postJSON 把消息以 JSON 格式发送到 Webhook，返回响应内容
+func postJSON(webhook string, payload interface{}) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化通知失败: %v", err)
+	}
+	resp, err := httpClient.Post(webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// 错误信息中的 Webhook 地址包含密钥，不写入日志
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("发送通知失败: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("发送通知失败: HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+	return data, nil
+}
@@ -0,0 +1,124 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// slackEmojis 各严重程度在 Slack 消息中使用的标记
+var slackEmojis = map[types.SeverityLevel]string{
+	types.SeverityError:   ":red_circle:",
+	types.SeverityWarning: ":large_yellow_circle:",
+	types.SeverityInfo:    ":large_blue_circle:",
+}
+
+// Slack 通过 Incoming Webhook 发送到 Slack 频道
+type Slack struct {
+	webhook string
+	channel string
+}
+
+// NewSlack 创建 Slack 通知，channel 为空时发送到 Webhook 绑定的频道
+func NewSlack(webhook, channel string) *Slack {
+	return &Slack{webhook: webhook, channel: channel}
+}
+
+func (n *Slack) Name() string {
+	return "slack"
+}
+
+// slackMessage Incoming Webhook 的消息，text 为不支持 blocks 时显示的内容
+type slackMessage struct {
+	Channel string       `json:"channel,omitempty"`
+	Text    string       `json:"text"`
+	Blocks  []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type   string      `json:"type"`
+	Text   *slackText  `json:"text,omitempty"`
+	Fields []slackText `json:"fields,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Notify 发送评审摘要：标题与门禁结果、文件数和各严重程度的问题数、最严重的几个问题、报告和 pull request 的链接
+func (n *Slack) Notify(s *Summary) error {
+	t := s.Lang.T
+	title := t("notify.title", s.Project)
+	if s.Ref != "" {
+		title += " (" + s.Ref + ")"
+	}
+	gate := ":white_check_mark: " + t("notify.gate_passed")
+	if !s.Passed {
+		gate = ":x: " + t("notify.gate_failed")
+	}
+
+	fields := []slackText{
+		mrkdwn(fmt.Sprintf("*%s*\n%s", t("report.files_reviewed"), s.Lang.Number(s.Files))),
+		mrkdwn(fmt.Sprintf("*%s*\n%s", t("report.total_issues"), s.Lang.Number(s.Issues()))),
+		mrkdwn(fmt.Sprintf("*%s*\n%s", t("report.quality_score"), t("report.score_grade", s.Score, s.Grade))),
+	}
+	var severities []string
+	for _, severity := range severityOrder {
+		if count := s.Severities[severity]; count > 0 {
+			severities = append(severities, fmt.Sprintf("%s %s %s", slackEmojis[severity], severity, s.Lang.Number(count)))
+		}
+	}
+	if len(severities) == 0 {
+		severities = append(severities, t("report.no_issues"))
+	}
+	fields = append(fields, mrkdwn(fmt.Sprintf("*%s*\n%s", t("report.severity_distribution"), strings.Join(severities, "  "))))
+	if s.Model != "" {
+		fields = append(fields, mrkdwn(fmt.Sprintf("*%s*\n%s", t("notify.model"), slackEscape(s.Model))))
+	}
+	if s.Duration > 0 {
+		fields = append(fields, mrkdwn(fmt.Sprintf("*%s*\n%s", t("report.duration"), s.Lang.Duration(s.Duration))))
+	}
+
+	msg := slackMessage{
+		Channel: n.channel,
+		Text:    fmt.Sprintf("%s: %s", title, t("report.issues_short", s.Issues())),
+		Blocks: []slackBlock{
+			{Type: "section", Text: ptr(mrkdwn(fmt.Sprintf("*%s*\n%s", slackEscape(title), gate)))},
+			{Type: "section", Fields: fields},
+		},
+	}
+	if len(s.Top) > 0 {
+		lines := []string{fmt.Sprintf("*%s*", t("notify.top_issues"))}
+		for _, issue := range s.Top {
+			lines = append(lines, fmt.Sprintf("%s %s (`%s`)", slackEmojis[issue.Severity], slackEscape(issue.Title), slackEscape(issueLocation(issue))))
+		}
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "section", Text: ptr(mrkdwn(strings.Join(lines, "\n")))})
+	}
+	var links []string
+	if s.ReportURL != "" {
+		links = append(links, fmt.Sprintf("<%s|%s>", s.ReportURL, t("report.full_report")))
+	}
+	if s.ChangeURL != "" {
+		links = append(links, fmt.Sprintf("<%s|%s>", s.ChangeURL, t("notify.view_change")))
+	}
+	if len(links) > 0 {
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "section", Text: ptr(mrkdwn(strings.Join(links, " | ")))})
+	}
+	_, err := postJSON(n.webhook, msg)
+	return err
+}
+
+func mrkdwn(text string) slackText {
+	return slackText{Type: "mrkdwn", Text: text}
+}
+
+func ptr(t slackText) *slackText {
+	return &t
+}
+
+// slackEscape 转义 Slack 消息中有特殊含义的字符
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
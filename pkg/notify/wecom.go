@@ -0,0 +1,48 @@
+package notify
+
+import "unicode/utf8"
+
+// wecomMaxBytes 企业微信 Markdown 消息内容的长度上限
+const wecomMaxBytes = 4096
+
+// WeCom 通过群机器人发送到企业微信群，机器人以 Webhook 地址中的 key 鉴权
+type WeCom struct {
+	webhook string
+}
+
+// NewWeCom 创建企业微信通知
+func NewWeCom(webhook string) *WeCom {
+	return &WeCom{webhook: webhook}
+}
+
+func (n *WeCom) Name() string {
+	return "wecom"
+}
+
+// Notify 以 Markdown 消息发送评审摘要，超出长度上限时截断
+func (n *WeCom) Notify(s *Summary) error {
+	content := markdownSummary(s)
+	if len(content) > wecomMaxBytes {
+		content = truncateBytes(content, wecomMaxBytes-len("…")) + "…"
+	}
+	msg := map[string]interface{}{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"content": content},
+	}
+	data, err := postJSON(n.webhook, msg)
+	if err != nil {
+		return err
+	}
+	return checkErrcode(data)
+}
+
+// truncateBytes 按字节数截断字符串，不截断多字节字符
+func truncateBytes(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
@@ -0,0 +1,127 @@
+package policy
+
+import (
+	"path"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// DefaultMaxAssetSize 新增二进制文件和资源文件的默认大小上限
+const DefaultMaxAssetSize = 1 << 20
+
+// largeAssetTitleKey 大文件问题标题的文本键，门禁据此识别需要阻断的问题
+const largeAssetTitleKey = "asset.title"
+
+// isLargeAssetIssue 判断是否为大文件问题，标题按报告语言生成，与各语言的标题比较
+func isLargeAssetIssue(issue types.Issue) bool {
+	for _, lang := range []i18n.Lang{i18n.Chinese, i18n.English} {
+		if issue.Title == lang.T(largeAssetTitleKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// AssetPolicy 新增二进制文件和资源文件的检查规则
+type AssetPolicy struct {
+	// 文件大小上限，如 "1MB"，为空时使用默认值，"0" 表示不检查
+	MaxSize string `yaml:"max_size,omitempty" json:"max_size,omitempty"`
+	// 发现超限文件时是否使评审不通过
+	Block bool `yaml:"block,omitempty" json:"block,omitempty"`
+}
+
+// assetExtensions 按扩展名识别的资源文件，文本格式的资源（如 SVG、JSON 数据）也可能很大
+var assetExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".bmp": true, ".ico": true, ".webp": true, ".svg": true, ".psd": true,
+	".mp3": true, ".wav": true, ".ogg": true, ".flac": true, ".mp4": true, ".mov": true, ".avi": true, ".webm": true,
+	".ttf": true, ".otf": true, ".woff": true, ".woff2": true, ".eot": true,
+	".zip": true, ".tar": true, ".gz": true, ".tgz": true, ".7z": true, ".rar": true, ".jar": true, ".war": true,
+	".pdf": true, ".doc": true, ".docx": true, ".xls": true, ".xlsx": true, ".ppt": true, ".pptx": true,
+	".exe": true, ".dll": true, ".so": true, ".dylib": true, ".a": true, ".bin": true, ".wasm": true,
+	".db": true, ".sqlite": true, ".csv": true, ".parquet": true, ".onnx": true, ".pt": true, ".h5": true,
+}
+
+// IsBinaryDiff 判断差异内容是否为二进制文件的改动
+func IsBinaryDiff(diff string) bool {
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "Binary files ") && strings.HasSuffix(line, " differ") {
+			return true
+		}
+		if line == "GIT binary patch" {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAssetPath 判断文件是否为常见的资源文件
+func IsAssetPath(filePath string) bool {
+	return assetExtensions[strings.ToLower(path.Ext(filePath))]
+}
+
+// MaxAssetSize 返回新增资源文件的大小上限，0 表示不检查
+func (p *Policy) MaxAssetSize() int64 {
+	if p.Assets.MaxSize == "" {
+		return DefaultMaxAssetSize
+	}
+	size, err := review.ParseSize(p.Assets.MaxSize)
+	if err != nil {
+		return DefaultMaxAssetSize
+	}
+	return size
+}
+
+// CheckAssets 检查新增的二进制文件和资源文件，超过大小上限时按 lang 生成问题
+// sizeOf 返回文件在评审目标版本中的字节数；通过 Git LFS 管理的文件在仓库中只是指针，不会超限
+func (p *Policy) CheckAssets(changes []types.FileChange, lang i18n.Lang, sizeOf func(filePath string) (int64, error)) []types.Issue {
+	limit := p.MaxAssetSize()
+	if limit == 0 {
+		return nil
+	}
+
+	severity := types.SeverityWarning
+	if p.Assets.Block {
+		severity = types.SeverityError
+	}
+
+	var issues []types.Issue
+	for _, change := range changes {
+		if !isAdded(change) {
+			continue
+		}
+		binary := IsBinaryDiff(change.DiffContent)
+		if !binary && !IsAssetPath(change.FilePath) {
+			continue
+		}
+		size, err := sizeOf(change.FilePath)
+		if err != nil || size <= limit {
+			continue
+		}
+
+		pattern := "*" + path.Ext(change.FilePath)
+		if pattern == "*" {
+			pattern = change.FilePath
+		}
+		kind := lang.T("asset.asset")
+		if binary {
+			kind = lang.T("asset.binary")
+		}
+		issues = append(issues, types.Issue{
+			Title:       lang.T(largeAssetTitleKey),
+			FilePath:    change.FilePath,
+			Severity:    severity,
+			Category:    types.CategoryMaintainability,
+			Description: lang.T("asset.description", kind, review.FormatSize(size), review.FormatSize(limit)),
+			Suggestion:  lang.T("asset.suggestion", pattern),
+		})
+	}
+	return issues
+}
+
+// isAdded 判断是否为新增文件，提交范围模式下的改动类型不可靠，同时检查差异头
+func isAdded(change types.FileChange) bool {
+	return change.ChangeType == "added" || strings.Contains(change.DiffContent, "\nnew file mode ")
+}
@@ -0,0 +1,382 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/config"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// Policy 评审策略，包括路径排除、模型提供方限制和质量门禁
+type Policy struct {
+	// 不参与评审的路径
+	Exclude []string `yaml:"exclude" json:"exclude"`
+	// 禁止使用的模型提供方
+	ForbiddenProviders []string `yaml:"forbidden_providers" json:"forbidden_providers"`
+	// 质量门禁
+	Gate Gate `yaml:"gate" json:"gate"`
+	// 新增二进制文件和资源文件的检查规则
+	Assets AssetPolicy `yaml:"assets" json:"assets"`
+	// 不调用模型评审的改动类型，例如 dependency 表示只升级依赖的文件不做AI评审
+	SkipKinds []types.ChangeKind `yaml:"skip_kinds" json:"skip_kinds"`
+	// 领域术语表，键为术语或缩写，值为解释
+	Glossary map[string]string `yaml:"glossary" json:"glossary"`
+}
+
+// Gate 质量门禁
+type Gate struct {
+	// 出现该严重程度及以上的问题时评审不通过
+	FailOn types.SeverityLevel `yaml:"fail_on" json:"fail_on"`
+	// 各严重程度及以上的问题数上限，超过时评审不通过，例如 warning: 10 表示 warning 和 error 合计超过 10 个时不通过
+	MaxIssues map[types.SeverityLevel]int `yaml:"max_issues" json:"max_issues,omitempty"`
+	// 质量分下限，低于该分数时评审不通过，0 表示不检查
+	MinScore int `yaml:"min_score" json:"min_score,omitempty"`
+}
+
+// Result 门禁评估结果
+type Result struct {
+	Passed  bool
+	Reasons []string
+}
+
+// FromConfig 从仓库配置构建策略
+func FromConfig(cfg *config.Config) *Policy {
+	return &Policy{
+		Exclude:            append([]string(nil), cfg.Review.Exclude...),
+		ForbiddenProviders: append([]string(nil), cfg.ForbiddenProviders...),
+		Gate:               Gate{FailOn: types.SeverityLevel(cfg.Gate.FailOn), MaxIssues: toMaxIssues(cfg.Gate.MaxIssues), MinScore: cfg.Gate.MinScore},
+		Assets:             AssetPolicy{MaxSize: cfg.Assets.MaxSize, Block: cfg.Assets.Block},
+		SkipKinds:          toKinds(cfg.Review.SkipKinds),
+		Glossary:           mergeGlossary(cfg.Glossary),
+	}
+}
+
+// toKinds 将配置中的改动类型转换为 ChangeKind
+func toKinds(kinds []string) []types.ChangeKind {
+	var result []types.ChangeKind
+	for _, kind := range kinds {
+		result = append(result, types.ChangeKind(strings.ToLower(strings.TrimSpace(kind))))
+	}
+	return result
+}
+
+// toMaxIssues 将配置中的问题数上限转换为按严重程度索引，全部为空时返回 nil
+func toMaxIssues(limits map[string]int) map[types.SeverityLevel]int {
+	var result map[types.SeverityLevel]int
+	for severity, limit := range limits {
+		if result == nil {
+			result = make(map[types.SeverityLevel]int)
+		}
+		result[types.SeverityLevel(strings.ToLower(strings.TrimSpace(severity)))] = limit
+	}
+	return result
+}
+
+// Merge 将组织级策略合并到当前策略之下
+// 排除路径、跳过的改动类型和禁用提供方取并集；术语表合并，同一术语以仓库配置的解释为准；门禁级别、问题数上限、质量分下限和资源文件上限取两者中更严格的一个，仓库配置只能收紧不能放宽
+func (p *Policy) Merge(org *Policy) *Policy {
+	if org == nil {
+		return p
+	}
+
+	merged := &Policy{
+		Exclude:            mergeUnique(org.Exclude, p.Exclude),
+		ForbiddenProviders: mergeUnique(org.ForbiddenProviders, p.ForbiddenProviders),
+		Gate:               p.Gate,
+		Assets:             AssetPolicy{MaxSize: p.Assets.MaxSize, Block: p.Assets.Block || org.Assets.Block},
+		SkipKinds:          mergeUniqueKinds(org.SkipKinds, p.SkipKinds),
+		Glossary:           mergeGlossary(org.Glossary, p.Glossary),
+	}
+	if stricter(org.Gate.FailOn, p.Gate.FailOn) {
+		merged.Gate.FailOn = org.Gate.FailOn
+	}
+	merged.Gate.MaxIssues = mergeMaxIssues(org.Gate.MaxIssues, p.Gate.MaxIssues)
+	if org.Gate.MinScore > p.Gate.MinScore {
+		merged.Gate.MinScore = org.Gate.MinScore
+	}
+	if org.Assets.MaxSize != "" {
+		orgLimit, limit := org.MaxAssetSize(), p.MaxAssetSize()
+		if limit == 0 || (orgLimit != 0 && orgLimit < limit) {
+			merged.Assets.MaxSize = org.Assets.MaxSize
+		}
+	}
+	return merged
+}
+
+// Validate 检查策略内容是否有效
+func (p *Policy) Validate() error {
+	if p.Gate.FailOn != "" && p.Gate.FailOn.Rank() == 0 {
+		return fmt.Errorf("无效的门禁级别: %s", p.Gate.FailOn)
+	}
+	for severity, limit := range p.Gate.MaxIssues {
+		if severity.Rank() == 0 {
+			return fmt.Errorf("无效的问题数上限级别: %s", severity)
+		}
+		if limit < 0 {
+			return fmt.Errorf("问题数上限不能为负数: %s: %d", severity, limit)
+		}
+	}
+	if p.Gate.MinScore < 0 || p.Gate.MinScore > 100 {
+		return fmt.Errorf("质量分下限必须在 0 到 100 之间: %d", p.Gate.MinScore)
+	}
+	for _, pattern := range p.Exclude {
+		if _, err := globToRegexp(pattern); err != nil {
+			return fmt.Errorf("无效的排除规则 %q: %v", pattern, err)
+		}
+	}
+	for _, kind := range p.SkipKinds {
+		if !knownKind(kind) {
+			return fmt.Errorf("无效的改动类型: %s", kind)
+		}
+	}
+	if p.Assets.MaxSize != "" {
+		if _, err := review.ParseSize(p.Assets.MaxSize); err != nil {
+			return fmt.Errorf("无效的资源文件大小上限: %v", err)
+		}
+	}
+	return nil
+}
+
+// IsExcluded 判断文件是否被排除在评审之外
+// 不含 / 的规则同时匹配文件名，例如 "*.pb.go" 可以匹配任意目录下的文件
+func (p *Policy) IsExcluded(filePath string) bool {
+	filePath = strings.TrimPrefix(path.Clean(filePath), "./")
+	for _, pattern := range p.Exclude {
+		re, err := globToRegexp(pattern)
+		if err != nil {
+			continue
+		}
+		if re.MatchString(filePath) {
+			return true
+		}
+		if !strings.Contains(pattern, "/") && re.MatchString(path.Base(filePath)) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterChanges 过滤掉被排除的文件，返回保留和排除的文件改动
+func (p *Policy) FilterChanges(changes []types.FileChange) ([]types.FileChange, []types.FileChange) {
+	kept := make([]types.FileChange, 0, len(changes))
+	var excluded []types.FileChange
+	for _, change := range changes {
+		if p.IsExcluded(change.FilePath) {
+			excluded = append(excluded, change)
+			continue
+		}
+		kept = append(kept, change)
+	}
+	return kept, excluded
+}
+
+// FilterKinds 过滤掉策略指定跳过的改动类型，返回保留和跳过的文件改动
+// 改动需要先通过 review.ClassifyChanges 推断类型
+func (p *Policy) FilterKinds(changes []types.FileChange) ([]types.FileChange, []types.FileChange) {
+	if len(p.SkipKinds) == 0 {
+		return changes, nil
+	}
+	skip := make(map[types.ChangeKind]bool, len(p.SkipKinds))
+	for _, kind := range p.SkipKinds {
+		skip[kind] = true
+	}
+	kept := make([]types.FileChange, 0, len(changes))
+	var skipped []types.FileChange
+	for _, change := range changes {
+		if skip[change.Kind] {
+			skipped = append(skipped, change)
+			continue
+		}
+		kept = append(kept, change)
+	}
+	return kept, skipped
+}
+
+// knownKind 判断是否为支持的改动类型
+func knownKind(kind types.ChangeKind) bool {
+	for _, k := range types.ChangeKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckProvider 检查模型提供方是否被允许使用
+func (p *Policy) CheckProvider(provider string) error {
+	for _, forbidden := range p.ForbiddenProviders {
+		if strings.EqualFold(forbidden, provider) {
+			return fmt.Errorf("策略禁止使用模型提供方: %s", provider)
+		}
+	}
+	return nil
+}
+
+// Evaluate 根据门禁评估评审结果
+func (p *Policy) Evaluate(issues []types.Issue) Result {
+	result := Result{Passed: true}
+
+	// 策略要求阻断超限的资源文件时，不受门禁级别影响
+	if p.Assets.Block {
+		blocked := 0
+		for _, issue := range issues {
+			if isLargeAssetIssue(issue) {
+				blocked++
+			}
+		}
+		if blocked > 0 {
+			result.Passed = false
+			result.Reasons = append(result.Reasons, fmt.Sprintf("新增了 %d 个超过大小上限的二进制文件或资源文件", blocked))
+		}
+	}
+
+	if p.Gate.FailOn != "" {
+		if count := countAtLeast(issues, p.Gate.FailOn); count > 0 {
+			result.Passed = false
+			result.Reasons = append(result.Reasons, fmt.Sprintf("发现 %d 个 %s 及以上级别的问题", count, p.Gate.FailOn))
+		}
+	}
+
+	// 问题数上限按严重程度从高到低检查，原因的顺序固定
+	for _, severity := range []types.SeverityLevel{types.SeverityError, types.SeverityWarning, types.SeverityInfo} {
+		limit, ok := p.Gate.MaxIssues[severity]
+		if !ok {
+			continue
+		}
+		if count := countAtLeast(issues, severity); count > limit {
+			result.Passed = false
+			result.Reasons = append(result.Reasons, fmt.Sprintf("%s 及以上级别的问题有 %d 个，超过上限 %d", severity, count, limit))
+		}
+	}
+
+	if p.Gate.MinScore > 0 {
+		if score := review.QualityScore(issues); score < p.Gate.MinScore {
+			result.Passed = false
+			result.Reasons = append(result.Reasons, fmt.Sprintf("质量分 %d 低于下限 %d", score, p.Gate.MinScore))
+		}
+	}
+	return result
+}
+
+// countAtLeast 统计该严重程度及以上的问题数
+func countAtLeast(issues []types.Issue, severity types.SeverityLevel) int {
+	threshold := severity.Rank()
+	count := 0
+	for _, issue := range issues {
+		if issue.Severity.Rank() >= threshold {
+			count++
+		}
+	}
+	return count
+}
+
+// mergeMaxIssues 合并问题数上限，同一严重程度取较小的上限，全部为空时返回 nil
+func mergeMaxIssues(limits ...map[types.SeverityLevel]int) map[types.SeverityLevel]int {
+	var merged map[types.SeverityLevel]int
+	for _, m := range limits {
+		for severity, limit := range m {
+			if merged == nil {
+				merged = make(map[types.SeverityLevel]int)
+			}
+			if current, ok := merged[severity]; !ok || limit < current {
+				merged[severity] = limit
+			}
+		}
+	}
+	return merged
+}
+
+// stricter 判断门禁级别a是否比b更严格，未设置的门禁视为最宽松
+func stricter(a, b types.SeverityLevel) bool {
+	if a == "" {
+		return false
+	}
+	if b == "" {
+		return true
+	}
+	return a.Rank() < b.Rank()
+}
+
+// mergeUnique 合并两个列表并去重，保持顺序
+func mergeUnique(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, list := range lists {
+		for _, item := range list {
+			if !seen[item] {
+				seen[item] = true
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
+
+// mergeUniqueKinds 合并两个改动类型列表并去重，保持顺序
+func mergeUniqueKinds(lists ...[]types.ChangeKind) []types.ChangeKind {
+	var strs [][]string
+	for _, list := range lists {
+		s := make([]string, 0, len(list))
+		for _, kind := range list {
+			s = append(s, string(kind))
+		}
+		strs = append(strs, s)
+	}
+	return toKinds(mergeUnique(strs...))
+}
+
+// mergeGlossary 合并术语表，后面的术语表覆盖前面的同名术语，全部为空时返回 nil
+func mergeGlossary(glossaries ...map[string]string) map[string]string {
+	var merged map[string]string
+	for _, glossary := range glossaries {
+		for term, definition := range glossary {
+			term, definition = strings.TrimSpace(term), strings.TrimSpace(definition)
+			if term == "" || definition == "" {
+				continue
+			}
+			if merged == nil {
+				merged = make(map[string]string)
+			}
+			merged[term] = definition
+		}
+	}
+	return merged
+}
+
+// globToRegexp 将路径通配符转换为正则表达式，** 匹配任意层级目录，* 和 ? 不跨越目录
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var buf strings.Builder
+	buf.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				// "**/" 可以匹配零个或多个目录
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					buf.WriteString("(?:.*/)?")
+				} else {
+					buf.WriteString(".*")
+				}
+			} else {
+				buf.WriteString("[^/]*")
+			}
+		case '?':
+			buf.WriteString("[^/]")
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	// 目录规则匹配其下的所有文件
+	if strings.HasSuffix(pattern, "/") {
+		buf.WriteString(".*")
+	}
+	buf.WriteString("$")
+	return regexp.Compile(buf.String())
+}
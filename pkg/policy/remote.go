@@ -0,0 +1,151 @@
+package policy
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// RefreshInterval 组织级策略缓存的刷新间隔
+	RefreshInterval = time.Hour
+
+	// maxPolicySize 策略文件的最大字节数
+	maxPolicySize = 1 << 20
+)
+
+// LoadRemote 加载组织级策略
+// 策略文件地址为 url，签名文件地址为 url+".sig"，内容是策略文件原始字节的 Ed25519 签名（base64）。
+// 成功下载并校验签名后会缓存到 cacheDir；缓存未过期时直接使用缓存，下载失败时退回到已校验过的旧缓存。
+// cacheDir 为空时不读写缓存
+func LoadRemote(url, publicKey, cacheDir string) (*Policy, error) {
+	if publicKey == "" {
+		return nil, fmt.Errorf("已配置 policy_url 但未配置 policy_public_key，无法校验策略签名")
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("policy_public_key 不是有效的 Ed25519 公钥")
+	}
+
+	if cacheDir == "" {
+		body, sig, err := fetchPolicy(url)
+		if err != nil {
+			return nil, fmt.Errorf("获取组织级策略失败: %v", err)
+		}
+		if err := verify(body, sig, key); err != nil {
+			return nil, err
+		}
+		return parsePolicy(body)
+	}
+
+	sum := sha256.Sum256([]byte(url))
+	cacheBase := filepath.Join(cacheDir, fmt.Sprintf("%x", sum[:8]))
+	bodyPath, sigPath := cacheBase+".yaml", cacheBase+".sig"
+
+	// 优先使用未过期的缓存
+	if info, err := os.Stat(bodyPath); err == nil && time.Since(info.ModTime()) < RefreshInterval {
+		if p, err := loadCached(bodyPath, sigPath, key); err == nil {
+			return p, nil
+		}
+	}
+
+	body, sig, fetchErr := fetchPolicy(url)
+	if fetchErr == nil {
+		if err := verify(body, sig, key); err != nil {
+			return nil, err
+		}
+		p, err := parsePolicy(body)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(cacheDir, 0755); err == nil {
+			os.WriteFile(bodyPath, body, 0644)
+			os.WriteFile(sigPath, sig, 0644)
+		}
+		return p, nil
+	}
+
+	// 下载失败时使用旧缓存
+	if p, err := loadCached(bodyPath, sigPath, key); err == nil {
+		fmt.Fprintf(os.Stderr, "获取组织级策略失败，使用本地缓存: %v\n", fetchErr)
+		return p, nil
+	}
+	return nil, fmt.Errorf("获取组织级策略失败: %v", fetchErr)
+}
+
+// fetchPolicy 下载策略文件和签名
+func fetchPolicy(url string) ([]byte, []byte, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	body, err := fetch(client, url)
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := fetch(client, url+".sig")
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取策略签名失败: %v", err)
+	}
+	return body, sig, nil
+}
+
+// fetch 发送GET请求并读取响应内容
+func fetch(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s 返回状态码 %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxPolicySize))
+}
+
+// loadCached 读取并重新校验缓存的策略
+func loadCached(bodyPath, sigPath string, key ed25519.PublicKey) (*Policy, error) {
+	body, err := os.ReadFile(bodyPath)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := os.ReadFile(sigPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(body, sig, key); err != nil {
+		return nil, err
+	}
+	return parsePolicy(body)
+}
+
+// verify 校验策略签名
+func verify(body, sig []byte, key ed25519.PublicKey) error {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("策略签名格式错误: %v", err)
+	}
+	if !ed25519.Verify(key, body, decoded) {
+		return fmt.Errorf("组织级策略签名校验失败")
+	}
+	return nil
+}
+
+// parsePolicy 解析策略内容，支持YAML和JSON
+func parsePolicy(body []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("解析组织级策略失败: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,157 @@
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// SkipListRefreshInterval 跳过清单缓存的重新验证间隔，过期后带 If-None-Match 请求，内容未变时服务端只返回 304
+const SkipListRefreshInterval = 10 * time.Minute
+
+// SkipList 配置服务集中维护的跳过清单，如第三方代码、生成代码所在的目录
+// 与组织级策略不同，跳过清单只能扩大排除范围，不需要签名
+type SkipList struct {
+	// 不参与评审的路径，规则与配置文件中的 review.exclude 相同
+	Exclude []string `json:"exclude"`
+	// 不调用模型评审的改动类型
+	SkipKinds []types.ChangeKind `json:"skip_kinds"`
+}
+
+// Policy 将跳过清单转换为策略，用于与仓库配置合并
+func (s *SkipList) Policy() *Policy {
+	return &Policy{Exclude: s.Exclude, SkipKinds: s.SkipKinds}
+}
+
+// cachedSkipList 跳过清单的本地缓存
+type cachedSkipList struct {
+	ETag      string          `json:"etag"`
+	CheckedAt time.Time       `json:"checked_at"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// LoadSkipList 从 url 获取 JSON 格式的跳过清单
+// 缓存到 cacheDir，缓存未过期时直接使用；过期后用 ETag 重新验证，内容未变时继续使用缓存，
+// 获取失败时退回到旧缓存。cacheDir 为空时不读写缓存
+func LoadSkipList(url, cacheDir string) (*SkipList, error) {
+	if cacheDir == "" {
+		body, _, _, err := fetchSkipList(url, "")
+		if err != nil {
+			return nil, fmt.Errorf("获取跳过清单失败: %v", err)
+		}
+		return parseSkipList(body)
+	}
+
+	sum := sha256.Sum256([]byte(url))
+	cachePath := filepath.Join(cacheDir, fmt.Sprintf("%x.json", sum[:8]))
+	cached := loadCachedSkipList(cachePath)
+	if cached != nil && time.Since(cached.CheckedAt) < SkipListRefreshInterval {
+		if s, err := parseSkipList(cached.Body); err == nil {
+			return s, nil
+		}
+	}
+
+	etag := ""
+	if cached != nil {
+		etag = cached.ETag
+	}
+	body, newETag, notModified, fetchErr := fetchSkipList(url, etag)
+	if fetchErr == nil {
+		if notModified {
+			body, newETag = cached.Body, cached.ETag
+		}
+		s, err := parseSkipList(body)
+		if err != nil {
+			return nil, err
+		}
+		saveSkipList(cachePath, &cachedSkipList{ETag: newETag, CheckedAt: time.Now(), Body: body})
+		return s, nil
+	}
+
+	// 获取失败时使用旧缓存
+	if cached != nil {
+		if s, err := parseSkipList(cached.Body); err == nil {
+			fmt.Fprintf(os.Stderr, "获取跳过清单失败，使用本地缓存: %v\n", fetchErr)
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("获取跳过清单失败: %v", fetchErr)
+}
+
+// fetchSkipList 下载跳过清单，etag 不为空时带 If-None-Match 请求，服务端返回 304 时 notModified 为 true
+func fetchSkipList(url, etag string) (body []byte, newETag string, notModified bool, err error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && etag != "":
+		return nil, "", true, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", false, fmt.Errorf("%s 返回状态码 %d", url, resp.StatusCode)
+	}
+	body, err = io.ReadAll(io.LimitReader(resp.Body, maxPolicySize))
+	if err != nil {
+		return nil, "", false, err
+	}
+	return body, resp.Header.Get("ETag"), false, nil
+}
+
+// loadCachedSkipList 读取本地缓存，缓存不存在或损坏时返回 nil
+func loadCachedSkipList(path string) *cachedSkipList {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var cached cachedSkipList
+	if err := json.Unmarshal(data, &cached); err != nil || len(cached.Body) == 0 {
+		return nil
+	}
+	return &cached
+}
+
+// saveSkipList 写入本地缓存，失败时忽略，下次重新下载
+func saveSkipList(path string, cached *cachedSkipList) {
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	os.Rename(tmp, path)
+}
+
+// parseSkipList 解析并校验跳过清单
+func parseSkipList(body []byte) (*SkipList, error) {
+	var s SkipList
+	if err := json.Unmarshal(body, &s); err != nil {
+		return nil, fmt.Errorf("解析跳过清单失败: %v", err)
+	}
+	if err := s.Policy().Validate(); err != nil {
+		return nil, fmt.Errorf("跳过清单无效: %v", err)
+	}
+	return &s, nil
+}
@@ -0,0 +1,134 @@
+package publish
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/bitbucket"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// BitbucketReportKey 评审结果在 Code Insights 中的报告标识，重复发布时替换同一份报告
+const BitbucketReportKey = "ai-cr-tool"
+
+// bitbucketSeverities 各严重程度对应的注解级别
+var bitbucketSeverities = map[types.SeverityLevel]string{
+	types.SeverityError:   bitbucket.SeverityHigh,
+	types.SeverityWarning: bitbucket.SeverityMedium,
+	types.SeverityInfo:    bitbucket.SeverityLow,
+}
+
+// BitbucketReport 生成 Code Insights 报告和各问题的注解，有 error 级别的问题时报告不通过
+// 注解超过报告上限的问题计入 Outside
+func BitbucketReport(reporter *review.DefaultReporter, issues []types.Issue) (bitbucket.Report, []bitbucket.Annotation, Result) {
+	t := reporter.Lang.T
+	score := review.QualityScore(issues)
+	report := bitbucket.Report{
+		Title:    t("report.title"),
+		Details:  t("report.bitbucket_details", len(issues)),
+		Reporter: "ai-cr-tool",
+		Link:     reporter.ReportURL,
+		Passed:   review.HighestSeverity(issues) != types.SeverityError,
+		Data: []bitbucket.ReportData{
+			{Title: t("report.quality_score"), Type: "NUMBER", Value: score},
+			{Title: t("report.grade"), Type: "TEXT", Value: review.Grade(score)},
+			{Title: t("report.total_issues"), Type: "NUMBER", Value: len(issues)},
+		},
+	}
+
+	var result Result
+	var annotations []bitbucket.Annotation
+	for _, issue := range issues {
+		if len(annotations) >= bitbucket.MaxAnnotations {
+			result.Outside++
+			continue
+		}
+		severity, ok := bitbucketSeverities[issue.Severity]
+		if !ok {
+			severity = bitbucket.SeverityLow
+		}
+		annotationType := bitbucket.TypeCodeSmell
+		switch issue.Category {
+		case types.CategorySecurity:
+			annotationType = bitbucket.TypeVulnerability
+		case types.CategoryBug:
+			annotationType = bitbucket.TypeBug
+		}
+		annotations = append(annotations, bitbucket.Annotation{
+			ExternalID: issue.Fingerprint,
+			Path:       path.Clean(issue.FilePath),
+			Line:       issue.Line,
+			Message:    truncate(issue.Title, 450),
+			Details:    truncate(strings.TrimSpace(issue.Description+"\n\n"+issue.Suggestion), 2000),
+			Severity:   severity,
+			Type:       annotationType,
+		})
+		result.Inline++
+	}
+	return report, annotations, result
+}
+
+// BitbucketSummary 生成 pull request 上的总结评论：质量分、各严重程度的问题数和完整报告的链接
+// Bitbucket 不支持折叠内容，问题详情由 Reports 标签页中的注解展示
+func BitbucketSummary(reporter *review.DefaultReporter, issues []types.Issue) string {
+	t := reporter.Lang.T
+	score := review.QualityScore(issues)
+	parts := []string{fmt.Sprintf("**%s**", t("report.score_short", score, review.Grade(score)))}
+	counts := make(map[types.SeverityLevel]int)
+	for _, issue := range issues {
+		counts[issue.Severity]++
+	}
+	for _, severity := range []types.SeverityLevel{types.SeverityError, types.SeverityWarning, types.SeverityInfo} {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", severity, counts[severity]))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("## %s\n\n%s\n\n", t("report.title"), strings.Join(parts, " · ")))
+	if len(issues) == 0 {
+		sb.WriteString(t("report.no_issues") + "\n")
+	} else {
+		sb.WriteString(t("report.bitbucket_reports_tab") + "\n")
+	}
+	if reporter.ReportURL != "" {
+		sb.WriteString(fmt.Sprintf("\n[%s](%s)\n", t("report.full_report"), reporter.ReportURL))
+	}
+	return sb.String()
+}
+
+// PublishBitbucket 在 pull request 的源提交上创建 Code Insights 报告和注解，再发表一条总结评论
+func PublishBitbucket(client *bitbucket.Client, id int, reporter *review.DefaultReporter, issues []types.Issue, summary string) (Result, error) {
+	pr, err := client.GetPullRequest(id)
+	if err != nil {
+		return Result{}, fmt.Errorf("获取 pull request #%d 失败: %v", id, err)
+	}
+
+	report, annotations, result := BitbucketReport(reporter, issues)
+	if err := client.PutReport(pr.Commit, BitbucketReportKey, report); err != nil {
+		return Result{}, fmt.Errorf("创建 Code Insights 报告失败: %v", err)
+	}
+	if err := client.AddAnnotations(pr.Commit, BitbucketReportKey, annotations); err != nil {
+		return Result{}, fmt.Errorf("添加报告注解失败: %v", err)
+	}
+
+	result.URL, err = client.CreateComment(id, summary)
+	if err != nil {
+		return result, fmt.Errorf("发表总结评论失败: %v", err)
+	}
+	if result.URL == "" {
+		result.URL = pr.URL
+	}
+	return result, nil
+}
+
+// truncate 按字符数截断文本
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
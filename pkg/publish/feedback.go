@@ -0,0 +1,81 @@
+package publish
+
+import (
+	"fmt"
+
+	"github.com/icatw/ai-cr-tool/pkg/github"
+	"github.com/icatw/ai-cr-tool/pkg/gitlab"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+)
+
+// Feedback 已发布的一条行内评论及用户的反馈
+type Feedback struct {
+	Fingerprint string
+	Model       string
+	// 👎 多于 👍 时视为被驳回
+	Dismissed bool
+}
+
+// feedback 解析评论中的标记，没有指纹或模型标记的评论（如旧版本发布的评论）不参与统计
+func feedback(body string, up, down int) (Feedback, bool) {
+	fingerprint, model, ok := review.ParseMarkers(body)
+	if !ok || model == "" {
+		return Feedback{}, false
+	}
+	return Feedback{Fingerprint: fingerprint, Model: model, Dismissed: down > up}, true
+}
+
+// GitHubFeedback 读取 pull request 上已发布的行内评论收到的 👍/👎
+func GitHubFeedback(client *github.Client, number int) ([]Feedback, error) {
+	comments, err := client.ListReviewComments(number)
+	if err != nil {
+		return nil, fmt.Errorf("获取 pull request #%d 的评论失败: %v", number, err)
+	}
+	var result []Feedback
+	for _, c := range comments {
+		var up, down int
+		if c.Reactions != nil {
+			up, down = c.Reactions.PlusOne, c.Reactions.MinusOne
+		}
+		if f, ok := feedback(c.Body, up, down); ok {
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+// GitLabFeedback 读取 merge request 上已发布的行内讨论收到的 👍/👎
+func GitLabFeedback(client *gitlab.Client, iid int) ([]Feedback, error) {
+	discussions, err := client.ListDiscussions(iid)
+	if err != nil {
+		return nil, fmt.Errorf("获取 merge request !%d 的讨论失败: %v", iid, err)
+	}
+	var result []Feedback
+	for _, d := range discussions {
+		// 讨论的第一条评论是发布的问题，之后的是回复
+		if len(d.Notes) == 0 {
+			continue
+		}
+		note := d.Notes[0]
+		if _, model, ok := review.ParseMarkers(note.Body); !ok || model == "" {
+			continue
+		}
+		emoji, err := client.ListNoteAwardEmoji(iid, note.ID)
+		if err != nil {
+			return nil, fmt.Errorf("获取评论 %d 的表情回应失败: %v", note.ID, err)
+		}
+		var up, down int
+		for _, e := range emoji {
+			switch e.Name {
+			case "thumbsup":
+				up++
+			case "thumbsdown":
+				down++
+			}
+		}
+		if f, ok := feedback(note.Body, up, down); ok {
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,176 @@
+package publish
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/gerrit"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+const (
+	// GerritRobotID 机器人评论的 robot_id
+	GerritRobotID = "ai-cr-tool"
+	// gerritTag 评审消息的标签，autogenerated: 前缀使其可以在界面上与人工评论区分和过滤
+	gerritTag = "autogenerated:ai-cr-tool"
+)
+
+// GerritReview 生成补丁集上的评审：改动文件中的问题作为机器人评论，多行的改进建议作为替换问题所在行的修复建议，
+// 其余问题列在评审消息中；existing 中已有同一指纹的问题不再重复评论
+// issues 为全部问题，用于评审消息中的统计；inline 为需要发表机器人评论的问题
+func GerritReview(reporter *review.DefaultReporter, issues, inline []types.Issue, files map[string]gerrit.FileInfo, existing map[string][]gerrit.RobotComment, runID string) (gerrit.ReviewInput, Result) {
+	seen := make(map[string]bool)
+	for _, comments := range existing {
+		for _, c := range comments {
+			if c.RobotID == GerritRobotID && c.Properties["fingerprint"] != "" {
+				seen[c.Properties["fingerprint"]] = true
+			}
+		}
+	}
+
+	var result Result
+	var outside []types.Issue
+	input := gerrit.ReviewInput{Tag: gerritTag, RobotComments: make(map[string][]gerrit.RobotComment)}
+	for _, issue := range inline {
+		filePath := path.Clean(issue.FilePath)
+		if file, ok := files[filePath]; !ok || file.Status == "D" {
+			outside = append(outside, issue)
+			result.Outside++
+			continue
+		}
+		if issue.Fingerprint != "" && seen[issue.Fingerprint] {
+			result.Duplicate++
+			continue
+		}
+		input.RobotComments[filePath] = append(input.RobotComments[filePath], gerritComment(reporter, issue, filePath, runID))
+		result.Inline++
+	}
+	input.Message = GerritSummary(reporter, issues, outside)
+	return input, result
+}
+
+// gerritComment 生成单个问题的机器人评论，指纹、模型、严重程度和类别写在评论属性中
+func gerritComment(reporter *review.DefaultReporter, issue types.Issue, filePath, runID string) gerrit.RobotComment {
+	t := reporter.Lang.T
+	var msg strings.Builder
+	if reporter.CommentStyle == review.ConventionalStyle {
+		msg.WriteString(fmt.Sprintf("%s: %s\n", review.ConventionalLabel(issue), issue.Title))
+	} else {
+		msg.WriteString(fmt.Sprintf("[%s] %s\n", issue.Severity, issue.Title))
+	}
+	if issue.Description != "" {
+		msg.WriteString("\n" + issue.Description + "\n")
+	}
+
+	comment := gerrit.RobotComment{
+		RobotID:    GerritRobotID,
+		RobotRunID: runID,
+		URL:        reporter.ReportURL,
+		Line:       issue.Line,
+		Properties: map[string]string{
+			"fingerprint": issue.Fingerprint,
+			"severity":    string(issue.Severity),
+			"category":    string(issue.Category),
+		},
+	}
+	if issue.Category == "" {
+		comment.Properties["category"] = string(types.CategoryOther)
+	}
+	if reporter.Model != "" {
+		comment.Properties["model"] = reporter.Model
+	}
+
+	// 与 GitHub 的 suggestion 代码块一致，多行的改进建议视为问题所在行的替换代码
+	if suggestion := strings.TrimSpace(issue.Suggestion); suggestion != "" {
+		if strings.Contains(suggestion, "\n") && issue.Line > 0 {
+			comment.FixSuggestions = []gerrit.FixSuggestion{{
+				Description: truncate(t("report.suggestion")+issue.Title, 200),
+				Replacements: []gerrit.FixReplacement{{
+					Path:        filePath,
+					Range:       gerrit.CommentRange{StartLine: issue.Line, EndLine: issue.Line + 1},
+					Replacement: suggestion + "\n",
+				}},
+			}}
+		} else {
+			msg.WriteString("\n" + t("report.suggestion") + suggestion + "\n")
+		}
+	}
+	if len(issue.References) > 0 {
+		refs := make([]string, 0, len(issue.References))
+		for _, ref := range issue.References {
+			if ref.URL != "" {
+				refs = append(refs, fmt.Sprintf("%s (%s)", ref.Title, ref.URL))
+			} else {
+				refs = append(refs, ref.Title)
+			}
+		}
+		msg.WriteString("\n" + t("report.references") + strings.Join(refs, t("report.list_separator")) + "\n")
+	}
+	comment.Message = strings.TrimSpace(msg.String())
+	return comment
+}
+
+// GerritSummary 生成补丁集上的评审消息：质量分、各严重程度的问题数、不在改动文件中的问题和完整报告的链接
+// outside 为需要评论但不在改动文件中的问题
+// Gerrit 的评审消息只支持纯文本，不使用 Markdown 格式
+func GerritSummary(reporter *review.DefaultReporter, issues, outside []types.Issue) string {
+	t := reporter.Lang.T
+	score := review.QualityScore(issues)
+	parts := []string{t("report.score_short", score, review.Grade(score))}
+	counts := make(map[types.SeverityLevel]int)
+	for _, issue := range issues {
+		counts[issue.Severity]++
+	}
+	for _, severity := range []types.SeverityLevel{types.SeverityError, types.SeverityWarning, types.SeverityInfo} {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", severity, counts[severity]))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s\n\n%s\n", t("report.title"), strings.Join(parts, " · ")))
+	if len(issues) == 0 {
+		sb.WriteString("\n" + t("report.no_issues") + "\n")
+	} else if len(outside) < len(issues) {
+		sb.WriteString("\n" + t("report.gerrit_robot_comments") + "\n")
+	}
+	if len(outside) > 0 {
+		sb.WriteString("\n" + t("report.gerrit_outside") + "\n")
+		for _, issue := range outside {
+			location := issue.FilePath
+			if issue.Line > 0 {
+				location = fmt.Sprintf("%s:%d", issue.FilePath, issue.Line)
+			}
+			sb.WriteString(fmt.Sprintf("* [%s] %s %s\n", issue.Severity, location, issue.Title))
+		}
+	}
+	if reporter.ReportURL != "" {
+		sb.WriteString(fmt.Sprintf("\n%s: %s\n", t("report.full_report"), reporter.ReportURL))
+	}
+	return sb.String()
+}
+
+// PublishGerrit 在补丁集上发表评审消息和各问题的机器人评论，revision 为补丁集的提交或 current
+func PublishGerrit(client *gerrit.Client, number int, revision string, reporter *review.DefaultReporter, issues, inline []types.Issue, runID string) (Result, error) {
+	change, err := client.GetChange(number)
+	if err != nil {
+		return Result{}, fmt.Errorf("获取变更 %d 失败: %v", number, err)
+	}
+	files, err := client.ListFiles(number, revision)
+	if err != nil {
+		return Result{}, fmt.Errorf("获取变更 %d 的改动文件失败: %v", number, err)
+	}
+	existing, err := client.ListRobotComments(number)
+	if err != nil {
+		return Result{}, fmt.Errorf("获取变更 %d 已有的机器人评论失败: %v", number, err)
+	}
+
+	input, result := GerritReview(reporter, issues, inline, files, existing, runID)
+	if err := client.SetReview(number, revision, input); err != nil {
+		return result, fmt.Errorf("发布评审失败: %v", err)
+	}
+	result.URL = client.ChangeURL(change.Project, change.Number)
+	return result, nil
+}
@@ -0,0 +1,113 @@
+package publish
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/gitea"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// GiteaReview 根据 pull request 的完整差异生成评审：能对应到差异中某一行的问题作为行内评论，
+// 其余问题只出现在总结评论中；existing 中已包含同一指纹标记的问题不再重复评论
+func GiteaReview(reporter *review.DefaultReporter, issues []types.Issue, diff string, existing []gitea.ReviewComment, summary string) (gitea.NewReview, Result) {
+	bodies := make([]string, 0, len(existing))
+	for _, c := range existing {
+		bodies = append(bodies, c.Body)
+	}
+	lines := make(map[string]map[int]int)
+	for file, patch := range FileDiffs(diff) {
+		lines[file] = DiffLines(patch)
+	}
+
+	var result Result
+	req := gitea.NewReview{Body: summary, Event: "COMMENT"}
+	for _, issue := range issues {
+		filePath := path.Clean(issue.FilePath)
+		if _, ok := lines[filePath][issue.Line]; !ok {
+			result.Outside++
+			continue
+		}
+		if commented(bodies, review.FingerprintMarker(issue)) {
+			result.Duplicate++
+			continue
+		}
+		req.Comments = append(req.Comments, gitea.ReviewComment{
+			Path:        filePath,
+			Body:        reporter.GitHubComment(issue),
+			NewPosition: issue.Line,
+		})
+		result.Inline++
+	}
+	return req, result
+}
+
+// PublishGitea 在 pull request 上创建一次评审，包含总结评论和各问题的行内评论
+func PublishGitea(client *gitea.Client, number int, reporter *review.DefaultReporter, issues []types.Issue, summary string) (Result, error) {
+	pr, err := client.GetPullRequest(number)
+	if err != nil {
+		return Result{}, fmt.Errorf("获取 pull request #%d 失败: %v", number, err)
+	}
+	diff, err := client.GetPullRequestDiff(number)
+	if err != nil {
+		return Result{}, fmt.Errorf("获取 pull request #%d 的差异失败: %v", number, err)
+	}
+	reviews, err := client.ListReviews(number)
+	if err != nil {
+		return Result{}, fmt.Errorf("获取 pull request #%d 已有的评审失败: %v", number, err)
+	}
+	var existing []gitea.ReviewComment
+	for _, r := range reviews {
+		comments, err := client.ListReviewComments(number, r.ID)
+		if err != nil {
+			return Result{}, fmt.Errorf("获取 pull request #%d 已有的评论失败: %v", number, err)
+		}
+		existing = append(existing, comments...)
+	}
+
+	req, result := GiteaReview(reporter, issues, diff, existing, summary)
+	req.CommitID = pr.Head.SHA
+	created, err := client.CreateReview(number, req)
+	if err != nil {
+		return result, fmt.Errorf("发布评审失败: %v", err)
+	}
+	result.URL = created.HTMLURL
+	if result.URL == "" {
+		result.URL = pr.HTMLURL
+	}
+	return result, nil
+}
+
+// FileDiffs 将包含多个文件的统一差异按文件拆分，返回新文件路径到该文件差异的映射；删除的文件不在映射中
+func FileDiffs(diff string) map[string]string {
+	files := make(map[string]string)
+	var file string
+	var section []string
+	flush := func() {
+		if file != "" {
+			files[file] = strings.Join(section, "\n")
+		}
+		file, section = "", nil
+	}
+	for _, line := range strings.Split(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			continue
+		}
+		// 找到文件名之后不再识别文件头，避免把内容以 "++ " 开头的新增行当作文件头
+		if file == "" && strings.HasPrefix(line, "+++ ") {
+			name := strings.TrimPrefix(line, "+++ ")
+			if name != "/dev/null" {
+				file = path.Clean(strings.TrimPrefix(name, "b/"))
+			}
+			continue
+		}
+		if file != "" {
+			section = append(section, line)
+		}
+	}
+	flush()
+	return files
+}
@@ -0,0 +1,100 @@
+package publish
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/github"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// GitHubReview 根据 pull request 的文件差异生成评审：能对应到差异中某一行的问题作为行内评论，
+// 其余问题只出现在总结评论中；existing 中已包含同一指纹标记的问题不再重复评论
+func GitHubReview(reporter *review.DefaultReporter, issues []types.Issue, files []github.PullRequestFile, existing []github.ReviewComment, summary string) (github.NewReview, Result) {
+	bodies := make([]string, 0, len(existing))
+	for _, c := range existing {
+		bodies = append(bodies, c.Body)
+	}
+	positions := make(map[string]map[int]int, len(files))
+	for _, f := range files {
+		positions[path.Clean(f.Filename)] = DiffPositions(f.Patch)
+	}
+
+	var result Result
+	req := github.NewReview{Body: summary, Event: "COMMENT"}
+	for _, issue := range issues {
+		position, ok := positions[path.Clean(issue.FilePath)][issue.Line]
+		if !ok {
+			result.Outside++
+			continue
+		}
+		if commented(bodies, review.FingerprintMarker(issue)) {
+			result.Duplicate++
+			continue
+		}
+		req.Comments = append(req.Comments, github.ReviewComment{
+			Path:     path.Clean(issue.FilePath),
+			Position: position,
+			Body:     reporter.GitHubComment(issue),
+		})
+		result.Inline++
+	}
+	return req, result
+}
+
+// PublishGitHub 在 pull request 上创建一次评审，包含总结评论和各问题的行内评论
+func PublishGitHub(client *github.Client, number int, reporter *review.DefaultReporter, issues []types.Issue, summary string) (Result, error) {
+	pr, err := client.GetPullRequest(number)
+	if err != nil {
+		return Result{}, fmt.Errorf("获取 pull request #%d 失败: %v", number, err)
+	}
+	files, err := client.ListPullRequestFiles(number)
+	if err != nil {
+		return Result{}, fmt.Errorf("获取 pull request #%d 的改动文件失败: %v", number, err)
+	}
+	existing, err := client.ListReviewComments(number)
+	if err != nil {
+		return Result{}, fmt.Errorf("获取 pull request #%d 已有的评论失败: %v", number, err)
+	}
+
+	req, result := GitHubReview(reporter, issues, files, existing, summary)
+	req.CommitID = pr.Head.SHA
+	created, err := client.CreateReview(number, req)
+	if err != nil {
+		return result, fmt.Errorf("发布评审失败: %v", err)
+	}
+	result.URL = created.HTMLURL
+	return result, nil
+}
+
+// DiffPositions 计算统一差异中新文件各行的位置，返回新文件行号到位置的映射
+// 位置从第一个 @@ 头部行的下一行开始计数为 1，之后的每一行（包括后续的 @@ 头部行）依次加 1；
+// 只有新增行和上下文行可以评论，删除的行不在映射中
+func DiffPositions(patch string) map[int]int {
+	positions := make(map[int]int)
+	position, line := 0, 0
+	started := false
+	for _, text := range strings.Split(strings.TrimRight(patch, "\n"), "\n") {
+		if strings.HasPrefix(text, "@@") {
+			if started {
+				position++
+			}
+			started = true
+			hunk := review.Hunk{Header: text}
+			line, _ = hunk.NewRange()
+			continue
+		}
+		if !started {
+			continue
+		}
+		position++
+		// 删除的行和 "\ No newline at end of file" 只占位置，不对应新文件的行
+		if text == "" || text[0] == '+' || text[0] == ' ' {
+			positions[line] = position
+			line++
+		}
+	}
+	return positions
+}
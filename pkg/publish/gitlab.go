@@ -0,0 +1,130 @@
+package publish
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/gitlab"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// GitLabDiscussions 根据 merge request 的文件差异生成行内讨论：能对应到差异中某一行的问题作为行内讨论，
+// 其余问题只出现在总结评论中；existing 中已包含同一指纹标记的问题不再重复评论
+// 返回的讨论只包含文件和行号，提交 SHA 由 PublishGitLab 根据 merge request 填写
+func GitLabDiscussions(reporter *review.DefaultReporter, issues []types.Issue, diffs []gitlab.MergeRequestDiff, existing []gitlab.Discussion) ([]gitlab.NewDiscussion, Result) {
+	var bodies []string
+	for _, d := range existing {
+		for _, note := range d.Notes {
+			bodies = append(bodies, note.Body)
+		}
+	}
+	files := make(map[string]gitlab.MergeRequestDiff, len(diffs))
+	lines := make(map[string]map[int]int, len(diffs))
+	for _, d := range diffs {
+		if d.DeletedFile {
+			continue
+		}
+		files[path.Clean(d.NewPath)] = d
+		lines[path.Clean(d.NewPath)] = DiffLines(d.Diff)
+	}
+
+	var result Result
+	var discussions []gitlab.NewDiscussion
+	for _, issue := range issues {
+		filePath := path.Clean(issue.FilePath)
+		oldLine, ok := lines[filePath][issue.Line]
+		if !ok {
+			result.Outside++
+			continue
+		}
+		if commented(bodies, review.FingerprintMarker(issue)) {
+			result.Duplicate++
+			continue
+		}
+		discussions = append(discussions, gitlab.NewDiscussion{
+			Body: reporter.GitHubComment(issue),
+			Position: &gitlab.Position{
+				PositionType: "text",
+				OldPath:      files[filePath].OldPath,
+				NewPath:      files[filePath].NewPath,
+				OldLine:      oldLine,
+				NewLine:      issue.Line,
+			},
+		})
+		result.Inline++
+	}
+	return discussions, result
+}
+
+// PublishGitLab 在 merge request 上为各问题创建行内讨论，再发表一条总结评论
+// 个别行内讨论因位置无效被拒绝时，该问题计入 Outside，不影响其余讨论
+func PublishGitLab(client *gitlab.Client, iid int, reporter *review.DefaultReporter, issues []types.Issue, summary string) (Result, error) {
+	mr, err := client.GetMergeRequest(iid)
+	if err != nil {
+		return Result{}, fmt.Errorf("获取 merge request !%d 失败: %v", iid, err)
+	}
+	diffs, err := client.ListMergeRequestDiffs(iid)
+	if err != nil {
+		return Result{}, fmt.Errorf("获取 merge request !%d 的改动文件失败: %v", iid, err)
+	}
+	existing, err := client.ListDiscussions(iid)
+	if err != nil {
+		return Result{}, fmt.Errorf("获取 merge request !%d 已有的讨论失败: %v", iid, err)
+	}
+
+	discussions, result := GitLabDiscussions(reporter, issues, diffs, existing)
+	for _, d := range discussions {
+		d.Position.BaseSHA = mr.DiffRefs.BaseSHA
+		d.Position.StartSHA = mr.DiffRefs.StartSHA
+		d.Position.HeadSHA = mr.DiffRefs.HeadSHA
+		if _, err := client.CreateDiscussion(iid, d); err != nil {
+			log.Printf("在 %s:%d 创建行内讨论失败，该问题只出现在总结评论中: %v\n", d.Position.NewPath, d.Position.NewLine, err)
+			result.Inline--
+			result.Outside++
+		}
+	}
+
+	note, err := client.CreateNote(iid, summary)
+	if err != nil {
+		return result, fmt.Errorf("发表总结评论失败: %v", err)
+	}
+	if mr.WebURL != "" {
+		result.URL = fmt.Sprintf("%s#note_%d", mr.WebURL, note.ID)
+	}
+	return result, nil
+}
+
+// DiffLines 计算统一差异中新文件各行对应的旧文件行号，返回新文件行号到旧文件行号的映射
+// 新增的行对应 0；删除的行不在映射中
+func DiffLines(patch string) map[int]int {
+	lines := make(map[int]int)
+	oldLine, newLine := 0, 0
+	started := false
+	for _, text := range strings.Split(strings.TrimRight(patch, "\n"), "\n") {
+		if strings.HasPrefix(text, "@@") {
+			started = true
+			hunk := review.Hunk{Header: text}
+			newLine, _ = hunk.NewRange()
+			oldLine, _ = hunk.OldRange()
+			continue
+		}
+		if !started || strings.HasPrefix(text, `\`) {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(text, "+"):
+			lines[newLine] = 0
+			newLine++
+		case strings.HasPrefix(text, "-"):
+			oldLine++
+		default:
+			lines[newLine] = oldLine
+			oldLine++
+			newLine++
+		}
+	}
+	return lines
+}
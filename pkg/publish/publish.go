@@ -0,0 +1,25 @@
+package publish
+
+import "strings"
+
+// Result 发布到代码托管平台的结果
+type Result struct {
+	// 评审或总结评论的链接，没有创建时为空
+	URL string
+	// 新发布的行内评论数
+	Inline int
+	// 此前已经评论过、本次跳过的问题数
+	Duplicate int
+	// 不在差异中、只出现在总结评论里的问题数
+	Outside int
+}
+
+// commented 判断已有评论中是否包含指定的指纹标记
+func commented(bodies []string, marker string) bool {
+	for _, body := range bodies {
+		if strings.Contains(body, marker) {
+			return true
+		}
+	}
+	return false
+}
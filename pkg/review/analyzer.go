@@ -20,78 +20,31 @@ func NewAnalyzer(gitClient *git.GitClient) *Analyzer {
 
 // AnalyzeChanges 分析代码改动
 func (a *Analyzer) AnalyzeChanges(from, to string) ([]types.FileChange, error) {
-	// 获取改动的文件列表
-	files, err := a.gitClient.GetChangedFiles(from, to)
-	if err != nil {
-		return nil, fmt.Errorf("获取改动文件列表失败: %v", err)
-	}
-
 	// 获取详细的差异内容
 	diff, err := a.gitClient.GetDiff(from, to)
 	if err != nil {
 		return nil, fmt.Errorf("获取差异内容失败: %v", err)
 	}
 
-	// 解析差异内容
-	changes := make([]types.FileChange, 0, len(files))
-	for _, file := range files {
-		change := types.FileChange{
-			FilePath: file,
+	// 解析差异内容，按文件得到路径、改动类型和改动块
+	changes := git.ParseDiff(diff)
+	for i := range changes {
+		change := &changes[i]
+		if change.ChangeType == "deleted" || change.Binary {
+			continue
 		}
-
-		// 根据差异内容判断改动类型
-		if strings.Contains(diff, fmt.Sprintf("a/%s", file)) && strings.Contains(diff, fmt.Sprintf("b/%s", file)) {
-			change.ChangeType = "modified"
-			// 获取新文件内容
-			newContent, err := a.gitClient.GetFileContent(file, to)
-			if err == nil {
-				change.NewContent = newContent
-				// 将新文件内容按行分割
-				change.Lines = strings.Split(newContent, "\n")
-			}
-		} else if strings.Contains(diff, fmt.Sprintf("a/%s", file)) {
-			change.ChangeType = "deleted"
-		} else {
-			change.ChangeType = "added"
-			// 获取新文件内容
-			newContent, err := a.gitClient.GetFileContent(file, to)
-			if err == nil {
-				change.NewContent = newContent
-				// 将新文件内容按行分割
-				change.Lines = strings.Split(newContent, "\n")
-			}
+		// 获取新文件内容
+		newContent, err := a.gitClient.GetFileContent(change.FilePath, to)
+		if err == nil {
+			change.NewContent = newContent
+			// 将新文件内容按行分割
+			change.Lines = strings.Split(newContent, "\n")
 		}
-
-		// 提取该文件的差异内容
-		change.DiffContent = extractFileDiff(diff, file)
-		changes = append(changes, change)
 	}
 
 	return changes, nil
 }
 
-// extractFileDiff 从完整的差异内容中提取指定文件的差异
-func extractFileDiff(diff, filePath string) string {
-	lines := strings.Split(diff, "\n")
-	var fileDiff strings.Builder
-	inFile := false
-
-	for _, line := range lines {
-		if strings.Contains(line, fmt.Sprintf("diff --git a/%s b/%s", filePath, filePath)) {
-			inFile = true
-		} else if strings.HasPrefix(line, "diff --git") && inFile {
-			break
-		}
-
-		if inFile {
-			fileDiff.WriteString(line)
-			fileDiff.WriteString("\n")
-		}
-	}
-
-	return fileDiff.String()
-}
-
 // AnalyzeFiles 分析指定文件的改动
 func (a *Analyzer) AnalyzeFiles(files []string) ([]types.FileChange, error) {
 	var changes []types.FileChange
@@ -100,13 +53,18 @@ func (a *Analyzer) AnalyzeFiles(files []string) ([]types.FileChange, error) {
 		if err != nil {
 			return nil, fmt.Errorf("获取文件 %s 的改动失败: %v", file, err)
 		}
-		if diff != "" {
-			changes = append(changes, types.FileChange{
-				FilePath:    file,
-				ChangeType:  "modified",
-				DiffContent: diff,
-			})
+		if diff == "" {
+			continue
+		}
+		if parsed := git.ParseDiff(diff); len(parsed) > 0 {
+			changes = append(changes, parsed...)
+			continue
 		}
+		changes = append(changes, types.FileChange{
+			FilePath:    file,
+			ChangeType:  "modified",
+			DiffContent: diff,
+		})
 	}
 	return changes, nil
 }
@@ -125,3 +83,8 @@ func (a *Analyzer) AnalyzeCommit(commitHash string) ([]types.FileChange, error)
 func (a *Analyzer) AnalyzeWorkingDirChanges() ([]types.FileChange, error) {
 	return a.gitClient.GetWorkingDirChanges()
 }
+
+// AnalyzeUntrackedFiles 分析工作区中未跟踪的文件，遵循 .gitignore，每个文件作为包含完整内容的新增文件
+func (a *Analyzer) AnalyzeUntrackedFiles() ([]types.FileChange, error) {
+	return a.gitClient.GetUntrackedChanges()
+}
@@ -0,0 +1,269 @@
+package review
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/coverage"
+	"github.com/icatw/ai-cr-tool/pkg/deps"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/impact"
+	"github.com/icatw/ai-cr-tool/pkg/testrun"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// maxArtifacts 保留的评审结果数，超出时删除最早的
+const maxArtifacts = 50
+
+// runIDLayout 评审编号中的时间格式
+const runIDLayout = "20060102-150405"
+
+// LatestArtifact 表示最近一次评审结果的编号
+const LatestArtifact = "latest"
+
+// ArtifactDir 返回评审结果的保存目录，每次评审的结果以 JSON 报告保存为 <编号>.json，
+// 之后可以用 cr render 重新生成其他格式的报告，不需要再次调用模型
+func ArtifactDir(gitDir string) string {
+	return filepath.Join(gitDir, "ai-cr-tool", "artifacts")
+}
+
+// SaveArtifact 以 JSON 报告保存一次评审的结果，返回评审编号（形如 run-20240101-120000）
+// 同一秒内多次评审时编号附加序号，保存后删除超出保留数量的最早结果
+func SaveArtifact(dir string, report []byte, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("创建评审结果目录失败: %v", err)
+	}
+	base := "run-" + now.Format(runIDLayout)
+	id := base
+	for n := 2; ; n++ {
+		f, err := os.OpenFile(filepath.Join(dir, id+".json"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			id = fmt.Sprintf("%s-%d", base, n)
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("保存评审结果失败: %v", err)
+		}
+		_, err = f.Write(report)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", fmt.Errorf("保存评审结果失败: %v", err)
+		}
+		break
+	}
+	pruneArtifacts(dir)
+	return id, nil
+}
+
+// listArtifacts 返回目录中的评审编号，按评审时间从早到晚排列
+func listArtifacts(dir string) []string {
+	paths, _ := filepath.Glob(filepath.Join(dir, "run-*.json"))
+	ids := make([]string, 0, len(paths))
+	for _, path := range paths {
+		ids = append(ids, strings.TrimSuffix(filepath.Base(path), ".json"))
+	}
+	// 编号以时间开头，同一秒内的序号按数值排列
+	sort.Slice(ids, func(i, j int) bool {
+		ti, ni := splitRunID(ids[i])
+		tj, nj := splitRunID(ids[j])
+		if ti != tj {
+			return ti < tj
+		}
+		return ni < nj
+	})
+	return ids
+}
+
+// splitRunID 拆分评审编号中的时间和同一秒内的序号，没有序号时为1
+func splitRunID(id string) (stamp string, seq int) {
+	rest := strings.TrimPrefix(id, "run-")
+	if len(rest) <= len(runIDLayout) {
+		return rest, 1
+	}
+	seq, _ = strconv.Atoi(strings.TrimPrefix(rest[len(runIDLayout):], "-"))
+	return rest[:len(runIDLayout)], seq
+}
+
+// pruneArtifacts 删除超出保留数量的最早结果，失败时忽略
+func pruneArtifacts(dir string) {
+	ids := listArtifacts(dir)
+	for len(ids) > maxArtifacts {
+		os.Remove(filepath.Join(dir, ids[0]+".json"))
+		ids = ids[1:]
+	}
+}
+
+// ResolveArtifact 返回评审结果文件的路径：ref 为已存在的文件时直接使用，
+// 为 latest 时使用最近一次评审的结果，否则视为评审编号
+func ResolveArtifact(dir, ref string) (string, error) {
+	if info, err := os.Stat(ref); err == nil && !info.IsDir() {
+		return ref, nil
+	}
+	if ref == LatestArtifact {
+		ids := listArtifacts(dir)
+		if len(ids) == 0 {
+			return "", i18n.Errorf("render.err.no_artifacts")
+		}
+		ref = ids[len(ids)-1]
+	}
+	path := filepath.Join(dir, strings.TrimSuffix(ref, ".json")+".json")
+	if _, err := os.Stat(path); err != nil {
+		return "", i18n.Errorf("render.err.artifact_not_found", ref)
+	}
+	return path, nil
+}
+
+// NewReporter 从 JSON 报告还原报告生成器，用于重新生成其他格式的报告
+// 文件改动不在 JSON 报告中，还原后问题下只展示代码片段
+func (r *JSONReport) NewReporter(lang i18n.Lang) *DefaultReporter {
+	reporter := NewReporterWithLang(r.Project, r.Commit, lang)
+	reporter.ReviewedAt = r.GeneratedAt.Local()
+	issues := r.ToIssues()
+
+	if s := r.Summary; s.Model != "" || s.DurationMS > 0 {
+		reporter.Stats = &ReviewStats{
+			Model:            s.Model,
+			ChangedLines:     s.ChangedLines,
+			Duration:         time.Duration(s.DurationMS) * time.Millisecond,
+			PromptTokens:     s.PromptTokens,
+			CompletionTokens: s.CompletionTokens,
+			CachedTokens:     s.CachedTokens,
+			Cost:             s.Cost,
+			Currency:         s.Currency,
+			CostSaved:        s.CostSaved,
+		}
+		reporter.Model = s.Model
+	}
+
+	if c := r.Comparison; c != nil {
+		comparison := &Comparison{
+			PreviousAt:     c.PreviousAt,
+			PreviousScore:  c.PreviousScore,
+			Score:          c.Score,
+			PreviousCounts: make(map[types.SeverityLevel]int),
+			Counts:         CountBySeverity(issues),
+			Resolved:       (&JSONReport{Issues: c.Resolved}).ToIssues(),
+		}
+		for severity, count := range c.PreviousBySeverity {
+			comparison.PreviousCounts[types.SeverityLevel(severity)] = count
+		}
+		comparison.New = issuesWithFingerprints(issues, c.NewFingerprints)
+		comparison.Persisting = issuesWithFingerprints(issues, c.PersistingFingerprints)
+		reporter.Comparison = comparison
+	}
+
+	for _, i := range r.Impact {
+		reporter.Impact = append(reporter.Impact, impact.PackageImpact{Package: i.Package, Files: i.Files, Direct: i.Direct, Transitive: i.Transitive})
+	}
+
+	if t := r.Tests; t != nil {
+		tests := &testrun.Result{Command: t.Command, Passed: t.Passed, Duration: time.Duration(t.DurationMS) * time.Millisecond, Output: t.Output}
+		for _, f := range t.Failures {
+			tests.Failures = append(tests.Failures, testrun.Failure{Package: f.Package, Test: f.Test, Output: f.Output})
+		}
+		reporter.Tests = tests
+	}
+
+	if c := r.Coverage; c != nil {
+		cov := &coverage.Report{Total: c.Total, Covered: c.Covered}
+		for _, f := range c.Files {
+			cov.Files = append(cov.Files, coverage.FileCoverage{File: f.File, Total: f.Total, Covered: f.Covered, Uncovered: f.Uncovered})
+		}
+		reporter.Coverage = cov
+	}
+
+	for _, a := range r.Authors {
+		reporter.Authors = append(reporter.Authors, AuthorIssues{Author: a.Author, Files: a.Files, Issues: (&JSONReport{Issues: a.Issues}).ToIssues()})
+	}
+
+	if tb := r.TimeBox; tb != nil {
+		reporter.TimeBox = &TimeBox{Limit: time.Duration(tb.LimitMS) * time.Millisecond, Skipped: tb.Skipped}
+	}
+
+	reporter.BinaryFiles = r.BinaryFiles
+
+	for _, f := range r.Failures {
+		reporter.Failures = append(reporter.Failures, FailedFile{
+			FilePath:   f.File,
+			Provider:   f.Provider,
+			StatusCode: f.StatusCode,
+			Class:      ErrorClass(f.ErrorClass),
+			Retries:    f.Retries,
+			Message:    f.Error,
+		})
+	}
+
+	if s := r.ExecutiveSummary; s != nil {
+		reporter.Summary = &ExecutiveSummary{Overview: s.Overview, Risks: s.Risks, Decision: MergeDecision(s.Decision)}
+		if tr := s.Translation; tr != nil {
+			reporter.Summary.Translation = &SummaryTranslation{Lang: i18n.Lang(tr.Lang), Overview: tr.Overview, Risks: tr.Risks}
+		}
+	}
+	for _, jt := range r.Themes {
+		theme := Theme{Title: jt.Title, Summary: jt.Summary, Severity: types.SeverityLevel(jt.Severity)}
+		for _, issue := range jt.Issues {
+			theme.Issues = append(theme.Issues, ThemeIssue{
+				File:        issue.File,
+				Line:        issue.Line,
+				Severity:    types.SeverityLevel(issue.Severity),
+				Title:       issue.Title,
+				Fingerprint: issue.Fingerprint,
+			})
+		}
+		reporter.Themes = append(reporter.Themes, theme)
+	}
+
+	// JSON 中的改动类型没有顺序，按展示顺序还原
+	for _, kind := range types.ChangeKinds {
+		if n := r.ChangeKinds[string(kind)]; n > 0 {
+			reporter.ChangeKinds = append(reporter.ChangeKinds, KindCount{Kind: kind, Files: n})
+		}
+	}
+
+	if d := r.Dependencies; d != nil {
+		dependencies := &DependencyReview{Overview: d.Overview}
+		for _, c := range d.Changes {
+			dependencies.Findings = append(dependencies.Findings, DependencyFinding{
+				Change: deps.Change{
+					Manifest: c.Manifest, Ecosystem: c.Ecosystem, Name: c.Name,
+					From: c.From, To: c.To, Type: deps.ChangeType(c.Change), Jump: deps.Jump(c.Jump),
+				},
+				Risk:        DependencyRisk(c.Risk),
+				Breaking:    c.Breaking,
+				SupplyChain: c.SupplyChain,
+			})
+		}
+		reporter.Dependencies = dependencies
+	}
+
+	for _, c := range r.Cache {
+		status := CacheStatus{FilePath: c.File, Hit: c.Hit, Model: c.Model}
+		if c.CachedAt != nil {
+			status.CachedAt = *c.CachedAt
+		}
+		reporter.Cache = append(reporter.Cache, status)
+	}
+	return reporter
+}
+
+// issuesWithFingerprints 返回指纹在 fingerprints 中的问题
+func issuesWithFingerprints(issues []types.Issue, fingerprints []string) []types.Issue {
+	wanted := make(map[string]bool, len(fingerprints))
+	for _, fp := range fingerprints {
+		wanted[fp] = true
+	}
+	var result []types.Issue
+	for _, issue := range issues {
+		if wanted[issue.Fingerprint] {
+			result = append(result, issue)
+		}
+	}
+	return result
+}
@@ -0,0 +1,42 @@
+// 离线报告使用的轻量代码高亮：识别注释、字符串、数字和常见关键字，
+// 行首的 "> 12 │" 行号标记单独着色。
+(function () {
+  var keywords = new Set((
+    "break case catch class const continue def default defer delete do elif else enum except export extends " +
+    "false finally fn for func function go goto if impl import in interface let map match new nil none null " +
+    "package private protected public range raise return select self static struct super switch this throw " +
+    "true try type typeof var void while with yield async await lambda from as pass fi then done esac local"
+  ).split(" "));
+  var token = /(\/\/.*|#.*|\/\*[\s\S]*?\*\/)|("(?:\\.|[^"\\])*"|'(?:\\.|[^'\\])*'|`[^`]*`)|\b(\d+(?:\.\d+)?)\b|\b([A-Za-z_]\w*)\b/g;
+
+  function escape(s) {
+    return s.replace(/&/g, "&amp;").replace(/</g, "&lt;").replace(/>/g, "&gt;");
+  }
+
+  function highlight(code) {
+    var out = "", last = 0, m;
+    token.lastIndex = 0;
+    while ((m = token.exec(code)) !== null) {
+      out += escape(code.slice(last, m.index));
+      last = token.lastIndex;
+      if (m[1]) out += '<span class="hl-comment">' + escape(m[1]) + "</span>";
+      else if (m[2]) out += '<span class="hl-string">' + escape(m[2]) + "</span>";
+      else if (m[3]) out += '<span class="hl-number">' + m[3] + "</span>";
+      else if (keywords.has(m[4])) out += '<span class="hl-keyword">' + m[4] + "</span>";
+      else out += m[4];
+    }
+    return out + escape(code.slice(last));
+  }
+
+  function highlightLine(line) {
+    var sep = line.indexOf("│");
+    if (sep < 0) return highlight(line);
+    return '<span class="hl-marker">' + escape(line.slice(0, sep + 1)) + "</span>" + highlight(line.slice(sep + 1));
+  }
+
+  document.addEventListener("DOMContentLoaded", function () {
+    document.querySelectorAll("pre code").forEach(function (el) {
+      el.innerHTML = el.textContent.split("\n").map(highlightLine).join("\n");
+    });
+  });
+})();
@@ -0,0 +1,82 @@
+// HTML 报告的交互功能：按严重程度、文件和类别筛选、全文搜索、折叠问题、表格排序。
+(function () {
+  function setupFilters() {
+    var toolbar = document.getElementById("issue-toolbar");
+    if (!toolbar) return;
+    var issues = Array.prototype.slice.call(document.querySelectorAll(".issue"));
+    var search = document.getElementById("issue-search");
+    var severity = document.getElementById("severity-filter");
+    var file = document.getElementById("file-filter");
+    var category = document.getElementById("category-filter");
+    var groups = Array.prototype.slice.call(document.querySelectorAll(".category-group, .file-group"));
+    var count = document.getElementById("issue-count");
+    var toggle = document.getElementById("toggle-all");
+    var countFormat = toolbar.getAttribute("data-count-format");
+
+    function apply() {
+      var query = search.value.trim().toLowerCase();
+      var shown = 0;
+      issues.forEach(function (el) {
+        var visible = (!severity.value || el.getAttribute("data-severity") === severity.value) &&
+          (!file.value || el.getAttribute("data-file") === file.value) &&
+          (!category.value || el.getAttribute("data-category") === category.value) &&
+          (!query || el.textContent.toLowerCase().indexOf(query) >= 0);
+        el.hidden = !visible;
+        if (visible) shown++;
+      });
+      // 隐藏没有可见问题的文件和类别分组
+      groups.forEach(function (group) {
+        group.hidden = !group.querySelector(".issue:not([hidden])");
+      });
+      count.textContent = countFormat.replace("{shown}", shown).replace("{total}", issues.length);
+    }
+
+    search.addEventListener("input", apply);
+    severity.addEventListener("change", apply);
+    file.addEventListener("change", apply);
+    category.addEventListener("change", apply);
+
+    issues.forEach(function (el) {
+      el.querySelector(".issue-title").addEventListener("click", function () {
+        el.classList.toggle("collapsed");
+      });
+    });
+    toggle.addEventListener("click", function () {
+      var collapse = issues.some(function (el) { return !el.classList.contains("collapsed"); });
+      issues.forEach(function (el) { el.classList.toggle("collapsed", collapse); });
+      toggle.textContent = toggle.getAttribute(collapse ? "data-expand" : "data-collapse");
+    });
+    apply();
+  }
+
+  // 点击表头按该列排序，再次点击反向；数字列按数值排序
+  function setupSortableTables() {
+    document.querySelectorAll("table").forEach(function (table) {
+      var rows = table.querySelectorAll("tr");
+      if (rows.length < 3) return;
+      var header = rows[0];
+      Array.prototype.forEach.call(header.children, function (th, col) {
+        th.classList.add("sortable");
+        th.addEventListener("click", function () {
+          var asc = th.getAttribute("data-sort") !== "asc";
+          Array.prototype.forEach.call(header.children, function (h) { h.removeAttribute("data-sort"); });
+          th.setAttribute("data-sort", asc ? "asc" : "desc");
+          var body = Array.prototype.slice.call(table.querySelectorAll("tr")).slice(1);
+          body.sort(function (a, b) {
+            var x = a.children[col] ? a.children[col].textContent.trim() : "";
+            var y = b.children[col] ? b.children[col].textContent.trim() : "";
+            var nx = parseFloat(x), ny = parseFloat(y);
+            var cmp = !isNaN(nx) && !isNaN(ny) ? nx - ny : x.localeCompare(y);
+            return asc ? cmp : -cmp;
+          });
+          body.forEach(function (row) { row.parentNode.appendChild(row); });
+        });
+      });
+    });
+  }
+
+  document.addEventListener("DOMContentLoaded", function () {
+    setupFilters();
+    setupSortableTables();
+  });
+})();
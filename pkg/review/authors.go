@@ -0,0 +1,175 @@
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// AuthorIssues 某位作者负责的改动和问题
+type AuthorIssues struct {
+	// 作者，格式为 "姓名 <邮箱>"，无法确定作者时为空
+	Author string
+	// 作者修改过的文件
+	Files []string
+	// 归属于该作者的问题
+	Issues []types.Issue
+}
+
+// GroupByAuthor 按作者分组问题
+// authorOf 返回问题的作者，filesOf 为每位作者修改过的文件；结果按问题数降序排列，未确定作者的分组排在最后
+func GroupByAuthor(issues []types.Issue, filesOf map[string][]string, authorOf func(types.Issue) string) []AuthorIssues {
+	groups := make(map[string]*AuthorIssues)
+	get := func(author string) *AuthorIssues {
+		g, ok := groups[author]
+		if !ok {
+			g = &AuthorIssues{Author: author, Files: filesOf[author]}
+			groups[author] = g
+		}
+		return g
+	}
+	for author := range filesOf {
+		get(author)
+	}
+	for _, issue := range issues {
+		g := get(authorOf(issue))
+		g.Issues = append(g.Issues, issue)
+	}
+
+	result := make([]AuthorIssues, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if (a.Author == "") != (b.Author == "") {
+			return b.Author == ""
+		}
+		if len(a.Issues) != len(b.Issues) {
+			return len(a.Issues) > len(b.Issues)
+		}
+		return a.Author < b.Author
+	})
+	return result
+}
+
+// AuthorSlug 将作者转换为可用于文件名的标识，优先使用邮箱的用户名部分
+func AuthorSlug(author string) string {
+	name := author
+	if start := strings.Index(author, "<"); start >= 0 {
+		if at := strings.Index(author[start:], "@"); at > 1 {
+			name = author[start+1 : start+at]
+		} else {
+			name = strings.TrimSpace(author[:start])
+		}
+	}
+	var buf strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			buf.WriteRune(r)
+		case r > 127:
+			buf.WriteRune(r)
+		default:
+			buf.WriteRune('-')
+		}
+	}
+	if slug := strings.Trim(buf.String(), "-."); slug != "" {
+		return slug
+	}
+	return "unknown"
+}
+
+// AuthorName 返回作者的显示名称，无法确定作者时返回本地化的“未知作者”
+func (r *DefaultReporter) AuthorName(author string) string {
+	if author == "" {
+		return r.Lang.T("report.unknown_author")
+	}
+	return author
+}
+
+// authorSummary 生成作者问题数的概要，如 "error 1, warning 2"
+func authorSummary(issues []types.Issue) string {
+	counts := CountBySeverity(issues)
+	var parts []string
+	for _, severity := range []types.SeverityLevel{types.SeverityError, types.SeverityWarning, types.SeverityInfo} {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", severity, counts[severity]))
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeMarkdownAuthors 写入Markdown格式的按作者分组
+func (r *DefaultReporter) writeMarkdownAuthors(buf *bytes.Buffer) {
+	t := r.Lang.T
+	buf.WriteString(fmt.Sprintf("## %s\n\n", t("report.by_author")))
+	buf.WriteString(fmt.Sprintf("| %s | %s | %s |\n", t("report.author"), t("report.files_changed"), t("report.issues_column")))
+	buf.WriteString("|------|------|------|\n")
+	for _, g := range r.Authors {
+		buf.WriteString(fmt.Sprintf("| %s | %d | %s |\n", tableCell(r.AuthorName(g.Author)), len(g.Files), authorSummary(g.Issues)))
+	}
+	buf.WriteString("\n")
+
+	for _, g := range r.Authors {
+		if len(g.Issues) == 0 {
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("### %s\n\n", r.AuthorName(g.Author)))
+		for _, issue := range g.Issues {
+			buf.WriteString(fmt.Sprintf("- **[%s]** %s — `%s`", issue.Severity, issue.Title, issue.FilePath))
+			if issue.Line > 0 {
+				buf.WriteString(fmt.Sprintf(":%d", issue.Line))
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString("\n")
+	}
+}
+
+// writeHTMLAuthors 写入HTML格式的按作者分组
+func (r *DefaultReporter) writeHTMLAuthors(buf *bytes.Buffer) {
+	t := r.Lang.T
+	buf.WriteString(fmt.Sprintf(`
+	<h2>%s</h2>
+	<div class="chart">
+		<table>
+			<tr><th>%s</th><th>%s</th><th>%s</th></tr>`,
+		t("report.by_author"), t("report.author"), t("report.files_changed"), t("report.issues_column")))
+	for _, g := range r.Authors {
+		buf.WriteString(fmt.Sprintf(`
+			<tr><td>%s</td><td>%d</td><td>%s</td></tr>`,
+			html.EscapeString(r.AuthorName(g.Author)), len(g.Files), authorSummary(g.Issues)))
+	}
+	buf.WriteString(`
+		</table>`)
+
+	for _, g := range r.Authors {
+		if len(g.Issues) == 0 {
+			continue
+		}
+		buf.WriteString(fmt.Sprintf(`
+		<h3>%s</h3>
+		<ul>`, html.EscapeString(r.AuthorName(g.Author))))
+		for _, issue := range g.Issues {
+			location := issue.FilePath
+			if issue.Line > 0 {
+				location = fmt.Sprintf("%s:%d", issue.FilePath, issue.Line)
+			}
+			buf.WriteString(fmt.Sprintf(`
+			<li><strong>[%s]</strong> %s — <code>%s</code></li>`,
+				issue.Severity, html.EscapeString(issue.Title), html.EscapeString(location)))
+		}
+		buf.WriteString(`
+		</ul>`)
+	}
+	buf.WriteString(`
+	</div>`)
+}
@@ -0,0 +1,95 @@
+package review
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// badgeColors 徽章颜色，按最高严重程度选择，与 shields.io 的配色一致
+var badgeColors = map[types.SeverityLevel]struct{ name, hex string }{
+	types.SeverityError:   {"red", "#e05d44"},
+	types.SeverityWarning: {"yellow", "#dfb317"},
+	types.SeverityInfo:    {"blue", "#007ec6"},
+	"":                    {"brightgreen", "#4c1"},
+}
+
+// HighestSeverity 返回问题中最高的严重程度，没有问题时返回空字符串
+func HighestSeverity(issues []types.Issue) types.SeverityLevel {
+	var highest types.SeverityLevel
+	for _, issue := range issues {
+		if issue.Severity.Rank() > highest.Rank() {
+			highest = issue.Severity
+		}
+	}
+	return highest
+}
+
+// badgeText 返回徽章的标签和内容，如 "代码评审"、"85 B · 3 个问题"
+func (r *DefaultReporter) badgeText(issues []types.Issue) (string, string) {
+	score := QualityScore(issues)
+	if len(issues) == 0 {
+		return r.Lang.T("badge.label"), r.Lang.T("badge.no_issues", score, Grade(score))
+	}
+	return r.Lang.T("badge.label"), r.Lang.T("badge.message", score, Grade(score), len(issues))
+}
+
+// generateBadgeJSON 生成 shields.io endpoint 格式的徽章数据
+// 字段只包含 endpoint 规范定义的内容，问题数等明细见 JSON 报告的 summary
+func (r *DefaultReporter) generateBadgeJSON(issues []types.Issue) ([]byte, error) {
+	label, message := r.badgeText(issues)
+	badge := struct {
+		SchemaVersion int    `json:"schemaVersion"`
+		Label         string `json:"label"`
+		Message       string `json:"message"`
+		Color         string `json:"color"`
+	}{1, label, message, badgeColors[HighestSeverity(issues)].name}
+	data, err := json.Marshal(badge)
+	if err != nil {
+		return nil, fmt.Errorf("生成徽章失败: %v", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// generateBadgeSVG 生成 shields.io flat 样式的 SVG 徽章
+func (r *DefaultReporter) generateBadgeSVG(issues []types.Issue) ([]byte, error) {
+	label, message := r.badgeText(issues)
+	color := badgeColors[HighestSeverity(issues)].hex
+	// 按 11px Verdana 估算文字宽度，中日韩字符按两个字符计算
+	labelWidth := displayWidth(label)*6 + 12
+	messageWidth := displayWidth(message)*6 + 12
+	width := labelWidth + messageWidth
+
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">
+  <title>%s: %s</title>
+  <linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+  <clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>
+  <g clip-path="url(#r)">
+    <rect width="%d" height="20" fill="#555"/>
+    <rect x="%d" width="%d" height="20" fill="%s"/>
+    <rect width="%d" height="20" fill="url(#s)"/>
+  </g>
+  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+    <text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text>
+    <text x="%d" y="14">%s</text>
+    <text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text>
+    <text x="%d" y="14">%s</text>
+  </g>
+</svg>
+`,
+		width, html.EscapeString(label), html.EscapeString(message),
+		html.EscapeString(label), html.EscapeString(message),
+		width,
+		labelWidth,
+		labelWidth, messageWidth, color,
+		width,
+		labelWidth/2, html.EscapeString(label),
+		labelWidth/2, html.EscapeString(label),
+		labelWidth+messageWidth/2, html.EscapeString(message),
+		labelWidth+messageWidth/2, html.EscapeString(message)))
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,100 @@
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// binaryExtensions 按扩展名识别的二进制文件，git 把它们当作文本比较时（如开头没有 NUL 字节）同样跳过
+var binaryExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".bmp": true, ".ico": true, ".webp": true, ".tif": true, ".tiff": true, ".psd": true,
+	".mp3": true, ".wav": true, ".ogg": true, ".flac": true, ".mp4": true, ".mov": true, ".avi": true, ".webm": true,
+	".ttf": true, ".otf": true, ".woff": true, ".woff2": true, ".eot": true,
+	".zip": true, ".tar": true, ".gz": true, ".tgz": true, ".bz2": true, ".xz": true, ".zst": true, ".7z": true, ".rar": true,
+	".jar": true, ".war": true, ".ear": true, ".class": true, ".pyc": true, ".pyo": true, ".o": true, ".obj": true,
+	".exe": true, ".dll": true, ".so": true, ".dylib": true, ".a": true, ".lib": true, ".bin": true, ".wasm": true,
+	".pdf": true, ".doc": true, ".docx": true, ".xls": true, ".xlsx": true, ".ppt": true, ".pptx": true,
+	".db": true, ".sqlite": true, ".parquet": true, ".onnx": true, ".pt": true, ".h5": true, ".pb": true, ".keystore": true, ".jks": true, ".p12": true,
+}
+
+// maxNonTextRatio 差异内容中无效 UTF-8 和控制字符所占比例超过该值时视为二进制内容
+const maxNonTextRatio = 0.1
+
+// IsBinaryChange 判断文件改动是否为二进制文件：git 输出了二进制标记（Binary files ... differ 或 GIT binary patch）、
+// 扩展名为常见的二进制格式，或差异内容中含有 NUL 字节、大量无效 UTF-8 和控制字符（如设置了 diff 属性强制按文本比较）
+func IsBinaryChange(change types.FileChange) bool {
+	if change.Binary || binaryExtensions[strings.ToLower(path.Ext(change.FilePath))] {
+		return true
+	}
+	return isBinaryContent(change.Hunks)
+}
+
+// isBinaryContent 检查改动块中的内容是否像二进制数据
+func isBinaryContent(hunks []types.DiffHunk) bool {
+	total, bad := 0, 0
+	for _, hunk := range hunks {
+		for _, line := range hunk.Lines {
+			if strings.IndexByte(line.Content, 0) >= 0 {
+				return true
+			}
+			for i := 0; i < len(line.Content); {
+				r, size := utf8.DecodeRuneInString(line.Content[i:])
+				if r == utf8.RuneError && size == 1 || r < 0x20 && r != '\t' && r != '\r' && r != '\f' {
+					bad++
+				}
+				total++
+				i += size
+			}
+		}
+	}
+	return total > 0 && float64(bad) > float64(total)*maxNonTextRatio
+}
+
+// SkipBinary 分出二进制文件，marked 为 .gitattributes 中标记为二进制（binary 或 -diff）的文件
+// 返回其余的改动和跳过的改动
+func SkipBinary(changes []types.FileChange, marked map[string]bool) ([]types.FileChange, []types.FileChange) {
+	var kept, skipped []types.FileChange
+	for _, change := range changes {
+		if marked[change.FilePath] || IsBinaryChange(change) {
+			skipped = append(skipped, change)
+		} else {
+			kept = append(kept, change)
+		}
+	}
+	return kept, skipped
+}
+
+// binaryNotice 返回跳过二进制文件的说明文本
+func (r *DefaultReporter) binaryNotice() string {
+	return r.Lang.T("report.binary_skipped", len(r.BinaryFiles))
+}
+
+// writeMarkdownBinary 写入Markdown格式的二进制文件说明
+func (r *DefaultReporter) writeMarkdownBinary(buf *bytes.Buffer) {
+	buf.WriteString(fmt.Sprintf("> ℹ️ **%s**\n>\n", r.binaryNotice()))
+	for _, file := range r.BinaryFiles {
+		buf.WriteString(fmt.Sprintf("> - `%s`\n", file))
+	}
+	buf.WriteString("\n")
+}
+
+// writeHTMLBinary 写入HTML格式的二进制文件说明
+func (r *DefaultReporter) writeHTMLBinary(buf *bytes.Buffer) {
+	buf.WriteString(fmt.Sprintf(`
+	<div class="notice">
+		<strong>ℹ️ %s</strong>
+		<ul>`, html.EscapeString(r.binaryNotice())))
+	for _, file := range r.BinaryFiles {
+		buf.WriteString(fmt.Sprintf(`
+			<li><code>%s</code></li>`, html.EscapeString(file)))
+	}
+	buf.WriteString(`
+		</ul>
+	</div>`)
+}
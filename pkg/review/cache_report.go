@@ -0,0 +1,89 @@
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"time"
+)
+
+// CacheStatus 单个文件的评审结果缓存命中情况
+type CacheStatus struct {
+	FilePath string
+	// 评审结果是否来自缓存
+	Hit bool
+	// 命中时缓存结果的生成时间
+	CachedAt time.Time
+	// 命中时生成缓存结果的模型，较早的缓存没有记录模型
+	Model string
+}
+
+// ModelName 返回生成缓存结果的模型，未记录时返回 "-"
+func (s CacheStatus) ModelName() string {
+	if s.Model == "" {
+		return "-"
+	}
+	return s.Model
+}
+
+// CacheStatuses 返回上一次 Review 中各文件的缓存命中情况，按输入顺序排列
+// 未使用缓存、从断点恢复和因评审时限跳过的文件不包含在内
+func (e *Engine) CacheStatuses() []CacheStatus {
+	return e.cacheStatuses
+}
+
+// cacheHits 统计命中缓存的文件数
+func cacheHits(statuses []CacheStatus) int {
+	hits := 0
+	for _, s := range statuses {
+		if s.Hit {
+			hits++
+		}
+	}
+	return hits
+}
+
+// cacheRow 返回缓存附录中单个文件的状态、生成时间和模型
+func (r *DefaultReporter) cacheRow(s CacheStatus) (status, cachedAt, model string) {
+	if !s.Hit {
+		return r.Lang.T("report.cache_miss"), "-", "-"
+	}
+	age := r.Lang.T("report.cache_age", s.CachedAt.Format("2006-01-02 15:04"), r.Lang.Duration(time.Since(s.CachedAt)))
+	return r.Lang.T("report.cache_hit"), age, s.ModelName()
+}
+
+// writeMarkdownCache 写入Markdown格式的缓存附录
+func (r *DefaultReporter) writeMarkdownCache(buf *bytes.Buffer) {
+	t := r.Lang.T
+	buf.WriteString(fmt.Sprintf("## %s\n\n", t("report.cache_appendix")))
+	buf.WriteString(fmt.Sprintf("%s\n\n", t("report.cache_notice", cacheHits(r.Cache), len(r.Cache))))
+	buf.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", t("report.file_column"), t("report.cache_column"), t("report.cached_at_column"), t("report.model_column")))
+	buf.WriteString("|------|------|------|------|\n")
+	for _, s := range r.Cache {
+		status, cachedAt, model := r.cacheRow(s)
+		buf.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s |\n", s.FilePath, status, cachedAt, model))
+	}
+	buf.WriteString("\n")
+}
+
+// writeHTMLCache 写入HTML格式的缓存附录
+func (r *DefaultReporter) writeHTMLCache(buf *bytes.Buffer) {
+	t := r.Lang.T
+	buf.WriteString(fmt.Sprintf(`
+	<h2>%s</h2>
+	<p>%s</p>
+	<div class="chart">
+		<table>
+			<tr><th>%s</th><th>%s</th><th>%s</th><th>%s</th></tr>`,
+		t("report.cache_appendix"), html.EscapeString(t("report.cache_notice", cacheHits(r.Cache), len(r.Cache))),
+		t("report.file_column"), t("report.cache_column"), t("report.cached_at_column"), t("report.model_column")))
+	for _, s := range r.Cache {
+		status, cachedAt, model := r.cacheRow(s)
+		buf.WriteString(fmt.Sprintf(`
+			<tr><td><code>%s</code></td><td>%s</td><td>%s</td><td>%s</td></tr>`,
+			html.EscapeString(s.FilePath), status, html.EscapeString(cachedAt), html.EscapeString(model)))
+	}
+	buf.WriteString(`
+		</table>
+	</div>`)
+}
@@ -0,0 +1,148 @@
+package review
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/model"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// Checkpoint 评审断点，记录每个已完成文件的评审结果
+// 每完成一个文件就会落盘，进程被中断后可以通过 --resume 从断点继续
+type Checkpoint struct {
+	path string
+	mu   sync.Mutex
+
+	// 本次评审的标识，由模型、报告语言、评审提示和规则以及所有文件的差异内容计算得到
+	RunKey string `json:"run_key"`
+	// 评审开始时间
+	StartedAt time.Time `json:"started_at"`
+	// 已完成文件的评审结果
+	Completed map[string][]types.Issue `json:"completed"`
+}
+
+// RunKey 计算本次评审的标识，改动内容、模型、报告语言、提示模板或术语表不同的评审不会共用断点
+// prompt 为 nil 时按默认提示模板计算
+func RunKey(modelName string, prompt *model.ReviewPrompt, changes []types.FileChange) string {
+	if prompt == nil {
+		prompt = model.DefaultReviewPrompt()
+	}
+	sorted := make([]types.FileChange, len(changes))
+	copy(sorted, changes)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].FilePath < sorted[j].FilePath })
+
+	h := sha256.New()
+	h.Write([]byte(modelName))
+	h.Write([]byte{0})
+	h.Write([]byte(prompt.Language))
+	h.Write([]byte{0})
+	h.Write([]byte(prompt.Fingerprint()))
+	terms := make([]string, 0, len(prompt.Glossary))
+	for term := range prompt.Glossary {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+	for _, term := range terms {
+		h.Write([]byte{0})
+		h.Write([]byte(term + "=" + prompt.Glossary[term]))
+	}
+	for _, change := range sorted {
+		h.Write([]byte{0})
+		h.Write([]byte(change.FilePath))
+		h.Write([]byte{0})
+		h.Write([]byte(change.DiffContent))
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// OpenCheckpoint 打开断点文件
+// resume 为 true 且已有断点的 RunKey 与本次一致时沿用已完成的结果，否则从头开始
+func OpenCheckpoint(path, runKey string, resume bool) (*Checkpoint, error) {
+	cp := &Checkpoint{
+		path:      path,
+		RunKey:    runKey,
+		StartedAt: time.Now(),
+		Completed: make(map[string][]types.Issue),
+	}
+	if !resume {
+		return cp, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cp, nil
+		}
+		return nil, fmt.Errorf("读取评审断点失败: %v", err)
+	}
+
+	var saved Checkpoint
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("解析评审断点失败: %v", err)
+	}
+	if saved.RunKey != runKey {
+		// 改动内容已经变化，旧断点不再适用
+		return cp, nil
+	}
+	if saved.Completed != nil {
+		cp.Completed = saved.Completed
+	}
+	cp.StartedAt = saved.StartedAt
+	return cp, nil
+}
+
+// Lookup 查询文件是否已在断点中完成
+func (c *Checkpoint) Lookup(filePath string) ([]types.Issue, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	issues, ok := c.Completed[filePath]
+	return issues, ok
+}
+
+// Len 返回已完成的文件数
+func (c *Checkpoint) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.Completed)
+}
+
+// Record 记录文件的评审结果并立即落盘
+func (c *Checkpoint) Record(filePath string, issues []types.Issue) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if issues == nil {
+		issues = []types.Issue{}
+	}
+	c.Completed[filePath] = issues
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// Remove 评审全部完成后删除断点文件
+func (c *Checkpoint) Remove() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
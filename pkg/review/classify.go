@@ -0,0 +1,180 @@
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"path"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// dependencyFiles 依赖清单和锁文件
+var dependencyFiles = map[string]bool{
+	"go.mod": true, "go.sum": true, "go.work": true, "go.work.sum": true,
+	"package.json": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true, "npm-shrinkwrap.json": true,
+	"requirements.txt": true, "pipfile": true, "pipfile.lock": true, "poetry.lock": true, "pyproject.toml": true,
+	"cargo.toml": true, "cargo.lock": true, "gemfile": true, "gemfile.lock": true,
+	"composer.json": true, "composer.lock": true, "pom.xml": true, "build.gradle": true, "build.gradle.kts": true,
+}
+
+// docExtensions 文档文件的扩展名
+var docExtensions = map[string]bool{
+	".md": true, ".markdown": true, ".rst": true, ".adoc": true, ".txt": true,
+}
+
+// configExtensions 配置文件的扩展名
+var configExtensions = map[string]bool{
+	".yml": true, ".yaml": true, ".json": true, ".toml": true, ".ini": true, ".cfg": true,
+	".conf": true, ".properties": true, ".env": true, ".editorconfig": true,
+}
+
+// ClassifyChange 根据路径和差异推断文件改动的类型
+// 依赖、测试、文档和配置文件按路径判断；源代码文件新增或以新增为主时为 feature，否则为 refactor，
+// 是否为 bugfix 需要由模型在评审时确认
+func ClassifyChange(change types.FileChange) types.ChangeKind {
+	filePath := strings.TrimPrefix(path.Clean(change.FilePath), "./")
+	base := strings.ToLower(path.Base(filePath))
+	ext := path.Ext(base)
+
+	switch {
+	case dependencyFiles[base], strings.HasPrefix(base, "requirements") && ext == ".txt",
+		strings.HasPrefix(filePath, "vendor/"), strings.Contains(filePath, "/vendor/"),
+		strings.Contains(filePath, "node_modules/"):
+		return types.KindDependency
+	case isTestFile(filePath, base):
+		return types.KindTest
+	case docExtensions[ext], strings.HasPrefix(filePath, "docs/"), strings.HasPrefix(base, "license"),
+		strings.HasPrefix(base, "changelog"):
+		return types.KindDocs
+	case configExtensions[ext], strings.HasPrefix(filePath, ".github/"), base == "dockerfile",
+		strings.HasPrefix(base, "docker-compose"), base == ".gitignore", base == ".gitattributes":
+		return types.KindConfig
+	}
+
+	switch change.ChangeType {
+	case "added":
+		return types.KindFeature
+	case "deleted", "renamed":
+		return types.KindRefactor
+	}
+	added, removed := diffLineCounts(change.DiffContent)
+	if removed == 0 || added >= 2*removed {
+		return types.KindFeature
+	}
+	return types.KindRefactor
+}
+
+// isTestFile 判断是否为测试文件
+func isTestFile(filePath, base string) bool {
+	for _, dir := range []string{"test/", "tests/", "__tests__/", "testdata/", "spec/"} {
+		if strings.HasPrefix(filePath, dir) || strings.Contains(filePath, "/"+dir) {
+			return true
+		}
+	}
+	name := strings.TrimSuffix(base, path.Ext(base))
+	return strings.HasSuffix(name, "_test") || strings.HasSuffix(name, ".test") || strings.HasSuffix(name, ".spec") ||
+		strings.HasPrefix(name, "test_") || (strings.HasSuffix(name, "test") && path.Ext(base) == ".java")
+}
+
+// diffLineCounts 统计差异中新增和删除的行数
+func diffLineCounts(diff string) (added, removed int) {
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// ClassifyChanges 推断所有文件改动的类型，已有类型的改动保持不变
+func ClassifyChanges(changes []types.FileChange) {
+	for i := range changes {
+		if changes[i].Kind == "" {
+			changes[i].Kind = ClassifyChange(changes[i])
+		}
+	}
+}
+
+// ModelConfirmable 判断改动类型是否可以由模型的判断覆盖
+// 按路径确定的类型（测试、文档、配置、依赖）不会被覆盖
+func ModelConfirmable(kind types.ChangeKind) bool {
+	switch kind {
+	case "", types.KindFeature, types.KindBugfix, types.KindRefactor:
+		return true
+	default:
+		return false
+	}
+}
+
+// KindCount 某种改动类型的文件数
+type KindCount struct {
+	Kind  types.ChangeKind
+	Files int
+}
+
+// CountKinds 按展示顺序统计各改动类型的文件数，省略没有文件的类型
+func CountKinds(changes []types.FileChange) []KindCount {
+	counts := make(map[types.ChangeKind]int)
+	for _, change := range changes {
+		kind := change.Kind
+		if kind == "" {
+			kind = ClassifyChange(change)
+		}
+		counts[kind]++
+	}
+	var result []KindCount
+	for _, kind := range types.ChangeKinds {
+		if counts[kind] > 0 {
+			result = append(result, KindCount{Kind: kind, Files: counts[kind]})
+		}
+	}
+	return result
+}
+
+// kindSummary 返回改动类型分布的文本，如 "功能 3, 测试 2"
+func (r *DefaultReporter) kindSummary() string {
+	parts := make([]string, 0, len(r.ChangeKinds))
+	for _, kc := range r.ChangeKinds {
+		parts = append(parts, fmt.Sprintf("%s %d", r.Lang.T("kind."+string(kc.Kind)), kc.Files))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeMarkdownKinds 写入Markdown格式的改动类型分布
+func (r *DefaultReporter) writeMarkdownKinds(buf *bytes.Buffer) {
+	t := r.Lang.T
+	total := 0
+	for _, kc := range r.ChangeKinds {
+		total += kc.Files
+	}
+	buf.WriteString(fmt.Sprintf("### %s\n\n", t("report.change_kinds")))
+	buf.WriteString(fmt.Sprintf("| %s | %s | %s |\n", t("report.kind_column"), t("report.files_column"), t("report.percent_column")))
+	buf.WriteString("|---------|---------|---------|\n")
+	for _, kc := range r.ChangeKinds {
+		buf.WriteString(fmt.Sprintf("| %s | %s | %s |\n", t("kind."+string(kc.Kind)), r.Lang.Number(kc.Files), r.Lang.Percent(percentOf(kc.Files, total))))
+	}
+	buf.WriteString("\n")
+}
+
+// writeHTMLKinds 写入HTML格式的改动类型分布，作为统计区域中的一张卡片
+func (r *DefaultReporter) writeHTMLKinds(buf *bytes.Buffer) {
+	total := 0
+	for _, kc := range r.ChangeKinds {
+		total += kc.Files
+	}
+	buf.WriteString(fmt.Sprintf(`
+	<div class="stat-card">
+		<h3>%s</h3>`, r.Lang.T("report.change_kinds")))
+	for _, kc := range r.ChangeKinds {
+		buf.WriteString(fmt.Sprintf(`
+		<p>%s: %s (%s)</p>`, html.EscapeString(r.Lang.T("kind."+string(kc.Kind))), r.Lang.Number(kc.Files), r.Lang.Percent(percentOf(kc.Files, total))))
+	}
+	buf.WriteString(`
+	</div>`)
+}
@@ -0,0 +1,93 @@
+package review
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// CodeQualityIssue GitLab Code Quality（Code Climate 子集）报告中的单个问题
+// 参见 https://docs.gitlab.com/ee/ci/testing/code_quality.html#implement-a-custom-tool
+type CodeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Categories  []string            `json:"categories,omitempty"`
+	Location    CodeQualityLocation `json:"location"`
+}
+
+// CodeQualityLocation 问题位置
+type CodeQualityLocation struct {
+	Path  string           `json:"path"`
+	Lines CodeQualityLines `json:"lines"`
+}
+
+// CodeQualityLines 问题所在行
+type CodeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+// codeQualitySeverity 将严重程度映射为 Code Quality 的级别：info, minor, major, critical, blocker
+func codeQualitySeverity(severity types.SeverityLevel) string {
+	switch severity {
+	case types.SeverityError:
+		return "critical"
+	case types.SeverityWarning:
+		return "major"
+	default:
+		return "minor"
+	}
+}
+
+// codeQualityCategory 将问题类别映射为 Code Climate 的类别
+func codeQualityCategory(category types.IssueCategory) string {
+	switch category {
+	case types.CategorySecurity:
+		return "Security"
+	case types.CategoryBug:
+		return "Bug Risk"
+	case types.CategoryPerformance:
+		return "Performance"
+	case types.CategoryMaintainability:
+		return "Clarity"
+	default:
+		return "Style"
+	}
+}
+
+// generateCodeQuality 生成 GitLab Code Quality 格式的报告
+// 使用问题的内容指纹，不包含行号，MR 中代码移动后同一问题不会被识别为新问题
+func (r *DefaultReporter) generateCodeQuality(issues []types.Issue) ([]byte, error) {
+	result := make([]CodeQualityIssue, 0, len(issues))
+	fingerprints := issueFingerprints(issues)
+	for i, issue := range issues {
+		description := strings.TrimSpace(issue.Title)
+		if desc := strings.TrimSpace(issue.Description); desc != "" {
+			description += ": " + desc
+		}
+		line := issue.Line
+		if line < 1 {
+			line = 1
+		}
+
+		result = append(result, CodeQualityIssue{
+			Description: description,
+			CheckName:   "ai-cr-tool/" + string(issue.Severity),
+			Fingerprint: fingerprints[i],
+			Severity:    codeQualitySeverity(issue.Severity),
+			Categories:  []string{codeQualityCategory(issueCategory(issue))},
+			Location: CodeQualityLocation{
+				Path:  issue.FilePath,
+				Lines: CodeQualityLines{Begin: line},
+			},
+		})
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
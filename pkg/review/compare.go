@@ -0,0 +1,196 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// RunRecord 保存的一次评审结果，用于与下一次评审对比
+type RunRecord struct {
+	At     time.Time     `json:"at"`
+	Files  []string      `json:"files"`
+	Issues []types.Issue `json:"issues"`
+}
+
+// Comparison 本次评审与上一次评审的对比
+type Comparison struct {
+	PreviousAt     time.Time
+	PreviousScore  int
+	Score          int
+	PreviousCounts map[types.SeverityLevel]int
+	Counts         map[types.SeverityLevel]int
+	// 以下统计只包含两次都评审过的文件
+	// 上次发现、本次重新评审后不再出现的问题
+	Resolved []types.Issue
+	// 本次新出现的问题
+	New []types.Issue
+	// 两次都出现的问题，使用本次的结果
+	Persisting []types.Issue
+}
+
+// ScoreDelta 返回质量分的变化
+func (c *Comparison) ScoreDelta() int {
+	return c.Score - c.PreviousScore
+}
+
+// Compare 对比两次评审结果
+// 只对比两次都评审过的文件，评审范围的增减不会被误判为问题的新增或解决
+func Compare(previous *RunRecord, files []string, issues []types.Issue) *Comparison {
+	reviewedBefore := make(map[string]bool, len(previous.Files))
+	for _, file := range previous.Files {
+		reviewedBefore[file] = true
+	}
+	common := make(map[string]bool, len(files))
+	for _, file := range files {
+		if reviewedBefore[file] {
+			common[file] = true
+		}
+	}
+
+	var before, after []types.Issue
+	for _, issue := range previous.Issues {
+		if common[issue.FilePath] {
+			before = append(before, issue)
+		}
+	}
+	for _, issue := range issues {
+		if common[issue.FilePath] {
+			after = append(after, issue)
+		}
+	}
+
+	current := make(map[string]bool, len(after))
+	for _, issue := range after {
+		current[issueKey(issue)] = true
+	}
+	earlier := make(map[string]bool, len(before))
+	for _, issue := range before {
+		earlier[issueKey(issue)] = true
+	}
+
+	c := &Comparison{
+		PreviousAt:     previous.At,
+		PreviousScore:  QualityScore(before),
+		Score:          QualityScore(after),
+		PreviousCounts: CountBySeverity(before),
+		Counts:         CountBySeverity(after),
+	}
+	for _, issue := range before {
+		if !current[issueKey(issue)] {
+			c.Resolved = append(c.Resolved, issue)
+		}
+	}
+	for _, issue := range after {
+		if earlier[issueKey(issue)] {
+			c.Persisting = append(c.Persisting, issue)
+		} else {
+			c.New = append(c.New, issue)
+		}
+	}
+	return c
+}
+
+// CompareReports 对比两份JSON报告，两份报告中出现过的文件都参与对比
+func CompareReports(previous, current *JSONReport) *Comparison {
+	before, after := previous.ToIssues(), current.ToIssues()
+	return Compare(&RunRecord{At: previous.GeneratedAt, Files: issueFiles(before, after), Issues: before},
+		issueFiles(before, after), after)
+}
+
+// CompareBaseline 以JSON报告为基线对比本次评审结果
+// 基线中只有本次评审过的文件参与对比，评审范围缩小时不会被误判为问题已解决
+func CompareBaseline(baseline *JSONReport, files []string, issues []types.Issue) *Comparison {
+	return Compare(&RunRecord{At: baseline.GeneratedAt, Files: files, Issues: baseline.ToIssues()}, files, issues)
+}
+
+// issueFiles 返回问题涉及的所有文件，按首次出现的顺序排列
+func issueFiles(lists ...[]types.Issue) []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, issues := range lists {
+		for _, issue := range issues {
+			if !seen[issue.FilePath] {
+				seen[issue.FilePath] = true
+				files = append(files, issue.FilePath)
+			}
+		}
+	}
+	return files
+}
+
+// Merge 用本次评审结果更新记录：本次评审过的文件使用新结果，其余文件保留上次的结果
+func (r *RunRecord) Merge(at time.Time, files []string, issues []types.Issue) *RunRecord {
+	reviewed := make(map[string]bool, len(files))
+	for _, file := range files {
+		reviewed[file] = true
+	}
+
+	merged := &RunRecord{At: at, Files: append([]string(nil), files...), Issues: append([]types.Issue(nil), issues...)}
+	if r == nil {
+		return merged
+	}
+	for _, file := range r.Files {
+		if !reviewed[file] {
+			merged.Files = append(merged.Files, file)
+		}
+	}
+	for _, issue := range r.Issues {
+		if !reviewed[issue.FilePath] {
+			merged.Issues = append(merged.Issues, issue)
+		}
+	}
+	return merged
+}
+
+// issueKey 用文件和标题标识问题，不包含行号，避免修复其他问题导致行号变化后误判
+func issueKey(issue types.Issue) string {
+	return path.Clean(issue.FilePath) + "\x00" + strings.ToLower(strings.TrimSpace(issue.Title))
+}
+
+// RunRecordPath 返回分支最近一次评审结果的保存路径
+func RunRecordPath(gitDir, branch string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(branch)
+	if name == "" {
+		name = "HEAD"
+	}
+	return filepath.Join(gitDir, "ai-cr-tool", "runs", name+".json")
+}
+
+// LoadRunRecord 读取保存的评审结果，文件不存在时返回 nil
+func LoadRunRecord(path string) (*RunRecord, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取上次评审结果失败: %v", err)
+	}
+	var record RunRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("解析上次评审结果失败: %v", err)
+	}
+	return &record, nil
+}
+
+// SaveRunRecord 保存本次评审结果
+func SaveRunRecord(path string, record *RunRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建评审结果目录失败: %v", err)
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("序列化评审结果失败: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("保存评审结果失败: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
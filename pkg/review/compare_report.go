@@ -0,0 +1,243 @@
+package review
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// comparisonRow 对比表格中的一行
+type comparisonRow struct {
+	label    string
+	previous int
+	current  int
+}
+
+// delta 返回带符号的变化值
+func (row comparisonRow) delta() string {
+	d := row.current - row.previous
+	if d > 0 {
+		return fmt.Sprintf("+%d", d)
+	}
+	return fmt.Sprint(d)
+}
+
+// comparisonRows 生成对比表格的各行：各严重程度的问题数、问题总数和质量分
+func (r *DefaultReporter) comparisonRows() []comparisonRow {
+	c := r.Comparison
+	var rows []comparisonRow
+	totalBefore, totalAfter := 0, 0
+	for _, severity := range []types.SeverityLevel{types.SeverityError, types.SeverityWarning, types.SeverityInfo} {
+		rows = append(rows, comparisonRow{string(severity), c.PreviousCounts[severity], c.Counts[severity]})
+		totalBefore += c.PreviousCounts[severity]
+		totalAfter += c.Counts[severity]
+	}
+	rows = append(rows,
+		comparisonRow{r.Lang.T("report.total_issues"), totalBefore, totalAfter},
+		comparisonRow{r.Lang.T("report.quality_score"), c.PreviousScore, c.Score},
+	)
+	return rows
+}
+
+// writeMarkdownComparison 写入Markdown格式的对比内容
+func (r *DefaultReporter) writeMarkdownComparison(buf *bytes.Buffer) {
+	t := r.Lang.T
+	c := r.Comparison
+
+	buf.WriteString(fmt.Sprintf("## %s\n\n", t("report.comparison")))
+	buf.WriteString(fmt.Sprintf("%s%s\n\n", t("report.previous_at"), c.PreviousAt.Format("2006-01-02 15:04:05")))
+	buf.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", t("report.metric"), t("report.previous"), t("report.current"), t("report.change")))
+	buf.WriteString("|------|------|------|------|\n")
+	for _, row := range r.comparisonRows() {
+		buf.WriteString(fmt.Sprintf("| %s | %d | %d | %s |\n", row.label, row.previous, row.current, row.delta()))
+	}
+	buf.WriteString("\n")
+
+	if len(c.Resolved) > 0 {
+		buf.WriteString(fmt.Sprintf("### %s\n\n", t("report.resolved")))
+		for _, issue := range c.Resolved {
+			buf.WriteString(fmt.Sprintf("- ~~%s~~ `%s` (%s)\n", issue.Title, issue.FilePath, issue.Severity))
+		}
+		buf.WriteString("\n")
+	}
+}
+
+// writeHTMLComparison 写入HTML格式的对比内容
+func (r *DefaultReporter) writeHTMLComparison(buf *bytes.Buffer) {
+	t := r.Lang.T
+	c := r.Comparison
+
+	buf.WriteString(fmt.Sprintf(`
+	<h2>%s</h2>
+	<div class="chart">
+		<p>%s%s</p>
+		<table>
+			<tr><th>%s</th><th>%s</th><th>%s</th><th>%s</th></tr>`,
+		t("report.comparison"), t("report.previous_at"), c.PreviousAt.Format("2006-01-02 15:04:05"),
+		t("report.metric"), t("report.previous"), t("report.current"), t("report.change")))
+	for _, row := range r.comparisonRows() {
+		buf.WriteString(fmt.Sprintf(`
+			<tr><td>%s</td><td>%d</td><td>%d</td><td>%s</td></tr>`, html.EscapeString(row.label), row.previous, row.current, row.delta()))
+	}
+	buf.WriteString(`
+		</table>`)
+
+	if len(c.Resolved) > 0 {
+		buf.WriteString(fmt.Sprintf(`
+		<h3>%s</h3>
+		<ul>`, t("report.resolved")))
+		for _, issue := range c.Resolved {
+			buf.WriteString(fmt.Sprintf(`
+			<li><del>%s</del> <code>%s</code> (%s)</li>`,
+				html.EscapeString(issue.Title), html.EscapeString(issue.FilePath), issue.Severity))
+		}
+		buf.WriteString(`
+		</ul>`)
+	}
+	buf.WriteString(`
+	</div>`)
+}
+
+// JSONComparisonReport 两次评审结果的对比报告，由 cr report compare 以 JSON 格式输出
+type JSONComparisonReport struct {
+	PreviousAt         time.Time      `json:"previous_at"`
+	PreviousScore      int            `json:"previous_score"`
+	Score              int            `json:"score"`
+	PreviousBySeverity map[string]int `json:"previous_by_severity"`
+	BySeverity         map[string]int `json:"by_severity"`
+	New                []JSONIssue    `json:"new"`
+	Resolved           []JSONIssue    `json:"resolved"`
+	Persisting         []JSONIssue    `json:"persisting"`
+}
+
+// GenerateComparison 生成只包含对比结果的报告，列出新增、已解决和仍存在的问题
+// 支持 markdown、json 和 terminal 格式
+func (r *DefaultReporter) GenerateComparison(format ReportFormat) ([]byte, error) {
+	if r.Comparison == nil {
+		return nil, fmt.Errorf("没有可用的对比结果")
+	}
+	switch format {
+	case MarkdownFormat:
+		return r.generateMarkdownComparisonReport(), nil
+	case JSONFormat:
+		return r.generateJSONComparisonReport()
+	case TerminalFormat:
+		return r.generateTerminalComparisonReport(), nil
+	default:
+		return nil, fmt.Errorf("对比报告只支持 markdown、json 和 terminal 格式: %s", format)
+	}
+}
+
+// generateMarkdownComparisonReport 生成Markdown格式的对比报告
+func (r *DefaultReporter) generateMarkdownComparisonReport() []byte {
+	t := r.Lang.T
+	c := r.Comparison
+	var buf bytes.Buffer
+
+	buf.WriteString(fmt.Sprintf("# %s\n\n", t("report.comparison")))
+	buf.WriteString(fmt.Sprintf("%s%s\n\n", t("report.previous_at"), c.PreviousAt.Format("2006-01-02 15:04:05")))
+	buf.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", t("report.metric"), t("report.previous"), t("report.current"), t("report.change")))
+	buf.WriteString("|------|------|------|------|\n")
+	for _, row := range r.comparisonRows() {
+		buf.WriteString(fmt.Sprintf("| %s | %d | %d | %s |\n", row.label, row.previous, row.current, row.delta()))
+	}
+	buf.WriteString("\n")
+
+	for _, section := range []struct {
+		key    string
+		issues []types.Issue
+		format string
+	}{
+		{"report.new_issues", c.New, "- **%s** `%s` (%s)\n"},
+		{"report.resolved", c.Resolved, "- ~~%s~~ `%s` (%s)\n"},
+		{"report.persisting", c.Persisting, "- %s `%s` (%s)\n"},
+	} {
+		buf.WriteString(fmt.Sprintf("## %s (%d)\n\n", t(section.key), len(section.issues)))
+		for _, issue := range section.issues {
+			buf.WriteString(fmt.Sprintf(section.format, issue.Title, issueLocation(issue), issue.Severity))
+		}
+		if len(section.issues) > 0 {
+			buf.WriteString("\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+// generateJSONComparisonReport 生成JSON格式的对比报告
+func (r *DefaultReporter) generateJSONComparisonReport() ([]byte, error) {
+	c := r.Comparison
+	report := JSONComparisonReport{
+		PreviousAt:         c.PreviousAt,
+		PreviousScore:      c.PreviousScore,
+		Score:              c.Score,
+		PreviousBySeverity: make(map[string]int),
+		BySeverity:         make(map[string]int),
+		New:                make([]JSONIssue, 0, len(c.New)),
+		Resolved:           make([]JSONIssue, 0, len(c.Resolved)),
+		Persisting:         make([]JSONIssue, 0, len(c.Persisting)),
+	}
+	for severity, count := range c.PreviousCounts {
+		report.PreviousBySeverity[string(severity)] = count
+	}
+	for severity, count := range c.Counts {
+		report.BySeverity[string(severity)] = count
+	}
+	for _, issue := range c.New {
+		report.New = append(report.New, newJSONIssue(issue))
+	}
+	for _, issue := range c.Resolved {
+		report.Resolved = append(report.Resolved, newJSONIssue(issue))
+	}
+	for _, issue := range c.Persisting {
+		report.Persisting = append(report.Persisting, newJSONIssue(issue))
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("生成JSON对比报告失败: %v", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// generateTerminalComparisonReport 生成终端格式的对比报告
+func (r *DefaultReporter) generateTerminalComparisonReport() []byte {
+	style := newTerminalStyle()
+	t := r.Lang.T
+	c := r.Comparison
+	var buf bytes.Buffer
+
+	color := ansiDim
+	switch {
+	case c.ScoreDelta() > 0:
+		color = ansiGreen
+	case c.ScoreDelta() < 0:
+		color = ansiRed
+	}
+	buf.WriteString(style.paint(ansiBold, t("report.comparison")) + style.paint(ansiDim, "  "+t("report.previous_at")+c.PreviousAt.Format("2006-01-02 15:04:05")) + "\n")
+	buf.WriteString(fmt.Sprintf("%s %d → %d %s  %s  %s  %s\n", t("report.quality_score"), c.PreviousScore, c.Score,
+		style.paint(color, fmt.Sprintf("(%+d)", c.ScoreDelta())),
+		t("report.new_short", len(c.New)), t("report.resolved_short", len(c.Resolved)), t("report.persisting_short", len(c.Persisting))))
+	buf.WriteString(style.paint(ansiDim, strings.Repeat("─", style.width)) + "\n")
+	for _, issue := range c.New {
+		buf.WriteString(style.paint(ansiRed, "  + ") + issue.Title + style.paint(ansiDim, "  "+issueLocation(issue)) + "\n")
+	}
+	for _, issue := range c.Resolved {
+		buf.WriteString(style.paint(ansiGreen, "  ✓ ") + issue.Title + style.paint(ansiDim, "  "+issueLocation(issue)) + "\n")
+	}
+	for _, issue := range c.Persisting {
+		buf.WriteString(style.paint(ansiDim, "  · "+issue.Title+"  "+issueLocation(issue)) + "\n")
+	}
+	return buf.Bytes()
+}
+
+// issueLocation 返回问题的位置，如 main.go:12
+func issueLocation(issue types.Issue) string {
+	if issue.Line > 0 {
+		return fmt.Sprintf("%s:%d", issue.FilePath, issue.Line)
+	}
+	return issue.FilePath
+}
@@ -0,0 +1,49 @@
+package review
+
+import (
+	"fmt"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// CommentStyle 评论中每个问题的标题样式
+type CommentStyle string
+
+const (
+	// DefaultStyle 严重程度图标加问题标题
+	DefaultStyle CommentStyle = "default"
+	// ConventionalStyle 按 Conventional Comments（https://conventionalcomments.org）约定，
+	// 以 "issue (blocking):"、"suggestion:"、"nitpick:" 等标签开头
+	ConventionalStyle CommentStyle = "conventional"
+)
+
+// ParseCommentStyle 解析评论样式，空字符串视为默认样式
+func ParseCommentStyle(s string) (CommentStyle, error) {
+	switch style := CommentStyle(s); style {
+	case "":
+		return DefaultStyle, nil
+	case DefaultStyle, ConventionalStyle:
+		return style, nil
+	}
+	return "", fmt.Errorf("不支持的评论样式: %s，可选值：default, conventional", s)
+}
+
+// ConventionalLabel 返回问题的 Conventional Comments 标签和修饰：
+// error 为阻塞合并的 issue；warning 级别的缺陷和安全问题为不阻塞的 issue，其余为 suggestion；info 为 nitpick
+func ConventionalLabel(issue types.Issue) string {
+	switch issue.Severity {
+	case types.SeverityError:
+		return "issue (blocking)"
+	case types.SeverityWarning:
+		if issue.Category == types.CategoryBug || issue.Category == types.CategorySecurity {
+			return "issue (non-blocking)"
+		}
+		return "suggestion"
+	}
+	return "nitpick"
+}
+
+// conventional 判断是否使用 Conventional Comments 样式
+func (r *DefaultReporter) conventional() bool {
+	return r.CommentStyle == ConventionalStyle
+}
@@ -0,0 +1,51 @@
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+
+	"github.com/icatw/ai-cr-tool/pkg/coverage"
+)
+
+// writeMarkdownCoverage 写入Markdown格式的变更行覆盖率
+func (r *DefaultReporter) writeMarkdownCoverage(buf *bytes.Buffer) {
+	t := r.Lang.T
+	buf.WriteString(fmt.Sprintf("## %s\n\n", t("report.coverage")))
+	buf.WriteString(fmt.Sprintf("- %s%d/%d (%.1f%%)\n\n", t("report.coverage_total"), r.Coverage.Covered, r.Coverage.Total, r.Coverage.Percent()))
+	if len(r.Coverage.Files) == 0 {
+		return
+	}
+
+	buf.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", t("report.file_column"), t("report.covered_lines"), t("report.coverage_percent"), t("report.uncovered_lines")))
+	buf.WriteString("|------|------|------|------|\n")
+	for _, fc := range r.Coverage.Files {
+		buf.WriteString(fmt.Sprintf("| `%s` | %d/%d | %.1f%% | %s |\n", fc.File, fc.Covered, fc.Total, fc.Percent(), coverage.FormatRanges(fc.Uncovered)))
+	}
+	buf.WriteString("\n")
+}
+
+// writeHTMLCoverage 写入HTML格式的变更行覆盖率
+func (r *DefaultReporter) writeHTMLCoverage(buf *bytes.Buffer) {
+	t := r.Lang.T
+	buf.WriteString(fmt.Sprintf(`
+	<h2>%s</h2>
+	<div class="chart">
+		<p>%s%d/%d (%.1f%%)</p>`,
+		t("report.coverage"), t("report.coverage_total"), r.Coverage.Covered, r.Coverage.Total, r.Coverage.Percent()))
+	if len(r.Coverage.Files) > 0 {
+		buf.WriteString(fmt.Sprintf(`
+		<table>
+			<tr><th>%s</th><th>%s</th><th>%s</th><th>%s</th></tr>`,
+			t("report.file_column"), t("report.covered_lines"), t("report.coverage_percent"), t("report.uncovered_lines")))
+		for _, fc := range r.Coverage.Files {
+			buf.WriteString(fmt.Sprintf(`
+			<tr><td><code>%s</code></td><td>%d/%d</td><td>%.1f%%</td><td>%s</td></tr>`,
+				html.EscapeString(fc.File), fc.Covered, fc.Total, fc.Percent(), coverage.FormatRanges(fc.Uncovered)))
+		}
+		buf.WriteString(`
+		</table>`)
+	}
+	buf.WriteString(`
+	</div>`)
+}
@@ -0,0 +1,194 @@
+package review
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/git"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// 被删除文件的评审方式
+const (
+	// DeletedFilesDiff 与其他文件一样只评审差异，默认方式
+	DeletedFilesDiff = "diff"
+	// DeletedFilesReferences 在仓库中搜索被删除文件中定义的符号，连同搜索结果使用专门的提示评审，检查悬空引用和有风险的删除
+	DeletedFilesReferences = "references"
+	// DeletedFilesSkip 不评审被删除的文件
+	DeletedFilesSkip = "skip"
+)
+
+const (
+	// maxDeletedSymbols 每个被删除文件最多搜索的符号数
+	maxDeletedSymbols = 30
+	// maxReferencesPerFile 每个引用文件中最多列出的行数
+	maxReferencesPerFile = 3
+	// maxDeletedReferences 每个被删除文件最多列出的引用数
+	maxDeletedReferences = 30
+	// maxReferenceText 引用行的最大字符数，超出部分截断
+	maxReferenceText = 160
+	// minSymbolLength 参与搜索的符号的最短长度，更短的名称误报太多
+	minSymbolLength = 4
+)
+
+// symbolPatterns 各语言中顶层定义的符号，只匹配行首的定义，不匹配 Go 的方法等过于常见的名称
+var symbolPatterns = []*regexp.Regexp{
+	// Go
+	regexp.MustCompile(`(?m)^func ([A-Za-z_]\w*)`),
+	regexp.MustCompile(`(?m)^(?:type|var|const) ([A-Za-z_]\w*)`),
+	// Python
+	regexp.MustCompile(`(?m)^(?:async\s+)?def ([A-Za-z_]\w*)`),
+	regexp.MustCompile(`(?m)^class ([A-Za-z_]\w*)`),
+	// JavaScript、TypeScript
+	regexp.MustCompile(`(?m)^export\s+(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?(?:async\s+)?(?:function\*?|class|const|let|var|interface|type|enum)\s+([A-Za-z_$][\w$]*)`),
+	// Java、Kotlin、C#、Scala、Swift
+	regexp.MustCompile(`(?m)^\s*(?:(?:public|protected|internal|private)\s+)?(?:(?:abstract|final|static|sealed|data|open|partial)\s+)*(?:class|interface|enum|record|object|struct|trait)\s+([A-Za-z_]\w*)`),
+	// Rust
+	regexp.MustCompile(`(?m)^pub(?:\([^)]*\))?\s+(?:async\s+)?(?:fn|struct|enum|trait|type|const|static|mod)\s+([A-Za-z_]\w*)`),
+}
+
+// genericStems 过于常见、不适合作为引用搜索的文件名
+var genericStems = map[string]bool{
+	"index": true, "main": true, "init": true, "__init__": true, "test": true, "tests": true,
+	"util": true, "utils": true, "types": true, "common": true, "helpers": true, "config": true,
+}
+
+// DeletedReference 被删除文件中的符号在仓库中的一处引用
+type DeletedReference struct {
+	// 被引用的符号或文件名
+	Symbol string
+	Path   string
+	Line   int
+	Text   string
+}
+
+// String 返回 路径:行号: 内容（引用 符号） 形式的描述
+func (r DeletedReference) String() string {
+	return fmt.Sprintf("%s:%d: %s（引用 %s）", r.Path, r.Line, r.Text, r.Symbol)
+}
+
+// DeletedSymbols 返回被删除文件中定义的顶层符号和文件名，用于在仓库中搜索残留的引用
+// 内容取自差异中被删除的行；按出现顺序返回，最多 maxDeletedSymbols 个
+func DeletedSymbols(change types.FileChange) []string {
+	var old strings.Builder
+	for _, hunk := range change.Hunks {
+		for _, line := range hunk.Lines {
+			if line.Kind == '-' {
+				old.WriteString(line.Content)
+				old.WriteByte('\n')
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	var symbols []string
+	add := func(name string) {
+		if len(name) >= minSymbolLength && !seen[name] && len(symbols) < maxDeletedSymbols {
+			seen[name] = true
+			symbols = append(symbols, name)
+		}
+	}
+	// 文件名用于匹配导入语句和配置中的路径，如 "./helper"、"helper.js"
+	base := path.Base(change.FilePath)
+	stem := strings.TrimSuffix(base, path.Ext(base))
+	if !genericStems[strings.ToLower(stem)] && !strings.HasPrefix(base, ".") {
+		add(stem)
+		add(base)
+	}
+	content := old.String()
+	for _, pattern := range symbolPatterns {
+		for _, m := range pattern.FindAllStringSubmatch(content, -1) {
+			add(m[1])
+		}
+	}
+	return symbols
+}
+
+// FindDeletedReferences 在 rev 版本的仓库中搜索每个被删除文件的符号，返回各被删除文件的引用，键为文件路径
+// rev 的含义与 GitClient.GrepWords 相同；没有找到引用的文件对应空列表，二进制文件不搜索
+func FindDeletedReferences(gitClient *git.GitClient, rev string, changes []types.FileChange) (map[string][]DeletedReference, error) {
+	deleted := make(map[string]bool)
+	for _, change := range changes {
+		if change.ChangeType == "deleted" {
+			deleted[change.FilePath] = true
+		}
+	}
+
+	result := make(map[string][]DeletedReference)
+	for _, change := range changes {
+		if change.ChangeType != "deleted" || change.Binary {
+			continue
+		}
+		symbols := DeletedSymbols(change)
+		refs := []DeletedReference{}
+		if len(symbols) > 0 {
+			matches, err := gitClient.GrepWords(rev, symbols, maxReferencesPerFile)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range matches {
+				// 同时删除的文件中的引用不会残留
+				if deleted[m.Path] {
+					continue
+				}
+				symbol := matchedSymbol(m.Text, symbols)
+				if symbol == "" {
+					continue
+				}
+				text := strings.TrimSpace(m.Text)
+				if runes := []rune(text); len(runes) > maxReferenceText {
+					text = string(runes[:maxReferenceText-1]) + "…"
+				}
+				refs = append(refs, DeletedReference{Symbol: symbol, Path: m.Path, Line: m.Line, Text: text})
+			}
+			sort.SliceStable(refs, func(i, j int) bool {
+				if refs[i].Path != refs[j].Path {
+					return refs[i].Path < refs[j].Path
+				}
+				return refs[i].Line < refs[j].Line
+			})
+		}
+		result[change.FilePath] = refs
+	}
+	return result, nil
+}
+
+// DeletedReferenceSummaries 把引用转换为评审提示中的说明，超过 maxDeletedReferences 的部分只给出数量
+func DeletedReferenceSummaries(refs []DeletedReference) []string {
+	summaries := make([]string, 0, len(refs))
+	for i, ref := range refs {
+		if i == maxDeletedReferences {
+			summaries = append(summaries, fmt.Sprintf("……另有 %d 处引用未列出", len(refs)-i))
+			break
+		}
+		summaries = append(summaries, ref.String())
+	}
+	return summaries
+}
+
+// matchedSymbol 返回行中以完整单词出现的第一个符号，git grep 的 -w 与此一致
+func matchedSymbol(text string, symbols []string) string {
+	for _, symbol := range symbols {
+		for start := 0; start < len(text); {
+			i := strings.Index(text[start:], symbol)
+			if i < 0 {
+				break
+			}
+			i += start
+			end := i + len(symbol)
+			if (i == 0 || !isWordChar(text[i-1])) && (end == len(text) || !isWordChar(text[end])) {
+				return symbol
+			}
+			start = i + 1
+		}
+	}
+	return ""
+}
+
+// isWordChar 判断字节是否为单词字符，与 git grep -w 相同
+func isWordChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
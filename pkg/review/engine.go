@@ -0,0 +1,438 @@
+package review
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/cache"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/model"
+	"github.com/icatw/ai-cr-tool/pkg/tracing"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// DefaultConcurrency 默认并发评审的文件数
+const DefaultConcurrency = 4
+
+// errDeadline 评审时限将到，不再发起新的模型调用
+var errDeadline = errors.New("已达到评审时限")
+
+// EngineOptions 评审引擎配置
+type EngineOptions struct {
+	// 当前使用的模型配置
+	ModelConfig *model.Config
+	// 评审提示模板，为空时使用默认模板
+	Prompt *model.ReviewPrompt
+	// 评审结果缓存，为空时不使用缓存
+	Cache *cache.ReviewCache
+	// 缓存有效期
+	CacheTTL time.Duration
+	// 同时评审的文件数
+	Concurrency int
+	// 评审结果中单个代码块保留的最大行数，为0时使用默认值
+	MaxQuoteLines int
+	// 仓库中跟踪的文件，评审结果中提到的文件路径既不在改动中也不在其中时替换为占位文字；为空时只认可改动中的文件
+	RepoFiles []string
+	// 进度事件通道，为空时不上报进度；通道由调用方创建和关闭
+	Progress chan<- ProgressInfo
+	// 事件回调，为空时不上报；与 Progress 可以同时使用
+	Events EngineEvents
+	// 评审断点，为空时不记录断点
+	Checkpoint *Checkpoint
+	// 外部调度器，服务模式下用于在多个仓库之间公平分配模型调用
+	Limiter Limiter
+	// 输出每次模型调用的 token 用量和提示缓存命中情况
+	Verbose bool
+	// 评审截止时间，剩余时间不足一次模型调用的平均耗时时不再发起新的调用，
+	// 已发出的调用会等待完成；零值表示不限时
+	Deadline time.Time
+	// 链路中的上级 span，每个文件的评审和每次模型调用记录为其下的 span；为 nil 时不记录
+	Span *tracing.Span
+	// 归纳问题主题使用的模型客户端及其配置，通常是更便宜的模型；为 nil 时使用评审模型
+	ThemeClient model.ModelClient
+	ThemeModel  *model.Config
+}
+
+// Limiter 限制模型调用并发的调度器，Acquire 阻塞直到获得名额并返回释放函数
+type Limiter interface {
+	Acquire() func()
+}
+
+// Engine 评审引擎，负责调度各个文件的AI评审
+type Engine struct {
+	client model.ModelClient
+	opts   EngineOptions
+	// 评审提示模板的指纹，模板变化后旧的缓存结果不再命中
+	promptFingerprint string
+
+	// 进度统计
+	mu        sync.Mutex
+	completed int
+	total     int
+
+	// 模型调用的 token 用量，以及用于估计单次调用耗时的累计耗时和次数
+	usageMu  sync.Mutex
+	usage    model.Usage
+	callTime time.Duration
+	calls    int
+
+	// 因达到评审时限而未评审的文件
+	skipped []string
+	// 重试之后仍评审失败的文件
+	failed []FailedFile
+	// 各文件的缓存命中情况，未使用缓存时为空
+	cacheStatuses []CacheStatus
+}
+
+// fileResult 单个文件的评审结果
+type fileResult struct {
+	issues  []types.Issue
+	err     error
+	skipped bool
+	// 模型判断的改动类型，未给出时为空
+	kind types.ChangeKind
+	// 缓存命中情况，未查询缓存时为 nil
+	cache *CacheStatus
+}
+
+// NewEngine 创建新的评审引擎
+func NewEngine(client model.ModelClient, opts EngineOptions) *Engine {
+	if opts.Prompt == nil {
+		opts.Prompt = model.DefaultReviewPrompt()
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.CacheTTL == 0 {
+		opts.CacheTTL = 24 * time.Hour
+	}
+	return &Engine{client: client, opts: opts, promptFingerprint: opts.Prompt.Fingerprint()}
+}
+
+// Review 并发评审所有文件改动，返回的问题列表与输入文件顺序一致
+// 模型对源代码文件改动类型的判断会写回 changes 中对应元素的 Kind
+func (e *Engine) Review(changes []types.FileChange) []types.Issue {
+	results := make([]fileResult, len(changes))
+	e.completed, e.total = 0, len(changes)
+
+	workers := e.opts.Concurrency
+	if workers > len(changes) {
+		workers = len(changes)
+	}
+
+	// 每个文件的问题在评审结束时即过滤，事件回调拿到的问题与最终结果一致
+	filter := NewSafetyFilter(changes, e.opts.RepoFiles, e.opts.Prompt.Language, e.opts.MaxQuoteLines)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// 断点中已完成的文件直接复用结果
+				if cp := e.opts.Checkpoint; cp != nil {
+					if issues, ok := cp.Lookup(changes[i].FilePath); ok {
+						results[i] = fileResult{issues: filter.FilterIssues(issues)}
+						e.emitIssues(changes[i].FilePath, results[i].issues)
+						e.report(changes[i].FilePath, StatusResumed, 0, nil)
+						continue
+					}
+				}
+
+				start := time.Now()
+				e.report(changes[i].FilePath, StatusReviewing, 0, nil)
+
+				span := e.opts.Span.Start("review file")
+				span.SetAttr("code.filepath", changes[i].FilePath)
+				issues, kind, cacheStatus, err := e.reviewFile(changes[i], span)
+				if errors.Is(err, errDeadline) {
+					span.SetAttr("cr.skipped", true)
+					span.End(nil)
+					results[i] = fileResult{skipped: true}
+					e.report(changes[i].FilePath, StatusSkipped, 0, nil)
+					continue
+				}
+				if err == nil && e.opts.Checkpoint != nil {
+					if err := e.opts.Checkpoint.Record(changes[i].FilePath, issues); err != nil {
+						log.Println(i18n.M("engine.checkpoint_failed", err))
+					}
+				}
+				if err == nil {
+					issues = filter.FilterIssues(issues)
+					e.emitIssues(changes[i].FilePath, issues)
+				}
+				span.SetAttr("cr.issues", len(issues))
+				span.End(err)
+				results[i] = fileResult{issues: issues, err: err, kind: kind, cache: cacheStatus}
+
+				status := StatusDone
+				switch {
+				case err != nil:
+					status = StatusFailed
+				case cacheStatus != nil && cacheStatus.Hit:
+					status = StatusCached
+				}
+				e.report(changes[i].FilePath, status, time.Since(start), err)
+			}
+		}()
+	}
+
+	for i := range changes {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// 按输入顺序汇总结果
+	var issues []types.Issue
+	e.skipped, e.failed, e.cacheStatuses = nil, nil, nil
+	for i, result := range results {
+		if result.skipped {
+			e.skipped = append(e.skipped, changes[i].FilePath)
+			continue
+		}
+		if result.cache != nil {
+			e.cacheStatuses = append(e.cacheStatuses, *result.cache)
+		}
+		if result.err != nil {
+			log.Println(i18n.M("engine.review_failed", changes[i].FilePath, result.err))
+			e.failed = append(e.failed, e.failedFile(changes[i].FilePath, result.err))
+			continue
+		}
+		if result.kind != "" && ModelConfirmable(changes[i].Kind) {
+			changes[i].Kind = result.kind
+		}
+		issues = append(issues, result.issues...)
+	}
+	return issues
+}
+
+// report 发送进度事件，并调用对应的事件回调
+func (e *Engine) report(filePath string, status FileStatus, elapsed time.Duration, err error) {
+	if e.opts.Progress == nil && e.opts.Events == nil {
+		return
+	}
+
+	e.mu.Lock()
+	if status.IsFinished() {
+		e.completed++
+	}
+	info := ProgressInfo{
+		FilePath:  filePath,
+		Status:    status,
+		Completed: e.completed,
+		Total:     e.total,
+		Elapsed:   elapsed,
+		Err:       err,
+	}
+	// 在锁内发送，保证事件中的完成数单调递增
+	if e.opts.Progress != nil {
+		e.opts.Progress <- info
+	}
+	if events := e.opts.Events; events != nil {
+		if status.IsFinished() {
+			events.OnFileDone(info)
+		} else {
+			events.OnFileStart(info)
+		}
+	}
+	e.mu.Unlock()
+}
+
+// emitIssues 逐个上报文件评审得到的问题
+func (e *Engine) emitIssues(filePath string, issues []types.Issue) {
+	if e.opts.Events == nil {
+		return
+	}
+	for _, issue := range issues {
+		e.opts.Events.OnIssue(filePath, issue)
+	}
+}
+
+// reviewFile 评审单个文件改动，返回问题、模型判断的改动类型，以及缓存命中情况（未使用缓存时为 nil）
+// span 为该文件的评审在链路中的 span，缓存查询和模型调用记录为其子 span
+func (e *Engine) reviewFile(change types.FileChange, span *tracing.Span) ([]types.Issue, types.ChangeKind, *CacheStatus, error) {
+	// 检查缓存
+	key := e.cacheKey(change)
+	var cacheStatus *CacheStatus
+	if e.opts.Cache != nil {
+		cacheStatus = &CacheStatus{FilePath: change.FilePath}
+		lookup := span.Start("cache lookup")
+		cached, err := e.opts.Cache.GetWithPrompt(key, e.promptFingerprint)
+		lookup.SetAttr("cr.cache_hit", err == nil && cached != nil)
+		lookup.End(nil)
+		if err == nil && cached != nil {
+			cacheStatus.Hit, cacheStatus.CachedAt, cacheStatus.Model = true, cached.CachedAt, cached.Model
+			if e.opts.Verbose {
+				log.Println(i18n.M("engine.cache_hit", change.FilePath,
+					cached.CachedAt.Format("2006-01-02 15:04:05"), time.Since(cached.CachedAt).Round(time.Second), cacheStatus.ModelName()))
+			}
+			return buildIssues(change, cached.ReviewResult, "缓存的评审结果"), ParseChangeKind(cached.ReviewResult), cacheStatus, nil
+		}
+		if e.opts.Verbose {
+			log.Println(i18n.M("engine.cache_miss", change.FilePath))
+		}
+	}
+
+	// 生成评审提示
+	messages := e.opts.Prompt.GeneratePrompt(change.FilePath, change.ChangeType, change.DiffContent)
+
+	// 调用AI进行评审，记录重试次数用于报告中的失败说明
+	retries := 0
+	onRetry := e.retryHook(change.FilePath)
+	req := &model.ChatRequest{Messages: messages, OnRetry: func(attempt int, err error) {
+		retries++
+		if onRetry != nil {
+			onRetry(attempt, err)
+		}
+	}}
+	if cfg := e.opts.ModelConfig; cfg != nil {
+		req.Model = cfg.Model
+		req.MaxTokens = cfg.MaxTokens
+		req.Temperature = cfg.Temperature
+	}
+
+	if e.opts.Limiter != nil {
+		release := e.opts.Limiter.Acquire()
+		defer release()
+	}
+	// 等待调度名额之后再检查时限，排队期间时间可能已经用完
+	if e.nearDeadline() {
+		return nil, "", cacheStatus, errDeadline
+	}
+	start := time.Now()
+	resp, err := e.callModel(span, req)
+	if err != nil {
+		return nil, "", cacheStatus, &callError{err: err, retries: retries}
+	}
+	e.addUsage(change.FilePath, resp.Usage, time.Since(start))
+	if e.opts.Verbose {
+		log.Println(i18n.M("engine.usage", change.FilePath,
+			resp.Usage.PromptTokens, resp.Usage.CachedTokens(), resp.Usage.CompletionTokens, time.Since(start).Round(time.Millisecond)))
+	}
+	if len(resp.Choices) == 0 {
+		return nil, "", cacheStatus, &callError{err: errEmptyResponse, retries: retries}
+	}
+	content := resp.Choices[0].Message.Content
+
+	// 缓存评审结果
+	if e.opts.Cache != nil {
+		expireAfter := e.opts.CacheTTL
+		if err := e.opts.Cache.SetWithModel(key, content, &expireAfter, e.promptFingerprint, e.ModelName()); err != nil {
+			log.Println(i18n.M("engine.cache_write_failed", err))
+		}
+	}
+
+	return buildIssues(change, content, "AI代码评审结果"), ParseChangeKind(content), cacheStatus, nil
+}
+
+// callModel 调用模型并在链路中记录为 parent 的子 span，属性按 OpenTelemetry 的生成式 AI 语义约定命名
+func (e *Engine) callModel(parent *tracing.Span, req *model.ChatRequest) (*model.ChatResponse, error) {
+	return e.callModelWith(e.client, e.opts.ModelConfig, parent, req)
+}
+
+// callModelWith 使用指定的模型客户端发起调用，cfg 用于在 span 中标注模型提供方
+func (e *Engine) callModelWith(client model.ModelClient, cfg *model.Config, parent *tracing.Span, req *model.ChatRequest) (*model.ChatResponse, error) {
+	span := parent.StartKind("chat "+req.Model, tracing.KindClient)
+	span.SetAttr("gen_ai.operation.name", "chat")
+	span.SetAttr("gen_ai.request.model", req.Model)
+	if cfg != nil {
+		span.SetAttr("gen_ai.system", cfg.Type)
+	}
+	resp, err := client.Chat(req)
+	if err == nil {
+		span.SetAttr("gen_ai.response.model", resp.Model)
+		span.SetAttr("gen_ai.usage.input_tokens", resp.Usage.PromptTokens)
+		span.SetAttr("gen_ai.usage.output_tokens", resp.Usage.CompletionTokens)
+		span.SetAttr("cr.cached_tokens", resp.Usage.CachedTokens())
+	}
+	span.End(err)
+	return resp, err
+}
+
+// addUsage 累计模型调用的 token 用量和耗时，并上报用量事件；filePath 为空表示不属于单个文件的调用
+func (e *Engine) addUsage(filePath string, usage model.Usage, elapsed time.Duration) {
+	e.usageMu.Lock()
+	e.usage.Add(usage)
+	e.callTime += elapsed
+	e.calls++
+	e.usageMu.Unlock()
+	if e.opts.Events != nil {
+		e.opts.Events.OnUsage(filePath, usage, elapsed)
+	}
+}
+
+// nearDeadline 判断评审时限是否将到：剩余时间不足一次模型调用的平均耗时
+// 还没有完成过调用时，只在时限已过时返回 true
+func (e *Engine) nearDeadline() bool {
+	if e.opts.Deadline.IsZero() {
+		return false
+	}
+	e.usageMu.Lock()
+	var average time.Duration
+	if e.calls > 0 {
+		average = e.callTime / time.Duration(e.calls)
+	}
+	e.usageMu.Unlock()
+	return time.Until(e.opts.Deadline) <= average
+}
+
+// Skipped 返回上次 Review 中因达到评审时限而未评审的文件，顺序与输入一致
+func (e *Engine) Skipped() []string {
+	return e.skipped
+}
+
+// Usage 返回本引擎累计的 token 用量，命中缓存的文件不计入
+func (e *Engine) Usage() model.Usage {
+	e.usageMu.Lock()
+	defer e.usageMu.Unlock()
+	return e.usage
+}
+
+// ModelName 返回评审使用的模型名称，未配置模型时为空
+func (e *Engine) ModelName() string {
+	if e.opts.ModelConfig == nil {
+		return ""
+	}
+	return e.opts.ModelConfig.Model
+}
+
+// cacheKey 生成缓存键，评审语言、测试结果、用到的术语、提交历史、接口变更、残留引用和仓库概览不同时分开缓存
+func (e *Engine) cacheKey(change types.FileChange) string {
+	key := change.DiffContent
+	if p := e.opts.Prompt; p != nil {
+		if p.Language != "" {
+			key += "\x00lang=" + string(p.Language)
+		}
+		if p.TestResults != "" {
+			key += "\x00tests=" + p.TestResults
+		}
+		if glossary := p.GlossaryFor(change.FilePath, change.DiffContent); glossary != "" {
+			key += "\x00glossary=" + glossary
+		}
+		if history := p.HistoryFor(change.FilePath); history != "" {
+			key += "\x00history=" + history
+		}
+		if apiSpec := p.APISpecFor(change.FilePath); apiSpec != "" {
+			key += "\x00apispec=" + apiSpec
+		}
+		if deletion := p.DeletionFor(change.FilePath); deletion != "" {
+			key += "\x00deleted=" + deletion
+		}
+		if p.Overview != "" {
+			key += "\x00overview=" + p.Overview
+		}
+	}
+	return key
+}
+
+// buildIssues 解析模型输出，并检查结果是否受到差异中嵌入指令的影响
+func buildIssues(change types.FileChange, content, fallbackTitle string) []types.Issue {
+	issues := ParseIssues(change.FilePath, content, fallbackTitle)
+	if hit, evidence := DetectInjectionCompliance(change.DiffContent, content); hit {
+		issues = append(issues, injectionWarning(change.FilePath, evidence))
+	}
+	return issues
+}
@@ -0,0 +1,43 @@
+package review
+
+import (
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/model"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// EngineEvents 评审引擎的事件回调，嵌入方（TUI、服务端面板、编辑器插件）可以据此实现自己的进度界面
+// 回调在评审协程中同步调用，不同文件的事件可能并发到达，实现需要并发安全并尽快返回；
+// 只关心部分事件时可以嵌入 NopEvents
+type EngineEvents interface {
+	// OnFileStart 文件开始评审，info.Status 为 StatusReviewing
+	OnFileStart(info ProgressInfo)
+	// OnIssue 文件评审得到一个问题，已经过安全过滤；来自缓存和断点的问题同样上报
+	OnIssue(filePath string, issue types.Issue)
+	// OnFileDone 文件评审结束，info.Status 为结束状态之一
+	OnFileDone(info ProgressInfo)
+	// OnRetry 模型调用失败后即将重试，attempt 为第几次重试；filePath 为空表示不属于单个文件的调用，如依赖评估和执行摘要
+	OnRetry(filePath string, attempt int, err error)
+	// OnUsage 一次模型调用完成，usage 为本次调用的 token 用量；filePath 为空的含义同 OnRetry
+	OnUsage(filePath string, usage model.Usage, elapsed time.Duration)
+}
+
+// NopEvents 不做任何处理的事件回调，嵌入后只需实现关心的方法
+type NopEvents struct{}
+
+func (NopEvents) OnFileStart(ProgressInfo)                   {}
+func (NopEvents) OnIssue(string, types.Issue)                {}
+func (NopEvents) OnFileDone(ProgressInfo)                    {}
+func (NopEvents) OnRetry(string, int, error)                 {}
+func (NopEvents) OnUsage(string, model.Usage, time.Duration) {}
+
+// retryHook 返回上报重试事件的回调，未设置事件回调时返回 nil
+func (e *Engine) retryHook(filePath string) func(int, error) {
+	if e.opts.Events == nil {
+		return nil
+	}
+	return func(attempt int, err error) {
+		e.opts.Events.OnRetry(filePath, attempt, err)
+	}
+}
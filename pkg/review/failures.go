@@ -0,0 +1,172 @@
+package review
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/model"
+)
+
+// maxFailureMessage 报告中单个失败原因保留的最大字符数，模型服务返回的错误内容可能很长
+const maxFailureMessage = 300
+
+// ErrorClass 模型调用失败的错误类型
+type ErrorClass string
+
+const (
+	// ErrorRateLimit 请求被限流（HTTP 429）
+	ErrorRateLimit ErrorClass = "rate_limit"
+	// ErrorAuth 认证或授权失败（HTTP 401/403）
+	ErrorAuth ErrorClass = "auth"
+	// ErrorClient 其他请求错误，如超出上下文长度（HTTP 4xx）
+	ErrorClient ErrorClass = "client_error"
+	// ErrorServer 模型服务内部错误（HTTP 5xx）
+	ErrorServer ErrorClass = "server_error"
+	// ErrorTimeout 请求超时
+	ErrorTimeout ErrorClass = "timeout"
+	// ErrorNetwork 连接失败等网络错误
+	ErrorNetwork ErrorClass = "network"
+	// ErrorInvalidResponse 响应无法解析或没有内容
+	ErrorInvalidResponse ErrorClass = "invalid_response"
+	// ErrorUnknown 无法归类的错误
+	ErrorUnknown ErrorClass = "unknown"
+)
+
+// errEmptyResponse 模型的响应中没有评审结果
+var errEmptyResponse = errors.New("模型未返回评审结果")
+
+// FailedFile 重试之后仍评审失败的文件，报告中单独列出，使"没有问题"和"因错误未评审"可以区分
+type FailedFile struct {
+	FilePath string
+	// 失败的模型提供方，使用模型池时为池中提供方的名称
+	Provider string
+	// 模型服务返回的 HTTP 状态码，没有收到响应时为0
+	StatusCode int
+	Class      ErrorClass
+	// 失败前的重试次数
+	Retries int
+	Message string
+}
+
+// callError 模型调用失败的错误，附带调用期间的重试次数
+type callError struct {
+	err     error
+	retries int
+}
+
+func (e *callError) Error() string {
+	return e.err.Error()
+}
+
+func (e *callError) Unwrap() error {
+	return e.err
+}
+
+// ClassifyError 判断模型调用失败的错误类型
+func ClassifyError(err error) ErrorClass {
+	var apiErr *model.APIError
+	if errors.As(err, &apiErr) {
+		switch code := apiErr.StatusCode; {
+		case code == 429:
+			return ErrorRateLimit
+		case code == 401 || code == 403:
+			return ErrorAuth
+		case code >= 500:
+			return ErrorServer
+		case code >= 400:
+			return ErrorClient
+		}
+		return ErrorUnknown
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorTimeout
+		}
+		return ErrorNetwork
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.Is(err, errEmptyResponse) || errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return ErrorInvalidResponse
+	}
+	return ErrorUnknown
+}
+
+// failedFile 根据评审错误生成失败记录
+func (e *Engine) failedFile(filePath string, err error) FailedFile {
+	f := FailedFile{FilePath: filePath, Class: ClassifyError(err), Message: err.Error()}
+	if cfg := e.opts.ModelConfig; cfg != nil {
+		f.Provider = cfg.Type
+	}
+	var providerErr *model.ProviderError
+	if errors.As(err, &providerErr) {
+		f.Provider = providerErr.Provider
+	}
+	var apiErr *model.APIError
+	if errors.As(err, &apiErr) {
+		f.StatusCode = apiErr.StatusCode
+	}
+	var ce *callError
+	if errors.As(err, &ce) {
+		f.Retries = ce.retries
+	}
+	if runes := []rune(f.Message); len(runes) > maxFailureMessage {
+		f.Message = string(runes[:maxFailureMessage]) + "…"
+	}
+	return f
+}
+
+// Failed 返回上一次 Review 中评审失败的文件，按输入顺序排列
+func (e *Engine) Failed() []FailedFile {
+	return e.failed
+}
+
+// failuresNotice 返回评审失败的说明文本
+func (r *DefaultReporter) failuresNotice() string {
+	return r.Lang.T("report.failed_files", len(r.Failures))
+}
+
+// failureDetail 返回单个失败文件的提供方、状态码、错误类型和重试次数
+func (r *DefaultReporter) failureDetail(f FailedFile) string {
+	var parts []string
+	if f.Provider != "" {
+		parts = append(parts, f.Provider)
+	}
+	if f.StatusCode != 0 {
+		parts = append(parts, fmt.Sprintf("HTTP %d", f.StatusCode))
+	}
+	parts = append(parts, string(f.Class), r.Lang.T("report.retries", f.Retries))
+	return strings.Join(parts, " · ")
+}
+
+// writeMarkdownFailures 写入Markdown格式的评审失败说明
+func (r *DefaultReporter) writeMarkdownFailures(buf *bytes.Buffer) {
+	buf.WriteString(fmt.Sprintf("> ❌ **%s**\n>\n", r.failuresNotice()))
+	for _, f := range r.Failures {
+		buf.WriteString(fmt.Sprintf("> - `%s` · %s\n", f.FilePath, r.failureDetail(f)))
+	}
+	buf.WriteString("\n")
+}
+
+// writeHTMLFailures 写入HTML格式的评审失败说明
+func (r *DefaultReporter) writeHTMLFailures(buf *bytes.Buffer) {
+	buf.WriteString(fmt.Sprintf(`
+	<div class="notice">
+		<strong>❌ %s</strong>
+		<ul>`, html.EscapeString(r.failuresNotice())))
+	for _, f := range r.Failures {
+		buf.WriteString(fmt.Sprintf(`
+			<li><code>%s</code>: %s <span title="%s">ⓘ</span></li>`,
+			html.EscapeString(f.FilePath), html.EscapeString(r.failureDetail(f)), html.EscapeString(f.Message)))
+	}
+	buf.WriteString(`
+		</ul>
+	</div>`)
+}
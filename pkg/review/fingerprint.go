@@ -0,0 +1,141 @@
+package review
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// fingerprintLength 指纹的十六进制字符数
+const fingerprintLength = 16
+
+// AssignFingerprints 为每个问题计算基于内容的稳定指纹，写入 Fingerprint 字段
+// 指纹由文件路径、归一化的描述和问题所在改动块中增删的行计算，不包含行号，
+// 代码移动或其他问题被修复后同一问题的指纹保持不变；同一次评审中内容相同的问题按出现顺序区分
+func AssignFingerprints(issues []types.Issue, changes []types.FileChange) {
+	hunks := make(map[string][]Hunk, len(changes))
+	for _, change := range changes {
+		_, fileHunks := SplitHunks(change)
+		hunks[path.Clean(change.FilePath)] = fileHunks
+	}
+
+	seen := make(map[string]int)
+	for i := range issues {
+		file := path.Clean(issues[i].FilePath)
+		issues[i].Fingerprint = distinctFingerprint(Fingerprint(issues[i], hunkAt(hunks[file], issues[i].Line)), seen)
+	}
+}
+
+// issueFingerprints 返回各问题互不相同的指纹，未计算过指纹的问题只按文件和描述计算
+func issueFingerprints(issues []types.Issue) []string {
+	result := make([]string, 0, len(issues))
+	seen := make(map[string]int)
+	for _, issue := range issues {
+		result = append(result, distinctFingerprint(issueFingerprint(issue), seen))
+	}
+	return result
+}
+
+// distinctFingerprint 记录指纹的出现次数，重复出现时以序号重新计算，保证同一次评审中指纹唯一
+func distinctFingerprint(fp string, seen map[string]int) string {
+	seen[fp]++
+	if n := seen[fp]; n > 1 {
+		return hashFingerprint(fmt.Sprintf("%s\x00%d", fp, n))
+	}
+	return fp
+}
+
+// Fingerprint 根据文件路径、归一化的描述和改动块内容计算问题的指纹，hunk 为 nil 时只使用前两者
+func Fingerprint(issue types.Issue, hunk *Hunk) string {
+	var key strings.Builder
+	key.WriteString(path.Clean(issue.FilePath))
+	key.WriteString("\x00")
+	description := issue.Description
+	if strings.TrimSpace(description) == "" {
+		description = issue.Title
+	}
+	key.WriteString(normalizeText(description))
+	if hunk != nil {
+		key.WriteString("\x00")
+		key.WriteString(normalizeHunk(hunk.Body))
+	}
+	return hashFingerprint(key.String())
+}
+
+// issueFingerprint 返回问题的指纹，未计算过时只按文件和描述计算
+func issueFingerprint(issue types.Issue) string {
+	if issue.Fingerprint != "" {
+		return issue.Fingerprint
+	}
+	return Fingerprint(issue, nil)
+}
+
+// hashFingerprint 返回内容的 SHA-256 前缀
+func hashFingerprint(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])[:fingerprintLength]
+}
+
+// hunkAt 返回新文件中包含指定行的改动块，找不到时返回 nil
+func hunkAt(hunks []Hunk, line int) *Hunk {
+	if line <= 0 {
+		return nil
+	}
+	for i := range hunks {
+		start, count := hunks[i].NewRange()
+		if line >= start && line < start+max(count, 1) {
+			return &hunks[i]
+		}
+	}
+	return nil
+}
+
+// normalizeText 归一化描述文本：转为小写，数字（如描述中提到的行号）替换为 #，合并空白和标点
+func normalizeText(text string) string {
+	var b strings.Builder
+	pendingSpace := false
+	lastDigit := false
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsDigit(r):
+			if !lastDigit {
+				if pendingSpace && b.Len() > 0 {
+					b.WriteByte(' ')
+				}
+				b.WriteByte('#')
+			}
+			pendingSpace, lastDigit = false, true
+			continue
+		case unicode.IsSpace(r) || unicode.IsPunct(r):
+			pendingSpace = true
+		default:
+			if pendingSpace && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			pendingSpace = false
+		}
+		lastDigit = false
+	}
+	return b.String()
+}
+
+// normalizeHunk 只保留改动块中增删的行，并去掉首尾空白，缩进和上下文的变化不影响指纹；
+// 行尾的 \r 一并去掉，同一改动在 Windows（CRLF）和 Linux（LF）上的指纹相同
+func normalizeHunk(body string) string {
+	var lines []string
+	for _, line := range strings.Split(body, "\n") {
+		if !strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "-") {
+			continue
+		}
+		if text := strings.TrimSpace(line[1:]); text != "" {
+			lines = append(lines, line[:1]+text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
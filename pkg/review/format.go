@@ -11,9 +11,16 @@ func (f Format) String() string {
 // IsValid 检查格式是否有效
 func (f Format) IsValid() bool {
 	switch f {
-	case MarkdownFormat, HTMLFormat, PDFFormat:
+	case MarkdownFormat, HTMLFormat, PDFFormat, JSONFormat, TerminalFormat, CodeQualityFormat, RDJSONFormat, RDJSONLFormat,
+		BadgeFormat, BadgeJSONFormat, GitHubMarkdownFormat:
 		return true
 	default:
 		return false
 	}
 }
+
+// IsMachineReadable 判断格式是否供其他工具解析，这类报告输出到标准输出时不附加任何提示文字
+func (f Format) IsMachineReadable() bool {
+	return f == JSONFormat || f == CodeQualityFormat || f == RDJSONFormat || f == RDJSONLFormat ||
+		f == BadgeFormat || f == BadgeJSONFormat
+}
@@ -0,0 +1,124 @@
+package review
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/git"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// 识别为生成的代码或第三方代码的原因，输出详细日志时显示
+const (
+	ReasonLinguistGenerated = git.LinguistGenerated
+	ReasonLinguistVendored  = git.LinguistVendored
+	// 文件开头有 "Code generated ... DO NOT EDIT" 等生成代码的标记
+	ReasonGeneratedHeader = "generated-header"
+	// 文件位于 vendor/、node_modules/ 等第三方代码目录中
+	ReasonVendorPath = "vendor-path"
+)
+
+// generatedHeaderLines 检查生成代码标记的文件开头行数
+const generatedHeaderLines = 30
+
+// vendorDirs 按惯例存放第三方代码的目录，出现在路径的任意一级即视为第三方代码
+var vendorDirs = map[string]bool{
+	"vendor": true, "node_modules": true, "bower_components": true, "third_party": true,
+}
+
+var (
+	// generatedMarker 同一行中同时出现 generated 和 do not edit/modify，
+	// 如 Go 的 "// Code generated by protoc-gen-go. DO NOT EDIT."、"AUTO-GENERATED FILE. DO NOT MODIFY."
+	generatedMarker = regexp.MustCompile(`(?i)\bgenerated\b.*\bdo not (edit|modify)\b`)
+	// generatedTag Facebook 等工具使用的 @generated 标记和 C# 的 <auto-generated> 标记
+	generatedTag = regexp.MustCompile(`(^|[^\w@])@generated\b|<auto-generated`)
+)
+
+// DetectGenerated 判断文件是否为生成的代码或第三方代码，返回原因，不是时返回空字符串
+// attrs 为 .gitattributes 中为该文件显式指定的 linguist 属性：设置时按属性判断，与 GitHub 相同，
+// 显式取消（-linguist-generated、-linguist-vendored）时不再按文件头和目录判断
+// read 读取文件在评审目标版本中的内容，只在差异中没有文件开头时调用
+func DetectGenerated(change types.FileChange, attrs map[string]bool, read func(filePath string) (string, error)) string {
+	generated, generatedSet := attrs[git.LinguistGenerated]
+	vendored, vendoredSet := attrs[git.LinguistVendored]
+	switch {
+	case generated:
+		return ReasonLinguistGenerated
+	case vendored:
+		return ReasonLinguistVendored
+	}
+	if !vendoredSet && isVendorPath(change.FilePath) {
+		return ReasonVendorPath
+	}
+	if generatedSet {
+		return ""
+	}
+
+	header, ok := diffHeader(change)
+	if !ok && read != nil && change.ChangeType != "deleted" {
+		content, err := read(change.FilePath)
+		if err != nil {
+			return ""
+		}
+		header = content
+	}
+	if hasGeneratedMarker(header) {
+		return ReasonGeneratedHeader
+	}
+	return ""
+}
+
+// isVendorPath 判断文件是否位于第三方代码目录中
+func isVendorPath(filePath string) bool {
+	dirs := strings.Split(filePath, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if vendorDirs[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// diffHeader 从差异中取出文件开头的内容：新增和修改的文件取新文件，被删除的文件取删除前的内容
+// 差异中没有完整的文件开头时返回 false；新增和删除的文件差异中就是完整内容
+func diffHeader(change types.FileChange) (string, bool) {
+	if len(change.Hunks) == 0 {
+		return "", false
+	}
+	first := change.Hunks[0]
+	deleted := change.ChangeType == "deleted"
+	start := first.NewStart
+	if deleted {
+		start = first.OldStart
+	}
+	if start > 1 {
+		return "", false
+	}
+	var b strings.Builder
+	n := 0
+	for _, line := range first.Lines {
+		if (deleted && line.Kind == '+') || (!deleted && line.Kind == '-') {
+			continue
+		}
+		b.WriteString(line.Content)
+		b.WriteByte('\n')
+		if n++; n == generatedHeaderLines {
+			break
+		}
+	}
+	whole := deleted || change.ChangeType == "added"
+	return b.String(), whole || n == generatedHeaderLines
+}
+
+// hasGeneratedMarker 判断内容的开头是否有生成代码的标记
+func hasGeneratedMarker(content string) bool {
+	for i, line := range strings.SplitN(content, "\n", generatedHeaderLines+1) {
+		if i == generatedHeaderLines {
+			break
+		}
+		if generatedMarker.MatchString(line) || generatedTag.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/icatw/ai-cr-tool/pkg/model"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// GitHubCommentLimit GitHub 评论正文的最大字符数
+const GitHubCommentLimit = 65536
+
+// githubNoticeReserve 为截断说明和完整报告链接预留的字符数
+const githubNoticeReserve = 1024
+
+// severityEmojis 各严重程度在 GitHub 评论中使用的标记
+var severityEmojis = map[types.SeverityLevel]string{
+	types.SeverityError:   "🔴",
+	types.SeverityWarning: "🟡",
+	types.SeverityInfo:    "🔵",
+}
+
+// severityEmoji 返回严重程度对应的标记，无法识别时为白色圆点
+func severityEmoji(severity types.SeverityLevel) string {
+	if emoji, ok := severityEmojis[severity]; ok {
+		return emoji
+	}
+	return "⚪"
+}
+
+// generateGitHubMarkdown 生成适合作为 GitHub PR 评论的 Markdown 报告
+// 每个文件的问题放在可折叠的 <details> 中，包含 error 的文件默认展开；
+// 内容超过 GitHub 评论长度上限时按文件截断，并附上完整报告的链接
+func (r *DefaultReporter) generateGitHubMarkdown(issues []types.Issue) ([]byte, error) {
+	var header bytes.Buffer
+	t := r.Lang.T
+	score := QualityScore(issues)
+
+	header.WriteString(fmt.Sprintf("## %s\n\n", t("report.title")))
+	parts := []string{fmt.Sprintf("**%s**", t("report.score_short", score, Grade(score)))}
+	severityCount := make(map[types.SeverityLevel]int)
+	for _, issue := range issues {
+		severityCount[issue.Severity]++
+	}
+	for _, severity := range severityOrder {
+		if count := severityCount[severity]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%s %s %s", severityEmoji(severity), severity, r.Lang.Number(count)))
+		}
+	}
+	if len(issues) == 0 {
+		parts = append(parts, "✅ "+t("report.no_issues"))
+	}
+	header.WriteString(strings.Join(parts, " · ") + "\n\n")
+
+	if r.TimeBox != nil {
+		r.writeMarkdownTimeBox(&header)
+	}
+	if len(r.Failures) > 0 {
+		r.writeMarkdownFailures(&header)
+	}
+	if len(r.BinaryFiles) > 0 {
+		r.writeMarkdownBinary(&header)
+	}
+	if r.Summary != nil {
+		r.writeMarkdownSummary(&header)
+	}
+	if len(r.Themes) > 0 {
+		r.writeMarkdownThemes(&header)
+	}
+
+	var footer string
+	if r.ReportURL != "" && safeURL(r.ReportURL) {
+		footer = fmt.Sprintf("[%s](%s)\n", t("report.full_report"), r.ReportURL)
+	}
+
+	budget := GitHubCommentLimit - utf8.RuneCount(header.Bytes()) - utf8.RuneCountInString(footer) - githubNoticeReserve
+	var body bytes.Buffer
+	groups := groupIssues(issues)
+	shown := 0
+	for _, g := range groups {
+		block := r.githubFileBlock(g)
+		if utf8.RuneCount(body.Bytes())+utf8.RuneCount(block) > budget {
+			break
+		}
+		body.Write(block)
+		shown++
+	}
+
+	var buf bytes.Buffer
+	buf.Write(header.Bytes())
+	buf.Write(body.Bytes())
+	if shown < len(groups) {
+		hidden := 0
+		for _, g := range groups[shown:] {
+			hidden += len(g.Issues)
+		}
+		buf.WriteString(fmt.Sprintf("> ⚠️ %s\n\n", t("report.github_truncated", len(groups)-shown, hidden)))
+	}
+	buf.WriteString(footer)
+	return buf.Bytes(), nil
+}
+
+// githubFileBlock 生成单个文件的可折叠问题列表
+func (r *DefaultReporter) githubFileBlock(g FileGroup) []byte {
+	var buf bytes.Buffer
+	t := r.Lang.T
+	highest := HighestSeverity(g.Issues)
+	open := ""
+	if highest == types.SeverityError {
+		open = " open"
+	}
+	buf.WriteString(fmt.Sprintf("<details%s>\n<summary>%s <code>%s</code> · %s · %s %s</summary>\n\n",
+		open, severityEmoji(highest), html.EscapeString(g.File), t("report.file_summary", len(g.Issues)), t("report.grade"), r.fileGrade(g)))
+	for _, c := range g.Categories {
+		for _, issue := range c.Issues {
+			r.writeGitHubIssue(&buf, issue)
+		}
+	}
+	buf.WriteString("</details>\n\n")
+	return buf.Bytes()
+}
+
+// writeGitHubIssue 写入单个问题，多行的改进建议视为替换代码，放在 suggestion 代码块中
+// 问题的指纹写在不显示的 HTML 注释中，便于后续按指纹查找和更新评论；
+// 使用 Conventional Comments 样式时以加粗的标签代替严重程度图标
+func (r *DefaultReporter) writeGitHubIssue(buf *bytes.Buffer, issue types.Issue) {
+	t := r.Lang.T
+	buf.WriteString(FingerprintMarker(issue) + "\n")
+	if r.conventional() {
+		buf.WriteString(fmt.Sprintf("**%s:** %s · %s · %s\n\n", ConventionalLabel(issue), issue.Title,
+			t("report.line", issue.Line), r.categoryName(issueCategory(issue))))
+	} else {
+		buf.WriteString(fmt.Sprintf("%s **%s** · %s · %s\n\n", severityEmoji(issue.Severity), issue.Title,
+			t("report.line", issue.Line), r.categoryName(issueCategory(issue))))
+	}
+	if issue.Description != "" {
+		buf.WriteString(issue.Description + "\n\n")
+	}
+	if suggestion := strings.TrimSpace(issue.Suggestion); suggestion != "" {
+		if strings.Contains(suggestion, "\n") {
+			fence := codeFence(suggestion)
+			buf.WriteString(fmt.Sprintf("💡 %s\n\n%ssuggestion\n%s\n%s\n\n", t("report.suggestion"), fence, suggestion, fence))
+		} else {
+			buf.WriteString(fmt.Sprintf("💡 %s%s\n\n", t("report.suggestion"), suggestion))
+		}
+	}
+	if len(issue.References) > 0 {
+		links := make([]string, 0, len(issue.References))
+		for _, ref := range issue.References {
+			links = append(links, fmt.Sprintf("[%s](%s)", ref.Title, ref.URL))
+		}
+		buf.WriteString(fmt.Sprintf("%s%s\n\n", t("report.references"), strings.Join(links, t("report.list_separator"))))
+	}
+	if excerpt := r.issueHunk(issue); excerpt != nil {
+		r.writeMarkdownHunk(buf, excerpt)
+	} else if issue.CodeSnippet != "" {
+		if lines := r.snippetLines(issue); len(lines) > 0 {
+			snippet := strings.Join(lines, "\n")
+			fence := codeFence(snippet)
+			buf.WriteString(fmt.Sprintf("%s%s\n%s\n%s\n\n", fence, model.DetectLanguage(issue.FilePath, issue.CodeSnippet), snippet, fence))
+		}
+	}
+}
+
+// FingerprintMarker 返回写在评论中的隐藏指纹标记，发布评论时据此判断问题是否已经评论过
+func FingerprintMarker(issue types.Issue) string {
+	return fmt.Sprintf("<!-- cr-fingerprint: %s -->", issueFingerprint(issue))
+}
+
+// ModelMarker 返回写在行内评论中的隐藏模型标记，用于按模型统计问题被驳回的比例
+func ModelMarker(model string) string {
+	return fmt.Sprintf("<!-- cr-model: %s -->", model)
+}
+
+// ParseMarkers 从评论正文中读取指纹标记和模型标记，没有指纹标记时返回 false
+func ParseMarkers(body string) (fingerprint, model string, ok bool) {
+	fingerprint, ok = markerValue(body, "<!-- cr-fingerprint: ")
+	model, _ = markerValue(body, "<!-- cr-model: ")
+	return fingerprint, model, ok
+}
+
+// markerValue 读取形如 <!-- name: value --> 的隐藏标记的值
+func markerValue(body, prefix string) (string, bool) {
+	_, rest, ok := strings.Cut(body, prefix)
+	if !ok {
+		return "", false
+	}
+	value, _, ok := strings.Cut(rest, " -->")
+	return strings.TrimSpace(value), ok
+}
+
+// GitHubComment 生成单个问题的 GitHub 评论正文，用作 PR 中的行内评论
+// 设置了 Model 时附带模型标记
+func (r *DefaultReporter) GitHubComment(issue types.Issue) string {
+	var buf bytes.Buffer
+	if r.Model != "" {
+		buf.WriteString(ModelMarker(r.Model) + "\n")
+	}
+	r.writeGitHubIssue(&buf, issue)
+	return strings.TrimSpace(buf.String())
+}
+
+// codeFence 返回比内容中最长的连续反引号更长的代码块围栏，至少三个反引号
+func codeFence(content string) string {
+	longest, run := 0, 0
+	for _, ch := range content {
+		if ch == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
@@ -0,0 +1,148 @@
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// CategoryIssues 同一类别的问题
+type CategoryIssues struct {
+	Category types.IssueCategory
+	Issues   []types.Issue
+}
+
+// FileGroup 同一文件中按类别分组的问题
+type FileGroup struct {
+	File string
+	// 报告中的锚点，用于从目录跳转
+	Anchor     string
+	Issues     []types.Issue
+	Categories []CategoryIssues
+}
+
+// issueCategory 返回问题的类别，未设置或无法识别时为 other
+func issueCategory(issue types.Issue) types.IssueCategory {
+	if !knownCategory(issue.Category) {
+		return types.CategoryOther
+	}
+	return issue.Category
+}
+
+// groupIssues 按文件分组问题，文件内再按类别分组，类别按 types.Categories 的顺序排列
+// 文件保持问题首次出现的顺序
+func groupIssues(issues []types.Issue) []FileGroup {
+	files := groupIssuesByFile(issues)
+	groups := make([]FileGroup, 0, len(files))
+	used := make(map[string]bool)
+	for _, f := range files {
+		groups = append(groups, FileGroup{
+			File:       f.File,
+			Anchor:     fileAnchor(f.File, used),
+			Issues:     f.Issues,
+			Categories: groupByCategory(f.Issues),
+		})
+	}
+	return groups
+}
+
+// groupByCategory 按类别分组问题，类别按 types.Categories 的顺序排列
+func groupByCategory(issues []types.Issue) []CategoryIssues {
+	var groups []CategoryIssues
+	for _, category := range types.Categories {
+		var matched []types.Issue
+		for _, issue := range issues {
+			if issueCategory(issue) == category {
+				matched = append(matched, issue)
+			}
+		}
+		if len(matched) > 0 {
+			groups = append(groups, CategoryIssues{Category: category, Issues: matched})
+		}
+	}
+	return groups
+}
+
+// knownCategory 判断是否为预定义的类别
+func knownCategory(category types.IssueCategory) bool {
+	for _, c := range types.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// fileAnchor 根据文件路径生成报告内唯一的锚点，如 file-pkg-review-reporter-go
+func fileAnchor(file string, used map[string]bool) string {
+	var buf strings.Builder
+	for _, r := range strings.ToLower(file) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			buf.WriteRune(r)
+		} else {
+			buf.WriteRune('-')
+		}
+	}
+	base := "file-" + strings.Trim(buf.String(), "-")
+	anchor := base
+	for i := 2; used[anchor]; i++ {
+		anchor = fmt.Sprintf("%s-%d", base, i)
+	}
+	used[anchor] = true
+	return anchor
+}
+
+// categoryName 返回类别的本地化名称
+func (r *DefaultReporter) categoryName(category types.IssueCategory) string {
+	return r.Lang.T("category." + string(category))
+}
+
+// categorySummary 按类别汇总问题数，如 "安全 2、性能 1"
+func (r *DefaultReporter) categorySummary(groups []CategoryIssues) string {
+	parts := make([]string, 0, len(groups))
+	for _, g := range groups {
+		parts = append(parts, fmt.Sprintf("%s %d", r.categoryName(g.Category), len(g.Issues)))
+	}
+	return strings.Join(parts, r.Lang.T("report.list_separator"))
+}
+
+// fileGrade 返回文件的等级和质量分，如 "B (85)"
+func (r *DefaultReporter) fileGrade(g FileGroup) string {
+	score := QualityScore(g.Issues)
+	return fmt.Sprintf("%s (%d)", Grade(score), score)
+}
+
+// writeMarkdownTOC 写入Markdown格式的目录，列出每个文件的问题汇总并链接到对应章节
+func (r *DefaultReporter) writeMarkdownTOC(buf *bytes.Buffer, groups []FileGroup) {
+	t := r.Lang.T
+	buf.WriteString(fmt.Sprintf("## %s\n\n", t("report.toc")))
+	buf.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n", t("report.file_column"), t("report.grade"), t("report.count"), t("report.severity_column"), t("report.categories_column")))
+	buf.WriteString("|------|------|------|------|------|\n")
+	for _, g := range groups {
+		buf.WriteString(fmt.Sprintf("| [%s](#%s) | %s | %d | %s | %s |\n",
+			tableCell(g.File), g.Anchor, r.fileGrade(g), len(g.Issues), authorSummary(g.Issues), r.categorySummary(g.Categories)))
+	}
+	buf.WriteString("\n")
+}
+
+// writeHTMLTOC 写入HTML格式的目录
+func (r *DefaultReporter) writeHTMLTOC(buf *bytes.Buffer, groups []FileGroup) {
+	t := r.Lang.T
+	buf.WriteString(fmt.Sprintf(`
+	<h2>%s</h2>
+	<nav class="toc">
+		<table>
+			<tr><th>%s</th><th>%s</th><th>%s</th><th>%s</th><th>%s</th></tr>`,
+		t("report.toc"), t("report.file_column"), t("report.grade"), t("report.count"), t("report.severity_column"), t("report.categories_column")))
+	for _, g := range groups {
+		buf.WriteString(fmt.Sprintf(`
+			<tr><td><a href="#%s">%s</a></td><td>%s</td><td>%d</td><td>%s</td><td>%s</td></tr>`,
+			g.Anchor, html.EscapeString(g.File), r.fileGrade(g), len(g.Issues), authorSummary(g.Issues), html.EscapeString(r.categorySummary(g.Categories))))
+	}
+	buf.WriteString(`
+		</table>
+	</nav>`)
+}
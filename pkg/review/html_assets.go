@@ -0,0 +1,87 @@
+package review
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html"
+	"sort"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// 内嵌到HTML报告中的样式和脚本，报告不依赖外部网络即可完整显示
+var (
+	//go:embed assets/report.css
+	reportCSS string
+	//go:embed assets/highlight.js
+	highlightJS string
+	//go:embed assets/report.js
+	reportJS string
+)
+
+// highlight.js 的 CDN 地址，仅在 CDNAssets 为 true 时使用
+const (
+	highlightCDNScript = "https://cdnjs.cloudflare.com/ajax/libs/highlight.js/11.7.0/highlight.min.js"
+	highlightCDNStyle  = "https://cdnjs.cloudflare.com/ajax/libs/highlight.js/11.7.0/styles/vs2015.min.css"
+)
+
+// writeHTMLScripts 写入报告脚本
+// 交互脚本始终内联；代码高亮默认内联内置脚本，CDNAssets 时引用 CDN 上的 highlight.js
+func (r *DefaultReporter) writeHTMLScripts(buf *bytes.Buffer) {
+	buf.WriteString(`
+	<script>
+`)
+	buf.WriteString(reportJS)
+	if !r.CDNAssets {
+		buf.WriteString(highlightJS)
+	}
+	buf.WriteString(`	</script>`)
+	if r.CDNAssets {
+		buf.WriteString(`
+	<script src="` + highlightCDNScript + `"></script>
+	<link rel="stylesheet" href="` + highlightCDNStyle + `">
+	<script>hljs.highlightAll();</script>`)
+	}
+}
+
+// writeHTMLToolbar 写入问题列表的筛选工具栏
+func (r *DefaultReporter) writeHTMLToolbar(buf *bytes.Buffer, issues []types.Issue) {
+	t := r.Lang.T
+	buf.WriteString(fmt.Sprintf(`
+	<div class="toolbar" id="issue-toolbar" data-count-format="%s">
+		<input type="search" id="issue-search" placeholder="%s">
+		<select id="severity-filter">
+			<option value="">%s</option>`,
+		html.EscapeString(t("report.issue_count_format")), html.EscapeString(t("report.search_placeholder")), t("report.all_severities")))
+	counts := CountBySeverity(issues)
+	for _, severity := range []types.SeverityLevel{types.SeverityError, types.SeverityWarning, types.SeverityInfo} {
+		if counts[severity] > 0 {
+			buf.WriteString(fmt.Sprintf(`
+			<option value="%s">%s (%d)</option>`, severity, severity, counts[severity]))
+		}
+	}
+	buf.WriteString(fmt.Sprintf(`
+		</select>
+		<select id="file-filter">
+			<option value="">%s</option>`, t("report.all_files")))
+	files := getUniqueFiles(issues)
+	sort.Strings(files)
+	for _, file := range files {
+		buf.WriteString(fmt.Sprintf(`
+			<option value="%s">%s</option>`, html.EscapeString(file), html.EscapeString(file)))
+	}
+	buf.WriteString(fmt.Sprintf(`
+		</select>
+		<select id="category-filter">
+			<option value="">%s</option>`, t("report.all_categories")))
+	for _, g := range groupByCategory(issues) {
+		buf.WriteString(fmt.Sprintf(`
+			<option value="%s">%s (%d)</option>`, g.Category, r.categoryName(g.Category), len(g.Issues)))
+	}
+	buf.WriteString(fmt.Sprintf(`
+		</select>
+		<button type="button" id="toggle-all" data-collapse="%s" data-expand="%s">%s</button>
+		<span class="count" id="issue-count"></span>
+	</div>`, t("report.collapse_all"), t("report.expand_all"), t("report.collapse_all")))
+}
@@ -0,0 +1,163 @@
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"path"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// maxHunkLines 问题下展示的改动块最大行数，超出时只保留问题行附近的内容
+const maxHunkLines = 20
+
+// hunkLine 改动块中的一行
+type hunkLine struct {
+	// '+' 新增、'-' 删除、' ' 上下文
+	Kind byte
+	// 新文件中的行号，删除的行为 0
+	Line int
+	Text string
+}
+
+// hunkExcerpt 问题所在改动块的摘录
+type hunkExcerpt struct {
+	Header string
+	Lines  []hunkLine
+	// 问题行在 Lines 中的下标，不在摘录中时为 -1
+	Focus int
+	// 摘录前后省略的行数
+	Before, After int
+}
+
+// issueHunk 从本次改动中找出问题所在的改动块并截取问题行附近的内容，找不到时返回 nil
+func (r *DefaultReporter) issueHunk(issue types.Issue) *hunkExcerpt {
+	if len(r.Changes) == 0 || issue.Line <= 0 {
+		return nil
+	}
+	file := path.Clean(issue.FilePath)
+	for _, change := range r.Changes {
+		if path.Clean(change.FilePath) != file {
+			continue
+		}
+		change.Hunks = diffHunks(change)
+		if hunk := change.HunkAt(issue.Line); hunk != nil {
+			return excerptHunk(*hunk, issue.Line)
+		}
+		return nil
+	}
+	return nil
+}
+
+// excerptHunk 取出改动块的新增、删除和上下文行，超过 maxHunkLines 行时以问题行为中心截取
+func excerptHunk(hunk types.DiffHunk, line int) *hunkExcerpt {
+	excerpt := &hunkExcerpt{Header: hunk.Header(), Focus: -1}
+	for _, dl := range hunk.Lines {
+		l := hunkLine{Kind: dl.Kind, Line: dl.NewLine, Text: dl.Content}
+		if l.Line == line && excerpt.Focus < 0 {
+			excerpt.Focus = len(excerpt.Lines)
+		}
+		excerpt.Lines = append(excerpt.Lines, l)
+	}
+
+	if len(excerpt.Lines) > maxHunkLines {
+		from := max(0, excerpt.Focus-maxHunkLines/2)
+		to := min(len(excerpt.Lines), from+maxHunkLines)
+		from = max(0, to-maxHunkLines)
+		excerpt.Before, excerpt.After = from, len(excerpt.Lines)-to
+		excerpt.Lines = excerpt.Lines[from:to]
+		excerpt.Focus -= from
+	}
+	return excerpt
+}
+
+// hunkDiffText 以统一差异格式返回改动块摘录，省略的行以说明行代替
+func (r *DefaultReporter) hunkDiffText(excerpt *hunkExcerpt) string {
+	var sb strings.Builder
+	sb.WriteString(excerpt.Header + "\n")
+	if excerpt.Before > 0 {
+		sb.WriteString(r.Lang.T("report.hunk_omitted", excerpt.Before) + "\n")
+	}
+	for _, l := range excerpt.Lines {
+		sb.WriteString(string(l.Kind) + l.Text + "\n")
+	}
+	if excerpt.After > 0 {
+		sb.WriteString(r.Lang.T("report.hunk_omitted", excerpt.After) + "\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// writeMarkdownHunk 以 diff 代码块写入问题所在的改动块
+func (r *DefaultReporter) writeMarkdownHunk(buf *bytes.Buffer, excerpt *hunkExcerpt) {
+	diff := r.hunkDiffText(excerpt)
+	fence := codeFence(diff)
+	buf.WriteString(fmt.Sprintf("%sdiff\n%s\n%s\n\n", fence, diff, fence))
+}
+
+// writeHTMLHunk 写入问题所在的改动块，新增和删除的行分别着色，问题行高亮
+// 不使用 <code> 元素，避免被代码高亮脚本改写
+func (r *DefaultReporter) writeHTMLHunk(buf *bytes.Buffer, excerpt *hunkExcerpt) {
+	buf.WriteString(`
+			<pre class="code diff">`)
+	buf.WriteString(fmt.Sprintf(`<span class="diff-line diff-header">%s</span>`, html.EscapeString(excerpt.Header)))
+	if excerpt.Before > 0 {
+		buf.WriteString(fmt.Sprintf(`<span class="diff-line diff-omitted">%s</span>`, html.EscapeString(r.Lang.T("report.hunk_omitted", excerpt.Before))))
+	}
+	for i, l := range excerpt.Lines {
+		class := "diff-context"
+		switch l.Kind {
+		case '+':
+			class = "diff-add"
+		case '-':
+			class = "diff-del"
+		}
+		if i == excerpt.Focus {
+			class += " diff-focus"
+		}
+		number := ""
+		if l.Line > 0 {
+			number = fmt.Sprint(l.Line)
+		}
+		buf.WriteString(fmt.Sprintf(`<span class="diff-line %s"><span class="line-number">%4s</span>%c%s</span>`,
+			class, number, l.Kind, html.EscapeString(l.Text)))
+	}
+	if excerpt.After > 0 {
+		buf.WriteString(fmt.Sprintf(`<span class="diff-line diff-omitted">%s</span>`, html.EscapeString(r.Lang.T("report.hunk_omitted", excerpt.After))))
+	}
+	buf.WriteString(`</pre>`)
+}
+
+// writeTerminalHunk 写入问题所在的改动块，新增的行为绿色，删除的行为红色，问题行以 ">" 标记
+func (r *DefaultReporter) writeTerminalHunk(buf *bytes.Buffer, excerpt *hunkExcerpt, style terminalStyle, indent string, width int) {
+	gutter := style.paint(ansiDim, "│ ")
+	buf.WriteString(indent + gutter + style.paint(ansiCyan, excerpt.Header) + "\n")
+	if excerpt.Before > 0 {
+		buf.WriteString(indent + gutter + style.paint(ansiDim, r.Lang.T("report.hunk_omitted", excerpt.Before)) + "\n")
+	}
+	for i, l := range excerpt.Lines {
+		marker := "  "
+		if i == excerpt.Focus {
+			marker = "> "
+		}
+		color := ""
+		switch l.Kind {
+		case '+':
+			color = ansiGreen
+		case '-':
+			color = ansiRed
+		}
+		text := strings.ReplaceAll(string(l.Kind)+l.Text, "\t", strings.Repeat(" ", snippetTabWidth))
+		for _, part := range splitWidth(text, width-4, maxSnippetRows) {
+			if color != "" {
+				part = style.paint(color, part)
+			}
+			buf.WriteString(indent + gutter + marker + part + "\n")
+			marker = "  "
+		}
+	}
+	if excerpt.After > 0 {
+		buf.WriteString(indent + gutter + style.paint(ansiDim, r.Lang.T("report.hunk_omitted", excerpt.After)) + "\n")
+	}
+}
@@ -0,0 +1,116 @@
+package review
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/git"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// Hunk 表示文件差异中的一个改动块（以 @@ 开头）
+type Hunk struct {
+	FilePath string // 所属文件
+	Header   string // @@ -a,b +c,d @@ 行
+	Body     string // 改动块内容，不含头部行
+}
+
+// String 返回完整的改动块文本
+func (h Hunk) String() string {
+	return h.Header + "\n" + h.Body
+}
+
+// SplitHunks 将文件差异拆分为文件头（diff --git、---、+++ 等）和若干改动块
+func SplitHunks(change types.FileChange) (string, []Hunk) {
+	lines := strings.Split(strings.TrimRight(change.DiffContent, "\n"), "\n")
+
+	var header []string
+	var hunks []Hunk
+	var body []string
+	flush := func() {
+		if len(hunks) > 0 {
+			hunks[len(hunks)-1].Body = strings.Join(body, "\n")
+		}
+		body = nil
+	}
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, "@@") {
+			flush()
+			hunks = append(hunks, Hunk{FilePath: change.FilePath, Header: line})
+			continue
+		}
+		if len(hunks) == 0 {
+			header = append(header, line)
+		} else {
+			body = append(body, line)
+		}
+	}
+	flush()
+
+	return strings.Join(header, "\n"), hunks
+}
+
+// JoinHunks 用选中的改动块重新构造文件改动，没有选中任何改动块时返回 false
+func JoinHunks(change types.FileChange, header string, hunks []Hunk) (types.FileChange, bool) {
+	if len(hunks) == 0 {
+		return change, false
+	}
+
+	var sb strings.Builder
+	sb.WriteString(header)
+	for _, hunk := range hunks {
+		sb.WriteString("\n")
+		sb.WriteString(hunk.String())
+	}
+	sb.WriteString("\n")
+
+	change.DiffContent = sb.String()
+	// 原有的改动块已与差异内容不一致，按新的差异重新解析
+	change.Hunks = nil
+	change.Hunks = diffHunks(change)
+	return change, true
+}
+
+// diffHunks 返回文件的结构化改动块，未解析过（如调用方直接构造的改动）时从差异内容中解析
+func diffHunks(change types.FileChange) []types.DiffHunk {
+	if change.Hunks != nil {
+		return change.Hunks
+	}
+	if parsed := git.ParseDiff(change.DiffContent); len(parsed) == 1 {
+		return parsed[0].Hunks
+	}
+	return nil
+}
+
+// NewRange 解析头部行 @@ -a,b +c,d @@ 中新文件的起始行号 c 和行数 d，省略行数时为 1，无法解析时返回 0, 0
+func (h Hunk) NewRange() (int, int) {
+	return h.hunkRange(" +")
+}
+
+// OldRange 解析头部行 @@ -a,b +c,d @@ 中旧文件的起始行号 a 和行数 b，省略行数时为 1，无法解析时返回 0, 0
+func (h Hunk) OldRange() (int, int) {
+	return h.hunkRange(" -")
+}
+
+// hunkRange 解析头部行中 marker 之后的 start,count
+func (h Hunk) hunkRange(marker string) (int, int) {
+	i := strings.Index(h.Header, marker)
+	if i < 0 {
+		return 0, 0
+	}
+	spec, _, _ := strings.Cut(h.Header[i+2:], " ")
+	startText, countText, hasCount := strings.Cut(spec, ",")
+	start, err := strconv.Atoi(startText)
+	if err != nil {
+		return 0, 0
+	}
+	if !hasCount {
+		return start, 1
+	}
+	count, err := strconv.Atoi(countText)
+	if err != nil {
+		return 0, 0
+	}
+	return start, count
+}
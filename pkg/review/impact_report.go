@@ -0,0 +1,50 @@
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// 影响范围表格中最多列出的直接依赖方
+const maxListedDependents = 5
+
+// listDependents 生成依赖方列表文本，超出部分以数量表示
+func listDependents(deps []string) string {
+	if len(deps) <= maxListedDependents {
+		return strings.Join(deps, ", ")
+	}
+	return fmt.Sprintf("%s … (+%d)", strings.Join(deps[:maxListedDependents], ", "), len(deps)-maxListedDependents)
+}
+
+// writeMarkdownImpact 写入Markdown格式的影响范围
+func (r *DefaultReporter) writeMarkdownImpact(buf *bytes.Buffer) {
+	t := r.Lang.T
+	buf.WriteString(fmt.Sprintf("## %s\n\n", t("report.impact")))
+	buf.WriteString(fmt.Sprintf("| %s | %s | %s |\n", t("report.package"), t("report.direct_dependents"), t("report.transitive_dependents")))
+	buf.WriteString("|------|------|------|\n")
+	for _, impact := range r.Impact {
+		buf.WriteString(fmt.Sprintf("| `%s` | %s | %d |\n", impact.Package, listDependents(impact.Direct), len(impact.Transitive)))
+	}
+	buf.WriteString("\n")
+}
+
+// writeHTMLImpact 写入HTML格式的影响范围
+func (r *DefaultReporter) writeHTMLImpact(buf *bytes.Buffer) {
+	t := r.Lang.T
+	buf.WriteString(fmt.Sprintf(`
+	<h2>%s</h2>
+	<div class="chart">
+		<table>
+			<tr><th>%s</th><th>%s</th><th>%s</th></tr>`,
+		t("report.impact"), t("report.package"), t("report.direct_dependents"), t("report.transitive_dependents")))
+	for _, impact := range r.Impact {
+		buf.WriteString(fmt.Sprintf(`
+			<tr><td><code>%s</code></td><td>%s</td><td>%d</td></tr>`,
+			html.EscapeString(impact.Package), html.EscapeString(listDependents(impact.Direct)), len(impact.Transitive)))
+	}
+	buf.WriteString(`
+		</table>
+	</div>`)
+}
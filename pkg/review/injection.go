@@ -0,0 +1,113 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+var (
+	// 代码中试图操控评审者的内容
+	embeddedInstructionPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(AI|LLM|code)\s*review(er)?s?\b.{0,40}\b(must|should|please|ignore|approve)`),
+		regexp.MustCompile(`(?i)\b(approve|lgtm)\s+(this|the)\s+(change|pr|mr|commit|code)`),
+		regexp.MustCompile(`(?i)\bdo\s+not\s+(report|flag|mention)\b`),
+		regexp.MustCompile(`(?i)\b(respond|reply|answer)\s+(only\s+)?with\b`),
+		regexp.MustCompile(`(评审|审查|审核)(者|助手|工具|AI).{0,20}(必须|应该|请|忽略|批准|通过)`),
+		regexp.MustCompile(`(不要|无需|不必)(报告|指出|提及).{0,10}(问题|漏洞)`),
+	}
+
+	// 评审结果中表明模型执行了嵌入指令的措辞，出现在输出的任何位置都算
+	complianceMarkers = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\bas\s+(requested|instructed)\b`),
+		regexp.MustCompile(`(?i)\bper\s+the\s+(instructions?|comment)\s+in\s+the\s+code\b`),
+		regexp.MustCompile(`(按照|根据|遵照)(代码|注释|提交)(中)?的?(要求|指示|说明)`),
+	}
+
+	// 批准改动的措辞；问题描述中也常出现 approved、已通过等词，只有整个输出是批准结论时才算
+	approvalMarkers = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)\b(approving|approved|lgtm)\b`),
+		regexp.MustCompile(`(已|予以)(批准|通过)`),
+		regexp.MustCompile(`无需(进一步)?评审`),
+	}
+)
+
+// maxVerdictRunes 非结构化输出被视为一句批准结论的最大长度
+const maxVerdictRunes = 200
+
+// suspiciousPatterns 返回差异中需要检查的全部注入特征
+func suspiciousPatterns() []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, 0, len(embeddedInstructionPatterns)+len(injectionPatterns))
+	return append(append(patterns, embeddedInstructionPatterns...), injectionPatterns...)
+}
+
+// DetectInjectionCompliance 判断模型输出是否表明其执行了差异中嵌入的指令
+// 只有差异中存在疑似注入内容，且输出中出现顺从性措辞、或整个输出是批准结论时才认为命中，返回命中的证据
+func DetectInjectionCompliance(diff, output string) (bool, string) {
+	patterns := suspiciousPatterns()
+	var embedded string
+	for _, line := range strings.Split(diff, "\n") {
+		// 只检查新增和上下文行
+		if strings.HasPrefix(line, "-") {
+			continue
+		}
+		for _, pattern := range patterns {
+			if m := pattern.FindString(line); m != "" {
+				embedded = m
+				break
+			}
+		}
+		if embedded != "" {
+			break
+		}
+	}
+	if embedded == "" {
+		return false, ""
+	}
+
+	for _, marker := range complianceMarkers {
+		if m := marker.FindString(output); m != "" {
+			return true, fmt.Sprintf("差异中包含 %q，评审结果中出现 %q", embedded, m)
+		}
+	}
+	if m := approvalVerdict(output); m != "" {
+		return true, fmt.Sprintf("差异中包含 %q，评审结果为 %q 且没有报告问题", embedded, m)
+	}
+	return false, ""
+}
+
+// approvalVerdict 判断整个输出是否是批准改动的结论，返回其中的批准措辞
+// 结构化输出需要问题列表为空，非结构化输出需要足够短、只是一句结论；报告了问题的评审即使提到 approved 也不算
+func approvalVerdict(output string) string {
+	var parsed struct {
+		Issues []json.RawMessage `json:"issues"`
+	}
+	if raw := extractJSON(output); raw != "" && json.Unmarshal([]byte(raw), &parsed) == nil {
+		if len(parsed.Issues) > 0 {
+			return ""
+		}
+	} else if len([]rune(strings.TrimSpace(output))) > maxVerdictRunes {
+		return ""
+	}
+	for _, marker := range approvalMarkers {
+		if m := marker.FindString(output); m != "" {
+			return m
+		}
+	}
+	return ""
+}
+
+// injectionWarning 生成提示词注入告警问题
+func injectionWarning(filePath, evidence string) types.Issue {
+	return types.Issue{
+		Title:       "评审结果可能受到提示词注入影响",
+		FilePath:    filePath,
+		Severity:    types.SeverityWarning,
+		Category:    types.CategorySecurity,
+		Description: "被评审的代码中包含试图操控AI评审者的内容，且评审结果疑似执行了这些指令，该文件的AI评审结论不可信。" + evidence,
+		Suggestion:  "请人工评审该文件，并移除代码中针对评审工具的指令性内容",
+	}
+}
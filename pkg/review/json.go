@@ -0,0 +1,532 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// JSONSchemaVersion JSON报告的结构版本
+// 字段只增不改；删除或修改字段含义时必须递增版本号
+const JSONSchemaVersion = 1
+
+// JSONReport JSON格式报告的顶层结构
+//
+//	{
+//	  "schema_version": 1,
+//	  "project": "ai-cr-tool",
+//	  "commit": "HEAD",
+//	  "generated_at": "2024-01-01T12:00:00+08:00",
+//	  "summary": {"files": 2, "issues": 3, "by_severity": {"error": 1, "warning": 1, "info": 1}},
+//	  "issues": [{
+//	    "title": "...", "file": "main.go", "line": 12, "severity": "error",
+//	    "description": "...", "suggestion": "...", "code_snippet": "...",
+//	    "references": [{"title": "CWE-89", "url": "https://cwe.mitre.org/data/definitions/89.html"}]
+//	  }]
+//	}
+type JSONReport struct {
+	SchemaVersion int         `json:"schema_version"`
+	Project       string      `json:"project"`
+	Commit        string      `json:"commit"`
+	GeneratedAt   time.Time   `json:"generated_at"`
+	Summary       JSONSummary `json:"summary"`
+	Issues        []JSONIssue `json:"issues"`
+	// 与上次评审的对比，没有上次评审结果时省略
+	Comparison *JSONComparison `json:"comparison,omitempty"`
+	// Go 仓库中被修改的包的影响范围
+	Impact []JSONImpact `json:"impact,omitempty"`
+	// 测试执行结果
+	Tests *JSONTests `json:"tests,omitempty"`
+	// 变更行覆盖率
+	Coverage *JSONCoverage `json:"coverage,omitempty"`
+	// 按作者分组的问题
+	Authors []JSONAuthor `json:"authors,omitempty"`
+	// 达到评审时限时的说明，报告只包含部分结果
+	TimeBox *JSONTimeBox `json:"time_box,omitempty"`
+	// 重试之后仍评审失败的文件，这些文件的问题不在报告中
+	Failures []JSONFailure `json:"failures,omitempty"`
+	// 跳过的二进制文件
+	BinaryFiles []string `json:"binary_files,omitempty"`
+	// 有问题的文件的质量分和等级
+	Files []JSONFileGrade `json:"files,omitempty"`
+	// 各改动类型的文件数
+	ChangeKinds map[string]int `json:"change_kinds,omitempty"`
+	// 执行摘要，未启用 --summary 时省略
+	ExecutiveSummary *JSONExecutiveSummary `json:"executive_summary,omitempty"`
+	// 归纳问题得到的主题，未启用 --themes 时省略
+	Themes []JSONTheme `json:"themes,omitempty"`
+	// 依赖清单文件中的依赖变更及风险评估
+	Dependencies *JSONDependencies `json:"dependencies,omitempty"`
+	// 各文件的评审缓存命中情况，未使用缓存时省略
+	Cache []JSONCacheStatus `json:"cache,omitempty"`
+}
+
+// JSONDependencies 依赖变更及风险评估
+type JSONDependencies struct {
+	Overview string           `json:"overview,omitempty"`
+	Changes  []JSONDependency `json:"changes"`
+}
+
+// JSONDependency 单个依赖的变更
+type JSONDependency struct {
+	Manifest  string `json:"manifest"`
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	// added、updated 或 removed
+	Change string `json:"change"`
+	From   string `json:"from,omitempty"`
+	To     string `json:"to,omitempty"`
+	// major、minor、patch、downgrade 或 unknown，只对更新的依赖给出
+	Jump string `json:"jump,omitempty"`
+	// high、medium 或 low，未评估时省略
+	Risk        string `json:"risk,omitempty"`
+	Breaking    string `json:"breaking,omitempty"`
+	SupplyChain string `json:"supply_chain,omitempty"`
+}
+
+// JSONExecutiveSummary 执行摘要
+type JSONExecutiveSummary struct {
+	Overview string   `json:"overview"`
+	Risks    []string `json:"risks"`
+	// approve、approve_with_suggestions 或 request_changes，模型未给出时为空
+	Decision string `json:"decision,omitempty"`
+	// 翻译为第二种语言的摘要，使用 --summary-lang 时提供
+	Translation *JSONSummaryTranslation `json:"translation,omitempty"`
+}
+
+// JSONSummaryTranslation 执行摘要的译文
+type JSONSummaryTranslation struct {
+	Lang     string   `json:"lang"`
+	Overview string   `json:"overview"`
+	Risks    []string `json:"risks"`
+}
+
+// JSONTheme 归纳问题得到的主题
+type JSONTheme struct {
+	Title   string `json:"title"`
+	Summary string `json:"summary,omitempty"`
+	// 主题下问题的最高严重程度
+	Severity string           `json:"severity"`
+	Issues   []JSONThemeIssue `json:"issues"`
+}
+
+// JSONThemeIssue 主题下的一个问题，可按 fingerprint 对应到 issues 中的问题
+type JSONThemeIssue struct {
+	File        string `json:"file"`
+	Line        int    `json:"line"`
+	Severity    string `json:"severity"`
+	Title       string `json:"title"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// JSONFileGrade 单个文件的质量分和等级
+type JSONFileGrade struct {
+	File   string `json:"file"`
+	Issues int    `json:"issues"`
+	Score  int    `json:"score"`
+	Grade  string `json:"grade"`
+}
+
+// JSONTimeBox 达到评审时限的说明
+type JSONTimeBox struct {
+	LimitMS int64    `json:"limit_ms"`
+	Skipped []string `json:"skipped_files"`
+}
+
+// JSONFailure 评审失败的文件
+type JSONFailure struct {
+	File       string `json:"file"`
+	Provider   string `json:"provider,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	// rate_limit、auth、client_error、server_error、timeout、network、invalid_response 或 unknown
+	ErrorClass string `json:"error_class"`
+	Retries    int    `json:"retries"`
+	Error      string `json:"error"`
+}
+
+// JSONCacheStatus 单个文件的评审缓存命中情况
+type JSONCacheStatus struct {
+	File string `json:"file"`
+	Hit  bool   `json:"hit"`
+	// 命中时缓存结果的生成时间和模型
+	CachedAt *time.Time `json:"cached_at,omitempty"`
+	Model    string     `json:"model,omitempty"`
+}
+
+// JSONAuthor 某位作者的改动和问题
+type JSONAuthor struct {
+	Author string      `json:"author"`
+	Files  []string    `json:"files"`
+	Issues []JSONIssue `json:"issues"`
+}
+
+// JSONCoverage 变更行覆盖率
+type JSONCoverage struct {
+	Total   int                `json:"total"`
+	Covered int                `json:"covered"`
+	Percent float64            `json:"percent"`
+	Files   []JSONFileCoverage `json:"files"`
+}
+
+// JSONFileCoverage 单个文件的变更行覆盖率
+type JSONFileCoverage struct {
+	File      string  `json:"file"`
+	Total     int     `json:"total"`
+	Covered   int     `json:"covered"`
+	Percent   float64 `json:"percent"`
+	Uncovered []int   `json:"uncovered_lines"`
+}
+
+// JSONTests 测试执行结果
+type JSONTests struct {
+	Command    string            `json:"command"`
+	Passed     bool              `json:"passed"`
+	DurationMS int64             `json:"duration_ms"`
+	Failures   []JSONTestFailure `json:"failures"`
+	Output     string            `json:"output,omitempty"`
+}
+
+// JSONTestFailure 失败的测试用例
+type JSONTestFailure struct {
+	Package string `json:"package"`
+	Test    string `json:"test,omitempty"`
+	Output  string `json:"output"`
+}
+
+// JSONImpact 被修改的包的影响范围
+type JSONImpact struct {
+	Package    string   `json:"package"`
+	Files      []string `json:"files"`
+	Direct     []string `json:"direct_dependents"`
+	Transitive []string `json:"transitive_dependents"`
+}
+
+// JSONComparison 与上次评审的对比
+type JSONComparison struct {
+	PreviousAt         time.Time      `json:"previous_at"`
+	PreviousScore      int            `json:"previous_score"`
+	Score              int            `json:"score"`
+	PreviousBySeverity map[string]int `json:"previous_by_severity"`
+	NewIssues          int            `json:"new_issues"`
+	PersistingIssues   int            `json:"persisting_issues"`
+	Resolved           []JSONIssue    `json:"resolved"`
+	// 新出现和两次都出现的问题的指纹，用于 cr render 还原对比
+	NewFingerprints        []string `json:"new_fingerprints,omitempty"`
+	PersistingFingerprints []string `json:"persisting_fingerprints,omitempty"`
+}
+
+// JSONSummary 评审结果统计
+type JSONSummary struct {
+	Files      int            `json:"files"`
+	Issues     int            `json:"issues"`
+	BySeverity map[string]int `json:"by_severity"`
+	// 各严重程度问题的占比（百分比）
+	BySeverityPercent map[string]float64 `json:"by_severity_percent"`
+	// 各类别的问题数
+	ByCategory map[string]int `json:"by_category,omitempty"`
+	// 质量分（满分100）和等级 A-F
+	Score int    `json:"score"`
+	Grade string `json:"grade"`
+	// 最高的严重程度，没有问题时省略
+	HighestSeverity string `json:"highest_severity,omitempty"`
+	// 以下字段来自评审过程的统计，离线渲染的报告中省略
+	Model             string  `json:"model,omitempty"`
+	ChangedLines      int     `json:"changed_lines,omitempty"`
+	IssuesPer100Lines float64 `json:"issues_per_100_lines,omitempty"`
+	DurationMS        int64   `json:"duration_ms,omitempty"`
+	PromptTokens      int     `json:"prompt_tokens,omitempty"`
+	CompletionTokens  int     `json:"completion_tokens,omitempty"`
+	CachedTokens      int     `json:"cached_tokens,omitempty"`
+	Cost              float64 `json:"cost,omitempty"`
+	CostSaved         float64 `json:"cost_saved,omitempty"`
+	Currency          string  `json:"currency,omitempty"`
+}
+
+// JSONIssue 单个问题
+type JSONIssue struct {
+	Title       string          `json:"title"`
+	File        string          `json:"file"`
+	Line        int             `json:"line"`
+	Severity    string          `json:"severity"`
+	Category    string          `json:"category,omitempty"`
+	Description string          `json:"description"`
+	Suggestion  string          `json:"suggestion,omitempty"`
+	CodeSnippet string          `json:"code_snippet,omitempty"`
+	References  []JSONReference `json:"references"`
+	Fingerprint string          `json:"fingerprint"`
+}
+
+// JSONReference 问题引用
+type JSONReference struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// generateJSON 生成JSON格式的报告
+func (r *DefaultReporter) generateJSON(issues []types.Issue) ([]byte, error) {
+	report := JSONReport{
+		SchemaVersion: JSONSchemaVersion,
+		Project:       r.ProjectName,
+		Commit:        r.CommitID,
+		GeneratedAt:   r.reviewTime(),
+		Summary: JSONSummary{
+			Files:             len(getUniqueFiles(issues)),
+			Issues:            len(issues),
+			BySeverity:        make(map[string]int),
+			BySeverityPercent: make(map[string]float64),
+			ByCategory:        make(map[string]int),
+		},
+		Issues: make([]JSONIssue, 0, len(issues)),
+	}
+
+	for _, issue := range issues {
+		report.Summary.BySeverity[string(issue.Severity)]++
+		report.Summary.ByCategory[string(issueCategory(issue))]++
+		report.Issues = append(report.Issues, newJSONIssue(issue))
+	}
+	report.Summary.Score = QualityScore(issues)
+	report.Summary.Grade = Grade(report.Summary.Score)
+	report.Summary.HighestSeverity = string(HighestSeverity(issues))
+	for _, fg := range FileGrades(issues) {
+		report.Files = append(report.Files, JSONFileGrade{File: fg.File, Issues: fg.Issues, Score: fg.Score, Grade: fg.Grade})
+	}
+	for severity, count := range report.Summary.BySeverity {
+		report.Summary.BySeverityPercent[severity] = math.Round(percentOf(count, len(issues))*10) / 10
+	}
+	if s := r.Stats; s != nil {
+		report.Summary.Model = s.Model
+		report.Summary.ChangedLines = s.ChangedLines
+		report.Summary.IssuesPer100Lines = math.Round(s.IssuesPer100Lines(len(issues))*100) / 100
+		report.Summary.DurationMS = s.Duration.Milliseconds()
+		report.Summary.PromptTokens = s.PromptTokens
+		report.Summary.CompletionTokens = s.CompletionTokens
+		report.Summary.Cost = s.Cost
+		report.Summary.CachedTokens = s.CachedTokens
+		report.Summary.CostSaved = s.CostSaved
+		report.Summary.Currency = s.Currency
+	}
+
+	if c := r.Comparison; c != nil {
+		comparison := &JSONComparison{
+			PreviousAt:         c.PreviousAt,
+			PreviousScore:      c.PreviousScore,
+			Score:              c.Score,
+			PreviousBySeverity: make(map[string]int),
+			NewIssues:          len(c.New),
+			PersistingIssues:   len(c.Persisting),
+			Resolved:           make([]JSONIssue, 0, len(c.Resolved)),
+		}
+		for severity, count := range c.PreviousCounts {
+			comparison.PreviousBySeverity[string(severity)] = count
+		}
+		for _, issue := range c.Resolved {
+			comparison.Resolved = append(comparison.Resolved, newJSONIssue(issue))
+		}
+		for _, issue := range c.New {
+			comparison.NewFingerprints = append(comparison.NewFingerprints, issueFingerprint(issue))
+		}
+		for _, issue := range c.Persisting {
+			comparison.PersistingFingerprints = append(comparison.PersistingFingerprints, issueFingerprint(issue))
+		}
+		report.Comparison = comparison
+	}
+
+	if r.Tests != nil {
+		tests := &JSONTests{
+			Command:    r.Tests.Command,
+			Passed:     r.Tests.Passed,
+			DurationMS: r.Tests.Duration.Milliseconds(),
+			Failures:   make([]JSONTestFailure, 0, len(r.Tests.Failures)),
+			Output:     r.Tests.Output,
+		}
+		for _, failure := range r.Tests.Failures {
+			tests.Failures = append(tests.Failures, JSONTestFailure{Package: failure.Package, Test: failure.Test, Output: failure.Output})
+		}
+		report.Tests = tests
+	}
+
+	if len(r.ChangeKinds) > 0 {
+		report.ChangeKinds = make(map[string]int, len(r.ChangeKinds))
+		for _, kc := range r.ChangeKinds {
+			report.ChangeKinds[string(kc.Kind)] = kc.Files
+		}
+	}
+
+	if s := r.Summary; s != nil {
+		report.ExecutiveSummary = &JSONExecutiveSummary{Overview: s.Overview, Risks: s.Risks, Decision: string(s.Decision)}
+		if report.ExecutiveSummary.Risks == nil {
+			report.ExecutiveSummary.Risks = []string{}
+		}
+		if tr := s.Translation; tr != nil {
+			report.ExecutiveSummary.Translation = &JSONSummaryTranslation{Lang: string(tr.Lang), Overview: tr.Overview, Risks: tr.Risks}
+			if tr.Risks == nil {
+				report.ExecutiveSummary.Translation.Risks = []string{}
+			}
+		}
+	}
+	for _, theme := range r.Themes {
+		jt := JSONTheme{Title: theme.Title, Summary: theme.Summary, Severity: string(theme.Severity)}
+		for _, issue := range theme.Issues {
+			jt.Issues = append(jt.Issues, JSONThemeIssue{
+				File:        issue.File,
+				Line:        issue.Line,
+				Severity:    string(issue.Severity),
+				Title:       issue.Title,
+				Fingerprint: issue.Fingerprint,
+			})
+		}
+		report.Themes = append(report.Themes, jt)
+	}
+
+	if tb := r.TimeBox; tb != nil {
+		report.TimeBox = &JSONTimeBox{LimitMS: tb.Limit.Milliseconds(), Skipped: tb.Skipped}
+	}
+	for _, f := range r.Failures {
+		report.Failures = append(report.Failures, JSONFailure{
+			File:       f.FilePath,
+			Provider:   f.Provider,
+			StatusCode: f.StatusCode,
+			ErrorClass: string(f.Class),
+			Retries:    f.Retries,
+			Error:      f.Message,
+		})
+	}
+	report.BinaryFiles = r.BinaryFiles
+	for _, c := range r.Cache {
+		status := JSONCacheStatus{File: c.FilePath, Hit: c.Hit, Model: c.Model}
+		if c.Hit {
+			cachedAt := c.CachedAt
+			status.CachedAt = &cachedAt
+		}
+		report.Cache = append(report.Cache, status)
+	}
+
+	if c := r.Coverage; c != nil {
+		cov := &JSONCoverage{
+			Total:   c.Total,
+			Covered: c.Covered,
+			Percent: c.Percent(),
+			Files:   make([]JSONFileCoverage, 0, len(c.Files)),
+		}
+		for _, fc := range c.Files {
+			cov.Files = append(cov.Files, JSONFileCoverage{
+				File:      fc.File,
+				Total:     fc.Total,
+				Covered:   fc.Covered,
+				Percent:   fc.Percent(),
+				Uncovered: fc.Uncovered,
+			})
+		}
+		report.Coverage = cov
+	}
+
+	for _, g := range r.Authors {
+		author := JSONAuthor{Author: g.Author, Files: g.Files, Issues: make([]JSONIssue, 0, len(g.Issues))}
+		for _, issue := range g.Issues {
+			author.Issues = append(author.Issues, newJSONIssue(issue))
+		}
+		report.Authors = append(report.Authors, author)
+	}
+
+	if d := r.Dependencies; d != nil {
+		report.Dependencies = &JSONDependencies{Overview: d.Overview, Changes: make([]JSONDependency, 0, len(d.Findings))}
+		for _, f := range d.Findings {
+			report.Dependencies.Changes = append(report.Dependencies.Changes, JSONDependency{
+				Manifest: f.Manifest, Ecosystem: f.Ecosystem, Name: f.Name, Change: string(f.Type),
+				From: f.From, To: f.To, Jump: string(f.Jump),
+				Risk: string(f.Risk), Breaking: f.Breaking, SupplyChain: f.SupplyChain,
+			})
+		}
+	}
+
+	for _, impact := range r.Impact {
+		report.Impact = append(report.Impact, JSONImpact{
+			Package:    impact.Package,
+			Files:      impact.Files,
+			Direct:     impact.Direct,
+			Transitive: impact.Transitive,
+		})
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// newJSONIssue 将问题转换为JSON结构
+func newJSONIssue(issue types.Issue) JSONIssue {
+	refs := make([]JSONReference, 0, len(issue.References))
+	for _, ref := range issue.References {
+		refs = append(refs, JSONReference{Title: ref.Title, URL: ref.URL})
+	}
+	return JSONIssue{
+		Title:       issue.Title,
+		File:        issue.FilePath,
+		Line:        issue.Line,
+		Severity:    string(issue.Severity),
+		Category:    string(issue.Category),
+		Description: issue.Description,
+		Suggestion:  issue.Suggestion,
+		CodeSnippet: issue.CodeSnippet,
+		References:  refs,
+		Fingerprint: issueFingerprint(issue),
+	}
+}
+
+// LoadJSONReport 读取 --format json 生成的报告，path 为 "-" 时从标准输入读取
+func LoadJSONReport(path string) (*JSONReport, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取JSON报告失败: %v", err)
+	}
+	return ParseJSONReport(data)
+}
+
+// ParseJSONReport 解析 JSON 报告
+func ParseJSONReport(data []byte) (*JSONReport, error) {
+	var report JSONReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("解析JSON报告失败: %v", err)
+	}
+	if report.SchemaVersion > JSONSchemaVersion {
+		return nil, fmt.Errorf("JSON报告版本 %d 高于当前支持的版本 %d，请升级 cr", report.SchemaVersion, JSONSchemaVersion)
+	}
+	return &report, nil
+}
+
+// ToIssues 将JSON报告中的问题还原为问题列表
+func (r *JSONReport) ToIssues() []types.Issue {
+	issues := make([]types.Issue, 0, len(r.Issues))
+	for _, issue := range r.Issues {
+		refs := make([]types.Reference, 0, len(issue.References))
+		for _, ref := range issue.References {
+			refs = append(refs, types.Reference{Title: ref.Title, URL: ref.URL})
+		}
+		issues = append(issues, types.Issue{
+			Title:       issue.Title,
+			FilePath:    issue.File,
+			Line:        issue.Line,
+			Severity:    types.SeverityLevel(issue.Severity),
+			Category:    types.IssueCategory(issue.Category),
+			Description: issue.Description,
+			Suggestion:  issue.Suggestion,
+			CodeSnippet: issue.CodeSnippet,
+			References:  refs,
+			Fingerprint: issue.Fingerprint,
+		})
+	}
+	return issues
+}
@@ -0,0 +1,113 @@
+package review
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// 默认的单次评审上限
+const (
+	DefaultMaxFiles    = 100
+	DefaultMaxDiffSize = 2 << 20
+)
+
+// Limits 单次评审的安全上限，字段为0表示不限制
+type Limits struct {
+	MaxFiles    int   // 最多评审的文件数
+	MaxDiffSize int64 // 所有文件差异内容的总字节数上限
+}
+
+// SkippedChange 因超出上限而被跳过的文件
+type SkippedChange struct {
+	FilePath string
+	DiffSize int
+	Reason   string
+}
+
+// ApplyLimits 按输入顺序筛选文件改动，超出上限的文件会被跳过
+// 单个文件超出剩余字节预算时跳过该文件，但仍继续尝试后面较小的文件
+func ApplyLimits(changes []types.FileChange, limits Limits) ([]types.FileChange, []SkippedChange) {
+	kept := make([]types.FileChange, 0, len(changes))
+	var skipped []SkippedChange
+	var total int64
+
+	for _, change := range changes {
+		size := len(change.DiffContent)
+		switch {
+		case limits.MaxFiles > 0 && len(kept) >= limits.MaxFiles:
+			skipped = append(skipped, SkippedChange{
+				FilePath: change.FilePath,
+				DiffSize: size,
+				Reason:   fmt.Sprintf("超出文件数上限 %d", limits.MaxFiles),
+			})
+		case limits.MaxDiffSize > 0 && total+int64(size) > limits.MaxDiffSize:
+			skipped = append(skipped, SkippedChange{
+				FilePath: change.FilePath,
+				DiffSize: size,
+				Reason:   fmt.Sprintf("超出差异总大小上限 %s", FormatSize(limits.MaxDiffSize)),
+			})
+		default:
+			kept = append(kept, change)
+			total += int64(size)
+		}
+	}
+	return kept, skipped
+}
+
+// SkippedSummary 生成被跳过文件的摘要
+func SkippedSummary(skipped []SkippedChange) string {
+	if len(skipped) == 0 {
+		return ""
+	}
+
+	var total int64
+	var buf strings.Builder
+	for _, s := range skipped {
+		total += int64(s.DiffSize)
+		buf.WriteString(fmt.Sprintf("  - %s (%s): %s\n", s.FilePath, FormatSize(int64(s.DiffSize)), s.Reason))
+	}
+	return fmt.Sprintf("已跳过 %d 个文件，共 %s 差异内容，可通过 --max-files / --max-diff-size 调整上限：\n%s",
+		len(skipped), FormatSize(total), buf.String())
+}
+
+// ParseSize 解析带单位的字节大小，如 "512KB"、"2MB"、"1048576"
+func ParseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	units := []struct {
+		suffix string
+		factor int64
+	}{
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+	}
+
+	factor := int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, u.suffix))
+			factor = u.factor
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("无效的大小: %s", s)
+	}
+	return int64(n * float64(factor)), nil
+}
+
+// FormatSize 将字节数格式化为易读的形式
+func FormatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
@@ -0,0 +1,150 @@
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// lockRefreshInterval 持有者刷新锁文件修改时间的间隔
+	lockRefreshInterval = 5 * time.Second
+	// lockStaleAge 锁文件超过该时间未刷新时视为持有进程已异常退出
+	lockStaleAge = 30 * time.Second
+	// lockPollInterval 等待锁释放时的检查间隔
+	lockPollInterval = 500 * time.Millisecond
+)
+
+// ErrLockTimeout 等待其他评审释放锁超时
+var ErrLockTimeout = errors.New("等待仓库中的其他评审结束超时")
+
+// LockHolder 锁文件中记录的持有者信息
+type LockHolder struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// RunLock 仓库级的评审锁
+// 编辑器触发的钩子和手动执行的评审同时运行时，后启动的评审等待前一个结束，再从评审缓存中复用结果，避免重复调用模型
+// 持有者定期刷新锁文件的修改时间，进程异常退出后锁在 lockStaleAge 后失效
+type RunLock struct {
+	path string
+	stop chan struct{}
+	done chan struct{}
+}
+
+// AcquireRunLock 获取 path 处的评审锁，其他评审持有锁时最多等待 timeout
+// 开始等待时调用一次 onWait；超时返回 ErrLockTimeout
+func AcquireRunLock(path string, timeout time.Duration, onWait func(LockHolder)) (*RunLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建锁目录失败: %v", err)
+	}
+	holder := LockHolder{PID: os.Getpid(), StartedAt: time.Now()}
+	holder.Host, _ = os.Hostname()
+	data, _ := json.Marshal(holder)
+
+	err := waitLock(path, timeout, onWait, func() (bool, error) {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("创建锁文件失败: %v", err)
+		}
+		_, err = f.Write(data)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(path)
+			return false, fmt.Errorf("写入锁文件失败: %v", err)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	l := &RunLock{path: path, stop: make(chan struct{}), done: make(chan struct{})}
+	go l.refresh()
+	return l, nil
+}
+
+// WaitRunLock 等待 path 处的评审锁被释放，不获取锁，用于不能写入仓库的只读模式
+func WaitRunLock(path string, timeout time.Duration, onWait func(LockHolder)) error {
+	return waitLock(path, timeout, onWait, func() (bool, error) {
+		_, err := os.Stat(path)
+		return os.IsNotExist(err), nil
+	})
+}
+
+// waitLock 反复调用 try 直到成功，期间清理失效的锁
+func waitLock(path string, timeout time.Duration, onWait func(LockHolder), try func() (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+	waiting := false
+	for {
+		ok, err := try()
+		if err != nil || ok {
+			return err
+		}
+		info, err := os.Stat(path)
+		if err == nil && time.Since(info.ModTime()) > lockStaleAge {
+			os.Remove(path)
+			continue
+		}
+		if !waiting {
+			waiting = true
+			if onWait != nil {
+				onWait(readLockHolder(path))
+			}
+		}
+		if !time.Now().Before(deadline) {
+			return ErrLockTimeout
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// readLockHolder 读取锁文件中的持有者信息，读取失败时返回空值
+func readLockHolder(path string) LockHolder {
+	var holder LockHolder
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &holder)
+	}
+	return holder
+}
+
+// refresh 定期刷新锁文件的修改时间，直到锁被释放
+func (l *RunLock) refresh() {
+	defer close(l.done)
+	ticker := time.NewTicker(lockRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			os.Chtimes(l.path, now, now)
+		}
+	}
+}
+
+// Release 释放锁，可以重复调用
+func (l *RunLock) Release() {
+	if l == nil {
+		return
+	}
+	select {
+	case <-l.stop:
+		return
+	default:
+	}
+	close(l.stop)
+	<-l.done
+	os.Remove(l.path)
+}
@@ -0,0 +1,113 @@
+package review
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// 模型输出中常见的 Markdown 行内语法，匹配在转义之后的文本上进行
+var (
+	mdBold = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	mdLink = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	// 有序和无序列表项
+	mdListItem = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+`)
+)
+
+// safeURL 判断链接是否可以放入 href，只允许 http、https 和 mailto，避免 javascript: 等协议在报告中执行
+func safeURL(url string) bool {
+	lower := strings.ToLower(strings.TrimSpace(url))
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "mailto:")
+}
+
+// renderInline 将一行文本中的行内 Markdown 转换为HTML，其余内容全部转义
+// 支持 `代码`、**加粗** 和 [链接](url)
+func renderInline(text string) string {
+	var buf strings.Builder
+	parts := strings.Split(text, "`")
+	for i, part := range parts {
+		// 奇数段位于反引号之间；缺少闭合反引号时按普通文本处理
+		if i%2 == 1 && i < len(parts)-1 {
+			buf.WriteString("<code>" + html.EscapeString(part) + "</code>")
+			continue
+		}
+		if i%2 == 1 {
+			buf.WriteString("`")
+		}
+		escaped := html.EscapeString(part)
+		escaped = mdBold.ReplaceAllString(escaped, "<strong>$1</strong>")
+		escaped = mdLink.ReplaceAllStringFunc(escaped, func(m string) string {
+			sub := mdLink.FindStringSubmatch(m)
+			if !safeURL(html.UnescapeString(sub[2])) {
+				return m
+			}
+			return `<a href="` + sub[2] + `" target="_blank" rel="noopener noreferrer">` + sub[1] + `</a>`
+		})
+		buf.WriteString(escaped)
+	}
+	return buf.String()
+}
+
+// renderMarkdown 将模型输出的 Markdown 文本安全地转换为HTML
+// 只支持段落、列表、代码块和行内语法，原文中的HTML标签一律转义后显示
+func renderMarkdown(text string) string {
+	var buf strings.Builder
+	var paragraph []string
+	listTag := ""
+
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			buf.WriteString("<p>" + strings.Join(paragraph, "<br>") + "</p>")
+			paragraph = nil
+		}
+	}
+	closeList := func() {
+		if listTag != "" {
+			buf.WriteString("</" + listTag + ">")
+			listTag = ""
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t\r")
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flushParagraph()
+			closeList()
+			lang := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			class := ""
+			if lang != "" {
+				class = ` class="language-` + html.EscapeString(lang) + `"`
+			}
+			buf.WriteString(`<pre class="code"><code` + class + `>` + html.EscapeString(strings.Join(code, "\n")) + `</code></pre>`)
+		case trimmed == "":
+			flushParagraph()
+			closeList()
+		case mdListItem.MatchString(line):
+			flushParagraph()
+			tag := "ul"
+			if c := trimmed[0]; c >= '0' && c <= '9' {
+				tag = "ol"
+			}
+			if listTag != tag {
+				closeList()
+				buf.WriteString("<" + tag + ">")
+				listTag = tag
+			}
+			buf.WriteString("<li>" + renderInline(mdListItem.ReplaceAllString(line, "")) + "</li>")
+		default:
+			closeList()
+			paragraph = append(paragraph, renderInline(trimmed))
+		}
+	}
+	flushParagraph()
+	closeList()
+	return buf.String()
+}
@@ -0,0 +1,173 @@
+package review
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// modelIssue 模型结构化输出中的单个问题
+type modelIssue struct {
+	Title       string           `json:"title"`
+	Line        int              `json:"line"`
+	Severity    string           `json:"severity"`
+	Category    string           `json:"category"`
+	Description string           `json:"description"`
+	Suggestion  string           `json:"suggestion"`
+	References  []modelReference `json:"references"`
+}
+
+// modelReference 模型输出的引用，既可以是对象也可以是纯字符串
+type modelReference struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// UnmarshalJSON 兼容 "CWE-89" 这样的纯字符串引用
+func (r *modelReference) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		if strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") {
+			r.URL = s
+		} else {
+			r.Title = s
+		}
+		return nil
+	}
+
+	type plain modelReference
+	return json.Unmarshal(data, (*plain)(r))
+}
+
+// ParseIssues 解析模型的评审输出
+// 优先按结构化JSON解析；无法解析时把整段输出作为一个问题返回，标题取输出的第一句
+// 模型没有给出标题时从描述中提取，都提取不到时使用 fallbackTitle
+func ParseIssues(filePath, content, fallbackTitle string) []types.Issue {
+	var parsed struct {
+		Issues []modelIssue `json:"issues"`
+	}
+	if raw := extractJSON(content); raw == "" || json.Unmarshal([]byte(raw), &parsed) != nil {
+		title := SummarizeTitle(content)
+		if title == "" {
+			title = fallbackTitle
+		}
+		return []types.Issue{{
+			Title:       title,
+			FilePath:    filePath,
+			Description: content,
+			Severity:    types.SeverityInfo,
+		}}
+	}
+
+	issues := make([]types.Issue, 0, len(parsed.Issues))
+	for _, mi := range parsed.Issues {
+		refs := make([]types.Reference, 0, len(mi.References))
+		for _, r := range mi.References {
+			refs = append(refs, types.Reference{Title: r.Title, URL: r.URL})
+		}
+
+		title := truncateTitle(strings.Join(strings.Fields(mi.Title), " "))
+		if title == "" {
+			title = SummarizeTitle(mi.Description)
+		}
+		if title == "" {
+			title = fallbackTitle
+		}
+		issues = append(issues, types.Issue{
+			Title:       title,
+			FilePath:    filePath,
+			Line:        mi.Line,
+			Severity:    normalizeSeverity(mi.Severity),
+			Category:    NormalizeCategory(mi.Category),
+			Description: strings.TrimSpace(mi.Description),
+			Suggestion:  strings.TrimSpace(mi.Suggestion),
+			References:  NormalizeReferences(refs),
+		})
+	}
+	return issues
+}
+
+// extractJSON 从模型输出中提取JSON对象，兼容 ```json 代码块包裹和前后多余文字
+func extractJSON(content string) string {
+	content = strings.TrimSpace(content)
+	if start := strings.Index(content, "```"); start >= 0 {
+		body := content[start+3:]
+		body = strings.TrimPrefix(body, "json")
+		if end := strings.Index(body, "```"); end >= 0 {
+			content = strings.TrimSpace(body[:end])
+		}
+	}
+
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return ""
+	}
+	return content[start : end+1]
+}
+
+// normalizeSeverity 将模型输出的严重程度映射到统一的级别
+func normalizeSeverity(severity string) types.SeverityLevel {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "error", "critical", "high", "blocker", "严重", "错误":
+		return types.SeverityError
+	case "warning", "warn", "medium", "major", "警告":
+		return types.SeverityWarning
+	default:
+		return types.SeverityInfo
+	}
+}
+
+// ParseChangeKind 解析模型输出中对改动类型的判断，未给出或无法识别时返回空字符串
+func ParseChangeKind(content string) types.ChangeKind {
+	var parsed struct {
+		ChangeKind string `json:"change_kind"`
+	}
+	if raw := extractJSON(content); raw == "" || json.Unmarshal([]byte(raw), &parsed) != nil {
+		return ""
+	}
+	return NormalizeChangeKind(parsed.ChangeKind)
+}
+
+// NormalizeChangeKind 将改动类型规范化，无法识别时返回空字符串
+func NormalizeChangeKind(kind string) types.ChangeKind {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "feature", "feat", "新功能", "功能":
+		return types.KindFeature
+	case "bugfix", "fix", "bug", "缺陷修复", "修复":
+		return types.KindBugfix
+	case "refactor", "refactoring", "重构":
+		return types.KindRefactor
+	case "test", "tests", "测试":
+		return types.KindTest
+	case "docs", "doc", "documentation", "文档":
+		return types.KindDocs
+	case "config", "configuration", "配置":
+		return types.KindConfig
+	case "dependency", "dependencies", "deps", "依赖":
+		return types.KindDependency
+	default:
+		return ""
+	}
+}
+
+// NormalizeCategory 将模型输出的问题类别映射到统一的类别，无法识别时返回 other
+func NormalizeCategory(category string) types.IssueCategory {
+	switch strings.ToLower(strings.TrimSpace(strings.ReplaceAll(category, "-", "_"))) {
+	case "security", "安全", "安全性":
+		return types.CategorySecurity
+	case "bug", "correctness", "logic", "error_handling", "缺陷", "正确性", "错误处理":
+		return types.CategoryBug
+	case "performance", "perf", "性能":
+		return types.CategoryPerformance
+	case "maintainability", "quality", "readability", "style", "naming", "documentation", "可维护性", "代码质量", "可读性", "命名", "注释":
+		return types.CategoryMaintainability
+	case "best_practice", "best_practices", "bestpractice", "最佳实践":
+		return types.CategoryBestPractice
+	case "test", "tests", "testing", "coverage", "测试", "测试覆盖":
+		return types.CategoryTest
+	default:
+		return types.CategoryOther
+	}
+}
@@ -0,0 +1,31 @@
+package review
+
+import "time"
+
+// FileStatus 文件评审状态
+type FileStatus string
+
+const (
+	StatusReviewing FileStatus = "reviewing"
+	StatusCached    FileStatus = "cached"
+	StatusResumed   FileStatus = "resumed"
+	StatusDone      FileStatus = "done"
+	StatusFailed    FileStatus = "failed"
+	// 达到评审时限，未发起模型调用
+	StatusSkipped FileStatus = "skipped"
+)
+
+// IsFinished 判断该状态是否表示文件评审已结束
+func (s FileStatus) IsFinished() bool {
+	return s == StatusCached || s == StatusResumed || s == StatusDone || s == StatusFailed || s == StatusSkipped
+}
+
+// ProgressInfo 评审进度事件
+type ProgressInfo struct {
+	FilePath  string        // 文件路径
+	Status    FileStatus    // 当前状态
+	Completed int           // 已完成的文件数（包含本事件）
+	Total     int           // 文件总数
+	Elapsed   time.Duration // 该文件评审耗时，仅在结束状态时有效
+	Err       error         // 失败原因，仅在 StatusFailed 时有效
+}
@@ -0,0 +1,118 @@
+package review
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// reviewdog Diagnostic Format 的结构
+// 参见 https://github.com/reviewdog/reviewdog/tree/master/proto/rdf
+type rdDiagnosticResult struct {
+	Source      rdSource       `json:"source"`
+	Diagnostics []rdDiagnostic `json:"diagnostics"`
+}
+
+type rdDiagnostic struct {
+	Message  string     `json:"message"`
+	Location rdLocation `json:"location"`
+	Severity string     `json:"severity"`
+	Source   rdSource   `json:"source"`
+	Code     *rdCode    `json:"code,omitempty"`
+	// rdf 中没有指纹字段，以 "fingerprint: xxx" 的形式放在原始输出中，reviewdog 不会展示
+	OriginalOutput string `json:"original_output,omitempty"`
+}
+
+type rdSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type rdLocation struct {
+	Path  string  `json:"path"`
+	Range rdRange `json:"range"`
+}
+
+type rdRange struct {
+	Start rdPosition `json:"start"`
+}
+
+type rdPosition struct {
+	Line int `json:"line"`
+}
+
+type rdCode struct {
+	Value string `json:"value"`
+	URL   string `json:"url,omitempty"`
+}
+
+// rdSourceName reviewdog 中显示的工具名称
+const rdSourceName = "ai-cr-tool"
+
+// newRDDiagnostic 将问题转换为 reviewdog 诊断
+func newRDDiagnostic(issue types.Issue) rdDiagnostic {
+	message := strings.TrimSpace(issue.Title)
+	if desc := strings.TrimSpace(issue.Description); desc != "" {
+		message += "\n\n" + desc
+	}
+	if suggestion := strings.TrimSpace(issue.Suggestion); suggestion != "" {
+		message += "\n\n" + suggestion
+	}
+
+	severity := "INFO"
+	switch issue.Severity {
+	case types.SeverityError:
+		severity = "ERROR"
+	case types.SeverityWarning:
+		severity = "WARNING"
+	}
+
+	line := issue.Line
+	if line < 1 {
+		line = 1
+	}
+
+	diagnostic := rdDiagnostic{
+		Message:        message,
+		Location:       rdLocation{Path: issue.FilePath, Range: rdRange{Start: rdPosition{Line: line}}},
+		Severity:       severity,
+		Source:         rdSource{Name: rdSourceName},
+		OriginalOutput: "fingerprint: " + issueFingerprint(issue),
+	}
+	// 以第一条引用作为规则编号，如 CWE-89
+	if len(issue.References) > 0 {
+		diagnostic.Code = &rdCode{Value: issue.References[0].Title, URL: issue.References[0].URL}
+	}
+	return diagnostic
+}
+
+// generateRDJSON 生成 reviewdog rdjson 格式的报告
+func (r *DefaultReporter) generateRDJSON(issues []types.Issue) ([]byte, error) {
+	result := rdDiagnosticResult{
+		Source:      rdSource{Name: rdSourceName},
+		Diagnostics: make([]rdDiagnostic, 0, len(issues)),
+	}
+	for _, issue := range issues {
+		result.Diagnostics = append(result.Diagnostics, newRDDiagnostic(issue))
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// generateRDJSONL 生成 reviewdog rdjsonl 格式的报告，每行一个诊断
+func (r *DefaultReporter) generateRDJSONL(issues []types.Issue) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, issue := range issues {
+		if err := enc.Encode(newRDDiagnostic(issue)); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,100 @@
+package review
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+var (
+	cwePattern   = regexp.MustCompile(`(?i)\bCWE[-\s]?(\d{1,4})\b`)
+	owaspPattern = regexp.MustCompile(`(?i)\bA(\d{2}):?(2017|2021)\b`)
+)
+
+// owasp2021Slugs OWASP Top 10 2021 各条目的页面路径
+var owasp2021Slugs = map[string]string{
+	"01": "A01_2021-Broken_Access_Control",
+	"02": "A02_2021-Cryptographic_Failures",
+	"03": "A03_2021-Injection",
+	"04": "A04_2021-Insecure_Design",
+	"05": "A05_2021-Security_Misconfiguration",
+	"06": "A06_2021-Vulnerable_and_Outdated_Components",
+	"07": "A07_2021-Identification_and_Authentication_Failures",
+	"08": "A08_2021-Software_and_Data_Integrity_Failures",
+	"09": "A09_2021-Security_Logging_and_Monitoring_Failures",
+	"10": "A10_2021-Server-Side_Request_Forgery_%28SSRF%29",
+}
+
+// NormalizeReferences 校验并规范化问题引用
+// 能识别的 CWE、OWASP、Effective Go 引用统一生成标准链接；无法识别且链接无效的引用会被丢弃，重复的引用只保留一个
+func NormalizeReferences(refs []types.Reference) []types.Reference {
+	seen := make(map[string]bool)
+	result := make([]types.Reference, 0, len(refs))
+	for _, ref := range refs {
+		normalized, ok := normalizeReference(ref)
+		if !ok {
+			continue
+		}
+		key := strings.ToLower(normalized.URL)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, normalized)
+	}
+	return result
+}
+
+// normalizeReference 规范化单个引用
+func normalizeReference(ref types.Reference) (types.Reference, bool) {
+	title := strings.TrimSpace(ref.Title)
+	link := strings.TrimSpace(ref.URL)
+	text := title + " " + link
+
+	// CWE 编号统一指向 MITRE 官方页面
+	if m := cwePattern.FindStringSubmatch(text); m != nil {
+		id := strings.TrimLeft(m[1], "0")
+		if title == "" || strings.EqualFold(title, m[0]) {
+			title = "CWE-" + id
+		}
+		return types.Reference{
+			Title: title,
+			URL:   fmt.Sprintf("https://cwe.mitre.org/data/definitions/%s.html", id),
+		}, true
+	}
+
+	// OWASP Top 10 2021 条目
+	if m := owaspPattern.FindStringSubmatch(text); m != nil && m[2] == "2021" && strings.Contains(strings.ToLower(text), "owasp") {
+		if slug, ok := owasp2021Slugs[m[1]]; ok {
+			if title == "" {
+				title = "OWASP " + strings.ReplaceAll(slug, "_", " ")
+			}
+			return types.Reference{Title: title, URL: "https://owasp.org/Top10/" + slug + "/"}, true
+		}
+	}
+
+	// Effective Go 章节，如 "Effective Go#errors"
+	if link == "" && strings.HasPrefix(strings.ToLower(title), "effective go") {
+		anchor := ""
+		if _, section, found := strings.Cut(title, "#"); found {
+			anchor = "#" + strings.ToLower(strings.ReplaceAll(strings.TrimSpace(section), " ", "_"))
+		}
+		return types.Reference{Title: title, URL: "https://go.dev/doc/effective_go" + anchor}, true
+	}
+
+	// 其他引用必须是有效的 http(s) 链接
+	u, err := url.Parse(link)
+	if link == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return types.Reference{}, false
+	}
+	if u.Host == "golang.org" {
+		u.Host = "go.dev"
+	}
+	if title == "" {
+		title = u.Host + u.Path
+	}
+	return types.Reference{Title: title, URL: u.String()}, true
+}
@@ -3,12 +3,21 @@ package review
 import (
 	"bytes"
 	"fmt"
+	"html"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"sort"
 	"strings"
+	"text/template"
 	"time"
 
+	"github.com/icatw/ai-cr-tool/pkg/coverage"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/impact"
+	"github.com/icatw/ai-cr-tool/pkg/model"
+	"github.com/icatw/ai-cr-tool/pkg/testrun"
+	"github.com/icatw/ai-cr-tool/pkg/tracing"
 	"github.com/icatw/ai-cr-tool/pkg/types"
 )
 
@@ -19,6 +28,20 @@ const (
 	MarkdownFormat ReportFormat = "markdown"
 	HTMLFormat     ReportFormat = "html"
 	PDFFormat      ReportFormat = "pdf"
+	JSONFormat     ReportFormat = "json"
+	TerminalFormat ReportFormat = "terminal"
+	// GitLab Code Quality 报告，用于在 MR 中显示代码质量组件
+	CodeQualityFormat ReportFormat = "codequality"
+	// reviewdog 诊断格式，可由 reviewdog 发布为 PR 行内评论
+	RDJSONFormat  ReportFormat = "rdjson"
+	RDJSONLFormat ReportFormat = "rdjsonl"
+	// 使用 --report-template 指定的 text/template 模板生成
+	TemplateFormat ReportFormat = "template"
+	// 显示质量分、等级和问题数的 SVG 徽章，以及 shields.io endpoint 格式的徽章数据
+	BadgeFormat     ReportFormat = "badge"
+	BadgeJSONFormat ReportFormat = "badge-json"
+	// 可折叠的 GitHub Markdown，长度不超过 GitHub 评论上限，适合作为 PR 评论
+	GitHubMarkdownFormat ReportFormat = "markdown-github"
 )
 
 // Reporter 定义报告生成器接口
@@ -30,26 +53,107 @@ type Reporter interface {
 type DefaultReporter struct {
 	ProjectName string
 	CommitID    string
+	// 报告标题、统计表格等固定文本使用的语言
+	Lang i18n.Lang
+	// 与上次评审的对比，为 nil 时不输出对比内容
+	Comparison *Comparison
+	// Go 仓库中被修改的包的影响范围，为空时不输出
+	Impact []impact.PackageImpact
+	// 测试执行结果，为 nil 时不输出
+	Tests *testrun.Result
+	// 变更行覆盖率，为 nil 时不输出
+	Coverage *coverage.Report
+	// 自定义报告模板，用于 template 格式
+	Template *template.Template
+	// 按作者分组的问题，为空时不输出
+	Authors []AuthorIssues
+	// HTML 报告从 CDN 加载代码高亮脚本，默认内联内置资源以便离线查看
+	CDNAssets bool
+	// 评审过程的统计信息，为 nil 时只输出问题统计
+	Stats *ReviewStats
+	// 达到评审时限时的说明，为 nil 表示评审完整
+	TimeBox *TimeBox
+	// 重试之后仍评审失败的文件，为空时不输出
+	Failures []FailedFile
+	// 跳过的二进制文件，为空时不输出
+	BinaryFiles []string
+	// 汇总所有问题生成的执行摘要，为 nil 时不输出
+	Summary *ExecutiveSummary
+	// 归纳问题得到的主题，为空时不输出
+	Themes []Theme
+	// 各改动类型的文件数，为空时不输出
+	ChangeKinds []KindCount
+	// 依赖清单文件中的依赖变更及风险评估，为 nil 时不输出
+	Dependencies *DependencyReview
+	// 代码片段每行的最大显示宽度，超出部分折行，0 表示不限制
+	SnippetWidth int
+	// 完整报告的链接，markdown-github 格式附在评论末尾
+	ReportURL string
+	// 本次评审的文件改动，用于在问题下展示所在的改动块，为空时只展示代码片段
+	Changes []types.FileChange
+	// 发现问题的模型，写入行内评论的隐藏标记，为空时不写
+	Model string
+	// Markdown 报告和 PR 评论中问题标题的样式，为空时使用默认样式
+	CommentStyle CommentStyle
+	// 各文件的评审缓存命中情况，有文件命中缓存时在报告末尾附上
+	Cache []CacheStatus
+	// 评审时间，为零值时使用生成报告的时间；从保存的评审结果重新生成报告时为原评审时间
+	ReviewedAt time.Time
+	// 链路中的上级 span，每次生成报告记录为其子 span；为 nil 时不记录
+	Span *tracing.Span
 }
 
-// NewReporter 创建新的报告生成器
+// reviewTime 返回报告中显示的评审时间
+func (r *DefaultReporter) reviewTime() time.Time {
+	if r.ReviewedAt.IsZero() {
+		return time.Now()
+	}
+	return r.ReviewedAt
+}
+
+// NewReporter 创建新的报告生成器，使用默认语言
 func NewReporter(projectName, commitID string) Reporter {
+	return NewReporterWithLang(projectName, commitID, i18n.Default)
+}
+
+// NewReporterWithLang 创建使用指定语言的报告生成器
+func NewReporterWithLang(projectName, commitID string, lang i18n.Lang) *DefaultReporter {
 	return &DefaultReporter{
-		ProjectName: projectName,
-		CommitID:    commitID,
+		ProjectName:  projectName,
+		CommitID:     commitID,
+		Lang:         lang,
+		SnippetWidth: DefaultSnippetWidth,
 	}
 }
 
 // generateMarkdown 生成Markdown格式的报告
 func (r *DefaultReporter) generateMarkdown(issues []types.Issue) ([]byte, error) {
 	var buf bytes.Buffer
+	t := r.Lang.T
 
 	// 写入报告头部
-	buf.WriteString(fmt.Sprintf("# 代码评审报告\n\n"))
-	buf.WriteString(fmt.Sprintf("## 项目信息\n\n"))
-	buf.WriteString(fmt.Sprintf("- 项目名称：%s\n", r.ProjectName))
-	buf.WriteString(fmt.Sprintf("- 提交ID：%s\n", r.CommitID))
-	buf.WriteString(fmt.Sprintf("- 评审时间：%s\n\n", time.Now().Format("2006-01-02 15:04:05")))
+	buf.WriteString(fmt.Sprintf("# %s\n\n", t("report.title")))
+	buf.WriteString(fmt.Sprintf("## %s\n\n", t("report.project_info")))
+	buf.WriteString(fmt.Sprintf("- %s%s\n", t("report.project_name"), r.ProjectName))
+	buf.WriteString(fmt.Sprintf("- %s%s\n", t("report.commit_id"), r.CommitID))
+	buf.WriteString(fmt.Sprintf("- %s%s\n\n", t("report.review_time"), r.reviewTime().Format("2006-01-02 15:04:05")))
+
+	// 达到评审时限时提示报告只包含部分结果
+	if r.TimeBox != nil {
+		r.writeMarkdownTimeBox(&buf)
+	}
+	if len(r.Failures) > 0 {
+		r.writeMarkdownFailures(&buf)
+	}
+	if len(r.BinaryFiles) > 0 {
+		r.writeMarkdownBinary(&buf)
+	}
+	if r.Summary != nil {
+		r.writeMarkdownSummary(&buf)
+	}
+	if len(r.Themes) > 0 {
+		r.writeMarkdownThemes(&buf)
+	}
 
 	// 按严重程度分类统计
 	severityCount := make(map[types.SeverityLevel]int)
@@ -58,105 +162,152 @@ func (r *DefaultReporter) generateMarkdown(issues []types.Issue) ([]byte, error)
 	}
 
 	// 写入统计信息
-	buf.WriteString("## 评审结果统计\n\n")
+	buf.WriteString(fmt.Sprintf("## %s\n\n", t("report.stats")))
 
 	// 添加代码统计信息
-	buf.WriteString("### 代码变更统计\n\n")
-	buf.WriteString("| 指标 | 数值 |\n")
+	buf.WriteString(fmt.Sprintf("### %s\n\n", t("report.change_stats")))
+	buf.WriteString(fmt.Sprintf("| %s | %s |\n", t("report.metric"), t("report.value")))
 	buf.WriteString("|------|---------|\n")
-	buf.WriteString(fmt.Sprintf("| 评审文件数 | %d |\n", len(getUniqueFiles(issues))))
-	buf.WriteString(fmt.Sprintf("| 问题总数 | %d |\n", len(issues)))
+	for _, row := range r.statRows(issues) {
+		buf.WriteString(fmt.Sprintf("| %s | %s |\n", row[0], row[1]))
+	}
 
 	// 写入严重程度统计
-	buf.WriteString("\n### 问题严重程度分布\n\n")
-	buf.WriteString("| 严重程度 | 数量 |\n")
-	buf.WriteString("|---------|---------|\n")
-	for severity, count := range severityCount {
-		buf.WriteString(fmt.Sprintf("| %s | %d |\n", string(severity), count))
+	buf.WriteString(fmt.Sprintf("\n### %s\n\n", t("report.severity_distribution")))
+	buf.WriteString(fmt.Sprintf("| %s | %s | %s |\n", t("report.severity_column"), t("report.count"), t("report.percent_column")))
+	buf.WriteString("|---------|---------|---------|\n")
+	for _, severity := range severityOrder {
+		if count := severityCount[severity]; count > 0 {
+			buf.WriteString(fmt.Sprintf("| %s | %s | %s |\n", string(severity), r.Lang.Number(count), r.Lang.Percent(percentOf(count, len(issues)))))
+		}
 	}
 	buf.WriteString("\n")
 
+	// 写入改动类型分布
+	if len(r.ChangeKinds) > 0 {
+		r.writeMarkdownKinds(&buf)
+	}
+
+	// 写入与上次评审的对比
+	if r.Comparison != nil {
+		r.writeMarkdownComparison(&buf)
+	}
+
+	// 写入改动的影响范围
+	if len(r.Impact) > 0 {
+		r.writeMarkdownImpact(&buf)
+	}
+
+	// 写入依赖变更
+	if r.Dependencies != nil {
+		r.writeMarkdownDependencies(&buf)
+	}
+
+	// 写入测试结果
+	if r.Tests != nil {
+		r.writeMarkdownTests(&buf)
+	}
+
+	// 写入变更行覆盖率
+	if r.Coverage != nil {
+		r.writeMarkdownCoverage(&buf)
+	}
+
+	// 写入按作者分组的问题
+	if len(r.Authors) > 0 {
+		r.writeMarkdownAuthors(&buf)
+	}
+
 	// 写入优化建议总结
-	buf.WriteString("## 整体优化建议\n\n")
+	buf.WriteString(fmt.Sprintf("## %s\n\n", t("report.overall_suggestions")))
 	suggestions := summarizeSuggestions(issues)
 	for _, suggestion := range suggestions {
-		buf.WriteString(fmt.Sprintf("- %s\n", suggestion))
+		buf.WriteString(fmt.Sprintf("- %s\n", strings.TrimPrefix(indentListItem(suggestion), "  ")))
 	}
 	buf.WriteString("\n")
 
-	// 写入详细问题列表
-	buf.WriteString("## 详细问题列表\n\n")
-	for i, issue := range issues {
-		buf.WriteString(fmt.Sprintf("### %d. %s\n\n", i+1, issue.Title))
-		buf.WriteString(fmt.Sprintf("- 文件：`%s`\n", issue.FilePath))
-		buf.WriteString(fmt.Sprintf("- 位置：第%d行\n", issue.Line))
-		buf.WriteString(fmt.Sprintf("- 严重程度：**%s**\n", issue.Severity))
-		buf.WriteString(fmt.Sprintf("- 描述：%s\n", issue.Description))
-		if issue.Suggestion != "" {
-			buf.WriteString(fmt.Sprintf("- 建议：> %s\n", issue.Suggestion))
-		}
-		buf.WriteString("\n")
-
-		// 添加代码片段（如果有）
-		if issue.CodeSnippet != "" {
-			// 获取代码片段的上下文
-			lines := strings.Split(issue.CodeSnippet, "\n")
-			contextStart := max(0, issue.Line-3)
-			contextEnd := min(len(lines), issue.Line+3)
-
-			buf.WriteString("```go\n")
-			// 添加行号和语法高亮
-			for i := contextStart; i < contextEnd; i++ {
-				linePrefix := "  "
-				if i == issue.Line-1 { // 高亮问题行
-					linePrefix = ">"
-				}
-				buf.WriteString(fmt.Sprintf("%s %4d │ %s\n", linePrefix, i+1, lines[i]))
+	// 写入目录和按文件、类别分组的问题列表
+	groups := groupIssues(issues)
+	if len(groups) > 0 {
+		r.writeMarkdownTOC(&buf, groups)
+	}
+	buf.WriteString(fmt.Sprintf("## %s\n\n", t("report.issue_list")))
+	n := 0
+	for _, g := range groups {
+		buf.WriteString(fmt.Sprintf("<a id=\"%s\"></a>\n\n### `%s`\n\n", g.Anchor, g.File))
+		buf.WriteString(fmt.Sprintf("> %s｜%s｜%s %s\n\n", t("report.file_summary", len(g.Issues)), authorSummary(g.Issues), t("report.grade"), r.fileGrade(g)))
+		for _, c := range g.Categories {
+			buf.WriteString(fmt.Sprintf("#### %s\n\n", r.categoryName(c.Category)))
+			for _, issue := range c.Issues {
+				n++
+				r.writeMarkdownIssue(&buf, n, issue)
 			}
-			buf.WriteString("```\n\n")
 		}
 	}
 
+	// 写入缓存附录，说明哪些结果来自缓存
+	if cacheHits(r.Cache) > 0 {
+		r.writeMarkdownCache(&buf)
+	}
+
 	return buf.Bytes(), nil
 }
 
+// writeMarkdownIssue 写入Markdown格式的单个问题，n 为问题在报告中的序号
+// 使用 Conventional Comments 样式时标题以问题的标签开头
+func (r *DefaultReporter) writeMarkdownIssue(buf *bytes.Buffer, n int, issue types.Issue) {
+	t := r.Lang.T
+	if r.conventional() {
+		buf.WriteString(fmt.Sprintf("##### %d. %s: %s\n\n", n, ConventionalLabel(issue), issue.Title))
+	} else {
+		buf.WriteString(fmt.Sprintf("##### %d. %s\n\n", n, issue.Title))
+	}
+	buf.WriteString(fmt.Sprintf("- %s`%s`\n", t("report.file"), issue.FilePath))
+	buf.WriteString(fmt.Sprintf("- %s%s\n", t("report.location"), t("report.line", issue.Line)))
+	buf.WriteString(fmt.Sprintf("- %s**%s**\n", t("report.severity"), issue.Severity))
+	buf.WriteString(fmt.Sprintf("- %s`%s`\n", t("report.fingerprint"), issueFingerprint(issue)))
+	buf.WriteString(fmt.Sprintf("- %s%s\n", t("report.description"), issue.Description))
+	if issue.Suggestion != "" {
+		// 建议可能有多行或包含代码块，作为列表项下缩进的段落输出
+		buf.WriteString(fmt.Sprintf("- %s\n\n%s\n", t("report.suggestion"), indentListItem(issue.Suggestion)))
+	}
+	if len(issue.References) > 0 {
+		links := make([]string, 0, len(issue.References))
+		for _, ref := range issue.References {
+			links = append(links, fmt.Sprintf("[%s](%s)", ref.Title, ref.URL))
+		}
+		buf.WriteString(fmt.Sprintf("- %s%s\n", t("report.references"), strings.Join(links, t("report.list_separator"))))
+	}
+	buf.WriteString("\n")
+
+	// 优先展示问题所在的改动块，其次是代码片段
+	if excerpt := r.issueHunk(issue); excerpt != nil {
+		r.writeMarkdownHunk(buf, excerpt)
+	} else if issue.CodeSnippet != "" {
+		snippet := strings.Join(r.snippetLines(issue), "\n")
+		fence := codeFence(snippet)
+		buf.WriteString(fmt.Sprintf("%sgo\n%s\n%s\n\n", fence, snippet, fence))
+	}
+}
+
 // generateHTML 生成HTML格式的报告
 func (r *DefaultReporter) generateHTML(issues []types.Issue) ([]byte, error) {
 	var buf bytes.Buffer
+	t := r.Lang.T
 
 	// 写入HTML头部
-	buf.WriteString(`<!DOCTYPE html>
-<html>
+	buf.WriteString(fmt.Sprintf(`<!DOCTYPE html>
+<html lang="%s">
 <head>
 	<meta charset="UTF-8">
-	<title>代码评审报告</title>
+	<title>%s</title>`, r.Lang, t("report.title")))
+	buf.WriteString(`
 	<style>
-		body { font-family: Arial, sans-serif; line-height: 1.6; margin: 0; padding: 20px; background: #f5f5f5; }
-		.container { max-width: 1200px; margin: 0 auto; padding: 0 20px; }
-		.header { background: #fff; padding: 20px; border-radius: 8px; margin-bottom: 20px; box-shadow: 0 2px 4px rgba(0,0,0,0.1); }
-		.stats { display: grid; grid-template-columns: repeat(auto-fit, minmax(250px, 1fr)); gap: 20px; margin: 20px 0; }
-		.stat-card { background: white; padding: 20px; border-radius: 8px; box-shadow: 0 2px 4px rgba(0,0,0,0.1); transition: transform 0.2s; }
-		.stat-card:hover { transform: translateY(-2px); }
-		.severity { display: inline-block; padding: 4px 10px; border-radius: 4px; font-size: 0.9em; font-weight: 500; }
-		.critical { background: #dc3545; color: white; }
-		.high { background: #fd7e14; color: white; }
-		.medium { background: #ffc107; color: black; }
-		.low { background: #28a745; color: white; }
-		.issue { background: white; padding: 25px; margin: 15px 0; border-radius: 8px; box-shadow: 0 2px 4px rgba(0,0,0,0.1); }
-		.code { background: #1e1e1e; color: #d4d4d4; padding: 20px; border-radius: 8px; overflow-x: auto; font-family: 'Consolas', monospace; }
-		.code .line-number { color: #858585; padding-right: 15px; user-select: none; }
-		.code .highlight { background: rgba(255,255,0,0.1); display: block; }
-		.suggestion { border-left: 4px solid #007bff; padding: 15px; margin: 15px 0; background: #f8f9fa; border-radius: 0 8px 8px 0; }
-		.chart { margin: 20px 0; padding: 20px; background: white; border-radius: 8px; box-shadow: 0 2px 4px rgba(0,0,0,0.1); }
-		.code-block { position: relative; margin: 1em 0; }
-		.code-block pre { margin: 0; }
-		.code-block .language-badge { position: absolute; top: 0; right: 0; padding: 4px 8px; background: rgba(0,0,0,0.5); color: #fff; border-radius: 0 8px 0 4px; font-size: 0.8em; }
-		.issue-meta { display: grid; grid-template-columns: repeat(auto-fit, minmax(200px, 1fr)); gap: 10px; margin-bottom: 15px; }
-		.issue-meta-item { background: #f8f9fa; padding: 10px; border-radius: 4px; }
-	</style>
-	<script src="https://cdnjs.cloudflare.com/ajax/libs/highlight.js/11.7.0/highlight.min.js"></script>
-	<link rel="stylesheet" href="https://cdnjs.cloudflare.com/ajax/libs/highlight.js/11.7.0/styles/vs2015.min.css">
-	<script>hljs.highlightAll();</script>
+`)
+	buf.WriteString(reportCSS)
+	buf.WriteString(`	</style>`)
+	r.writeHTMLScripts(&buf)
+	buf.WriteString(`
 </head>
 <body>
 	<div class="container">`)
@@ -164,11 +315,29 @@ func (r *DefaultReporter) generateHTML(issues []types.Issue) ([]byte, error) {
 	// 写入报告头部信息
 	buf.WriteString(fmt.Sprintf(`
 	<div class="header">
-		<h1>代码评审报告</h1>
-		<p>项目名称：%s</p>
-		<p>提交ID：%s</p>
-		<p>评审时间：%s</p>
-	</div>`, r.ProjectName, r.CommitID, time.Now().Format("2006-01-02 15:04:05")))
+		<h1>%s</h1>
+		<p>%s%s</p>
+		<p>%s%s</p>
+		<p>%s%s</p>
+	</div>`, t("report.title"), t("report.project_name"), html.EscapeString(r.ProjectName), t("report.commit_id"), html.EscapeString(r.CommitID),
+		t("report.review_time"), r.reviewTime().Format("2006-01-02 15:04:05")))
+
+	// 达到评审时限时提示报告只包含部分结果
+	if r.TimeBox != nil {
+		r.writeHTMLTimeBox(&buf)
+	}
+	if len(r.Failures) > 0 {
+		r.writeHTMLFailures(&buf)
+	}
+	if len(r.BinaryFiles) > 0 {
+		r.writeHTMLBinary(&buf)
+	}
+	if r.Summary != nil {
+		r.writeHTMLSummary(&buf)
+	}
+	if len(r.Themes) > 0 {
+		r.writeHTMLThemes(&buf)
+	}
 
 	// 统计信息
 	severityCount := make(map[types.SeverityLevel]int)
@@ -179,87 +348,108 @@ func (r *DefaultReporter) generateHTML(issues []types.Issue) ([]byte, error) {
 	// 写入统计卡片
 	buf.WriteString(`
 	<div class="stats">`)
-	buf.WriteString(fmt.Sprintf(`
-		<div class="stat-card">
-			<h3>评审文件数</h3>
-			<p>%d</p>
-		</div>`, len(getUniqueFiles(issues))))
-	buf.WriteString(fmt.Sprintf(`
+	for _, row := range r.statRows(issues) {
+		buf.WriteString(fmt.Sprintf(`
 		<div class="stat-card">
-			<h3>问题总数</h3>
-			<p>%d</p>
-		</div>`, len(issues)))
+			<h3>%s</h3>
+			<p>%s</p>
+		</div>`, row[0], row[1]))
+	}
 
 	// 写入严重程度分布
-	buf.WriteString(`
+	buf.WriteString(fmt.Sprintf(`
 	<div class="stat-card">
-		<h3>问题严重程度分布</h3>`)
-	for severity, count := range severityCount {
-		buf.WriteString(fmt.Sprintf(`
-		<p><span class="severity %s">%s</span>: %d</p>`, strings.ToLower(string(severity)), severity, count))
+		<h3>%s</h3>`, t("report.severity_distribution")))
+	for _, severity := range severityOrder {
+		if count := severityCount[severity]; count > 0 {
+			buf.WriteString(fmt.Sprintf(`
+		<p><span class="severity %s">%s</span>: %s (%s)</p>`, strings.ToLower(string(severity)), severity,
+				r.Lang.Number(count), r.Lang.Percent(percentOf(count, len(issues)))))
+		}
 	}
 	buf.WriteString(`
-	</div>
 	</div>`)
+	if len(r.ChangeKinds) > 0 {
+		r.writeHTMLKinds(&buf)
+	}
+	buf.WriteString(`
+	</div>`)
+
+	// 写入与上次评审的对比
+	if r.Comparison != nil {
+		r.writeHTMLComparison(&buf)
+	}
+
+	// 写入改动的影响范围
+	if len(r.Impact) > 0 {
+		r.writeHTMLImpact(&buf)
+	}
+	if r.Dependencies != nil {
+		r.writeHTMLDependencies(&buf)
+	}
+
+	// 写入测试结果
+	if r.Tests != nil {
+		r.writeHTMLTests(&buf)
+	}
+
+	// 写入变更行覆盖率
+	if r.Coverage != nil {
+		r.writeHTMLCoverage(&buf)
+	}
+
+	// 写入按作者分组的问题
+	if len(r.Authors) > 0 {
+		r.writeHTMLAuthors(&buf)
+	}
 
 	// 写入优化建议
-	buf.WriteString(`
-	<h2>整体优化建议</h2>
-	<div class="suggestions">`)
+	buf.WriteString(fmt.Sprintf(`
+	<h2>%s</h2>
+	<div class="suggestions">`, t("report.overall_suggestions")))
 	suggestions := summarizeSuggestions(issues)
 	for _, suggestion := range suggestions {
 		buf.WriteString(fmt.Sprintf(`
-		<div class="suggestion">%s</div>`, suggestion))
+		<div class="suggestion">%s</div>`, renderMarkdown(suggestion)))
 	}
 	buf.WriteString(`
 	</div>`)
 
-	// 写入详细问题列表
-	buf.WriteString(`
-	<h2>详细问题列表</h2>`)
-	for i, issue := range issues {
+	// 写入目录和按文件、类别分组的问题列表
+	groups := groupIssues(issues)
+	if len(groups) > 0 {
+		r.writeHTMLTOC(&buf, groups)
+	}
+	buf.WriteString(fmt.Sprintf(`
+	<h2>%s</h2>`, t("report.issue_list")))
+	if len(issues) > 0 {
+		r.writeHTMLToolbar(&buf, issues)
+	}
+	n := 0
+	for _, g := range groups {
 		buf.WriteString(fmt.Sprintf(`
-	<div class="issue">
-		<h3>%d. %s</h3>
-		<div class="issue-meta">
-			<div class="issue-meta-item">
-				<strong>文件：</strong>%s
-			</div>
-			<div class="issue-meta-item">
-				<strong>位置：</strong>第%d行
-			</div>
-			<div class="issue-meta-item">
-				<strong>严重程度：</strong><span class="severity %s">%s</span>
-			</div>
-		</div>
-		<p><strong>描述：</strong>%s</p>`,
-			i+1, issue.Title, issue.FilePath, issue.Line,
-			strings.ToLower(string(issue.Severity)), issue.Severity, issue.Description))
-
-		if issue.Suggestion != "" {
+	<section class="file-group" id="%s">
+		<h3 class="file-title"><code>%s</code></h3>
+		<p class="file-summary">%s｜%s｜%s %s</p>`,
+			g.Anchor, html.EscapeString(g.File), t("report.file_summary", len(g.Issues)), authorSummary(g.Issues), t("report.grade"), r.fileGrade(g)))
+		for _, c := range g.Categories {
 			buf.WriteString(fmt.Sprintf(`
-		<div class="suggestion">%s</div>`, issue.Suggestion))
-		}
-
-		if issue.CodeSnippet != "" {
-			buf.WriteString(`
-		<pre class="code">`)
-			lines := strings.Split(issue.CodeSnippet, "\n")
-			contextStart := max(0, issue.Line-3)
-			contextEnd := min(len(lines), issue.Line+3)
-
-			for i := contextStart; i < contextEnd; i++ {
-				linePrefix := "  "
-				if i == issue.Line-1 {
-					linePrefix = ">"
-				}
-				buf.WriteString(fmt.Sprintf("%s %4d │ %s\n", linePrefix, i+1, lines[i]))
+		<div class="category-group">
+		<h4 class="category-title">%s</h4>`, r.categoryName(c.Category)))
+			for _, issue := range c.Issues {
+				n++
+				r.writeHTMLIssue(&buf, n, issue)
 			}
-			buf.WriteString(`</pre>`)
+			buf.WriteString(`
+		</div>`)
 		}
-
 		buf.WriteString(`
-	</div>`)
+	</section>`)
+	}
+
+	// 写入缓存附录，说明哪些结果来自缓存
+	if cacheHits(r.Cache) > 0 {
+		r.writeHTMLCache(&buf)
 	}
 
 	// 写入HTML尾部
@@ -271,6 +461,76 @@ func (r *DefaultReporter) generateHTML(issues []types.Issue) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// writeHTMLIssue 写入HTML格式的单个问题，n 为问题在报告中的序号
+func (r *DefaultReporter) writeHTMLIssue(buf *bytes.Buffer, n int, issue types.Issue) {
+	t := r.Lang.T
+	severity := html.EscapeString(string(issue.Severity))
+	buf.WriteString(fmt.Sprintf(`
+		<div class="issue" data-severity="%s" data-file="%s" data-category="%s" data-fingerprint="%s">
+			<h5 class="issue-title">%d. %s</h5>
+			<div class="issue-body">
+			<div class="issue-meta">
+				<div class="issue-meta-item">
+					<strong>%s</strong>%s
+				</div>
+				<div class="issue-meta-item">
+					<strong>%s</strong>%s
+				</div>
+				<div class="issue-meta-item">
+					<strong>%s</strong><span class="severity %s">%s</span>
+				</div>
+				<div class="issue-meta-item">
+					<strong>%s</strong><code>%s</code>
+				</div>
+			</div>
+			<div class="description"><strong>%s</strong>%s</div>`,
+		severity, html.EscapeString(issue.FilePath), issueCategory(issue), issueFingerprint(issue),
+		n, renderInline(issue.Title), t("report.file"), html.EscapeString(issue.FilePath), t("report.location"), t("report.line", issue.Line),
+		t("report.severity"), strings.ToLower(severity), severity, t("report.fingerprint"), issueFingerprint(issue),
+		t("report.description"), renderMarkdown(issue.Description)))
+
+	if issue.Suggestion != "" {
+		buf.WriteString(fmt.Sprintf(`
+			<div class="suggestion">%s</div>`, renderMarkdown(issue.Suggestion)))
+	}
+
+	if len(issue.References) > 0 {
+		buf.WriteString(fmt.Sprintf(`
+			<p class="references"><strong>%s</strong>`, t("report.references")))
+		for j, ref := range issue.References {
+			if j > 0 {
+				buf.WriteString(t("report.list_separator"))
+			}
+			if !safeURL(ref.URL) {
+				buf.WriteString(html.EscapeString(ref.Title))
+				continue
+			}
+			buf.WriteString(fmt.Sprintf(`<a href="%s" target="_blank" rel="noopener noreferrer">%s</a>`,
+				html.EscapeString(ref.URL), html.EscapeString(ref.Title)))
+		}
+		buf.WriteString(`</p>`)
+	}
+
+	if excerpt := r.issueHunk(issue); excerpt != nil {
+		r.writeHTMLHunk(buf, excerpt)
+	} else if issue.CodeSnippet != "" {
+		lang := model.DetectLanguage(issue.FilePath, issue.CodeSnippet)
+		if lang == "" {
+			lang = "plaintext"
+		}
+		buf.WriteString(fmt.Sprintf(`
+			<pre class="code"><code class="language-%s">`, lang))
+		for _, line := range r.snippetLines(issue) {
+			buf.WriteString(html.EscapeString(line) + "\n")
+		}
+		buf.WriteString(`</code></pre>`)
+	}
+
+	buf.WriteString(`
+			</div>
+		</div>`)
+}
+
 // 辅助函数：获取唯一文件列表
 func getUniqueFiles(issues []types.Issue) []string {
 	filesMap := make(map[string]bool)
@@ -324,7 +584,7 @@ func (r *DefaultReporter) generatePDF(issues []types.Issue) ([]byte, error) {
 	tmpPDF.Close()
 
 	// 使用wkhtmltopdf将HTML转换为PDF
-	cmd := exec.Command("wkhtmltopdf",
+	cmd := exec.Command(pdfRenderer,
 		"--enable-local-file-access",
 		"--margin-top", "20",
 		"--margin-right", "20",
@@ -348,8 +608,39 @@ func (r *DefaultReporter) generatePDF(issues []types.Issue) ([]byte, error) {
 	return pdfContent, nil
 }
 
+// pdfRenderer 将HTML报告转换为PDF的外部程序
+const pdfRenderer = "wkhtmltopdf"
+
+// PDFRendererAvailable 检查PDF转换程序是否可用
+func PDFRendererAvailable() bool {
+	_, err := exec.LookPath(pdfRenderer)
+	return err == nil
+}
+
+// PDFFallback 在PDF转换程序不可用时将PDF输出降级为HTML
+// 返回实际使用的格式和输出路径，路径以 .pdf 结尾时改为 .html；第三个返回值表示是否发生了降级
+func PDFFallback(format ReportFormat, path string) (ReportFormat, string, bool) {
+	if format != PDFFormat || PDFRendererAvailable() {
+		return format, path, false
+	}
+	if ext := filepath.Ext(path); strings.EqualFold(ext, ".pdf") {
+		path = strings.TrimSuffix(path, ext) + ".html"
+	}
+	return HTMLFormat, path, true
+}
+
 // Generate 生成评审报告
 func (r *DefaultReporter) Generate(issues []types.Issue, format ReportFormat) ([]byte, error) {
+	span := r.Span.Start("report " + string(format))
+	span.SetAttr("cr.report.format", string(format))
+	content, err := r.generate(issues, format)
+	span.SetAttr("cr.report.bytes", len(content))
+	span.End(err)
+	return content, err
+}
+
+// generate 按格式生成报告
+func (r *DefaultReporter) generate(issues []types.Issue, format ReportFormat) ([]byte, error) {
 	switch format {
 	case MarkdownFormat:
 		return r.generateMarkdown(issues)
@@ -357,11 +648,42 @@ func (r *DefaultReporter) Generate(issues []types.Issue, format ReportFormat) ([
 		return r.generateHTML(issues)
 	case PDFFormat:
 		return r.generatePDF(issues)
+	case JSONFormat:
+		return r.generateJSON(issues)
+	case TerminalFormat:
+		return r.generateTerminal(issues)
+	case CodeQualityFormat:
+		return r.generateCodeQuality(issues)
+	case RDJSONFormat:
+		return r.generateRDJSON(issues)
+	case RDJSONLFormat:
+		return r.generateRDJSONL(issues)
+	case TemplateFormat:
+		return r.generateTemplate(issues)
+	case BadgeFormat:
+		return r.generateBadgeSVG(issues)
+	case BadgeJSONFormat:
+		return r.generateBadgeJSON(issues)
+	case GitHubMarkdownFormat:
+		return r.generateGitHubMarkdown(issues)
 	default:
 		return nil, fmt.Errorf("unsupported format: %s", format)
 	}
 }
 
+// indentListItem 把文本的每一行缩进到列表项内，空行保持为空
+func indentListItem(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = "  " + line
+		} else {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 // summarizeSuggestions 汇总分析评审问题中的建议，生成整体优化建议列表
 func summarizeSuggestions(issues []types.Issue) []string {
 	// 使用map对建议进行分类和去重
@@ -405,6 +727,24 @@ func ParseReportFormat(format string) (ReportFormat, error) {
 		return HTMLFormat, nil
 	case string(PDFFormat):
 		return PDFFormat, nil
+	case string(JSONFormat):
+		return JSONFormat, nil
+	case string(TerminalFormat):
+		return TerminalFormat, nil
+	case string(CodeQualityFormat), "gitlab":
+		return CodeQualityFormat, nil
+	case string(RDJSONFormat):
+		return RDJSONFormat, nil
+	case string(RDJSONLFormat):
+		return RDJSONLFormat, nil
+	case string(TemplateFormat):
+		return TemplateFormat, nil
+	case string(BadgeFormat), "svg":
+		return BadgeFormat, nil
+	case string(BadgeJSONFormat):
+		return BadgeJSONFormat, nil
+	case string(GitHubMarkdownFormat):
+		return GitHubMarkdownFormat, nil
 	default:
 		return "", fmt.Errorf("不支持的报告格式: %s", format)
 	}
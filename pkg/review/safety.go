@@ -0,0 +1,166 @@
+package review
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// DefaultMaxQuoteLines 评审结果中单个代码块默认保留的最大行数
+const DefaultMaxQuoteLines = 30
+
+var (
+	// 形如 pkg/foo/bar.go、./main.py 的文件路径
+	pathPattern = regexp.MustCompile("(?:^|[\\s`'\"(（])((?:\\.{0,2}/)?(?:[\\w.-]+/)*[\\w.-]+\\.(?:go|py|js|jsx|ts|tsx|java|kt|rb|rs|c|h|cc|cpp|hpp|php|sh|sql|yaml|yml|json|proto|md))\\b")
+
+	// 被评审代码中常见的提示词注入残留
+	injectionPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`<\|[a-z_]+\|>`),
+		regexp.MustCompile(`\[/?INST\]|<</?SYS>>|</s>`),
+		regexp.MustCompile(`(?i)\b(ignore|disregard|forget)\s+(all\s+)?(the\s+)?(previous|prior|above|earlier)\s+(instructions|prompts?|rules)`),
+		regexp.MustCompile(`(?i)\byou\s+are\s+now\s+(a|an|in)\b`),
+		regexp.MustCompile(`(?i)^\s*(system|assistant)\s*:`),
+		regexp.MustCompile(`(忽略|无视|忘记)(之前|以上|前面|上述)(的)?(所有)?(指令|提示|规则)`),
+	}
+)
+
+// SafetyFilter 对模型输出进行安全过滤
+// 替换仓库中不存在的文件路径、截断过长的代码引用、删除被评审代码中夹带的提示词注入内容
+type SafetyFilter struct {
+	// 本次改动和仓库中的文件路径及其各级后缀，模型常只写出文件名或路径的后半部分
+	knownPaths    map[string]bool
+	lang          i18n.Lang
+	maxQuoteLines int
+}
+
+// NewSafetyFilter 根据本次评审的文件改动和仓库中跟踪的文件创建安全过滤器，
+// 替换的占位文字和省略提示使用 lang，maxQuoteLines<=0 时使用默认值
+func NewSafetyFilter(changes []types.FileChange, repoFiles []string, lang i18n.Lang, maxQuoteLines int) *SafetyFilter {
+	if maxQuoteLines <= 0 {
+		maxQuoteLines = DefaultMaxQuoteLines
+	}
+	if lang == "" {
+		lang = i18n.Default
+	}
+	f := &SafetyFilter{knownPaths: make(map[string]bool, len(changes)+len(repoFiles)), lang: lang, maxQuoteLines: maxQuoteLines}
+	for _, change := range changes {
+		f.addKnownPath(change.FilePath)
+	}
+	for _, file := range repoFiles {
+		f.addKnownPath(file)
+	}
+	return f
+}
+
+// addKnownPath 记录文件路径及其各级后缀，如 pkg/a/b.go、a/b.go 和 b.go
+func (f *SafetyFilter) addKnownPath(p string) {
+	p = path.Clean(p)
+	for {
+		f.knownPaths[p] = true
+		i := strings.IndexByte(p, '/')
+		if i < 0 {
+			return
+		}
+		p = p[i+1:]
+	}
+}
+
+// FilterIssues 过滤问题列表
+func (f *SafetyFilter) FilterIssues(issues []types.Issue) []types.Issue {
+	for i := range issues {
+		issues[i].Title = f.filterText(issues[i].Title)
+		issues[i].Description = f.filterText(issues[i].Description)
+		issues[i].Suggestion = f.filterText(issues[i].Suggestion)
+		issues[i].CodeSnippet = truncateLines(issues[i].CodeSnippet, f.maxQuoteLines, f.lang)
+	}
+	return issues
+}
+
+// filterText 依次执行各项过滤
+func (f *SafetyFilter) filterText(text string) string {
+	if text == "" {
+		return text
+	}
+	text = stripInjection(text)
+	text = f.stripUnknownPaths(text)
+	return f.truncateCodeBlocks(text)
+}
+
+// isKnownPath 判断路径是否属于本次改动或存在于仓库中，允许只写出文件名或路径后缀
+func (f *SafetyFilter) isKnownPath(p string) bool {
+	return f.knownPaths[path.Clean(strings.TrimPrefix(p, "./"))]
+}
+
+// stripUnknownPaths 替换模型臆造的、既不在本次改动中也不存在于仓库中的文件路径
+func (f *SafetyFilter) stripUnknownPaths(text string) string {
+	return pathPattern.ReplaceAllStringFunc(text, func(match string) string {
+		sub := pathPattern.FindStringSubmatch(match)
+		if f.isKnownPath(sub[1]) {
+			return match
+		}
+		return strings.Replace(match, sub[1], f.lang.T("safety.unknown_file"), 1)
+	})
+}
+
+// truncateCodeBlocks 截断过长的 ``` 代码块
+func (f *SafetyFilter) truncateCodeBlocks(text string) string {
+	lines := strings.Split(text, "\n")
+	result := make([]string, 0, len(lines))
+
+	inBlock := false
+	blockLines := 0
+	omitted := 0
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			if inBlock && omitted > 0 {
+				result = append(result, "// ... "+f.lang.T("safety.omitted_lines", omitted))
+			}
+			inBlock = !inBlock
+			blockLines, omitted = 0, 0
+			result = append(result, line)
+			continue
+		}
+		if inBlock {
+			blockLines++
+			if blockLines > f.maxQuoteLines {
+				omitted++
+				continue
+			}
+		}
+		result = append(result, line)
+	}
+	return strings.Join(result, "\n")
+}
+
+// stripInjection 删除文本中符合提示词注入特征的片段，同一行的其他内容保留；删除后只剩空白的行整行删除
+func stripInjection(text string) string {
+	lines := strings.Split(text, "\n")
+	result := lines[:0]
+	for _, line := range lines {
+		stripped := line
+		for _, pattern := range injectionPatterns {
+			stripped = pattern.ReplaceAllString(stripped, "")
+		}
+		if stripped != line {
+			// 合并删除片段后留下的连续空白
+			stripped = strings.Join(strings.Fields(stripped), " ")
+			if stripped == "" {
+				continue
+			}
+		}
+		result = append(result, stripped)
+	}
+	return strings.Join(result, "\n")
+}
+
+// truncateLines 将文本截断到指定行数，省略提示使用 lang
+func truncateLines(text string, maxLines int, lang i18n.Lang) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) <= maxLines {
+		return text
+	}
+	return strings.Join(lines[:maxLines], "\n") + "\n... " + lang.T("safety.omitted_lines", len(lines)-maxLines)
+}
@@ -0,0 +1,66 @@
+package review
+
+import "github.com/icatw/ai-cr-tool/pkg/types"
+
+// 各严重程度的扣分
+var severityPenalty = map[types.SeverityLevel]int{
+	types.SeverityError:   10,
+	types.SeverityWarning: 3,
+	types.SeverityInfo:    1,
+}
+
+// QualityScore 计算质量分：满分100，按问题的严重程度扣分，最低为0
+func QualityScore(issues []types.Issue) int {
+	score := 100
+	for _, issue := range issues {
+		score -= severityPenalty[issue.Severity]
+	}
+	if score < 0 {
+		return 0
+	}
+	return score
+}
+
+// Grade 将质量分换算为等级：A（90及以上）、B（80及以上）、C（70及以上）、D（60及以上）、F
+func Grade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	default:
+		return "F"
+	}
+}
+
+// FileGrade 单个文件的质量分和等级
+type FileGrade struct {
+	File   string
+	Issues int
+	Score  int
+	Grade  string
+}
+
+// FileGrades 计算每个文件的质量分和等级，文件按问题首次出现的顺序排列
+// 没有问题的文件不在结果中，其质量分为满分
+func FileGrades(issues []types.Issue) []FileGrade {
+	var grades []FileGrade
+	for _, g := range groupIssuesByFile(issues) {
+		score := QualityScore(g.Issues)
+		grades = append(grades, FileGrade{File: g.File, Issues: len(g.Issues), Score: score, Grade: Grade(score)})
+	}
+	return grades
+}
+
+// CountBySeverity 按严重程度统计问题数量
+func CountBySeverity(issues []types.Issue) map[types.SeverityLevel]int {
+	counts := make(map[types.SeverityLevel]int)
+	for _, issue := range issues {
+		counts[issue.Severity]++
+	}
+	return counts
+}
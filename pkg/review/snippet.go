@@ -0,0 +1,80 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// DefaultSnippetWidth 代码片段每行默认的最大显示宽度
+const DefaultSnippetWidth = 120
+
+// maxSnippetRows 一行代码最多折成的行数，超出部分截断
+const maxSnippetRows = 4
+
+// snippetTabWidth 制表符展开的空格数，使不同渲染环境下的对齐一致
+const snippetTabWidth = 4
+
+// snippetContext 问题行前后展示的行数
+const snippetContext = 3
+
+// snippetLines 生成问题所在位置前后的代码片段，每行带行号，问题行以 ">" 标记
+// 超过 SnippetWidth 的行按显示宽度折行，续行不带行号；折行过多时截断并以 "…" 结尾
+func (r *DefaultReporter) snippetLines(issue types.Issue) []string {
+	lines := strings.Split(issue.CodeSnippet, "\n")
+	contextStart := max(0, issue.Line-snippetContext)
+	contextEnd := min(len(lines), issue.Line+snippetContext)
+
+	var result []string
+	for i := contextStart; i < contextEnd; i++ {
+		linePrefix := "  "
+		if i == issue.Line-1 { // 高亮问题行，标记与空白前缀等宽以保持对齐
+			linePrefix = "> "
+		}
+		text := strings.ReplaceAll(lines[i], "\t", strings.Repeat(" ", snippetTabWidth))
+		for j, part := range splitWidth(text, r.SnippetWidth, maxSnippetRows) {
+			if j == 0 {
+				result = append(result, fmt.Sprintf("%s %4d │ %s", linePrefix, i+1, part))
+			} else {
+				result = append(result, fmt.Sprintf("%s %4s ┆ %s", linePrefix, "", part))
+			}
+		}
+	}
+	return result
+}
+
+// splitWidth 按显示宽度拆分一行文本，width 为 0 时不拆分；超过 maxRows 行时截断，最后一行以 "…" 结尾
+func splitWidth(text string, width, maxRows int) []string {
+	if width <= 0 || displayWidth(text) <= width {
+		return []string{text}
+	}
+
+	var rows []string
+	var current strings.Builder
+	currentWidth, truncated := 0, false
+	for _, r := range text {
+		w := runeWidth(r)
+		if currentWidth+w > width {
+			if len(rows) == maxRows-1 {
+				truncated = true
+				break
+			}
+			rows = append(rows, current.String())
+			current.Reset()
+			currentWidth = 0
+		}
+		current.WriteRune(r)
+		currentWidth += w
+	}
+	last := current.String()
+	if truncated {
+		// 为省略号腾出一列
+		runes := []rune(last)
+		for len(runes) > 0 && displayWidth(string(runes))+1 > width {
+			runes = runes[:len(runes)-1]
+		}
+		last = string(runes) + "…"
+	}
+	return append(rows, last)
+}
@@ -0,0 +1,102 @@
+package review
+
+import (
+	"strings"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// ReviewStats 评审过程的统计信息
+type ReviewStats struct {
+	// 评审使用的模型，使用模型池时为池名称
+	Model string
+	// 所有文件新增和删除的行数
+	ChangedLines int
+	// 模型评审耗时
+	Duration time.Duration
+	// 模型调用的 token 用量，命中缓存的文件不计入
+	PromptTokens     int
+	CompletionTokens int
+	// 输入中命中模型提供方提示缓存的 token 数
+	CachedTokens int
+	// 按配置的单价估算的费用，Currency 为空表示未配置单价
+	Cost     float64
+	Currency string
+	// 提示缓存节省的费用，未配置缓存单价时为0
+	CostSaved float64
+}
+
+// ChangedLines 统计差异中新增和删除的行数，不含文件头
+func ChangedLines(changes []types.FileChange) int {
+	total := 0
+	for _, change := range changes {
+		for _, line := range strings.Split(change.DiffContent, "\n") {
+			if strings.HasPrefix(line, "+++ ") || strings.HasPrefix(line, "--- ") {
+				continue
+			}
+			if strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") {
+				total++
+			}
+		}
+	}
+	return total
+}
+
+// TotalTokens 返回输入和输出 token 的总数
+func (s *ReviewStats) TotalTokens() int {
+	return s.PromptTokens + s.CompletionTokens
+}
+
+// IssuesPer100Lines 返回每百行变更的问题数
+func (s *ReviewStats) IssuesPer100Lines(issues int) float64 {
+	if s.ChangedLines == 0 {
+		return 0
+	}
+	return float64(issues) * 100 / float64(s.ChangedLines)
+}
+
+// severityOrder 报告中严重程度的展示顺序
+var severityOrder = []types.SeverityLevel{types.SeverityError, types.SeverityWarning, types.SeverityInfo}
+
+// percentOf 计算 count 占 total 的百分比
+func percentOf(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) * 100 / float64(total)
+}
+
+// statRows 生成报告统计表格的行，每行为指标名称和按报告语言格式化的数值
+func (r *DefaultReporter) statRows(issues []types.Issue) [][2]string {
+	t, l := r.Lang.T, r.Lang
+	rows := [][2]string{
+		{t("report.files_reviewed"), l.Number(len(getUniqueFiles(issues)))},
+		{t("report.total_issues"), l.Number(len(issues))},
+		{t("report.quality_score"), t("report.score_grade", QualityScore(issues), Grade(QualityScore(issues)))},
+	}
+	s := r.Stats
+	if s == nil {
+		return rows
+	}
+	rows = append(rows,
+		[2]string{t("report.changed_lines"), l.Number(s.ChangedLines)},
+		[2]string{t("report.issues_per_100"), l.Decimal(s.IssuesPer100Lines(len(issues)), 2)},
+		[2]string{t("report.duration"), l.Duration(s.Duration)},
+	)
+	if s.TotalTokens() > 0 {
+		rows = append(rows, [2]string{t("report.tokens"),
+			t("report.tokens_detail", l.Number(s.TotalTokens()), l.Number(s.PromptTokens), l.Number(s.CompletionTokens))})
+	}
+	if s.CachedTokens > 0 {
+		value := t("report.prompt_cache_detail", l.Number(s.CachedTokens), l.Percent(percentOf(s.CachedTokens, s.PromptTokens)))
+		if s.CostSaved > 0 && s.Currency != "" {
+			value += t("report.list_separator") + t("report.cost_saved", l.Money(s.CostSaved, s.Currency))
+		}
+		rows = append(rows, [2]string{t("report.prompt_cache"), value})
+	}
+	if s.Currency != "" {
+		rows = append(rows, [2]string{t("report.cost"), l.Money(s.Cost, s.Currency)})
+	}
+	return rows
+}
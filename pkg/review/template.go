@@ -0,0 +1,172 @@
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/coverage"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/impact"
+	"github.com/icatw/ai-cr-tool/pkg/testrun"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// TemplateData 自定义报告模板可以使用的数据
+type TemplateData struct {
+	Project     string
+	Commit      string
+	GeneratedAt time.Time
+	Lang        i18n.Lang
+	Issues      []types.Issue
+	Stats       TemplateStats
+	// 按出现次数排序的整体优化建议
+	Suggestions []string
+	// 以下字段未启用对应功能时为空
+	Comparison *Comparison
+	Impact     []impact.PackageImpact
+	Tests      *testrun.Result
+	Coverage   *coverage.Report
+	Authors    []AuthorIssues
+	TimeBox    *TimeBox
+	Failures   []FailedFile
+	// 跳过的二进制文件
+	BinaryFiles []string
+	Summary     *ExecutiveSummary
+	// 归纳问题得到的主题
+	Themes []Theme
+	// 各改动类型的文件数
+	ChangeKinds []KindCount
+	// 依赖变更及风险评估
+	Dependencies *DependencyReview
+	// 评审过程的统计信息，离线渲染时为空
+	Review *ReviewStats
+}
+
+// TemplateStats 报告统计信息
+type TemplateStats struct {
+	Files      int
+	Issues     int
+	BySeverity map[string]int
+	Score      int
+	Grade      string
+}
+
+// templateFuncs 模板中可用的辅助函数
+func templateFuncs(lang i18n.Lang) template.FuncMap {
+	return template.FuncMap{
+		"t":        lang.T,
+		"upper":    func(v interface{}) string { return strings.ToUpper(fmt.Sprint(v)) },
+		"lower":    func(v interface{}) string { return strings.ToLower(fmt.Sprint(v)) },
+		"trim":     strings.TrimSpace,
+		"join":     strings.Join,
+		"replace":  strings.ReplaceAll,
+		"add":      func(a, b int) int { return a + b },
+		"date":     func(layout string, t time.Time) string { return t.Format(layout) },
+		"number":   lang.Number,
+		"percent":  lang.Percent,
+		"duration": lang.Duration,
+		// severity 筛选指定严重程度的问题，如 {{range severity "error" .Issues}}
+		"severity": func(level string, issues []types.Issue) []types.Issue {
+			var result []types.Issue
+			for _, issue := range issues {
+				if string(issue.Severity) == level {
+					result = append(result, issue)
+				}
+			}
+			return result
+		},
+		// byFile 按文件分组问题，返回的文件按首次出现的顺序排列
+		"byFile": groupIssuesByFile,
+		// byCategory 按类别分组问题，如 {{range byCategory .Issues}}{{category .Category}}{{end}}
+		"byCategory": groupByCategory,
+		// grades 计算每个文件的质量分和等级
+		"grades": FileGrades,
+		// kind 返回改动类型的本地化名称
+		"kind": func(kind types.ChangeKind) string { return lang.T("kind." + string(kind)) },
+		// category 返回类别的本地化名称
+		"category": func(category types.IssueCategory) string { return lang.T("category." + string(category)) },
+	}
+}
+
+// FileIssues 同一文件中的问题
+type FileIssues struct {
+	File   string
+	Issues []types.Issue
+}
+
+// groupIssuesByFile 按文件分组问题
+func groupIssuesByFile(issues []types.Issue) []FileIssues {
+	var groups []FileIssues
+	index := make(map[string]int)
+	for _, issue := range issues {
+		i, ok := index[issue.FilePath]
+		if !ok {
+			i = len(groups)
+			index[issue.FilePath] = i
+			groups = append(groups, FileIssues{File: issue.FilePath})
+		}
+		groups[i].Issues = append(groups[i].Issues, issue)
+	}
+	return groups
+}
+
+// LoadTemplate 读取 text/template 格式的报告模板
+func LoadTemplate(path string, lang i18n.Lang) (*template.Template, error) {
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs(lang)).ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("解析报告模板失败: %v", err)
+	}
+	return tmpl, nil
+}
+
+// templateData 汇总模板使用的数据
+func (r *DefaultReporter) templateData(issues []types.Issue) TemplateData {
+	bySeverity := make(map[string]int)
+	for severity, count := range CountBySeverity(issues) {
+		bySeverity[string(severity)] = count
+	}
+	return TemplateData{
+		Project:     r.ProjectName,
+		Commit:      r.CommitID,
+		GeneratedAt: r.reviewTime(),
+		Lang:        r.Lang,
+		Issues:      issues,
+		Stats: TemplateStats{
+			Files:      len(getUniqueFiles(issues)),
+			Issues:     len(issues),
+			BySeverity: bySeverity,
+			Score:      QualityScore(issues),
+			Grade:      Grade(QualityScore(issues)),
+		},
+		Suggestions:  summarizeSuggestions(issues),
+		Comparison:   r.Comparison,
+		Impact:       r.Impact,
+		Tests:        r.Tests,
+		Coverage:     r.Coverage,
+		Authors:      r.Authors,
+		TimeBox:      r.TimeBox,
+		Failures:     r.Failures,
+		BinaryFiles:  r.BinaryFiles,
+		Summary:      r.Summary,
+		Themes:       r.Themes,
+		ChangeKinds:  r.ChangeKinds,
+		Dependencies: r.Dependencies,
+		Review:       r.Stats,
+	}
+}
+
+// generateTemplate 使用自定义模板生成报告
+func (r *DefaultReporter) generateTemplate(issues []types.Issue) ([]byte, error) {
+	if r.Template == nil {
+		return nil, fmt.Errorf("未指定报告模板，请使用 --report-template")
+	}
+	var buf bytes.Buffer
+	if err := r.Template.Execute(&buf, r.templateData(issues)); err != nil {
+		return nil, fmt.Errorf("渲染报告模板失败: %v", err)
+	}
+	return buf.Bytes(), nil
+}
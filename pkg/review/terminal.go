@@ -0,0 +1,350 @@
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/coverage"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// ANSI 控制序列
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiDim    = "\033[2m"
+	ansiRed    = "\033[31m"
+	ansiYellow = "\033[33m"
+	ansiBlue   = "\033[34m"
+	ansiGreen  = "\033[32m"
+	ansiCyan   = "\033[36m"
+	ansiBgRed  = "\033[41;97m"
+	ansiBgYel  = "\033[43;30m"
+	ansiBgBlue = "\033[44;97m"
+)
+
+// defaultTerminalWidth 无法获取终端宽度时使用的默认宽度
+const defaultTerminalWidth = 100
+
+// terminalStyle 终端渲染样式，设置 NO_COLOR 环境变量时不输出颜色
+type terminalStyle struct {
+	color bool
+	width int
+}
+
+// newTerminalStyle 根据环境变量创建终端样式
+func newTerminalStyle() terminalStyle {
+	width := defaultTerminalWidth
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 20 {
+		width = cols
+	}
+	_, noColor := os.LookupEnv("NO_COLOR")
+	return terminalStyle{color: !noColor, width: width}
+}
+
+// paint 为文本添加ANSI样式
+func (s terminalStyle) paint(code, text string) string {
+	if !s.color {
+		return text
+	}
+	return code + text + ansiReset
+}
+
+// badge 生成严重程度徽标
+func (s terminalStyle) badge(severity types.SeverityLevel) string {
+	label := " " + strings.ToUpper(string(severity)) + " "
+	switch severity {
+	case types.SeverityError:
+		return s.paint(ansiBgRed+ansiBold, label)
+	case types.SeverityWarning:
+		return s.paint(ansiBgYel+ansiBold, label)
+	default:
+		return s.paint(ansiBgBlue+ansiBold, label)
+	}
+}
+
+// severityColor 返回严重程度对应的前景色
+func severityColor(severity types.SeverityLevel) string {
+	switch severity {
+	case types.SeverityError:
+		return ansiRed
+	case types.SeverityWarning:
+		return ansiYellow
+	default:
+		return ansiBlue
+	}
+}
+
+// generateTerminal 生成适合直接在终端阅读的彩色报告
+func (r *DefaultReporter) generateTerminal(issues []types.Issue) ([]byte, error) {
+	style := newTerminalStyle()
+	t := r.Lang.T
+	var buf bytes.Buffer
+
+	// 报告头部
+	buf.WriteString(style.paint(ansiBold, t("report.title")))
+	buf.WriteString(style.paint(ansiDim, fmt.Sprintf("  %s @ %s  %s\n", r.ProjectName, r.CommitID, r.reviewTime().Format("2006-01-02 15:04:05"))))
+	if r.TimeBox != nil {
+		buf.WriteString(style.paint(ansiYellow, "⚠ "+r.timeBoxNotice()) + "\n")
+	}
+	if len(r.Failures) > 0 {
+		buf.WriteString(style.paint(ansiRed, "✗ "+r.failuresNotice()) + "\n")
+		for _, f := range r.Failures {
+			buf.WriteString(style.paint(ansiDim, fmt.Sprintf("  %s · %s", f.FilePath, r.failureDetail(f))) + "\n")
+		}
+	}
+	if len(r.BinaryFiles) > 0 {
+		buf.WriteString(style.paint(ansiDim, "ℹ "+r.binaryNotice()+": "+strings.Join(r.BinaryFiles, ", ")) + "\n")
+	}
+	if r.Summary != nil {
+		r.writeTerminalSummary(&buf, style)
+	}
+	if len(r.Themes) > 0 {
+		r.writeTerminalThemes(&buf, style)
+	}
+
+	// 统计信息
+	severityCount := make(map[types.SeverityLevel]int)
+	for _, issue := range issues {
+		severityCount[issue.Severity]++
+	}
+	score := QualityScore(issues)
+	buf.WriteString(style.paint(ansiBold, t("report.score_short", score, Grade(score))) + "  ")
+	buf.WriteString(t("report.files_short", len(getUniqueFiles(issues))) + "  " + t("report.issues_short", len(issues)) + " ")
+	for _, severity := range severityOrder {
+		if count := severityCount[severity]; count > 0 {
+			buf.WriteString(" " + style.paint(severityColor(severity), fmt.Sprintf("● %s %d (%s)", severity, count,
+				r.Lang.Percent(percentOf(count, len(issues))))))
+		}
+	}
+	buf.WriteString("\n")
+	if s := r.Stats; s != nil {
+		parts := []string{
+			t("report.changed_lines_short", r.Lang.Number(s.ChangedLines)),
+			t("report.issues_per_100_short", r.Lang.Decimal(s.IssuesPer100Lines(len(issues)), 2)),
+			r.Lang.Duration(s.Duration),
+		}
+		if s.TotalTokens() > 0 {
+			parts = append(parts, r.Lang.Number(s.TotalTokens())+" tokens")
+		}
+		if s.CachedTokens > 0 {
+			parts = append(parts, t("report.prompt_cache_short", r.Lang.Number(s.CachedTokens)))
+		}
+		if s.Currency != "" {
+			parts = append(parts, r.Lang.Money(s.Cost, s.Currency))
+		}
+		buf.WriteString(style.paint(ansiDim, strings.Join(parts, " · ")) + "\n")
+	}
+
+	// 与上次评审的对比
+	if c := r.Comparison; c != nil {
+		color := ansiDim
+		switch {
+		case c.ScoreDelta() > 0:
+			color = ansiGreen
+		case c.ScoreDelta() < 0:
+			color = ansiRed
+		}
+		buf.WriteString(fmt.Sprintf("%s %d → %d %s  %s  %s\n", t("report.quality_score"), c.PreviousScore, c.Score,
+			style.paint(color, fmt.Sprintf("(%+d)", c.ScoreDelta())),
+			t("report.resolved_short", len(c.Resolved)), t("report.new_short", len(c.New))))
+		for _, issue := range c.Resolved {
+			buf.WriteString(style.paint(ansiDim, fmt.Sprintf("  ✓ %s  %s", issue.Title, issue.FilePath)) + "\n")
+		}
+	}
+	if r.Tests != nil {
+		color := ansiGreen
+		if !r.Tests.Passed {
+			color = ansiRed
+		}
+		buf.WriteString(style.paint(color, r.testStatus()) + style.paint(ansiDim, "  "+r.Tests.Command) + "\n")
+		for _, failure := range r.Tests.Failures {
+			buf.WriteString(style.paint(ansiRed, "  ✗ "+failureName(failure)) + "\n")
+		}
+	}
+	if c := r.Coverage; c != nil {
+		color := ansiGreen
+		if c.Covered < c.Total {
+			color = ansiYellow
+		}
+		buf.WriteString(style.paint(color, t("report.coverage_short", c.Percent(), c.Covered, c.Total)) + "\n")
+		for _, fc := range c.Files {
+			if len(fc.Uncovered) > 0 {
+				buf.WriteString(style.paint(ansiDim, fmt.Sprintf("  %s: %s", fc.File, coverage.FormatRanges(fc.Uncovered))) + "\n")
+			}
+		}
+	}
+	if len(r.ChangeKinds) > 0 {
+		buf.WriteString(style.paint(ansiDim, t("report.kinds_short", r.kindSummary())) + "\n")
+	}
+	for _, g := range r.Authors {
+		buf.WriteString(style.paint(ansiDim, t("report.author_short", r.AuthorName(g.Author), authorSummary(g.Issues))) + "\n")
+	}
+	for _, impact := range r.Impact {
+		buf.WriteString(style.paint(ansiDim, t("report.impact_short", impact.Package, len(impact.Transitive))) + "\n")
+	}
+	if r.Dependencies != nil {
+		color, high := ansiDim, r.Dependencies.HighRisk()
+		if high > 0 {
+			color = ansiYellow
+		}
+		buf.WriteString(style.paint(color, t("report.dependencies_short", len(r.Dependencies.Findings), high)) + "\n")
+	}
+	for _, fg := range FileGrades(issues) {
+		buf.WriteString(style.paint(ansiDim, fmt.Sprintf("  %s %3d  %s", fg.Grade, fg.Score, fg.File)) + "\n")
+	}
+	buf.WriteString(style.paint(ansiDim, strings.Repeat("─", style.width)) + "\n")
+
+	if len(issues) == 0 {
+		buf.WriteString(style.paint(ansiBold, "✓ "+t("report.no_issues")) + "\n")
+		return buf.Bytes(), nil
+	}
+
+	indent := "  "
+	textWidth := style.width - len(indent)
+	for _, issue := range issues {
+		buf.WriteString("\n" + style.badge(issue.Severity) + " " + style.paint(ansiBold, issue.Title) + "\n")
+
+		location := issue.FilePath
+		if issue.Line > 0 {
+			location = fmt.Sprintf("%s:%d", issue.FilePath, issue.Line)
+		}
+		buf.WriteString(indent + style.paint(ansiCyan, location) + "  " + style.paint(ansiDim, "#"+issueFingerprint(issue)) + "\n")
+
+		for _, line := range wrapText(issue.Description, textWidth) {
+			buf.WriteString(indent + line + "\n")
+		}
+		if issue.Suggestion != "" {
+			label := t("report.suggestion")
+			for i, line := range wrapText(issue.Suggestion, textWidth-displayWidth(label)) {
+				prefix := strings.Repeat(" ", displayWidth(label))
+				if i == 0 {
+					prefix = style.paint(ansiBold, label)
+				}
+				buf.WriteString(indent + prefix + line + "\n")
+			}
+		}
+		for _, ref := range issue.References {
+			buf.WriteString(indent + style.paint(ansiDim, fmt.Sprintf("%s%s <%s>", t("report.references"), ref.Title, ref.URL)) + "\n")
+		}
+
+		if excerpt := r.issueHunk(issue); excerpt != nil {
+			r.writeTerminalHunk(&buf, excerpt, style, indent, textWidth)
+		} else if issue.CodeSnippet != "" {
+			gutter := style.paint(ansiDim, "│ ")
+			for _, line := range strings.Split(strings.TrimRight(issue.CodeSnippet, "\n"), "\n") {
+				line = strings.ReplaceAll(line, "\t", strings.Repeat(" ", snippetTabWidth))
+				for _, part := range splitWidth(line, textWidth-2, maxSnippetRows) {
+					buf.WriteString(indent + gutter + part + "\n")
+				}
+			}
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeTerminalSummary 写入执行摘要，合并建议按结论着色
+func (r *DefaultReporter) writeTerminalSummary(buf *bytes.Buffer, style terminalStyle) {
+	buf.WriteString(style.paint(ansiBold, r.Lang.T("report.executive_summary")))
+	if text := r.decisionText(); text != "" {
+		color := ansiGreen
+		switch r.Summary.Decision {
+		case DecisionRequestChanges:
+			color = ansiRed
+		case DecisionApproveWithSuggestions:
+			color = ansiYellow
+		}
+		buf.WriteString("  " + style.paint(color+ansiBold, text))
+	}
+	buf.WriteString("\n")
+	for _, line := range wrapText(r.Summary.Overview, style.width-2) {
+		buf.WriteString("  " + line + "\n")
+	}
+	for _, risk := range r.Summary.Risks {
+		for i, line := range wrapText(risk, style.width-4) {
+			prefix := "    "
+			if i == 0 {
+				prefix = "  • "
+			}
+			buf.WriteString(prefix + line + "\n")
+		}
+	}
+	if tr := r.Summary.Translation; tr != nil {
+		buf.WriteString(style.paint(ansiBold, tr.Lang.T("report.executive_summary")) + "\n")
+		for _, line := range wrapText(tr.Overview, style.width-2) {
+			buf.WriteString("  " + line + "\n")
+		}
+		for _, risk := range tr.Risks {
+			for i, line := range wrapText(risk, style.width-4) {
+				prefix := "    "
+				if i == 0 {
+					prefix = "  • "
+				}
+				buf.WriteString(prefix + line + "\n")
+			}
+		}
+	}
+}
+
+// wrapText 按显示宽度折行，保留原有换行，过长的单词按字符强制拆分
+func wrapText(text string, width int) []string {
+	if width < 10 {
+		width = 10
+	}
+
+	var lines []string
+	for _, paragraph := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if displayWidth(paragraph) <= width {
+			lines = append(lines, paragraph)
+			continue
+		}
+
+		var current []rune
+		currentWidth := 0
+		for _, r := range paragraph {
+			if w := runeWidth(r); currentWidth+w > width && len(current) > 0 {
+				// 优先在后半段的最后一个空格处断行
+				cut := len(current)
+				for i := len(current) - 1; i > len(current)/2; i-- {
+					if current[i] == ' ' {
+						cut = i
+						break
+					}
+				}
+				lines = append(lines, strings.TrimRight(string(current[:cut]), " "))
+				current = append([]rune(nil), current[cut:]...)
+				if len(current) > 0 && current[0] == ' ' {
+					current = current[1:]
+				}
+				currentWidth = displayWidth(string(current))
+			}
+			current = append(current, r)
+			currentWidth += runeWidth(r)
+		}
+		if len(current) > 0 {
+			lines = append(lines, string(current))
+		}
+	}
+	return lines
+}
+
+// displayWidth 估算文本在终端中占用的列数，中日韩字符与全角符号按两列计算
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// runeWidth 估算单个字符占用的列数
+func runeWidth(r rune) int {
+	if r >= 0x2E80 && r <= 0xFFEF {
+		return 2
+	}
+	return 1
+}
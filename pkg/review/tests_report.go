@@ -0,0 +1,69 @@
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/testrun"
+)
+
+// failureName 返回失败用例的显示名称
+func failureName(failure testrun.Failure) string {
+	if failure.Test == "" {
+		return failure.Package
+	}
+	return failure.Package + "." + failure.Test
+}
+
+// testStatus 返回测试结果的状态文本
+func (r *DefaultReporter) testStatus() string {
+	if r.Tests.Passed {
+		return r.Lang.T("report.test_passed")
+	}
+	if n := len(r.Tests.Failures); n > 0 {
+		return r.Lang.T("report.test_failed_n", n)
+	}
+	return r.Lang.T("report.test_failed")
+}
+
+// writeMarkdownTests 写入Markdown格式的测试结果
+func (r *DefaultReporter) writeMarkdownTests(buf *bytes.Buffer) {
+	t := r.Lang.T
+	buf.WriteString(fmt.Sprintf("## %s\n\n", t("report.tests")))
+	buf.WriteString(fmt.Sprintf("- %s`%s`\n", t("report.test_command"), r.Tests.Command))
+	buf.WriteString(fmt.Sprintf("- %s%s\n", t("report.test_duration"), r.Tests.Duration.Round(time.Millisecond)))
+	buf.WriteString(fmt.Sprintf("- **%s**\n\n", r.testStatus()))
+
+	for _, failure := range r.Tests.Failures {
+		buf.WriteString(fmt.Sprintf("### ✗ %s\n\n```\n%s\n```\n\n", failureName(failure), failure.Output))
+	}
+	if len(r.Tests.Failures) == 0 && r.Tests.Output != "" && !r.Tests.Passed {
+		buf.WriteString(fmt.Sprintf("```\n%s\n```\n\n", r.Tests.Output))
+	}
+}
+
+// writeHTMLTests 写入HTML格式的测试结果
+func (r *DefaultReporter) writeHTMLTests(buf *bytes.Buffer) {
+	t := r.Lang.T
+	buf.WriteString(fmt.Sprintf(`
+	<h2>%s</h2>
+	<div class="chart">
+		<p>%s<code>%s</code></p>
+		<p>%s%s</p>
+		<p><strong>%s</strong></p>`,
+		t("report.tests"), t("report.test_command"), html.EscapeString(r.Tests.Command),
+		t("report.test_duration"), r.Tests.Duration.Round(time.Millisecond), r.testStatus()))
+	for _, failure := range r.Tests.Failures {
+		buf.WriteString(fmt.Sprintf(`
+		<h3>✗ %s</h3>
+		<pre class="code">%s</pre>`, html.EscapeString(failureName(failure)), html.EscapeString(failure.Output)))
+	}
+	if len(r.Tests.Failures) == 0 && r.Tests.Output != "" && !r.Tests.Passed {
+		buf.WriteString(fmt.Sprintf(`
+		<pre class="code">%s</pre>`, html.EscapeString(r.Tests.Output)))
+	}
+	buf.WriteString(`
+	</div>`)
+}
@@ -0,0 +1,45 @@
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"time"
+)
+
+// TimeBox 达到评审时限时的说明，报告据此标记为部分结果
+type TimeBox struct {
+	// 本次评审的时限
+	Limit time.Duration
+	// 因达到时限而未评审的文件
+	Skipped []string
+}
+
+// timeBoxNotice 返回时限说明文本
+func (r *DefaultReporter) timeBoxNotice() string {
+	return r.Lang.T("report.time_boxed", r.Lang.Duration(r.TimeBox.Limit), len(r.TimeBox.Skipped))
+}
+
+// writeMarkdownTimeBox 写入Markdown格式的时限说明
+func (r *DefaultReporter) writeMarkdownTimeBox(buf *bytes.Buffer) {
+	buf.WriteString(fmt.Sprintf("> ⚠️ **%s**\n>\n", r.timeBoxNotice()))
+	for _, file := range r.TimeBox.Skipped {
+		buf.WriteString(fmt.Sprintf("> - `%s`\n", file))
+	}
+	buf.WriteString("\n")
+}
+
+// writeHTMLTimeBox 写入HTML格式的时限说明
+func (r *DefaultReporter) writeHTMLTimeBox(buf *bytes.Buffer) {
+	buf.WriteString(fmt.Sprintf(`
+	<div class="notice">
+		<strong>⚠️ %s</strong>
+		<ul>`, html.EscapeString(r.timeBoxNotice())))
+	for _, file := range r.TimeBox.Skipped {
+		buf.WriteString(fmt.Sprintf(`
+			<li><code>%s</code></li>`, html.EscapeString(file)))
+	}
+	buf.WriteString(`
+		</ul>
+	</div>`)
+}
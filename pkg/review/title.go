@@ -0,0 +1,130 @@
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// maxTitleRunes 问题标题的最大字符数，超出时截断，保证报告和评论中的标题便于浏览
+const maxTitleRunes = 80
+
+// SummarizeTitle 从问题描述或模型的非结构化输出中提取简短的标题：
+// 取正文中第一句有内容的文字（跳过代码块，标题行只在没有正文时使用），去掉 Markdown 标记，超长时截断；
+// 没有可用的内容时返回空字符串
+func SummarizeTitle(text string) string {
+	var heading string
+	inFence := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		isHeading := strings.HasPrefix(line, "#")
+		line = strings.TrimLeft(line, "#>-*+ \t")
+		line = strings.NewReplacer("**", "", "__", "", "`", "").Replace(line)
+		if end := sentenceEnd(line); end > 0 {
+			line = line[:end]
+		}
+		line = strings.TrimSpace(strings.TrimRight(line, "：:，,；;"))
+		if !strings.ContainsFunc(line, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+			continue
+		}
+		if isHeading {
+			// 标题行多为"总体评价"之类的泛称
+			if heading == "" {
+				heading = line
+			}
+			continue
+		}
+		return truncateTitle(line)
+	}
+	return truncateTitle(heading)
+}
+
+// sentenceEnd 返回第一个句子结束的位置，中文句号等标点之后、或英文句点后跟空格时视为句子结束
+func sentenceEnd(line string) int {
+	for i, r := range line {
+		switch r {
+		case '。', '！', '？', '；':
+			return i
+		case '.', '!', '?':
+			if next := i + 1; next >= len(line) || line[next] == ' ' {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// truncateTitle 截断超长的标题
+func truncateTitle(title string) string {
+	if runes := []rune(title); len(runes) > maxTitleRunes {
+		return strings.TrimSpace(string(runes[:maxTitleRunes-1])) + "…"
+	}
+	return title
+}
+
+// TitleData 问题标题模板可以使用的字段
+type TitleData struct {
+	// 模型给出或从描述中提取的简短标题
+	Summary  string
+	Severity types.SeverityLevel
+	Category types.IssueCategory
+	// 文件路径和文件名
+	File string
+	Base string
+	Line int
+}
+
+// TitleTemplate 问题标题模板，使用 text/template 语法，如 "[{{.Category}}] {{.Summary}}"
+type TitleTemplate struct {
+	tmpl *template.Template
+}
+
+// ParseTitleTemplate 解析问题标题模板
+func ParseTitleTemplate(text string) (*TitleTemplate, error) {
+	tmpl, err := template.New("title").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析标题模板失败: %v", err)
+	}
+	// 用示例数据检查模板只引用了存在的字段
+	if err := tmpl.Execute(&bytes.Buffer{}, TitleData{}); err != nil {
+		return nil, fmt.Errorf("标题模板无效: %v", err)
+	}
+	return &TitleTemplate{tmpl: tmpl}, nil
+}
+
+// Apply 用模板生成每个问题的标题，生成结果为空时保留原标题
+func (t *TitleTemplate) Apply(issues []types.Issue) {
+	for i, issue := range issues {
+		category := issue.Category
+		if category == "" {
+			category = types.CategoryOther
+		}
+		data := TitleData{
+			Summary:  issue.Title,
+			Severity: issue.Severity,
+			Category: category,
+			File:     issue.FilePath,
+			Base:     path.Base(issue.FilePath),
+			Line:     issue.Line,
+		}
+		var buf bytes.Buffer
+		if err := t.tmpl.Execute(&buf, data); err != nil {
+			continue
+		}
+		if title := strings.Join(strings.Fields(buf.String()), " "); title != "" {
+			issues[i].Title = title
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/history"
+)
+
+// maxFinishedJobs 保留结果的已结束任务数上限，超出时丢弃最早结束的任务
+const maxFinishedJobs = 1000
+
+// ReviewRequest 通过 REST API 提交的评审请求，仓库（clone_url、base、head）与补丁（diff）二选一
+type ReviewRequest struct {
+	// 仓库的 HTTP(S) 克隆地址
+	CloneURL string `json:"clone_url,omitempty"`
+	// 目标分支和源分支，可以是分支名或以 refs/ 开头的完整引用
+	Base string `json:"base,omitempty"`
+	Head string `json:"head,omitempty"`
+	// 统一差异格式的补丁
+	Diff string `json:"diff,omitempty"`
+	// 评审使用的模型和报告语言，为空时使用服务的默认设置
+	Model string `json:"model,omitempty"`
+	Lang  string `json:"lang,omitempty"`
+}
+
+// validate 检查评审请求
+func (r *ReviewRequest) validate() error {
+	switch {
+	case r.Diff != "" && r.CloneURL != "":
+		return fmt.Errorf("clone_url 和 diff 只能指定一个")
+	case r.Diff != "":
+		return nil
+	case r.CloneURL == "" || r.Base == "" || r.Head == "":
+		return fmt.Errorf("需要指定 clone_url、base 和 head，或者 diff")
+	}
+	u, err := url.Parse(r.CloneURL)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return fmt.Errorf("无效的克隆地址: %s", r.CloneURL)
+	}
+	for _, ref := range []string{r.Base, r.Head} {
+		if strings.HasPrefix(ref, "-") || strings.Contains(ref, "..") || strings.ContainsAny(ref, ": \t\n") {
+			return fmt.Errorf("无效的引用: %s", ref)
+		}
+	}
+	return nil
+}
+
+// event 将仓库评审请求转换为检出使用的事件，仓库以克隆地址的主机和路径区分
+func (r *ReviewRequest) event() *Event {
+	u, _ := url.Parse(r.CloneURL)
+	repo := u.Host + strings.TrimSuffix(u.Path, ".git")
+	return &Event{Platform: "git", Repo: repo, CloneURL: r.CloneURL, BaseRef: r.Base, Head: r.Head}
+}
+
+// JobStatus 评审任务的状态
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job 通过 REST API 提交的评审任务
+type Job struct {
+	ID         string     `json:"id"`
+	Status     JobStatus  `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	// 已经失败的次数，失败后等待重试的任务重新处于 queued 状态
+	Attempts int `json:"attempts,omitempty"`
+	// 最近一次失败的原因
+	Error string `json:"error,omitempty"`
+	// JSON 格式的评审报告，任务成功后才有
+	Report json.RawMessage `json:"report,omitempty"`
+}
+
+// JobFunc 执行 API 提交的评审任务，返回 JSON 格式的评审报告
+// dir 为评审的工作目录，args 为评审范围的命令行参数（--commit-range 或 --diff-file）
+type JobFunc func(req *ReviewRequest, dir string, args []string) ([]byte, error)
+
+// newID 生成随机的标识，用于 API 任务和队列中的任务
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// authorized 校验 API 请求的 Authorization: Bearer 令牌
+// 也接受以令牌为密码的 Basic 认证（用户名任意），便于在浏览器中打开评审历史页面
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		_, token, ok = r.BasicAuth()
+	}
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.APIToken)) == 1
+}
+
+// handleReviews 处理 POST /reviews：创建评审任务，入队后返回 202 和任务状态
+func (s *Server) handleReviews(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadBytes))
+	if err != nil {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	var req ReviewRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("解析请求失败: %v", err))
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 先保存任务状态，评审协程取出任务时总能找到
+	job := &Job{ID: newID(), Status: JobQueued, CreatedAt: time.Now()}
+	if err := s.queue.SaveJob(job); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, fmt.Sprintf("保存评审任务失败: %v", err))
+		return
+	}
+	if !s.enqueue(&Task{ID: newID(), Key: "job:" + job.ID, JobID: job.ID, Request: &req}) {
+		s.failJob(job.ID, "review queue is full")
+		writeJSONError(w, http.StatusServiceUnavailable, "review queue is full")
+		return
+	}
+	w.Header().Set("Location", "/reviews/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// handleReview 处理 GET /reviews/{id}：返回任务的状态，任务成功时包含评审报告
+func (s *Server) handleReview(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	job, err := s.queue.Job(strings.TrimPrefix(r.URL.Path, "/reviews/"))
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if job == nil {
+		writeJSONError(w, http.StatusNotFound, "review not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// Status 服务的队列和评审状态
+type Status struct {
+	Workers int `json:"workers"`
+	// 等待评审、等待仓库空闲和等待重试的任务数
+	Queued   int `json:"queued"`
+	Deferred int `json:"deferred"`
+	Retrying int `json:"retrying"`
+	// 服务启动以来完成和最终失败的任务数
+	Completed int          `json:"completed"`
+	Failed    int          `json:"failed"`
+	Running   []TaskStatus `json:"running"`
+	Model     *ModelStatus `json:"model_calls,omitempty"`
+}
+
+// TaskStatus 正在评审的任务
+type TaskStatus struct {
+	Task      string    `json:"task"`
+	Repo      string    `json:"repo,omitempty"`
+	Attempts  int       `json:"attempts,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// ModelStatus 正在进行的模型调用数
+type ModelStatus struct {
+	Running int            `json:"running"`
+	Repos   map[string]int `json:"repos"`
+}
+
+// handleStatus 处理 GET /status：返回队列长度、正在评审的任务和模型调用数
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	queued, err := s.queue.Len()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	status := Status{
+		Workers:   s.opts.Workers,
+		Queued:    queued,
+		Deferred:  len(s.deferred),
+		Retrying:  s.retrying,
+		Completed: s.completed,
+		Failed:    s.failed,
+		Running:   []TaskStatus{},
+	}
+	for _, run := range s.running {
+		status.Running = append(status.Running, TaskStatus{
+			Task:      run.task.String(),
+			Repo:      run.task.Repo(),
+			Attempts:  run.task.Attempts,
+			StartedAt: run.started,
+		})
+	}
+	s.mu.Unlock()
+	sort.Slice(status.Running, func(i, j int) bool {
+		return status.Running[i].StartedAt.Before(status.Running[j].StartedAt)
+	})
+
+	if s.opts.Scheduler != nil {
+		running, repos := s.opts.Scheduler.Stats()
+		status.Model = &ModelStatus{Running: running, Repos: repos}
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// runJob 执行 API 提交的评审任务并保存结果，willRetry 为 true 时失败的任务重新处于 queued 状态
+func (s *Server) runJob(t *Task, willRetry bool) error {
+	job, err := s.queue.Job(t.JobID)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		// 任务状态已过期，不再评审
+		log.Printf("%s 的状态不存在，跳过\n", t)
+		return nil
+	}
+
+	start := time.Now()
+	log.Printf("开始%s\n", t)
+	job.Status, job.StartedAt, job.FinishedAt = JobRunning, &start, nil
+	s.queue.SaveJob(job)
+
+	report, err := s.executeJob(t.Request)
+	now := time.Now()
+	switch {
+	case err == nil:
+		log.Printf("%s 完成，耗时 %s\n", t, time.Since(start).Round(time.Second))
+		job.Status, job.FinishedAt, job.Report, job.Error = JobSucceeded, &now, report, ""
+		// 补丁任务不属于任何仓库，不记录到评审历史
+		if repo := t.Repo(); repo != "" {
+			s.record(history.Run{Repo: repo, Ref: t.Request.Head, Trigger: "api"}, report)
+		}
+	case willRetry:
+		log.Printf("%s 失败: %v\n", t, err)
+		job.Status, job.Attempts, job.Error = JobQueued, t.Attempts+1, err.Error()
+	default:
+		log.Printf("%s 失败: %v\n", t, err)
+		job.Status, job.FinishedAt, job.Attempts, job.Error = JobFailed, &now, t.Attempts+1, err.Error()
+	}
+	if saveErr := s.queue.SaveJob(job); saveErr != nil {
+		log.Printf("保存%s的状态失败: %v\n", t, saveErr)
+	}
+	return err
+}
+
+// failJob 把未能入队的任务标记为失败
+func (s *Server) failJob(id, reason string) {
+	job, err := s.queue.Job(id)
+	if err != nil || job == nil {
+		return
+	}
+	now := time.Now()
+	job.Status, job.FinishedAt, job.Error = JobFailed, &now, reason
+	s.queue.SaveJob(job)
+}
+
+// executeJob 检出仓库或写入补丁文件后调用评审
+func (s *Server) executeJob(req *ReviewRequest) ([]byte, error) {
+	if req.Diff != "" {
+		// 补丁在独立的临时目录中评审，不依赖任何仓库
+		dir, err := os.MkdirTemp("", "cr-job-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		patch := filepath.Join(dir, "review.diff")
+		if err := os.WriteFile(patch, []byte(req.Diff), 0644); err != nil {
+			return nil, err
+		}
+		return s.opts.RunJob(req, dir, []string{"--diff-file", patch})
+	}
+
+	e := req.event()
+	unlock := s.opts.Workspace.Lock(e)
+	defer unlock()
+	checkout, err := s.opts.Workspace.Checkout(e)
+	if err != nil {
+		return nil, err
+	}
+	return s.opts.RunJob(req, checkout.Dir, []string{"--commit-range", checkout.Range})
+}
+
+// writeJSON 输出 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError 输出 JSON 格式的错误
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
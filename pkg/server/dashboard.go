@@ -0,0 +1,357 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/history"
+	"github.com/icatw/ai-cr-tool/pkg/i18n"
+	"github.com/icatw/ai-cr-tool/pkg/review"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+const (
+	// chartReviews 趋势图中展示的最近评审数
+	chartReviews = 60
+	// 趋势图的尺寸
+	chartWidth  = 720
+	chartHeight = 160
+)
+
+// chartSeverities 趋势图中自下而上堆叠的严重程度及颜色
+var chartSeverities = []struct {
+	severity types.SeverityLevel
+	color    string
+}{
+	{types.SeverityError, "#d73a49"},
+	{types.SeverityWarning, "#e36209"},
+	{types.SeverityInfo, "#0366d6"},
+}
+
+// handleDashboard 注册评审历史页面：/dashboard 列出仓库，/dashboard/repo?name=<仓库> 列出仓库的评审和趋势，
+// /dashboard/reviews/<ID> 查看评审报告（?format=json 返回 JSON 报告）
+// 各页面默认使用服务的界面语言（--locale），?lang=en 切换页面和报告的语言，页面中的链接保持该语言
+func (s *Server) handleDashboard(mux *http.ServeMux) {
+	mux.HandleFunc("/dashboard", s.dashboardPage(s.handleDashboardIndex))
+	mux.HandleFunc("/dashboard/repo", s.dashboardPage(s.handleDashboardRepo))
+	mux.HandleFunc("/dashboard/reviews/", s.dashboardPage(s.handleDashboardReview))
+}
+
+// dashboardPage 校验访问令牌，未通过时要求浏览器进行 Basic 认证
+func (s *Server) dashboardPage(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="ai-cr-tool", charset="UTF-8"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// handleDashboardIndex 列出有评审记录的仓库
+func (s *Server) handleDashboardIndex(w http.ResponseWriter, r *http.Request) {
+	repos, err := s.opts.History.Repos()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	renderDashboard(w, r, "index", struct {
+		Repos []history.RepoSummary
+	}{repos})
+}
+
+// repoPage 仓库评审页面的数据
+type repoPage struct {
+	Repo string
+	// 评审记录，最近的在前
+	Entries []history.Run
+	Chart   *trendChart
+}
+
+// handleDashboardRepo 列出仓库的评审记录和问题数趋势
+func (s *Server) handleDashboardRepo(w http.ResponseWriter, r *http.Request) {
+	repo := r.URL.Query().Get("name")
+	runs, err := s.opts.History.Runs(history.Filter{Repo: repo, Limit: maxHistoryPerRepo})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(runs) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	// 趋势图从早到晚排列
+	chronological := make([]history.Run, 0, len(runs))
+	for i := len(runs) - 1; i >= 0; i-- {
+		chronological = append(chronological, runs[i])
+	}
+	lang, ok := dashboardLang(w, r)
+	if !ok {
+		return
+	}
+	renderDashboard(w, r, "repo", repoPage{Repo: repo, Entries: runs, Chart: newTrendChart(chronological, lang)})
+}
+
+// handleDashboardReview 以 HTML 格式展示评审报告
+func (s *Server) handleDashboardReview(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/dashboard/reviews/")
+	data, err := s.opts.History.Report(id)
+	if errors.Is(err, history.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if r.URL.Query().Get("format") == "json" {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+		return
+	}
+	lang, ok := dashboardLang(w, r)
+	if !ok {
+		return
+	}
+	report, err := review.ParseJSONReport(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	content, err := report.NewReporter(lang).Generate(report.ToIssues(), review.HTMLFormat)
+	if err != nil {
+		http.Error(w, lang.T("dashboard.render_failed", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(content)
+}
+
+// trendChart 问题数趋势图，每次评审一根按严重程度堆叠的柱子，质量分为折线
+type trendChart struct {
+	Width, Height int
+	Bars          []trendBar
+	// 质量分折线的顶点，0 分在底部、100 分在顶部
+	ScorePoints string
+	// 问题数最多的一次评审的问题数，即纵轴的上限
+	MaxIssues  int
+	First      time.Time
+	Last       time.Time
+	Severities []chartLegend
+}
+
+// trendBar 一次评审的柱子
+type trendBar struct {
+	X, Width float64
+	Segments []trendSegment
+	Title    string
+}
+
+// trendSegment 柱子中一种严重程度的部分
+type trendSegment struct {
+	Y, Height float64
+	Color     string
+}
+
+// chartLegend 图例
+type chartLegend struct {
+	Severity string
+	Color    string
+}
+
+// newTrendChart 根据最近的评审记录生成趋势图，entries 按评审时间从早到晚排列，柱子的提示文字使用 lang
+func newTrendChart(entries []history.Run, lang i18n.Lang) *trendChart {
+	if len(entries) > chartReviews {
+		entries = entries[len(entries)-chartReviews:]
+	}
+	chart := &trendChart{Width: chartWidth, Height: chartHeight, First: entries[0].ReviewedAt, Last: entries[len(entries)-1].ReviewedAt}
+	for _, s := range chartSeverities {
+		chart.Severities = append(chart.Severities, chartLegend{Severity: string(s.severity), Color: s.color})
+	}
+	for _, e := range entries {
+		chart.MaxIssues = max(chart.MaxIssues, e.Issues)
+	}
+	scale := float64(chartHeight) / float64(max(chart.MaxIssues, 1))
+	slot := float64(chartWidth) / float64(len(entries))
+
+	var points []string
+	for i, e := range entries {
+		bar := trendBar{
+			X:     round1(float64(i)*slot + slot*0.15),
+			Width: round1(slot * 0.7),
+			Title: lang.T("dashboard.bar_title", e.ReviewedAt.Local().Format("2006-01-02 15:04"), e.Issues, e.Score),
+		}
+		y := float64(chartHeight)
+		for _, s := range chartSeverities {
+			count := e.BySeverity[string(s.severity)]
+			if count == 0 {
+				continue
+			}
+			height := float64(count) * scale
+			y -= height
+			bar.Segments = append(bar.Segments, trendSegment{Y: round1(y), Height: round1(height), Color: s.color})
+		}
+		chart.Bars = append(chart.Bars, bar)
+		scoreY := float64(chartHeight) * (1 - float64(e.Score)/100)
+		points = append(points, fmt.Sprintf("%.1f,%.1f", float64(i)*slot+slot/2, scoreY))
+	}
+	chart.ScorePoints = strings.Join(points, " ")
+	return chart
+}
+
+// round1 保留一位小数，缩短 SVG 中的坐标
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// dashboardLang 返回页面使用的语言：?lang 指定的语言，未指定时为服务的界面语言；语言无效时返回 400
+func dashboardLang(w http.ResponseWriter, r *http.Request) (i18n.Lang, bool) {
+	value := r.URL.Query().Get("lang")
+	if value == "" {
+		return i18n.Locale(), true
+	}
+	lang, err := i18n.Parse(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+	return lang, true
+}
+
+// renderDashboard 使用指定的模板输出页面，页面文本使用请求的语言，请求指定了 ?lang 时页面中的链接也带上该参数
+func renderDashboard(w http.ResponseWriter, r *http.Request, name string, data interface{}) {
+	lang, ok := dashboardLang(w, r)
+	if !ok {
+		return
+	}
+	query := ""
+	if r.URL.Query().Get("lang") != "" {
+		query = "lang=" + string(lang)
+	}
+	tmpl, err := dashboardTemplates.Clone()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tmpl.Funcs(template.FuncMap{
+		"t": lang.T,
+		"link": func(path string) string {
+			if query == "" {
+				return path
+			}
+			return path + "?" + query
+		},
+		"repoURL": func(repo string) string {
+			link := "/dashboard/repo?name=" + url.QueryEscape(repo)
+			if query != "" {
+				link += "&" + query
+			}
+			return link
+		},
+	})
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tmpl.ExecuteTemplate(w, name, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// dashboardTemplates 评审历史页面的模板，t、link 和 repoURL 在 renderDashboard 中按请求的语言替换
+var dashboardTemplates = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"time": func(t time.Time) string {
+		return t.Local().Format("2006-01-02 15:04")
+	},
+	"t":       i18n.Default.T,
+	"link":    func(path string) string { return path },
+	"repoURL": func(repo string) string { return repo },
+	"severity": func(e history.Run, severity string) int {
+		return e.BySeverity[severity]
+	},
+}).Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html lang="{{t "dashboard.html_lang"}}">
+<head>
+<meta charset="utf-8">
+<title>{{.}} - {{t "dashboard.title"}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", "PingFang SC", "Microsoft YaHei", sans-serif; margin: 2em auto; max-width: 960px; color: #24292e; padding: 0 1em; }
+table { border-collapse: collapse; width: 100%; margin-top: 1em; }
+th, td { text-align: left; padding: 6px 10px; border-bottom: 1px solid #e1e4e8; }
+th { background: #f6f8fa; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+a { color: #0366d6; text-decoration: none; }
+a:hover { text-decoration: underline; }
+.muted { color: #6a737d; font-size: 0.9em; }
+.legend span { display: inline-block; margin-right: 1.2em; }
+.legend i { display: inline-block; width: 10px; height: 10px; margin-right: 4px; }
+</style>
+</head>
+<body>
+{{end}}
+
+{{define "index"}}{{template "head" (t "dashboard.repositories")}}
+<h1>{{t "dashboard.title"}}</h1>
+{{if .Repos}}
+<table>
+<tr><th>{{t "dashboard.repository"}}</th><th>{{t "dashboard.reviews"}}</th><th>{{t "dashboard.latest"}}</th><th>{{t "dashboard.issues"}}</th><th>{{t "dashboard.score"}}</th></tr>
+{{range .Repos}}
+<tr>
+<td><a href="{{repoURL .Repo}}">{{.Repo}}</a></td>
+<td class="num">{{.Reviews}}</td>
+<td>{{time .Latest.ReviewedAt}}</td>
+<td class="num">{{.Latest.Issues}}</td>
+<td class="num">{{.Latest.Score}} ({{.Latest.Grade}})</td>
+</tr>
+{{end}}
+</table>
+{{else}}
+<p class="muted">{{t "dashboard.empty"}}</p>
+{{end}}
+</body>
+</html>
+{{end}}
+
+{{define "repo"}}{{template "head" .Repo}}
+<p><a href="{{link "/dashboard"}}">← {{t "dashboard.all_repositories"}}</a></p>
+<h1>{{.Repo}}</h1>
+{{with .Chart}}
+<h2>{{t "dashboard.trend"}}</h2>
+<p class="legend">{{range .Severities}}<span><i style="background: {{.Color}}"></i>{{.Severity}}</span>{{end}}<span><i style="background: #28a745"></i>{{t "dashboard.score"}}</span></p>
+<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" role="img" style="border-bottom: 1px solid #e1e4e8; overflow: visible">
+{{range .Bars}}<g><title>{{.Title}}</title>{{$bar := .}}{{range .Segments}}<rect x="{{$bar.X}}" y="{{.Y}}" width="{{$bar.Width}}" height="{{.Height}}" fill="{{.Color}}"></rect>{{end}}<rect x="{{.X}}" y="0" width="{{.Width}}" height="{{$.Chart.Height}}" fill="transparent"></rect></g>
+{{end}}
+<polyline points="{{.ScorePoints}}" fill="none" stroke="#28a745" stroke-width="2"></polyline>
+</svg>
+<p class="muted">{{t "dashboard.chart_note" (len .Bars) (time .First) (time .Last) .MaxIssues}}</p>
+{{end}}
+<h2>{{t "dashboard.history"}}</h2>
+<table>
+<tr><th>{{t "dashboard.time"}}</th><th>Pull request</th><th>{{t "dashboard.ref"}}</th><th>{{t "dashboard.files"}}</th><th>error</th><th>warning</th><th>info</th><th>{{t "dashboard.score"}}</th><th></th></tr>
+{{range .Entries}}
+<tr>
+<td>{{time .ReviewedAt}}</td>
+<td>{{if .URL}}<a href="{{.URL}}">{{.Change}}</a>{{else if .Change}}{{.Change}}{{else}}<span class="muted">API</span>{{end}}</td>
+<td><code>{{if gt (len .Ref) 10}}{{slice .Ref 0 10}}{{else}}{{.Ref}}{{end}}</code></td>
+<td class="num">{{.Files}}</td>
+<td class="num">{{severity . "error"}}</td>
+<td class="num">{{severity . "warning"}}</td>
+<td class="num">{{severity . "info"}}</td>
+<td class="num">{{.Score}} ({{.Grade}})</td>
+<td><a href="{{link (printf "/dashboard/reviews/%s" .ID)}}">{{t "dashboard.report"}}</a></td>
+</tr>
+{{end}}
+</table>
+</body>
+</html>
+{{end}}
+`))
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrQueueClosed 队列已关闭且没有等待中的任务
+var ErrQueueClosed = errors.New("评审队列已关闭")
+
+// Task 评审队列中的任务：webhook 事件或 API 提交的评审任务
+type Task struct {
+	// 每次入队生成的唯一标识
+	ID string `json:"id"`
+	// 合并任务的标识，同一 key 的任务在等待期间只保留最新的一个
+	Key   string `json:"key"`
+	Event *Event `json:"event,omitempty"`
+	// API 任务的 ID 和请求
+	JobID   string         `json:"job_id,omitempty"`
+	Request *ReviewRequest `json:"request,omitempty"`
+	// 已经失败的次数
+	Attempts int `json:"attempts,omitempty"`
+}
+
+// Repo 返回任务所在的仓库，同一仓库的任务依次执行；补丁任务不属于任何仓库，返回空字符串
+func (t *Task) Repo() string {
+	switch {
+	case t.Event != nil:
+		return t.Event.Platform + ":" + t.Event.Repo
+	case t.Request != nil && t.Request.CloneURL != "":
+		e := t.Request.event()
+		return e.Platform + ":" + e.Repo
+	default:
+		return ""
+	}
+}
+
+// String 返回便于日志阅读的任务描述
+func (t *Task) String() string {
+	if t.JobID != "" {
+		return "评审任务 " + t.JobID
+	}
+	return t.Event.String()
+}
+
+// Queue 评审队列的存储，同时保存 API 任务的状态
+type Queue interface {
+	// Push 加入任务，队列已满时返回 false
+	// 同一 key 的任务已在等待时，replace 为 true 则替换为新的任务，否则保留原任务
+	Push(t *Task, replace bool) (bool, error)
+	// Pop 取出最早加入的任务，队列为空时等待；队列关闭后返回 ErrQueueClosed
+	Pop(ctx context.Context) (*Task, error)
+	// Done 标记取出的任务已经结束
+	Done(t *Task) error
+	// Len 返回等待中的任务数
+	Len() (int, error)
+	// SaveJob 保存 API 任务的状态
+	SaveJob(job *Job) error
+	// Job 读取 API 任务的状态，任务不存在时返回 nil
+	Job(id string) (*Job, error)
+	// Close 停止接收新的任务
+	Close() error
+}
+
+// MemoryQueue 内存中的评审队列，服务停止后等待中的任务和 API 任务的结果都会丢失
+type MemoryQueue struct {
+	size int
+
+	mu     sync.Mutex
+	keys   []string
+	tasks  map[string]*Task
+	closed bool
+	// 有新任务时通知等待的 Pop，队列关闭时关闭 done
+	notify chan struct{}
+	done   chan struct{}
+	jobs   map[string]*Job
+	// 按结束顺序记录已结束任务的 ID
+	finished []string
+}
+
+// NewMemoryQueue 创建最多容纳 size 个等待任务的内存队列
+func NewMemoryQueue(size int) *MemoryQueue {
+	return &MemoryQueue{
+		size:   size,
+		tasks:  make(map[string]*Task),
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		jobs:   make(map[string]*Job),
+	}
+}
+
+func (q *MemoryQueue) Push(t *Task, replace bool) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return false, ErrQueueClosed
+	}
+	if _, ok := q.tasks[t.Key]; ok {
+		if replace {
+			q.tasks[t.Key] = t
+		}
+		return true, nil
+	}
+	if len(q.keys) >= q.size {
+		return false, nil
+	}
+	q.keys = append(q.keys, t.Key)
+	q.tasks[t.Key] = t
+	q.signal()
+	return true, nil
+}
+
+func (q *MemoryQueue) Pop(ctx context.Context) (*Task, error) {
+	for {
+		q.mu.Lock()
+		if len(q.keys) > 0 {
+			key := q.keys[0]
+			q.keys = q.keys[1:]
+			t := q.tasks[key]
+			delete(q.tasks, key)
+			// 还有任务时继续唤醒其他等待者
+			if len(q.keys) > 0 {
+				q.signal()
+			}
+			q.mu.Unlock()
+			return t, nil
+		}
+		closed := q.closed
+		q.mu.Unlock()
+		if closed {
+			return nil, ErrQueueClosed
+		}
+
+		select {
+		case <-q.notify:
+		case <-q.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// signal 唤醒一个等待的 Pop，调用方需持有锁
+func (q *MemoryQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (q *MemoryQueue) Done(t *Task) error {
+	return nil
+}
+
+func (q *MemoryQueue) Len() (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.keys), nil
+}
+
+func (q *MemoryQueue) SaveJob(job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	previous, known := q.jobs[job.ID]
+	saved := *job
+	q.jobs[job.ID] = &saved
+	if job.FinishedAt != nil && (!known || previous.FinishedAt == nil) {
+		q.finished = append(q.finished, job.ID)
+		if len(q.finished) > maxFinishedJobs {
+			delete(q.jobs, q.finished[0])
+			q.finished = q.finished[1:]
+		}
+	}
+	return nil
+}
+
+func (q *MemoryQueue) Job(id string) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+	saved := *job
+	return &saved, nil
+}
+
+func (q *MemoryQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed = true
+		close(q.done)
+	}
+	return nil
+}
@@ -0,0 +1,357 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// redisPollInterval 队列为空时轮询 Redis 的间隔
+	redisPollInterval = time.Second
+	// redisJobTTL API 任务的状态在 Redis 中的保留时间
+	redisJobTTL = 7 * 24 * time.Hour
+)
+
+// pushScript 合并同一 key 的任务后入队，队列已满时返回0
+// KEYS: 队列、等待中的任务；ARGV: key、任务、队列上限、是否替换
+const pushScript = `
+if redis.call('HEXISTS', KEYS[2], ARGV[1]) == 1 then
+	if ARGV[4] == '1' then redis.call('HSET', KEYS[2], ARGV[1], ARGV[2]) end
+	return 1
+end
+if redis.call('LLEN', KEYS[1]) >= tonumber(ARGV[3]) then return 0 end
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+redis.call('LPUSH', KEYS[1], ARGV[1])
+return 1`
+
+// popScript 取出最早的任务并移入进行中的任务，队列为空时返回 nil
+// KEYS: 队列、等待中的任务、进行中的任务；ARGV: 无
+const popScript = `
+local key = redis.call('RPOP', KEYS[1])
+if not key then return false end
+local task = redis.call('HGET', KEYS[2], key)
+redis.call('HDEL', KEYS[2], key)
+if not task then return false end
+redis.call('HSET', KEYS[3], cjson.decode(task).id, task)
+return task`
+
+// RedisQueue 保存在 Redis 中的评审队列，服务重启后等待中的任务继续评审，
+// 上次停止时未结束的任务重新排队
+type RedisQueue struct {
+	conn   *redisConn
+	prefix string
+	size   int
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewRedisQueue 连接 redis:// 或 rediss:// 地址指定的 Redis，并把上次未结束的任务重新排队
+// 地址的格式为 redis://[[用户名]:密码@]主机[:端口][/数据库编号]，size 为等待任务数的上限
+func NewRedisQueue(rawURL string, size int) (*RedisQueue, error) {
+	conn, err := parseRedisURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	q := &RedisQueue{conn: conn, prefix: "cr:", size: size}
+	if _, err := conn.do("PING"); err != nil {
+		return nil, fmt.Errorf("连接 Redis 失败: %v", err)
+	}
+	if err := q.recover(); err != nil {
+		return nil, fmt.Errorf("恢复未结束的任务失败: %v", err)
+	}
+	return q, nil
+}
+
+// recover 把进行中的任务放回队列，同一 key 已有等待中的任务时保留较新的任务
+func (q *RedisQueue) recover() error {
+	reply, err := q.conn.do("HGETALL", q.prefix+"running")
+	if err != nil {
+		return err
+	}
+	fields, _ := reply.([]interface{})
+	for i := 0; i+1 < len(fields); i += 2 {
+		id, _ := fields[i].(string)
+		data, _ := fields[i+1].(string)
+		var t Task
+		if err := json.Unmarshal([]byte(data), &t); err == nil {
+			if _, err := q.push(&t, false, true); err != nil {
+				return err
+			}
+		}
+		if _, err := q.conn.do("HDEL", q.prefix+"running", id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *RedisQueue) Push(t *Task, replace bool) (bool, error) {
+	return q.push(t, replace, false)
+}
+
+// push 执行入队脚本，force 为 true 时不检查队列上限
+func (q *RedisQueue) push(t *Task, replace, force bool) (bool, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return false, err
+	}
+	limit := strconv.Itoa(q.size)
+	if force {
+		limit = strconv.Itoa(math.MaxInt32)
+	}
+	flag := "0"
+	if replace {
+		flag = "1"
+	}
+	reply, err := q.conn.do("EVAL", pushScript, "2", q.prefix+"queue", q.prefix+"pending",
+		t.Key, string(data), limit, flag)
+	if err != nil {
+		return false, err
+	}
+	return reply == int64(1), nil
+}
+
+func (q *RedisQueue) Pop(ctx context.Context) (*Task, error) {
+	for {
+		q.mu.Lock()
+		closed := q.closed
+		q.mu.Unlock()
+		if closed {
+			// 等待中的任务留在 Redis 中，下次启动后继续评审
+			return nil, ErrQueueClosed
+		}
+
+		reply, err := q.conn.do("EVAL", popScript, "3", q.prefix+"queue", q.prefix+"pending", q.prefix+"running")
+		if err != nil {
+			return nil, err
+		}
+		if data, ok := reply.(string); ok {
+			var t Task
+			if err := json.Unmarshal([]byte(data), &t); err != nil {
+				return nil, fmt.Errorf("解析任务失败: %v", err)
+			}
+			return &t, nil
+		}
+
+		select {
+		case <-time.After(redisPollInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (q *RedisQueue) Done(t *Task) error {
+	_, err := q.conn.do("HDEL", q.prefix+"running", t.ID)
+	return err
+}
+
+func (q *RedisQueue) Len() (int, error) {
+	reply, err := q.conn.do("LLEN", q.prefix+"queue")
+	if err != nil {
+		return 0, err
+	}
+	n, _ := reply.(int64)
+	return int(n), nil
+}
+
+func (q *RedisQueue) SaveJob(job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	_, err = q.conn.do("SET", q.prefix+"job:"+job.ID, string(data), "EX", strconv.Itoa(int(redisJobTTL.Seconds())))
+	return err
+}
+
+func (q *RedisQueue) Job(id string) (*Job, error) {
+	reply, err := q.conn.do("GET", q.prefix+"job:"+id)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := reply.(string)
+	if !ok {
+		return nil, nil
+	}
+	var job Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return nil, fmt.Errorf("解析任务状态失败: %v", err)
+	}
+	return &job, nil
+}
+
+// Close 停止取出任务，已入队的任务留在 Redis 中；之后仍可以入队，用于保存重试的任务
+func (q *RedisQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	return nil
+}
+
+// redisError Redis 返回的错误
+type redisError string
+
+func (e redisError) Error() string {
+	return "Redis: " + string(e)
+}
+
+// redisConn 单个 Redis 连接，命令依次执行，连接出错后下一条命令重新连接
+type redisConn struct {
+	addr     string
+	user     string
+	password string
+	db       int
+	tls      bool
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// parseRedisURL 解析 Redis 地址
+func parseRedisURL(rawURL string) (*redisConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") || u.Host == "" {
+		return nil, fmt.Errorf("无效的 Redis 地址: %s", rawURL)
+	}
+	c := &redisConn{addr: u.Host, tls: u.Scheme == "rediss"}
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		c.user = u.User.Username()
+		c.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if c.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("无效的 Redis 数据库编号: %s", db)
+		}
+	}
+	return c, nil
+}
+
+// do 执行一条命令并返回结果：字符串、整数、nil 或数组
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		if err := c.connect(); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := c.command(args)
+	var redisErr redisError
+	if err != nil && !errors.As(err, &redisErr) {
+		// 连接可能已经损坏，下一条命令重新连接
+		c.conn.Close()
+		c.conn = nil
+	}
+	return reply, err
+}
+
+// connect 建立连接并完成认证和选择数据库，调用方需持有锁
+func (c *redisConn) connect() error {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	var conn net.Conn
+	var err error
+	if c.tls {
+		conn, err = tls.DialWithDialer(dialer, "tcp", c.addr, nil)
+	} else {
+		conn, err = dialer.Dial("tcp", c.addr)
+	}
+	if err != nil {
+		return err
+	}
+	c.conn, c.r = conn, bufio.NewReader(conn)
+
+	setup := [][]string{}
+	switch {
+	case c.user != "":
+		// Redis 6 的 ACL 用户
+		setup = append(setup, []string{"AUTH", c.user, c.password})
+	case c.password != "":
+		setup = append(setup, []string{"AUTH", c.password})
+	}
+	if c.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.db)})
+	}
+	for _, args := range setup {
+		if _, err := c.command(args); err != nil {
+			conn.Close()
+			c.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+// command 发送命令并读取回复，调用方需持有锁
+func (c *redisConn) command(args []string) (interface{}, error) {
+	c.conn.SetDeadline(time.Now().Add(10 * time.Second))
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+// readReply 读取一个 RESP 回复
+func (c *redisConn) readReply() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("Redis 回复格式错误")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("Redis 回复格式错误: %q", line)
+	}
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"sync"
+)
+
+// Scheduler 跨仓库的模型调用调度器
+// 同时限制全局并发和单个仓库的并发，有空闲名额时按仓库轮询分配，
+// 避免某个超大仓库的评审占满所有名额导致其他仓库长时间等待
+type Scheduler struct {
+	mu       sync.Mutex
+	global   int
+	perRepo  int
+	running  int
+	repoRuns map[string]int
+	// 每个仓库的等待队列
+	waiters map[string][]chan struct{}
+	// 有等待者的仓库，按轮询顺序排列
+	order []string
+}
+
+// NewScheduler 创建调度器，global 和 perRepo 为0表示不限制
+func NewScheduler(global, perRepo int) *Scheduler {
+	return &Scheduler{
+		global:   global,
+		perRepo:  perRepo,
+		repoRuns: make(map[string]int),
+		waiters:  make(map[string][]chan struct{}),
+	}
+}
+
+// Acquire 为指定仓库申请一个执行名额，返回释放函数
+// ctx 取消时放弃等待并返回错误
+func (s *Scheduler) Acquire(ctx context.Context, repo string) (func(), error) {
+	s.mu.Lock()
+	if s.canRun(repo) && len(s.waiters[repo]) == 0 && len(s.order) == 0 {
+		s.start(repo)
+		s.mu.Unlock()
+		return s.releaseFunc(repo), nil
+	}
+
+	ready := make(chan struct{})
+	if len(s.waiters[repo]) == 0 {
+		s.order = append(s.order, repo)
+	}
+	s.waiters[repo] = append(s.waiters[repo], ready)
+	s.dispatch()
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return s.releaseFunc(repo), nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-ready:
+			// 名额已经分配，直接归还
+			s.finish(repo)
+		default:
+			s.removeWaiter(repo, ready)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// Stats 返回当前全局运行数和各仓库的运行数
+func (s *Scheduler) Stats() (int, map[string]int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := make(map[string]int, len(s.repoRuns))
+	for repo, n := range s.repoRuns {
+		runs[repo] = n
+	}
+	return s.running, runs
+}
+
+// ForRepo 返回绑定到指定仓库的限流器，供评审引擎使用
+func (s *Scheduler) ForRepo(repo string) *RepoLimiter {
+	return &RepoLimiter{scheduler: s, repo: repo}
+}
+
+// RepoLimiter 绑定到单个仓库的限流器
+type RepoLimiter struct {
+	scheduler *Scheduler
+	repo      string
+}
+
+// Acquire 申请执行名额，返回释放函数
+func (l *RepoLimiter) Acquire() func() {
+	release, _ := l.scheduler.Acquire(context.Background(), l.repo)
+	return release
+}
+
+// canRun 判断仓库是否还有可用名额，调用方需持有锁
+func (s *Scheduler) canRun(repo string) bool {
+	if s.global > 0 && s.running >= s.global {
+		return false
+	}
+	return s.perRepo <= 0 || s.repoRuns[repo] < s.perRepo
+}
+
+// start 占用名额，调用方需持有锁
+func (s *Scheduler) start(repo string) {
+	s.running++
+	s.repoRuns[repo]++
+}
+
+// finish 归还名额并唤醒等待者，调用方需持有锁
+func (s *Scheduler) finish(repo string) {
+	s.running--
+	s.repoRuns[repo]--
+	if s.repoRuns[repo] == 0 {
+		delete(s.repoRuns, repo)
+	}
+	s.dispatch()
+}
+
+// releaseFunc 生成只会生效一次的释放函数
+func (s *Scheduler) releaseFunc(repo string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.finish(repo)
+		})
+	}
+}
+
+// dispatch 按仓库轮询把空闲名额分配给等待者，调用方需持有锁
+func (s *Scheduler) dispatch() {
+	for len(s.order) > 0 {
+		if s.global > 0 && s.running >= s.global {
+			return
+		}
+
+		// 找到下一个有名额的仓库
+		idx := -1
+		for i, repo := range s.order {
+			if s.canRun(repo) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return
+		}
+
+		repo := s.order[idx]
+		queue := s.waiters[repo]
+		ready := queue[0]
+		s.waiters[repo] = queue[1:]
+
+		// 被服务过的仓库移到队尾，其余仓库保持原有顺序
+		s.order = append(s.order[:idx], s.order[idx+1:]...)
+		if len(s.waiters[repo]) > 0 {
+			s.order = append(s.order, repo)
+		} else {
+			delete(s.waiters, repo)
+		}
+
+		s.start(repo)
+		close(ready)
+	}
+}
+
+// removeWaiter 移除放弃等待的请求，调用方需持有锁
+func (s *Scheduler) removeWaiter(repo string, ready chan struct{}) {
+	queue := s.waiters[repo]
+	for i, w := range queue {
+		if w == ready {
+			queue = append(queue[:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(queue) > 0 {
+		s.waiters[repo] = queue
+		return
+	}
+
+	delete(s.waiters, repo)
+	for i, r := range s.order {
+		if r == repo {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,376 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/icatw/ai-cr-tool/pkg/history"
+)
+
+const (
+	// maxPayloadBytes webhook 请求体的大小上限，与 GitHub 的上限一致
+	maxPayloadBytes = 25 << 20
+	// DefaultQueueSize 等待评审的任务数上限，队列满时拒绝新的事件
+	DefaultQueueSize = 100
+	// retryDelay 评审失败后第一次重试前的等待时间，之后每次加倍
+	retryDelay = 30 * time.Second
+	// maxHistoryPerRepo 每个仓库在评审历史中保留的评审数
+	maxHistoryPerRepo = 200
+)
+
+// ReviewFunc 评审已检出的 pull request 并发布结果，返回 JSON 格式的评审报告，没有需要评审的改动时返回 nil
+type ReviewFunc func(e *Event, c *Checkout) ([]byte, error)
+
+// Options 服务模式的选项
+type Options struct {
+	// GitHub webhook 的签名密钥，为空时不接收 GitHub 事件
+	GitHubSecret string
+	// GitLab webhook 的令牌，为空时不接收 GitLab 事件
+	GitLabToken string
+	// 同时评审的任务数
+	Workers int
+	// 评审失败后的重试次数
+	Retries int
+	// 评审队列，为空时使用内存队列
+	Queue Queue
+	// 模型调用的调度器，用于在状态接口中报告模型调用数，可以为空
+	Scheduler *Scheduler
+	// 仓库的本地克隆
+	Workspace *Workspace
+	// 评审和发布结果
+	Review ReviewFunc
+	// REST API 的访问令牌，为空时不提供 API
+	APIToken string
+	// 执行 API 提交的评审任务
+	RunJob JobFunc
+	// 评审历史，为空时不记录；设置了 APIToken 时在 /dashboard 提供浏览历史评审的页面
+	History history.Store
+}
+
+// runningTask 正在评审的任务
+type runningTask struct {
+	task    *Task
+	started time.Time
+}
+
+// Server 接收代码托管平台的 webhook 和 REST API 的评审请求，在后台排队评审
+// 同一 pull request 在等待期间收到的多个事件合并为一次评审；同一仓库的任务依次评审，
+// 等待仓库空闲的任务暂存起来，不占用评审协程
+type Server struct {
+	opts  Options
+	queue Queue
+
+	mu sync.Mutex
+	// 正在评审的任务，按任务的唯一标识索引
+	running map[string]*runningTask
+	// 各仓库正在评审的任务数
+	repoRuns map[string]int
+	// 等待仓库空闲的任务
+	deferred []*Task
+	// 等待重试、已完成和最终失败的任务数
+	retrying  int
+	completed int
+	failed    int
+	wg        sync.WaitGroup
+}
+
+// NewServer 创建服务，Workers 小于1时按1处理
+func NewServer(opts Options) *Server {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	queue := opts.Queue
+	if queue == nil {
+		queue = NewMemoryQueue(DefaultQueueSize)
+	}
+	return &Server{
+		opts:     opts,
+		queue:    queue,
+		running:  make(map[string]*runningTask),
+		repoRuns: make(map[string]int),
+	}
+}
+
+// Handler 返回服务的 HTTP 处理器：webhook /webhook/github、/webhook/gitlab，
+// 设置了 APIToken 时的 REST API /reviews、/reviews/{id}、状态接口 /status 和评审历史页面 /dashboard，以及健康检查 /healthz
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	if s.opts.GitHubSecret != "" {
+		mux.HandleFunc("/webhook/github", s.webhook(func(h http.Header, body []byte) (*Event, error) {
+			return ParseGitHubEvent(h, body, s.opts.GitHubSecret)
+		}))
+	}
+	if s.opts.GitLabToken != "" {
+		mux.HandleFunc("/webhook/gitlab", s.webhook(func(h http.Header, body []byte) (*Event, error) {
+			return ParseGitLabEvent(h, body, s.opts.GitLabToken)
+		}))
+	}
+	if s.opts.APIToken != "" {
+		mux.HandleFunc("/reviews", s.handleReviews)
+		mux.HandleFunc("/reviews/", s.handleReview)
+		mux.HandleFunc("/status", s.handleStatus)
+		if s.opts.History != nil {
+			s.handleDashboard(mux)
+		}
+	}
+	return mux
+}
+
+// webhook 生成接收单个平台事件的处理器，需要评审的事件入队后立即返回 202
+func (s *Server) webhook(parse func(http.Header, []byte) (*Event, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadBytes))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		event, err := parse(r.Header, body)
+		switch {
+		case errors.Is(err, ErrUnauthorized):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case event == nil:
+			w.Write([]byte("ignored\n"))
+			return
+		}
+		if !s.Enqueue(event) {
+			http.Error(w, "review queue is full", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("queued\n"))
+	}
+}
+
+// Enqueue 把事件加入评审队列，队列已满或服务已停止时返回 false
+// 同一 pull request 已在队列中时只更新为最新的事件
+func (s *Server) Enqueue(e *Event) bool {
+	return s.enqueue(&Task{ID: newID(), Key: e.Key(), Event: e})
+}
+
+// enqueue 把任务加入评审队列，相同 key 的任务已在队列中时替换为新的任务
+func (s *Server) enqueue(t *Task) bool {
+	ok, err := s.queue.Push(t, true)
+	if err != nil {
+		log.Printf("%s 加入评审队列失败: %v\n", t, err)
+		return false
+	}
+	if ok {
+		log.Printf("%s 已加入评审队列\n", t)
+	}
+	return ok
+}
+
+// Start 启动评审协程
+func (s *Server) Start() {
+	for i := 0; i < s.opts.Workers; i++ {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.work()
+		}()
+	}
+}
+
+// work 依次取出并评审任务，队列关闭后返回
+// 仓库已有任务在评审时暂存取出的任务，该仓库的任务结束后由评审它的协程接着评审
+func (s *Server) work() {
+	for {
+		t := s.nextDeferred()
+		if t == nil {
+			var err error
+			t, err = s.queue.Pop(context.Background())
+			if errors.Is(err, ErrQueueClosed) {
+				return
+			}
+			if err != nil {
+				log.Printf("读取评审队列失败: %v\n", err)
+				time.Sleep(time.Second)
+				continue
+			}
+			if !s.acquire(t) {
+				continue
+			}
+		}
+		s.run(t)
+	}
+}
+
+// acquire 仓库没有正在评审的任务时开始评审并返回 true，否则暂存任务
+// 暂存的任务中已有同一 key 的任务时替换为新的任务
+func (s *Server) acquire(t *Task) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	repo := t.Repo()
+	if repo == "" || s.repoRuns[repo] == 0 {
+		s.start(t)
+		return true
+	}
+	for i, d := range s.deferred {
+		if d.Key == t.Key {
+			s.deferred[i] = t
+			s.queue.Done(d)
+			return false
+		}
+	}
+	s.deferred = append(s.deferred, t)
+	return false
+}
+
+// nextDeferred 取出仓库已经空闲的暂存任务，没有时返回 nil
+func (s *Server) nextDeferred() *Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, t := range s.deferred {
+		if s.repoRuns[t.Repo()] == 0 {
+			s.deferred = append(s.deferred[:i], s.deferred[i+1:]...)
+			s.start(t)
+			return t
+		}
+	}
+	return nil
+}
+
+// start 记录任务开始评审，调用方需持有锁
+func (s *Server) start(t *Task) {
+	s.running[t.ID] = &runningTask{task: t, started: time.Now()}
+	if repo := t.Repo(); repo != "" {
+		s.repoRuns[repo]++
+	}
+}
+
+// run 评审单个任务，失败且还有重试次数时延迟后重新入队，等待时间每次加倍
+func (s *Server) run(t *Task) {
+	willRetry := t.Attempts < s.opts.Retries
+	var err error
+	if t.JobID != "" {
+		err = s.runJob(t, willRetry)
+	} else {
+		err = s.process(t.Event)
+	}
+
+	s.mu.Lock()
+	delete(s.running, t.ID)
+	if repo := t.Repo(); repo != "" {
+		s.repoRuns[repo]--
+	}
+	switch {
+	case err == nil:
+		s.completed++
+	case willRetry:
+		s.retrying++
+	default:
+		s.failed++
+	}
+	s.mu.Unlock()
+
+	if err == nil || !willRetry {
+		s.queue.Done(t)
+		return
+	}
+	delay := retryDelay << t.Attempts
+	log.Printf("%s 将在 %s 后重试\n", t, delay)
+	time.AfterFunc(delay, func() {
+		s.retry(t)
+	})
+}
+
+// retry 把失败的任务重新入队，同一 key 已有更新的任务在等待时只评审更新的任务
+// 重新入队之前任务仍记录在队列中，服务在此期间停止时，Redis 队列会在下次启动后重新评审
+func (s *Server) retry(t *Task) {
+	s.mu.Lock()
+	s.retrying--
+	s.mu.Unlock()
+
+	retry := *t
+	retry.ID = newID()
+	retry.Attempts++
+	ok, err := s.queue.Push(&retry, false)
+	if err != nil || !ok {
+		log.Printf("%s 无法重试，评审队列已满或已停止: %v\n", t, err)
+		if t.JobID != "" {
+			s.failJob(t.JobID, "review queue is full or stopped")
+		}
+		s.queue.Done(t)
+		return
+	}
+	s.queue.Done(t)
+}
+
+// Stop 停止接收新的任务，等待正在进行的评审完成
+// 内存队列中等待的任务会先评审完成，Redis 队列中的任务留到下次启动后评审
+func (s *Server) Stop() {
+	s.queue.Close()
+	s.wg.Wait()
+}
+
+// process 检出并评审单个 pull request，失败时记录日志并返回错误
+func (s *Server) process(e *Event) error {
+	unlock := s.opts.Workspace.Lock(e)
+	defer unlock()
+
+	start := time.Now()
+	log.Printf("开始评审 %s\n", e)
+	checkout, err := s.opts.Workspace.Checkout(e)
+	switch {
+	case err != nil:
+	case checkout.Files != nil && len(checkout.Files) == 0:
+		log.Printf("%s 强制推送后没有新的改动，跳过评审\n", e)
+	default:
+		if checkout.Files != nil {
+			log.Printf("%s 被强制推送，只评审有变化的提交修改的 %d 个文件\n", e, len(checkout.Files))
+		}
+		var report []byte
+		report, err = s.opts.Review(e, checkout)
+		if err == nil && report != nil {
+			s.record(history.Run{Repo: e.Platform + ":" + e.Repo, Change: e.String(), URL: e.URL, Ref: e.HeadSHA, Trigger: "webhook"}, report)
+		}
+	}
+	if err == nil {
+		err = s.opts.Workspace.MarkReviewed(e, checkout)
+	}
+	if err != nil {
+		log.Printf("评审 %s 失败: %v\n", e, err)
+		return err
+	}
+	log.Printf("%s 评审完成，耗时 %s\n", e, time.Since(start).Round(time.Second))
+	return nil
+}
+
+// record 把评审结果记录到评审历史中，每个仓库只保留最近 maxHistoryPerRepo 次评审，失败时只记录日志
+// meta 中的仓库、pull request 等信息覆盖从报告中读取的信息
+func (s *Server) record(meta history.Run, report []byte) {
+	if s.opts.History == nil {
+		return
+	}
+	run, err := history.FromReport(report)
+	if err == nil {
+		run.Repo, run.Change, run.URL, run.Trigger = meta.Repo, meta.Change, meta.URL, meta.Trigger
+		if meta.Ref != "" {
+			run.Ref = meta.Ref
+		}
+		err = s.opts.History.Record(run, report)
+	}
+	if err == nil {
+		err = s.opts.History.Prune(run.Repo, maxHistoryPerRepo)
+	}
+	if err != nil {
+		log.Printf("记录 %s 的评审历史失败: %v\n", meta.Repo, err)
+	}
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrUnauthorized webhook 的签名或令牌校验失败
+var ErrUnauthorized = errors.New("webhook 签名校验失败")
+
+// Event 需要评审的 pull request 或 merge request 事件
+type Event struct {
+	// 代码托管平台：github 或 gitlab
+	Platform string
+	// 仓库名称，GitHub 为 owner/name，GitLab 为 group/name 形式的项目路径
+	Repo string
+	// 仓库的 HTTPS 克隆地址
+	CloneURL string
+	// pull request 编号或 merge request 的 IID
+	Number int
+	// 目标分支
+	BaseRef string
+	// 触发事件时源分支最新的提交
+	HeadSHA string
+	// 源分支，为空时使用托管平台为 pull request 维护的引用
+	Head string
+	// pull request 的页面地址，用于在评审历史中链接回 pull request
+	URL string
+}
+
+// Key 返回事件对应的 pull request 的唯一标识，同一 pull request 的多个事件只需评审最新的一次
+func (e *Event) Key() string {
+	return e.Platform + ":" + e.Repo + "#" + strconv.Itoa(e.Number)
+}
+
+// HeadRef 返回需要取回的源分支引用
+// 未指定源分支时使用托管平台为 pull request 维护的引用，来自 fork 的 pull request 也可以从目标仓库取回
+func (e *Event) HeadRef() string {
+	if e.Head != "" {
+		return fullRef(e.Head)
+	}
+	if e.Platform == "gitlab" {
+		return fmt.Sprintf("refs/merge-requests/%d/head", e.Number)
+	}
+	return fmt.Sprintf("refs/pull/%d/head", e.Number)
+}
+
+// String 返回便于日志阅读的事件描述
+func (e *Event) String() string {
+	if e.Platform == "gitlab" {
+		return fmt.Sprintf("%s!%d", e.Repo, e.Number)
+	}
+	return fmt.Sprintf("%s#%d", e.Repo, e.Number)
+}
+
+// validate 检查事件中评审所需的字段
+func (e *Event) validate() error {
+	if !strings.Contains(e.Repo, "/") || e.CloneURL == "" || e.Number <= 0 || e.BaseRef == "" {
+		return fmt.Errorf("webhook 事件缺少仓库、编号或目标分支信息")
+	}
+	return nil
+}
+
+// ParseGitHubEvent 解析 GitHub 的 pull_request 事件，secret 用于校验 X-Hub-Signature-256 签名
+// 只有打开、重新打开、推送新提交和结束草稿状态的事件需要评审，其余事件返回 nil
+func ParseGitHubEvent(header http.Header, body []byte, secret string) (*Event, error) {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(header.Get("X-Hub-Signature-256")), []byte(expected)) {
+		return nil, ErrUnauthorized
+	}
+	if header.Get("X-GitHub-Event") != "pull_request" {
+		return nil, nil
+	}
+
+	var payload struct {
+		Action      string `json:"action"`
+		Number      int    `json:"number"`
+		PullRequest struct {
+			Draft   bool   `json:"draft"`
+			HTMLURL string `json:"html_url"`
+			Base    struct {
+				Ref  string `json:"ref"`
+				Repo struct {
+					FullName string `json:"full_name"`
+					CloneURL string `json:"clone_url"`
+				} `json:"repo"`
+			} `json:"base"`
+			Head struct {
+				SHA string `json:"sha"`
+			} `json:"head"`
+		} `json:"pull_request"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("解析 GitHub 事件失败: %v", err)
+	}
+	switch payload.Action {
+	case "opened", "reopened", "synchronize", "ready_for_review":
+	default:
+		return nil, nil
+	}
+	if payload.PullRequest.Draft {
+		return nil, nil
+	}
+
+	pr := payload.PullRequest
+	event := &Event{
+		Platform: "github",
+		Repo:     pr.Base.Repo.FullName,
+		CloneURL: pr.Base.Repo.CloneURL,
+		Number:   payload.Number,
+		BaseRef:  pr.Base.Ref,
+		HeadSHA:  pr.Head.SHA,
+		URL:      pr.HTMLURL,
+	}
+	return event, event.validate()
+}
+
+// ParseGitLabEvent 解析 GitLab 的 Merge Request Hook 事件，token 用于校验 X-Gitlab-Token
+// 只有打开、重新打开和推送新提交的事件需要评审，修改标题、描述等事件返回 nil
+func ParseGitLabEvent(header http.Header, body []byte, token string) (*Event, error) {
+	if subtle.ConstantTimeCompare([]byte(header.Get("X-Gitlab-Token")), []byte(token)) != 1 {
+		return nil, ErrUnauthorized
+	}
+	if header.Get("X-Gitlab-Event") != "Merge Request Hook" {
+		return nil, nil
+	}
+
+	var payload struct {
+		Project struct {
+			PathWithNamespace string `json:"path_with_namespace"`
+			GitHTTPURL        string `json:"git_http_url"`
+		} `json:"project"`
+		ObjectAttributes struct {
+			IID          int    `json:"iid"`
+			Action       string `json:"action"`
+			TargetBranch string `json:"target_branch"`
+			OldRev       string `json:"oldrev"`
+			Draft        bool   `json:"draft"`
+			URL          string `json:"url"`
+			LastCommit   struct {
+				ID string `json:"id"`
+			} `json:"last_commit"`
+		} `json:"object_attributes"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("解析 GitLab 事件失败: %v", err)
+	}
+	attrs := payload.ObjectAttributes
+	switch {
+	case attrs.Action == "open" || attrs.Action == "reopen":
+	case attrs.Action == "update" && attrs.OldRev != "":
+		// 只有带 oldrev 的 update 事件表示推送了新提交
+	default:
+		return nil, nil
+	}
+	if attrs.Draft {
+		return nil, nil
+	}
+
+	event := &Event{
+		Platform: "gitlab",
+		Repo:     payload.Project.PathWithNamespace,
+		CloneURL: payload.Project.GitHTTPURL,
+		Number:   attrs.IID,
+		BaseRef:  attrs.TargetBranch,
+		HeadSHA:  attrs.LastCommit.ID,
+		URL:      attrs.URL,
+	}
+	return event, event.validate()
+}
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/icatw/ai-cr-tool/pkg/config"
+	"github.com/icatw/ai-cr-tool/pkg/git"
+)
+
+const (
+	// baseRef 取回的目标分支在本地克隆中的引用
+	baseRef = "refs/cr/base"
+	// headRef 取回的源分支在本地克隆中的引用
+	headRef = "refs/cr/head"
+	// reviewedRefPrefix 记录每个 pull request 上次评审的版本，强制推送后用于比较新旧版本
+	reviewedRefPrefix = "refs/cr/reviewed/"
+)
+
+// Checkout 检出的 pull request
+type Checkout struct {
+	// 仓库目录
+	Dir string
+	// 评审范围
+	Range string
+	// 强制推送后需要重新评审的文件，为 nil 时评审全部改动
+	// 为空切片时表示强制推送没有带来新的改动（如只是变基），不需要重新评审
+	Files []string
+}
+
+// Credential 克隆某个主机上的仓库时使用的访问令牌
+type Credential struct {
+	// 主机名，可以带端口，如 github.com、gitlab.example.com:8443
+	Host string
+	// 与令牌一起使用的用户名，GitHub 为 x-access-token，GitLab 为 oauth2
+	User  string
+	Token string
+}
+
+// Workspace 服务模式下各仓库的本地克隆，每个仓库一个目录，同一仓库同时只能有一次评审使用
+type Workspace struct {
+	root string
+	// 克隆仓库使用的访问令牌，令牌只发送给对应的主机，其余主机匿名访问
+	credentials []Credential
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewWorkspace 创建工作目录
+func NewWorkspace(root string, credentials []Credential) *Workspace {
+	return &Workspace{root: root, credentials: credentials, locks: make(map[string]*sync.Mutex)}
+}
+
+// Lock 锁定事件所在仓库的克隆，返回解锁函数
+func (w *Workspace) Lock(e *Event) func() {
+	key := e.Platform + ":" + e.Repo
+	w.mu.Lock()
+	lock, ok := w.locks[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		w.locks[key] = lock
+	}
+	w.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// Checkout 取回事件的目标分支和源分支并检出源分支，调用方需持有仓库的锁
+// 仓库第一次出现时初始化克隆，之后只增量取回；访问令牌只在取回时使用，不会写入克隆的配置
+// 配置文件取自目标分支，避免 pull request 通过修改配置文件改变评审方式（如执行任意的测试命令）
+// pull request 在上次评审之后被强制推送时，用 git range-diff 找出需要重新评审的文件
+func (w *Workspace) Checkout(e *Event) (*Checkout, error) {
+	// 仓库名称中的 / 转义后作为单级目录名
+	dir := filepath.Join(w.root, e.Platform, url.PathEscape(e.Repo))
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("创建仓库目录失败: %v", err)
+		}
+		if _, err := w.git(dir, "init", "-q"); err != nil {
+			return nil, err
+		}
+	}
+
+	remote, err := w.remoteURL(e)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.git(dir, "fetch", "-q", "--force", "--no-tags", remote,
+		"+"+fullRef(e.BaseRef)+":"+baseRef, "+"+e.HeadRef()+":"+headRef); err != nil {
+		return nil, fmt.Errorf("取回 %s 失败: %v", e, err)
+	}
+	if _, err := w.git(dir, "checkout", "-q", "--force", "--detach", headRef); err != nil {
+		return nil, err
+	}
+	if _, err := w.git(dir, "clean", "-q", "-fdx"); err != nil {
+		return nil, err
+	}
+
+	configPath := filepath.Join(dir, config.FileName)
+	if content, err := w.git(dir, "show", baseRef+":"+config.FileName); err == nil {
+		err = os.WriteFile(configPath, []byte(content), 0644)
+		if err != nil {
+			return nil, fmt.Errorf("写入目标分支的配置文件失败: %v", err)
+		}
+	} else if err := os.Remove(configPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("删除源分支的配置文件失败: %v", err)
+	}
+	checkout := &Checkout{Dir: dir, Range: baseRef + "..." + headRef}
+	if e.Number > 0 {
+		checkout.Files, err = w.forcePushedFiles(dir, reviewedRefPrefix+strconv.Itoa(e.Number))
+		if err != nil {
+			// 无法比较时评审全部改动
+			log.Printf("比较 %s 的新旧版本失败: %v\n", e, err)
+			checkout.Files = nil
+		}
+	}
+	return checkout, nil
+}
+
+// forcePushedFiles 上次评审的版本不是当前版本的祖先时（强制推送），返回新版本中修改过或新增的提交修改的文件
+// 没有上次评审的记录或者不是强制推送时返回 nil
+func (w *Workspace) forcePushedFiles(dir, reviewed string) ([]string, error) {
+	if _, err := w.git(dir, "rev-parse", "--verify", "-q", reviewed); err != nil {
+		return nil, nil
+	}
+	client := git.NewGitClient(dir)
+	if ancestor, err := client.IsAncestor(reviewed, headRef); err != nil || ancestor {
+		return nil, err
+	}
+
+	oldBase, err := client.MergeBase(baseRef, reviewed)
+	if err != nil {
+		return nil, err
+	}
+	newBase, err := client.MergeBase(baseRef, headRef)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := client.RangeDiff(oldBase+".."+reviewed, newBase+".."+headRef)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Status != git.RangeDiffChanged && entry.Status != git.RangeDiffAdded {
+			continue
+		}
+		changed, err := client.CommitFiles(entry.New)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range changed {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files, nil
+}
+
+// MarkReviewed 记录 pull request 本次评审的版本，调用方需持有仓库的锁
+func (w *Workspace) MarkReviewed(e *Event, c *Checkout) error {
+	if e.Number <= 0 {
+		return nil
+	}
+	_, err := w.git(c.Dir, "update-ref", reviewedRefPrefix+strconv.Itoa(e.Number), headRef)
+	return err
+}
+
+// fullRef 将分支名转换为完整的引用，已经是完整引用时原样返回
+func fullRef(ref string) string {
+	if strings.HasPrefix(ref, "refs/") {
+		return ref
+	}
+	return "refs/heads/" + ref
+}
+
+// remoteURL 返回取回地址，主机配置了访问令牌时带上令牌；只允许 HTTP(S) 地址，令牌只通过 HTTPS 发送
+func (w *Workspace) remoteURL(e *Event) (string, error) {
+	u, err := url.Parse(e.CloneURL)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return "", fmt.Errorf("无效的克隆地址: %s", e.CloneURL)
+	}
+	u.User = nil
+	for _, c := range w.credentials {
+		if c.Token == "" || !strings.EqualFold(c.Host, u.Host) {
+			continue
+		}
+		if u.Scheme != "https" {
+			return "", fmt.Errorf("克隆地址不是 HTTPS，不能使用访问令牌: %s", e.CloneURL)
+		}
+		u.User = url.UserPassword(c.User, c.Token)
+		break
+	}
+	return u.String(), nil
+}
+
+// git 在仓库目录中执行 git 命令，错误信息中的访问令牌会被隐藏
+func (w *Workspace) git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		for _, c := range w.credentials {
+			if c.Token != "" {
+				msg = strings.ReplaceAll(msg, c.Token, "***")
+			}
+		}
+		return "", fmt.Errorf("git %s 失败: %v\n%s", args[0], err, msg)
+	}
+	return stdout.String(), nil
+}
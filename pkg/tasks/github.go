@@ -0,0 +1,76 @@
+package tasks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/codeowners"
+	"github.com/icatw/ai-cr-tool/pkg/github"
+)
+
+// IssueLabel 导出的 issue 都带有该标签，用于去重和筛选
+const IssueLabel = "code-review"
+
+// NewGitHubIssue 将待办转换为 GitHub issue 请求，负责人中的个人账号作为 assignee
+func NewGitHubIssue(task Task) github.NewIssue {
+	issue := task.Issue
+
+	var body strings.Builder
+	body.WriteString(fmt.Sprintf("**文件**：`%s`\n", task.Location()))
+	body.WriteString(fmt.Sprintf("**严重程度**：%s\n\n", issue.Severity))
+	body.WriteString(strings.TrimSpace(issue.Description) + "\n")
+	if issue.Suggestion != "" {
+		body.WriteString("\n**建议**\n\n" + strings.TrimSpace(issue.Suggestion) + "\n")
+	}
+	if issue.CodeSnippet != "" {
+		body.WriteString("\n```\n" + strings.TrimRight(issue.CodeSnippet, "\n") + "\n```\n")
+	}
+	if len(issue.References) > 0 {
+		body.WriteString("\n**参考**\n\n")
+		for _, ref := range issue.References {
+			body.WriteString(fmt.Sprintf("- [%s](%s)\n", ref.Title, ref.URL))
+		}
+	}
+	if len(task.Owners) > 0 {
+		body.WriteString("\n负责人：" + strings.Join(task.Owners, " ") + "\n")
+	}
+	body.WriteString(fmt.Sprintf("\n<!-- cr:%s -->\n", task.ID))
+
+	return github.NewIssue{
+		Title:     fmt.Sprintf("[%s] %s (%s)", issue.Severity, firstLine(issue.Title), issue.FilePath),
+		Body:      body.String(),
+		Labels:    []string{IssueLabel, "severity:" + string(issue.Severity)},
+		Assignees: codeowners.Users(task.Owners),
+	}
+}
+
+// ExportGitHub 为尚未创建过的待办创建 GitHub issue，返回新建 issue 的链接和跳过的数量
+// 已有未关闭的同一待办（按 issue 正文中的隐藏标识判断）时跳过
+func ExportGitHub(client *github.Client, tasks []Task) ([]string, int, error) {
+	open, err := client.ListOpenIssues([]string{IssueLabel})
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取已有 issue 失败: %v", err)
+	}
+	known := make(map[string]bool)
+	for _, issue := range open {
+		for _, m := range todoMarker.FindAllStringSubmatch(issue.Body, -1) {
+			known[m[1]] = true
+		}
+	}
+
+	var created []string
+	skipped := 0
+	for _, task := range tasks {
+		if known[task.ID] {
+			skipped++
+			continue
+		}
+		issue, err := client.CreateIssue(NewGitHubIssue(task))
+		if err != nil {
+			return created, skipped, fmt.Errorf("创建 issue 失败（%s）: %v", task.Location(), err)
+		}
+		known[task.ID] = true
+		created = append(created, issue.HTMLURL)
+	}
+	return created, skipped, nil
+}
@@ -0,0 +1,59 @@
+package tasks
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/icatw/ai-cr-tool/pkg/codeowners"
+	"github.com/icatw/ai-cr-tool/pkg/types"
+)
+
+// Task 由评审问题转换而来的待办事项
+type Task struct {
+	Issue types.Issue
+	// 文件在 CODEOWNERS 中的负责人，如 @alice、@org/team
+	Owners []string
+	// 用于去重的标识，同一文件中标题相同的问题视为同一个待办
+	ID string
+}
+
+// Collect 选出严重程度不低于 minSeverity 的问题并转换为待办，owners 为 nil 时不填写负责人
+func Collect(issues []types.Issue, minSeverity types.SeverityLevel, owners *codeowners.Owners) []Task {
+	var result []Task
+	for _, issue := range issues {
+		if issue.Severity.Rank() < minSeverity.Rank() {
+			continue
+		}
+		task := Task{Issue: issue, ID: taskID(issue)}
+		if owners != nil {
+			task.Owners = owners.Of(issue.FilePath)
+		}
+		result = append(result, task)
+	}
+	return result
+}
+
+// Location 返回问题所在位置，如 main.go:12
+func (t Task) Location() string {
+	if t.Issue.Line > 0 {
+		return fmt.Sprintf("%s:%d", t.Issue.FilePath, t.Issue.Line)
+	}
+	return t.Issue.FilePath
+}
+
+// taskID 根据文件和标题生成待办标识，不包含行号，避免代码移动后产生重复待办
+func taskID(issue types.Issue) string {
+	sum := sha1.Sum([]byte(issue.FilePath + "\x00" + strings.TrimSpace(issue.Title)))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// firstLine 返回文本的第一行
+func firstLine(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		return strings.TrimSpace(text[:i])
+	}
+	return text
+}
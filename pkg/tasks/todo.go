@@ -0,0 +1,48 @@
+package tasks
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// todoMarker 写在每个待办末尾的隐藏标识，重复导出时据此跳过已有的待办
+var todoMarker = regexp.MustCompile(`<!-- cr:([0-9a-f]+) -->`)
+
+// MergeTODO 将待办追加到已有的 TODO.md 内容中，已存在的待办（无论是否勾选）不会重复添加
+// 返回新的文件内容和新增的待办数量
+func MergeTODO(existing []byte, tasks []Task) ([]byte, int) {
+	known := make(map[string]bool)
+	for _, m := range todoMarker.FindAllSubmatch(existing, -1) {
+		known[string(m[1])] = true
+	}
+
+	var buf bytes.Buffer
+	buf.Write(existing)
+	if len(bytes.TrimSpace(existing)) == 0 {
+		buf.Reset()
+		buf.WriteString("# 代码评审待办\n\n")
+	} else if !bytes.HasSuffix(existing, []byte("\n")) {
+		buf.WriteString("\n")
+	}
+
+	added := 0
+	for _, task := range tasks {
+		if known[task.ID] {
+			continue
+		}
+		known[task.ID] = true
+		added++
+
+		line := fmt.Sprintf("- [ ] **[%s]** %s — `%s`", task.Issue.Severity, firstLine(task.Issue.Title), task.Location())
+		if len(task.Owners) > 0 {
+			line += " " + strings.Join(task.Owners, " ")
+		}
+		buf.WriteString(fmt.Sprintf("%s <!-- cr:%s -->\n", line, task.ID))
+		if desc := firstLine(task.Issue.Description); desc != "" {
+			buf.WriteString("  " + desc + "\n")
+		}
+	}
+	return buf.Bytes(), added
+}
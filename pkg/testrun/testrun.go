@@ -0,0 +1,223 @@
+package testrun
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout 测试命令的默认超时时间
+const DefaultTimeout = 10 * time.Minute
+
+// maxOutputBytes 每个失败用例保留的输出长度
+const maxOutputBytes = 4 << 10
+
+// Failure 一个失败的测试用例，Test 为空表示整个包失败（如编译错误）
+type Failure struct {
+	Package string
+	Test    string
+	Output  string
+}
+
+// Result 测试执行结果
+type Result struct {
+	// 执行的命令
+	Command  string
+	Passed   bool
+	Duration time.Duration
+	// 解析出的失败用例，自定义命令无法解析时为空
+	Failures []Failure
+	// 自定义命令的输出末尾，用于展示失败原因
+	Output string
+}
+
+// GoPackages 返回改动文件所在的 Go 包目录（相对 root，形如 ./pkg/foo），已删除的目录会被忽略
+func GoPackages(root string, files []string) []string {
+	seen := make(map[string]bool)
+	var packages []string
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".go") {
+			continue
+		}
+		dir := filepath.Dir(file)
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		if info, err := os.Stat(filepath.Join(root, dir)); err != nil || !info.IsDir() {
+			continue
+		}
+		packages = append(packages, "./"+filepath.ToSlash(dir))
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+// RunGo 在 root 下对指定的包执行 go test -json，并解析失败的用例
+// extraArgs 为附加的 go test 参数，如 -coverprofile
+func RunGo(root string, packages []string, timeout time.Duration, extraArgs ...string) (*Result, error) {
+	args := append([]string{"test", "-json"}, extraArgs...)
+	args = append(args, packages...)
+	stdout, stderr, duration, runErr := run(root, timeout, "go", args...)
+
+	result := &Result{
+		Command:  "go " + strings.Join(args, " "),
+		Passed:   runErr == nil,
+		Duration: duration,
+		Failures: parseTestEvents(stdout),
+	}
+	if runErr != nil && len(result.Failures) == 0 {
+		// 没有解析到失败用例时（如命令本身出错），保留输出以便排查
+		result.Output = tail(string(stderr)+string(stdout), maxOutputBytes)
+		if _, ok := runErr.(*exec.ExitError); !ok {
+			return nil, fmt.Errorf("执行 go test 失败: %v", runErr)
+		}
+	}
+	return result, nil
+}
+
+// RunCommand 在 root 下通过 shell 执行自定义测试命令
+func RunCommand(root, command string, timeout time.Duration) (*Result, error) {
+	stdout, stderr, duration, runErr := run(root, timeout, "sh", "-c", command)
+	if runErr != nil {
+		if _, ok := runErr.(*exec.ExitError); !ok {
+			return nil, fmt.Errorf("执行测试命令失败: %v", runErr)
+		}
+	}
+	return &Result{
+		Command:  command,
+		Passed:   runErr == nil,
+		Duration: duration,
+		Output:   tail(string(stdout)+string(stderr), maxOutputBytes),
+	}, nil
+}
+
+// run 执行命令并收集输出
+func run(dir string, timeout time.Duration, name string, args ...string) ([]byte, []byte, time.Duration, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("测试超时（%s）", timeout)
+	}
+	return stdout.Bytes(), stderr.Bytes(), time.Since(start), err
+}
+
+// testEvent go test -json 输出的事件
+type testEvent struct {
+	Action     string
+	Package    string
+	Test       string
+	Output     string
+	ImportPath string // build-output 事件使用，形如 "x/b [x/b.test]"
+}
+
+// parseTestEvents 从 go test -json 的输出中解析失败的用例
+// 包内有失败的用例时只报告用例；没有用例失败但包失败时（编译错误等）报告整个包
+func parseTestEvents(data []byte) []Failure {
+	type key struct{ pkg, test string }
+	outputs := make(map[key]*strings.Builder)
+	var failures []Failure
+	failedTests := make(map[string]bool)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var event testEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		k := key{event.Package, event.Test}
+		if event.Action == "build-output" {
+			// 编译输出归入所属包，在包失败时一并报告
+			if fields := strings.Fields(event.ImportPath); len(fields) > 0 {
+				k = key{fields[0], ""}
+			}
+		}
+		switch event.Action {
+		case "output", "build-output":
+			if outputs[k] == nil {
+				outputs[k] = &strings.Builder{}
+			}
+			outputs[k].WriteString(event.Output)
+		case "fail":
+			if event.Test != "" {
+				failedTests[event.Package] = true
+			} else if failedTests[event.Package] {
+				continue
+			}
+			output := ""
+			if b := outputs[k]; b != nil {
+				output = tail(b.String(), maxOutputBytes)
+			}
+			failures = append(failures, Failure{Package: event.Package, Test: event.Test, Output: output})
+		}
+	}
+	return failures
+}
+
+// Summary 生成供模型参考的测试结果摘要，最长 maxBytes 字节
+func (r *Result) Summary(maxBytes int) string {
+	var sb strings.Builder
+	if r.Passed {
+		sb.WriteString(fmt.Sprintf("测试命令 `%s` 全部通过。\n", r.Command))
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("测试命令 `%s` 失败：\n", r.Command))
+	for _, failure := range r.Failures {
+		name := failure.Package
+		if failure.Test != "" {
+			name += "." + failure.Test
+		}
+		sb.WriteString(fmt.Sprintf("--- FAIL: %s\n%s\n", name, tail(failure.Output, 1024)))
+	}
+	if len(r.Failures) == 0 && r.Output != "" {
+		sb.WriteString(r.Output + "\n")
+	}
+	return truncate(sb.String(), maxBytes)
+}
+
+// tail 保留文本末尾最多 n 字节，从完整的行开始
+func tail(s string, n int) string {
+	s = strings.TrimRight(s, "\n")
+	if len(s) <= n {
+		return s
+	}
+	s = s[len(s)-n:]
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return "...\n" + s
+}
+
+// truncate 截断文本到最多 n 字节，在行边界处截断
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[:n]
+	if i := strings.LastIndexByte(s, '\n'); i > 0 {
+		s = s[:i]
+	}
+	return s + "\n..."
+}
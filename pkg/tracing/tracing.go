@@ -0,0 +1,234 @@
+// Package tracing 记录评审过程的 OpenTelemetry 链路，通过 OTLP/HTTP（JSON 编码）导出到采集器，
+// 用于定位评审慢在 Git 操作、模型调用还是报告生成
+//
+// 按 OpenTelemetry 的标准环境变量配置，未设置导出地址时不记录任何数据：
+//
+//	OTEL_EXPORTER_OTLP_TRACES_ENDPOINT  链路的完整导出地址，如 http://localhost:4318/v1/traces
+//	OTEL_EXPORTER_OTLP_ENDPOINT         采集器地址，链路导出到其下的 /v1/traces
+//	OTEL_EXPORTER_OTLP_HEADERS          附加的请求头，如 Authorization=Bearer%20xxx,X-Tenant=dev
+//	OTEL_SERVICE_NAME                   服务名，默认 ai-cr-tool
+//	OTEL_SDK_DISABLED=true              关闭链路记录
+//	TRACEPARENT                         W3C traceparent，设置后评审的链路挂在该链路之下（如 CI 流水线）
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultServiceName 未设置 OTEL_SERVICE_NAME 时使用的服务名
+	DefaultServiceName = "ai-cr-tool"
+	// exportInterval 定期导出已结束的 span 的间隔，与 OpenTelemetry SDK 的默认值一致
+	exportInterval = 5 * time.Second
+	// maxBatch 单次导出的最大 span 数
+	maxBatch = 512
+	// maxQueue 等待导出的最大 span 数，采集器不可用时丢弃超出的部分，避免占用过多内存
+	maxQueue = 2048
+)
+
+// tracer 全局的链路记录器，未启用时为 nil
+var (
+	tracerMu sync.Mutex
+	tracer   *exporter
+)
+
+// Setup 按环境变量启用链路记录，返回是否已启用；version 为工具版本，记录在导出数据中
+func Setup(version string) bool {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") || os.Getenv("OTEL_TRACES_EXPORTER") == "none" {
+		return false
+	}
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		if base == "" {
+			return false
+		}
+		endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+	}
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = DefaultServiceName
+	}
+	if protocol := os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); protocol != "" && protocol != "http/json" {
+		log.Printf("链路导出只支持 http/json 协议，忽略 OTEL_EXPORTER_OTLP_PROTOCOL=%s\n", protocol)
+	}
+
+	e := &exporter{
+		endpoint: endpoint,
+		headers:  parseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")),
+		service:  service,
+		version:  version,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	e.parent = parseTraceparent(os.Getenv("TRACEPARENT"))
+	tracerMu.Lock()
+	tracer = e
+	tracerMu.Unlock()
+	go e.run()
+	return true
+}
+
+// Shutdown 导出剩余的 span 并停止链路记录，进程退出前调用；未启用时不做任何事
+func Shutdown() {
+	tracerMu.Lock()
+	e := tracer
+	tracer = nil
+	tracerMu.Unlock()
+	if e != nil {
+		e.shutdown()
+	}
+}
+
+// current 返回当前的链路记录器
+func current() *exporter {
+	tracerMu.Lock()
+	defer tracerMu.Unlock()
+	return tracer
+}
+
+// Span 一段被记录的操作，方法在 nil 上调用时不做任何事，未启用链路记录时调用方无需判断
+type Span struct {
+	exporter *exporter
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	kind     Kind
+	start    time.Time
+
+	mu    sync.Mutex
+	end   time.Time
+	attrs []attribute
+	err   string
+	ended bool
+}
+
+// Kind span 的类型
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	// KindClient 调用外部服务，如模型接口
+	KindClient Kind = 3
+)
+
+// attribute span 的属性，value 为 string、int、int64、float64 或 bool
+type attribute struct {
+	key   string
+	value interface{}
+}
+
+// Start 开始一条新链路的根 span，设置了 TRACEPARENT 时挂在其下；未启用链路记录时返回 nil
+func Start(name string) *Span {
+	e := current()
+	if e == nil {
+		return nil
+	}
+	span := newSpan(e, name, KindInternal)
+	if e.parent != nil {
+		span.traceID, span.parentID = e.parent.traceID, e.parent.spanID
+	} else {
+		rand.Read(span.traceID[:])
+	}
+	return span
+}
+
+// Start 开始 s 的子 span，s 为 nil 时返回 nil
+func (s *Span) Start(name string) *Span {
+	return s.StartKind(name, KindInternal)
+}
+
+// StartKind 开始指定类型的子 span，s 为 nil 时返回 nil
+func (s *Span) StartKind(name string, kind Kind) *Span {
+	if s == nil {
+		return nil
+	}
+	span := newSpan(s.exporter, name, kind)
+	span.traceID, span.parentID = s.traceID, s.spanID
+	return span
+}
+
+// newSpan 创建 span 并生成 span ID
+func newSpan(e *exporter, name string, kind Kind) *Span {
+	span := &Span{exporter: e, name: name, kind: kind, start: time.Now()}
+	rand.Read(span.spanID[:])
+	return span
+}
+
+// SetAttr 设置属性，value 为 string、int、int64、float64 或 bool，其他类型按字符串记录
+func (s *Span) SetAttr(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.attrs {
+		if s.attrs[i].key == key {
+			s.attrs[i].value = value
+			return
+		}
+	}
+	s.attrs = append(s.attrs, attribute{key: key, value: value})
+}
+
+// End 结束 span，err 不为 nil 时标记为失败；重复调用时只有第一次有效
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	if err != nil {
+		s.err = err.Error()
+	}
+	s.mu.Unlock()
+	s.exporter.add(s)
+}
+
+// parseTraceparent 解析 W3C traceparent，格式不正确时返回 nil
+func parseTraceparent(value string) *Span {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return nil
+	}
+	span := &Span{}
+	if _, err := hex.Decode(span.traceID[:], []byte(parts[1])); err != nil {
+		return nil
+	}
+	if _, err := hex.Decode(span.spanID[:], []byte(parts[2])); err != nil {
+		return nil
+	}
+	return span
+}
+
+// parseHeaders 解析 OTEL_EXPORTER_OTLP_HEADERS，格式为逗号分隔的 key=value，value 经过 URL 编码
+func parseHeaders(value string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		if decoded, err := url.PathUnescape(strings.TrimSpace(val)); err == nil {
+			val = decoded
+		}
+		headers[key] = strings.TrimSpace(val)
+	}
+	return headers
+}
@@ -1,11 +1,119 @@
 package types
 
+import "fmt"
+
 // FileChange 表示文件改动的信息
 type FileChange struct {
 	FilePath    string
-	ChangeType  string // "added", "modified", "deleted"
+	ChangeType  string // "added", "modified", "deleted", "renamed", "copied"
+	OldPath     string // 重命名或复制前的路径，其他改动为空
+	Similarity  int    // 重命名或复制时与原文件的相似度（0-100）
 	OldContent  string
 	NewContent  string
 	DiffContent string
-	Lines       []string // 代码行内容
+	Hunks       []DiffHunk // 从 DiffContent 解析出的改动块
+	Binary      bool       // 二进制文件的差异，没有改动块
+	Lines       []string   // 代码行内容
+	Kind        ChangeKind // 改动类型，由路径和差异推断，源代码文件的类型会由模型确认
+}
+
+// SourcePath 返回文件改动前的路径，重命名或复制的文件为原文件的路径
+func (c *FileChange) SourcePath() string {
+	if c.OldPath != "" {
+		return c.OldPath
+	}
+	return c.FilePath
+}
+
+// PureMove 判断是否为内容没有变化的重命名或复制，这类改动没有需要评审的代码
+func (c *FileChange) PureMove() bool {
+	return (c.ChangeType == "renamed" || c.ChangeType == "copied") && len(c.Hunks) == 0 && !c.Binary
+}
+
+// HunkAt 返回新文件中第 line 行所在的改动块，不在任何改动块中时返回 nil
+func (c *FileChange) HunkAt(line int) *DiffHunk {
+	if line <= 0 {
+		return nil
+	}
+	for i := range c.Hunks {
+		if c.Hunks[i].Contains(line) {
+			return &c.Hunks[i]
+		}
+	}
+	return nil
+}
+
+// DiffHunk 统一差异中的一个改动块
+type DiffHunk struct {
+	// 旧文件中的起始行号和行数
+	OldStart, OldLines int
+	// 新文件中的起始行号和行数
+	NewStart, NewLines int
+	// @@ 行末尾的上下文，通常是改动所在的函数
+	Section string
+	Lines   []DiffLine
+}
+
+// Header 返回改动块的头部行，如 "@@ -1,3 +1,4 @@ func main()"
+func (h DiffHunk) Header() string {
+	header := fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+	if h.Section != "" {
+		header += " " + h.Section
+	}
+	return header
+}
+
+// hunkRange 按统一差异的格式输出起始行号和行数，行数为 1 时省略
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// Contains 判断新文件中的第 line 行是否在改动块的范围内
+func (h DiffHunk) Contains(line int) bool {
+	return line >= h.NewStart && line < h.NewStart+max(h.NewLines, 1)
+}
+
+// Line 返回新文件中第 line 行对应的差异行，该行不在改动块中时返回 nil
+func (h *DiffHunk) Line(line int) *DiffLine {
+	for i := range h.Lines {
+		if h.Lines[i].NewLine == line {
+			return &h.Lines[i]
+		}
+	}
+	return nil
+}
+
+// DiffLine 改动块中的一行
+type DiffLine struct {
+	// '+' 新增、'-' 删除、' ' 上下文
+	Kind byte
+	// 去掉首个标记字符后的内容
+	Content string
+	// 旧文件中的行号，新增的行为 0
+	OldLine int
+	// 新文件中的行号，删除的行为 0
+	NewLine int
+	// 该行之后是 "\ No newline at end of file"，即文件末尾没有换行符
+	NoNewline bool
+}
+
+// ChangeKind 定义文件改动的类型
+type ChangeKind string
+
+const (
+	KindFeature    ChangeKind = "feature"
+	KindBugfix     ChangeKind = "bugfix"
+	KindRefactor   ChangeKind = "refactor"
+	KindTest       ChangeKind = "test"
+	KindDocs       ChangeKind = "docs"
+	KindConfig     ChangeKind = "config"
+	KindDependency ChangeKind = "dependency"
+)
+
+// ChangeKinds 按报告中的展示顺序列出全部改动类型
+var ChangeKinds = []ChangeKind{
+	KindFeature, KindBugfix, KindRefactor, KindTest, KindDocs, KindConfig, KindDependency,
 }
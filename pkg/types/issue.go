@@ -9,13 +9,55 @@ const (
 	SeverityError   SeverityLevel = "error"
 )
 
+// Rank 返回严重程度的级别，数值越大越严重，未知级别返回0
+func (s SeverityLevel) Rank() int {
+	switch s {
+	case SeverityInfo:
+		return 1
+	case SeverityWarning:
+		return 2
+	case SeverityError:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// IssueCategory 定义问题类别
+type IssueCategory string
+
+const (
+	CategorySecurity        IssueCategory = "security"
+	CategoryBug             IssueCategory = "bug"
+	CategoryPerformance     IssueCategory = "performance"
+	CategoryMaintainability IssueCategory = "maintainability"
+	CategoryBestPractice    IssueCategory = "best_practice"
+	CategoryTest            IssueCategory = "test"
+	CategoryOther           IssueCategory = "other"
+)
+
+// Categories 按报告中的展示顺序列出全部问题类别
+var Categories = []IssueCategory{
+	CategorySecurity, CategoryBug, CategoryPerformance, CategoryMaintainability,
+	CategoryBestPractice, CategoryTest, CategoryOther,
+}
+
+// Reference 问题引用的规范或资料
+type Reference struct {
+	Title string // 引用标题，如 "CWE-89"、"Effective Go: Errors"
+	URL   string // 引用链接
+}
+
 // Issue 表示代码评审发现的问题
 type Issue struct {
 	Title       string        // 问题标题
 	FilePath    string        // 文件路径
 	Line        int           // 行号
 	Severity    SeverityLevel // 严重程度
+	Category    IssueCategory // 问题类别，为空时视为 other
 	Description string        // 问题描述
 	Suggestion  string        // 改进建议
 	CodeSnippet string        // 相关代码片段
+	References  []Reference   // 引用的规范或资料
+	Fingerprint string        // 基于内容的稳定指纹，用于跨次评审去重、跟踪和屏蔽问题
 }
@@ -0,0 +1,4 @@
+package version
+
+// Version 工具版本号，发布构建时通过 -ldflags "-X github.com/icatw/ai-cr-tool/pkg/version.Version=v1.2.3" 注入
+var Version = "dev"